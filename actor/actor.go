@@ -0,0 +1,37 @@
+package actor
+
+import "context"
+
+// Actor names. The REST and GraphQL APIs have no user accounts, so their changes are
+// attributed to API, and those made with ADMIN_TOKEN to Admin.
+const (
+	API    = "api"
+	Admin  = "admin"
+	GRPC   = "grpc"
+	CLI    = "cli"
+	Seed   = "seed"
+	System = "system"
+)
+
+// Actor identifies who made a change and from where.
+type Actor struct {
+	Name string
+	// IP is the client address, empty for changes that did not come over the network
+	IP string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying actor.
+func NewContext(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// FromContext returns the actor stored in ctx, or System when there is none.
+func FromContext(ctx context.Context) Actor {
+	actor, ok := ctx.Value(contextKey{}).(Actor)
+	if !ok {
+		return Actor{Name: System}
+	}
+	return actor
+}
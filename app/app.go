@@ -0,0 +1,556 @@
+package app
+
+import (
+	"context"
+	"example_api/cache"
+	"example_api/captcha"
+	"example_api/config"
+	"example_api/docs"
+	"example_api/events"
+	"example_api/grpcserver"
+	"example_api/handlers"
+	"example_api/initializers"
+	"example_api/jobs"
+	"example_api/mailer"
+	"example_api/migrations"
+	"example_api/mongodb"
+	"example_api/publishers"
+	"example_api/ratelimit"
+	"example_api/repositories"
+	"example_api/scheduler"
+	"example_api/seed"
+	"example_api/services"
+	"example_api/sms"
+	"example_api/tenant"
+	"example_api/usage"
+	"example_api/webhooks"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+)
+
+// App holds every assembled application component.
+type App struct {
+	Config *config.Config
+	Logger *slog.Logger
+	// LogLevel is the level Logger logs at, which Reload changes
+	LogLevel *slog.LevelVar
+
+	// At most one of DB and Postgres is set, depending on the configured driver
+	DB       *mongodb.Database
+	Postgres *pgxpool.Pool
+	Redis    *redis.Client
+	// Limiter is nil unless RATE_LIMIT_PLANS is set, LoginThrottle when LOGIN_THROTTLE_ATTEMPTS
+	// is 0, and UsageMeter when USAGE_FLUSH_INTERVAL is
+	Limiter       ratelimit.Limiter
+	LoginThrottle ratelimit.LoginThrottle
+	UsageMeter    *usage.Meter
+
+	UserStore           repositories.UserStore
+	IdempotencyStore    repositories.IdempotencyStore
+	WebhookStore        repositories.WebhookStore
+	AvatarStore         repositories.AvatarStore
+	AuditStore          repositories.AuditStore
+	OrgStore            repositories.OrganizationStore
+	RoleStore           repositories.RoleStore
+	GroupStore          repositories.GroupStore
+	NotificationStore   repositories.NotificationStore
+	JobStore            repositories.JobStore
+	ExportStore         repositories.ExportStore
+	TaskRunStore        repositories.TaskRunStore
+	FeatureFlagStore    repositories.FeatureFlagStore
+	PhoneCodeStore      repositories.PhoneCodeStore
+	PasswordHistory     repositories.PasswordHistoryStore
+	UsageStore          repositories.UsageStore
+	StatsStore          repositories.UserStatsStore
+	SearchStore         repositories.UserSearchStore
+	Transactor          repositories.Transactor
+	UserService         *services.UserService
+	UserHandler         *handlers.UserHandler
+	HealthHandler       *handlers.HealthHandler
+	EventsHandler       *handlers.EventsHandler
+	WebhookHandler      *handlers.WebhookHandler
+	EmailHandler        *handlers.EmailHandler
+	AvatarHandler       *handlers.AvatarHandler
+	ImportHandler       *handlers.ImportHandler
+	AuditHandler        *handlers.AuditHandler
+	StatsHandler        *handlers.StatsHandler
+	OrgHandler          *handlers.OrganizationHandler
+	RoleHandler         *handlers.RoleHandler
+	GroupHandler        *handlers.GroupHandler
+	NotificationHandler *handlers.NotificationHandler
+	AddressHandler      *handlers.AddressHandler
+	PreferencesHandler  *handlers.PreferencesHandler
+	ExportHandler       *handlers.ExportHandler
+	JobHandler          *handlers.JobHandler
+	TaskHandler         *handlers.TaskHandler
+	FeatureFlagHandler  *handlers.FeatureFlagHandler
+	ReloadHandler       *handlers.ReloadHandler
+	UsageHandler        *handlers.UsageHandler
+	// PhoneHandler is nil unless SMS_PROVIDER is set, and Captcha unless CAPTCHA_PROVIDER is
+	PhoneHandler *handlers.PhoneHandler
+	Captcha      captcha.Verifier
+	// Notifications creates notifications for the subsystems that produce them
+	Notifications *services.NotificationService
+	AuthService   *services.AuthService
+	// Authenticator checks the credentials of sign-ins, through LoginThrottle when it is set
+	Authenticator ratelimit.Authenticator
+	// Features says which feature flags are on, for behavior that ships dark
+	Features *services.FeatureFlagService
+	// Encryption is nil unless ENCRYPTION_KEYS is set, UserCache unless REDIS_URI is, and
+	// UserWatcher unless UserCache is in front of MongoDB
+	Encryption  *repositories.EncryptingUserStore
+	UserCache   *repositories.CachedUserStore
+	UserWatcher *repositories.UserChangeWatcher
+
+	// Events receives every user change made through UserStore
+	Events *events.Broker
+	// Queue runs background jobs, such as emails, exports, and webhook deliveries, while the
+	// server runs
+	Queue *jobs.Queue
+	// Scheduler runs the maintenance tasks while the server runs
+	Scheduler *scheduler.Scheduler
+	// Dispatcher queues a delivery of Events to every subscribed webhook
+	Dispatcher *webhooks.Dispatcher
+	// Bus and BusRelay are nil unless EVENT_BUS selects a message bus
+	Bus      publishers.Publisher
+	BusRelay *publishers.Relay
+	// OutboxStore and OutboxRelay are nil unless OUTBOX_ENABLED is set; OutboxRelay then
+	// replaces BusRelay
+	OutboxStore repositories.OutboxStore
+	OutboxRelay *publishers.OutboxRelay
+	// EmailRenderer renders every email; Mailer and WelcomeSender are nil unless SMTP_HOST is set
+	EmailRenderer *mailer.Renderer
+	Mailer        mailer.Mailer
+	WelcomeSender *mailer.WelcomeSender
+
+	Handler http.Handler
+	// GRPCServer is nil when GRPC_PORT is empty
+	GRPCServer *grpc.Server
+	// router is created before the handlers so they can build links from its named routes
+	router *mux.Router
+	// reloading serializes reloads, which SIGHUP and the admin API can ask for at once
+	reloading sync.Mutex
+
+	shutdownTracing func(context.Context) error
+}
+
+// New wires the application together from cfg: logger, tracing, database, repositories,
+// services, handlers, router, and gRPC server.
+func New(ctx context.Context, cfg *config.Config) (*App, error) {
+	a := &App{Config: cfg}
+
+	// Set up the application logger
+	a.LogLevel = new(slog.LevelVar)
+	a.LogLevel.Set(initializers.LogLevel(cfg.LogLevel))
+	a.Logger = initializers.NewLogger(cfg, a.LogLevel)
+	slog.SetDefault(a.Logger)
+
+	// Set up distributed tracing
+	shutdownTracing, err := initializers.InitTracing(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	a.shutdownTracing = shutdownTracing
+
+	// Connect to the database and build the matching store
+	var db handlers.Pinger
+	switch cfg.DBDriver {
+	case "postgres":
+		a.Postgres, err = initializers.ConnectToPostgres(cfg, a.Logger)
+		if err != nil {
+			break
+		}
+		store := repositories.NewPostgresUserRepository(a.Postgres)
+		if err = store.EnsureSchema(ctx); err != nil {
+			break
+		}
+		a.UserStore, a.SearchStore = a.encryptUsers(store, store)
+		a.StatsStore = store
+		a.IdempotencyStore = repositories.NewPostgresIdempotencyRepository(a.Postgres)
+		a.WebhookStore = repositories.NewPostgresWebhookRepository(a.Postgres)
+		a.AvatarStore = repositories.NewPostgresAvatarRepository(a.Postgres)
+		a.AuditStore = repositories.NewPostgresAuditRepository(a.Postgres)
+		a.OrgStore = repositories.NewPostgresOrganizationRepository(a.Postgres)
+		a.RoleStore = repositories.NewPostgresRoleRepository(a.Postgres)
+		a.GroupStore = repositories.NewPostgresGroupRepository(a.Postgres)
+		a.NotificationStore = repositories.NewPostgresNotificationRepository(a.Postgres)
+		a.JobStore = repositories.NewPostgresJobRepository(a.Postgres)
+		a.ExportStore = repositories.NewPostgresExportRepository(a.Postgres)
+		a.TaskRunStore = repositories.NewPostgresTaskRunRepository(a.Postgres)
+		a.FeatureFlagStore = repositories.NewPostgresFeatureFlagRepository(a.Postgres)
+		a.PhoneCodeStore = repositories.NewPostgresPhoneCodeRepository(a.Postgres)
+		a.PasswordHistory = repositories.NewPostgresPasswordHistoryRepository(a.Postgres)
+		a.UsageStore = repositories.NewPostgresUsageRepository(a.Postgres)
+		db = a.Postgres
+	case "memory":
+		store := repositories.NewMemoryUserRepository()
+		a.Logger.Warn("Using in-memory storage; data will not survive a restart")
+		a.UserStore, a.SearchStore = a.encryptUsers(store, store)
+		a.StatsStore = store
+		a.IdempotencyStore = repositories.NewMemoryIdempotencyRepository()
+		a.WebhookStore = repositories.NewMemoryWebhookRepository()
+		a.AvatarStore = repositories.NewMemoryAvatarRepository()
+		a.AuditStore = repositories.NewMemoryAuditRepository()
+		a.OrgStore = repositories.NewMemoryOrganizationRepository()
+		a.RoleStore = repositories.NewMemoryRoleRepository()
+		a.GroupStore = repositories.NewMemoryGroupRepository()
+		a.NotificationStore = repositories.NewMemoryNotificationRepository()
+		a.JobStore = repositories.NewMemoryJobRepository()
+		a.ExportStore = repositories.NewMemoryExportRepository()
+		a.TaskRunStore = repositories.NewMemoryTaskRunRepository()
+		a.FeatureFlagStore = repositories.NewMemoryFeatureFlagRepository()
+		a.PhoneCodeStore = repositories.NewMemoryPhoneCodeRepository()
+		a.PasswordHistory = repositories.NewMemoryPasswordHistoryRepository()
+		a.UsageStore = repositories.NewMemoryUsageRepository()
+		db = store
+	default:
+		a.DB, err = initializers.ConnectToDB(cfg, a.Logger)
+		if err != nil {
+			break
+		}
+		if cfg.MigrateOnStart {
+			if err = migrations.Run(ctx, a.DB, a.Logger); err != nil {
+				break
+			}
+		}
+		if err = initializers.EnsureIndexes(ctx, a.DB, a.Logger); err != nil {
+			break
+		}
+		a.Transactor = repositories.NewMongoTransactor(a.DB.Client())
+		users := repositories.NewUserRepository(a.DB, cfg.SearchIndex)
+		a.StatsStore = users
+		store, search := a.encryptUsers(users, users)
+		a.SearchStore = search
+		if cfg.Bus.Outbox.Enabled {
+			a.OutboxStore = repositories.NewOutboxRepository(a.DB)
+			if cfg.EncryptionKeys != nil {
+				a.OutboxStore = repositories.NewEncryptingOutboxStore(a.OutboxStore, cfg.EncryptionKeys)
+			}
+			store = repositories.NewOutboxUserStore(store, a.OutboxStore, a.Transactor)
+		}
+		a.UserStore = repositories.NewRetryingUserStore(store, repositories.RetryPolicy{
+			MaxAttempts: cfg.MongoRetry.MaxAttempts,
+			BaseDelay:   cfg.MongoRetry.BaseDelay,
+			MaxDelay:    cfg.MongoRetry.MaxDelay,
+		})
+		a.IdempotencyStore = repositories.NewIdempotencyRepository(a.DB)
+		a.WebhookStore = repositories.NewWebhookRepository(a.DB)
+		a.AvatarStore = repositories.NewGridFSAvatarRepository(a.DB)
+		a.AuditStore = repositories.NewAuditRepository(a.DB)
+		a.OrgStore = repositories.NewOrganizationRepository(a.DB)
+		a.RoleStore = repositories.NewRoleRepository(a.DB)
+		a.GroupStore = repositories.NewGroupRepository(a.DB)
+		a.NotificationStore = repositories.NewNotificationRepository(a.DB)
+		a.JobStore = repositories.NewJobRepository(a.DB)
+		a.ExportStore = repositories.NewGridFSExportRepository(a.DB)
+		a.TaskRunStore = repositories.NewTaskRunRepository(a.DB)
+		a.FeatureFlagStore = repositories.NewFeatureFlagRepository(a.DB)
+		a.PhoneCodeStore = repositories.NewPhoneCodeRepository(a.DB)
+		a.PasswordHistory = repositories.NewPasswordHistoryRepository(a.DB)
+		a.UsageStore = repositories.NewUsageRepository(a.DB)
+		db = mongoPinger{a.DB.Client()}
+	}
+	if err != nil {
+		a.Close(ctx)
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	if a.Transactor == nil {
+		a.Transactor = repositories.NoopTransactor{}
+	}
+	a.encryptStores()
+
+	// Keep the user counts lists report unless USER_COUNT_CACHE_TTL is 0
+	if cfg.CountCacheTTL > 0 {
+		counting := repositories.NewCountingUserStore(a.UserStore, a.StatsStore, cfg.CountCacheTTL)
+		a.UserStore, a.StatsStore = counting, counting
+	}
+
+	// Put the optional Redis cache in front of user lookups
+	if cfg.RedisURI != "" {
+		a.Redis, err = initializers.ConnectToRedis(cfg, a.Logger)
+		if err != nil {
+			a.Close(ctx)
+			return nil, fmt.Errorf("failed to connect to the cache: %w", err)
+		}
+		var users cache.Cache = cache.NewRedisCache(a.Redis)
+		if cfg.EncryptionKeys != nil {
+			users = cache.NewEncryptingCache(users, cfg.EncryptionKeys)
+		}
+		a.UserCache = repositories.NewCachedUserStore(a.UserStore, users, cfg.CacheTTL, a.Logger)
+		a.UserStore = a.UserCache
+		if a.DB != nil {
+			a.UserWatcher = repositories.NewUserChangeWatcher(a.DB, a.UserCache, append([]string{tenant.Default}, cfg.Tenancy.Tenants...), a.Logger)
+		}
+	}
+
+	// Limit API requests per client when RATE_LIMIT_PLANS is set, in Redis when there is one so
+	// every instance counts the same requests
+	switch {
+	case len(cfg.RateLimit.Plans) == 0:
+	case a.Redis != nil:
+		a.Limiter = ratelimit.NewRedisLimiter(a.Redis, cfg.RateLimit.Window)
+	default:
+		a.Limiter = ratelimit.NewMemoryLimiter(cfg.RateLimit.Window)
+		a.Logger.Warn("Rate limits are counted by each instance on its own without REDIS_URI")
+	}
+
+	// Make addresses that keep failing to sign in wait unless LOGIN_THROTTLE_ATTEMPTS is 0, in
+	// Redis when there is one so the failures made through every instance add up
+	throttle := cfg.LoginThrottle
+	escalation := ratelimit.Escalation{Attempts: throttle.Attempts, Delay: throttle.Delay, MaxDelay: throttle.MaxDelay, Window: throttle.Window}
+	switch {
+	case throttle.Attempts == 0:
+	case a.Redis != nil:
+		a.LoginThrottle = ratelimit.NewRedisLoginThrottle(a.Redis, escalation)
+	default:
+		a.LoginThrottle = ratelimit.NewMemoryLoginThrottle(escalation)
+	}
+
+	// Record how much of the API each client uses unless USAGE_FLUSH_INTERVAL is 0
+	if cfg.Usage.FlushInterval > 0 {
+		a.UsageMeter = usage.NewMeter(a.UsageStore, cfg.Usage.FlushInterval, a.Logger)
+	}
+
+	// Keep avatars in object storage instead of the database when AVATAR_STORAGE selects it
+	if cfg.AvatarStorage.Driver == "s3" {
+		client, err := initializers.ConnectToS3(cfg, a.Logger)
+		if err != nil {
+			a.Close(ctx)
+			return nil, fmt.Errorf("failed to connect to avatar storage: %w", err)
+		}
+		a.AvatarStore = repositories.NewS3AvatarRepository(client, cfg.AvatarStorage.S3.Bucket, cfg.AvatarStorage.S3.Prefix)
+	}
+
+	// Record user changes in the audit log, whichever API or command makes them
+	a.UserStore = repositories.NewAuditingUserStore(a.UserStore, a.AuditStore, a.Logger)
+
+	// Publish user changes to event subscribers
+	a.Events = events.NewBroker(a.Logger)
+	a.UserStore = repositories.NewPublishingUserStore(a.UserStore, a.Events, a.Logger)
+	a.Notifications = services.NewNotificationService(a.NotificationStore, a.UserStore, a.Logger)
+	a.Queue = jobs.NewQueue(a.JobStore, jobs.Options{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: cfg.Jobs.PollInterval,
+		Lease:        cfg.Jobs.Lease,
+	}, a.Logger)
+	a.Dispatcher = webhooks.NewDispatcher(a.WebhookStore, a.Events, a.Queue, a.Notifications, webhooks.Options{
+		Timeout: cfg.Webhooks.Timeout,
+		Retry: repositories.RetryPolicy{
+			MaxAttempts: cfg.Webhooks.Retry.MaxAttempts,
+			BaseDelay:   cfg.Webhooks.Retry.BaseDelay,
+			MaxDelay:    cfg.Webhooks.Retry.MaxDelay,
+		},
+	}, a.Logger)
+
+	// Forward events to the configured message bus
+	switch cfg.Bus.Driver {
+	case "kafka":
+		a.Bus = publishers.NewKafkaPublisher(cfg.Bus.Kafka.Brokers, cfg.Bus.Kafka.Topic)
+	case "nats":
+		var bus *publishers.NATSPublisher
+		if bus, err = publishers.NewNATSPublisher(cfg.Bus.NATS.URL, cfg.Bus.NATS.SubjectPrefix, a.Logger); err == nil {
+			a.Bus = bus
+		}
+	case "rabbitmq":
+		var bus *publishers.RabbitMQPublisher
+		if bus, err = publishers.NewRabbitMQPublisher(cfg.Bus.RabbitMQ.URL, cfg.Bus.RabbitMQ.Exchange); err == nil {
+			a.Bus = bus
+		}
+	}
+	if err != nil {
+		a.Close(ctx)
+		return nil, fmt.Errorf("failed to connect to the event bus: %w", err)
+	}
+	switch {
+	case a.Bus != nil && a.OutboxStore != nil:
+		a.OutboxRelay = publishers.NewOutboxRelay(a.OutboxStore, a.Bus, a.Events, cfg.Bus.Outbox.PollInterval, cfg.Bus.Outbox.BatchSize, a.Logger)
+	case a.Bus != nil:
+		a.BusRelay = publishers.NewRelay(a.Bus, a.Events, a.Logger)
+	}
+
+	// Email new users
+	a.EmailRenderer, err = mailer.NewRenderer(cfg.SMTP.Locale)
+	if err != nil {
+		a.Close(ctx)
+		return nil, fmt.Errorf("failed to load email templates: %w", err)
+	}
+	if cfg.SMTP.Host != "" {
+		options := mailer.Options{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			Timeout:  cfg.SMTP.Timeout,
+		}
+		// Credentials kept in a secrets manager may be rotated while the server runs
+		if cfg.Secrets != nil {
+			options.Credentials = func() (string, string) {
+				return cfg.Secrets.Value("SMTP_USERNAME", cfg.SMTP.Username), cfg.Secrets.Value("SMTP_PASSWORD", cfg.SMTP.Password)
+			}
+		}
+		var smtpMailer *mailer.SMTPMailer
+		smtpMailer, err = mailer.NewSMTPMailer(options)
+		if err != nil {
+			a.Close(ctx)
+			return nil, fmt.Errorf("failed to configure email: %w", err)
+		}
+		a.Mailer = smtpMailer
+		a.WelcomeSender = mailer.NewWelcomeSender(a.Mailer, a.EmailRenderer, a.Events, a.Queue, a.UserStore, a.Logger)
+	}
+
+	// Initialize services and handlers
+	a.UserService = services.NewUserService(a.UserStore, a.AvatarStore, a.AuditStore, a.SearchStore, a.OrgStore, a.RoleStore, a.GroupStore, a.NotificationStore, a.PasswordHistory, a.PhoneCodeStore, a.Notifications, cfg.BcryptCost, cfg.PasswordPolicy.History)
+	a.router = mux.NewRouter()
+	a.UserHandler = handlers.NewUserHandler(a.UserService, a.Logger, a.router)
+	a.HealthHandler = handlers.NewHealthHandler(db)
+	a.EventsHandler = handlers.NewEventsHandler(a.Events, a.Logger)
+	a.WebhookHandler = handlers.NewWebhookHandler(services.NewWebhookService(a.WebhookStore), a.Logger, a.router)
+	a.EmailHandler = handlers.NewEmailHandler(a.EmailRenderer, a.Logger)
+	a.AvatarHandler = handlers.NewAvatarHandler(services.NewAvatarService(a.UserStore, a.AvatarStore, int64(cfg.AvatarMaxSize)), a.Logger)
+	a.ImportHandler = handlers.NewImportHandler(a.UserService, int64(cfg.ImportMaxSize), a.Logger)
+	a.AuditHandler = handlers.NewAuditHandler(services.NewAuditService(a.AuditStore), a.Logger)
+	a.StatsHandler = handlers.NewStatsHandler(services.NewStatsService(a.StatsStore, cfg.StatsCacheTTL), a.Logger)
+	a.OrgHandler = handlers.NewOrganizationHandler(services.NewOrganizationService(a.OrgStore, a.UserStore, a.Notifications), a.Logger, a.router)
+	a.RoleHandler = handlers.NewRoleHandler(services.NewRoleService(a.RoleStore, a.UserStore), a.Logger, a.router)
+	a.GroupHandler = handlers.NewGroupHandler(services.NewGroupService(a.GroupStore, a.UserStore), a.Logger, a.router)
+	a.NotificationHandler = handlers.NewNotificationHandler(a.Notifications, a.Logger)
+	a.AddressHandler = handlers.NewAddressHandler(services.NewAddressService(a.UserStore), a.Logger, a.router)
+	a.PreferencesHandler = handlers.NewPreferencesHandler(services.NewPreferencesService(a.UserStore), a.Logger)
+	a.ExportHandler = handlers.NewExportHandler(services.NewExportService(a.JobStore, a.ExportStore, a.Queue, handlers.RenderExport(a.UserService)), a.Logger, a.router)
+	a.JobHandler = handlers.NewJobHandler(services.NewJobService(a.JobStore, a.Queue), a.Logger)
+	a.Scheduler = scheduler.New(a.TaskRunStore, a.Logger)
+	a.registerTasks()
+	a.TaskHandler = handlers.NewTaskHandler(services.NewTaskService(a.Scheduler, a.TaskRunStore), a.Logger)
+	a.AuthService = services.NewAuthService(a.UserStore, a.RoleStore, a.GroupStore)
+	a.Authenticator = a.AuthService
+	if a.LoginThrottle != nil {
+		a.Authenticator = ratelimit.ThrottleLogins(a.AuthService, a.LoginThrottle, a.Logger)
+	}
+	a.Features = services.NewFeatureFlagService(a.FeatureFlagStore, cfg.FlagCacheTTL, a.Logger)
+	a.FeatureFlagHandler = handlers.NewFeatureFlagHandler(a.Features, a.Logger)
+	a.ReloadHandler = handlers.NewReloadHandler(a, a.Logger)
+	a.UsageHandler = handlers.NewUsageHandler(services.NewUsageService(a.UsageStore), a.Logger)
+
+	// Verify phone numbers by text message when SMS_PROVIDER selects how to send them
+	var sender sms.Sender
+	switch cfg.SMS.Provider {
+	case "log":
+		sender = sms.NewLogSender(a.Logger)
+	case "twilio":
+		sender = sms.NewTwilioSender(sms.TwilioOptions{
+			AccountSID: cfg.SMS.Twilio.AccountSID,
+			AuthToken:  cfg.SMS.Twilio.AuthToken,
+			From:       cfg.SMS.Twilio.From,
+			Timeout:    cfg.SMS.Timeout,
+		})
+	}
+	if sender != nil {
+		phones := services.NewPhoneVerificationService(a.UserStore, a.PhoneCodeStore, sender)
+		a.PhoneHandler = handlers.NewPhoneHandler(phones, services.PhoneCodeInterval, a.Logger)
+	}
+
+	// Make anonymous signups solve a CAPTCHA when CAPTCHA_PROVIDER selects whose
+	if provider := cfg.Captcha.Provider; provider != "" {
+		endpoint := cfg.Captcha.VerifyURL
+		if endpoint == "" {
+			endpoint = captcha.VerifyURLs[provider]
+		}
+		a.Captcha = captcha.NewSiteVerifier(endpoint, cfg.Captcha.Secret, cfg.Captcha.Timeout)
+	}
+
+	// Populate development data when requested
+	if cfg.Seed {
+		if err := seed.Users(ctx, a.UserService, cfg.SeedCount, a.Logger); err != nil {
+			a.Close(ctx)
+			return nil, err
+		}
+	}
+
+	spec, err := docs.Load()
+	if err != nil {
+		a.Close(ctx)
+		return nil, fmt.Errorf("failed to load the OpenAPI document: %w", err)
+	}
+	a.Handler = a.newRouter(spec)
+	if cfg.GRPCPort != "" {
+		access := grpcserver.Access{
+			AdminToken:    cfg.AdminToken,
+			Admin:         services.AdminPrincipal(),
+			Authenticator: a.Authenticator,
+			Policies:      policies,
+			Captcha:       a.Captcha,
+		}
+		a.GRPCServer = grpcserver.New(a.UserService, access, cfg.Tenancy.Header, cfg.Tenancy.Tenants, a.Logger)
+	}
+
+	return a, nil
+}
+
+// encryptStores puts encryption in front of the stores that keep copies of user emails and
+// phone numbers when ENCRYPTION_KEYS is set: the audit log, stored responses, job payloads,
+// export files, and phone verification codes.
+func (a *App) encryptStores() {
+	keys := a.Config.EncryptionKeys
+	if keys == nil {
+		return
+	}
+	a.AuditStore = repositories.NewEncryptingAuditStore(a.AuditStore, keys)
+	a.IdempotencyStore = repositories.NewEncryptingIdempotencyStore(a.IdempotencyStore, keys)
+	a.JobStore = repositories.NewEncryptingJobStore(a.JobStore, keys)
+	a.ExportStore = repositories.NewEncryptingExportStore(a.ExportStore, keys)
+	a.PhoneCodeStore = repositories.NewEncryptingPhoneCodeStore(a.PhoneCodeStore, keys)
+}
+
+// encryptUsers puts encryption in front of the users of the storage backend when
+// ENCRYPTION_KEYS is set, so every other store and service sees plaintext.
+func (a *App) encryptUsers(store repositories.UserStore, search repositories.UserSearchStore) (repositories.UserStore, repositories.UserSearchStore) {
+	if a.Config.EncryptionKeys == nil {
+		return store, search
+	}
+	a.Encryption = repositories.NewEncryptingUserStore(store, a.Config.EncryptionKeys)
+	return a.Encryption, repositories.NewEncryptingUserSearchStore(search, a.Encryption)
+}
+
+// Close releases the database connection and flushes pending traces.
+func (a *App) Close(ctx context.Context) {
+	if a.DB != nil {
+		if err := a.DB.Client().Disconnect(ctx); err != nil {
+			a.Logger.Error("Failed to disconnect from MongoDB", slog.Any("error", err))
+		}
+	}
+	if a.Postgres != nil {
+		a.Postgres.Close()
+	}
+	if a.Bus != nil {
+		if err := a.Bus.Close(); err != nil {
+			a.Logger.Error("Failed to close event bus", slog.Any("error", err))
+		}
+	}
+	if a.Redis != nil {
+		if err := a.Redis.Close(); err != nil {
+			a.Logger.Error("Failed to close Redis client", slog.Any("error", err))
+		}
+	}
+	if err := a.shutdownTracing(ctx); err != nil {
+		a.Logger.Error("Failed to flush traces", slog.Any("error", err))
+	}
+}
+
+// mongoPinger adapts a MongoDB client to the health check Pinger interface.
+type mongoPinger struct {
+	client *mongo.Client
+}
+
+func (p mongoPinger) Ping(ctx context.Context) error {
+	return p.client.Ping(ctx, nil)
+}
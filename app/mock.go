@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"example_api/actor"
+	"example_api/config"
+	"example_api/docs"
+	"example_api/initializers"
+	"example_api/middleware"
+	"example_api/mock"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// RunMock serves example responses for the operations of the OpenAPI document, along with the
+// document and its Swagger UI, until ctx is cancelled. It needs no database or other backend,
+// so clients can be developed against the API before one is available.
+func RunMock(ctx context.Context, cfg *config.Config) error {
+	logger := initializers.NewLogger(cfg, initializers.LogLevel(cfg.LogLevel))
+	spec, err := docs.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load the OpenAPI document: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", docs.Spec)
+	mux.Handle("GET /swagger/", middleware.ContentSecurityPolicy(cfg.SecurityHeaders.DocsContentSecurityPolicy)(docs.UI("/openapi.json")))
+	mux.Handle("/", http.MaxBytesHandler(mock.NewHandler(spec), int64(cfg.BodyMaxSize)))
+
+	headers := cfg.SecurityHeaders
+	limits := cfg.HTTPServer
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           middleware.SecurityHeaders(headers.StrictTransportSecurity, headers.ContentSecurityPolicy, cfg.TrustedProxies)(middleware.RequestID(middleware.Locale(middleware.Actor(actor.API)(middleware.AccessLog(logger)(mux))))),
+		ReadTimeout:       limits.ReadTimeout,
+		ReadHeaderTimeout: limits.ReadHeaderTimeout,
+		WriteTimeout:      limits.WriteTimeout,
+		IdleTimeout:       limits.IdleTimeout,
+		MaxHeaderBytes:    limits.MaxHeaderBytes,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Serving mock responses", slog.String("port", cfg.Port))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-serverErr:
+	}
+
+	logger.Info("Shutting down mock server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Mock server did not shut down cleanly", slog.Any("error", err))
+	}
+	return runErr
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	models "example_api/models"
+	"example_api/policy"
+	"example_api/proto/userv1"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// policies is the authorization policy of every API route, GraphQL root field, and gRPC method.
+// HTTP routes are keyed by method and path below /api/v1, which the unversioned mount shares,
+// so a route registered without an entry here is denied.
+var policies = policy.Table{
+	// Users. Anyone may sign up, and users may read and change their own account.
+	"GET /users":                policy.Require(models.PermUsersRead),
+	"GET /users/search":         policy.Require(models.PermUsersRead),
+	"POST /users/batch-get":     policy.Require(models.PermUsersRead),
+	"GET /users/export":         policy.Require(models.PermUsersRead),
+	"POST /users":               policy.Public,
+	"GET /users/{id}":           policy.Require(models.PermUsersRead).OrSelf("id"),
+	"HEAD /users/{id}":          policy.Require(models.PermUsersRead).OrSelf("id"),
+	"PUT /users/{id}":           policy.Require(models.PermUsersWrite).OrSelf("id"),
+	"DELETE /users/{id}":        policy.Require(models.PermUsersDelete).OrSelf("id"),
+	"GET /users/{id}/avatar":    policy.Require(models.PermUsersRead).OrSelf("id"),
+	"PUT /users/{id}/avatar":    policy.Require(models.PermUsersWrite).OrSelf("id"),
+	"DELETE /users/{id}/avatar": policy.Require(models.PermUsersWrite).OrSelf("id"),
+	"PUT /users/{id}/role":      policy.Require(models.PermRolesWrite),
+
+	// Background exports
+	"POST /users/exports":          policy.Require(models.PermUsersRead),
+	"GET /users/exports/{id}":      policy.Require(models.PermUsersRead),
+	"GET /users/exports/{id}/file": policy.Require(models.PermUsersRead),
+
+	// Organizations. Users may see their own memberships and accept their own invitations.
+	"GET /users/{id}/organizations":                    policy.Require(models.PermOrganizationsRead).OrSelf("id"),
+	"GET /organizations":                               policy.Require(models.PermOrganizationsRead),
+	"POST /organizations":                              policy.Require(models.PermOrganizationsWrite),
+	"GET /organizations/{id}":                          policy.Require(models.PermOrganizationsRead),
+	"PUT /organizations/{id}":                          policy.Require(models.PermOrganizationsWrite),
+	"DELETE /organizations/{id}":                       policy.Require(models.PermOrganizationsWrite),
+	"GET /organizations/{id}/members":                  policy.Require(models.PermOrganizationsRead),
+	"POST /organizations/{id}/members":                 policy.Require(models.PermOrganizationsWrite),
+	"PUT /organizations/{id}/members/{userId}":         policy.Require(models.PermOrganizationsWrite),
+	"DELETE /organizations/{id}/members/{userId}":      policy.Require(models.PermOrganizationsWrite).OrSelf("userId"),
+	"POST /organizations/{id}/members/{userId}/accept": policy.Require(models.PermOrganizationsWrite).OrSelf("userId"),
+
+	// Roles
+	"GET /roles":           policy.Require(models.PermRolesRead),
+	"POST /roles":          policy.Require(models.PermRolesWrite),
+	"GET /roles/{name}":    policy.Require(models.PermRolesRead),
+	"PUT /roles/{name}":    policy.Require(models.PermRolesWrite),
+	"DELETE /roles/{name}": policy.Require(models.PermRolesWrite),
+
+	// Groups. Users may see the groups they are in.
+	"GET /users/{id}/groups":               policy.Require(models.PermGroupsRead).OrSelf("id"),
+	"GET /groups":                          policy.Require(models.PermGroupsRead),
+	"POST /groups":                         policy.Require(models.PermGroupsWrite),
+	"GET /groups/{id}":                     policy.Require(models.PermGroupsRead),
+	"PUT /groups/{id}":                     policy.Require(models.PermGroupsWrite),
+	"DELETE /groups/{id}":                  policy.Require(models.PermGroupsWrite),
+	"GET /groups/{id}/members":             policy.Require(models.PermGroupsRead),
+	"POST /groups/{id}/members":            policy.Require(models.PermGroupsWrite),
+	"DELETE /groups/{id}/members/{userId}": policy.Require(models.PermGroupsWrite),
+
+	// Notifications. Users may read and dismiss their own, but only administrators send notices.
+	"GET /users/{id}/notifications":                        policy.Require(models.PermNotificationsRead).OrSelf("id"),
+	"POST /users/{id}/notifications":                       policy.Require(models.PermNotificationsWrite),
+	"POST /users/{id}/notifications/read":                  policy.Require(models.PermNotificationsWrite).OrSelf("id"),
+	"POST /users/{id}/notifications/{notificationId}/read": policy.Require(models.PermNotificationsWrite).OrSelf("id"),
+	"DELETE /users/{id}/notifications/{notificationId}":    policy.Require(models.PermNotificationsWrite).OrSelf("id"),
+
+	// Phone verification. Users may verify their own number.
+	"POST /users/{id}/phone/verification":         policy.Require(models.PermUsersWrite).OrSelf("id"),
+	"POST /users/{id}/phone/verification/confirm": policy.Require(models.PermUsersWrite).OrSelf("id"),
+
+	// Addresses. Users may read and change their own.
+	"GET /users/{id}/addresses":                policy.Require(models.PermUsersRead).OrSelf("id"),
+	"POST /users/{id}/addresses":               policy.Require(models.PermUsersWrite).OrSelf("id"),
+	"GET /users/{id}/addresses/{addressId}":    policy.Require(models.PermUsersRead).OrSelf("id"),
+	"PUT /users/{id}/addresses/{addressId}":    policy.Require(models.PermUsersWrite).OrSelf("id"),
+	"DELETE /users/{id}/addresses/{addressId}": policy.Require(models.PermUsersWrite).OrSelf("id"),
+
+	// Preferences. Users may read and change their own.
+	"GET /users/{id}/preferences": policy.Require(models.PermUsersRead).OrSelf("id"),
+	"PUT /users/{id}/preferences": policy.Require(models.PermUsersWrite).OrSelf("id"),
+
+	// Anyone may see which features are on for them, and any authenticated caller their usage
+	"GET /features": policy.Public,
+	"GET /me/usage": policy.Require(),
+
+	// Operator tools and personal data requests need ADMIN_TOKEN, and event streams and
+	// webhooks EVENTS_TOKEN, which their routes check themselves
+	"POST /users/import":                policy.Public,
+	"GET /users/{id}/export":            policy.Public,
+	"POST /users/{id}/erase":            policy.Public,
+	"GET /audit-logs":                   policy.Public,
+	"GET /admin/stats":                  policy.Public,
+	"GET /admin/usage":                  policy.Public,
+	"GET /admin/emails":                 policy.Public,
+	"GET /admin/emails/{name}":          policy.Public,
+	"GET /admin/jobs":                   policy.Public,
+	"POST /admin/jobs/{id}/retry":       policy.Public,
+	"GET /admin/tasks":                  policy.Public,
+	"GET /admin/tasks/{name}/runs":      policy.Public,
+	"GET /admin/feature-flags":          policy.Public,
+	"GET /admin/feature-flags/{key}":    policy.Public,
+	"PUT /admin/feature-flags/{key}":    policy.Public,
+	"DELETE /admin/feature-flags/{key}": policy.Public,
+	"POST /admin/reload":                policy.Public,
+	"GET /ws":                           policy.Public,
+	"GET /events":                       policy.Public,
+	"GET /webhooks":                     policy.Public,
+	"POST /webhooks":                    policy.Public,
+	"GET /webhooks/{id}":                policy.Public,
+	"PUT /webhooks/{id}":                policy.Public,
+	"DELETE /webhooks/{id}":             policy.Public,
+	"GET /webhooks/{id}/deliveries":     policy.Public,
+
+	// GraphQL
+	"Query.user":          policy.Require(models.PermUsersRead).OrSelf("id"),
+	"Query.users":         policy.Require(models.PermUsersRead),
+	"Mutation.createUser": policy.Public,
+	"Mutation.updateUser": policy.Require(models.PermUsersWrite).OrSelf("id"),
+	"Mutation.deleteUser": policy.Require(models.PermUsersDelete).OrSelf("id"),
+
+	// gRPC, keyed by full method name. Health checks and reflection are open to load balancers
+	// and tools such as grpcurl.
+	userv1.UserService_CreateUser_FullMethodName:                             policy.Public,
+	userv1.UserService_GetUser_FullMethodName:                                policy.Require(models.PermUsersRead).OrSelf("id"),
+	userv1.UserService_UpdateUser_FullMethodName:                             policy.Require(models.PermUsersWrite).OrSelf("id"),
+	userv1.UserService_DeleteUser_FullMethodName:                             policy.Require(models.PermUsersDelete).OrSelf("id"),
+	userv1.UserService_ListUsers_FullMethodName:                              policy.Require(models.PermUsersRead),
+	healthpb.Health_Check_FullMethodName:                                     policy.Public,
+	healthpb.Health_Watch_FullMethodName:                                     policy.Public,
+	reflectionpb.ServerReflection_ServerReflectionInfo_FullMethodName:        policy.Public,
+	reflectionv1alphapb.ServerReflection_ServerReflectionInfo_FullMethodName: policy.Public,
+}
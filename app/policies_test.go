@@ -0,0 +1,17 @@
+package app
+
+import (
+	"example_api/proto/userv1"
+	"testing"
+)
+
+// Methods without a policy are denied, so a method added to the proto must be given one here.
+func TestEveryGRPCMethodHasAPolicy(t *testing.T) {
+	service := userv1.UserService_ServiceDesc
+	for _, method := range service.Methods {
+		name := "/" + service.ServiceName + "/" + method.MethodName
+		if _, ok := policies.Method(name); !ok {
+			t.Errorf("%s has no policy", name)
+		}
+	}
+}
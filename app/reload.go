@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"example_api/config"
+	"example_api/initializers"
+	models "example_api/models"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reload reads the configuration again, from the environment, the .env file, and the secrets
+// manager, and applies the settings that can change without dropping connections: LOG_LEVEL and
+// FEATURE_FLAGS_CACHE_TTL. Feature flags are read from the store again too. Other settings
+// take effect on restart. An invalid configuration is rejected, keeping the settings in
+// effect.
+func (a *App) Reload(ctx context.Context) (*models.RuntimeSettings, error) {
+	a.reloading.Lock()
+	defer a.reloading.Unlock()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	a.LogLevel.Set(initializers.LogLevel(cfg.LogLevel))
+	a.Features.Reconfigure(cfg.FlagCacheTTL)
+
+	settings := &models.RuntimeSettings{
+		LogLevel:             cfg.LogLevel,
+		FeatureFlagsCacheTTL: cfg.FlagCacheTTL.String(),
+	}
+	a.Logger.InfoContext(ctx, "Configuration reloaded", slog.String("logLevel", settings.LogLevel), slog.String("featureFlagsCacheTtl", settings.FeatureFlagsCacheTTL))
+	return settings, nil
+}
+
+// reloadOnHangup reloads the configuration on every SIGHUP until ctx is done.
+func (a *App) reloadOnHangup(ctx context.Context) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			if _, err := a.Reload(ctx); err != nil {
+				a.Logger.Error("Failed to reload the configuration", slog.Any("error", err))
+			}
+		}
+	}
+}
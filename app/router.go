@@ -0,0 +1,329 @@
+package app
+
+import (
+	"example_api/actor"
+	"example_api/docs"
+	"example_api/graph"
+	"example_api/handlers"
+	"example_api/initializers"
+	"example_api/middleware"
+	"example_api/ratelimit"
+	"example_api/services"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+)
+
+// newRouter registers every route and wraps the router in the global middleware chain. Requests
+// are validated against spec, the OpenAPI document.
+func (a *App) newRouter(spec *openapi3.T) http.Handler {
+	r := a.router
+
+	// Trace and record metrics for every matched route, authorize API routes by their policy,
+	// cap request bodies, and validate requests against the OpenAPI document
+	r.Use(otelmux.Middleware(initializers.ServiceName), middleware.Metrics, middleware.Authorize(policies, a.Logger, "/api/v1", "/api"),
+		middleware.BodyLimit(int64(a.Config.BodyMaxSize), a.bodyLimits(), "/api/v1", "/api"), middleware.ValidateRequests(spec, "/api/v1", "/api"))
+
+	// Metrics route
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	// OpenAPI document and the Swagger UI for it
+	docsPolicy := middleware.ContentSecurityPolicy(a.Config.SecurityHeaders.DocsContentSecurityPolicy)
+	r.HandleFunc("/openapi.json", docs.Spec).Methods("GET")
+	r.PathPrefix("/swagger/").Handler(docsPolicy(docs.UI("/openapi.json"))).Methods("GET")
+
+	// Health probes
+	r.HandleFunc("/healthz", a.HealthHandler.Liveness).Methods("GET")
+	r.HandleFunc("/readyz", a.HealthHandler.Readiness).Methods("GET")
+
+	// GraphQL shares the API middleware; the playground is a static page for exploring the schema
+	gql := r.PathPrefix("/graphql").Subrouter()
+	gql.Use(middleware.Gzip, middleware.Timeout(a.Config.RequestTimeout))
+	gql.Handle("", graph.NewHandler(a.UserService, policies, a.Captcha, a.Logger)).Methods("GET", "POST")
+	gql.Handle("/playground", docsPolicy(playground.Handler("Example API", "/graphql"))).Methods("GET")
+
+	// Event streams hold their connection open, so they bypass the API and server timeouts and
+	// compression
+	if a.Config.EventsToken != "" {
+		requireToken := middleware.RequireToken(a.Config.EventsToken, true)
+		r.Handle("/api/ws", middleware.LongRunning(requireToken(http.HandlerFunc(a.EventsHandler.WebSocket)))).Methods("GET")
+		r.Handle("/api/events", middleware.LongRunning(requireToken(http.HandlerFunc(a.EventsHandler.Stream)))).Methods("GET")
+	}
+
+	// Exports stream for as long as reading every user takes, so they bypass the API and server
+	// timeouts
+	exportUsers := middleware.LongRunning(middleware.Gzip(http.HandlerFunc(a.UserHandler.ExportUsers)))
+	r.Handle("/api/v1/users/export", middleware.APIVersion("v1", nil)(exportUsers)).Methods("GET")
+	r.Handle("/api/users/export", middleware.APIVersion("v1", &middleware.Deprecation{Successor: "/api/v1"})(exportUsers)).Methods("GET")
+	// Files of background exports can be as large, and are only under /api/v1
+	downloadExport := middleware.LongRunning(middleware.Gzip(http.HandlerFunc(a.ExportHandler.DownloadExport)))
+	r.Handle("/api/v1/users/exports/{id}/file", middleware.APIVersion("v1", nil)(downloadExport)).Methods("GET")
+
+	// Imports hash a password per row, which takes longer than the API and server timeouts
+	// allow. They create accounts of any role, so they need ADMIN_TOKEN and are disabled
+	// without it.
+	if a.Config.AdminToken != "" {
+		importUsers := middleware.LongRunning(a.requireAdmin(http.HandlerFunc(a.ImportHandler.ImportUsers)))
+		r.Handle("/api/v1/users/import", middleware.APIVersion("v1", nil)(importUsers)).Methods("POST")
+		r.Handle("/api/users/import", middleware.APIVersion("v1", &middleware.Deprecation{Successor: "/api/v1"})(importUsers)).Methods("POST")
+	}
+
+	// API routes
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(middleware.Gzip, middleware.Timeout(a.Config.RequestTimeout))
+
+	// Version 1
+	v1 := api.PathPrefix("/v1").Subrouter()
+	v1.Use(middleware.APIVersion("v1", nil))
+	// Registered before /users/{id}, which would otherwise take "exports" for an ID
+	a.registerExportRoutes(v1)
+	a.registerV1Routes(v1, true)
+	a.registerOrganizationRoutes(v1)
+	a.registerRoleRoutes(v1)
+	a.registerGroupRoutes(v1)
+	a.registerNotificationRoutes(v1)
+	a.registerFeatureRoutes(v1)
+	a.registerPhoneRoutes(v1)
+	a.registerAddressRoutes(v1)
+	a.registerPreferencesRoutes(v1)
+	a.registerUsageRoutes(v1)
+	a.registerWebhookRoutes(v1)
+	a.registerAdminRoutes(v1)
+
+	// Unversioned paths predate versioning; they keep serving v1 but point clients at /api/v1
+	legacy := api.NewRoute().Subrouter()
+	legacy.Use(middleware.APIVersion("v1", &middleware.Deprecation{Successor: "/api/v1"}))
+	a.registerV1Routes(legacy, false)
+
+	// Tell clients which methods a path takes, with 405 for the others and 204 for OPTIONS,
+	// rather than 404
+	r.NotFoundHandler = handlers.Unmatched(r)
+	r.MethodNotAllowedHandler = r.NotFoundHandler
+
+	// Wrap the router with the client address, security headers, request IDs, the language of
+	// messages, the actor for the audit log, access logging, the tenant, the principal, which
+	// is looked up in the tenant unless its address failed to sign in too often, usage
+	// metering, and the rate limit of the principal's plan
+	tenancy := a.Config.Tenancy
+	headers := a.Config.SecurityHeaders
+	authenticate := middleware.Authenticate(a.Config.AdminToken, services.AdminPrincipal(), a.Authenticator, a.Logger)
+	var handler http.Handler = r
+	if a.Limiter != nil {
+		limits := a.Config.RateLimit
+		plans := ratelimit.Plans{Limits: limits.Plans, ByRole: limits.RolePlans, Default: limits.DefaultPlan}
+		handler = middleware.RateLimit(a.Limiter, plans, a.Logger, "/api", "/graphql")(handler)
+	}
+	if a.UsageMeter != nil {
+		handler = middleware.Usage(a.UsageMeter, "/api", "/graphql")(handler)
+	}
+	return middleware.ClientIP(a.Config.TrustedProxies)(middleware.SecurityHeaders(headers.StrictTransportSecurity, headers.ContentSecurityPolicy, a.Config.TrustedProxies)(middleware.RequestID(middleware.Locale(middleware.Actor(actor.API)(middleware.AccessLog(a.Logger)(middleware.Tenant(tenancy.Header, tenancy.Domain, tenancy.Tenants)(authenticate(handler))))))))
+}
+
+// bodyLimits are the largest request bodies of the routes that take uploads, keyed like
+// policies. Their handlers hold the file itself to its own limit.
+func (a *App) bodyLimits() map[string]int64 {
+	return map[string]int64{
+		"PUT /users/{id}/avatar": int64(a.Config.AvatarMaxSize) + handlers.MultipartOverhead,
+		"POST /users/import":     int64(a.Config.ImportMaxSize) + handlers.MultipartOverhead,
+	}
+}
+
+// requireAdmin rejects requests without ADMIN_TOKEN and attributes the changes of the rest to
+// the admin actor.
+func (a *App) requireAdmin(next http.Handler) http.Handler {
+	return middleware.RequireToken(a.Config.AdminToken, false)(middleware.Actor(actor.Admin)(next))
+}
+
+// registerV1Routes registers the version 1 API on r. A breaking change ships as a new
+// registerV2Routes mounted under /api/v2 while this one keeps working. Route names are
+// router-wide, so only the canonical mount is named; response links always point at it.
+func (a *App) registerV1Routes(r *mux.Router, named bool) {
+	name := func(route *mux.Route, routeName string) {
+		if named {
+			route.Name(routeName)
+		}
+	}
+
+	// User routes
+	name(r.HandleFunc("/users", a.UserHandler.ListUsers).Methods("GET"), handlers.RouteListUsers)
+	// Registered before /users/{id}, which would otherwise take "search" for an ID
+	r.HandleFunc("/users/search", a.UserHandler.SearchUsers).Methods("GET")
+	r.HandleFunc("/users/batch-get", a.UserHandler.GetUsers).Methods("POST")
+	// Retries of a signup replay its response, so only the first accepted CAPTCHA is spent; a
+	// rejected one is not replayed, so a retry with the same key can bring a new one
+	var createUser http.Handler = http.HandlerFunc(a.UserHandler.CreateUser)
+	if a.Captcha != nil {
+		createUser = middleware.RequireCaptcha(a.Captcha, a.Logger)(createUser)
+	}
+	name(r.Handle("/users", middleware.Idempotency(a.IdempotencyStore, a.Config.IdempotencyTTL, a.Logger)(createUser)).Methods("POST"), handlers.RouteCreateUser)
+	name(r.HandleFunc("/users/{id}", a.UserHandler.GetUserByID).Methods("GET"), handlers.RouteGetUser)
+	r.HandleFunc("/users/{id}", a.UserHandler.HeadUser).Methods("HEAD")
+	name(r.HandleFunc("/users/{id}", a.UserHandler.UpdateUser).Methods("PUT"), handlers.RouteUpdateUser)
+	name(r.HandleFunc("/users/{id}", a.UserHandler.DeleteUser).Methods("DELETE"), handlers.RouteDeleteUser)
+
+	// Avatar routes
+	r.HandleFunc("/users/{id}/avatar", a.AvatarHandler.GetAvatar).Methods("GET")
+	r.HandleFunc("/users/{id}/avatar", a.AvatarHandler.PutAvatar).Methods("PUT")
+	r.HandleFunc("/users/{id}/avatar", a.AvatarHandler.DeleteAvatar).Methods("DELETE")
+
+	// Personal data requests are answered by operators, so they need ADMIN_TOKEN
+	if a.Config.AdminToken != "" {
+		r.Handle("/users/{id}/export", a.requireAdmin(http.HandlerFunc(a.UserHandler.ExportUserData))).Methods("GET")
+		r.Handle("/users/{id}/erase", a.requireAdmin(http.HandlerFunc(a.UserHandler.EraseUser))).Methods("POST")
+	}
+}
+
+// registerExportRoutes registers background user exports on r. They are newer than versioning
+// and only exist under /api/v1. Their files are downloaded outside the API timeout, in newRouter.
+func (a *App) registerExportRoutes(r *mux.Router) {
+	exports := r.PathPrefix("/users/exports").Subrouter()
+	exports.HandleFunc("", a.ExportHandler.StartExport).Methods("POST")
+	exports.HandleFunc("/{id}", a.ExportHandler.GetExport).Methods("GET").Name(handlers.RouteGetExport)
+}
+
+// registerOrganizationRoutes registers organizations and their memberships on r. They are newer
+// than versioning and only exist under /api/v1.
+func (a *App) registerOrganizationRoutes(r *mux.Router) {
+	r.HandleFunc("/users/{id}/organizations", a.OrgHandler.ListUserMemberships).Methods("GET")
+
+	orgs := r.PathPrefix("/organizations").Subrouter()
+	orgs.HandleFunc("", a.OrgHandler.ListOrganizations).Methods("GET")
+	orgs.HandleFunc("", a.OrgHandler.CreateOrganization).Methods("POST")
+	orgs.HandleFunc("/{id}", a.OrgHandler.GetOrganization).Methods("GET").Name(handlers.RouteGetOrganization)
+	orgs.HandleFunc("/{id}", a.OrgHandler.UpdateOrganization).Methods("PUT")
+	orgs.HandleFunc("/{id}", a.OrgHandler.DeleteOrganization).Methods("DELETE")
+	orgs.HandleFunc("/{id}/members", a.OrgHandler.ListMembers).Methods("GET")
+	orgs.HandleFunc("/{id}/members", a.OrgHandler.InviteMember).Methods("POST")
+	orgs.HandleFunc("/{id}/members/{userId}", a.OrgHandler.UpdateMember).Methods("PUT")
+	orgs.HandleFunc("/{id}/members/{userId}", a.OrgHandler.RemoveMember).Methods("DELETE")
+	orgs.HandleFunc("/{id}/members/{userId}/accept", a.OrgHandler.AcceptInvitation).Methods("POST")
+}
+
+// registerRoleRoutes registers role management and assignment on r. They are newer than
+// versioning and only exist under /api/v1.
+func (a *App) registerRoleRoutes(r *mux.Router) {
+	r.HandleFunc("/users/{id}/role", a.UserHandler.AssignRole).Methods("PUT")
+
+	roles := r.PathPrefix("/roles").Subrouter()
+	roles.HandleFunc("", a.RoleHandler.ListRoles).Methods("GET")
+	roles.HandleFunc("", a.RoleHandler.CreateRole).Methods("POST")
+	roles.HandleFunc("/{name}", a.RoleHandler.GetRole).Methods("GET").Name(handlers.RouteGetRole)
+	roles.HandleFunc("/{name}", a.RoleHandler.UpdateRole).Methods("PUT")
+	roles.HandleFunc("/{name}", a.RoleHandler.DeleteRole).Methods("DELETE")
+}
+
+// registerGroupRoutes registers groups and their members on r. They are newer than versioning
+// and only exist under /api/v1.
+func (a *App) registerGroupRoutes(r *mux.Router) {
+	r.HandleFunc("/users/{id}/groups", a.GroupHandler.ListUserGroups).Methods("GET")
+
+	groups := r.PathPrefix("/groups").Subrouter()
+	groups.HandleFunc("", a.GroupHandler.ListGroups).Methods("GET")
+	groups.HandleFunc("", a.GroupHandler.CreateGroup).Methods("POST")
+	groups.HandleFunc("/{id}", a.GroupHandler.GetGroup).Methods("GET").Name(handlers.RouteGetGroup)
+	groups.HandleFunc("/{id}", a.GroupHandler.UpdateGroup).Methods("PUT")
+	groups.HandleFunc("/{id}", a.GroupHandler.DeleteGroup).Methods("DELETE")
+	groups.HandleFunc("/{id}/members", a.GroupHandler.ListMembers).Methods("GET")
+	groups.HandleFunc("/{id}/members", a.GroupHandler.AddMembers).Methods("POST")
+	groups.HandleFunc("/{id}/members/{userId}", a.GroupHandler.RemoveMember).Methods("DELETE")
+}
+
+// registerNotificationRoutes registers the notifications of users on r. They are newer than
+// versioning and only exist under /api/v1.
+func (a *App) registerNotificationRoutes(r *mux.Router) {
+	notifications := r.PathPrefix("/users/{id}/notifications").Subrouter()
+	notifications.HandleFunc("", a.NotificationHandler.ListNotifications).Methods("GET")
+	notifications.HandleFunc("", a.NotificationHandler.SendNotice).Methods("POST")
+	notifications.HandleFunc("/read", a.NotificationHandler.MarkAllRead).Methods("POST")
+	notifications.HandleFunc("/{notificationId}/read", a.NotificationHandler.MarkRead).Methods("POST")
+	notifications.HandleFunc("/{notificationId}", a.NotificationHandler.DeleteNotification).Methods("DELETE")
+}
+
+// registerFeatureRoutes registers the features enabled for the caller on r. They are newer than
+// versioning and only exist under /api/v1.
+func (a *App) registerFeatureRoutes(r *mux.Router) {
+	r.HandleFunc("/features", a.FeatureFlagHandler.ListFeatures).Methods("GET")
+}
+
+// registerPhoneRoutes registers phone number verification on r. It needs a way to send text
+// messages, so it is disabled without SMS_PROVIDER. It is newer than versioning and only exists
+// under /api/v1.
+func (a *App) registerPhoneRoutes(r *mux.Router) {
+	if a.PhoneHandler == nil {
+		return
+	}
+	r.HandleFunc("/users/{id}/phone/verification", a.PhoneHandler.SendPhoneCode).Methods("POST")
+	r.HandleFunc("/users/{id}/phone/verification/confirm", a.PhoneHandler.ConfirmPhoneCode).Methods("POST")
+}
+
+// registerAddressRoutes registers the postal addresses of users on r. They are newer than
+// versioning and only exist under /api/v1.
+func (a *App) registerAddressRoutes(r *mux.Router) {
+	addresses := r.PathPrefix("/users/{id}/addresses").Subrouter()
+	addresses.HandleFunc("", a.AddressHandler.ListAddresses).Methods("GET")
+	addresses.HandleFunc("", a.AddressHandler.AddAddress).Methods("POST")
+	addresses.HandleFunc("/{addressId}", a.AddressHandler.GetAddress).Methods("GET").Name(handlers.RouteGetAddress)
+	addresses.HandleFunc("/{addressId}", a.AddressHandler.UpdateAddress).Methods("PUT")
+	addresses.HandleFunc("/{addressId}", a.AddressHandler.DeleteAddress).Methods("DELETE")
+}
+
+// registerPreferencesRoutes registers the application settings of users on r. They are newer
+// than versioning and only exist under /api/v1.
+func (a *App) registerPreferencesRoutes(r *mux.Router) {
+	r.HandleFunc("/users/{id}/preferences", a.PreferencesHandler.GetPreferences).Methods("GET")
+	r.HandleFunc("/users/{id}/preferences", a.PreferencesHandler.UpdatePreferences).Methods("PUT")
+}
+
+// registerUsageRoutes registers the API usage of the caller on r. It is newer than versioning
+// and only exists under /api/v1.
+func (a *App) registerUsageRoutes(r *mux.Router) {
+	r.HandleFunc("/me/usage", a.UsageHandler.GetOwnUsage).Methods("GET")
+}
+
+// registerWebhookRoutes registers webhook management on r. Webhooks receive the same events as the
+// event streams, so they are managed with the same token and disabled along with them. They are
+// newer than versioning and only exist under /api/v1.
+func (a *App) registerWebhookRoutes(r *mux.Router) {
+	if a.Config.EventsToken == "" {
+		return
+	}
+
+	wh := r.PathPrefix("/webhooks").Subrouter()
+	wh.Use(middleware.RequireToken(a.Config.EventsToken, false))
+	wh.HandleFunc("", a.WebhookHandler.ListWebhooks).Methods("GET")
+	wh.HandleFunc("", a.WebhookHandler.CreateWebhook).Methods("POST")
+	wh.HandleFunc("/{id}", a.WebhookHandler.GetWebhook).Methods("GET").Name(handlers.RouteGetWebhook)
+	wh.HandleFunc("/{id}", a.WebhookHandler.UpdateWebhook).Methods("PUT")
+	wh.HandleFunc("/{id}", a.WebhookHandler.DeleteWebhook).Methods("DELETE")
+	wh.HandleFunc("/{id}/deliveries", a.WebhookHandler.ListDeliveries).Methods("GET")
+}
+
+// registerAdminRoutes registers operator tools on r, protected by ADMIN_TOKEN and disabled
+// without it. They only exist under /api/v1.
+func (a *App) registerAdminRoutes(r *mux.Router) {
+	if a.Config.AdminToken == "" {
+		return
+	}
+
+	r.Handle("/audit-logs", a.requireAdmin(http.HandlerFunc(a.AuditHandler.ListAuditLogs))).Methods("GET")
+
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(a.requireAdmin)
+	admin.HandleFunc("/stats", a.StatsHandler.GetStats).Methods("GET")
+	admin.HandleFunc("/emails", a.EmailHandler.ListTemplates).Methods("GET")
+	admin.HandleFunc("/emails/{name}", a.EmailHandler.PreviewTemplate).Methods("GET")
+	admin.HandleFunc("/usage", a.UsageHandler.ListUsage).Methods("GET")
+	admin.HandleFunc("/jobs", a.JobHandler.ListJobs).Methods("GET")
+	admin.HandleFunc("/jobs/{id}/retry", a.JobHandler.RetryJob).Methods("POST")
+	admin.HandleFunc("/tasks", a.TaskHandler.ListTasks).Methods("GET")
+	admin.HandleFunc("/tasks/{name}/runs", a.TaskHandler.ListTaskRuns).Methods("GET")
+	admin.HandleFunc("/feature-flags", a.FeatureFlagHandler.ListFlags).Methods("GET")
+	admin.HandleFunc("/feature-flags/{key}", a.FeatureFlagHandler.GetFlag).Methods("GET")
+	admin.HandleFunc("/feature-flags/{key}", a.FeatureFlagHandler.PutFlag).Methods("PUT")
+	admin.HandleFunc("/feature-flags/{key}", a.FeatureFlagHandler.DeleteFlag).Methods("DELETE")
+	admin.HandleFunc("/reload", a.ReloadHandler.Reload).Methods("POST")
+}
@@ -0,0 +1,281 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"example_api/config"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+)
+
+// Run serves HTTP, or HTTPS when TLS is configured, and, when enabled, gRPC until ctx is
+// cancelled, then drains in-flight requests and releases resources.
+func (a *App) Run(ctx context.Context) error {
+	limits := a.Config.HTTPServer
+	server := &http.Server{
+		Addr:              ":" + a.Config.Port,
+		Handler:           a.Handler,
+		ReadTimeout:       limits.ReadTimeout,
+		ReadHeaderTimeout: limits.ReadHeaderTimeout,
+		WriteTimeout:      limits.WriteTimeout,
+		IdleTimeout:       limits.IdleTimeout,
+		MaxHeaderBytes:    limits.MaxHeaderBytes,
+	}
+	tlsEnabled := a.Config.TLS.Enabled()
+	var certManager *autocert.Manager
+	if tlsEnabled {
+		tlsConfig, manager, err := newTLSConfig(a.Config.TLS)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tlsConfig
+		certManager = manager
+	}
+	// End event streams as soon as shutdown starts; otherwise they hold it up until the timeout
+	server.RegisterOnShutdown(a.Events.Close)
+	// Stop claiming jobs and starting tasks too; those under way get until the timeout to finish
+	server.RegisterOnShutdown(a.Queue.Close)
+	server.RegisterOnShutdown(a.Scheduler.Close)
+
+	serverErr := make(chan error, 3)
+	go func() {
+		a.Logger.Info("Server is running", slog.String("port", a.Config.Port), slog.Bool("tls", tlsEnabled))
+		var err error
+		if tlsEnabled {
+			// Certificates come from TLSConfig
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Send plain HTTP requests to HTTPS, except the ACME challenges of autocert mode
+	var redirectServer *http.Server
+	if a.Config.TLS.RedirectPort != "" {
+		handler := redirectToHTTPS(a.Config.Port)
+		if certManager != nil {
+			handler = certManager.HTTPHandler(handler)
+		}
+		redirectServer = &http.Server{
+			Addr:              ":" + a.Config.TLS.RedirectPort,
+			Handler:           handler,
+			ReadTimeout:       limits.ReadTimeout,
+			ReadHeaderTimeout: limits.ReadHeaderTimeout,
+			WriteTimeout:      limits.WriteTimeout,
+			IdleTimeout:       limits.IdleTimeout,
+			MaxHeaderBytes:    limits.MaxHeaderBytes,
+		}
+		go func() {
+			a.Logger.Info("Redirecting HTTP to HTTPS", slog.String("port", a.Config.TLS.RedirectPort))
+			if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	// Serve gRPC on its own port
+	if a.GRPCServer != nil {
+		listener, err := net.Listen("tcp", ":"+a.Config.GRPCPort)
+		if err != nil {
+			server.Close()
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		go func() {
+			a.Logger.Info("gRPC server is running", slog.String("port", a.Config.GRPCPort))
+			if err := a.GRPCServer.Serve(listener); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
+
+	// Run jobs and maintenance tasks, refresh secrets, and forward events to webhooks, the message bus, and email
+	// until shutdown; work still under way when the shutdown timeout passes is abandoned, and jobs are run again later
+	consumers := []func(context.Context){a.Queue.Run, a.Scheduler.Run, a.Dispatcher.Run}
+	if a.BusRelay != nil {
+		consumers = append(consumers, a.BusRelay.Run)
+	}
+	if a.OutboxRelay != nil {
+		consumers = append(consumers, a.OutboxRelay.Run)
+	}
+	if a.WelcomeSender != nil {
+		consumers = append(consumers, a.WelcomeSender.Run)
+	}
+	if a.Config.Secrets != nil {
+		consumers = append(consumers, func(ctx context.Context) { a.Config.Secrets.Run(ctx, a.Logger) })
+	}
+	consumerCtx, stopConsumers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopConsumers()
+	var consumersDone sync.WaitGroup
+	for _, run := range consumers {
+		consumersDone.Add(1)
+		go func() {
+			defer consumersDone.Done()
+			run(consumerCtx)
+		}()
+	}
+
+	// Optionally expose profiling endpoints on a separate internal address
+	var pprofServer *http.Server
+	if a.Config.PprofAddr != "" {
+		pprofServer = newPprofServer(a.Config.PprofAddr)
+		go func() {
+			a.Logger.Info("pprof is listening", slog.String("addr", a.Config.PprofAddr))
+			if err := pprofServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.Logger.Error("pprof server failed", slog.Any("error", err))
+			}
+		}()
+	}
+
+	// Reload the configuration on SIGHUP until shutdown begins
+	go a.reloadOnHangup(ctx)
+	// Drop cached users changed elsewhere until shutdown begins
+	if a.UserWatcher != nil {
+		go a.UserWatcher.Run(ctx)
+	}
+	// Record API usage until shutdown begins, and that of the drained requests after it
+	if a.UsageMeter != nil {
+		go a.UsageMeter.Run(ctx)
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-serverErr:
+	}
+
+	a.Logger.Info("Shutting down server")
+
+	// Stop accepting connections and drain in-flight requests
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		a.Logger.Error("Server did not shut down cleanly", slog.Any("error", err))
+	}
+	if redirectServer != nil {
+		redirectServer.Shutdown(shutdownCtx)
+	}
+	if a.GRPCServer != nil {
+		stopGRPC(shutdownCtx, a.GRPCServer)
+	}
+	if pprofServer != nil {
+		pprofServer.Shutdown(shutdownCtx)
+	}
+	if a.UsageMeter != nil {
+		a.UsageMeter.Flush(shutdownCtx)
+	}
+	// Shutdown closed the broker, so consumers only have the work under way left to finish
+	stopped := make(chan struct{})
+	go func() {
+		consumersDone.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		stopConsumers()
+		<-stopped
+	}
+
+	a.Close(shutdownCtx)
+	a.Logger.Info("Server stopped")
+
+	return runErr
+}
+
+// stopGRPC drains in-flight calls, cutting them off if ctx expires first.
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+// newTLSConfig returns a TLS configuration for cfg that offers HTTP/2 and refuses versions
+// older than TLS 1.2. It loads the certificate files or, in autocert mode, also returns the
+// manager that obtains certificates when they are first asked for.
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, *autocert.Manager, error) {
+	if len(cfg.Autocert.Domains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Autocert.Domains...),
+			Cache:      autocert.DirCache(cfg.Autocert.CacheDir),
+			Email:      cfg.Autocert.Email,
+		}
+		if cfg.Autocert.DirectoryURL != "" {
+			manager.Client = &acme.Client{DirectoryURL: cfg.Autocert.DirectoryURL}
+		}
+		// The manager's configuration also answers TLS-ALPN-01 challenges
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, manager, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil, nil
+}
+
+// redirectToHTTPS returns a handler that permanently redirects every request to the same URL
+// over HTTPS on port, keeping its method and body.
+func redirectToHTTPS(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := (&url.URL{Host: r.Host}).Hostname()
+		if host == "" {
+			http.Error(w, "Host header required", http.StatusBadRequest)
+			return
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			// IPv6 literals keep their brackets
+			host = "[" + host + "]"
+		}
+		target := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
+
+// newPprofServer returns a server exposing the net/http/pprof handlers on addr.
+// It is kept off the public router so profiles are only reachable from the internal network.
+func newPprofServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	// Profiles take as long as asked for, so only reading headers is bounded
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
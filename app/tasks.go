@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"example_api/scheduler"
+	"time"
+)
+
+// Names of the scheduled maintenance tasks
+const (
+	TaskExpireIdempotencyKeys = "idempotency-keys.expire"
+	TaskCompactAuditLogs      = "audit-logs.compact"
+)
+
+// registerTasks adds the maintenance tasks to the scheduler, enabled and timed as configured.
+func (a *App) registerTasks() {
+	tasks := a.Config.Tasks
+	a.Scheduler.Register(scheduler.Task{
+		Name:        TaskExpireIdempotencyKeys,
+		Description: "Deletes expired idempotency keys along with the responses stored for replay",
+		Interval:    tasks.ExpireIdempotencyKeys.Interval,
+		Enabled:     tasks.ExpireIdempotencyKeys.Enabled,
+		Run: func(ctx context.Context) (int64, error) {
+			return a.IdempotencyStore.Purge(ctx, time.Now())
+		},
+	})
+	a.Scheduler.Register(scheduler.Task{
+		Name:        TaskCompactAuditLogs,
+		Description: "Deletes audit log entries older than AUDIT_RETENTION in every tenant",
+		Interval:    tasks.CompactAuditLogs.Interval,
+		Enabled:     tasks.CompactAuditLogs.Enabled,
+		Run: func(ctx context.Context) (int64, error) {
+			return a.AuditStore.Purge(ctx, time.Now().Add(-tasks.AuditRetention))
+		},
+	})
+}
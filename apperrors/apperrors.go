@@ -0,0 +1,184 @@
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an application error independently of transport.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindPreconditionRequired
+	KindTooLarge
+	KindUnsupportedMediaType
+	KindUnauthenticated
+	KindForbidden
+	KindTooManyRequests
+	KindUnavailable
+)
+
+// Error is an application error with a client-safe message.
+type Error struct {
+	Kind    Kind
+	Message string
+	// Fields lists the individual problems behind a validation error
+	Fields []FieldError
+	Err    error
+}
+
+// FieldError describes one invalid input field.
+type FieldError struct {
+	Field   string `json:"field" xml:"field,attr"`
+	Rule    string `json:"rule" xml:"rule,attr"`
+	Message string `json:"message" xml:",chardata"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is lets errors.Is match any error of the same kind against the kind sentinels below.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Err == nil && t.Fields == nil && t.Kind == e.Kind
+}
+
+// Kind sentinels for use with errors.Is, e.g. errors.Is(err, apperrors.ErrNotFound).
+var (
+	ErrInternal   = &Error{Kind: KindInternal}
+	ErrNotFound   = &Error{Kind: KindNotFound}
+	ErrConflict   = &Error{Kind: KindConflict}
+	ErrValidation = &Error{Kind: KindValidation}
+
+	ErrPreconditionRequired = &Error{Kind: KindPreconditionRequired}
+	ErrTooLarge             = &Error{Kind: KindTooLarge}
+	ErrUnsupportedMediaType = &Error{Kind: KindUnsupportedMediaType}
+	ErrUnauthenticated      = &Error{Kind: KindUnauthenticated}
+	ErrForbidden            = &Error{Kind: KindForbidden}
+	ErrTooManyRequests      = &Error{Kind: KindTooManyRequests}
+	ErrUnavailable          = &Error{Kind: KindUnavailable}
+)
+
+// NotFound returns an error for a missing resource.
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// Conflict returns an error for a request that clashes with the current state.
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Validation returns an error for invalid client input.
+func Validation(message string) *Error {
+	return &Error{Kind: KindValidation, Message: message}
+}
+
+// InvalidFields returns a validation error listing each offending field.
+func InvalidFields(message string, fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+// PreconditionRequired returns an error for a change submitted without the required precondition.
+func PreconditionRequired(message string) *Error {
+	return &Error{Kind: KindPreconditionRequired, Message: message}
+}
+
+// TooLarge returns an error for a request body or upload over the size limit.
+func TooLarge(message string) *Error {
+	return &Error{Kind: KindTooLarge, Message: message}
+}
+
+// UnsupportedMediaType returns an error for content in a format the server does not accept.
+func UnsupportedMediaType(message string) *Error {
+	return &Error{Kind: KindUnsupportedMediaType, Message: message}
+}
+
+// Unauthenticated returns an error for a request that needs credentials and has none.
+func Unauthenticated(message string) *Error {
+	return &Error{Kind: KindUnauthenticated, Message: message}
+}
+
+// Forbidden returns an error for a principal that may not do what it asked.
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// TooManyRequests returns an error for a request repeated sooner than allowed.
+func TooManyRequests(message string) *Error {
+	return &Error{Kind: KindTooManyRequests, Message: message}
+}
+
+// Unavailable wraps the failure of a dependency the request cannot do without, such as an
+// external provider, which a retry may get past; message is safe to show clients, err is not.
+func Unavailable(message string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Message: message, Err: err}
+}
+
+// Internal wraps an unexpected failure; message is safe to show clients, err is not.
+func Internal(message string, err error) *Error {
+	return &Error{Kind: KindInternal, Message: message, Err: err}
+}
+
+// HTTPStatus maps err to the HTTP status code that represents it. Errors that are not
+// application errors are treated as internal.
+func HTTPStatus(err error) int {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError
+	}
+	switch appErr.Kind {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case KindUnauthenticated:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Fields returns the field-level details of a validation error, if any.
+func Fields(err error) []FieldError {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return nil
+	}
+	return appErr.Fields
+}
+
+// Message returns the client-safe message of err, or fallback for internal and unknown errors.
+func Message(err error, fallback string) string {
+	var appErr *Error
+	if !errors.As(err, &appErr) || appErr.Kind == KindInternal {
+		return fallback
+	}
+	return appErr.Message
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrInvalidCredentials is returned when credentials do not match an account.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is who a request is authenticated as and what they may do.
+type Principal struct {
+	// UserID is the hex ID of the user, empty for ADMIN_TOKEN
+	UserID      string
+	Role        string
+	Permissions []string
+}
+
+// Can reports whether the principal holds permission.
+func (p *Principal) Can(permission string) bool {
+	return slices.Contains(p.Permissions, permission)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying principal.
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal stored in ctx, or nil for anonymous requests.
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
+
+// ClientKey returns the key the client of a request by principal from address ip is known by
+// when its requests are counted: "user:" and the ID of users, "admin" for ADMIN_TOKEN, and "ip:"
+// and the address of anonymous clients.
+func ClientKey(principal *Principal, ip string) string {
+	switch {
+	case principal == nil:
+		return "ip:" + ip
+	case principal.UserID == "":
+		return "admin"
+	default:
+		return "user:" + principal.UserID
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"example_api/encryption"
+	"time"
+)
+
+// EncryptingCache seals the values it stores in another Cache, so a copy of the cache does not
+// reveal them. Values that cannot be opened are treated as missing.
+type EncryptingCache struct {
+	cache Cache
+	keys  *encryption.Keyring
+}
+
+func NewEncryptingCache(cache Cache, keys *encryption.Keyring) *EncryptingCache {
+	return &EncryptingCache{
+		cache: cache,
+		keys:  keys,
+	}
+}
+
+var _ Cache = (*EncryptingCache)(nil)
+
+func (c *EncryptingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok, err := c.cache.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if value, err = c.keys.Open(key, value); err != nil {
+		return nil, false, nil
+	}
+	return value, true, nil
+}
+
+func (c *EncryptingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	sealed, err := c.keys.Seal(key, value)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, key, sealed, ttl)
+}
+
+func (c *EncryptingCache) Delete(ctx context.Context, keys ...string) error {
+	return c.cache.Delete(ctx, keys...)
+}
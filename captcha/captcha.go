@@ -0,0 +1,105 @@
+// Package captcha checks the tokens of solved CAPTCHAs with the provider that issued them,
+// reCAPTCHA, hCaptcha, or Turnstile, so scripts cannot sign up accounts in bulk.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Header is the request header clients send the token of their solved CAPTCHA in.
+const Header = "X-Captcha-Token"
+
+var (
+	// ErrMissing is returned when a request has no token to check
+	ErrMissing = errors.New("captcha token missing")
+	// ErrRejected is wrapped by the errors of tokens the provider did not accept, such as
+	// expired, reused, or forged ones
+	ErrRejected = errors.New("captcha token rejected")
+)
+
+// VerifyURLs are the token verification endpoints of the providers, by name.
+var VerifyURLs = map[string]string{
+	"recaptcha": "https://www.google.com/recaptcha/api/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// Verifier checks CAPTCHA tokens.
+type Verifier interface {
+	// Verify returns nil if token is a CAPTCHA solved by the client at ip, ErrMissing if token
+	// is empty, and an error wrapping ErrRejected if the provider does not accept it.
+	Verify(ctx context.Context, token, ip string) error
+}
+
+// SiteVerifier checks tokens with the siteverify protocol every provider speaks: the secret,
+// token, and client address are posted as a form and answered with whether the token is good.
+type SiteVerifier struct {
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+// NewSiteVerifier checks tokens at endpoint, one of VerifyURLs or a stand-in for it, with the
+// secret key of the site. Each check may take up to timeout.
+func NewSiteVerifier(endpoint, secret string, timeout time.Duration) *SiteVerifier {
+	return &SiteVerifier{
+		endpoint: endpoint,
+		secret:   secret,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+var _ Verifier = (*SiteVerifier)(nil)
+
+// siteVerifyResult is the body of a siteverify response.
+type siteVerifyResult struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify asks the provider whether token is good. Tokens can be checked only once, so a token
+// that passed is rejected the next time. The provider refusing the secret key is an error of
+// its own rather than a rejected token, since no token can pass until the key is fixed.
+func (v *SiteVerifier) Verify(ctx context.Context, token, ip string) error {
+	if token == "" {
+		return ErrMissing
+	}
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the captcha provider: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha provider responded %d", resp.StatusCode)
+	}
+	var result siteVerifyResult
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("invalid captcha provider response: %w", err)
+	}
+	if result.Success {
+		return nil
+	}
+	if slices.ContainsFunc(result.ErrorCodes, func(code string) bool { return strings.Contains(code, "secret") }) {
+		return fmt.Errorf("captcha provider refused the secret key: %s", strings.Join(result.ErrorCodes, ", "))
+	}
+	return fmt.Errorf("%w: %s", ErrRejected, strings.Join(result.ErrorCodes, ", "))
+}
@@ -0,0 +1,92 @@
+// Package clientip works out the address of the client behind a request, which trusted
+// reverse proxies name in the X-Forwarded-For or X-Real-IP header.
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the client address ip.
+func NewContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// FromContext returns the client address stored in ctx, or an empty string if there is none.
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKey{}).(string)
+	return ip
+}
+
+// Resolve returns the address of the client that sent r. Forwarding headers are only believed
+// when the peer is in trusted: X-Forwarded-For is read from the right, where the nearest proxy
+// appended its peer, and the first address outside trusted is the client's. X-Real-IP is used
+// when there is no X-Forwarded-For.
+func Resolve(r *http.Request, trusted []netip.Prefix) string {
+	peer, err := peerAddr(r)
+	if err != nil {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	}
+	if !contains(trusted, peer) {
+		return peer.String()
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// Whatever a proxy we trust did not write cannot be relied on
+				break
+			}
+			client = hop.Unmap()
+			if !contains(trusted, client) {
+				break
+			}
+		}
+		return client.String()
+	}
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap().String()
+	}
+	return peer.String()
+}
+
+// Trusted reports whether r comes straight from one of the proxies in trusted, whose forwarding
+// headers can then be believed.
+func Trusted(r *http.Request, trusted []netip.Prefix) bool {
+	peer, err := peerAddr(r)
+	return err == nil && contains(trusted, peer)
+}
+
+// peerAddr returns the address r was received from.
+func peerAddr(r *http.Request) (netip.Addr, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return peer.Unmap(), nil
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"example_api/app"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newAdminCommand() *cobra.Command {
+	admin := &cobra.Command{
+		Use:   "admin",
+		Short: "Manage users directly against the database",
+	}
+
+	admin.AddCommand(
+		newAdminCreateCommand(),
+		newAdminResetPasswordCommand(),
+		newAdminListCommand(),
+		newAdminDeleteCommand(),
+		newAdminReencryptCommand(),
+	)
+	return admin
+}
+
+func newAdminCreateCommand() *cobra.Command {
+	var user models.User
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user with the admin role",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withApp(cmd.Context(), func(a *app.App) error {
+				created, err := a.UserService.CreateAdmin(cmd.Context(), &user)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Created admin %s with ID %s\n", created.Email, created.Id.Hex())
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&user.Email, "email", "", "admin email (required)")
+	cmd.Flags().StringVar(&user.Password, "password", "", "admin password (required)")
+	cmd.Flags().StringVar(&user.FirstName, "first-name", "", "admin first name (required)")
+	cmd.Flags().StringVar(&user.LastName, "last-name", "", "admin last name (required)")
+	for _, name := range []string{"email", "password", "first-name", "last-name"} {
+		cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+func newAdminResetPasswordCommand() *cobra.Command {
+	var password string
+
+	cmd := &cobra.Command{
+		Use:   "reset-password <user-id>",
+		Short: "Set a new password for a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withApp(cmd.Context(), func(a *app.App) error {
+				if err := a.UserService.ResetPassword(cmd.Context(), args[0], password); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Password reset for user %s\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&password, "password", "", "new password (required)")
+	cmd.MarkFlagRequired("password")
+	return cmd
+}
+
+func newAdminListCommand() *cobra.Command {
+	var page, limit int
+	var role string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List users",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withApp(cmd.Context(), func(a *app.App) error {
+				users, total, err := a.UserService.ListUsers(cmd.Context(), repositories.UserFilter{Role: role}, "", nil, page, limit)
+				if err != nil {
+					return err
+				}
+
+				tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "ID\tEMAIL\tNAME\tROLE\tJOINED")
+				for _, user := range users {
+					fmt.Fprintf(tw, "%s\t%s\t%s %s\t%s\t%s\n", user.Id.Hex(), user.Email, user.FirstName, user.LastName, user.Role, user.JoinDate.Format("2006-01-02"))
+				}
+				tw.Flush()
+				fmt.Fprintf(cmd.OutOrStdout(), "Page %d, %d of %d users\n", page, len(users), total)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&page, "page", 1, "page number")
+	cmd.Flags().IntVar(&limit, "limit", 20, "users per page")
+	cmd.Flags().StringVar(&role, "role", "", "only list users with this role")
+	return cmd
+}
+
+func newAdminDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <user-id>",
+		Short: "Delete a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withApp(cmd.Context(), func(a *app.App) error {
+				if err := a.UserService.DeleteUser(cmd.Context(), args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Deleted user %s\n", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func newAdminReencryptCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reencrypt",
+		Short: "Encrypt user emails and phone numbers under the current encryption key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withApp(cmd.Context(), func(a *app.App) error {
+				if a.Encryption == nil {
+					return fmt.Errorf("ENCRYPTION_KEYS is not set")
+				}
+				changed, skipped, err := a.Encryption.Reencrypt(cmd.Context(), func(id primitive.ObjectID) {
+					// The cache holds the user's old version
+					if a.UserCache != nil {
+						a.UserCache.Invalidate(cmd.Context(), id)
+					}
+				})
+				fmt.Fprintf(cmd.OutOrStdout(), "Re-encrypted %d users\n", changed)
+				if err != nil {
+					return err
+				}
+				if skipped > 0 {
+					return fmt.Errorf("%d users changed while being re-encrypted; run the command again", skipped)
+				}
+				return nil
+			})
+		},
+	}
+}
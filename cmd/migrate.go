@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"example_api/config"
+	"example_api/initializers"
+	"example_api/migrations"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending MongoDB data migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if cfg.DBDriver != "mongo" {
+				return fmt.Errorf("migrations are only supported with DB_DRIVER=mongo")
+			}
+
+			logger := initializers.NewLogger(cfg, initializers.LogLevel(cfg.LogLevel))
+			db, err := initializers.ConnectToDB(cfg, logger)
+			if err != nil {
+				return err
+			}
+			defer db.Client().Disconnect(context.Background())
+
+			return migrations.Run(cmd.Context(), db, logger)
+		},
+	}
+}
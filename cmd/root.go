@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"example_api/actor"
+	"example_api/app"
+	"example_api/config"
+	"example_api/tenant"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// Execute runs the command line, serving the API when no subcommand is given.
+func Execute() {
+	// Stop on an interrupt or termination signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Commands change data as the CLI; the server attributes each request to its own actor
+	ctx = actor.NewContext(ctx, actor.Actor{Name: actor.CLI})
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		slog.Error("Command failed", slog.Any("error", err))
+		stop()
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	var tenantID string
+	var mockMode bool
+	serve := func(cmd *cobra.Command, args []string) error {
+		if mockMode {
+			return runMock(cmd, args)
+		}
+		return runServe(cmd, args)
+	}
+
+	root := &cobra.Command{
+		Use:           "example_api",
+		Short:         "Example API server and management tools",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		// Management commands work in one tenant; withApp checks that it exists
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !tenant.Valid(tenantID) {
+				return fmt.Errorf("--tenant must hold lowercase letters, digits, and hyphens, got %q", tenantID)
+			}
+			cmd.SetContext(tenant.NewContext(cmd.Context(), tenantID))
+			return nil
+		},
+		RunE: serve,
+	}
+	root.PersistentFlags().StringVar(&tenantID, "tenant", tenant.Default, "tenant management commands work in")
+	root.Flags().BoolVar(&mockMode, "mock", false, "serve example responses from the OpenAPI document without a database")
+
+	serveCommand := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the HTTP API (default)",
+		RunE:  serve,
+	}
+	serveCommand.Flags().BoolVar(&mockMode, "mock", false, "serve example responses from the OpenAPI document without a database")
+
+	root.AddCommand(
+		serveCommand,
+		newMigrateCommand(),
+		newSeedCommand(),
+		newAdminCommand(),
+	)
+	return root
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	// Assemble the application
+	application, err := app.New(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	// Serve until shutdown
+	return application.Run(cmd.Context())
+}
+
+// runMock serves example responses from the OpenAPI document in place of the API.
+func runMock(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadMock()
+	if err != nil {
+		return err
+	}
+	return app.RunMock(cmd.Context(), cfg)
+}
+
+// withApp loads configuration, assembles the application for a one-off command, and
+// releases its resources once fn returns.
+func withApp(ctx context.Context, fn func(a *app.App) error) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cfg.Seed = false
+	if _, err := tenant.Lookup(tenant.FromContext(ctx), cfg.Tenancy.Tenants); err != nil {
+		return fmt.Errorf("unknown tenant %q; list it in TENANTS", tenant.FromContext(ctx))
+	}
+
+	application, err := app.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer application.Close(context.Background())
+
+	return fn(application)
+}
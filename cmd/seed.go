@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"example_api/app"
+	"example_api/seed"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newSeedCommand() *cobra.Command {
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Populate the database with fake users",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if count < 1 {
+				return fmt.Errorf("count must be at least 1")
+			}
+			return withApp(cmd.Context(), func(a *app.App) error {
+				return seed.Users(cmd.Context(), a.UserService, count, a.Logger)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&count, "count", 50, "number of users to create")
+	return cmd
+}
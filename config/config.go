@@ -0,0 +1,1096 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"example_api/encryption"
+	"example_api/secrets"
+	"example_api/tenant"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config holds every setting the application needs, loaded at startup. A configuration reload
+// loads it again, but applies only some of its settings.
+// OpenTelemetry exporters are configured separately through the standard OTEL_* variables.
+type Config struct {
+	Port            string
+	GRPCPort        string
+	TLS             TLSConfig
+	TrustedProxies  []netip.Prefix
+	HTTPServer      HTTPServerConfig
+	SecurityHeaders SecurityHeadersConfig
+	DBDriver        string
+	MongoURI        string
+	MongoPrefix     string
+	MongoPool       MongoPoolConfig
+	MongoConcerns   MongoConcernConfig
+	MongoRetry      RetryConfig
+	PostgresURI     string
+	RedisURI        string
+	CacheTTL        time.Duration
+	IdempotencyTTL  time.Duration
+	EventsToken     string
+	AdminToken      string
+	Webhooks        WebhookConfig
+	Jobs            JobConfig
+	Tasks           TaskConfig
+	Bus             BusConfig
+	SMTP            SMTPConfig
+	SMS             SMSConfig
+	Captcha         CaptchaConfig
+	AvatarMaxSize   int
+	AvatarStorage   AvatarStorageConfig
+	ImportMaxSize   int
+	BodyMaxSize     int
+	StatsCacheTTL   time.Duration
+	CountCacheTTL   time.Duration
+	FlagCacheTTL    time.Duration
+	SearchIndex     string
+	DBName          string
+	BcryptCost      int
+	PasswordPolicy  PasswordPolicyConfig
+	RequestTimeout  time.Duration
+	ShutdownTimeout time.Duration
+	LogLevel        string
+	LogFormat       string
+	PprofAddr       string
+	MigrateOnStart  bool
+	Seed            bool
+	SeedCount       int
+	Tenancy         TenancyConfig
+	RateLimit       RateLimitConfig
+	LoginThrottle   LoginThrottleConfig
+	Usage           UsageConfig
+	SecretsManager  SecretsManagerConfig
+	// Secrets holds the settings read from the secrets manager, and is nil without one
+	Secrets *secrets.Cache
+	// EncryptionKeys encrypts the emails and phone numbers of users, and is nil unless
+	// ENCRYPTION_KEYS is set
+	EncryptionKeys *encryption.Keyring
+}
+
+// HTTPServerConfig bounds how long the HTTP server waits on each connection and how large
+// request headers may be, so slow or idle clients cannot hold connections open.
+type HTTPServerConfig struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+}
+
+// SecurityHeadersConfig holds the values of the security headers that can be changed. An
+// empty value leaves its header out. DocsContentSecurityPolicy replaces ContentSecurityPolicy
+// on the Swagger UI and GraphQL playground, which load scripts and styles.
+type SecurityHeadersConfig struct {
+	StrictTransportSecurity   string
+	ContentSecurityPolicy     string
+	DocsContentSecurityPolicy string
+}
+
+// TLSConfig makes the server terminate TLS itself, serving HTTPS and HTTP/2 on Port, when
+// CertFile and KeyFile are set or, in autocert mode, when AutocertDomains lists the domains to
+// obtain certificates for. RedirectPort, when set, serves plain HTTP that redirects to HTTPS.
+type TLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	RedirectPort string
+	Autocert     AutocertConfig
+}
+
+// Enabled reports whether the server terminates TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || len(c.Autocert.Domains) > 0
+}
+
+// AutocertConfig obtains and renews certificates from an ACME certificate authority, Let's
+// Encrypt unless DirectoryURL says otherwise, for Domains alone. Certificates and the account
+// key are kept in CacheDir.
+type AutocertConfig struct {
+	Domains      []string
+	CacheDir     string
+	Email        string
+	DirectoryURL string
+}
+
+// MongoPoolConfig tunes the MongoDB driver's connection pool and network timeouts.
+type MongoPoolConfig struct {
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	ConnectTimeout         time.Duration
+	SocketTimeout          time.Duration
+	ServerSelectionTimeout time.Duration
+}
+
+// MongoConcernConfig picks where MongoDB reads are served from and how durably writes are
+// acknowledged, trading latency for consistency. Lists, searches, and counts, and inserts, can
+// be set apart from other reads and writes. Nil settings use those of MONGO_URI, or the
+// driver's defaults.
+type MongoConcernConfig struct {
+	ReadPreference     *readpref.ReadPref
+	ListReadPreference *readpref.ReadPref
+	WriteConcern       *writeconcern.WriteConcern
+	CreateWriteConcern *writeconcern.WriteConcern
+}
+
+// TenancyConfig says how requests name their tenant and which tenants exist besides the
+// default one.
+type TenancyConfig struct {
+	Header  string
+	Domain  string
+	Tenants []string
+}
+
+// RateLimitConfig sets how many API requests each client may make per Window. Plans name the
+// number of requests they allow; users get the plan RolePlans gives their role, and other users
+// and anonymous clients, told apart by address, get DefaultPlan. Requests are not limited
+// without plans.
+type RateLimitConfig struct {
+	Plans       map[string]int
+	RolePlans   map[string]string
+	DefaultPlan string
+	Window      time.Duration
+}
+
+// LoginThrottleConfig makes addresses that keep failing to sign in wait before trying again:
+// after Attempts failures they wait Delay, doubling with every further failure up to MaxDelay,
+// until Window passes without one. Sign-ins are not throttled when Attempts is 0.
+type LoginThrottleConfig struct {
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+	Window   time.Duration
+}
+
+// UsageConfig sets how often the API usage counted in memory is added to the usage store.
+// Usage is not recorded when FlushInterval is 0.
+type UsageConfig struct {
+	FlushInterval time.Duration
+}
+
+// WebhookConfig tunes outgoing webhook delivery.
+type WebhookConfig struct {
+	Timeout time.Duration
+	Retry   RetryConfig
+}
+
+// JobConfig tunes the background job queue. A job whose handler runs longer than Lease is
+// taken to have been abandoned and is run again.
+type JobConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	Lease        time.Duration
+}
+
+// TaskConfig schedules the maintenance tasks. AuditRetention is the age at which
+// CompactAuditLogs deletes audit entries.
+type TaskConfig struct {
+	ExpireIdempotencyKeys TaskSchedule
+	CompactAuditLogs      TaskSchedule
+	AuditRetention        time.Duration
+}
+
+// TaskSchedule says whether a maintenance task runs and how often.
+type TaskSchedule struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// BusConfig selects the message bus user events are published to. Driver is empty when
+// publishing is disabled.
+type BusConfig struct {
+	Driver   string
+	Kafka    KafkaConfig
+	NATS     NATSConfig
+	RabbitMQ RabbitMQConfig
+	Outbox   OutboxConfig
+}
+
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+}
+
+type NATSConfig struct {
+	URL           string
+	SubjectPrefix string
+}
+
+type RabbitMQConfig struct {
+	URL      string
+	Exchange string
+}
+
+// OutboxConfig routes bus events through a transactional outbox when Enabled.
+type OutboxConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// SMTPConfig enables outgoing email when Host is set. Locale is the language of emails whose
+// recipient has no known language.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+	Locale   string
+}
+
+// SMSConfig selects how text messages are sent. An empty Provider disables phone verification;
+// log writes messages to the log instead of sending them, for development.
+type SMSConfig struct {
+	Provider string
+	Timeout  time.Duration
+	Twilio   TwilioConfig
+}
+
+// CaptchaConfig makes anonymous signups solve a CAPTCHA of Provider, which is recaptcha,
+// hcaptcha, or turnstile, or empty to not require one. Tokens are checked with the site's Secret
+// at the provider's verification endpoint, or at VerifyURL when it is set.
+type CaptchaConfig struct {
+	Provider  string
+	Secret    string
+	VerifyURL string
+	Timeout   time.Duration
+}
+
+// TwilioConfig holds the credentials of a Twilio account and the number or messaging service
+// messages come from.
+type TwilioConfig struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+// AvatarStorageConfig selects where avatar images are kept. An empty Driver keeps them in the
+// database selected by DB_DRIVER.
+type AvatarStorageConfig struct {
+	Driver string
+	S3     S3Config
+}
+
+// S3Config points at a bucket on AWS S3 or an S3-compatible service such as MinIO. Without an
+// access key, credentials come from the standard AWS environment, credentials file, or IAM role.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+}
+
+// PasswordPolicyConfig holds the rules passwords must follow. History is how many of a user's
+// most recent passwords, the current one included, a new password must differ from; 0 allows
+// any.
+type PasswordPolicyConfig struct {
+	History int
+}
+
+// SecretsManagerConfig selects the secrets manager settings are read from before the
+// environment. An empty Provider reads them only from the environment and the .env file.
+type SecretsManagerConfig struct {
+	Provider        string
+	RefreshInterval time.Duration
+	Timeout         time.Duration
+	Vault           VaultConfig
+	AWS             AWSSecretsConfig
+}
+
+// VaultConfig points at a secret in a key/value engine of HashiCorp Vault.
+type VaultConfig struct {
+	Address   string
+	Token     string
+	Namespace string
+	Path      string
+}
+
+// AWSSecretsConfig points at a secret in AWS Secrets Manager. Credentials come from the
+// standard AWS environment, credentials file, or IAM role.
+type AWSSecretsConfig struct {
+	Region   string
+	SecretID string
+}
+
+// RetryConfig controls retries of transient failures.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Load reads the optional .env file and the process environment into a validated Config. With
+// a secrets manager, the settings its secret holds take precedence over the environment.
+func Load() (*Config, error) {
+	return load(true)
+}
+
+// LoadMock reads configuration as Load does for the mock server, which serves without a
+// database and so does not require its settings.
+func LoadMock() (*Config, error) {
+	return load(false)
+}
+
+func load(database bool) (*Config, error) {
+	if err := loadDotenv(); err != nil {
+		return nil, fmt.Errorf("failed to load .env file: %v", err)
+	}
+
+	l := &loader{}
+	// The secrets manager itself is configured from the environment alone
+	manager := l.secretsManager()
+	l.secrets = l.readSecrets(manager)
+
+	cfg := &Config{
+		Port:     l.string("PORT", "8080"),
+		GRPCPort: l.optional("GRPC_PORT", "9090"),
+		TLS: TLSConfig{
+			CertFile:     l.string("TLS_CERT_FILE", ""),
+			KeyFile:      l.string("TLS_KEY_FILE", ""),
+			RedirectPort: l.string("TLS_REDIRECT_PORT", ""),
+			Autocert: AutocertConfig{
+				Domains:      l.list("TLS_AUTOCERT_DOMAINS"),
+				CacheDir:     l.string("TLS_AUTOCERT_CACHE_DIR", "autocert"),
+				Email:        l.string("TLS_AUTOCERT_EMAIL", ""),
+				DirectoryURL: l.string("TLS_AUTOCERT_DIRECTORY_URL", ""),
+			},
+		},
+		TrustedProxies: l.prefixes("TRUSTED_PROXIES"),
+		HTTPServer: HTTPServerConfig{
+			ReadTimeout:       l.duration("HTTP_READ_TIMEOUT", time.Minute),
+			ReadHeaderTimeout: l.duration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+			WriteTimeout:      l.duration("HTTP_WRITE_TIMEOUT", time.Minute),
+			IdleTimeout:       l.duration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+			MaxHeaderBytes:    l.int("HTTP_MAX_HEADER_BYTES", 64<<10),
+		},
+		SecurityHeaders: SecurityHeadersConfig{
+			StrictTransportSecurity: l.optional("STRICT_TRANSPORT_SECURITY", "max-age=31536000"),
+			ContentSecurityPolicy:   l.optional("CONTENT_SECURITY_POLICY", "default-src 'none'; frame-ancestors 'none'"),
+			// The playground loads its scripts and styles from jsDelivr, and both pages have inline ones
+			DocsContentSecurityPolicy: l.optional("DOCS_CONTENT_SECURITY_POLICY", "default-src 'self'; script-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net; "+
+				"style-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net; font-src 'self' data: https://cdn.jsdelivr.net; img-src 'self' data: https:; "+
+				"connect-src 'self'; frame-ancestors 'none'"),
+		},
+		DBDriver: strings.ToLower(l.string("DB_DRIVER", "mongo")),
+		MongoURI: l.string("MONGO_URI", ""),
+		// Deployments sharing a database tell their collections apart by prefix
+		MongoPrefix: l.string("MONGO_COLLECTION_PREFIX", ""),
+		MongoPool: MongoPoolConfig{
+			MaxPoolSize:            l.uint64("MONGO_MAX_POOL_SIZE", 100),
+			MinPoolSize:            l.uint64("MONGO_MIN_POOL_SIZE", 5),
+			ConnectTimeout:         l.duration("MONGO_CONNECT_TIMEOUT", 10*time.Second),
+			SocketTimeout:          l.duration("MONGO_SOCKET_TIMEOUT", 10*time.Second),
+			ServerSelectionTimeout: l.duration("MONGO_SERVER_SELECTION_TIMEOUT", 5*time.Second),
+		},
+		MongoConcerns: MongoConcernConfig{
+			ReadPreference:     l.readPreference("MONGO_READ_PREFERENCE"),
+			ListReadPreference: l.readPreference("MONGO_LIST_READ_PREFERENCE"),
+			WriteConcern:       l.writeConcern("MONGO_WRITE_CONCERN"),
+			CreateWriteConcern: l.writeConcern("MONGO_CREATE_WRITE_CONCERN"),
+		},
+		MongoRetry: RetryConfig{
+			MaxAttempts: l.int("MONGO_RETRY_MAX_ATTEMPTS", 3),
+			BaseDelay:   l.duration("MONGO_RETRY_BASE_DELAY", 100*time.Millisecond),
+			MaxDelay:    l.duration("MONGO_RETRY_MAX_DELAY", 2*time.Second),
+		},
+		PostgresURI:     l.string("POSTGRES_URI", ""),
+		RedisURI:        l.string("REDIS_URI", ""),
+		CacheTTL:        l.duration("CACHE_TTL", 5*time.Minute),
+		IdempotencyTTL:  l.duration("IDEMPOTENCY_TTL", 24*time.Hour),
+		EventsToken:     l.string("EVENTS_TOKEN", ""),
+		AdminToken:      l.string("ADMIN_TOKEN", ""),
+		DBName:          l.string("DB_NAME", "example-db"),
+		BcryptCost:      l.int("BCRYPT_COST", bcrypt.DefaultCost),
+		PasswordPolicy:  PasswordPolicyConfig{History: l.int("PASSWORD_HISTORY", 5)},
+		EncryptionKeys:  l.keyring("ENCRYPTION_KEYS"),
+		RequestTimeout:  l.duration("REQUEST_TIMEOUT", 5*time.Second),
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		LogLevel:        strings.ToLower(l.string("LOG_LEVEL", "info")),
+		LogFormat:       strings.ToLower(l.string("LOG_FORMAT", "json")),
+		PprofAddr:       l.string("PPROF_ADDR", ""),
+		MigrateOnStart:  l.bool("MIGRATE_ON_START", true),
+		Seed:            l.bool("SEED", false),
+		SeedCount:       l.int("SEED_COUNT", 50),
+		Webhooks: WebhookConfig{
+			Timeout: l.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+			Retry: RetryConfig{
+				MaxAttempts: l.int("WEBHOOK_MAX_ATTEMPTS", 5),
+				BaseDelay:   l.duration("WEBHOOK_RETRY_BASE_DELAY", time.Second),
+				MaxDelay:    l.duration("WEBHOOK_RETRY_MAX_DELAY", time.Minute),
+			},
+		},
+		Jobs: JobConfig{
+			// WEBHOOK_WORKERS sized the webhook worker pool before deliveries moved onto the queue
+			Workers:      l.int("JOB_WORKERS", l.int("WEBHOOK_WORKERS", 4)),
+			PollInterval: l.duration("JOB_POLL_INTERVAL", time.Second),
+			Lease:        l.duration("JOB_LEASE", 5*time.Minute),
+		},
+		Tasks: TaskConfig{
+			ExpireIdempotencyKeys: TaskSchedule{
+				Enabled:  l.bool("TASK_EXPIRE_IDEMPOTENCY_KEYS", true),
+				Interval: l.duration("TASK_EXPIRE_IDEMPOTENCY_KEYS_INTERVAL", time.Hour),
+			},
+			CompactAuditLogs: TaskSchedule{
+				Enabled:  l.bool("TASK_COMPACT_AUDIT_LOGS", false),
+				Interval: l.duration("TASK_COMPACT_AUDIT_LOGS_INTERVAL", 24*time.Hour),
+			},
+			AuditRetention: l.duration("AUDIT_RETENTION", 365*24*time.Hour),
+		},
+		Bus: BusConfig{
+			Driver: strings.ToLower(l.string("EVENT_BUS", "")),
+			Kafka: KafkaConfig{
+				Brokers: l.list("KAFKA_BROKERS"),
+				Topic:   l.string("KAFKA_TOPIC", "user-events"),
+			},
+			NATS: NATSConfig{
+				URL:           l.string("NATS_URL", ""),
+				SubjectPrefix: l.string("NATS_SUBJECT_PREFIX", "user-events"),
+			},
+			RabbitMQ: RabbitMQConfig{
+				URL:      l.string("RABBITMQ_URL", ""),
+				Exchange: l.string("RABBITMQ_EXCHANGE", "user-events"),
+			},
+			Outbox: OutboxConfig{
+				Enabled:      l.bool("OUTBOX_ENABLED", false),
+				PollInterval: l.duration("OUTBOX_POLL_INTERVAL", time.Second),
+				BatchSize:    l.int("OUTBOX_BATCH_SIZE", 100),
+			},
+		},
+		SMTP: SMTPConfig{
+			Host:     l.string("SMTP_HOST", ""),
+			Port:     l.string("SMTP_PORT", "587"),
+			Username: l.string("SMTP_USERNAME", ""),
+			Password: l.string("SMTP_PASSWORD", ""),
+			From:     l.string("SMTP_FROM", ""),
+			Timeout:  l.duration("SMTP_TIMEOUT", 10*time.Second),
+			Locale:   strings.ToLower(l.string("MAIL_LOCALE", "en")),
+		},
+		SMS: SMSConfig{
+			Provider: strings.ToLower(l.string("SMS_PROVIDER", "")),
+			Timeout:  l.duration("SMS_TIMEOUT", 10*time.Second),
+			Twilio: TwilioConfig{
+				AccountSID: l.string("TWILIO_ACCOUNT_SID", ""),
+				AuthToken:  l.string("TWILIO_AUTH_TOKEN", ""),
+				From:       l.string("TWILIO_FROM", ""),
+			},
+		},
+		Captcha: CaptchaConfig{
+			Provider:  strings.ToLower(l.string("CAPTCHA_PROVIDER", "")),
+			Secret:    l.string("CAPTCHA_SECRET", ""),
+			VerifyURL: l.string("CAPTCHA_VERIFY_URL", ""),
+			Timeout:   l.duration("CAPTCHA_TIMEOUT", 5*time.Second),
+		},
+		AvatarMaxSize: l.int("AVATAR_MAX_SIZE", 5<<20),
+		AvatarStorage: AvatarStorageConfig{
+			Driver: strings.ToLower(l.string("AVATAR_STORAGE", "")),
+			S3: S3Config{
+				Endpoint:        l.string("S3_ENDPOINT", "s3.amazonaws.com"),
+				Region:          l.string("S3_REGION", ""),
+				Bucket:          l.string("S3_BUCKET", ""),
+				Prefix:          l.optional("S3_PREFIX", "avatars/"),
+				AccessKeyID:     l.string("S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: l.string("S3_SECRET_ACCESS_KEY", ""),
+				UseSSL:          l.bool("S3_USE_SSL", true),
+			},
+		},
+		ImportMaxSize: l.int("IMPORT_MAX_SIZE", 10<<20),
+		BodyMaxSize:   l.int("BODY_MAX_SIZE", 1<<20),
+		StatsCacheTTL: l.duration("STATS_CACHE_TTL", time.Minute),
+		CountCacheTTL: l.duration("USER_COUNT_CACHE_TTL", time.Minute),
+		FlagCacheTTL:  l.duration("FEATURE_FLAGS_CACHE_TTL", 30*time.Second),
+		SearchIndex:   l.string("MONGO_SEARCH_INDEX", ""),
+		Tenancy: TenancyConfig{
+			Header:  l.string("TENANT_HEADER", "X-Tenant-ID"),
+			Domain:  l.string("TENANT_DOMAIN", ""),
+			Tenants: l.list("TENANTS"),
+		},
+		RateLimit: RateLimitConfig{
+			RolePlans:   l.pairs("RATE_LIMIT_ROLE_PLANS"),
+			DefaultPlan: l.string("RATE_LIMIT_DEFAULT_PLAN", "free"),
+			Window:      l.duration("RATE_LIMIT_WINDOW", time.Minute),
+		},
+		LoginThrottle: LoginThrottleConfig{
+			Attempts: l.int("LOGIN_THROTTLE_ATTEMPTS", 5),
+			Delay:    l.duration("LOGIN_THROTTLE_DELAY", time.Second),
+			MaxDelay: l.duration("LOGIN_THROTTLE_MAX_DELAY", 15*time.Minute),
+			Window:   l.duration("LOGIN_THROTTLE_WINDOW", 15*time.Minute),
+		},
+		Usage: UsageConfig{
+			FlushInterval: l.duration("USAGE_FLUSH_INTERVAL", time.Minute),
+		},
+		SecretsManager: manager,
+		Secrets:        l.secrets,
+	}
+	for _, id := range cfg.Tenancy.Tenants {
+		if !tenant.Valid(id) {
+			l.fail(fmt.Sprintf("TENANTS must hold lowercase letters, digits, and hyphens, got %q", id))
+		}
+	}
+	if plans := l.pairs("RATE_LIMIT_PLANS"); len(plans) > 0 {
+		cfg.RateLimit.Plans = make(map[string]int, len(plans))
+		for plan, value := range plans {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 {
+				l.fail(fmt.Sprintf("RATE_LIMIT_PLANS must give each plan a positive number of requests, got %q for %s", value, plan))
+			}
+			cfg.RateLimit.Plans[plan] = limit
+		}
+		if _, ok := plans[cfg.RateLimit.DefaultPlan]; !ok {
+			l.fail(fmt.Sprintf("RATE_LIMIT_DEFAULT_PLAN must name one of RATE_LIMIT_PLANS, got %q", cfg.RateLimit.DefaultPlan))
+		}
+		for role, plan := range cfg.RateLimit.RolePlans {
+			if _, ok := plans[plan]; !ok {
+				l.fail(fmt.Sprintf("RATE_LIMIT_ROLE_PLANS must give roles one of RATE_LIMIT_PLANS, got %q for %s", plan, role))
+			}
+		}
+	} else if len(cfg.RateLimit.RolePlans) > 0 {
+		l.fail("RATE_LIMIT_ROLE_PLANS requires RATE_LIMIT_PLANS")
+	}
+
+	if cfg.HTTPServer.ReadHeaderTimeout > cfg.HTTPServer.ReadTimeout {
+		l.fail("HTTP_READ_HEADER_TIMEOUT must not exceed HTTP_READ_TIMEOUT")
+	}
+	if cfg.HTTPServer.WriteTimeout <= cfg.RequestTimeout {
+		l.fail("HTTP_WRITE_TIMEOUT must be longer than REQUEST_TIMEOUT")
+	}
+	if cfg.HTTPServer.MaxHeaderBytes < 4<<10 {
+		l.fail("HTTP_MAX_HEADER_BYTES must be at least 4096")
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		l.fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLS.CertFile != "" && len(cfg.TLS.Autocert.Domains) > 0 {
+		l.fail("TLS_AUTOCERT_DOMAINS cannot be set together with TLS_CERT_FILE")
+	}
+	for i, domain := range cfg.TLS.Autocert.Domains {
+		cfg.TLS.Autocert.Domains[i] = strings.ToLower(domain)
+		if strings.ContainsAny(domain, "*/:") {
+			l.fail(fmt.Sprintf("TLS_AUTOCERT_DOMAINS must hold plain host names, got %q", domain))
+		}
+	}
+	if cfg.TLS.RedirectPort != "" {
+		if !cfg.TLS.Enabled() {
+			l.fail("TLS_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS")
+		}
+		if cfg.TLS.RedirectPort == cfg.Port {
+			l.fail("TLS_REDIRECT_PORT must differ from PORT")
+		}
+	}
+
+	// The mock server has no database whose settings could be missing
+	switch {
+	case !database:
+	case cfg.DBDriver == "mongo":
+		if cfg.MongoURI == "" {
+			l.fail("MONGO_URI is required when DB_DRIVER is mongo")
+		}
+		if cfg.DBName == "" || len(cfg.DBName) > 63 || strings.ContainsAny(cfg.DBName, "/\\. \"$*<>:|?") {
+			l.fail("DB_NAME must be at most 63 characters without spaces or any of /\\.\"$*<>:|?")
+		}
+		if strings.ContainsFunc(cfg.MongoPrefix, func(r rune) bool { return !validPrefixRune(r) }) || strings.HasPrefix(cfg.MongoPrefix, "system.") {
+			l.fail("MONGO_COLLECTION_PREFIX must hold letters, digits, dots, hyphens, and underscores, and not start with system.")
+		}
+	case cfg.DBDriver == "postgres":
+		if cfg.PostgresURI == "" {
+			l.fail("POSTGRES_URI is required when DB_DRIVER is postgres")
+		}
+	case cfg.DBDriver == "memory":
+	default:
+		l.fail("DB_DRIVER must be mongo, postgres, or memory")
+	}
+	// Setting only KAFKA_BROKERS predates EVENT_BUS and still selects Kafka
+	if cfg.Bus.Driver == "" && len(cfg.Bus.Kafka.Brokers) > 0 {
+		cfg.Bus.Driver = "kafka"
+	}
+	switch cfg.Bus.Driver {
+	case "":
+	case "kafka":
+		if len(cfg.Bus.Kafka.Brokers) == 0 {
+			l.fail("KAFKA_BROKERS is required when EVENT_BUS is kafka")
+		}
+	case "nats":
+		if cfg.Bus.NATS.URL == "" {
+			l.fail("NATS_URL is required when EVENT_BUS is nats")
+		}
+	case "rabbitmq":
+		if cfg.Bus.RabbitMQ.URL == "" {
+			l.fail("RABBITMQ_URL is required when EVENT_BUS is rabbitmq")
+		}
+	default:
+		l.fail("EVENT_BUS must be kafka, nats, or rabbitmq")
+	}
+	if cfg.Bus.Outbox.Enabled {
+		if cfg.DBDriver != "mongo" {
+			l.fail("OUTBOX_ENABLED requires DB_DRIVER mongo")
+		}
+		if cfg.Bus.Driver == "" {
+			l.fail("OUTBOX_ENABLED requires EVENT_BUS")
+		}
+		if cfg.Bus.Outbox.PollInterval <= 0 {
+			l.fail("OUTBOX_POLL_INTERVAL must be positive")
+		}
+		if cfg.Bus.Outbox.BatchSize < 1 {
+			l.fail("OUTBOX_BATCH_SIZE must be at least 1")
+		}
+	}
+	if cfg.SMTP.Host != "" {
+		if cfg.SMTP.From == "" {
+			l.fail("SMTP_FROM is required when SMTP_HOST is set")
+		}
+		if cfg.SMTP.Timeout <= 0 {
+			l.fail("SMTP_TIMEOUT must be positive")
+		}
+	}
+	switch cfg.SMS.Provider {
+	case "", "log":
+	case "twilio":
+		if cfg.SMS.Twilio.AccountSID == "" || cfg.SMS.Twilio.AuthToken == "" || cfg.SMS.Twilio.From == "" {
+			l.fail("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, and TWILIO_FROM are required when SMS_PROVIDER is twilio")
+		}
+		if cfg.SMS.Timeout <= 0 {
+			l.fail("SMS_TIMEOUT must be positive")
+		}
+	default:
+		l.fail("SMS_PROVIDER must be empty, log, or twilio")
+	}
+	switch cfg.Captcha.Provider {
+	case "":
+	case "recaptcha", "hcaptcha", "turnstile":
+		if cfg.Captcha.Secret == "" {
+			l.fail("CAPTCHA_SECRET is required when CAPTCHA_PROVIDER is set")
+		}
+		if cfg.Captcha.Timeout <= 0 {
+			l.fail("CAPTCHA_TIMEOUT must be positive")
+		}
+	default:
+		l.fail(fmt.Sprintf("CAPTCHA_PROVIDER must be empty, recaptcha, hcaptcha, or turnstile, got %q", cfg.Captcha.Provider))
+	}
+	switch cfg.AvatarStorage.Driver {
+	case "":
+	case "s3":
+		if cfg.AvatarStorage.S3.Bucket == "" {
+			l.fail("S3_BUCKET is required when AVATAR_STORAGE is s3")
+		}
+		if (cfg.AvatarStorage.S3.AccessKeyID == "") != (cfg.AvatarStorage.S3.SecretAccessKey == "") {
+			l.fail("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY must be set together")
+		}
+	default:
+		l.fail("AVATAR_STORAGE must be empty or s3")
+	}
+	if cfg.MongoPool.MaxPoolSize == 0 {
+		l.fail("MONGO_MAX_POOL_SIZE must be at least 1")
+	}
+	if cfg.MongoPool.MinPoolSize > cfg.MongoPool.MaxPoolSize {
+		l.fail("MONGO_MIN_POOL_SIZE must not exceed MONGO_MAX_POOL_SIZE")
+	}
+	if cfg.MongoRetry.MaxAttempts < 1 {
+		l.fail("MONGO_RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.Webhooks.Timeout <= 0 {
+		l.fail("WEBHOOK_TIMEOUT must be positive")
+	}
+	if cfg.Webhooks.Retry.MaxAttempts < 1 {
+		l.fail("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.Jobs.Workers < 1 {
+		l.fail("JOB_WORKERS must be at least 1")
+	}
+	if cfg.Jobs.PollInterval <= 0 {
+		l.fail("JOB_POLL_INTERVAL must be positive")
+	}
+	if cfg.Jobs.Lease <= 0 {
+		l.fail("JOB_LEASE must be positive")
+	}
+	if cfg.Webhooks.Timeout >= cfg.Jobs.Lease {
+		l.fail("WEBHOOK_TIMEOUT must be shorter than JOB_LEASE")
+	}
+	if cfg.Tasks.ExpireIdempotencyKeys.Interval < time.Minute {
+		l.fail("TASK_EXPIRE_IDEMPOTENCY_KEYS_INTERVAL must be at least 1m")
+	}
+	if cfg.Tasks.CompactAuditLogs.Interval < time.Minute {
+		l.fail("TASK_COMPACT_AUDIT_LOGS_INTERVAL must be at least 1m")
+	}
+	if cfg.Tasks.AuditRetention <= 0 {
+		l.fail("AUDIT_RETENTION must be positive")
+	}
+	if cfg.FlagCacheTTL < 0 {
+		l.fail("FEATURE_FLAGS_CACHE_TTL must not be negative")
+	}
+	if throttle := cfg.LoginThrottle; throttle.Attempts < 0 {
+		l.fail("LOGIN_THROTTLE_ATTEMPTS must not be negative")
+	} else if throttle.Attempts > 0 {
+		if throttle.Delay <= 0 {
+			l.fail("LOGIN_THROTTLE_DELAY must be positive")
+		}
+		if throttle.MaxDelay < throttle.Delay {
+			l.fail("LOGIN_THROTTLE_MAX_DELAY must not be shorter than LOGIN_THROTTLE_DELAY")
+		}
+		if throttle.Window <= 0 {
+			l.fail("LOGIN_THROTTLE_WINDOW must be positive")
+		}
+	}
+	if cfg.Usage.FlushInterval < 0 {
+		l.fail("USAGE_FLUSH_INTERVAL must not be negative")
+	}
+	if cfg.AvatarMaxSize < 1 {
+		l.fail("AVATAR_MAX_SIZE must be at least 1")
+	}
+	if cfg.ImportMaxSize < 1 {
+		l.fail("IMPORT_MAX_SIZE must be at least 1")
+	}
+	if cfg.BodyMaxSize < 1 {
+		l.fail("BODY_MAX_SIZE must be at least 1")
+	}
+	if cfg.IdempotencyTTL <= 0 {
+		l.fail("IDEMPOTENCY_TTL must be positive")
+	}
+	if cfg.SeedCount < 1 {
+		l.fail("SEED_COUNT must be at least 1")
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		l.fail(fmt.Sprintf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if cfg.PasswordPolicy.History < 0 || cfg.PasswordPolicy.History > 24 {
+		l.fail("PASSWORD_HISTORY must be between 0 and 24")
+	}
+	switch cfg.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		l.fail("LOG_LEVEL must be one of debug, info, warn, error")
+	}
+	switch cfg.LogFormat {
+	case "json", "text":
+	default:
+		l.fail("LOG_FORMAT must be json or text")
+	}
+
+	if err := errors.Join(l.errs...); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+var (
+	// inherited holds the variables the process was started with, which .env does not override
+	inherited     map[string]bool
+	inheritedOnce sync.Once
+	// dotenv holds the variables last read from .env
+	dotenv map[string]bool
+)
+
+// loadDotenv sets the variables of the optional .env file in the environment, except those the
+// process was started with. Loading it again applies the changes made to the file since, which
+// unsets the variables it no longer holds.
+func loadDotenv() error {
+	inheritedOnce.Do(func() {
+		inherited = map[string]bool{}
+		for _, variable := range os.Environ() {
+			name, _, _ := strings.Cut(variable, "=")
+			inherited[name] = true
+		}
+	})
+	values, err := godotenv.Read()
+	if errors.Is(err, fs.ErrNotExist) {
+		values, err = map[string]string{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	for name := range dotenv {
+		if _, ok := values[name]; !ok {
+			os.Unsetenv(name)
+		}
+	}
+	dotenv = map[string]bool{}
+	for name, value := range values {
+		if !inherited[name] {
+			os.Setenv(name, value)
+			dotenv[name] = true
+		}
+	}
+	return nil
+}
+
+// validPrefixRune reports whether r may appear in a collection prefix.
+func validPrefixRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_'
+}
+
+// secretsManager reads the settings of the secrets manager.
+func (l *loader) secretsManager() SecretsManagerConfig {
+	manager := SecretsManagerConfig{
+		Provider:        strings.ToLower(l.string("SECRETS_PROVIDER", "")),
+		RefreshInterval: l.duration("SECRETS_REFRESH_INTERVAL", 5*time.Minute),
+		Timeout:         l.duration("SECRETS_TIMEOUT", 10*time.Second),
+		Vault: VaultConfig{
+			Address:   l.string("VAULT_ADDR", ""),
+			Token:     l.string("VAULT_TOKEN", ""),
+			Namespace: l.string("VAULT_NAMESPACE", ""),
+			Path:      l.string("VAULT_SECRET_PATH", ""),
+		},
+		AWS: AWSSecretsConfig{
+			Region:   l.string("AWS_REGION", l.string("AWS_DEFAULT_REGION", "")),
+			SecretID: l.string("AWS_SECRET_ID", ""),
+		},
+	}
+	if manager.RefreshInterval < time.Minute {
+		l.fail("SECRETS_REFRESH_INTERVAL must be at least 1m")
+	}
+	return manager
+}
+
+// readSecrets reads the secret of manager, returning nil without a secrets manager or when it
+// cannot be read.
+func (l *loader) readSecrets(manager SecretsManagerConfig) *secrets.Cache {
+	var provider secrets.Provider
+	switch manager.Provider {
+	case "":
+		return nil
+	case "vault":
+		if manager.Vault.Address == "" || manager.Vault.Token == "" || manager.Vault.Path == "" {
+			l.fail("VAULT_ADDR, VAULT_TOKEN, and VAULT_SECRET_PATH are required when SECRETS_PROVIDER is vault")
+			return nil
+		}
+		provider = secrets.NewVault(secrets.VaultOptions{
+			Address:   manager.Vault.Address,
+			Token:     manager.Vault.Token,
+			Namespace: manager.Vault.Namespace,
+			Path:      manager.Vault.Path,
+		})
+	case "aws":
+		if manager.AWS.Region == "" || manager.AWS.SecretID == "" {
+			l.fail("AWS_REGION and AWS_SECRET_ID are required when SECRETS_PROVIDER is aws")
+			return nil
+		}
+		provider = secrets.NewAWS(secrets.AWSOptions{
+			Region:   manager.AWS.Region,
+			SecretID: manager.AWS.SecretID,
+		})
+	default:
+		l.fail("SECRETS_PROVIDER must be empty, vault, or aws")
+		return nil
+	}
+
+	cache := secrets.NewCache(provider, manager.RefreshInterval, manager.Timeout)
+	if err := cache.Refresh(context.Background()); err != nil {
+		l.fail(fmt.Sprintf("failed to read secrets from %s: %v", manager.Provider, err))
+		return nil
+	}
+	return cache
+}
+
+// loader reads typed values from the secrets manager, if any, and the environment, collecting
+// every problem instead of stopping at the first.
+type loader struct {
+	errs    []error
+	secrets *secrets.Cache
+}
+
+func (l *loader) fail(msg string) {
+	l.errs = append(l.errs, errors.New(msg))
+}
+
+// lookup returns the setting with the given name and whether it is set, preferring the secret.
+func (l *loader) lookup(name string) (string, bool) {
+	if l.secrets != nil {
+		if value, ok := l.secrets.Lookup(name); ok {
+			return value, true
+		}
+	}
+	return os.LookupEnv(name)
+}
+
+// get returns the setting with the given name, or an empty string if it is not set.
+func (l *loader) get(name string) string {
+	value, _ := l.lookup(name)
+	return value
+}
+
+func (l *loader) string(name, def string) string {
+	if value := l.get(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// optional is string for settings that are disabled by setting them to an empty value.
+func (l *loader) optional(name, def string) string {
+	if value, ok := l.lookup(name); ok {
+		return value
+	}
+	return def
+}
+
+// list splits a comma-separated variable into its non-empty, trimmed entries.
+func (l *loader) list(name string) []string {
+	var values []string
+	for _, value := range strings.Split(l.get(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// prefixes parses a comma-separated list of CIDR ranges, in which a single address stands for
+// a range holding only itself.
+func (l *loader) prefixes(name string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, value := range l.list(name) {
+		if addr, err := netip.ParseAddr(value); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			l.fail(fmt.Sprintf("%s must hold IP addresses and CIDR ranges, got %q", name, value))
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// pairs parses a comma-separated list of name:value pairs, returning nil when it is empty.
+func (l *loader) pairs(name string) map[string]string {
+	var pairs map[string]string
+	for _, entry := range l.list(name) {
+		key, value, ok := strings.Cut(entry, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			l.fail(fmt.Sprintf("%s must hold name:value pairs, got %q", name, entry))
+			continue
+		}
+		if pairs == nil {
+			pairs = map[string]string{}
+		}
+		pairs[key] = value
+	}
+	return pairs
+}
+
+// keyring parses a comma-separated list of encryption keys, the first of which is current,
+// returning nil when it is empty.
+func (l *loader) keyring(name string) *encryption.Keyring {
+	var keys []encryption.Key
+	for _, value := range l.list(name) {
+		key, err := encryption.ParseKey(value)
+		if err != nil {
+			l.fail(fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	keyring, err := encryption.NewKeyring(keys)
+	if err != nil {
+		l.fail(fmt.Sprintf("%s: %v", name, err))
+		return nil
+	}
+	return keyring
+}
+
+// readPreference reads a read preference mode such as secondaryPreferred, returning nil when
+// the setting is not set.
+func (l *loader) readPreference(name string) *readpref.ReadPref {
+	value := l.get(name)
+	if value == "" {
+		return nil
+	}
+	mode, err := readpref.ModeFromString(value)
+	if err != nil {
+		l.fail(fmt.Sprintf("%s must be primary, primaryPreferred, secondary, secondaryPreferred, or nearest, got %q", name, value))
+		return nil
+	}
+	pref, _ := readpref.New(mode)
+	return pref
+}
+
+// writeConcern reads a write concern, majority or the number of members that must acknowledge
+// a write, returning nil when the setting is not set.
+func (l *loader) writeConcern(name string) *writeconcern.WriteConcern {
+	value := l.get(name)
+	if value == "" {
+		return nil
+	}
+	if value == "majority" {
+		return writeconcern.Majority()
+	}
+	members, err := strconv.Atoi(value)
+	if err != nil || members < 1 {
+		l.fail(fmt.Sprintf("%s must be majority or a positive number of members, got %q", name, value))
+		return nil
+	}
+	return &writeconcern.WriteConcern{W: members}
+}
+
+func (l *loader) bool(name string, def bool) bool {
+	value := l.get(name)
+	if value == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail(fmt.Sprintf("%s must be true or false, got %q", name, value))
+		return def
+	}
+	return b
+}
+
+func (l *loader) int(name string, def int) int {
+	value := l.get(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail(fmt.Sprintf("%s must be an integer, got %q", name, value))
+		return def
+	}
+	return n
+}
+
+func (l *loader) uint64(name string, def uint64) uint64 {
+	value := l.get(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		l.fail(fmt.Sprintf("%s must be a non-negative integer, got %q", name, value))
+		return def
+	}
+	return n
+}
+
+func (l *loader) duration(name string, def time.Duration) time.Duration {
+	value := l.get(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		l.fail(fmt.Sprintf("%s must be a positive duration such as 5s, got %q", name, value))
+		return def
+	}
+	return d
+}
@@ -0,0 +1,131 @@
+//go:build ignore
+
+// This program generates openapi.json from the swag annotations of main.go and the handlers:
+// swag writes them out as a Swagger 2.0 document, which is converted to OpenAPI 3.0 and
+// upgraded to 3.1. Run it with go generate ./docs.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func main() {
+	dir, err := os.MkdirTemp("", "swagger")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	swag := exec.Command("go", "run", "github.com/swaggo/swag/cmd/swag@v1.16.4", "init", "--quiet", "--output", dir, "--outputTypes", "json")
+	swag.Dir = ".."
+	swag.Stdout, swag.Stderr = os.Stdout, os.Stderr
+	if err := swag.Run(); err != nil {
+		log.Fatalf("swag failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "swagger.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var swagger openapi2.T
+	if err := json.Unmarshal(data, &swagger); err != nil {
+		log.Fatalf("invalid Swagger document: %v", err)
+	}
+	doc, err := openapi2conv.ToV3(&swagger)
+	if err != nil {
+		log.Fatalf("failed to convert to OpenAPI 3: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		log.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	if err := upgrade(doc); err != nil {
+		log.Fatalf("failed to upgrade to OpenAPI 3.1: %v", err)
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("openapi.json", append(out, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// upgrade turns doc from OpenAPI 3.0 into 3.1. Of the differences between them, swag only
+// produces nullable schemas, which 3.1 writes as a type that includes null.
+func upgrade(doc *openapi3.T) error {
+	doc.OpenAPI = "3.1.0"
+	var err error
+	visit := func(ref *openapi3.SchemaRef) {
+		if err == nil {
+			err = upgradeSchema(ref)
+		}
+	}
+	for _, schema := range doc.Components.Schemas {
+		visit(schema)
+	}
+	for _, item := range doc.Paths.Map() {
+		for _, operation := range item.Operations() {
+			for _, param := range operation.Parameters {
+				visit(param.Value.Schema)
+			}
+			if operation.RequestBody != nil {
+				for _, media := range operation.RequestBody.Value.Content {
+					visit(media.Schema)
+				}
+			}
+			for _, response := range operation.Responses.Map() {
+				for _, media := range response.Value.Content {
+					visit(media.Schema)
+				}
+				for _, header := range response.Value.Headers {
+					visit(header.Value.Schema)
+				}
+			}
+		}
+	}
+	return err
+}
+
+// upgradeSchema upgrades the schema ref holds and the schemas nested in it. References are
+// upgraded where they point.
+func upgradeSchema(ref *openapi3.SchemaRef) error {
+	if ref == nil || ref.Ref != "" {
+		return nil
+	}
+	schema := ref.Value
+	if schema.ExclusiveMin || schema.ExclusiveMax {
+		return fmt.Errorf("exclusive bounds are not supported")
+	}
+	if schema.Nullable {
+		schema.Nullable = false
+		if schema.Type != nil {
+			types := append(schema.Type.Slice(), openapi3.TypeNull)
+			schema.Type = (*openapi3.Types)(&types)
+		}
+	}
+
+	nested := []*openapi3.SchemaRef{schema.Items, schema.Not, schema.AdditionalProperties.Schema}
+	for _, property := range schema.Properties {
+		nested = append(nested, property)
+	}
+	nested = append(nested, schema.AllOf...)
+	nested = append(nested, schema.AnyOf...)
+	nested = append(nested, schema.OneOf...)
+	for _, child := range nested {
+		if err := upgradeSchema(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
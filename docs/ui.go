@@ -0,0 +1,31 @@
+package docs
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// ui is a Swagger UI page. Swagger UI renders OpenAPI 3.1 from version 5 on, which is loaded
+// from jsDelivr.
+var ui = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Example API</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({url: {{.}}, dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`))
+
+// UI serves Swagger UI for the OpenAPI document at specURL.
+func UI(specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		ui.Execute(w, specURL)
+	})
+}
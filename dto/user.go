@@ -0,0 +1,213 @@
+// Package dto holds the user types the HTTP API reads from and writes to clients, and maps them
+// to and from models. Only the fields listed here cross the API, so fields added to models for
+// persistence or internal use are neither rendered nor settable by clients until they are added
+// here too.
+package dto
+
+import (
+	"encoding/json"
+	models "example_api/models"
+	"time"
+)
+
+// User is a user as API responses render it. The password hash is never rendered.
+type User struct {
+	ID string `json:"id"`
+	UserAttributes
+}
+
+// UserAttributes are the fields of a user other than its ID, which JSON:API documents render
+// as the attributes of the resource.
+type UserAttributes struct {
+	Email     string     `json:"email"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Phone     string     `json:"phone,omitempty"`
+	Addresses []Address  `json:"addresses,omitempty"`
+	Role      string     `json:"role"`
+	JoinDate  time.Time  `json:"joinDate"`
+	Version   int64      `json:"version"`
+	ErasedAt  *time.Time `json:"erasedAt,omitempty"`
+
+	PhoneVerifiedAt *time.Time      `json:"phoneVerifiedAt,omitempty"`
+	Preferences     *Preferences    `json:"preferences,omitempty"`
+	Metadata        models.Metadata `json:"metadata,omitempty"`
+}
+
+// Address is a postal address of a user as responses render it.
+type Address struct {
+	ID          string `json:"id"`
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	PostalCode  string `json:"postalCode,omitempty"`
+	CountryCode string `json:"countryCode"`
+}
+
+// Preferences are the application settings of a user, as clients read and write them.
+type Preferences struct {
+	Theme         string                  `json:"theme,omitempty"`
+	Locale        string                  `json:"locale,omitempty"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// NotificationPreferences are the channels a user has opted in to being notified on.
+type NotificationPreferences struct {
+	Email bool `json:"email"`
+	SMS   bool `json:"sms"`
+	Push  bool `json:"push"`
+}
+
+// NewUser maps user to its response.
+func NewUser(user *models.User) User {
+	var addresses []Address
+	for _, address := range user.Addresses {
+		addresses = append(addresses, Address{
+			ID:          address.Id.Hex(),
+			Street:      address.Street,
+			City:        address.City,
+			PostalCode:  address.PostalCode,
+			CountryCode: address.CountryCode,
+		})
+	}
+	return User{
+		ID: user.Id.Hex(),
+		UserAttributes: UserAttributes{
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Phone:     user.Phone,
+			Addresses: addresses,
+			Role:      user.Role,
+			JoinDate:  user.JoinDate,
+			Version:   user.Version,
+			ErasedAt:  user.ErasedAt,
+
+			PhoneVerifiedAt: user.PhoneVerifiedAt,
+			Preferences:     newPreferences(user.Preferences),
+			Metadata:        user.Metadata,
+		},
+	}
+}
+
+func newPreferences(preferences *models.Preferences) *Preferences {
+	if preferences == nil {
+		return nil
+	}
+	return &Preferences{
+		Theme:  preferences.Theme,
+		Locale: preferences.Locale,
+		Notifications: NotificationPreferences{
+			Email: preferences.Notifications.Email,
+			SMS:   preferences.Notifications.SMS,
+			Push:  preferences.Notifications.Push,
+		},
+	}
+}
+
+// GetUsersRequest names the users a batch get looks up, by ID.
+type GetUsersRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100"`
+}
+
+// UserLookup is the result of a batch get for one of the IDs it was given: the user, if one
+// has the ID.
+type UserLookup struct {
+	ID    string `json:"id"`
+	Found bool   `json:"found"`
+	User  *User  `json:"user,omitempty"`
+}
+
+// CreateUserRequest is a new user as clients submit it. The API assigns the ID, role, join
+// date, and version, and only a confirmed code verifies the phone number.
+type CreateUserRequest struct {
+	Email       string           `json:"email"`
+	Password    string           `json:"password"`
+	FirstName   string           `json:"firstName"`
+	LastName    string           `json:"lastName"`
+	Phone       string           `json:"phone,omitempty"`
+	Addresses   []AddressRequest `json:"addresses,omitempty"`
+	Preferences *Preferences     `json:"preferences,omitempty"`
+	Metadata    models.Metadata  `json:"metadata,omitempty"`
+}
+
+// AddressRequest is a postal address as clients submit it. The API assigns its ID.
+type AddressRequest struct {
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	PostalCode  string `json:"postalCode,omitempty"`
+	CountryCode string `json:"countryCode"`
+}
+
+// User maps the request to a new user, leaving every field the API assigns empty.
+func (r *CreateUserRequest) User() *models.User {
+	user := &models.User{
+		Email:     r.Email,
+		Password:  r.Password,
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Phone:     r.Phone,
+		Metadata:  r.Metadata,
+	}
+	for _, address := range r.Addresses {
+		user.Addresses = append(user.Addresses, address.Address())
+	}
+	if r.Preferences != nil {
+		user.Preferences = &models.Preferences{
+			Theme:  r.Preferences.Theme,
+			Locale: r.Preferences.Locale,
+			Notifications: models.NotificationPreferences{
+				Email: r.Preferences.Notifications.Email,
+				SMS:   r.Preferences.Notifications.SMS,
+				Push:  r.Preferences.Notifications.Push,
+			},
+		}
+	}
+	return user
+}
+
+// Address maps the request to an address without an ID.
+func (r AddressRequest) Address() models.Address {
+	return models.Address{
+		Street:      r.Street,
+		City:        r.City,
+		PostalCode:  r.PostalCode,
+		CountryCode: r.CountryCode,
+	}
+}
+
+// UpdateUserRequest is a partial update of a user as clients submit it. Fields left out are
+// not changed. Metadata is kept as sent, since null and an object mean different things to its
+// merge. Version is the version the update is based on.
+type UpdateUserRequest struct {
+	Email     *string         `json:"email,omitempty"`
+	Password  *string         `json:"password,omitempty"`
+	FirstName *string         `json:"firstName,omitempty"`
+	LastName  *string         `json:"lastName,omitempty"`
+	Phone     *string         `json:"phone,omitempty"`
+	Metadata  json.RawMessage `json:"metadata,omitempty" swaggertype:"object" extensions:"x-nullable"`
+	Version   *int64          `json:"version,omitempty"`
+}
+
+// Fields maps the request to the updates of the fields it sets, by JSON name, the form the
+// user service applies them in.
+func (r *UpdateUserRequest) Fields() map[string]interface{} {
+	fields := map[string]interface{}{}
+	for name, value := range map[string]*string{
+		"email":     r.Email,
+		"password":  r.Password,
+		"firstName": r.FirstName,
+		"lastName":  r.LastName,
+		"phone":     r.Phone,
+	} {
+		if value != nil {
+			fields[name] = *value
+		}
+	}
+	if r.Metadata != nil {
+		// The request was decoded from JSON, so metadata holds valid JSON
+		var metadata interface{}
+		json.Unmarshal(r.Metadata, &metadata)
+		fields["metadata"] = metadata
+	}
+	return fields
+}
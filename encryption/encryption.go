@@ -0,0 +1,221 @@
+// Package encryption encrypts sensitive user fields, such as emails and phone numbers, before
+// they are stored, so a copy of the database does not reveal them.
+//
+// Values are encrypted with AES-256-GCM under a nonce derived from the field and the plaintext,
+// so the same value always encrypts to the same ciphertext under the same key. That keeps
+// lookups by exact value and unique indexes working, at the cost of revealing which stored
+// values are equal. An encrypted value reads enc:{key ID}:{base64url nonce and ciphertext},
+// naming the key it was encrypted under, so keys can be rotated: new values are encrypted
+// under the current key and the others only decrypt.
+//
+// Documents that are never looked up by, such as cached users or stored responses, are sealed
+// instead, under a random nonce, so equal documents do not read alike.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// KeySize is the size of a key secret, in bytes.
+const KeySize = 32
+
+// prefix starts every encrypted value.
+const prefix = "enc:"
+
+// keyID matches the IDs keys may have.
+var keyID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Key is a named encryption key.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// ParseKey parses a key written as {ID}:{base64 secret}, such as the output of
+// openssl rand -base64 32 after an ID and a colon.
+func ParseKey(s string) (Key, error) {
+	id, secret, ok := strings.Cut(s, ":")
+	if !ok {
+		return Key{}, errors.New("encryption keys must be written as id:secret")
+	}
+	if !keyID.MatchString(id) {
+		return Key{}, fmt.Errorf("encryption key ID %q must be at most 32 letters, digits, _, and -", id)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil || len(decoded) != KeySize {
+		return Key{}, fmt.Errorf("encryption key %s must be %d bytes encoded in base64", id, KeySize)
+	}
+	return Key{ID: id, Secret: decoded}, nil
+}
+
+// derivedKey is a Key ready to use. Its encryption and nonce keys are derived from its secret.
+type derivedKey struct {
+	id       string
+	aead     cipher.AEAD
+	nonceKey []byte
+}
+
+// Keyring encrypts values under its current key and decrypts values encrypted under any of
+// its keys. It is safe for concurrent use.
+type Keyring struct {
+	keys []derivedKey
+}
+
+// NewKeyring returns a keyring of keys, the first of which is current.
+func NewKeyring(keys []Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("a keyring needs at least one key")
+	}
+	k := &Keyring{keys: make([]derivedKey, 0, len(keys))}
+	for _, spec := range keys {
+		if _, ok := k.key(spec.ID); ok {
+			return nil, fmt.Errorf("encryption key ID %s is used more than once", spec.ID)
+		}
+		if len(spec.Secret) != KeySize {
+			return nil, fmt.Errorf("encryption key %s must be %d bytes", spec.ID, KeySize)
+		}
+		encKey, nonceKey := make([]byte, KeySize), make([]byte, KeySize)
+		derive := hkdf.New(sha256.New, spec.Secret, nil, []byte("example_api field encryption"))
+		if _, err := io.ReadFull(derive, encKey); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(derive, nonceKey); err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(encKey)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys = append(k.keys, derivedKey{id: spec.ID, aead: aead, nonceKey: nonceKey})
+	}
+	return k, nil
+}
+
+// CurrentID returns the ID of the key values are encrypted under.
+func (k *Keyring) CurrentID() string {
+	return k.keys[0].id
+}
+
+// Encrypt encrypts plaintext, the value of field, under the current key. The empty string
+// stays empty, so an unset field reads the same encrypted or not.
+func (k *Keyring) Encrypt(field, plaintext string) string {
+	if plaintext == "" {
+		return ""
+	}
+	return k.keys[0].encrypt(field, plaintext)
+}
+
+func (k derivedKey) encrypt(field, plaintext string) string {
+	mac := hmac.New(sha256.New, k.nonceKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(plaintext))
+	nonce := mac.Sum(nil)[:k.aead.NonceSize()]
+	sealed := k.aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+	return prefix + k.id + ":" + base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// Decrypt returns the plaintext of value, the stored value of field. Values that are not
+// encrypted, such as those stored before encryption was turned on, are returned as they are.
+func (k *Keyring) Decrypt(field, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+	id, data, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	key, ok := k.key(id)
+	if !ok {
+		return "", fmt.Errorf("value is encrypted under unknown key %s", id)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil || len(sealed) < key.aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:key.aead.NonceSize()], sealed[key.aead.NonceSize():]
+	plaintext, err := key.aead.Open(nil, nonce, ciphertext, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value under key %s", id)
+	}
+	return string(plaintext), nil
+}
+
+// Seal encrypts data, a document of field, under the current key and a random nonce. It reads
+// like a value Encrypt returns, so Open also returns data that was stored unsealed. Empty data
+// stays empty.
+func (k *Keyring) Seal(field string, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	key := k.keys[0]
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := key.aead.Seal(nonce, nonce, data, []byte(field))
+	return []byte(prefix + key.id + ":" + base64.RawURLEncoding.EncodeToString(sealed)), nil
+}
+
+// Open returns the plaintext of data, a document of field that Seal may have encrypted.
+func (k *Keyring) Open(field string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(prefix)) {
+		return data, nil
+	}
+	plaintext, err := k.Decrypt(field, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(plaintext), nil
+}
+
+// Stored returns every form plaintext, the value of field, may be stored in: encrypted under
+// each key, current first, and as it is. Lookups by exact value match any of them.
+func (k *Keyring) Stored(field, plaintext string) []string {
+	if plaintext == "" {
+		return []string{""}
+	}
+	values := make([]string, 0, len(k.keys)+1)
+	for _, key := range k.keys {
+		values = append(values, key.encrypt(field, plaintext))
+	}
+	return append(values, plaintext)
+}
+
+// Encrypted reports whether value was encrypted by Encrypt or Seal, under any key.
+func Encrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Current reports whether value is stored in the form Encrypt produces: empty or encrypted
+// under the current key.
+func (k *Keyring) Current(value string) bool {
+	return value == "" || strings.HasPrefix(value, prefix+k.keys[0].id+":")
+}
+
+func (k *Keyring) key(id string) (derivedKey, bool) {
+	for _, key := range k.keys {
+		if key.id == id {
+			return key, true
+		}
+	}
+	return derivedKey{}, false
+}
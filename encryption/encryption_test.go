@@ -0,0 +1,146 @@
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func newKeyring(t *testing.T, ids ...string) *Keyring {
+	t.Helper()
+	var keys []Key
+	for _, id := range ids {
+		keys = append(keys, Key{ID: id, Secret: bytes.Repeat([]byte(id[:1]), KeySize)})
+	}
+	keyring, err := NewKeyring(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	keyring := newKeyring(t, "k1")
+
+	tests := []struct {
+		name      string
+		field     string
+		plaintext string
+	}{
+		{"email", "email", "ada@example.com"},
+		{"phone", "phone", "+15555550100"},
+		{"non-ASCII", "email", "ädä@exämple.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted := keyring.Encrypt(tt.field, tt.plaintext)
+			if !strings.HasPrefix(encrypted, "enc:k1:") || strings.Contains(encrypted, tt.plaintext) {
+				t.Fatalf("got %q, want it encrypted under k1", encrypted)
+			}
+			if again := keyring.Encrypt(tt.field, tt.plaintext); again != encrypted {
+				t.Fatalf("encrypting twice gave %q and %q, want the same value", encrypted, again)
+			}
+			if other := keyring.Encrypt(tt.field+"2", tt.plaintext); other == encrypted {
+				t.Fatal("the same value encrypts alike in another field")
+			}
+			decrypted, err := keyring.Decrypt(tt.field, encrypted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decrypted != tt.plaintext {
+				t.Fatalf("got %q, want %q", decrypted, tt.plaintext)
+			}
+			if _, err := keyring.Decrypt(tt.field+"2", encrypted); err == nil {
+				t.Fatal("decrypted the value as another field")
+			}
+		})
+	}
+
+	if encrypted := keyring.Encrypt("email", ""); encrypted != "" {
+		t.Fatalf("got %q for an empty value, want it empty", encrypted)
+	}
+	if decrypted, err := keyring.Decrypt("email", "ada@example.com"); err != nil || decrypted != "ada@example.com" {
+		t.Fatalf("got %q and %v for an unencrypted value, want it as it is", decrypted, err)
+	}
+}
+
+func TestDecryptAfterRotation(t *testing.T) {
+	old := newKeyring(t, "k1")
+	encrypted := old.Encrypt("email", "ada@example.com")
+	sealed, err := old.Seal("export", []byte("id,email"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newKeyring(t, "k2", "k1")
+	if decrypted, err := rotated.Decrypt("email", encrypted); err != nil || decrypted != "ada@example.com" {
+		t.Fatalf("got %q and %v decrypting under the older key, want the plaintext", decrypted, err)
+	}
+	if opened, err := rotated.Open("export", sealed); err != nil || string(opened) != "id,email" {
+		t.Fatalf("got %q and %v opening under the older key, want the plaintext", opened, err)
+	}
+	if rotated.Current(encrypted) {
+		t.Fatal("a value under the older key reads as current")
+	}
+	reencrypted := rotated.Encrypt("email", "ada@example.com")
+	if !strings.HasPrefix(reencrypted, "enc:k2:") || !rotated.Current(reencrypted) {
+		t.Fatalf("got %q, want it encrypted under the new key", reencrypted)
+	}
+	if stored := rotated.Stored("email", "ada@example.com"); len(stored) != 3 || stored[0] != reencrypted || stored[1] != encrypted {
+		t.Fatalf("got stored forms %q, want the new, older, and unencrypted forms", stored)
+	}
+
+	retired := newKeyring(t, "k2")
+	if _, err := retired.Decrypt("email", encrypted); err == nil {
+		t.Fatal("decrypted a value under a key that was removed")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	keyring := newKeyring(t, "k1")
+	data := []byte(`{"email":"ada@example.com"}`)
+
+	first, err := keyring.Seal("outbox", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := keyring.Seal("outbox", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) || bytes.Contains(first, []byte("ada@example.com")) {
+		t.Fatalf("got %q and %q, want equal documents sealed differently", first, second)
+	}
+	if !Encrypted(string(first)) {
+		t.Fatalf("got %q, which does not read as encrypted", first)
+	}
+	tampered := bytes.Clone(first)
+	tampered[len(tampered)-3] ^= 1
+
+	tests := []struct {
+		name  string
+		field string
+		data  []byte
+		want  []byte
+		fails bool
+	}{
+		{"sealed", "outbox", first, data, false},
+		{"unsealed", "outbox", data, data, false},
+		{"other field", "export", first, nil, true},
+		{"tampered", "outbox", tampered, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, err := keyring.Open(tt.field, tt.data)
+			if tt.fails {
+				if err == nil {
+					t.Fatalf("opened %q, want an error", opened)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(opened, tt.want) {
+				t.Fatalf("got %q and %v, want %q", opened, err, tt.want)
+			}
+		})
+	}
+}
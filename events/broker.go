@@ -0,0 +1,203 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"example_api/tenant"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// historySize is how many recent events a broker keeps for subscribers resuming after a disconnect.
+const historySize = 1000
+
+var (
+	// ErrSlowSubscriber ends a subscription whose buffer filled up; the subscriber should resume
+	// from the last event it handled
+	ErrSlowSubscriber = errors.New("subscriber fell too far behind")
+	// ErrBrokerClosed ends every subscription when the broker shuts down
+	ErrBrokerClosed = errors.New("event broker closed")
+)
+
+// Broker fans events out to in-process subscribers. Subscribers that fall too far behind are
+// disconnected rather than slowing down publishers.
+type Broker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[*Subscription]struct{}
+	// history holds the most recent events in publication order
+	history []Event
+	closed  bool
+	logger  *slog.Logger
+}
+
+func NewBroker(logger *slog.Logger) *Broker {
+	return &Broker{
+		subscribers: make(map[*Subscription]struct{}),
+		logger:      logger,
+	}
+}
+
+var _ Publisher = (*Broker)(nil)
+
+// Subscription receives the events published after it was created.
+type Subscription struct {
+	broker *Broker
+	events chan Event
+	// types limits delivery to these event types; nil means every type
+	types map[string]bool
+	err   error
+	once  sync.Once
+}
+
+// Events returns the channel events are delivered on. It is closed when the subscription ends;
+// Err then reports why.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns ErrSlowSubscriber or ErrBrokerClosed once the broker has ended the subscription,
+// and nil while it is open or after the subscriber closed it.
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.closeLocked(nil)
+}
+
+func (s *Subscription) closeLocked(err error) {
+	s.once.Do(func() {
+		s.err = err
+		delete(s.broker.subscribers, s)
+		close(s.events)
+	})
+}
+
+func (s *Subscription) wants(eventType string) bool {
+	return s.types == nil || s.types[eventType]
+}
+
+// Subscribe returns a subscription buffering up to buffer events of the given types, or of
+// every type when none are given.
+func (b *Broker) Subscribe(buffer int, types ...string) *Subscription {
+	_, sub := b.SubscribeSince(0, buffer, types...)
+	return sub
+}
+
+// SubscribeSince is Subscribe for a client resuming after the event with ID lastID. It also
+// returns the retained matching events published since then, so nothing falls between replay
+// and live delivery. A lastID of 0 replays nothing. An ID the broker has not issued yet comes
+// from before a restart, so the whole history is replayed.
+func (b *Broker) SubscribeSince(lastID uint64, buffer int, types ...string) ([]Event, *Subscription) {
+	sub := &Subscription{broker: b, events: make(chan Event, buffer)}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if lastID != 0 {
+		if lastID > b.nextID {
+			lastID = 0
+		}
+		for _, event := range b.history {
+			if event.ID > lastID && sub.wants(event.Type) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	b.subscribers[sub] = struct{}{}
+	if b.closed {
+		sub.closeLocked(ErrBrokerClosed)
+	}
+	return replay, sub
+}
+
+// Publish assigns the event an ID and delivers it to every matching subscriber without blocking.
+func (b *Broker) Publish(ctx context.Context, eventType string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := Event{ID: b.nextID, Type: eventType, Time: time.Now().UTC(), Tenant: tenant.FromContext(ctx), Data: data}
+	if len(b.history) == historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:historySize-1]
+	}
+	b.history = append(b.history, event)
+
+	for sub := range b.subscribers {
+		if !sub.wants(eventType) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.logger.WarnContext(ctx, "Dropping slow event subscriber", slog.String("type", eventType))
+			sub.closeLocked(ErrSlowSubscriber)
+		}
+	}
+}
+
+// Close ends every subscription so long-lived streams let the server shut down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		sub.closeLocked(ErrBrokerClosed)
+	}
+}
+
+// Consume calls handle for every event published from now on, in order, until the broker closes
+// or ctx is done. A handler slow enough to be dropped resumes from the broker's history, so it
+// only misses events when it falls more than the history behind.
+func (b *Broker) Consume(ctx context.Context, buffer int, handle func(Event)) {
+	var lastID uint64
+	sub := b.Subscribe(buffer)
+	defer func() { sub.Close() }()
+
+	for {
+		var event Event
+		var ok bool
+		select {
+		case event, ok = <-sub.Events():
+		case <-ctx.Done():
+			return
+		}
+
+		if !ok {
+			if !errors.Is(sub.Err(), ErrSlowSubscriber) {
+				return
+			}
+			var missed []Event
+			missed, sub = b.SubscribeSince(lastID, buffer)
+			if len(missed) > 0 && missed[0].ID > lastID+1 {
+				b.logger.ErrorContext(ctx, "Event consumer fell behind the retained history",
+					slog.Uint64("lastEventId", lastID), slog.Uint64("skipped", missed[0].ID-lastID-1))
+			}
+			for _, event := range missed {
+				handle(event)
+				lastID = event.ID
+			}
+			continue
+		}
+
+		handle(event)
+		lastID = event.ID
+	}
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	models "example_api/models"
+	"time"
+)
+
+// User lifecycle event types.
+const (
+	UserCreated = "user.created"
+	UserUpdated = "user.updated"
+	UserDeleted = "user.deleted"
+)
+
+// Event is one change notification. Its JSON form is the stable payload sent to subscribers.
+type Event struct {
+	// ID increases with every event published by a broker
+	ID   uint64    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Tenant is the ID of the tenant the change was made in
+	Tenant string      `json:"tenant"`
+	Data   interface{} `json:"data"`
+}
+
+// User is the event payload describing a user. The password hash is never included.
+type User struct {
+	ID        string     `json:"id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Phone     string     `json:"phone,omitempty"`
+	Role      string     `json:"role"`
+	JoinDate  time.Time  `json:"joinDate"`
+	Version   int64      `json:"version"`
+	ErasedAt  *time.Time `json:"erasedAt,omitempty"`
+
+	PhoneVerifiedAt *time.Time       `json:"phoneVerifiedAt,omitempty"`
+	Addresses       []models.Address `json:"addresses,omitempty"`
+	Metadata        models.Metadata  `json:"metadata,omitempty"`
+}
+
+// UserRef is the payload of events about a user that no longer exists.
+type UserRef struct {
+	ID string `json:"id"`
+}
+
+// UserPayload converts user to its event payload.
+func UserPayload(user *models.User) User {
+	return User{
+		ID:        user.Id.Hex(),
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Phone:     user.Phone,
+		Role:      user.Role,
+		JoinDate:  user.JoinDate,
+		Version:   user.Version,
+		ErasedAt:  user.ErasedAt,
+
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		Addresses:       user.Addresses,
+		Metadata:        user.Metadata,
+	}
+}
+
+// Publisher accepts events for delivery.
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, data interface{})
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	"example_api/i18n"
+	"log/slog"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// errorCodes maps application error kinds to the extensions.code of GraphQL errors.
+var errorCodes = map[apperrors.Kind]string{
+	apperrors.KindNotFound:             "NOT_FOUND",
+	apperrors.KindConflict:             "CONFLICT",
+	apperrors.KindValidation:           "BAD_USER_INPUT",
+	apperrors.KindPreconditionRequired: "PRECONDITION_REQUIRED",
+	apperrors.KindTooLarge:             "BAD_USER_INPUT",
+	apperrors.KindUnsupportedMediaType: "BAD_USER_INPUT",
+	apperrors.KindUnauthenticated:      "UNAUTHENTICATED",
+	apperrors.KindForbidden:            "FORBIDDEN",
+	apperrors.KindTooManyRequests:      "TOO_MANY_REQUESTS",
+	apperrors.KindUnavailable:          "UNAVAILABLE",
+}
+
+// errorPresenter renders resolver errors with a code and, for validation failures, the invalid
+// fields in extensions, in the language negotiated for the request. Internal errors are logged
+// and replaced by a generic message.
+func errorPresenter(logger *slog.Logger) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		presented := graphql.DefaultErrorPresenter(ctx, err)
+		locale := i18n.FromContext(ctx)
+
+		// Parse and validation errors from gqlgen itself are already client-safe
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) && gqlErr.Err == nil {
+			return presented
+		}
+
+		var appErr *apperrors.Error
+		code, ok := "", false
+		if errors.As(err, &appErr) {
+			code, ok = errorCodes[appErr.Kind]
+		}
+		if !ok {
+			logger.ErrorContext(ctx, "GraphQL resolver failed", slog.Any("error", err), slog.String("path", presented.Path.String()))
+			presented.Message = i18n.Translate(locale, "Internal server error")
+			presented.Extensions = map[string]interface{}{"code": "INTERNAL_SERVER_ERROR"}
+			return presented
+		}
+
+		presented.Message = i18n.Translate(locale, appErr.Message)
+		presented.Extensions = map[string]interface{}{"code": code}
+		if len(appErr.Fields) > 0 {
+			fields := make([]apperrors.FieldError, len(appErr.Fields))
+			for i, field := range appErr.Fields {
+				field.Message = i18n.Translate(locale, field.Message)
+				fields[i] = field
+			}
+			presented.Extensions["fields"] = fields
+		}
+		return presented
+	}
+}
+
+// recoverFunc turns a panicking resolver into an internal error instead of crashing the process.
+func recoverFunc(logger *slog.Logger) graphql.RecoverFunc {
+	return func(ctx context.Context, p interface{}) error {
+		logger.ErrorContext(ctx, "GraphQL resolver panicked", slog.Any("panic", p))
+		return errors.New("internal server error")
+	}
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	"example_api/auth"
+	"example_api/captcha"
+	"example_api/clientip"
+	"example_api/policy"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// maxComplexity bounds how expensive a single query may be, so nested selections over large
+// pages cannot be used to overload the database.
+const maxComplexity = 500
+
+// NewHandler returns the HTTP handler serving GraphQL queries and mutations over GET and POST.
+// Each root field is authorized by its entry in policies. With verifier, which may be nil,
+// anonymous signups need a solved CAPTCHA as they do over REST.
+func NewHandler(service UserService, policies policy.Table, verifier captcha.Verifier, logger *slog.Logger) http.Handler {
+	server := handler.New(NewExecutableSchema(Config{Resolvers: NewResolver(service)}))
+
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+
+	server.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	server.Use(extension.Introspection{})
+	server.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](100)})
+	server.Use(extension.FixedComplexityLimit(maxComplexity))
+
+	server.AroundRootFields(authorize(policies))
+	if verifier != nil {
+		server.AroundRootFields(requireCaptcha(verifier, logger))
+	}
+
+	server.SetErrorPresenter(errorPresenter(logger))
+	server.SetRecoverFunc(recoverFunc(logger))
+	return server
+}
+
+// authorize evaluates the policy of each root field against the principal of the request, with
+// the field's string arguments as its parameters. Introspection fields are always allowed, and
+// fields without a policy are denied.
+func authorize(policies policy.Table) graphql.RootFieldMiddleware {
+	return func(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+		field := graphql.GetRootFieldContext(ctx).Field
+		if strings.HasPrefix(field.Name, "__") {
+			return next(ctx)
+		}
+
+		// RootFieldContext.Object holds the field name, so the type comes from the definition
+		object := field.ObjectDefinition.Name
+		rule, ok := policies.Field(object, field.Name)
+		if !ok {
+			graphql.AddError(ctx, fmt.Errorf("field %s.%s has no authorization policy", object, field.Name))
+			return graphql.Null
+		}
+		params := map[string]string{}
+		for name, value := range field.ArgumentMap(graphql.GetOperationContext(ctx).Variables) {
+			if value, ok := value.(string); ok {
+				params[name] = value
+			}
+		}
+		if err := rule.Evaluate(auth.FromContext(ctx), params); err != nil {
+			graphql.AddError(ctx, err)
+			return graphql.Null
+		}
+		return next(ctx)
+	}
+}
+
+// requireCaptcha checks the X-Captcha-Token header of anonymous createUser mutations with
+// verifier, reporting an unreachable provider as UNAVAILABLE.
+func requireCaptcha(verifier captcha.Verifier, logger *slog.Logger) graphql.RootFieldMiddleware {
+	return func(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+		field := graphql.GetRootFieldContext(ctx).Field
+		if field.ObjectDefinition.Name != "Mutation" || field.Name != "createUser" || auth.FromContext(ctx) != nil {
+			return next(ctx)
+		}
+		token := graphql.GetOperationContext(ctx).Headers.Get(captcha.Header)
+		err := verifier.Verify(ctx, token, clientip.FromContext(ctx))
+		switch {
+		case errors.Is(err, captcha.ErrMissing):
+			graphql.AddError(ctx, apperrors.Validation("A solved CAPTCHA is required in the X-Captcha-Token header"))
+			return graphql.Null
+		case errors.Is(err, captcha.ErrRejected):
+			graphql.AddError(ctx, apperrors.Validation("CAPTCHA verification failed"))
+			return graphql.Null
+		case err != nil:
+			logger.ErrorContext(ctx, "CAPTCHA verification failed", slog.Any("error", err))
+			graphql.AddError(ctx, apperrors.Unavailable("CAPTCHA verification is unavailable, try again later", err))
+			return graphql.Null
+		}
+		return next(ctx)
+	}
+}
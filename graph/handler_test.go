@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example_api/captcha"
+	models "example_api/models"
+	"example_api/policy"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// signupService creates every user it is given and supports nothing else.
+type signupService struct {
+	UserService
+}
+
+func (signupService) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	created := *user
+	return &created, nil
+}
+
+// stubVerifier returns err for every token.
+type stubVerifier struct {
+	err error
+}
+
+func (v stubVerifier) Verify(ctx context.Context, token, ip string) error {
+	return v.err
+}
+
+func TestCreateUserCaptcha(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	policies := policy.Table{"Mutation.createUser": policy.Public}
+
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"solved", nil, ""},
+		{"missing", captcha.ErrMissing, "BAD_USER_INPUT"},
+		{"rejected", fmt.Errorf("%w: timeout-or-duplicate", captcha.ErrRejected), "BAD_USER_INPUT"},
+		{"provider unreachable", errors.New("connection refused"), "UNAVAILABLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(signupService{}, policies, stubVerifier{err: tt.err}, logger)
+			body := `{"query":"mutation { createUser(input: {email: \"ada@example.com\", password: \"secret123\", firstName: \"Ada\", lastName: \"Lovelace\"}) { email } }"}`
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var response struct {
+				Errors []struct {
+					Extensions struct {
+						Code string `json:"code"`
+					} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+			}
+			var code string
+			if len(response.Errors) > 0 {
+				code = response.Errors[0].Extensions.Code
+			}
+			if code != tt.code {
+				t.Fatalf("got code %q, want %q in %s", code, tt.code, rec.Body.String())
+			}
+		})
+	}
+}
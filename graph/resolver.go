@@ -0,0 +1,29 @@
+package graph
+
+//go:generate go run github.com/99designs/gqlgen generate --config ../gqlgen.yml
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+)
+
+// UserService is the business logic the GraphQL resolvers depend on.
+type UserService interface {
+	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	UpdateUser(ctx context.Context, id string, version int64, updates map[string]interface{}) (*models.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	ListUsers(ctx context.Context, filter repositories.UserFilter, sort string, fields []string, page, limit int) ([]models.User, int64, error)
+}
+
+// Resolver is the root resolver; it holds the dependencies shared by every resolver.
+type Resolver struct {
+	service UserService
+}
+
+func NewResolver(service UserService) *Resolver {
+	return &Resolver{
+		service: service,
+	}
+}
@@ -0,0 +1,100 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	"example_api/graph/model"
+	"example_api/models"
+	"example_api/repositories"
+)
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*models.User, error) {
+	return r.service.CreateUser(ctx, &models.User{
+		Email:     input.Email,
+		Password:  input.Password,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+	})
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id string, version int, input model.UpdateUserInput) (*models.User, error) {
+	updates := map[string]interface{}{}
+	for name, value := range map[string]*string{
+		"email":     input.Email,
+		"password":  input.Password,
+		"firstName": input.FirstName,
+		"lastName":  input.LastName,
+	} {
+		if value != nil {
+			updates[name] = *value
+		}
+	}
+	return r.service.UpdateUser(ctx, id, int64(version), updates)
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, error) {
+	if err := r.service.DeleteUser(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*models.User, error) {
+	user, err := r.service.GetUserByID(ctx, id)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		// A missing user is a null result rather than an error
+		return nil, nil
+	}
+	return user, err
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, filter *model.UserFilter, page int, limit int) (*model.UserPage, error) {
+	var userFilter repositories.UserFilter
+	if filter != nil {
+		if filter.Role != nil {
+			userFilter.Role = *filter.Role
+		}
+		if filter.Email != nil {
+			userFilter.Email = *filter.Email
+		}
+	}
+
+	users, total, err := r.service.ListUsers(ctx, userFilter, "", nil, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.User, len(users))
+	for i := range users {
+		items[i] = &users[i]
+	}
+	return &model.UserPage{Items: items, Total: int(total), Page: page, Limit: limit}, nil
+}
+
+// ID is the resolver for the id field.
+func (r *userResolver) ID(ctx context.Context, obj *models.User) (string, error) {
+	return obj.Id.Hex(), nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
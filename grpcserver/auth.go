@@ -0,0 +1,165 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"example_api/apperrors"
+	"example_api/auth"
+	"example_api/captcha"
+	"example_api/clientip"
+	"example_api/policy"
+	"example_api/proto/userv1"
+	"example_api/ratelimit"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator checks user credentials and returns whom they belong to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, email, password string) (*auth.Principal, error)
+}
+
+// Access says how callers are told apart and what each of them may call.
+type Access struct {
+	// AdminToken as a bearer token makes callers Admin, unless it is empty
+	AdminToken string
+	Admin      *auth.Principal
+	// Authenticator checks the email and password of Basic credentials
+	Authenticator Authenticator
+	// Policies holds the rule of every method by its full name
+	Policies policy.Table
+	// Captcha, unless nil, must accept the CAPTCHA of anonymous signups
+	Captcha captcha.Verifier
+}
+
+// authInterceptor sets the principal of calls whose authorization metadata holds credentials,
+// as the Authorization header does over HTTP: the admin token as a bearer token makes it admin,
+// and an email and password as Basic credentials make it that user. Calls with wrong credentials
+// are rejected, and calls without any stay anonymous.
+func authInterceptor(access Access, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		principal, err := authenticate(ctx, access, logger)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			ctx = auth.NewContext(ctx, principal)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticate returns the principal the credentials of a call belong to, or nil without them.
+func authenticate(ctx context.Context, access Access, logger *slog.Logger) (*auth.Principal, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	scheme, credentials, _ := strings.Cut(values[0], " ")
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		if access.AdminToken != "" && subtle.ConstantTimeCompare([]byte(credentials), []byte(access.AdminToken)) == 1 {
+			return access.Admin, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "Invalid bearer token")
+	case strings.EqualFold(scheme, "Basic"):
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "Malformed Basic credentials")
+		}
+		email, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "Malformed Basic credentials")
+		}
+
+		principal, err := access.Authenticator.Authenticate(ctx, email, password)
+		var throttled *ratelimit.ThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			seconds := int((throttled.Wait + time.Second - 1) / time.Second)
+			return nil, status.Errorf(codes.ResourceExhausted, "Too many failed sign-ins from your address, try again in %d seconds", seconds)
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			return nil, status.Error(codes.Unauthenticated, "Invalid email or password")
+		case err != nil:
+			logger.ErrorContext(ctx, "Authentication failed", slog.Any("error", err))
+			return nil, status.Error(codes.Internal, "Authentication failed")
+		}
+		return principal, nil
+	default:
+		return nil, status.Error(codes.Unauthenticated, "Credentials must use the Bearer or Basic scheme")
+	}
+}
+
+// authorizeInterceptor evaluates the policy of each method against the principal of the call,
+// with the id field of requests that have one as the id param.
+func authorizeInterceptor(policies policy.Table, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		params := map[string]string{}
+		if req, ok := req.(interface{ GetId() string }); ok {
+			params["id"] = req.GetId()
+		}
+		if err := authorize(ctx, policies, info.FullMethod, params, logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamAuthorizeInterceptor evaluates the policy of each streaming method for an anonymous
+// caller, since only the health and reflection services stream.
+func streamAuthorizeInterceptor(policies policy.Table, logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), policies, info.FullMethod, nil, logger); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// authorize returns nil if the principal of ctx may call method. Methods without a policy are
+// denied.
+func authorize(ctx context.Context, policies policy.Table, method string, params map[string]string, logger *slog.Logger) error {
+	rule, ok := policies.Method(method)
+	if !ok {
+		logger.ErrorContext(ctx, "Method has no authorization policy", slog.String("method", method))
+		return status.Error(codes.PermissionDenied, "Forbidden")
+	}
+	if err := rule.Evaluate(auth.FromContext(ctx), params); err != nil {
+		return status.Error(codeOf(err), apperrors.Message(err, "Forbidden"))
+	}
+	return nil
+}
+
+// captchaInterceptor lets anonymous CreateUser calls through only with the token of a solved
+// CAPTCHA in their x-captcha-token metadata, like RequireCaptcha does for REST signups. Calls
+// are rejected with Unavailable while the provider cannot be reached.
+func captchaInterceptor(verifier captcha.Verifier, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if info.FullMethod != userv1.UserService_CreateUser_FullMethodName || auth.FromContext(ctx) != nil {
+			return handler(ctx, req)
+		}
+		var token string
+		if values := metadata.ValueFromIncomingContext(ctx, captcha.Header); len(values) > 0 {
+			token = values[0]
+		}
+		err := verifier.Verify(ctx, token, clientip.FromContext(ctx))
+		switch {
+		case errors.Is(err, captcha.ErrMissing):
+			return nil, status.Error(codes.InvalidArgument, "A solved CAPTCHA is required in the x-captcha-token metadata")
+		case errors.Is(err, captcha.ErrRejected):
+			return nil, status.Error(codes.InvalidArgument, "CAPTCHA verification failed")
+		case err != nil:
+			logger.ErrorContext(ctx, "CAPTCHA verification failed", slog.Any("error", err))
+			return nil, status.Error(codes.Unavailable, "CAPTCHA verification is unavailable, try again later")
+		}
+		return handler(ctx, req)
+	}
+}
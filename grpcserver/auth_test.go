@@ -0,0 +1,90 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	"example_api/auth"
+	"example_api/policy"
+	"example_api/proto/userv1"
+	"example_api/ratelimit"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// stubAuthenticator knows one user, and throttles the password "throttled".
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) Authenticate(ctx context.Context, email, password string) (*auth.Principal, error) {
+	switch {
+	case password == "throttled":
+		return nil, &ratelimit.ThrottledError{Wait: 1500 * time.Millisecond}
+	case email == "ada@example.com" && password == "secret":
+		return &auth.Principal{UserID: "ada", Role: "user"}, nil
+	default:
+		return nil, auth.ErrInvalidCredentials
+	}
+}
+
+func basic(email, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(email+":"+password))
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	access := Access{
+		AdminToken:    "admin-token",
+		Admin:         &auth.Principal{Role: "admin", Permissions: []string{"users:read"}},
+		Authenticator: stubAuthenticator{},
+		Policies: policy.Table{
+			userv1.UserService_GetUser_FullMethodName:    policy.Require("users:read").OrSelf("id"),
+			userv1.UserService_CreateUser_FullMethodName: policy.Public,
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	authenticate := authInterceptor(access, logger)
+	authorize := authorizeInterceptor(access.Policies, logger)
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		req           interface{}
+		code          codes.Code
+	}{
+		{"anonymous public method", userv1.UserService_CreateUser_FullMethodName, "", &userv1.CreateUserRequest{}, codes.OK},
+		{"anonymous protected method", userv1.UserService_GetUser_FullMethodName, "", &userv1.GetUserRequest{Id: "ada"}, codes.Unauthenticated},
+		{"admin token", userv1.UserService_GetUser_FullMethodName, "Bearer admin-token", &userv1.GetUserRequest{Id: "bob"}, codes.OK},
+		{"wrong bearer token", userv1.UserService_GetUser_FullMethodName, "Bearer nope", &userv1.GetUserRequest{Id: "bob"}, codes.Unauthenticated},
+		{"user reads own account", userv1.UserService_GetUser_FullMethodName, basic("ada@example.com", "secret"), &userv1.GetUserRequest{Id: "ada"}, codes.OK},
+		{"user reads another account", userv1.UserService_GetUser_FullMethodName, basic("ada@example.com", "secret"), &userv1.GetUserRequest{Id: "bob"}, codes.PermissionDenied},
+		{"wrong password", userv1.UserService_GetUser_FullMethodName, basic("ada@example.com", "wrong"), &userv1.GetUserRequest{Id: "ada"}, codes.Unauthenticated},
+		{"throttled address", userv1.UserService_GetUser_FullMethodName, basic("ada@example.com", "throttled"), &userv1.GetUserRequest{Id: "ada"}, codes.ResourceExhausted},
+		{"malformed Basic credentials", userv1.UserService_GetUser_FullMethodName, "Basic !!!", &userv1.GetUserRequest{Id: "ada"}, codes.Unauthenticated},
+		{"method without a policy", userv1.UserService_DeleteUser_FullMethodName, "Bearer admin-token", &userv1.DeleteUserRequest{Id: "ada"}, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: tt.method}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return authorize(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return "ok", nil
+				})
+			}
+
+			_, err := authenticate(ctx, tt.req, info, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("got %s (%v), want %s", code, err, tt.code)
+			}
+		})
+	}
+}
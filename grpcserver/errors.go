@@ -0,0 +1,82 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	"example_api/repositories"
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus converts err to a gRPC status, mirroring how the REST handlers map error kinds to
+// HTTP statuses. Internal errors are logged and replaced by fallback so details never leak.
+func toStatus(ctx context.Context, logger *slog.Logger, err error, fallback string) error {
+	code := codeOf(err)
+	if code == codes.Internal {
+		logger.ErrorContext(ctx, fallback, slog.Any("error", err))
+	}
+	st := status.New(code, apperrors.Message(err, fallback))
+
+	// Attach field-level validation problems in the standard BadRequest detail
+	if fields := apperrors.Fields(err); len(fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+		for i, field := range fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: protoFieldName(field.Field), Description: field.Message}
+		}
+		if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+// codeOf returns the gRPC code for an application error.
+func codeOf(err error) codes.Code {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return codes.Internal
+	}
+	switch appErr.Kind {
+	case apperrors.KindNotFound:
+		return codes.NotFound
+	case apperrors.KindConflict:
+		// A stale version is a concurrency conflict the client resolves by re-reading and retrying
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return codes.Aborted
+		}
+		return codes.AlreadyExists
+	case apperrors.KindValidation, apperrors.KindUnsupportedMediaType:
+		return codes.InvalidArgument
+	case apperrors.KindTooLarge, apperrors.KindTooManyRequests:
+		return codes.ResourceExhausted
+	case apperrors.KindPreconditionRequired:
+		return codes.FailedPrecondition
+	case apperrors.KindUnauthenticated:
+		return codes.Unauthenticated
+	case apperrors.KindForbidden:
+		return codes.PermissionDenied
+	case apperrors.KindUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// protoFieldName converts a JSON field name such as firstName to its proto name, first_name.
+func protoFieldName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"example_api/actor"
+	"example_api/clientip"
+	"example_api/proto/userv1"
+	"example_api/requestid"
+	"example_api/tenant"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// New returns a gRPC server exposing the user service, the standard health service, and
+// reflection for tools such as grpcurl. Calls are traced, logged, and recovered from panics.
+// Each call works in the tenant named by its tenantHeader metadata, one of tenants or the
+// default tenant, as the principal its credentials belong to, and only if access allows it.
+func New(service UserService, access Access, tenantHeader string, tenants []string, logger *slog.Logger) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{
+		requestIDInterceptor,
+		actorInterceptor,
+		tenantInterceptor(tenantHeader, tenants),
+		loggingInterceptor(logger),
+		recoveryInterceptor(logger),
+		authInterceptor(access, logger),
+		authorizeInterceptor(access.Policies, logger),
+	}
+	if access.Captcha != nil {
+		interceptors = append(interceptors, captchaInterceptor(access.Captcha, logger))
+	}
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamAuthorizeInterceptor(access.Policies, logger)),
+	)
+
+	userv1.RegisterUserServiceServer(server, NewUserServer(service, logger))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(userv1.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+// requestIDInterceptor honors an incoming x-request-id metadata value or generates one, and
+// echoes it back in the response header.
+func requestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.Header); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if !requestid.Valid(id) {
+		id = uuid.NewString()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+	return handler(requestid.NewContext(ctx, id), req)
+}
+
+// actorInterceptor attributes the changes a call makes to the gRPC actor at the caller's address,
+// which is also the client address sign-ins are throttled by.
+func actorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	ctx = clientip.NewContext(ctx, ip)
+	return handler(actor.NewContext(ctx, actor.Actor{Name: actor.GRPC, IP: ip}), req)
+}
+
+// tenantInterceptor scopes each call to the tenant its header metadata names, rejecting
+// malformed and unknown tenants.
+func tenantInterceptor(header string, tenants []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if values := metadata.ValueFromIncomingContext(ctx, header); len(values) > 0 {
+			id = values[0]
+		}
+		id, err := tenant.Lookup(id, tenants)
+		if errors.Is(err, tenant.ErrInvalid) {
+			return nil, status.Error(codes.InvalidArgument, "Tenant IDs are lowercase letters, digits, and hyphens")
+		}
+		if err != nil {
+			return nil, status.Error(codes.NotFound, "Unknown tenant")
+		}
+		return handler(tenant.NewContext(ctx, id), req)
+	}
+}
+
+// loggingInterceptor logs one line per call, like the HTTP access log.
+func loggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(ctx, level, "gRPC call",
+			slog.String("requestId", requestid.FromContext(ctx)),
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Int64("latencyMs", time.Since(start).Milliseconds()),
+		)
+		return resp, err
+	}
+}
+
+// recoveryInterceptor turns a panicking handler into an Internal error instead of crashing the process.
+func recoveryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.ErrorContext(ctx, "gRPC handler panicked", slog.Any("panic", p), slog.String("stack", string(debug.Stack())))
+				err = status.Error(codes.Internal, "Internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
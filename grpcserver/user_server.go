@@ -0,0 +1,121 @@
+package grpcserver
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/proto/userv1"
+	"example_api/repositories"
+	"log/slog"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserService is the business logic the gRPC user server depends on.
+type UserService interface {
+	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	UpdateUser(ctx context.Context, id string, version int64, updates map[string]interface{}) (*models.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	ListUsers(ctx context.Context, filter repositories.UserFilter, sort string, fields []string, page, limit int) ([]models.User, int64, error)
+}
+
+// UserServer implements userv1.UserServiceServer on top of the user service layer.
+type UserServer struct {
+	userv1.UnimplementedUserServiceServer
+	service UserService
+	logger  *slog.Logger
+}
+
+func NewUserServer(service UserService, logger *slog.Logger) *UserServer {
+	return &UserServer{
+		service: service,
+		logger:  logger,
+	}
+}
+
+var _ userv1.UserServiceServer = (*UserServer)(nil)
+
+func (s *UserServer) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.User, error) {
+	created, err := s.service.CreateUser(ctx, &models.User{
+		Email:     req.GetEmail(),
+		Password:  req.GetPassword(),
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err, "Failed to create user")
+	}
+	return toProtoUser(created), nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.User, error) {
+	user, err := s.service.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err, "Failed to get user")
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.User, error) {
+	updates := map[string]interface{}{}
+	if req.Email != nil {
+		updates["email"] = req.GetEmail()
+	}
+	if req.Password != nil {
+		updates["password"] = req.GetPassword()
+	}
+	if req.FirstName != nil {
+		updates["firstName"] = req.GetFirstName()
+	}
+	if req.LastName != nil {
+		updates["lastName"] = req.GetLastName()
+	}
+
+	user, err := s.service.UpdateUser(ctx, req.GetId(), req.GetVersion(), updates)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err, "Failed to update user")
+	}
+	return toProtoUser(user), nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error) {
+	if err := s.service.DeleteUser(ctx, req.GetId()); err != nil {
+		return nil, toStatus(ctx, s.logger, err, "Failed to delete user")
+	}
+	return &userv1.DeleteUserResponse{}, nil
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	page, limit := int(req.GetPage()), int(req.GetPageSize())
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 20
+	}
+
+	filter := repositories.UserFilter{Role: req.GetRole(), Email: req.GetEmail()}
+	users, total, err := s.service.ListUsers(ctx, filter, "", nil, page, limit)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err, "Failed to list users")
+	}
+
+	resp := &userv1.ListUsersResponse{Users: make([]*userv1.User, len(users)), Total: total}
+	for i := range users {
+		resp.Users[i] = toProtoUser(&users[i])
+	}
+	return resp, nil
+}
+
+// toProtoUser converts user to its protobuf message, leaving out the password hash.
+func toProtoUser(user *models.User) *userv1.User {
+	return &userv1.User{
+		Id:        user.Id.Hex(),
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role:      user.Role,
+		JoinDate:  timestamppb.New(user.JoinDate),
+		Version:   user.Version,
+	}
+}
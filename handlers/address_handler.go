@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteGetAddress names the address route, used for the Location of added addresses.
+const RouteGetAddress = "addresses.get"
+
+// AddressService is the business logic the address handlers depend on.
+type AddressService interface {
+	ListAddresses(ctx context.Context, userID string) ([]models.Address, error)
+	GetAddress(ctx context.Context, userID, id string) (*models.Address, error)
+	AddAddress(ctx context.Context, userID string, address *models.Address) (*models.Address, error)
+	UpdateAddress(ctx context.Context, userID, id string, address *models.Address) (*models.Address, error)
+	DeleteAddress(ctx context.Context, userID, id string) error
+}
+
+type AddressHandler struct {
+	service AddressService
+	logger  *slog.Logger
+	router  *mux.Router
+}
+
+func NewAddressHandler(service AddressService, logger *slog.Logger, router *mux.Router) *AddressHandler {
+	return &AddressHandler{
+		service: service,
+		logger:  logger,
+		router:  router,
+	}
+}
+
+// ListAddresses godoc
+// @Summary List a user's addresses
+// @Description Retrieve every postal address of a user in the order they were added. Requires the
+// @Description users:read permission, except for the user's own addresses.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Success 200 {object} respond.Envelope{data=[]models.Address}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/addresses [get]
+func (h *AddressHandler) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	addresses, err := h.service.ListAddresses(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to retrieve addresses")
+		return
+	}
+	respond.OK(w, r, "Addresses retrieved successfully", addresses)
+}
+
+// AddAddress godoc
+// @Summary Add an address to a user
+// @Description Add a postal address to a user, who can have up to 10. The country code is an ISO 3166-1
+// @Description alpha-2 code such as TR. Requires the users:write permission, except for the user's own
+// @Description addresses.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param address body models.Address true "Address JSON"
+// @Success 201 {object} respond.Envelope{data=models.Address}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/addresses [post]
+func (h *AddressHandler) AddAddress(w http.ResponseWriter, r *http.Request) {
+	var address models.Address
+	if err := decodeJSON(r, &address); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	userID := mux.Vars(r)["id"]
+	added, err := h.service.AddAddress(r.Context(), userID, &address)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to add address")
+		return
+	}
+
+	if self, ok := routeLink(h.router, RouteGetAddress, http.MethodGet, "id", userID, "addressId", added.Id.Hex()); ok {
+		w.Header().Set("Location", self.Href)
+	}
+	respond.Created(w, r, fmt.Sprintf("Address added successfully with ID: %s", added.Id.Hex()), added)
+}
+
+// GetAddress godoc
+// @Summary Get a user's address
+// @Description Retrieve one postal address of a user. Requires the users:read permission, except for the
+// @Description user's own addresses.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param addressId path string true "Address ID"
+// @Success 200 {object} respond.Envelope{data=models.Address}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/addresses/{addressId} [get]
+func (h *AddressHandler) GetAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	address, err := h.service.GetAddress(r.Context(), vars["id"], vars["addressId"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to retrieve address")
+		return
+	}
+	respond.OK(w, r, "Address retrieved successfully", address)
+}
+
+// UpdateAddress godoc
+// @Summary Update a user's address
+// @Description Replace one postal address of a user, keeping its ID. Requires the users:write permission,
+// @Description except for the user's own addresses.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param addressId path string true "Address ID"
+// @Param address body models.Address true "Address JSON"
+// @Success 200 {object} respond.Envelope{data=models.Address}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/addresses/{addressId} [put]
+func (h *AddressHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	var address models.Address
+	if err := decodeJSON(r, &address); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	vars := mux.Vars(r)
+	updated, err := h.service.UpdateAddress(r.Context(), vars["id"], vars["addressId"], &address)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update address")
+		return
+	}
+	respond.OK(w, r, "Address updated successfully", updated)
+}
+
+// DeleteAddress godoc
+// @Summary Remove a user's address
+// @Description Remove one postal address of a user. Requires the users:write permission, except for the
+// @Description user's own addresses.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param addressId path string true "Address ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/addresses/{addressId} [delete]
+func (h *AddressHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.service.DeleteAddress(r.Context(), vars["id"], vars["addressId"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to remove address")
+		return
+	}
+	respond.OK(w, r, "Address removed successfully", nil)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+)
+
+// AuditService is the business logic the audit log handler depends on.
+type AuditService interface {
+	ListAuditLogs(ctx context.Context, query models.AuditQuery, page, limit int) ([]models.AuditEntry, int64, error)
+}
+
+type AuditHandler struct {
+	service AuditService
+	logger  *slog.Logger
+}
+
+func NewAuditHandler(service AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListAuditLogs godoc
+// @Summary List audit log entries
+// @Description Retrieve a page of the audit log, newest first. Each entry records a change to a user: the
+// @Description action, who made it and from where, and the changed fields with their values before and
+// @Description after. Filters combine; from is inclusive and to exclusive. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param actor query string false "Only changes made by this actor" Enums(api, admin, grpc, cli, seed, system)
+// @Param targetId query string false "Only changes to the user with this ID"
+// @Param action query string false "Only entries with this action" Enums(user.created, user.updated, user.deleted, user.erased)
+// @Param from query string false "Only entries at or after this RFC 3339 time"
+// @Param to query string false "Only entries before this RFC 3339 time"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.AuditEntry}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/audit-logs [get]
+func (h *AuditHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	from, err := queryTime(r, "from")
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid from; use an RFC 3339 time")
+		return
+	}
+	to, err := queryTime(r, "to")
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid to; use an RFC 3339 time")
+		return
+	}
+
+	query := r.URL.Query()
+	entries, total, err := h.service.ListAuditLogs(r.Context(), models.AuditQuery{
+		Actor:    query.Get("actor"),
+		Action:   query.Get("action"),
+		TargetID: query.Get("targetId"),
+		From:     from,
+		To:       to,
+	}, page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list audit log")
+		return
+	}
+	respond.Page(w, r, "Audit log retrieved successfully", entries, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
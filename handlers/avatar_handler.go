@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// avatarField is the multipart form field carrying the image.
+const avatarField = "avatar"
+
+// MultipartOverhead allows for the boundaries and part headers around an uploaded file.
+const MultipartOverhead = 64 << 10
+
+// AvatarService is the business logic the avatar handlers depend on.
+type AvatarService interface {
+	MaxSize() int64
+	SetAvatar(ctx context.Context, id string, data []byte) (*models.Avatar, error)
+	GetAvatar(ctx context.Context, id, variant string) (*models.Avatar, io.ReadCloser, error)
+	DeleteAvatar(ctx context.Context, id string) error
+}
+
+type AvatarHandler struct {
+	service AvatarService
+	logger  *slog.Logger
+}
+
+func NewAvatarHandler(service AvatarService, logger *slog.Logger) *AvatarHandler {
+	return &AvatarHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// PutAvatar godoc
+// @Summary Upload a user's avatar
+// @Description Replace a user's avatar with a PNG, JPEG, GIF, or WebP image sent as the avatar field of
+// @Description a multipart form. The format is detected from the image itself. EXIF and other metadata
+// @Description are removed, and thumbnail and medium copies are generated.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "User ID"
+// @Param avatar formData file true "Image"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 413 {object} problem.Problem
+// @Failure 415 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/avatar [put]
+func (h *AvatarHandler) PutAvatar(w http.ResponseWriter, r *http.Request) {
+	data, err := h.readUpload(w, r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid upload")
+		return
+	}
+
+	avatar, err := h.service.SetAvatar(r.Context(), mux.Vars(r)["id"], data)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to store avatar")
+		return
+	}
+	respond.OK(w, r, "Avatar updated successfully", avatar)
+}
+
+// readUpload reads the avatar part of the multipart request body, stopping as soon as it is
+// known to be too large.
+func (h *AvatarHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	maxSize := h.service.MaxSize()
+	tooLarge := apperrors.TooLarge(fmt.Sprintf("Avatar must not exceed %d bytes", maxSize))
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+MultipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, apperrors.UnsupportedMediaType("Send the avatar as multipart/form-data")
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, apperrors.Validation("Missing " + avatarField + " file")
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, tooLarge
+			}
+			return nil, apperrors.Validation("Malformed multipart body")
+		}
+		if part.FormName() != avatarField {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, maxSize+1))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, tooLarge
+			}
+			return nil, apperrors.Validation("Malformed multipart body")
+		}
+		if int64(len(data)) > maxSize {
+			return nil, tooLarge
+		}
+		return data, nil
+	}
+}
+
+// GetAvatar godoc
+// @Summary Get a user's avatar
+// @Description Stream a user's avatar image. The thumbnail (128px) and medium (512px) sizes are scaled
+// @Description down to fit a square of that size, as JPEG or, with transparency, PNG. Responses carry an
+// @Description ETag and must be revalidated, so clients get a 304 until the avatar changes.
+// @Tags users
+// @Produce image/png,image/jpeg,image/gif,image/webp
+// @Param id path string true "User ID"
+// @Param size query string false "Image size" Enums(original, medium, thumbnail) default(original)
+// @Param If-None-Match header string false "ETag of the cached avatar"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/avatar [get]
+func (h *AvatarHandler) GetAvatar(w http.ResponseWriter, r *http.Request) {
+	avatar, data, err := h.service.GetAvatar(r.Context(), mux.Vars(r)["id"], r.URL.Query().Get("size"))
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get avatar")
+		return
+	}
+	defer data.Close()
+
+	w.Header().Set("Last-Modified", avatar.UpdatedAt.UTC().Format(http.TimeFormat))
+	if respond.Conditional(w, r, avatar.ETag, "public, no-cache") {
+		return
+	}
+	header := w.Header()
+	header.Set("Content-Type", avatar.ContentType)
+	header.Set("Content-Length", strconv.FormatInt(avatar.Size, 10))
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, data); err != nil {
+		// The status is already sent, so all that is left is to cut the response short
+		h.logger.WarnContext(r.Context(), "Failed to stream avatar", slog.Any("error", err))
+	}
+}
+
+// DeleteAvatar godoc
+// @Summary Delete a user's avatar
+// @Description Remove a user's avatar
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/avatar [delete]
+func (h *AvatarHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteAvatar(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete avatar")
+		return
+	}
+	respond.OK(w, r, "Avatar deleted successfully", nil)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"example_api/apperrors"
+	"example_api/dto"
+	"example_api/mediatype"
+	models "example_api/models"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// decodeUser decodes a new user from a JSON or, per Content-Type, XML request body.
+func decodeUser(r *http.Request) (*models.User, error) {
+	var request dto.CreateUserRequest
+	if mediatype.IsXML(r.Header.Get("Content-Type")) {
+		input, err := decodeXMLUser(r)
+		if err != nil {
+			return nil, err
+		}
+		request = input.createRequest()
+	} else if err := decodeJSON(r, &request); err != nil {
+		return nil, err
+	}
+	return request.User(), nil
+}
+
+// decodeUpdates decodes user updates from a JSON object or, per Content-Type, an XML <user> document.
+func decodeUpdates(r *http.Request) (*dto.UpdateUserRequest, error) {
+	if mediatype.IsXML(r.Header.Get("Content-Type")) {
+		input, err := decodeXMLUser(r)
+		if err != nil {
+			return nil, err
+		}
+		request := input.updateRequest()
+		return &request, nil
+	}
+
+	var request dto.UpdateUserRequest
+	if err := decodeJSON(r, &request); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// decodeJSON decodes the request body into v, reporting type mismatches against the offending field.
+func decodeJSON(r *http.Request, v interface{}) error {
+	return jsonError(json.NewDecoder(r.Body).Decode(v))
+}
+
+// jsonError turns a JSON decoding error into a validation error.
+func jsonError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err := bodyTooLarge(err); err != nil {
+		return err
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apperrors.InvalidFields("Invalid input", []apperrors.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind())),
+		}})
+	}
+	return apperrors.Validation("Invalid input")
+}
+
+// bodyTooLarge returns the error for a request body cut off at its size limit, if err is one.
+func bodyTooLarge(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return apperrors.TooLarge(fmt.Sprintf("Request body must not exceed %d bytes", maxBytesErr.Limit))
+	}
+	return nil
+}
+
+// jsonTypeName names the JSON type that corresponds to a Go kind.
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return kind.String()
+	}
+}
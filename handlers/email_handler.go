@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"example_api/mailer"
+	"example_api/respond"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// EmailRenderer renders the email templates the handlers preview.
+type EmailRenderer interface {
+	Templates() []string
+	Locales() []string
+	Render(name, locale string, data mailer.Data) (mailer.Message, string, error)
+}
+
+// EmailTemplates lists the email templates and the locales they can be rendered in.
+type EmailTemplates struct {
+	Templates []string `json:"templates"`
+	Locales   []string `json:"locales"`
+}
+
+// EmailPreview is an email template rendered with sample data.
+type EmailPreview struct {
+	Template string `json:"template"`
+	// Locale is the locale that was rendered, which differs from the requested one when that
+	// locale has no variant of the template
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// previewData fills in every field a template may use.
+var previewData = mailer.Data{
+	FirstName: "Ada",
+	Email:     "ada@example.com",
+	Link:      "https://example.com/verify?token=preview",
+	Expires:   24 * time.Hour,
+}
+
+type EmailHandler struct {
+	renderer EmailRenderer
+	logger   *slog.Logger
+}
+
+func NewEmailHandler(renderer EmailRenderer, logger *slog.Logger) *EmailHandler {
+	return &EmailHandler{
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// ListTemplates godoc
+// @Summary List email templates
+// @Description Retrieve the names of the email templates and the locales they are available in
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Success 200 {object} respond.Envelope
+// @Failure 401 {object} problem.Problem
+// @Router /api/v1/admin/emails [get]
+func (h *EmailHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	respond.OK(w, r, "Email templates retrieved successfully", EmailTemplates{
+		Templates: h.renderer.Templates(),
+		Locales:   h.renderer.Locales(),
+	})
+}
+
+// PreviewTemplate godoc
+// @Summary Preview an email template
+// @Description Render an email template with sample data. Locales without their own variant fall back
+// @Description to the default locale. format=html or format=text returns just that body, for viewing
+// @Description in a browser.
+// @Tags admin
+// @Produce json,html,plain
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param name path string true "Template name"
+// @Param locale query string false "Locale, such as tr or tr-TR (default MAIL_LOCALE)"
+// @Param format query string false "json (default), html, or text"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/emails/{name} [get]
+func (h *EmailHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "json", "html", "text":
+	default:
+		respond.Error(w, r, http.StatusBadRequest, "Invalid format; use json, html, or text")
+		return
+	}
+
+	msg, locale, err := h.renderer.Render(name, r.URL.Query().Get("locale"), previewData)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to render email template")
+		return
+	}
+
+	switch format {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, msg.HTML)
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, msg.Text)
+	default:
+		respond.OK(w, r, "Email template rendered successfully", EmailPreview{
+			Template: name,
+			Locale:   locale,
+			Subject:  msg.Subject,
+			Text:     msg.Text,
+			HTML:     msg.HTML,
+		})
+	}
+}
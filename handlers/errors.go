@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"example_api/apperrors"
+	"example_api/problem"
+	"log/slog"
+	"net/http"
+)
+
+// writeError renders err as a problem response, mapping application error kinds to HTTP
+// statuses. Internal errors are logged and replaced by fallback so details never leak to clients.
+func writeError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, err error, fallback string) {
+	status := apperrors.HTTPStatus(err)
+	if status >= http.StatusInternalServerError {
+		logger.ErrorContext(r.Context(), fallback, slog.Any("error", err))
+	}
+	p := problem.New(status, apperrors.Message(err, fallback))
+	p.Errors = apperrors.Fields(err)
+	problem.Write(w, r, p)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"example_api/events"
+	"example_api/respond"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventBuffer is how many undelivered events a subscriber may fall behind by before it is dropped
+	eventBuffer = 64
+	// pingInterval keeps idle connections open through proxies and detects dead peers
+	pingInterval = 30 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+// upgrader accepts connections from any origin: clients authenticate with a token rather than
+// cookies, so a cross-site page cannot subscribe on a user's behalf.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// EventsHandler streams broker events to subscribers. Routes are expected to authenticate
+// subscribers before they reach it.
+type EventsHandler struct {
+	broker *events.Broker
+	logger *slog.Logger
+}
+
+func NewEventsHandler(broker *events.Broker, logger *slog.Logger) *EventsHandler {
+	return &EventsHandler{
+		broker: broker,
+		logger: logger,
+	}
+}
+
+// WebSocket godoc
+// @Summary Subscribe to user events over WebSocket
+// @Description Upgrade to a WebSocket that receives a JSON message for every user.created, user.updated,
+// @Description and user.deleted event. Authenticate with a bearer token or the token query parameter.
+// @Tags events
+// @Param token query string false "Events token, for clients that cannot set the Authorization header"
+// @Param types query string false "Comma-separated event types to receive (default all)"
+// @Success 101 "Switching to the WebSocket protocol"
+// @Failure 401 {object} problem.Problem
+// @Router /api/ws [get]
+func (h *EventsHandler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		h.logger.DebugContext(r.Context(), "WebSocket upgrade failed", slog.Any("error", err))
+		return
+	}
+	defer conn.Close()
+
+	sub := h.broker.Subscribe(eventBuffer, eventTypes(r)...)
+	defer sub.Close()
+
+	// Subscribers only listen; reading handles pongs and notices when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if !ok {
+				code := websocket.CloseTryAgainLater
+				if errors.Is(sub.Err(), events.ErrBrokerClosed) {
+					code = websocket.CloseGoingAway
+				}
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, sub.Err().Error()))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Stream godoc
+// @Summary Stream user events as Server-Sent Events
+// @Description Stream a text/event-stream with one message per user.created, user.updated, and user.deleted
+// @Description event. Each message has the event ID and type, and its data is the event as JSON. Clients
+// @Description that reconnect with Last-Event-ID first receive the events they missed, as far as the
+// @Description server still retains them. Authenticate with a bearer token or the token query parameter.
+// @Tags events
+// @Produce text/event-stream
+// @Param token query string false "Events token, for clients that cannot set the Authorization header"
+// @Param types query string false "Comma-separated event types to receive (default all)"
+// @Param Last-Event-ID header string false "ID of the last event received before reconnecting"
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Router /api/events [get]
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var lastID uint64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(header, 10, 64); err != nil {
+			respond.Error(w, r, http.StatusBadRequest, "Invalid Last-Event-ID header")
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	replay, sub := h.broker.SubscribeSince(lastID, eventBuffer, eventTypes(r)...)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stop reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Ask clients to reconnect quickly when the stream ends
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for _, event := range replay {
+		if err := writeSSE(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		h.logger.ErrorContext(r.Context(), "Event stream cannot be flushed", slog.Any("error", err))
+		return
+	}
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			// A dropped subscriber reconnects and catches up through Last-Event-ID
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			// Comments keep idle connections open through proxies
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSE writes event as one Server-Sent Events message.
+func writeSSE(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// eventTypes returns the event types requested in the types query parameter, or nil for all.
+func eventTypes(r *http.Request) []string {
+	var types []string
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
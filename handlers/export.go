@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/respond"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportColumn is a user field an export can include. value returns a string, a time.Time, or
+// an int64, so each format can render it natively.
+type exportColumn struct {
+	name  string
+	value func(*models.User) interface{}
+	// width is the column's width in an XLSX export, in characters
+	width float64
+}
+
+// exportColumns lists the exportable user fields in their default order. The password hash is
+// never exported.
+var exportColumns = []exportColumn{
+	{"id", func(u *models.User) interface{} { return u.Id.Hex() }, 26},
+	{"email", func(u *models.User) interface{} { return u.Email }, 32},
+	{"firstName", func(u *models.User) interface{} { return u.FirstName }, 16},
+	{"lastName", func(u *models.User) interface{} { return u.LastName }, 16},
+	{"phone", func(u *models.User) interface{} { return u.Phone }, 16},
+	{"role", func(u *models.User) interface{} { return u.Role }, 8},
+	{"joinDate", func(u *models.User) interface{} { return u.JoinDate.UTC() }, 20},
+	{"version", func(u *models.User) interface{} { return u.Version }, 8},
+}
+
+// selectExportColumns returns the columns named in a comma-separated list, in its order, or
+// every column when the list is empty.
+func selectExportColumns(list string) ([]exportColumn, error) {
+	if list == "" {
+		return exportColumns, nil
+	}
+	var selected []exportColumn
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, column := range exportColumns {
+			if column.name == name {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, apperrors.Validation(fmt.Sprintf("Unknown column %q; columns are %s", name, strings.Join(columnNames(exportColumns), ", ")))
+		}
+	}
+	return selected, nil
+}
+
+func columnNames(columns []exportColumn) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// spreadsheetSafe keeps spreadsheets from evaluating a cell as a formula by prefixing values
+// that start like one with a single quote.
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvValue renders a column value as CSV text.
+func csvValue(value interface{}) string {
+	switch value := value.(type) {
+	case time.Time:
+		return value.Format(time.RFC3339)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	default:
+		return spreadsheetSafe(value.(string))
+	}
+}
+
+// ExportUsers godoc
+// @Summary Export users
+// @Description Download every user, or those matching the filters, in ID order as CSV or as an Excel
+// @Description workbook. columns picks which fields to include and in what order; the password hash is
+// @Description never exported. CSV cells that would start a spreadsheet formula are prefixed with a
+// @Description single quote. CSV exports stream as they are read; XLSX exports are sent once complete
+// @Description and hold at most 1048575 users. Exports are not subject to the request timeout.
+// @Tags users
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Param columns query string false "Comma-separated columns: id, email, firstName, lastName, phone, role, joinDate, version"
+// @Param role query string false "Only users with this role"
+// @Param email query string false "Only the user with this email"
+// @Param filter query string false "Filter expression, as for listing users"
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/export [get]
+func (h *UserHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "csv" && format != "xlsx" {
+		respond.Error(w, r, http.StatusBadRequest, "Format must be csv or xlsx")
+		return
+	}
+	columns, err := selectExportColumns(query.Get("columns"))
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid columns")
+		return
+	}
+	filter := repositories.UserFilter{Role: query.Get("role"), Email: query.Get("email"), Expression: query.Get("filter")}
+
+	if format == "xlsx" {
+		h.exportXLSX(w, r, filter, columns)
+	} else {
+		h.exportCSV(w, r, filter, columns)
+	}
+}
+
+// exportCSV streams the users matching filter as CSV.
+func (h *UserHandler) exportCSV(w http.ResponseWriter, r *http.Request, filter repositories.UserFilter, columns []exportColumn) {
+	// The header row waits for the first batch, so a store that fails right away still gets
+	// a proper error response
+	out := csv.NewWriter(w)
+	started := false
+	row := make([]string, len(columns))
+	err := h.service.ExportUsers(r.Context(), filter, func(user *models.User) error {
+		if !started {
+			started = true
+			h.startExport(w, out, columns)
+		}
+		for i, column := range columns {
+			row[i] = csvValue(column.value(user))
+		}
+		return out.Write(row)
+	})
+	if err != nil && !started {
+		writeError(w, r, h.logger, err, "Failed to export users")
+		return
+	}
+	if !started {
+		h.startExport(w, out, columns)
+	}
+	out.Flush()
+	if err == nil {
+		err = out.Error()
+	}
+	if err != nil {
+		// Part of the file may already be sent; aborting tells the client it is incomplete
+		h.logger.ErrorContext(r.Context(), "User export failed partway", slog.Any("error", err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// RenderExport returns the renderer of background exports, which writes the users an export
+// request selects to w and returns the file's name and media type.
+func RenderExport(service UserService) func(ctx context.Context, request *models.ExportRequest, w io.Writer) (string, string, error) {
+	return func(ctx context.Context, request *models.ExportRequest, w io.Writer) (string, string, error) {
+		columns, err := selectExportColumns(request.Columns)
+		if err != nil {
+			return "", "", err
+		}
+		filter := repositories.UserFilter{Role: request.Role, Email: request.Email, Expression: request.Filter}
+
+		if request.Format == "xlsx" {
+			file, err := buildXLSX(ctx, service, filter, columns)
+			if err != nil {
+				return "", "", err
+			}
+			defer file.Close()
+			return "users.xlsx", xlsxContentType, file.Write(w)
+		}
+
+		out := csv.NewWriter(w)
+		out.Write(columnNames(columns))
+		row := make([]string, len(columns))
+		err = service.ExportUsers(ctx, filter, func(user *models.User) error {
+			for i, column := range columns {
+				row[i] = csvValue(column.value(user))
+			}
+			return out.Write(row)
+		})
+		if err != nil {
+			return "", "", err
+		}
+		out.Flush()
+		return "users.csv", "text/csv; charset=utf-8", out.Error()
+	}
+}
+
+// startExport sends the response headers and the CSV header row.
+func (h *UserHandler) startExport(w http.ResponseWriter, out *csv.Writer, columns []exportColumn) {
+	header := w.Header()
+	header.Set("Content-Type", "text/csv; charset=utf-8")
+	header.Set("Content-Disposition", `attachment; filename="users.csv"`)
+	header.Set("X-Content-Type-Options", "nosniff")
+	out.Write(columnNames(columns))
+}
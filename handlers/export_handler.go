@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteGetExport names the export route, used for the Location of started exports.
+const RouteGetExport = "exports.get"
+
+// ExportService is the business logic the background export handlers depend on.
+type ExportService interface {
+	StartExport(ctx context.Context, request *models.ExportRequest) (*models.Job, error)
+	GetExport(ctx context.Context, id string) (*models.Job, error)
+	OpenExport(ctx context.Context, id string) (*models.ExportFile, io.ReadCloser, error)
+}
+
+type ExportHandler struct {
+	service ExportService
+	logger  *slog.Logger
+	router  *mux.Router
+}
+
+func NewExportHandler(service ExportService, logger *slog.Logger, router *mux.Router) *ExportHandler {
+	return &ExportHandler{
+		service: service,
+		logger:  logger,
+		router:  router,
+	}
+}
+
+// StartExport godoc
+// @Summary Start a background user export
+// @Description Export every user, or those matching the filters, in the background, for exports too large
+// @Description to download in one request. The response is the export's job; poll it until its status is
+// @Description succeeded, then download the file, which is kept for 24 hours. Columns and filters work as
+// @Description for GET /users/export. Requires the users:read permission.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param export body models.ExportRequest true "Export JSON"
+// @Success 202 {object} respond.Envelope{data=models.Job}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/exports [post]
+func (h *ExportHandler) StartExport(w http.ResponseWriter, r *http.Request) {
+	var request models.ExportRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+	// Unknown columns are rejected now rather than failing the job
+	if _, err := selectExportColumns(request.Columns); err != nil {
+		writeError(w, r, h.logger, err, "Invalid columns")
+		return
+	}
+
+	job, err := h.service.StartExport(r.Context(), &request)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to start export")
+		return
+	}
+
+	if self, ok := routeLink(h.router, RouteGetExport, http.MethodGet, "id", job.Id.Hex()); ok {
+		w.Header().Set("Location", self.Href)
+	}
+	respond.Accepted(w, r, fmt.Sprintf("Export started with ID: %s", job.Id.Hex()), job)
+}
+
+// GetExport godoc
+// @Summary Get a background user export
+// @Description Retrieve the job of a background export to follow its progress. Requires the users:read
+// @Description permission.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Export ID"
+// @Success 200 {object} respond.Envelope{data=models.Job}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/exports/{id} [get]
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.GetExport(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get export")
+		return
+	}
+	respond.OK(w, r, "Export retrieved successfully", job)
+}
+
+// DownloadExport godoc
+// @Summary Download a background user export
+// @Description Download the file of a finished background export. Exports still running or that failed get
+// @Description 409, and ones older than 24 hours 404. Requires the users:read permission.
+// @Tags users
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Export ID"
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/exports/{id}/file [get]
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	file, data, err := h.service.OpenExport(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to download export")
+		return
+	}
+	defer data.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", file.ContentType)
+	header.Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, data); err != nil {
+		// The status is already sent, so all that is left is to cut the response short
+		h.logger.WarnContext(r.Context(), "Failed to stream export", slog.Any("error", err))
+	}
+}
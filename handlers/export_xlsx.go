@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxContentType is the media type of Excel workbooks.
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// xlsxSheetName names the worksheet and table an XLSX export puts its users in.
+const xlsxSheetName = "Users"
+
+// xlsxDateFormat displays dates the same way in every locale.
+var xlsxDateFormat = "yyyy-mm-dd hh:mm:ss"
+
+// errXLSXTooLarge is returned when the users to export do not fit in a worksheet.
+var errXLSXTooLarge = apperrors.Validation(fmt.Sprintf("XLSX exports hold at most %d users; use format=csv", excelize.TotalRows-1))
+
+// exportXLSX sends the users matching filter as an Excel workbook. A workbook is only complete
+// once every row is written, so it is built before anything is sent.
+func (h *UserHandler) exportXLSX(w http.ResponseWriter, r *http.Request, filter repositories.UserFilter, columns []exportColumn) {
+	file, err := buildXLSX(r.Context(), h.service, filter, columns)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to export users")
+		return
+	}
+	defer file.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", xlsxContentType)
+	header.Set("Content-Disposition", `attachment; filename="users.xlsx"`)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if err := file.Write(w); err != nil {
+		h.logger.ErrorContext(r.Context(), "User export failed partway", slog.Any("error", err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// buildXLSX returns a workbook of the users matching filter, which the caller must close.
+func buildXLSX(ctx context.Context, service UserService, filter repositories.UserFilter, columns []exportColumn) (*excelize.File, error) {
+	file := excelize.NewFile()
+	sheet, err := newXLSXSheet(file, columns)
+	if err == nil {
+		err = service.ExportUsers(ctx, filter, sheet.add)
+	}
+	if err == nil {
+		err = sheet.finish()
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// xlsxSheet writes an XLSX export's users to its worksheet, one row each below a header row.
+type xlsxSheet struct {
+	stream    *excelize.StreamWriter
+	columns   []exportColumn
+	dateStyle int
+	// rows counts the rows written so far, including the header
+	rows int
+	row  []interface{}
+}
+
+func newXLSXSheet(file *excelize.File, columns []exportColumn) (*xlsxSheet, error) {
+	// New workbooks start with a single empty worksheet
+	if err := file.SetSheetName(file.GetSheetName(0), xlsxSheetName); err != nil {
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		return nil, err
+	}
+	headerStyle, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+	dateStyle, err := file.NewStyle(&excelize.Style{CustomNumFmt: &xlsxDateFormat})
+	if err != nil {
+		return nil, err
+	}
+
+	// Column widths and the frozen header row have to come before the first row
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if err := stream.SetColWidth(i+1, i+1, column.width); err != nil {
+			return nil, err
+		}
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: column.name}
+	}
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, err
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+	return &xlsxSheet{
+		stream:    stream,
+		columns:   columns,
+		dateStyle: dateStyle,
+		rows:      1,
+		row:       make([]interface{}, len(columns)),
+	}, nil
+}
+
+// add writes the row of user. Strings are stored as text, so they are never evaluated as
+// formulas, and dates as dates.
+func (s *xlsxSheet) add(user *models.User) error {
+	if s.rows == excelize.TotalRows {
+		return errXLSXTooLarge
+	}
+	for i, column := range s.columns {
+		value := column.value(user)
+		if _, ok := value.(time.Time); ok {
+			value = excelize.Cell{StyleID: s.dateStyle, Value: value}
+		}
+		s.row[i] = value
+	}
+	s.rows++
+	cell, err := excelize.CoordinatesToCellName(1, s.rows)
+	if err != nil {
+		return err
+	}
+	return s.stream.SetRow(cell, s.row)
+}
+
+// finish turns the rows into a table, which spreadsheets show with filter and sort buttons, and
+// completes the worksheet.
+func (s *xlsxSheet) finish() error {
+	if s.rows > 1 {
+		end, err := excelize.CoordinatesToCellName(len(s.columns), s.rows)
+		if err != nil {
+			return err
+		}
+		if err := s.stream.AddTable(&excelize.Table{Range: "A1:" + end, Name: xlsxSheetName, StyleName: "TableStyleMedium2"}); err != nil {
+			return err
+		}
+	}
+	return s.stream.Flush()
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// FeatureFlagService is the business logic the feature flag handlers depend on.
+type FeatureFlagService interface {
+	ListFlags(ctx context.Context) ([]models.FeatureFlag, error)
+	GetFlag(ctx context.Context, key string) (*models.FeatureFlag, error)
+	PutFlag(ctx context.Context, key string, flag *models.FeatureFlag) (*models.FeatureFlag, error)
+	DeleteFlag(ctx context.Context, key string) error
+	EnabledFeatures(ctx context.Context) []string
+}
+
+type FeatureFlagHandler struct {
+	service FeatureFlagService
+	logger  *slog.Logger
+}
+
+func NewFeatureFlagHandler(service FeatureFlagService, logger *slog.Logger) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListFeatures godoc
+// @Summary List the features enabled for the caller
+// @Description List the keys of the feature flags that are on for the caller, sorted, so clients can show
+// @Description features that are still being rolled out. Rollouts pick signed-in users by their ID and
+// @Description anonymous callers by their tenant.
+// @Tags features
+// @Produce json
+// @Param Authorization header string false "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Success 200 {object} respond.Envelope{data=[]string}
+// @Failure 401 {object} problem.Problem
+// @Router /api/v1/features [get]
+func (h *FeatureFlagHandler) ListFeatures(w http.ResponseWriter, r *http.Request) {
+	respond.OK(w, r, "Features retrieved successfully", h.service.EnabledFeatures(r.Context()))
+}
+
+// ListFlags godoc
+// @Summary List feature flags
+// @Description List every feature flag ordered by key, with its rollout percentage and tenant overrides.
+// @Description Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Success 200 {object} respond.Envelope{data=[]models.FeatureFlag}
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/feature-flags [get]
+func (h *FeatureFlagHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.service.ListFlags(r.Context())
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list feature flags")
+		return
+	}
+	respond.OK(w, r, "Feature flags retrieved successfully", flags)
+}
+
+// GetFlag godoc
+// @Summary Get a feature flag by key
+// @Description Retrieve a feature flag. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param key path string true "Flag key"
+// @Success 200 {object} respond.Envelope{data=models.FeatureFlag}
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/feature-flags/{key} [get]
+func (h *FeatureFlagHandler) GetFlag(w http.ResponseWriter, r *http.Request) {
+	flag, err := h.service.GetFlag(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get feature flag")
+		return
+	}
+	respond.OK(w, r, "Feature flag retrieved successfully", flag)
+}
+
+// PutFlag godoc
+// @Summary Create or replace a feature flag
+// @Description Set a feature flag. An enabled flag is on for rollout percent of users (default 100), and
+// @Description tenants turns it on or off for whole tenants regardless. Other instances apply the change
+// @Description within FEATURE_FLAGS_CACHE_TTL. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param key path string true "Flag key"
+// @Param flag body models.FeatureFlag true "Feature flag JSON"
+// @Success 200 {object} respond.Envelope{data=models.FeatureFlag}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/feature-flags/{key} [put]
+func (h *FeatureFlagHandler) PutFlag(w http.ResponseWriter, r *http.Request) {
+	flag := models.FeatureFlag{Rollout: 100}
+	if err := decodeJSON(r, &flag); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	stored, err := h.service.PutFlag(r.Context(), mux.Vars(r)["key"], &flag)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to store feature flag")
+		return
+	}
+	respond.OK(w, r, "Feature flag stored successfully", stored)
+}
+
+// DeleteFlag godoc
+// @Summary Delete a feature flag
+// @Description Delete a feature flag, which turns it off everywhere. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param key path string true "Flag key"
+// @Success 200 {object} respond.Envelope
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/feature-flags/{key} [delete]
+func (h *FeatureFlagHandler) DeleteFlag(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteFlag(r.Context(), mux.Vars(r)["key"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete feature flag")
+		return
+	}
+	respond.OK(w, r, "Feature flag deleted successfully", nil)
+}
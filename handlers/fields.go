@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"example_api/apperrors"
+	models "example_api/models"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// selectableFields lists the user fields the fields parameter can select. The password hash is
+// not one of them, so sparse responses never include it.
+var selectableFields = []string{"id", "email", "firstName", "lastName", "phone", "phoneVerifiedAt", "addresses", "metadata", "role", "joinDate", "version", "erasedAt"}
+
+// fieldSet is the set of user fields a response includes. A nil set includes every field.
+type fieldSet map[string]bool
+
+// has reports whether the set includes the named field.
+func (s fieldSet) has(name string) bool {
+	return s == nil || s[name]
+}
+
+// names returns the fields of the set in selectableFields order, or nil for every field.
+func (s fieldSet) names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s))
+	for _, name := range selectableFields {
+		if s[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fieldsParam reads the comma-separated fields query parameter, such as fields=email,firstName.
+// JSON:API clients may send it as fields[users] instead. It returns nil when neither is set.
+func fieldsParam(r *http.Request) (fieldSet, error) {
+	query := r.URL.Query()
+	value, ok := query["fields"]
+	if !ok {
+		value, ok = query["fields[users]"]
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	fields := fieldSet{}
+	for _, name := range strings.Split(strings.Join(value, ","), ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(selectableFields, name) {
+			return nil, apperrors.Validation(fmt.Sprintf("Cannot select field %q; selectable fields are %s", name, strings.Join(selectableFields, ", ")))
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// sparseAttributes returns the fields of user in fields, other than id, by name.
+func sparseAttributes(user *models.User, fields fieldSet) map[string]interface{} {
+	attributes := make(map[string]interface{}, len(fields))
+	for name := range fields {
+		switch name {
+		case "email":
+			attributes[name] = user.Email
+		case "firstName":
+			attributes[name] = user.FirstName
+		case "lastName":
+			attributes[name] = user.LastName
+		case "phone":
+			attributes[name] = user.Phone
+		case "phoneVerifiedAt":
+			attributes[name] = user.PhoneVerifiedAt
+		case "addresses":
+			attributes[name] = user.Addresses
+		case "metadata":
+			attributes[name] = user.Metadata
+		case "role":
+			attributes[name] = user.Role
+		case "joinDate":
+			attributes[name] = user.JoinDate
+		case "version":
+			attributes[name] = user.Version
+		case "erasedAt":
+			attributes[name] = user.ErasedAt
+		}
+	}
+	return attributes
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteGetGroup names the group route, used for the Location of created groups.
+const RouteGetGroup = "groups.get"
+
+// GroupService is the business logic the group handlers depend on.
+type GroupService interface {
+	CreateGroup(ctx context.Context, group *models.Group) (*models.Group, error)
+	GetGroup(ctx context.Context, id string) (*models.Group, error)
+	UpdateGroup(ctx context.Context, id string, update *models.Group) (*models.Group, error)
+	DeleteGroup(ctx context.Context, id string) error
+	ListGroups(ctx context.Context, page, limit int) ([]models.Group, int64, error)
+	AddMembers(ctx context.Context, groupID string, addition *models.GroupMembersAddition) (*models.GroupMembersAdded, error)
+	RemoveMember(ctx context.Context, groupID, userID string) error
+	ListMembers(ctx context.Context, groupID string, page, limit int) ([]models.GroupMember, int64, error)
+	ListUserGroups(ctx context.Context, userID string, page, limit int) ([]models.Group, int64, error)
+}
+
+type GroupHandler struct {
+	service GroupService
+	logger  *slog.Logger
+	router  *mux.Router
+}
+
+func NewGroupHandler(service GroupService, logger *slog.Logger, router *mux.Router) *GroupHandler {
+	return &GroupHandler{
+		service: service,
+		logger:  logger,
+		router:  router,
+	}
+}
+
+// CreateGroup godoc
+// @Summary Create a group
+// @Description Create a group without members. Its members will hold its permissions on top of those of
+// @Description their role. Requires the groups:write permission.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param group body models.Group true "Group JSON"
+// @Success 201 {object} respond.Envelope{data=models.Group}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups [post]
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var group models.Group
+	if err := decodeJSON(r, &group); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	created, err := h.service.CreateGroup(r.Context(), &group)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to create group")
+		return
+	}
+
+	if self, ok := routeLink(h.router, RouteGetGroup, http.MethodGet, "id", created.Id.Hex()); ok {
+		w.Header().Set("Location", self.Href)
+	}
+	respond.Created(w, r, fmt.Sprintf("Group created successfully with ID: %s", created.Id.Hex()), created)
+}
+
+// ListGroups godoc
+// @Summary List groups
+// @Description Retrieve a page of groups ordered by ID. Requires the groups:read permission.
+// @Tags groups
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Group}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups [get]
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	groups, total, err := h.service.ListGroups(r.Context(), page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list groups")
+		return
+	}
+	respond.Page(w, r, "Groups retrieved successfully", groups, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// GetGroup godoc
+// @Summary Get a group by ID
+// @Description Retrieve a group and its permissions. Requires the groups:read permission.
+// @Tags groups
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Group ID"
+// @Success 200 {object} respond.Envelope{data=models.Group}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups/{id} [get]
+func (h *GroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := h.service.GetGroup(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get group")
+		return
+	}
+	respond.OK(w, r, "Group retrieved successfully", group)
+}
+
+// UpdateGroup godoc
+// @Summary Update a group
+// @Description Replace the name, description, and permissions of a group. Its members hold the new
+// @Description permissions from their next request. Requires the groups:write permission.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Group ID"
+// @Param group body models.Group true "Group JSON"
+// @Success 200 {object} respond.Envelope{data=models.Group}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups/{id} [put]
+func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	var update models.Group
+	if err := decodeJSON(r, &update); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	group, err := h.service.UpdateGroup(r.Context(), mux.Vars(r)["id"], &update)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update group")
+		return
+	}
+	respond.OK(w, r, "Group updated successfully", group)
+}
+
+// DeleteGroup godoc
+// @Summary Delete a group
+// @Description Delete a group and its memberships. Its members stay registered. Requires the groups:write
+// @Description permission.
+// @Tags groups
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Group ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups/{id} [delete]
+func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteGroup(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete group")
+		return
+	}
+	respond.OK(w, r, "Group deleted successfully", nil)
+}
+
+// ListMembers godoc
+// @Summary List group members
+// @Description Retrieve a page of a group's members ordered by user ID. Requires the groups:read permission.
+// @Tags groups
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Group ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.GroupMember}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups/{id}/members [get]
+func (h *GroupHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	members, total, err := h.service.ListMembers(r.Context(), mux.Vars(r)["id"], page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list members")
+		return
+	}
+	respond.Page(w, r, "Members retrieved successfully", members, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// AddMembers godoc
+// @Summary Add users to a group
+// @Description Add up to 100 existing users to a group at once. Users already in the group are skipped, so
+// @Description the response counts only those added. Requires the groups:write permission.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Group ID"
+// @Param members body models.GroupMembersAddition true "User IDs JSON"
+// @Success 200 {object} respond.Envelope{data=models.GroupMembersAdded}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups/{id}/members [post]
+func (h *GroupHandler) AddMembers(w http.ResponseWriter, r *http.Request) {
+	var addition models.GroupMembersAddition
+	if err := decodeJSON(r, &addition); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	added, err := h.service.AddMembers(r.Context(), mux.Vars(r)["id"], &addition)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to add members")
+		return
+	}
+	respond.OK(w, r, "Members added successfully", added)
+}
+
+// RemoveMember godoc
+// @Summary Remove a user from a group
+// @Description Take a user out of a group. Requires the groups:write permission.
+// @Tags groups
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "Group ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/groups/{id}/members/{userId} [delete]
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.service.RemoveMember(r.Context(), vars["id"], vars["userId"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to remove member")
+		return
+	}
+	respond.OK(w, r, "Member removed successfully", nil)
+}
+
+// ListUserGroups godoc
+// @Summary List a user's groups
+// @Description Retrieve a page of the groups a user is in ordered by ID. Requires the groups:read permission,
+// @Description except for the user's own groups.
+// @Tags groups
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Group}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/groups [get]
+func (h *GroupHandler) ListUserGroups(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	groups, total, err := h.service.ListUserGroups(r.Context(), mux.Vars(r)["id"], page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list groups")
+		return
+	}
+	respond.Page(w, r, "Groups retrieved successfully", groups, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"example_api/respond"
+	"net/http"
+	"time"
+)
+
+// Pinger reports whether a backing service is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	db Pinger
+}
+
+func NewHealthHandler(db Pinger) *HealthHandler {
+	return &HealthHandler{
+		db: db,
+	}
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description Report that the process is up and serving requests
+// @Tags health
+// @Produce json
+// @Success 200 {object} respond.Envelope
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	respond.OK(w, r, "OK", nil)
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Report whether the service can reach its database and is ready to receive traffic
+// @Tags health
+// @Produce json
+// @Success 200 {object} respond.Envelope
+// @Failure 503 {object} problem.Problem
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.db.Ping(ctx); err != nil {
+		respond.Error(w, r, http.StatusServiceUnavailable, "Database unavailable")
+		return
+	}
+
+	respond.OK(w, r, "Ready", nil)
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// importField is the multipart form field carrying the import file.
+const importField = "file"
+
+// Import file formats
+const (
+	importCSV    = "csv"
+	importNDJSON = "ndjson"
+)
+
+// UserImporter is the business logic the import handler depends on.
+type UserImporter interface {
+	ImportUsers(ctx context.Context, next func() (*models.User, int, error)) (*models.ImportReport, error)
+}
+
+type ImportHandler struct {
+	service UserImporter
+	maxSize int64
+	logger  *slog.Logger
+}
+
+func NewImportHandler(service UserImporter, maxSize int64, logger *slog.Logger) *ImportHandler {
+	return &ImportHandler{
+		service: service,
+		maxSize: maxSize,
+		logger:  logger,
+	}
+}
+
+// importColumn is a user field an import row can set.
+type importColumn struct {
+	name     string
+	required bool
+	set      func(*models.User, string)
+}
+
+// importColumns lists the columns a CSV import may have, in any order.
+var importColumns = []importColumn{
+	{"email", true, func(u *models.User, v string) { u.Email = v }},
+	{"password", true, func(u *models.User, v string) { u.Password = v }},
+	{"firstName", true, func(u *models.User, v string) { u.FirstName = v }},
+	{"lastName", true, func(u *models.User, v string) { u.LastName = v }},
+	{"phone", false, func(u *models.User, v string) { u.Phone = v }},
+	{"role", false, func(u *models.User, v string) { u.Role = v }},
+}
+
+// importRecord is one line of an NDJSON import.
+type importRecord struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Phone     string `json:"phone"`
+	Role      string `json:"role"`
+}
+
+// ImportUsers godoc
+// @Summary Import users
+// @Description Create users from a CSV file with a header row or an NDJSON file with one object per line.
+// @Description Rows set email, password, firstName, lastName, and optionally phone and role (user or
+// @Description admin). Send the file as the request body or as the file field of a multipart form; its
+// @Description format comes from the format parameter, the content type, or the file name. Rows are
+// @Description validated one by one: valid rows are created, and the report lists the line and reason of
+// @Description every other row. Imports are not subject to the request timeout. Requires ADMIN_TOKEN.
+// @Tags users
+// @Accept text/csv,application/x-ndjson,multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param format query string false "File format" Enums(csv, ndjson)
+// @Param file formData file false "Import file, when sending a multipart form"
+// @Success 200 {object} respond.Envelope{data=models.ImportReport}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 413 {object} problem.Problem
+// @Failure 415 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/import [post]
+func (h *ImportHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	file, format, err := h.openImport(w, r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid upload")
+		return
+	}
+	var next func() (*models.User, int, error)
+	if format == importCSV {
+		next, err = csvRows(file)
+	} else {
+		next = ndjsonRows(file)
+	}
+	if err != nil {
+		writeError(w, r, h.logger, h.readError(err), "Failed to read import")
+		return
+	}
+
+	report, err := h.service.ImportUsers(r.Context(), next)
+	if err != nil {
+		writeError(w, r, h.logger, h.readError(err), "Failed to import users")
+		return
+	}
+	respond.OK(w, r, fmt.Sprintf("Imported %d of %d users", report.Created, report.Total), report)
+}
+
+// openImport returns the import file of the request body, which is either the file itself or a
+// multipart form holding it, and its format.
+func (h *ImportHandler) openImport(w http.ResponseWriter, r *http.Request) (io.Reader, string, error) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != importCSV && format != importNDJSON {
+		return nil, "", apperrors.Validation("Format must be csv or ndjson")
+	}
+	unsupported := apperrors.UnsupportedMediaType("Send a CSV or NDJSON file, or name its format with the format parameter")
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxSize)
+		if format == "" {
+			format = importFormat(contentType, "")
+		}
+		if format == "" {
+			return nil, "", unsupported
+		}
+		return r.Body, format, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+MultipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", apperrors.Validation("Malformed multipart body")
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", apperrors.Validation("Missing " + importField + " file")
+		}
+		if err != nil {
+			if err := h.readError(err); apperrors.HTTPStatus(err) == http.StatusRequestEntityTooLarge {
+				return nil, "", err
+			}
+			return nil, "", apperrors.Validation("Malformed multipart body")
+		}
+		if part.FormName() != importField {
+			continue
+		}
+		if format == "" {
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			format = importFormat(partType, part.FileName())
+		}
+		if format == "" {
+			return nil, "", unsupported
+		}
+		// The outer limit leaves room for the rest of the form, so the file needs its own
+		return http.MaxBytesReader(w, part, h.maxSize), format, nil
+	}
+}
+
+// importFormat picks the format of an import file from its content type or, failing that, the
+// extension of its name. It returns an empty string when neither is recognised.
+func importFormat(contentType, filename string) string {
+	switch contentType {
+	case "text/csv":
+		return importCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return importNDJSON
+	}
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return importCSV
+	case ".ndjson", ".jsonl":
+		return importNDJSON
+	}
+	return ""
+}
+
+// readError reports a body larger than the import limit as such and returns other errors as is.
+func (h *ImportHandler) readError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return apperrors.TooLarge(fmt.Sprintf("Import file must not exceed %d bytes", h.maxSize))
+	}
+	return err
+}
+
+// csvRows reads the header row of a CSV import and returns a function yielding the user of each
+// row after it. A malformed row is reported as a validation error, so reading carries on past it.
+func csvRows(file io.Reader) (func() (*models.User, int, error), error) {
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, apperrors.Validation("The import file is empty")
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, apperrors.Validation("Malformed CSV header: " + parseErr.Err.Error())
+		}
+		return nil, err
+	}
+
+	setters := make([]func(*models.User, string), len(header))
+	present := make(map[string]bool, len(header))
+	for i, name := range header {
+		// Spreadsheets often start their CSV files with a byte order mark
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.TrimSpace(name)
+		column, ok := findImportColumn(name)
+		if !ok {
+			return nil, apperrors.Validation(fmt.Sprintf("Unknown column %q; columns are %s", name, strings.Join(importColumnNames(), ", ")))
+		}
+		if present[name] {
+			return nil, apperrors.Validation(fmt.Sprintf("Column %q appears more than once", name))
+		}
+		present[name] = true
+		setters[i] = column.set
+	}
+	for _, column := range importColumns {
+		if column.required && !present[column.name] {
+			return nil, apperrors.Validation(fmt.Sprintf("Missing column %q", column.name))
+		}
+	}
+
+	reader.ReuseRecord = true
+	return func() (*models.User, int, error) {
+		record, err := reader.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, parseErr.StartLine, apperrors.Validation("Malformed CSV row: " + parseErr.Err.Error())
+			}
+			return nil, 0, err
+		}
+		line, _ := reader.FieldPos(0)
+		user := &models.User{}
+		for i, value := range record {
+			setters[i](user, value)
+		}
+		return user, line, nil
+	}, nil
+}
+
+func findImportColumn(name string) (importColumn, bool) {
+	for _, column := range importColumns {
+		if column.name == name {
+			return column, true
+		}
+	}
+	return importColumn{}, false
+}
+
+func importColumnNames() []string {
+	names := make([]string, len(importColumns))
+	for i, column := range importColumns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// ndjsonRows returns a function yielding the user of each line of an NDJSON import, skipping
+// blank lines. A line that is not a JSON object is reported as a validation error.
+func ndjsonRows(file io.Reader) func() (*models.User, int, error) {
+	scanner := bufio.NewScanner(file)
+	line := 0
+	return func() (*models.User, int, error) {
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+			var record importRecord
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			if err := jsonError(decoder.Decode(&record)); err != nil {
+				return nil, line, err
+			}
+			if decoder.More() {
+				return nil, line, apperrors.Validation("Each line must hold a single JSON object")
+			}
+			return &models.User{
+				Email:     record.Email,
+				Password:  record.Password,
+				FirstName: record.FirstName,
+				LastName:  record.LastName,
+				Phone:     record.Phone,
+				Role:      record.Role,
+			}, line, nil
+		}
+		if err := scanner.Err(); err != nil {
+			if errors.Is(err, bufio.ErrTooLong) {
+				// The scanner cannot continue past a long line, so this ends the import
+				return nil, 0, apperrors.TooLarge(fmt.Sprintf("Line %d is longer than %d bytes", line+1, bufio.MaxScanTokenSize))
+			}
+			return nil, 0, err
+		}
+		return nil, 0, io.EOF
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// JobService is the business logic the job queue handlers depend on.
+type JobService interface {
+	ListJobs(ctx context.Context, filter repositories.JobFilter, page, limit int) ([]models.Job, int64, error)
+	RetryJob(ctx context.Context, id string) (*models.Job, error)
+}
+
+type JobHandler struct {
+	service JobService
+	logger  *slog.Logger
+}
+
+func NewJobHandler(service JobService, logger *slog.Logger) *JobHandler {
+	return &JobHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListJobs godoc
+// @Summary List background jobs
+// @Description Retrieve a page of background jobs, newest first. Jobs that failed for good or ran out of
+// @Description attempts are dead; listing them shows the dead letter queue. Finished jobs are kept for 14
+// @Description days. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param status query string false "Only jobs with this status" Enums(pending, running, succeeded, dead)
+// @Param type query string false "Only jobs of this type, such as email.welcome, users.export, or webhook.deliver"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Job}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/jobs [get]
+func (h *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	jobs, total, err := h.service.ListJobs(r.Context(), repositories.JobFilter{Status: query.Get("status"), Type: query.Get("type")}, page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list jobs")
+		return
+	}
+	respond.Page(w, r, "Jobs retrieved successfully", jobs, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// RetryJob godoc
+// @Summary Retry a dead job
+// @Description Move a job out of the dead letter queue to run again right away, with its attempts reset.
+// @Description Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param id path string true "Job ID"
+// @Success 200 {object} respond.Envelope{data=models.Job}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/jobs/{id}/retry [post]
+func (h *JobHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.RetryJob(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to retry job")
+		return
+	}
+	respond.OK(w, r, "Job queued for retry", job)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"example_api/dto"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/respond"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Names of the user routes, used to generate links. Only the canonical /api/v1 routes are named.
+const (
+	RouteListUsers  = "users.list"
+	RouteCreateUser = "users.create"
+	RouteGetUser    = "users.get"
+	RouteUpdateUser = "users.update"
+	RouteDeleteUser = "users.delete"
+)
+
+// userResource is a user together with the links clients can follow from it.
+type userResource struct {
+	dto.User
+	Links map[string]respond.Link `json:"links,omitempty"`
+}
+
+// routeLink returns a link to the named route, or false if the route is not registered.
+func routeLink(router *mux.Router, name, method string, pairs ...string) (respond.Link, bool) {
+	route := router.Get(name)
+	if route == nil {
+		return respond.Link{}, false
+	}
+	u, err := route.URL(pairs...)
+	if err != nil {
+		return respond.Link{}, false
+	}
+	return respond.Link{Href: u.String(), Method: method}, true
+}
+
+// userLinks returns the self, update, delete, and collection links of user.
+func (h *UserHandler) userLinks(user *models.User) map[string]respond.Link {
+	id := user.Id.Hex()
+	links := map[string]respond.Link{}
+	for rel, route := range map[string]struct{ name, method string }{
+		"self":   {RouteGetUser, http.MethodGet},
+		"update": {RouteUpdateUser, http.MethodPut},
+		"delete": {RouteDeleteUser, http.MethodDelete},
+	} {
+		if link, ok := routeLink(h.router, route.name, route.method, "id", id); ok {
+			links[rel] = link
+		}
+	}
+	if link, ok := routeLink(h.router, RouteListUsers, http.MethodGet); ok {
+		links["collection"] = link
+	}
+	return links
+}
+
+// userResource wraps user with its links. When fields is set, only those fields and the id are
+// included.
+func (h *UserHandler) userResource(user *models.User, fields fieldSet) interface{} {
+	if fields == nil {
+		return userResource{User: dto.NewUser(user), Links: h.userLinks(user)}
+	}
+	resource := sparseAttributes(user, fields)
+	resource["id"] = user.Id
+	resource["links"] = h.userLinks(user)
+	return resource
+}
+
+// userResources wraps every user in users with its links, like userResource.
+func (h *UserHandler) userResources(users []models.User, fields fieldSet) []interface{} {
+	resources := make([]interface{}, len(users))
+	for i := range users {
+		resources[i] = h.userResource(&users[i], fields)
+	}
+	return resources
+}
+
+// pageLinks returns the self, first, last, and where they exist prev and next links of a list
+// page, keeping the filter, sort, and fields in every link.
+func (h *UserHandler) pageLinks(page, limit int, total int64, filter repositories.UserFilter, sort string, fields fieldSet) map[string]respond.Link {
+	base, ok := routeLink(h.router, RouteListUsers, http.MethodGet)
+	if !ok {
+		return nil
+	}
+
+	lastPage := int((total + int64(limit) - 1) / int64(limit))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+	at := func(p int) respond.Link {
+		query := url.Values{"page": {strconv.Itoa(p)}, "limit": {strconv.Itoa(limit)}}
+		if filter.Role != "" {
+			query.Set("role", filter.Role)
+		}
+		if filter.Email != "" {
+			query.Set("email", filter.Email)
+		}
+		if filter.Expression != "" {
+			query.Set("filter", filter.Expression)
+		}
+		if sort != "" {
+			query.Set("sort", sort)
+		}
+		if fields != nil {
+			query.Set("fields", strings.Join(fields.names(), ","))
+		}
+		return respond.Link{Href: base.Href + "?" + query.Encode(), Method: http.MethodGet}
+	}
+
+	links := map[string]respond.Link{
+		"self":  at(page),
+		"first": at(1),
+		"last":  at(lastPage),
+	}
+	if page > 1 {
+		links["prev"] = at(min(page-1, lastPage))
+	}
+	if page < lastPage {
+		links["next"] = at(page + 1)
+	}
+	return links
+}
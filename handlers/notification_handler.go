@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NotificationService is the business logic the notification handlers depend on.
+type NotificationService interface {
+	SendNotice(ctx context.Context, userID string, notice *models.Notice) (*models.Notification, error)
+	ListNotifications(ctx context.Context, userID string, unreadOnly bool, page, limit int) ([]models.Notification, int64, error)
+	MarkRead(ctx context.Context, userID, id string) (*models.Notification, error)
+	MarkAllRead(ctx context.Context, userID string) (*models.NotificationsRead, error)
+	DeleteNotification(ctx context.Context, userID, id string) error
+}
+
+type NotificationHandler struct {
+	service NotificationService
+	logger  *slog.Logger
+}
+
+func NewNotificationHandler(service NotificationService, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListNotifications godoc
+// @Summary List a user's notifications
+// @Description Retrieve a page of a user's notifications, newest first. Notifications are kept for 90 days.
+// @Description Requires the notifications:read permission, except for the user's own notifications.
+// @Tags notifications
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param unread query bool false "Only list notifications not yet read"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Notification}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/notifications [get]
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	unread, err := queryBool(r, "unread")
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid unread; use true or false")
+		return
+	}
+
+	notifications, total, err := h.service.ListNotifications(r.Context(), mux.Vars(r)["id"], unread, page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list notifications")
+		return
+	}
+	respond.Page(w, r, "Notifications retrieved successfully", notifications, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// SendNotice godoc
+// @Summary Send a user a notice
+// @Description Create a notification of type notice for a user, with a message and optional data. Erased
+// @Description users cannot be sent notices. Requires the notifications:write permission.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param notice body models.Notice true "Notice JSON"
+// @Success 201 {object} respond.Envelope{data=models.Notification}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/notifications [post]
+func (h *NotificationHandler) SendNotice(w http.ResponseWriter, r *http.Request) {
+	var notice models.Notice
+	if err := decodeJSON(r, &notice); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	notification, err := h.service.SendNotice(r.Context(), mux.Vars(r)["id"], &notice)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to send notice")
+		return
+	}
+	respond.Created(w, r, "Notice sent successfully", notification)
+}
+
+// MarkAllRead godoc
+// @Summary Mark all of a user's notifications read
+// @Description Mark every unread notification of a user read and report how many there were. Requires the
+// @Description notifications:write permission, except for the user's own notifications.
+// @Tags notifications
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Success 200 {object} respond.Envelope{data=models.NotificationsRead}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/notifications/read [post]
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	read, err := h.service.MarkAllRead(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to mark notifications read")
+		return
+	}
+	respond.OK(w, r, "Notifications marked read successfully", read)
+}
+
+// MarkRead godoc
+// @Summary Mark a notification read
+// @Description Mark one of a user's notifications read. Marking it again keeps the first read time. Requires
+// @Description the notifications:write permission, except for the user's own notifications.
+// @Tags notifications
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param notificationId path string true "Notification ID"
+// @Success 200 {object} respond.Envelope{data=models.Notification}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/notifications/{notificationId}/read [post]
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	notification, err := h.service.MarkRead(r.Context(), vars["id"], vars["notificationId"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to mark notification read")
+		return
+	}
+	respond.OK(w, r, "Notification marked read successfully", notification)
+}
+
+// DeleteNotification godoc
+// @Summary Delete a notification
+// @Description Delete one of a user's notifications. Requires the notifications:write permission, except for
+// @Description the user's own notifications.
+// @Tags notifications
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param notificationId path string true "Notification ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/notifications/{notificationId} [delete]
+func (h *NotificationHandler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.service.DeleteNotification(r.Context(), vars["id"], vars["notificationId"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete notification")
+		return
+	}
+	respond.OK(w, r, "Notification deleted successfully", nil)
+}
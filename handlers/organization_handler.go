@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteGetOrganization names the organization route, used for the Location of created
+// organizations.
+const RouteGetOrganization = "organizations.get"
+
+// OrganizationService is the business logic the organization handlers depend on.
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, org *models.Organization) (*models.Organization, error)
+	GetOrganization(ctx context.Context, id string) (*models.Organization, error)
+	UpdateOrganization(ctx context.Context, id string, update *models.Organization) (*models.Organization, error)
+	DeleteOrganization(ctx context.Context, id string) error
+	ListOrganizations(ctx context.Context, page, limit int) ([]models.Organization, int64, error)
+	InviteMember(ctx context.Context, orgID string, invitation *models.Invitation) (*models.Membership, error)
+	AcceptInvitation(ctx context.Context, orgID, userID string) (*models.Membership, error)
+	UpdateMember(ctx context.Context, orgID, userID string, update *models.MembershipUpdate) (*models.Membership, error)
+	RemoveMember(ctx context.Context, orgID, userID string) error
+	ListMembers(ctx context.Context, orgID string, page, limit int) ([]models.Membership, int64, error)
+	ListUserMemberships(ctx context.Context, userID string, page, limit int) ([]models.Membership, int64, error)
+}
+
+type OrganizationHandler struct {
+	service OrganizationService
+	logger  *slog.Logger
+	router  *mux.Router
+}
+
+func NewOrganizationHandler(service OrganizationService, logger *slog.Logger, router *mux.Router) *OrganizationHandler {
+	return &OrganizationHandler{
+		service: service,
+		logger:  logger,
+		router:  router,
+	}
+}
+
+// CreateOrganization godoc
+// @Summary Create an organization
+// @Description Create an organization without members; invite users to it next
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param organization body models.Organization true "Organization JSON"
+// @Success 201 {object} respond.Envelope{data=models.Organization}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations [post]
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var org models.Organization
+	if err := decodeJSON(r, &org); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	created, err := h.service.CreateOrganization(r.Context(), &org)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to create organization")
+		return
+	}
+
+	if self, ok := routeLink(h.router, RouteGetOrganization, http.MethodGet, "id", created.Id.Hex()); ok {
+		w.Header().Set("Location", self.Href)
+	}
+	respond.Created(w, r, fmt.Sprintf("Organization created successfully with ID: %s", created.Id.Hex()), created)
+}
+
+// ListOrganizations godoc
+// @Summary List organizations
+// @Description Retrieve a page of organizations ordered by ID
+// @Tags organizations
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Organization}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations [get]
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	orgs, total, err := h.service.ListOrganizations(r.Context(), page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list organizations")
+		return
+	}
+	respond.Page(w, r, "Organizations retrieved successfully", orgs, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// GetOrganization godoc
+// @Summary Get an organization by ID
+// @Description Retrieve an organization
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} respond.Envelope{data=models.Organization}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id} [get]
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := h.service.GetOrganization(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get organization")
+		return
+	}
+	respond.OK(w, r, "Organization retrieved successfully", org)
+}
+
+// UpdateOrganization godoc
+// @Summary Rename an organization
+// @Description Replace the name of an organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param organization body models.Organization true "Organization JSON"
+// @Success 200 {object} respond.Envelope{data=models.Organization}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id} [put]
+func (h *OrganizationHandler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	var update models.Organization
+	if err := decodeJSON(r, &update); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	org, err := h.service.UpdateOrganization(r.Context(), mux.Vars(r)["id"], &update)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update organization")
+		return
+	}
+	respond.OK(w, r, "Organization updated successfully", org)
+}
+
+// DeleteOrganization godoc
+// @Summary Delete an organization
+// @Description Delete an organization and its memberships. Its members stay registered.
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id} [delete]
+func (h *OrganizationHandler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteOrganization(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete organization")
+		return
+	}
+	respond.OK(w, r, "Organization deleted successfully", nil)
+}
+
+// ListMembers godoc
+// @Summary List organization members
+// @Description Retrieve a page of an organization's memberships, pending invitations included, oldest first
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Membership}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id}/members [get]
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	memberships, total, err := h.service.ListMembers(r.Context(), mux.Vars(r)["id"], page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list members")
+		return
+	}
+	respond.Page(w, r, "Members retrieved successfully", memberships, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// InviteMember godoc
+// @Summary Invite a user to an organization
+// @Description Invite an existing user to an organization with an org-level role: owner, admin, or member,
+// @Description the default. The membership stays invited until the user accepts. Users can be invited to
+// @Description an organization once; change the role of an invitation with the member update instead.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param invitation body models.Invitation true "Invitation JSON"
+// @Success 201 {object} respond.Envelope{data=models.Membership}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id}/members [post]
+func (h *OrganizationHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	var invitation models.Invitation
+	if err := decodeJSON(r, &invitation); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	membership, err := h.service.InviteMember(r.Context(), mux.Vars(r)["id"], &invitation)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to invite member")
+		return
+	}
+	respond.Created(w, r, fmt.Sprintf("User %s invited successfully", membership.UserID.Hex()), membership)
+}
+
+// UpdateMember godoc
+// @Summary Assign an org-level role
+// @Description Give a member, or a pending invitation, a new role in the organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Param membership body models.MembershipUpdate true "Role JSON"
+// @Success 200 {object} respond.Envelope{data=models.Membership}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id}/members/{userId} [put]
+func (h *OrganizationHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	var update models.MembershipUpdate
+	if err := decodeJSON(r, &update); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	vars := mux.Vars(r)
+	membership, err := h.service.UpdateMember(r.Context(), vars["id"], vars["userId"], &update)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update member")
+		return
+	}
+	respond.OK(w, r, "Member updated successfully", membership)
+}
+
+// RemoveMember godoc
+// @Summary Remove a member from an organization
+// @Description Remove a member from an organization, or withdraw their invitation
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.service.RemoveMember(r.Context(), vars["id"], vars["userId"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to remove member")
+		return
+	}
+	respond.OK(w, r, "Member removed successfully", nil)
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Make an invited user a member of the organization. Accepting again changes nothing.
+// @Tags organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} respond.Envelope{data=models.Membership}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/organizations/{id}/members/{userId}/accept [post]
+func (h *OrganizationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	membership, err := h.service.AcceptInvitation(r.Context(), vars["id"], vars["userId"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to accept invitation")
+		return
+	}
+	respond.OK(w, r, "Invitation accepted successfully", membership)
+}
+
+// ListUserMemberships godoc
+// @Summary List a user's organizations
+// @Description Retrieve a page of a user's memberships, pending invitations included, oldest first
+// @Tags organizations
+// @Produce json
+// @Param id path string true "User ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Membership}
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/organizations [get]
+func (h *OrganizationHandler) ListUserMemberships(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	memberships, total, err := h.service.ListUserMemberships(r.Context(), mux.Vars(r)["id"], page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list memberships")
+		return
+	}
+	respond.Page(w, r, "Memberships retrieved successfully", memberships, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
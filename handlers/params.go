@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	models "example_api/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryInt parses the named query parameter as an integer, returning def when it is absent.
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// queryBool parses the named query parameter as a boolean, returning false when it is absent.
+func queryBool(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// queryTime parses the named query parameter as an RFC 3339 time, returning the zero time when
+// it is absent.
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// userETag returns the entity tag of a user, which changes whenever its version does.
+func userETag(user *models.User) string {
+	return fmt.Sprintf(`"%d"`, user.Version)
+}
+
+// ifMatchVersion returns the user version named by the If-Match header, or 0 when the header is
+// absent or "*". Weak tags are accepted since gzip weakens the tags it serves.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" || tag == "*" {
+		return 0, nil
+	}
+	tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+	return strconv.ParseInt(tag, 10, 64)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	models "example_api/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// avatarExtensions names the file extension of each avatar content type.
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// dataExportManifest describes the files of a personal data export.
+type dataExportManifest struct {
+	UserID      string           `json:"userId"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+	Files       []dataExportFile `json:"files"`
+}
+
+type dataExportFile struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Description string `json:"description"`
+}
+
+// dataExportProfile is the profile file of a personal data export. Unlike models.User it has
+// no password field, since the hash is never exported.
+type dataExportProfile struct {
+	ID        string     `json:"id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Phone     string     `json:"phone,omitempty"`
+	Role      string     `json:"role"`
+	JoinDate  time.Time  `json:"joinDate"`
+	Version   int64      `json:"version"`
+	ErasedAt  *time.Time `json:"erasedAt,omitempty"`
+
+	PhoneVerifiedAt *time.Time          `json:"phoneVerifiedAt,omitempty"`
+	Addresses       []models.Address    `json:"addresses,omitempty"`
+	Preferences     *models.Preferences `json:"preferences,omitempty"`
+	Metadata        models.Metadata     `json:"metadata,omitempty"`
+}
+
+// ExportUserData godoc
+// @Summary Export a user's personal data
+// @Description Download everything stored about a user as a ZIP archive, to answer a data subject access
+// @Description request. manifest.json lists and describes the other files: the profile, the audit log
+// @Description entries about the user, their notifications, and their organization and group memberships
+// @Description as JSON, and every stored size of the avatar. The password hash is never included.
+// @Description Requires ADMIN_TOKEN.
+// @Tags users
+// @Produce application/zip
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param id path string true "User ID"
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/export [get]
+func (h *UserHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	export, err := h.service.ExportUserData(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to export user data")
+		return
+	}
+	archive, err := dataExportArchive(export)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to export user data")
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "application/zip")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s.zip"`, export.User.Id.Hex()))
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.Write(archive)
+}
+
+// dataExportArchive packs export into a ZIP archive with a manifest of its files.
+func dataExportArchive(export *models.UserDataExport) ([]byte, error) {
+	user := export.User
+	manifest := dataExportManifest{UserID: user.Id.Hex(), GeneratedAt: export.GeneratedAt}
+	files := map[string][]byte{}
+	add := func(name, contentType, description string, data []byte) {
+		manifest.Files = append(manifest.Files, dataExportFile{Name: name, ContentType: contentType, Description: description})
+		files[name] = data
+	}
+
+	profile, err := json.MarshalIndent(dataExportProfile{
+		ID:        user.Id.Hex(),
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Phone:     user.Phone,
+		Role:      user.Role,
+		JoinDate:  user.JoinDate.UTC(),
+		Version:   user.Version,
+		ErasedAt:  user.ErasedAt,
+
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		Addresses:       user.Addresses,
+		Preferences:     user.Preferences,
+		Metadata:        user.Metadata,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	add("profile.json", "application/json", "Account profile", profile)
+	auditLog, err := json.MarshalIndent(export.AuditLog, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	add("audit-log.json", "application/json", "Changes made to the account, newest first", auditLog)
+	notifications, err := json.MarshalIndent(export.Notifications, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	add("notifications.json", "application/json", "Notifications sent to the account, newest first", notifications)
+	memberships, err := json.MarshalIndent(export.Memberships, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	add("organizations.json", "application/json", "Memberships in and invitations to organizations", memberships)
+	groups, err := json.MarshalIndent(export.Groups, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	add("groups.json", "application/json", "Groups the account is in", groups)
+	for _, avatar := range export.Avatars {
+		name := "avatar/" + avatar.Avatar.Variant + avatarExtensions[avatar.Avatar.ContentType]
+		add(name, avatar.Avatar.ContentType, fmt.Sprintf("Avatar, %s size, uploaded %s", avatar.Avatar.Variant, avatar.Avatar.UpdatedAt.UTC().Format(time.RFC3339)), avatar.Data)
+	}
+
+	var buf bytes.Buffer
+	out := zip.NewWriter(&buf)
+	write := func(name string, data []byte) error {
+		file, err := out.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: export.GeneratedAt})
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(data)
+		return err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := write("manifest.json", manifestData); err != nil {
+		return nil, err
+	}
+	for _, file := range manifest.Files {
+		if err := write(file.Name, files[file.Name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EraseUser godoc
+// @Summary Erase a user's personal data
+// @Description Irreversibly anonymize a user for a right to erasure request. The email is replaced by a
+// @Description placeholder, the names and password hash are cleared, and the avatar is deleted. The
+// @Description record stays as a tombstone with erasedAt set and can no longer be changed. Erasing a
+// @Description user again is harmless. Requires ADMIN_TOKEN.
+// @Tags users
+// @Produce json,application/vnd.api+json,xml
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param id path string true "User ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/erase [post]
+func (h *UserHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.EraseUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to erase user")
+		return
+	}
+	h.writeUser(w, r, http.StatusOK, "User erased successfully", user)
+}
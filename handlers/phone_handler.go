@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// PhoneVerificationService is the business logic the phone verification handlers depend on.
+type PhoneVerificationService interface {
+	SendCode(ctx context.Context, id string) (*models.PhoneVerification, error)
+	ConfirmCode(ctx context.Context, id string, confirmation *models.PhoneCodeConfirmation) (*models.PhoneVerification, error)
+}
+
+type PhoneHandler struct {
+	service PhoneVerificationService
+	// resendInterval is how long clients are told to wait before requesting another code
+	resendInterval time.Duration
+	logger         *slog.Logger
+}
+
+func NewPhoneHandler(service PhoneVerificationService, resendInterval time.Duration, logger *slog.Logger) *PhoneHandler {
+	return &PhoneHandler{
+		service:        service,
+		resendInterval: resendInterval,
+		logger:         logger,
+	}
+}
+
+// SendPhoneCode godoc
+// @Summary Send a phone verification code
+// @Description Text a six-digit code to a user's phone number, to be confirmed within 10 minutes. A new code
+// @Description replaces the last one and may be requested once a minute; sooner requests get 429 with
+// @Description Retry-After. The user must have an unverified phone number. Requires the users:write
+// @Description permission, except for the user's own number.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Success 200 {object} respond.Envelope{data=models.PhoneVerification}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/phone/verification [post]
+func (h *PhoneHandler) SendPhoneCode(w http.ResponseWriter, r *http.Request) {
+	verification, err := h.service.SendCode(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTooManyRequests) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(h.resendInterval/time.Second)))
+		}
+		writeError(w, r, h.logger, err, "Failed to send verification code")
+		return
+	}
+	respond.OK(w, r, "Verification code sent successfully", verification)
+}
+
+// ConfirmPhoneCode godoc
+// @Summary Confirm a phone verification code
+// @Description Verify a user's phone number with the code last texted to it. Five wrong codes void it, and
+// @Description changing the number voids it too. Requires the users:write permission, except for the
+// @Description user's own number.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param confirmation body models.PhoneCodeConfirmation true "Code JSON"
+// @Success 200 {object} respond.Envelope{data=models.PhoneVerification}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/phone/verification/confirm [post]
+func (h *PhoneHandler) ConfirmPhoneCode(w http.ResponseWriter, r *http.Request) {
+	var confirmation models.PhoneCodeConfirmation
+	if err := decodeJSON(r, &confirmation); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	verification, err := h.service.ConfirmCode(r.Context(), mux.Vars(r)["id"], &confirmation)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to verify phone number")
+		return
+	}
+	respond.OK(w, r, "Phone number verified successfully", verification)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// PreferencesService is the business logic the preferences handlers depend on.
+type PreferencesService interface {
+	GetPreferences(ctx context.Context, id string) (*models.Preferences, error)
+	UpdatePreferences(ctx context.Context, id string, preferences *models.Preferences) (*models.Preferences, error)
+}
+
+type PreferencesHandler struct {
+	service PreferencesService
+	logger  *slog.Logger
+}
+
+func NewPreferencesHandler(service PreferencesService, logger *slog.Logger) *PreferencesHandler {
+	return &PreferencesHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetPreferences godoc
+// @Summary Get a user's preferences
+// @Description Retrieve a user's application settings: their theme, language, and the notification channels
+// @Description they opted in to. Users who have not set any get the defaults. Requires the users:read
+// @Description permission, except for the user's own preferences.
+// @Tags users
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Success 200 {object} respond.Envelope{data=models.Preferences}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/preferences [get]
+func (h *PreferencesHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	preferences, err := h.service.GetPreferences(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to retrieve preferences")
+		return
+	}
+	respond.OK(w, r, "Preferences retrieved successfully", preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary Update a user's preferences
+// @Description Replace a user's application settings. The theme is system, light, or dark, and the locale one
+// @Description of the languages messages are translated to; either may be left out for the default. Every
+// @Description notification channel left out is opted out of. Requires the users:write permission, except
+// @Description for the user's own preferences.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param preferences body models.Preferences true "Preferences JSON"
+// @Success 200 {object} respond.Envelope{data=models.Preferences}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/preferences [put]
+func (h *PreferencesHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var preferences models.Preferences
+	if err := decodeJSON(r, &preferences); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	updated, err := h.service.UpdatePreferences(r.Context(), mux.Vars(r)["id"], &preferences)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update preferences")
+		return
+	}
+	respond.OK(w, r, "Preferences updated successfully", updated)
+}
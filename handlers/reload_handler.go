@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+)
+
+// Reloader reads the configuration again and applies the settings that can change while the
+// server runs.
+type Reloader interface {
+	Reload(ctx context.Context) (*models.RuntimeSettings, error)
+}
+
+type ReloadHandler struct {
+	reloader Reloader
+	logger   *slog.Logger
+}
+
+func NewReloadHandler(reloader Reloader, logger *slog.Logger) *ReloadHandler {
+	return &ReloadHandler{
+		reloader: reloader,
+		logger:   logger,
+	}
+}
+
+// Reload godoc
+// @Summary Reload the configuration
+// @Description Read the configuration again, as SIGHUP does, and apply LOG_LEVEL and FEATURE_FLAGS_CACHE_TTL
+// @Description without a restart. Feature flags are read from the database again too. Other settings take
+// @Description effect on restart, and an invalid configuration is rejected, keeping the settings in effect.
+// @Description Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Success 200 {object} respond.Envelope{data=models.RuntimeSettings}
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/reload [post]
+func (h *ReloadHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.reloader.Reload(r.Context())
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to reload the configuration")
+		return
+	}
+	respond.OK(w, r, "Configuration reloaded", settings)
+}
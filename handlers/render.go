@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"example_api/dto"
+	"example_api/mediatype"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/respond"
+	"net/http"
+)
+
+// jsonAPIUser converts user to a JSON:API resource object with the attributes in fields.
+func (h *UserHandler) jsonAPIUser(user *models.User, fields fieldSet) respond.Resource {
+	links := h.userLinks(user)
+	resource := respond.Resource{
+		Type:  "users",
+		ID:    user.Id.Hex(),
+		Links: map[string]string{"self": links["self"].Href},
+	}
+	if fields != nil {
+		resource.Attributes = sparseAttributes(user, fields)
+		return resource
+	}
+	resource.Attributes = dto.NewUser(user).UserAttributes
+	return resource
+}
+
+// negotiateUsers picks the representation of user responses from the Accept header.
+func negotiateUsers(r *http.Request) string {
+	return mediatype.Negotiate(r, mediatype.JSON, mediatype.JSONAPI, mediatype.XML)
+}
+
+// writeUser renders one user as an envelope or, when the client asks for it, a JSON:API or XML document.
+func (h *UserHandler) writeUser(w http.ResponseWriter, r *http.Request, status int, message string, user *models.User) {
+	h.writeUserFields(w, r, status, message, user, nil)
+}
+
+// writeUserFields renders one user like writeUser, with only the fields in fields.
+func (h *UserHandler) writeUserFields(w http.ResponseWriter, r *http.Request, status int, message string, user *models.User, fields fieldSet) {
+	varyAccept(w)
+	message = respond.Message(w, r, message)
+	switch negotiateUsers(r) {
+	case mediatype.JSONAPI:
+		respond.JSONAPI(w, status, respond.Document{
+			Data: h.jsonAPIUser(user, fields),
+			Meta: map[string]interface{}{"message": message},
+		})
+	case mediatype.XML:
+		element := h.xmlUserOf(user, fields)
+		writeXML(w, status, xmlResponse{Status: status, Message: message, User: &element})
+	default:
+		respond.JSON(w, status, respond.Envelope{Status: status, Message: message, Data: h.userResource(user, fields)})
+	}
+}
+
+// writeUserPage renders one page of users, with only the fields in fields, as an envelope or a
+// JSON:API or XML document.
+func (h *UserHandler) writeUserPage(w http.ResponseWriter, r *http.Request, message string, users []models.User, pagination respond.Pagination, filter repositories.UserFilter, sort string, fields fieldSet) {
+	varyAccept(w)
+	links := h.pageLinks(pagination.Page, pagination.Limit, pagination.Total, filter, sort, fields)
+	message = respond.Message(w, r, message)
+	switch negotiateUsers(r) {
+	case mediatype.JSONAPI:
+		resources := make([]respond.Resource, len(users))
+		for i := range users {
+			resources[i] = h.jsonAPIUser(&users[i], fields)
+		}
+		respond.JSONAPI(w, http.StatusOK, respond.Document{
+			Data:  resources,
+			Meta:  map[string]interface{}{"message": message, "page": pagination.Page, "limit": pagination.Limit, "total": pagination.Total},
+			Links: respond.Hrefs(links),
+		})
+	case mediatype.XML:
+		elements := make([]xmlUser, len(users))
+		for i := range users {
+			elements[i] = h.xmlUserOf(&users[i], fields)
+		}
+		writeXML(w, http.StatusOK, xmlResponse{
+			Status:     http.StatusOK,
+			Message:    message,
+			Users:      &elements,
+			Pagination: &pagination,
+			Links:      xmlLinks(links),
+		})
+	default:
+		respond.JSON(w, http.StatusOK, respond.Envelope{Status: http.StatusOK, Message: message, Data: h.userResources(users, fields), Pagination: &pagination, Links: links})
+	}
+}
+
+// varyAccept marks the response as depending on the Accept header, once.
+func varyAccept(w http.ResponseWriter) {
+	for _, value := range w.Header().Values("Vary") {
+		if value == "Accept" {
+			return
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteGetRole names the role route, used for the Location of created roles.
+const RouteGetRole = "roles.get"
+
+// RoleService is the business logic the role handlers depend on.
+type RoleService interface {
+	CreateRole(ctx context.Context, role *models.Role) (*models.Role, error)
+	GetRole(ctx context.Context, name string) (*models.Role, error)
+	UpdateRole(ctx context.Context, name string, update *models.Role) (*models.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+	ListRoles(ctx context.Context, page, limit int) ([]models.Role, int64, error)
+}
+
+type RoleHandler struct {
+	service RoleService
+	logger  *slog.Logger
+	router  *mux.Router
+}
+
+func NewRoleHandler(service RoleService, logger *slog.Logger, router *mux.Router) *RoleHandler {
+	return &RoleHandler{
+		service: service,
+		logger:  logger,
+		router:  router,
+	}
+}
+
+// CreateRole godoc
+// @Summary Create a role
+// @Description Create a named set of permissions to assign to users. Names are lowercase and cannot be
+// @Description changed later. Requires the roles:write permission.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param role body models.Role true "Role JSON"
+// @Success 201 {object} respond.Envelope{data=models.Role}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/roles [post]
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var role models.Role
+	if err := decodeJSON(r, &role); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	created, err := h.service.CreateRole(r.Context(), &role)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to create role")
+		return
+	}
+
+	if self, ok := routeLink(h.router, RouteGetRole, http.MethodGet, "name", created.Name); ok {
+		w.Header().Set("Location", self.Href)
+	}
+	respond.Created(w, r, fmt.Sprintf("Role %s created successfully", created.Name), created)
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description Retrieve a page of roles, the built-in admin and user roles first and the others by name.
+// @Description Requires the roles:read permission.
+// @Tags roles
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Role}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/roles [get]
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	roles, total, err := h.service.ListRoles(r.Context(), page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list roles")
+		return
+	}
+	respond.Page(w, r, "Roles retrieved successfully", roles, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// GetRole godoc
+// @Summary Get a role by name
+// @Description Retrieve a role and its permissions. Requires the roles:read permission.
+// @Tags roles
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param name path string true "Role name"
+// @Success 200 {object} respond.Envelope{data=models.Role}
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/roles/{name} [get]
+func (h *RoleHandler) GetRole(w http.ResponseWriter, r *http.Request) {
+	role, err := h.service.GetRole(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get role")
+		return
+	}
+	respond.OK(w, r, "Role retrieved successfully", role)
+}
+
+// UpdateRole godoc
+// @Summary Update a role
+// @Description Replace the description and permissions of a role. Its users hold the new permissions from
+// @Description their next request. Built-in roles cannot be changed. Requires the roles:write permission.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param name path string true "Role name"
+// @Param role body models.Role true "Role JSON"
+// @Success 200 {object} respond.Envelope{data=models.Role}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/roles/{name} [put]
+func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	var update models.Role
+	if err := decodeJSON(r, &update); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	role, err := h.service.UpdateRole(r.Context(), mux.Vars(r)["name"], &update)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update role")
+		return
+	}
+	respond.OK(w, r, "Role updated successfully", role)
+}
+
+// DeleteRole godoc
+// @Summary Delete a role
+// @Description Delete a role no user has. Built-in roles cannot be deleted. Requires the roles:write permission.
+// @Tags roles
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param name path string true "Role name"
+// @Success 200 {object} respond.Envelope
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/roles/{name} [delete]
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteRole(r.Context(), mux.Vars(r)["name"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete role")
+		return
+	}
+	respond.OK(w, r, "Role deleted successfully", nil)
+}
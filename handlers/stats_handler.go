@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+)
+
+// StatsService is the business logic the statistics handler depends on.
+type StatsService interface {
+	UserStats(ctx context.Context) (*models.UserStats, error)
+}
+
+type StatsHandler struct {
+	service StatsService
+	logger  *slog.Logger
+}
+
+func NewStatsHandler(service StatsService, logger *slog.Logger) *StatsHandler {
+	return &StatsHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetStats godoc
+// @Summary Get user statistics
+// @Description Count the users in total, per role, and erased, and the signups of the last 24 hours, 7
+// @Description days, and 30 days. The counts are reused for STATS_CACHE_TTL, so they may be up to that
+// @Description old; generatedAt says when they were taken. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Success 200 {object} respond.Envelope{data=models.UserStats}
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/stats [get]
+func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.UserStats(r.Context())
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get statistics")
+		return
+	}
+	respond.OK(w, r, "Statistics retrieved successfully", stats)
+}
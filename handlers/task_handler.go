@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// TaskService is the business logic the scheduled task handlers depend on.
+type TaskService interface {
+	ListTasks(ctx context.Context) ([]models.Task, error)
+	ListRuns(ctx context.Context, name string, page, limit int) ([]models.TaskRun, int64, error)
+}
+
+type TaskHandler struct {
+	service TaskService
+	logger  *slog.Logger
+}
+
+func NewTaskHandler(service TaskService, logger *slog.Logger) *TaskHandler {
+	return &TaskHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListTasks godoc
+// @Summary List scheduled tasks
+// @Description List the scheduled maintenance tasks, whether each is enabled, how often it runs, when it is
+// @Description next due, and its latest run. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Success 200 {object} respond.Envelope{data=[]models.Task}
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/tasks [get]
+func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.service.ListTasks(r.Context())
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list tasks")
+		return
+	}
+	respond.OK(w, r, "Tasks retrieved successfully", tasks)
+}
+
+// ListTaskRuns godoc
+// @Summary List the runs of a scheduled task
+// @Description Retrieve a page of the run history of a scheduled task, newest first, with each run's status,
+// @Description how many records it affected, and its error. Runs are kept for 30 days. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param name path string true "Task name"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.TaskRun}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/tasks/{name}/runs [get]
+func (h *TaskHandler) ListTaskRuns(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	runs, total, err := h.service.ListRuns(r.Context(), mux.Vars(r)["name"], page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list task runs")
+		return
+	}
+	respond.Page(w, r, "Task runs retrieved successfully", runs, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"example_api/problem"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// routedMethods are the methods routes are registered for, in the order Allow lists them.
+var routedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// Unmatched answers the requests no route of router matches. When routes exist for the path
+// with other methods, OPTIONS gets 204 No Content and every other method 405 Method Not
+// Allowed, both with an Allow header listing those methods; otherwise the request gets 404 Not
+// Found. Set it as both the NotFoundHandler and the MethodNotAllowedHandler of router, since
+// a path routed in one subrouter is not found by the others.
+func Unmatched(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range routedMethods {
+			var match mux.RouteMatch
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if router.Match(probe, &match) && match.MatchErr == nil {
+				allowed = append(allowed, method)
+			}
+		}
+		if len(allowed) == 0 {
+			problem.Error(w, r, http.StatusNotFound, fmt.Sprintf("No resource exists at %s", r.URL.Path))
+			return
+		}
+
+		allowed = append(allowed, http.MethodOptions)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		problem.Error(w, r, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not allowed here, only %s", r.Method, strings.Join(allowed, ", ")))
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"log/slog"
+	"net/http"
+)
+
+// UsageService is the business logic the usage handler depends on.
+type UsageService interface {
+	ListUsage(ctx context.Context, query models.UsageQuery, page, limit int) ([]models.Usage, int64, error)
+	ListOwnUsage(ctx context.Context, query models.UsageQuery, page, limit int) ([]models.Usage, int64, error)
+}
+
+type UsageHandler struct {
+	service UsageService
+	logger  *slog.Logger
+}
+
+func NewUsageHandler(service UsageService, logger *slog.Logger) *UsageHandler {
+	return &UsageHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetOwnUsage godoc
+// @Summary Get your API usage
+// @Description Retrieve a page of the caller's API usage, one record per UTC day, newest first: the
+// @Description requests made and the bytes of their bodies and of the responses. Usage is recorded every
+// @Description USAGE_FLUSH_INTERVAL, so the latest requests may not be counted yet. from and to are both
+// @Description inclusive.
+// @Tags usage
+// @Produce json
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param from query string false "Only days on or after this date, such as 2024-01-01"
+// @Param to query string false "Only days on or before this date, such as 2024-01-31"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Usage}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/me/usage [get]
+func (h *UsageHandler) GetOwnUsage(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.service.ListOwnUsage)
+}
+
+// ListUsage godoc
+// @Summary List API usage
+// @Description Retrieve a page of the API usage of every client, one record per client and UTC day, newest
+// @Description day first. Users are named user:<id>, ADMIN_TOKEN admin, and anonymous clients ip:<address>.
+// @Description Usage is recorded every USAGE_FLUSH_INTERVAL, so the latest requests may not be counted yet.
+// @Description from and to are both inclusive. Requires ADMIN_TOKEN.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token set by ADMIN_TOKEN"
+// @Param client query string false "Only the usage of this client, such as user:65a1b2c3d4e5f6a7b8c9d0e1"
+// @Param from query string false "Only days on or after this date, such as 2024-01-01"
+// @Param to query string false "Only days on or before this date, such as 2024-01-31"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope{data=[]models.Usage}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/usage [get]
+func (h *UsageHandler) ListUsage(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.service.ListUsage)
+}
+
+// list answers with the page of usage list returns for the query of r.
+func (h *UsageHandler) list(w http.ResponseWriter, r *http.Request, list func(context.Context, models.UsageQuery, int, int) ([]models.Usage, int64, error)) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	records, total, err := list(r.Context(), models.UsageQuery{
+		Client: query.Get("client"),
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+	}, page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list usage")
+		return
+	}
+	respond.Page(w, r, "Usage retrieved successfully", records, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
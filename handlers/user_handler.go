@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"context"
+	"example_api/dto"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/respond"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// UserService is the business logic the user handlers depend on.
+type UserService interface {
+	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	GetUsersByIDs(ctx context.Context, ids []string, fields []string) ([]models.User, error)
+	UpdateUser(ctx context.Context, id string, version int64, updates map[string]interface{}) (*models.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	ListUsers(ctx context.Context, filter repositories.UserFilter, sort string, fields []string, page, limit int) ([]models.User, int64, error)
+	SearchUsers(ctx context.Context, query, sort string, limit int) ([]models.SearchHit, error)
+	ExportUsers(ctx context.Context, filter repositories.UserFilter, visit func(*models.User) error) error
+	ExportUserData(ctx context.Context, id string) (*models.UserDataExport, error)
+	EraseUser(ctx context.Context, id string) (*models.User, error)
+	AssignRole(ctx context.Context, id string, assignment *models.RoleAssignment) (*models.User, error)
+}
+
+type UserHandler struct {
+	service UserService
+	logger  *slog.Logger
+	// router resolves named routes into response links
+	router *mux.Router
+}
+
+func NewUserHandler(service UserService, logger *slog.Logger, router *mux.Router) *UserHandler {
+	return &UserHandler{
+		service: service,
+		logger:  logger,
+		router:  router,
+	}
+}
+
+// CreateUser godoc
+// @Summary Create a new user
+// @Description Create a new user with email, password, first name, and last name. With CAPTCHA_PROVIDER
+// @Description set, anonymous requests must carry the token of a solved CAPTCHA in X-Captcha-Token.
+// @Tags users
+// @Accept json,xml
+// @Produce json,application/vnd.api+json,xml
+// @Param Idempotency-Key header string false "Client-chosen key that makes retries return the original response"
+// @Param X-Captcha-Token header string false "Token of the CAPTCHA the client solved, required of anonymous requests with CAPTCHA_PROVIDER set"
+// @Param user body dto.CreateUserRequest true "User JSON"
+// @Success 201 {object} respond.Envelope{data=dto.User}
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Failure 503 {object} problem.Problem
+// @Router /api/v1/users [post]
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	user, err := decodeUser(r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	created, err := h.service.CreateUser(r.Context(), user)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to create user")
+		return
+	}
+
+	if self, ok := routeLink(h.router, RouteGetUser, http.MethodGet, "id", created.Id.Hex()); ok {
+		w.Header().Set("Location", self.Href)
+	}
+	h.writeUser(w, r, http.StatusCreated, fmt.Sprintf("User created successfully with ID: %s", created.Id.Hex()), created)
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Retrieve a page of users, optionally filtered by role, email, or a filter expression. Users
+// @Description are ordered by the sort fields, then by ID.
+// @Tags users
+// @Accept json,xml
+// @Produce json,application/vnd.api+json,xml
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param role query string false "Only users with this role"
+// @Param email query string false "Only the user with this email"
+// @Param filter query string false "Filter expression, such as joinDate>=2024-01-01 AND lastName~oğlu. Compares email, firstName, lastName, role, and metadata.{key} with =, !=, or ~ (contains, ignoring case), and joinDate and version with =, !=, >, >=, <, or <=; combine comparisons with AND, OR, and parentheses"
+// @Param sort query string false "Comma-separated fields to sort by, each prefixed with - for descending order, such as joinDate,-lastName. Sortable fields are id, email, firstName, lastName, role, and joinDate"
+// @Param fields query string false "Comma-separated fields to include in each user, such as email,firstName. Selectable fields are id, email, firstName, lastName, phone, phoneVerifiedAt, addresses, metadata, role, joinDate, version, and erasedAt; the id and links are always included"
+// @Success 200 {object} respond.Envelope{data=[]dto.User}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users [get]
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := queryInt(r, "page", 1)
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid page")
+		return
+	}
+	limit, err := queryInt(r, "limit", 20)
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	fields, err := fieldsParam(r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid fields")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repositories.UserFilter{Role: query.Get("role"), Email: query.Get("email"), Expression: query.Get("filter")}
+	sort := r.URL.Query().Get("sort")
+	users, total, err := h.service.ListUsers(r.Context(), filter, sort, fields.names(), page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list users")
+		return
+	}
+
+	h.writeUserPage(w, r, "Users retrieved successfully", users, respond.Pagination{Page: page, Limit: limit, Total: total}, filter, sort, fields)
+}
+
+// searchHit is a search hit with the links of its user.
+type searchHit struct {
+	User       interface{}        `json:"user"`
+	Score      float64            `json:"score"`
+	Highlights []models.Highlight `json:"highlights"`
+}
+
+// SearchUsers godoc
+// @Summary Search users
+// @Description Find users by the words of their email, first name, and last name, most relevant first.
+// @Description Every word of q must start a word of one of those fields, ignoring case, so "cerra" finds
+// @Description "Cerrahoğlu". Each hit lists the fields that matched, split into the matching parts (type
+// @Description hit) and the text around them (type text). Erased users are not found.
+// @Tags users
+// @Produce json
+// @Param q query string true "Search words"
+// @Param limit query int false "Maximum number of hits (default 20, max 100)"
+// @Param sort query string false "Fields to reorder the hits by, as for listing users; by default they are ordered by relevance"
+// @Param fields query string false "Fields to include in each user, as for listing users"
+// @Success 200 {object} respond.Envelope{data=[]models.SearchHit}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/search [get]
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", 20)
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+	fields, err := fieldsParam(r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid fields")
+		return
+	}
+
+	hits, err := h.service.SearchUsers(r.Context(), r.URL.Query().Get("q"), r.URL.Query().Get("sort"), limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to search users")
+		return
+	}
+	results := make([]searchHit, len(hits))
+	for i := range hits {
+		results[i] = searchHit{User: h.userResource(&hits[i].User, fields), Score: hits[i].Score, Highlights: hits[i].Highlights}
+	}
+	respond.OK(w, r, fmt.Sprintf("Found %d users", len(results)), results)
+}
+
+// userLookup is the result of a batch get for one ID, with the links of its user.
+type userLookup struct {
+	ID    string      `json:"id"`
+	Found bool        `json:"found"`
+	User  interface{} `json:"user,omitempty"`
+}
+
+// GetUsers godoc
+// @Summary Get users by ID
+// @Description Look up to 100 users at once, in one read, for clients that hold many references to users.
+// @Description The results follow the order of the IDs, saying for each whether a user has it; IDs of no
+// @Description user are not an error.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param fields query string false "Fields to include in each user, as for listing users"
+// @Param ids body dto.GetUsersRequest true "User IDs JSON"
+// @Success 200 {object} respond.Envelope{data=[]dto.UserLookup}
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/batch-get [post]
+func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	fields, err := fieldsParam(r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid fields")
+		return
+	}
+	var request dto.GetUsersRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	users, err := h.service.GetUsersByIDs(r.Context(), request.IDs, fields.names())
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get users")
+		return
+	}
+	byID := make(map[string]*models.User, len(users))
+	for i := range users {
+		byID[users[i].Id.Hex()] = &users[i]
+	}
+	results := make([]userLookup, len(request.IDs))
+	found := 0
+	for i, id := range request.IDs {
+		results[i] = userLookup{ID: id}
+		// IDs were validated, so only the case of their hex digits can differ from the user's
+		if user := byID[strings.ToLower(id)]; user != nil {
+			results[i].Found = true
+			results[i].User = h.userResource(user, fields)
+			found++
+		}
+	}
+	respond.OK(w, r, fmt.Sprintf("Found %d of %d users", found, len(results)), results)
+}
+
+// GetUserByID godoc
+// @Summary Get a user by ID
+// @Description Retrieve user details by their unique ID
+// @Tags users
+// @Accept json,xml
+// @Produce json,application/vnd.api+json,xml
+// @Param id path string true "User ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param fields query string false "Fields to include, as for listing users"
+// @Success 200 {object} respond.Envelope{data=dto.User}
+// @Success 304 "Cached copy is still current"
+// @Header 200 {string} ETag "Entity tag of the user"
+// @Failure 400 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id} [get]
+func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
+	fields, err := fieldsParam(r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid fields")
+		return
+	}
+	user, err := h.service.GetUserByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get user")
+		return
+	}
+
+	// Clients may keep a copy but must revalidate it with If-None-Match before reuse
+	varyAccept(w)
+	if respond.Conditional(w, r, userETag(user), "private, no-cache") {
+		return
+	}
+	h.writeUserFields(w, r, http.StatusOK, "User retrieved successfully", user, fields)
+}
+
+// HeadUser godoc
+// @Summary Check that a user exists
+// @Description Answer as getting the user by ID would, with its ETag but without a body, so clients can check
+// @Description that a user exists, or that their copy is current, without downloading it.
+// @Tags users
+// @Param id path string true "User ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 "User exists"
+// @Success 304 "Cached copy is still current"
+// @Header 200 {string} ETag "Entity tag of the user"
+// @Failure 400 "Invalid ID"
+// @Failure 404 "User not found"
+// @Router /api/v1/users/{id} [head]
+func (h *UserHandler) HeadUser(w http.ResponseWriter, r *http.Request) {
+	// The server discards the body of responses to HEAD requests
+	h.GetUserByID(w, r)
+}
+
+// UpdateUser godoc
+// @Summary Update user details
+// @Description Update specific fields of a user by their ID. The version the change is based on must be
+// @Description sent as an If-Match ETag or a "version" field; a stale version is rejected with 409. A
+// @Description metadata object is merged into the user's metadata, with null removing a key.
+// @Tags users
+// @Accept json,xml
+// @Produce json,application/vnd.api+json,xml
+// @Param id path string true "User ID"
+// @Param If-Match header string false "ETag of the user version being updated"
+// @Param updates body dto.UpdateUserRequest true "Update fields JSON"
+// @Success 200 {object} respond.Envelope{data=dto.User}
+// @Header 200 {string} ETag "Entity tag of the updated user"
+// @Failure 400 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 428 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id} [put]
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	request, err := decodeUpdates(r)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	// If-Match takes precedence over a version in the body
+	var version int64
+	if request.Version != nil {
+		version = *request.Version
+	}
+	headerVersion, err := ifMatchVersion(r)
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid If-Match header")
+		return
+	}
+	if headerVersion != 0 {
+		version = headerVersion
+	}
+
+	user, err := h.service.UpdateUser(r.Context(), id, version, request.Fields())
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update user")
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
+	h.writeUser(w, r, http.StatusOK, "User updated successfully", user)
+}
+
+// DeleteUser godoc
+// @Summary Delete a user by ID
+// @Description Remove a user from the database using their unique ID
+// @Tags users
+// @Accept json,xml
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id} [delete]
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteUser(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete user")
+		return
+	}
+
+	respond.OK(w, r, "User deleted successfully", nil)
+}
+
+// AssignRole godoc
+// @Summary Assign a role to a user
+// @Description Give a user a built-in or custom role, whose permissions they hold from their next request.
+// @Description Requires the roles:write permission.
+// @Tags users
+// @Accept json
+// @Produce json,application/vnd.api+json,xml
+// @Param Authorization header string true "ADMIN_TOKEN as a bearer token, or the email and password of a user with Basic authentication"
+// @Param id path string true "User ID"
+// @Param assignment body models.RoleAssignment true "Role JSON"
+// @Success 200 {object} respond.Envelope{data=dto.User}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/users/{id}/role [put]
+func (h *UserHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	var assignment models.RoleAssignment
+	if err := decodeJSON(r, &assignment); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	user, err := h.service.AssignRole(r.Context(), mux.Vars(r)["id"], &assignment)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to assign role")
+		return
+	}
+	h.writeUser(w, r, http.StatusOK, "Role assigned successfully", user)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/respond"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteGetWebhook names the webhook route, used for the Location of created webhooks.
+const RouteGetWebhook = "webhooks.get"
+
+// WebhookService is the business logic the webhook handlers depend on.
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, update *models.Webhook) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhooks(ctx context.Context, page, limit int) ([]models.Webhook, int64, error)
+	ListDeliveries(ctx context.Context, id string, page, limit int) ([]models.WebhookDelivery, int64, error)
+}
+
+type WebhookHandler struct {
+	service WebhookService
+	logger  *slog.Logger
+	router  *mux.Router
+}
+
+func NewWebhookHandler(service WebhookService, logger *slog.Logger, router *mux.Router) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		logger:  logger,
+		router:  router,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description Subscribe a URL to user events. The response is the only one that includes the signing
+// @Description secret; one is generated when the request does not supply it.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token set by EVENTS_TOKEN"
+// @Param webhook body models.Webhook true "Webhook JSON"
+// @Success 201 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook models.Webhook
+	if err := decodeJSON(r, &webhook); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	created, err := h.service.CreateWebhook(r.Context(), &webhook)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to create webhook")
+		return
+	}
+
+	if self, ok := routeLink(h.router, RouteGetWebhook, http.MethodGet, "id", created.Id.Hex()); ok {
+		w.Header().Set("Location", self.Href)
+	}
+	respond.Created(w, r, fmt.Sprintf("Webhook created successfully with ID: %s", created.Id.Hex()), created)
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description Retrieve a page of webhooks ordered by ID, without their secrets
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token set by EVENTS_TOKEN"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks, total, err := h.service.ListWebhooks(r.Context(), page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list webhooks")
+		return
+	}
+	respond.Page(w, r, "Webhooks retrieved successfully", webhooks, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook by ID
+// @Description Retrieve a webhook, without its secret
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token set by EVENTS_TOKEN"
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := h.service.GetWebhook(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to get webhook")
+		return
+	}
+	respond.OK(w, r, "Webhook retrieved successfully", webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Replace the URL and events of a webhook. Sending a secret replaces the signing secret too;
+// @Description otherwise the current one is kept.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token set by EVENTS_TOKEN"
+// @Param id path string true "Webhook ID"
+// @Param webhook body models.Webhook true "Webhook JSON"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var update models.Webhook
+	if err := decodeJSON(r, &update); err != nil {
+		writeError(w, r, h.logger, err, "Invalid input")
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(r.Context(), mux.Vars(r)["id"], &update)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to update webhook")
+		return
+	}
+	respond.OK(w, r, "Webhook updated successfully", webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Stop delivering events to a webhook and discard its delivery log
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token set by EVENTS_TOKEN"
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteWebhook(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, h.logger, err, "Failed to delete webhook")
+		return
+	}
+	respond.OK(w, r, "Webhook deleted successfully", nil)
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Retrieve a page of a webhook's delivery attempts, newest first. Attempts are kept for 30 days.
+// @Tags webhooks
+// @Produce json
+// @Param Authorization header string true "Bearer token set by EVENTS_TOKEN"
+// @Param id path string true "Webhook ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} respond.Envelope
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := h.service.ListDeliveries(r.Context(), mux.Vars(r)["id"], page, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err, "Failed to list webhook deliveries")
+		return
+	}
+	respond.Page(w, r, "Webhook deliveries retrieved successfully", deliveries, respond.Pagination{Page: page, Limit: limit, Total: total}, nil)
+}
+
+// pageParams reads the page and limit query parameters, answering 400 when either is malformed.
+func pageParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	page, err := queryInt(r, "page", 1)
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid page")
+		return 0, 0, false
+	}
+	limit, err := queryInt(r, "limit", 20)
+	if err != nil {
+		respond.Error(w, r, http.StatusBadRequest, "Invalid limit")
+		return 0, 0, false
+	}
+	return page, limit, true
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"example_api/apperrors"
+	"example_api/dto"
+	"example_api/mediatype"
+	models "example_api/models"
+	"example_api/respond"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// xmlLink is a link rendered as an element with rel, href, and method attributes.
+type xmlLink struct {
+	Rel    string `xml:"rel,attr"`
+	Href   string `xml:"href,attr"`
+	Method string `xml:"method,attr,omitempty"`
+}
+
+// xmlUser is the XML representation of a user. The password hash is never rendered. Fields are
+// pointers so sparse responses can leave out the elements that were not selected.
+type xmlUser struct {
+	XMLName   xml.Name   `xml:"user"`
+	ID        string     `xml:"id,attr"`
+	Email     *string    `xml:"email,omitempty"`
+	FirstName *string    `xml:"firstName,omitempty"`
+	LastName  *string    `xml:"lastName,omitempty"`
+	Phone     *string    `xml:"phone,omitempty"`
+	Role      *string    `xml:"role,omitempty"`
+	JoinDate  *time.Time `xml:"joinDate,omitempty"`
+	Version   *int64     `xml:"version,omitempty"`
+	ErasedAt  *time.Time `xml:"erasedAt,omitempty"`
+
+	PhoneVerifiedAt *time.Time   `xml:"phoneVerifiedAt,omitempty"`
+	Addresses       []xmlAddress `xml:"addresses>address"`
+	Links           []xmlLink    `xml:"link"`
+}
+
+// xmlAddress is the XML representation of a postal address of a user.
+type xmlAddress struct {
+	ID          string `xml:"id,attr"`
+	Street      string `xml:"street"`
+	City        string `xml:"city"`
+	PostalCode  string `xml:"postalCode,omitempty"`
+	CountryCode string `xml:"countryCode"`
+}
+
+// xmlResponse is the XML counterpart of respond.Envelope.
+type xmlResponse struct {
+	XMLName    xml.Name            `xml:"response"`
+	Status     int                 `xml:"status,attr"`
+	Message    string              `xml:"message"`
+	User       *xmlUser            `xml:"user,omitempty"`
+	Users      *[]xmlUser          `xml:"users>user,omitempty"`
+	Pagination *respond.Pagination `xml:"pagination,omitempty"`
+	Links      []xmlLink           `xml:"link"`
+}
+
+// xmlUserInput is a user submitted as XML. Fields are pointers so updates can tell absent
+// elements from empty ones.
+type xmlUserInput struct {
+	XMLName   xml.Name `xml:"user"`
+	Email     *string  `xml:"email"`
+	Password  *string  `xml:"password"`
+	FirstName *string  `xml:"firstName"`
+	LastName  *string  `xml:"lastName"`
+	Phone     *string  `xml:"phone"`
+	Version   *int64   `xml:"version"`
+}
+
+// xmlLinks converts links to elements in a stable order.
+func xmlLinks(links map[string]respond.Link) []xmlLink {
+	rels := make([]string, 0, len(links))
+	for rel := range links {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	elements := make([]xmlLink, 0, len(rels))
+	for _, rel := range rels {
+		elements = append(elements, xmlLink{Rel: rel, Href: links[rel].Href, Method: links[rel].Method})
+	}
+	return elements
+}
+
+// xmlUserOf converts user to its XML representation with the elements in fields.
+func (h *UserHandler) xmlUserOf(user *models.User, fields fieldSet) xmlUser {
+	element := xmlUser{ID: user.Id.Hex(), Links: xmlLinks(h.userLinks(user))}
+	if fields.has("email") {
+		element.Email = &user.Email
+	}
+	if fields.has("firstName") {
+		element.FirstName = &user.FirstName
+	}
+	if fields.has("lastName") {
+		element.LastName = &user.LastName
+	}
+	if fields.has("phone") && user.Phone != "" {
+		element.Phone = &user.Phone
+	}
+	if fields.has("phoneVerifiedAt") {
+		element.PhoneVerifiedAt = user.PhoneVerifiedAt
+	}
+	if fields.has("addresses") {
+		for _, address := range user.Addresses {
+			element.Addresses = append(element.Addresses, xmlAddress{
+				ID:          address.Id.Hex(),
+				Street:      address.Street,
+				City:        address.City,
+				PostalCode:  address.PostalCode,
+				CountryCode: address.CountryCode,
+			})
+		}
+	}
+	if fields.has("role") {
+		element.Role = &user.Role
+	}
+	if fields.has("joinDate") {
+		element.JoinDate = &user.JoinDate
+	}
+	if fields.has("version") {
+		element.Version = &user.Version
+	}
+	if fields.has("erasedAt") {
+		element.ErasedAt = user.ErasedAt
+	}
+	return element
+}
+
+// writeXML writes v as an XML document with the given status.
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", mediatype.XML)
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+// decodeXMLUser decodes a <user> document from the request body.
+func decodeXMLUser(r *http.Request) (*xmlUserInput, error) {
+	var input xmlUserInput
+	if err := xml.NewDecoder(r.Body).Decode(&input); err != nil {
+		if err := bodyTooLarge(err); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.Validation("Invalid input")
+	}
+	return &input, nil
+}
+
+// updateRequest returns the elements present in input as an update.
+func (input *xmlUserInput) updateRequest() dto.UpdateUserRequest {
+	return dto.UpdateUserRequest{
+		Email:     input.Email,
+		Password:  input.Password,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		Phone:     input.Phone,
+		Version:   input.Version,
+	}
+}
+
+// createRequest returns input as a new user.
+func (input *xmlUserInput) createRequest() dto.CreateUserRequest {
+	var request dto.CreateUserRequest
+	for target, value := range map[*string]*string{
+		&request.Email:     input.Email,
+		&request.Password:  input.Password,
+		&request.FirstName: input.FirstName,
+		&request.LastName:  input.LastName,
+		&request.Phone:     input.Phone,
+	} {
+		if value != nil {
+			*target = *value
+		}
+	}
+	return request
+}
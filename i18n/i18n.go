@@ -0,0 +1,254 @@
+// Package i18n translates the human-readable messages of API responses. Messages are written in
+// English throughout the code and looked up by their English text in the catalog of each other
+// locale, embedded from locales/<locale>.json. Catalog keys may hold fmt verbs such as %s, %d,
+// and %q, which match the values messages were formatted with; translations place those
+// values with the same verbs, or with %[n]s to reorder them. Untranslated messages stay in
+// English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Default is the locale messages are written in, and the one clients get when they accept none
+// of Locales.
+const Default = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// verb matches an fmt verb in a catalog key or translation, with its explicit argument index.
+var verb = regexp.MustCompile(`%(?:\[(\d+)\])?([a-z%])`)
+
+// pattern translates the messages formatted from one catalog key that holds verbs.
+type pattern struct {
+	re          *regexp.Regexp
+	translation string
+	// literal is the length of the key without its verbs; longer keys are more specific
+	literal int
+	// nested says which values are themselves messages to translate, such as those of %s
+	nested []bool
+}
+
+// catalog holds the translations of one locale.
+type catalog struct {
+	messages map[string]string
+	patterns []pattern
+}
+
+var (
+	catalogs = loadCatalogs()
+	// Locales lists the supported locales, Default first.
+	Locales = locales()
+	matcher = language.NewMatcher(tags())
+)
+
+func loadCatalogs() map[string]*catalog {
+	files, err := fs.Glob(localeFS, "locales/*.json")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]*catalog, len(files))
+	for _, file := range files {
+		data, err := localeFS.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", file, err))
+		}
+		c, err := newCatalog(messages)
+		if err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", file, err))
+		}
+		catalogs[strings.TrimSuffix(path.Base(file), ".json")] = c
+	}
+	return catalogs
+}
+
+func newCatalog(messages map[string]string) (*catalog, error) {
+	c := &catalog{messages: make(map[string]string, len(messages))}
+	for key, translation := range messages {
+		if !verb.MatchString(key) {
+			c.messages[key] = translation
+			continue
+		}
+		p, err := compile(key, translation)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", key, err)
+		}
+		c.patterns = append(c.patterns, p)
+	}
+	slices.SortFunc(c.patterns, func(a, b pattern) int {
+		if a.literal != b.literal {
+			return b.literal - a.literal
+		}
+		return strings.Compare(a.re.String(), b.re.String())
+	})
+	return c, nil
+}
+
+// compile turns a catalog key into a regular expression capturing the value of each verb, and
+// checks that translation only refers to values the key has.
+func compile(key, translation string) (pattern, error) {
+	p := pattern{translation: translation}
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, m := range verb.FindAllStringSubmatchIndex(key, -1) {
+		literal := key[last:m[0]]
+		expr.WriteString(regexp.QuoteMeta(literal))
+		p.literal += len(literal)
+		last = m[1]
+		if m[2] >= 0 {
+			return pattern{}, fmt.Errorf("keys cannot use argument indexes")
+		}
+		switch key[m[4]:m[5]] {
+		case "%":
+			expr.WriteString("%")
+			p.literal++
+			continue
+		case "d":
+			expr.WriteString(`(-?\d+)`)
+			p.nested = append(p.nested, false)
+		case "q":
+			expr.WriteString(`("(?:[^"\\]|\\.)*")`)
+			p.nested = append(p.nested, false)
+		case "s", "v":
+			expr.WriteString(`(.+?)`)
+			p.nested = append(p.nested, true)
+		default:
+			return pattern{}, fmt.Errorf("unsupported verb %s", key[m[0]:m[1]])
+		}
+	}
+	literal := key[last:]
+	expr.WriteString(regexp.QuoteMeta(literal) + "$")
+	p.literal += len(literal)
+	p.re = regexp.MustCompile(expr.String())
+
+	next := 0
+	for _, m := range verb.FindAllStringSubmatch(translation, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		index := next
+		if m[1] != "" {
+			index, _ = strconv.Atoi(m[1])
+			index--
+		}
+		if index < 0 || index >= len(p.nested) {
+			return pattern{}, fmt.Errorf("translation refers to value %d of %d", index+1, len(p.nested))
+		}
+		next = index + 1
+	}
+	return p, nil
+}
+
+func locales() []string {
+	locales := []string{Default}
+	for locale := range catalogs {
+		if locale != Default {
+			locales = append(locales, locale)
+		}
+	}
+	slices.Sort(locales[1:])
+	return locales
+}
+
+func tags() []language.Tag {
+	tags := make([]language.Tag, len(Locales))
+	for i, locale := range Locales {
+		tags[i] = language.MustParse(locale)
+	}
+	return tags
+}
+
+// Negotiate returns the supported locale that best matches an Accept-Language header, or
+// Default when it matches none of them.
+func Negotiate(acceptLanguage string) string {
+	accepted, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(accepted) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(accepted...)
+	if confidence == language.No {
+		return Default
+	}
+	return Locales[index]
+}
+
+// Translate returns message in locale, or message itself when locale has no translation of it.
+func Translate(locale, message string) string {
+	c := catalogs[locale]
+	if c == nil || message == "" {
+		return message
+	}
+	return c.translate(message)
+}
+
+func (c *catalog) translate(message string) string {
+	if translation, ok := c.messages[message]; ok {
+		return translation
+	}
+	for _, p := range c.patterns {
+		values := p.re.FindStringSubmatch(message)
+		if values == nil {
+			continue
+		}
+		values = values[1:]
+		for i, nested := range p.nested {
+			if nested {
+				values[i] = c.translate(values[i])
+			}
+		}
+		return p.format(values)
+	}
+	return message
+}
+
+// format places values in the translation of p.
+func (p pattern) format(values []string) string {
+	next := 0
+	return verb.ReplaceAllStringFunc(p.translation, func(v string) string {
+		m := verb.FindStringSubmatch(v)
+		if m[2] == "%" {
+			return "%"
+		}
+		index := next
+		if m[1] != "" {
+			index, _ = strconv.Atoi(m[1])
+			index--
+		}
+		next = index + 1
+		return values[index]
+	})
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx whose responses are in locale.
+func NewContext(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale of the responses to the request of ctx, or Default when there
+// is none.
+func FromContext(ctx context.Context) string {
+	locale, ok := ctx.Value(contextKey{}).(string)
+	if !ok {
+		return Default
+	}
+	return locale
+}
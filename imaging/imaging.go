@@ -0,0 +1,185 @@
+// Package imaging prepares uploaded images for serving: it removes the metadata cameras and
+// editors embed in them and renders scaled-down copies.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the decoded size of an image. A few compressed megabytes can expand to
+// gigabytes of pixels, so larger images are refused before decoding.
+const MaxPixels = 40_000_000
+
+// jpegQuality is the quality of re-encoded JPEGs.
+const jpegQuality = 85
+
+var (
+	// ErrInvalidImage is returned when data cannot be decoded as its content type
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrTooManyPixels is returned for images exceeding MaxPixels
+	ErrTooManyPixels = errors.New("image has too many pixels")
+)
+
+// Image is a decoded upload.
+type Image struct {
+	data        []byte
+	contentType string
+	img         image.Image
+	// orientation is the EXIF orientation of a JPEG, 1 when upright
+	orientation int
+}
+
+// Decode parses data of the given sniffed content type: image/png, image/jpeg, image/gif, or
+// image/webp. Only the first frame of an animated GIF is kept for scaling.
+func Decode(data []byte, contentType string) (*Image, error) {
+	var decode func([]byte) (image.Image, error)
+	var decodeConfig func([]byte) (image.Config, error)
+	switch contentType {
+	case "image/png":
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+	case "image/jpeg":
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+	case "image/gif":
+		decode = func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) }
+		decodeConfig = func(b []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(b)) }
+	case "image/webp":
+		decode = func(b []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(b)) }
+		decodeConfig = func(b []byte) (image.Config, error) { return webp.DecodeConfig(bytes.NewReader(b)) }
+	default:
+		return nil, fmt.Errorf("%w: unsupported content type %q", ErrInvalidImage, contentType)
+	}
+
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("%w: empty image", ErrInvalidImage)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+	img, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	return &Image{data: data, contentType: contentType, img: img, orientation: orientation}, nil
+}
+
+// ContentType returns the content type the image was decoded as.
+func (i *Image) ContentType() string {
+	return i.contentType
+}
+
+// Stripped returns the original image without its EXIF, XMP, and text metadata. The encoded
+// pixels are kept as they are, except that a rotated JPEG is re-encoded upright, since
+// removing its orientation tag would otherwise turn it sideways. GIFs carry no EXIF and are
+// returned unchanged.
+func (i *Image) Stripped() ([]byte, error) {
+	switch i.contentType {
+	case "image/jpeg":
+		if i.orientation != 1 {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, orient(toNRGBA(i.img), i.orientation), &jpeg.Options{Quality: jpegQuality}); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+		return stripJPEG(i.data)
+	case "image/png":
+		return stripPNG(i.data)
+	case "image/webp":
+		return stripWebP(i.data)
+	default:
+		return i.data, nil
+	}
+}
+
+// Resized returns the image scaled down to fit within size×size pixels, keeping its aspect
+// ratio; smaller images are not enlarged. The copy carries no metadata and is encoded as JPEG,
+// or as PNG when it has transparency. Its content type is returned with it.
+func (i *Image) Resized(size int) ([]byte, string, error) {
+	bounds := i.img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	longest := max(width, height)
+	if longest > size {
+		width = max(1, (width*size+longest/2)/longest)
+		height = max(1, (height*size+longest/2)/longest)
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), i.img, bounds, draw.Src, nil)
+	upright := orient(scaled, i.orientation)
+
+	var buf bytes.Buffer
+	if upright.Opaque() {
+		if err := jpeg.Encode(&buf, upright, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, upright); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// toNRGBA copies img into an NRGBA image whose bounds start at the origin.
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// orient turns src upright according to an EXIF orientation value.
+func orient(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // mirrored and rotated 270° clockwise
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // mirrored and rotated 90° clockwise
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 270° clockwise
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+	return dst
+}
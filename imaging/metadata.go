@@ -0,0 +1,194 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// JPEG markers
+const (
+	markerSOS   = 0xDA
+	markerAPP1  = 0xE1 // EXIF and XMP
+	markerAPP13 = 0xED // Photoshop and IPTC
+	markerCOM   = 0xFE
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// jpegSegments calls visit with each marker segment before the image data, including its
+// marker and length bytes. It returns the offset where the entropy-coded data begins.
+func jpegSegments(data []byte, visit func(marker byte, segment []byte)) (int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, fmt.Errorf("%w: missing JPEG start marker", ErrInvalidImage)
+	}
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return 0, fmt.Errorf("%w: truncated JPEG header", ErrInvalidImage)
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			pos++
+			continue
+		}
+		if marker == markerSOS {
+			return pos, nil
+		}
+		// The length counts itself but not the marker
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 0, fmt.Errorf("%w: truncated JPEG segment", ErrInvalidImage)
+		}
+		visit(marker, data[pos:end])
+		pos = end
+	}
+}
+
+// stripJPEG drops the EXIF, XMP, Photoshop, and comment segments, keeping the colour profile
+// and the compressed image as they are.
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	start, err := jpegSegments(data, func(marker byte, segment []byte) {
+		switch marker {
+		case markerAPP1, markerAPP13, markerCOM:
+		default:
+			out = append(out, segment...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, data[start:]...), nil
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 when it has none.
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	jpegSegments(data, func(marker byte, segment []byte) {
+		if marker == markerAPP1 && bytes.HasPrefix(segment[4:], exifHeader) {
+			if value := exifOrientation(segment[4+len(exifHeader):]); value != 0 {
+				orientation = value
+			}
+		}
+	})
+	return orientation
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF structure, returning
+// 0 when it is missing or malformed.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		// Tag 0x0112 is a single SHORT stored inline in the value field
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+				return value
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// pngSignature starts every PNG file.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadata lists the chunks stripPNG drops: EXIF, text, and modification time.
+var pngMetadata = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNG drops the metadata chunks, keeping every chunk needed to render the image.
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("%w: missing PNG signature", ErrInvalidImage)
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for pos := len(pngSignature); pos < len(data); {
+		if pos+12 > len(data) {
+			return nil, fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+		}
+		// Length, type, data, and CRC
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:]))
+		if end > len(data) || end < pos {
+			return nil, fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+		}
+		if !pngMetadata[string(data[pos+4:pos+8])] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out, nil
+}
+
+// VP8X flags announcing EXIF and XMP chunks
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP drops the EXIF and XMP chunks of an extended WebP file and clears the flags that
+// announce them. Simple WebP files cannot carry metadata and come back unchanged.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("%w: missing WebP header", ErrInvalidImage)
+	}
+	// Anything after the RIFF container is not part of the image
+	limit := min(len(data), 8+int(binary.LittleEndian.Uint32(data[4:])))
+	out := make([]byte, 0, limit)
+	out = append(out, data[:12]...)
+	for pos := 12; pos < limit; {
+		if pos+8 > limit {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrInvalidImage)
+		}
+		// FourCC, size, and payload padded to an even length
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size&1
+		if end > limit || end < pos {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrInvalidImage)
+		}
+		switch string(data[pos : pos+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			flags := len(out) + 8
+			out = append(out, data[pos:end]...)
+			if size > 0 {
+				out[flags] &^= webpFlagEXIF | webpFlagXMP
+			}
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
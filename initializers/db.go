@@ -2,31 +2,37 @@ package initializers
 
 import (
 	"context"
+	"example_api/config"
+	"example_api/mongodb"
 	"fmt"
-	"os"
+	"log/slog"
 
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 // ConnectToDB initializes and returns a MongoDB database instance.
-func ConnectToDB() (*mongo.Database, error) {
-	// Load .env file
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Error loading .env file")
-	}
-
-	// Retrieve MongoDB URI from environment variables
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		return nil, fmt.Errorf("MONGO_URI not set in .env file")
-	}
-
+func ConnectToDB(cfg *config.Config, logger *slog.Logger) (*mongodb.Database, error) {
 	// Set MongoDB server API options
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	clientOptions := options.Client().ApplyURI(mongoURI).SetServerAPIOptions(serverAPI)
+	clientOptions := options.Client().ApplyURI(cfg.MongoURI).SetServerAPIOptions(serverAPI).SetMonitor(chainMonitors(mongoMetricsMonitor(), otelmongo.NewMonitor()))
+
+	// Apply connection pool and timeout settings
+	clientOptions.
+		SetMaxPoolSize(cfg.MongoPool.MaxPoolSize).
+		SetMinPoolSize(cfg.MongoPool.MinPoolSize).
+		SetConnectTimeout(cfg.MongoPool.ConnectTimeout).
+		SetSocketTimeout(cfg.MongoPool.SocketTimeout).
+		SetServerSelectionTimeout(cfg.MongoPool.ServerSelectionTimeout)
+
+	// Apply the read preference and write concern of every operation not set apart by class
+	if cfg.MongoConcerns.ReadPreference != nil {
+		clientOptions.SetReadPreference(cfg.MongoConcerns.ReadPreference)
+	}
+	if cfg.MongoConcerns.WriteConcern != nil {
+		clientOptions.SetWriteConcern(cfg.MongoConcerns.WriteConcern)
+	}
 
 	// Connect to MongoDB
 	client, err := mongo.Connect(context.TODO(), clientOptions)
@@ -40,8 +46,11 @@ func ConnectToDB() (*mongo.Database, error) {
 		return nil, fmt.Errorf("failed to ping MongoDB: %v", err)
 	}
 
-	fmt.Println("Connected to MongoDB!")
+	logger.Info("Connected to MongoDB")
 
-	// Return the specific database instance
-	return client.Database("example-db"), nil
+	// Return the configured database, naming collections with the configured prefix
+	return mongodb.New(client.Database(cfg.DBName), cfg.MongoPrefix, mongodb.Concerns{
+		ListReadPreference: cfg.MongoConcerns.ListReadPreference,
+		CreateWriteConcern: cfg.MongoConcerns.CreateWriteConcern,
+	}), nil
 }
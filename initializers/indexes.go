@@ -0,0 +1,238 @@
+package initializers
+
+import (
+	"context"
+	"example_api/mongodb"
+	"example_api/repositories"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexSpec declares one index the application relies on.
+type indexSpec struct {
+	Name   string
+	Keys   bson.D
+	Unique bool
+	// ExpireAfterSeconds makes this a TTL index when set
+	ExpireAfterSeconds *int32
+}
+
+// collectionIndexes lists every managed index per collection. Indexes on these collections
+// that are not declared here are reported but left in place.
+var collectionIndexes = map[string][]indexSpec{
+	"users": {
+		// Emails are unique per tenant; the default tenant has no tenantId
+		{Name: "email_unique", Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "email", Value: 1}}, Unique: true},
+		{Name: "joinDate", Keys: bson.D{{Key: "joinDate", Value: -1}}},
+		{Name: "user_text", Keys: bson.D{{Key: "email", Value: "text"}, {Key: "firstName", Value: "text"}, {Key: "lastName", Value: "text"}}},
+	},
+	"idempotency_keys": {
+		// Records carry their own expiry time, so they expire as soon as it passes
+		{Name: "expiresAt_ttl", Keys: bson.D{{Key: "expiresAt", Value: 1}}, ExpireAfterSeconds: ptr(int32(0))},
+	},
+	"webhooks": {
+		{Name: "events", Keys: bson.D{{Key: "events", Value: 1}}},
+	},
+	"webhook_deliveries": {
+		{Name: "webhookId_createdAt", Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Name: "createdAt_ttl", Keys: bson.D{{Key: "createdAt", Value: 1}}, ExpireAfterSeconds: ptr(int32(repositories.DeliveryRetention / time.Second))},
+	},
+	"audit_logs": {
+		{Name: "targetId_time", Keys: bson.D{{Key: "targetId", Value: 1}, {Key: "time", Value: -1}}},
+		{Name: "time", Keys: bson.D{{Key: "time", Value: -1}}},
+		{Name: "actor_time", Keys: bson.D{{Key: "actor", Value: 1}, {Key: "time", Value: -1}}},
+		{Name: "action_time", Keys: bson.D{{Key: "action", Value: 1}, {Key: "time", Value: -1}}},
+	},
+	"outbox": {
+		// Pending events have no sentAt, so the TTL index never removes them
+		{Name: "sentAt_id", Keys: bson.D{{Key: "sentAt", Value: 1}, {Key: "_id", Value: 1}}},
+		{Name: "sentAt_ttl", Keys: bson.D{{Key: "sentAt", Value: 1}}, ExpireAfterSeconds: ptr(int32(repositories.OutboxRetention / time.Second))},
+	},
+	"roles": {
+		{Name: "tenantId_name_unique", Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "name", Value: 1}}, Unique: true},
+	},
+	"memberships": {
+		// A user has at most one membership per organization
+		{Name: "organizationId_userId_unique", Keys: bson.D{{Key: "organizationId", Value: 1}, {Key: "userId", Value: 1}}, Unique: true},
+		{Name: "userId", Keys: bson.D{{Key: "userId", Value: 1}}},
+	},
+	"group_members": {
+		// A user is in a group at most once, and member pages are read in user order
+		{Name: "groupId_userId_unique", Keys: bson.D{{Key: "groupId", Value: 1}, {Key: "userId", Value: 1}}, Unique: true},
+		{Name: "userId_groupId", Keys: bson.D{{Key: "userId", Value: 1}, {Key: "groupId", Value: 1}}},
+	},
+	"jobs": {
+		// Workers look for due jobs, which are pending or running with an ended lease
+		{Name: "status_runAt", Keys: bson.D{{Key: "status", Value: 1}, {Key: "runAt", Value: 1}}},
+		{Name: "tenantId_status_id", Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: -1}}},
+		// Unfinished jobs have no finishedAt, so the TTL index never removes them
+		{Name: "finishedAt_ttl", Keys: bson.D{{Key: "finishedAt", Value: 1}}, ExpireAfterSeconds: ptr(int32(repositories.JobRetention / time.Second))},
+	},
+	"task_runs": {
+		// Each due run of a task is started by one instance, and history is read newest first
+		{Name: "task_scheduledAt_unique", Keys: bson.D{{Key: "task", Value: 1}, {Key: "scheduledAt", Value: -1}}, Unique: true},
+		{Name: "startedAt_ttl", Keys: bson.D{{Key: "startedAt", Value: 1}}, ExpireAfterSeconds: ptr(int32(repositories.TaskRunRetention / time.Second))},
+	},
+	"phone_codes": {
+		// Records carry their own expiry time, so they expire as soon as it passes
+		{Name: "expiresAt_ttl", Keys: bson.D{{Key: "expiresAt", Value: 1}}, ExpireAfterSeconds: ptr(int32(0))},
+	},
+	"notifications": {
+		{Name: "userId_id", Keys: bson.D{{Key: "userId", Value: 1}, {Key: "_id", Value: -1}}},
+		{Name: "createdAt_ttl", Keys: bson.D{{Key: "createdAt", Value: 1}}, ExpireAfterSeconds: ptr(int32(repositories.NotificationRetention / time.Second))},
+	},
+	"api_usage": {
+		// Reports read a tenant's records newest day first, of every client or of one
+		{Name: "tenantId_day_client", Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "day", Value: -1}, {Key: "client", Value: 1}}},
+		{Name: "tenantId_client_day", Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "client", Value: 1}, {Key: "day", Value: -1}}},
+	},
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// EnsureIndexes creates missing indexes and rebuilds ones whose definition has changed.
+func EnsureIndexes(ctx context.Context, db *mongodb.Database, logger *slog.Logger) error {
+	names := make([]string, 0, len(collectionIndexes))
+	for name := range collectionIndexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := reconcileIndexes(ctx, db.Collection(name), collectionIndexes[name], logger); err != nil {
+			return fmt.Errorf("failed to reconcile indexes on %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func reconcileIndexes(ctx context.Context, collection *mongo.Collection, specs []indexSpec, logger *slog.Logger) error {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	var existing []existingIndex
+	if err := cursor.All(ctx, &existing); err != nil {
+		return err
+	}
+
+	existingByName := make(map[string]existingIndex, len(existing))
+	for _, index := range existing {
+		existingByName[index.Name] = index
+	}
+
+	declared := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		declared[spec.Name] = true
+		log := logger.With(slog.String("collection", collection.Name()), slog.String("index", spec.Name))
+
+		if current, ok := existingByName[spec.Name]; ok {
+			if indexMatches(current, spec) {
+				continue
+			}
+			if _, err := collection.Indexes().DropOne(ctx, spec.Name); err != nil {
+				return fmt.Errorf("failed to drop outdated index %s: %w", spec.Name, err)
+			}
+			log.Info("Dropped outdated index")
+		}
+
+		indexOptions := options.Index().SetName(spec.Name).SetUnique(spec.Unique)
+		if spec.ExpireAfterSeconds != nil {
+			indexOptions.SetExpireAfterSeconds(*spec.ExpireAfterSeconds)
+		}
+		model := mongo.IndexModel{
+			Keys:    spec.Keys,
+			Options: indexOptions,
+		}
+		if _, err := collection.Indexes().CreateOne(ctx, model); err != nil {
+			return fmt.Errorf("failed to create index %s: %w", spec.Name, err)
+		}
+		log.Info("Created index")
+	}
+
+	for name := range existingByName {
+		if name != "_id_" && !declared[name] {
+			logger.Warn("Found unmanaged index", slog.String("collection", collection.Name()), slog.String("index", name))
+		}
+	}
+	return nil
+}
+
+// existingIndex is an index description as returned by listIndexes.
+type existingIndex struct {
+	Name    string `bson:"name"`
+	Key     bson.D `bson:"key"`
+	Unique  bool   `bson:"unique"`
+	Weights bson.M `bson:"weights"`
+
+	ExpireAfterSeconds *int32 `bson:"expireAfterSeconds"`
+}
+
+// indexMatches reports whether an index description returned by the server matches spec.
+func indexMatches(current existingIndex, spec indexSpec) bool {
+	if current.Unique != spec.Unique {
+		return false
+	}
+	if (current.ExpireAfterSeconds == nil) != (spec.ExpireAfterSeconds == nil) ||
+		(spec.ExpireAfterSeconds != nil && *current.ExpireAfterSeconds != *spec.ExpireAfterSeconds) {
+		return false
+	}
+
+	// Text indexes are stored as {_fts: "text", _ftsx: 1} with the fields listed in weights
+	textFields := map[string]bool{}
+	var keys bson.D
+	for _, key := range spec.Keys {
+		if key.Value == "text" {
+			textFields[key.Key] = true
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(textFields) > 0 {
+		if len(current.Weights) != len(textFields) {
+			return false
+		}
+		for field := range current.Weights {
+			if !textFields[field] {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(current.Key) != len(keys) {
+		return false
+	}
+	for i, key := range keys {
+		if current.Key[i].Key != key.Key || !reflect.DeepEqual(normalizeIndexValue(current.Key[i].Value), normalizeIndexValue(key.Value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeIndexValue converts numeric index directions to a common type for comparison.
+func normalizeIndexValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return v
+	}
+}
@@ -0,0 +1,31 @@
+package initializers
+
+import (
+	"example_api/config"
+	"log/slog"
+	"os"
+)
+
+// NewLogger builds the application logger in the configured output format, logging records at
+// level and above. A slog.LevelVar lets a configuration reload change the level.
+func NewLogger(cfg *config.Config, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.LogFormat == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
+// LogLevel returns the level LOG_LEVEL names.
+func LogLevel(name string) slog.Level {
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,29 @@
+package initializers
+
+import (
+	"context"
+	"example_api/config"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConnectToPostgres initializes and returns a PostgreSQL connection pool.
+func ConnectToPostgres(cfg *config.Config, logger *slog.Logger) (*pgxpool.Pool, error) {
+	// Connect to PostgreSQL
+	pool, err := pgxpool.New(context.TODO(), cfg.PostgresURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Postgres: %v", err)
+	}
+
+	// Ping the PostgreSQL server
+	if err := pool.Ping(context.TODO()); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping Postgres: %v", err)
+	}
+
+	logger.Info("Connected to Postgres")
+
+	return pool, nil
+}
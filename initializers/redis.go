@@ -0,0 +1,29 @@
+package initializers
+
+import (
+	"context"
+	"example_api/config"
+	"fmt"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ConnectToRedis initializes and returns a Redis client.
+func ConnectToRedis(cfg *config.Config, logger *slog.Logger) (*redis.Client, error) {
+	opts, err := redis.ParseURL(cfg.RedisURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URI: %v", err)
+	}
+
+	// Connect to Redis and verify the connection
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.TODO()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping Redis: %v", err)
+	}
+
+	logger.Info("Connected to Redis")
+
+	return client, nil
+}
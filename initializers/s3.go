@@ -0,0 +1,48 @@
+package initializers
+
+import (
+	"context"
+	"example_api/config"
+	"fmt"
+	"log/slog"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ConnectToS3 initializes an S3 client and verifies that the configured bucket exists.
+func ConnectToS3(cfg *config.Config, logger *slog.Logger) (*minio.Client, error) {
+	s3 := cfg.AvatarStorage.S3
+
+	// Use static keys when given, otherwise the standard AWS credential sources
+	creds := credentials.NewStaticV4(s3.AccessKeyID, s3.SecretAccessKey, "")
+	if s3.AccessKeyID == "" {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+
+	client, err := minio.New(s3.Endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: s3.UseSSL,
+		Region: s3.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT: %v", err)
+	}
+
+	// Check the bucket so a typo fails at startup rather than on the first upload
+	exists, err := client.BucketExists(context.TODO(), s3.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach S3 bucket %q: %v", s3.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("S3 bucket %q does not exist", s3.Bucket)
+	}
+
+	logger.Info("Connected to S3", slog.String("endpoint", s3.Endpoint), slog.String("bucket", s3.Bucket))
+
+	return client, nil
+}
@@ -0,0 +1,273 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/tenant"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Handler runs one job. A nil error completes it; other errors are retried with backoff until
+// the job's attempts run out, unless they are marked Permanent. Handlers can tell the last
+// attempt by job.Attempts reaching job.MaxAttempts.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Options tunes the workers.
+type Options struct {
+	// Workers is how many jobs run concurrently on this instance
+	Workers int
+	// PollInterval is how long idle workers wait before looking for due jobs again
+	PollInterval time.Duration
+	// Lease is how long a job may run before other workers consider its worker gone and run it
+	// again; handlers are cancelled when it ends
+	Lease time.Duration
+}
+
+// registration is a job type's handler and retry policy.
+type registration struct {
+	handler Handler
+	retry   repositories.RetryPolicy
+}
+
+// Queue runs jobs in the background on a pool of workers. Jobs are kept in a JobStore, so they
+// survive restarts and are shared by every instance using the same database. Each job type is
+// registered with its handler and retry policy before Run.
+type Queue struct {
+	store    repositories.JobStore
+	opts     Options
+	logger   *slog.Logger
+	handlers map[string]registration
+	// wake lets Enqueue start an idle worker without waiting for the next poll
+	wake chan struct{}
+	stop chan struct{}
+	once sync.Once
+}
+
+func NewQueue(store repositories.JobStore, opts Options, logger *slog.Logger) *Queue {
+	return &Queue{
+		store:    store,
+		opts:     opts,
+		logger:   logger,
+		handlers: make(map[string]registration),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register makes workers run jobs of the given type with handler, making up to
+// retry.MaxAttempts attempts with retry's backoff in between. It must be called before Run.
+func (q *Queue) Register(kind string, handler Handler, retry repositories.RetryPolicy) {
+	q.handlers[kind] = registration{handler: handler, retry: retry}
+}
+
+// Types returns the registered job types.
+func (q *Queue) Types() []string {
+	types := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		types = append(types, kind)
+	}
+	return types
+}
+
+// Enqueue stores a job of a registered type with payload encoded as its JSON input, in the
+// tenant of ctx, and returns it. The job is due right away.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (*models.Job, error) {
+	registered, ok := q.handlers[kind]
+	if !ok {
+		return nil, fmt.Errorf("job type %q is not registered", kind)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s job: %w", kind, err)
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		Id:          primitive.NewObjectID(),
+		Type:        kind,
+		Payload:     data,
+		Status:      models.JobPending,
+		MaxAttempts: max(registered.retry.MaxAttempts, 1),
+		RunAt:       now,
+		CreatedAt:   now,
+		Tenant:      tenant.FromContext(ctx),
+	}
+	if err := q.store.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Retry moves a dead job of the tenant of ctx back into the queue with its attempts reset, and
+// returns it, or repositories.ErrJobNotDead.
+func (q *Queue) Retry(ctx context.Context, id primitive.ObjectID) (*models.Job, error) {
+	job, err := q.store.Requeue(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Close stops workers from claiming jobs. Run returns once the jobs under way are done.
+func (q *Queue) Close() {
+	q.once.Do(func() { close(q.stop) })
+}
+
+// Run works through due jobs until Close is called, then finishes the jobs under way.
+// Cancelling ctx cancels them, and they are run again without counting the interrupted attempt.
+func (q *Queue) Run(ctx context.Context) {
+	if len(q.handlers) == 0 {
+		return
+	}
+	types := q.Types()
+	var wg sync.WaitGroup
+	for i := 0; i < q.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, types)
+		}()
+	}
+	wg.Wait()
+}
+
+// work claims and runs jobs one at a time, waiting for the poll interval or a new job whenever
+// none is due.
+func (q *Queue) work(ctx context.Context, types []string) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+
+		for {
+			select {
+			case <-q.stop:
+				return
+			default:
+			}
+			now := time.Now()
+			job, err := q.store.Claim(ctx, types, now, now.Add(q.opts.Lease))
+			if err != nil {
+				if ctx.Err() == nil {
+					q.logger.ErrorContext(ctx, "Failed to claim job", slog.Any("error", err))
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			q.run(ctx, job)
+		}
+		timer.Reset(q.opts.PollInterval)
+	}
+}
+
+// run calls the handler of a claimed job and records its outcome.
+func (q *Queue) run(ctx context.Context, job *models.Job) {
+	log := q.logger.With(slog.String("job", job.Id.Hex()), slog.String("type", job.Type), slog.Int("attempt", job.Attempts))
+	registered := q.handlers[job.Type]
+
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		// The worker that made the last attempt stopped before recording its outcome
+		err = errors.New("job was interrupted on its last attempt")
+	} else {
+		jobCtx, cancel := context.WithTimeout(tenant.NewContext(ctx, job.Tenant), q.opts.Lease)
+		err = q.call(jobCtx, registered.handler, job)
+		cancel()
+	}
+
+	// Outcomes are recorded even once ctx is done, so the job is not run again needlessly
+	attempt := job.Attempts
+	now := time.Now()
+	switch {
+	case err != nil && ctx.Err() != nil:
+		job.Status = models.JobPending
+		job.Attempts--
+		job.LastError = "interrupted by shutdown"
+		job.RunAt = now
+	case err == nil:
+		job.Status = models.JobSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		job.Status = models.JobDead
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		log.WarnContext(ctx, "Job failed and was moved to the dead letter queue", slog.Any("error", err))
+	default:
+		job.Status = models.JobPending
+		job.LastError = err.Error()
+		job.RunAt = now.Add(registered.retry.Backoff(job.Attempts))
+		log.InfoContext(ctx, "Job failed and will be retried", slog.Time("runAt", job.RunAt), slog.Any("error", err))
+	}
+	if err := q.store.Release(context.WithoutCancel(ctx), job, attempt); err != nil {
+		log.ErrorContext(ctx, "Failed to record job outcome", slog.Any("error", err))
+	}
+}
+
+// call runs handler, turning a panic into an error so one bad job cannot stop its worker.
+func (q *Queue) call(ctx context.Context, handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = Permanent(fmt.Errorf("job handler panicked: %v", recovered))
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// permanent marks a failure that retrying cannot fix.
+type permanent struct {
+	err error
+}
+
+func (p permanent) Error() string {
+	return p.err.Error()
+}
+
+func (p permanent) Unwrap() error {
+	return p.err
+}
+
+// Permanent marks err as a failure retrying cannot fix, so the job goes straight to the dead
+// letter queue.
+func Permanent(err error) error {
+	return permanent{err}
+}
+
+// IsPermanent reports whether err was marked Permanent.
+func IsPermanent(err error) bool {
+	var p permanent
+	return errors.As(err, &p)
+}
+
+// Decode unmarshals the payload of job into v, marking failures Permanent.
+func Decode(job *models.Job, v any) error {
+	if err := json.Unmarshal(job.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid %s job payload: %w", job.Type, err))
+	}
+	return nil
+}
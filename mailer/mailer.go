@@ -0,0 +1,258 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message is one email to a single recipient. HTML is optional; when set, it is sent as an
+// alternative to Text.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Options configures an SMTPMailer.
+type Options struct {
+	Host string
+	Port string
+	// Username and Password enable PLAIN authentication, which is only used over TLS
+	Username string
+	Password string
+	// Credentials, when set, is asked for the username and password of each send instead, so
+	// credentials rotated in a secrets manager are used without a restart
+	Credentials func() (username, password string)
+	From        string
+	// Timeout bounds a whole send, from dialing to QUIT
+	Timeout time.Duration
+}
+
+// SMTPMailer sends each message over a new SMTP connection. Port 465 uses implicit TLS; other
+// ports upgrade with STARTTLS whenever the server offers it.
+type SMTPMailer struct {
+	opts Options
+	from *mail.Address
+}
+
+func NewSMTPMailer(opts Options) (*SMTPMailer, error) {
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %v", err)
+	}
+	return &SMTPMailer{
+		opts: opts,
+		from: from,
+	}, nil
+}
+
+var _ Mailer = (*SMTPMailer)(nil)
+
+// Send delivers msg to the SMTP server, which relays it to the recipient.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid recipient address: %w", err))
+	}
+	body, err := m.compose(to, msg)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	defer cancel()
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	// net/smtp has no context support, so bound the connection by the deadline instead
+	stop := context.AfterFunc(ctx, func() { client.Close() })
+	defer stop()
+
+	if err := m.send(client, to.Address, body); err != nil {
+		return classify(err)
+	}
+	return nil
+}
+
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.opts.Host, m.opts.Port)
+	var conn net.Conn
+	var err error
+	if m.opts.Port == "465" {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: m.opts.Host}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.opts.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to greet SMTP server: %w", err)
+	}
+	return client, nil
+}
+
+func (m *SMTPMailer) send(client *smtp.Client, to string, body []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.opts.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	username, password := m.opts.Username, m.opts.Password
+	if m.opts.Credentials != nil {
+		username, password = m.opts.Credentials()
+	}
+	if username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection to a remote host
+		if err := client.Auth(smtp.PlainAuth("", username, password, m.opts.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("recipient rejected: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// compose renders msg as an RFC 5322 message with quoted-printable UTF-8 parts: the text body
+// alone, or the text and HTML bodies as multipart/alternative.
+func (m *SMTPMailer) compose(to *mail.Address, msg Message) ([]byte, error) {
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, errors.New("subject must be a single line")
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", m.from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+uuid.NewString()+"@"+domain(m.from.Address)+">")
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	buf.WriteString("\r\n")
+	// Clients show the last alternative they support, so the richer HTML part goes last
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func domain(address string) string {
+	return address[strings.LastIndexByte(address, '@')+1:]
+}
+
+// permanent marks a failure that resending cannot fix.
+type permanent struct {
+	err error
+}
+
+func (p permanent) Error() string {
+	return p.err.Error()
+}
+
+func (p permanent) Unwrap() error {
+	return p.err
+}
+
+// Permanent marks err as a failure that resending the same message cannot fix.
+func Permanent(err error) error {
+	return permanent{err}
+}
+
+// IsPermanent reports whether err is a failure that resending the same message cannot fix,
+// such as an invalid address or a 5xx reply from the server.
+func IsPermanent(err error) bool {
+	var p permanent
+	return errors.As(err, &p)
+}
+
+// classify marks 5xx SMTP replies as permanent; 4xx replies and network errors may succeed later.
+func classify(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"example_api/apperrors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Template names
+const (
+	TemplateWelcome       = "welcome"
+	TemplateVerification  = "verification"
+	TemplatePasswordReset = "password_reset"
+)
+
+// ErrTemplateNotFound is returned when no email template has the requested name.
+var ErrTemplateNotFound = apperrors.NotFound("Email template not found")
+
+// templateFS holds a directory per locale with a <name>.txt and <name>.html file per template.
+// The text file defines the "subject" template and is otherwise the plain-text body; the HTML
+// file defines the "content" placed in layout.html.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// Data is what templates can refer to. Link and Expires are only used by templates that send
+// the user somewhere, such as verification and password reset.
+type Data struct {
+	FirstName string
+	Email     string
+	Link      string
+	// Expires is how long Link stays valid
+	Expires time.Duration
+}
+
+// templateData is Data plus the values every template sees.
+type templateData struct {
+	Data
+	Locale  string
+	Subject string
+}
+
+var templateFuncs = map[string]any{
+	"hours": func(d time.Duration) int { return int(d.Round(time.Hour) / time.Hour) },
+}
+
+// localized is one template in one locale.
+type localized struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer renders the embedded email templates. A locale that lacks a template, or is not
+// supported at all, falls back to the default locale.
+type Renderer struct {
+	defaultLocale string
+	// templates maps locale and then template name to the parsed template
+	templates map[string]map[string]localized
+}
+
+// NewRenderer parses every embedded template. defaultLocale must provide every template.
+func NewRenderer(defaultLocale string) (*Renderer, error) {
+	r := &Renderer{
+		defaultLocale: defaultLocale,
+		templates:     make(map[string]map[string]localized),
+	}
+
+	locales, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range locales {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		files, err := fs.Glob(templateFS, path.Join("templates", locale, "*.txt"))
+		if err != nil {
+			return nil, err
+		}
+		r.templates[locale] = make(map[string]localized, len(files))
+		for _, file := range files {
+			name := strings.TrimSuffix(path.Base(file), ".txt")
+			t, err := parseTemplate(locale, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s email template %s: %v", locale, name, err)
+			}
+			r.templates[locale][name] = t
+		}
+	}
+
+	defaults, ok := r.templates[defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("no email templates for locale %q", defaultLocale)
+	}
+	for _, name := range []string{TemplateWelcome, TemplateVerification, TemplatePasswordReset} {
+		if _, ok := defaults[name]; !ok {
+			return nil, fmt.Errorf("locale %q has no %s email template", defaultLocale, name)
+		}
+	}
+	return r, nil
+}
+
+func parseTemplate(locale, name string) (localized, error) {
+	dir := path.Join("templates", locale)
+	text, err := texttemplate.New(name+".txt").Funcs(templateFuncs).ParseFS(templateFS, path.Join(dir, name+".txt"))
+	if err != nil {
+		return localized{}, err
+	}
+	if text.Lookup("subject") == nil {
+		return localized{}, fmt.Errorf("%s.txt does not define a subject", name)
+	}
+	html, err := htmltemplate.New("layout").Funcs(templateFuncs).ParseFS(templateFS, "templates/layout.html", path.Join(dir, name+".html"))
+	if err != nil {
+		return localized{}, err
+	}
+	return localized{text: text, html: html}, nil
+}
+
+// Locales returns the supported locales in alphabetical order.
+func (r *Renderer) Locales() []string {
+	locales := make([]string, 0, len(r.templates))
+	for locale := range r.templates {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Templates returns the template names in alphabetical order.
+func (r *Renderer) Templates() []string {
+	names := make([]string, 0, len(r.templates[r.defaultLocale]))
+	for name := range r.templates[r.defaultLocale] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Supports reports whether locale has its own templates.
+func (r *Renderer) Supports(locale string) bool {
+	_, ok := r.templates[locale]
+	return ok
+}
+
+// Render renders the named template for data in the best match for locale, such as "tr" for
+// "tr-TR", and returns the message without a recipient along with the locale used.
+func (r *Renderer) Render(name, locale string, data Data) (Message, string, error) {
+	locale = r.resolve(name, locale)
+	t, ok := r.templates[locale][name]
+	if !ok {
+		return Message{}, "", ErrTemplateNotFound
+	}
+
+	td := templateData{Data: data, Locale: locale}
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", td); err != nil {
+		return Message{}, "", fmt.Errorf("failed to render %s email subject: %w", name, err)
+	}
+	td.Subject = strings.TrimSpace(subject.String())
+	if err := t.text.Execute(&text, td); err != nil {
+		return Message{}, "", fmt.Errorf("failed to render %s email text: %w", name, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, "layout", td); err != nil {
+		return Message{}, "", fmt.Errorf("failed to render %s email HTML: %w", name, err)
+	}
+	return Message{Subject: td.Subject, Text: text.String(), HTML: html.String()}, locale, nil
+}
+
+// resolve returns the locale whose variant of the named template to use for locale.
+func (r *Renderer) resolve(name, locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	base, _, _ := strings.Cut(locale, "-")
+	for _, candidate := range []string{locale, base} {
+		if _, ok := r.templates[candidate][name]; ok {
+			return candidate
+		}
+	}
+	return r.defaultLocale
+}
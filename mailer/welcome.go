@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"example_api/events"
+	"example_api/jobs"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/tenant"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobWelcome is the job type of a welcome email.
+const JobWelcome = "email.welcome"
+
+// welcomeBuffer is how many signups may wait to be queued before the sender falls behind and
+// catches up from the broker's history instead.
+const welcomeBuffer = 256
+
+// retryPolicy rides out greylisting and brief mail server outages.
+var retryPolicy = repositories.RetryPolicy{MaxAttempts: 4, BaseDelay: 2 * time.Second, MaxDelay: 30 * time.Second}
+
+// WelcomeSender emails every new user once their account has been created. Each email is a
+// job, so signups never wait for the mail server and emails survive restarts.
+type WelcomeSender struct {
+	mailer   Mailer
+	renderer *Renderer
+	broker   *events.Broker
+	queue    *jobs.Queue
+	users    repositories.UserStore
+	logger   *slog.Logger
+}
+
+// NewWelcomeSender returns a sender and registers its emails with queue.
+func NewWelcomeSender(mailer Mailer, renderer *Renderer, broker *events.Broker, queue *jobs.Queue, users repositories.UserStore, logger *slog.Logger) *WelcomeSender {
+	s := &WelcomeSender{
+		mailer:   mailer,
+		renderer: renderer,
+		broker:   broker,
+		queue:    queue,
+		users:    users,
+		logger:   logger,
+	}
+	queue.Register(JobWelcome, s.send, retryPolicy)
+	return s
+}
+
+// welcome is the payload of a welcome email job. It only names the user, so the job store
+// holds no personal data and erasing the user cancels the email.
+type welcome struct {
+	UserID primitive.ObjectID `json:"userId"`
+}
+
+// Run queues a welcome email for every signup until the broker closes.
+func (s *WelcomeSender) Run(ctx context.Context) {
+	s.broker.Consume(ctx, welcomeBuffer, func(event events.Event) {
+		user, ok := event.Data.(events.User)
+		if event.Type != events.UserCreated || !ok {
+			return
+		}
+		id, err := primitive.ObjectIDFromHex(user.ID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Invalid user ID in signup event", slog.String("user", user.ID), slog.Any("error", err))
+			return
+		}
+		if _, err := s.queue.Enqueue(tenant.NewContext(ctx, event.Tenant), JobWelcome, welcome{UserID: id}); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to queue welcome email", slog.String("user", user.ID), slog.Any("error", err))
+		}
+	})
+}
+
+// send emails the user of a welcome job, unless they have been deleted or erased since.
+func (s *WelcomeSender) send(ctx context.Context, job *models.Job) error {
+	var payload welcome
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	user, err := s.users.GetByID(ctx, payload.UserID)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.ErasedAt != nil {
+		return nil
+	}
+
+	// Users do not record a language, so welcome emails use the renderer's default locale
+	msg, _, err := s.renderer.Render(TemplateWelcome, "", Data{FirstName: user.FirstName, Email: user.Email})
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	msg.To = user.Email
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		if IsPermanent(err) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	return nil
+}
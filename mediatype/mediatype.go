@@ -0,0 +1,78 @@
+package mediatype
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types the API can read or render.
+const (
+	JSON        = "application/json"
+	JSONAPI     = "application/vnd.api+json"
+	XML         = "application/xml"
+	ProblemJSON = "application/problem+json"
+	ProblemXML  = "application/problem+xml"
+)
+
+// Negotiate returns the offer the request's Accept header prefers. Ties go to the earlier
+// offer, and the first offer is returned when the header is absent or matches nothing.
+func Negotiate(r *http.Request, offers ...string) string {
+	header := r.Header.Get("Accept")
+	if header == "" {
+		return offers[0]
+	}
+
+	best, bestQuality := offers[0], 0.0
+	for _, offer := range offers {
+		if quality := acceptQuality(header, offer); quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the weight an Accept header gives mediaType, using its most specific
+// matching range.
+func acceptQuality(header, mediaType string) float64 {
+	offerType, offerSubtype, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(header, ",") {
+		accepted, params, _ := strings.Cut(part, ";")
+		acceptedType, acceptedSubtype, _ := strings.Cut(strings.ToLower(strings.TrimSpace(accepted)), "/")
+
+		var rank int
+		switch {
+		case acceptedType == offerType && acceptedSubtype == offerSubtype:
+			rank = 2
+		case acceptedType == offerType && acceptedSubtype == "*":
+			rank = 1
+		case acceptedType == "*" && acceptedSubtype == "*":
+			rank = 0
+		default:
+			continue
+		}
+		if rank <= specificity {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		quality, specificity = q, rank
+	}
+	return quality
+}
+
+// IsXML reports whether a Content-Type header value names an XML media type.
+func IsXML(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return mediaType == XML || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
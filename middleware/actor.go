@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"example_api/actor"
+	"net/http"
+)
+
+// Actor attributes the changes a request makes to the named actor at the client's address.
+// An inner Actor overrides an outer one, so routes can narrow down the default.
+func Actor(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := actor.NewContext(r.Context(), actor.Actor{Name: name, IP: remoteIP(r)})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
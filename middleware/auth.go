@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"example_api/apperrors"
+	"example_api/auth"
+	"example_api/policy"
+	"example_api/problem"
+	"example_api/ratelimit"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Authenticator checks user credentials and returns whom they belong to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, email, password string) (*auth.Principal, error)
+}
+
+// Authenticate sets the principal of requests that present credentials: adminToken as a bearer
+// token makes it admin, and an email and password in HTTP Basic authentication makes it that
+// user. Wrong Basic credentials get 401; other bearer tokens are left to the routes that take
+// them, and requests without credentials stay anonymous. Sign-ins that authenticator throttles
+// with a *ratelimit.ThrottledError get 429 Too Many Requests with Retry-After.
+func Authenticate(adminToken string, admin *auth.Principal, authenticator Authenticator, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var principal *auth.Principal
+			if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+				if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+					principal = admin
+				}
+			} else if email, password, ok := r.BasicAuth(); ok {
+				var err error
+				principal, err = authenticator.Authenticate(r.Context(), email, password)
+				var throttled *ratelimit.ThrottledError
+				switch {
+				case errors.As(err, &throttled):
+					tooManyFailures(w, r, throttled.Wait)
+					return
+				case errors.Is(err, auth.ErrInvalidCredentials):
+					unauthorized(w, r, "Invalid email or password")
+					return
+				case err != nil:
+					logger.ErrorContext(r.Context(), "Authentication failed", slog.Any("error", err))
+					problem.Error(w, r, http.StatusInternalServerError, "Authentication failed")
+					return
+				}
+			}
+			if principal != nil {
+				r = r.WithContext(auth.NewContext(r.Context(), principal))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authorize evaluates the policy of each matched route against the principal of the request.
+// Routes are looked up by their path template with the first matching prefix removed, so the
+// versioned and unversioned mounts share policies; routes outside every prefix are not API
+// routes and pass through. API routes without a policy are denied.
+func Authorize(policies policy.Table, logger *slog.Logger, prefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			path, ok := apiPath(template, prefixes)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rule, ok := policies.Route(r.Method, path)
+			if !ok {
+				logger.ErrorContext(r.Context(), "Route has no authorization policy", slog.String("method", r.Method), slog.String("route", path))
+				problem.Error(w, r, http.StatusForbidden, "Forbidden")
+				return
+			}
+			if err := rule.Evaluate(auth.FromContext(r.Context()), mux.Vars(r)); err != nil {
+				if errors.Is(err, apperrors.ErrUnauthenticated) {
+					unauthorized(w, r, apperrors.Message(err, "Authentication is required"))
+					return
+				}
+				problem.Error(w, r, apperrors.HTTPStatus(err), apperrors.Message(err, "Forbidden"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// apiPath returns template with the first of prefixes it starts with removed.
+func apiPath(template string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if path, ok := strings.CutPrefix(template, prefix); ok && strings.HasPrefix(path, "/") {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// tooManyFailures tells a client that failed to authenticate too often to wait before trying
+// again.
+func tooManyFailures(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	problem.Error(w, r, http.StatusTooManyRequests, fmt.Sprintf("Too many failed sign-ins from your address, try again in %d seconds", seconds))
+}
+
+// unauthorized rejects a request for lacking valid credentials. The challenge is Bearer even
+// though Basic credentials are accepted, since a Basic challenge makes browsers prompt for a
+// password and then send it with every later request to the API.
+func unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="example-api"`)
+	problem.Error(w, r, http.StatusUnauthorized, detail)
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"example_api/auth"
+	"example_api/policy"
+	"example_api/ratelimit"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// stubAuthenticator knows one user, and throttles the password "throttled".
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) Authenticate(ctx context.Context, email, password string) (*auth.Principal, error) {
+	switch {
+	case password == "throttled":
+		return nil, &ratelimit.ThrottledError{Wait: 1500 * time.Millisecond}
+	case email == "ada@example.com" && password == "secret":
+		return &auth.Principal{UserID: "ada", Role: "user"}, nil
+	default:
+		return nil, auth.ErrInvalidCredentials
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	admin := &auth.Principal{Role: "admin"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name       string
+		header     string
+		email      string
+		password   string
+		status     int
+		principal  string
+		retryAfter string
+	}{
+		{name: "anonymous", status: http.StatusOK},
+		{name: "admin token", header: "Bearer admin-token", status: http.StatusOK, principal: "admin"},
+		{name: "other bearer token", header: "Bearer other", status: http.StatusOK},
+		{name: "user", email: "ada@example.com", password: "secret", status: http.StatusOK, principal: "user"},
+		{name: "wrong password", email: "ada@example.com", password: "wrong", status: http.StatusUnauthorized},
+		{name: "throttled", email: "ada@example.com", password: "throttled", status: http.StatusTooManyRequests, retryAfter: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal string
+			handler := Authenticate("admin-token", admin, stubAuthenticator{}, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if p := auth.FromContext(r.Context()); p != nil {
+					principal = p.Role
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.email != "" {
+				req.SetBasicAuth(tt.email, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.status)
+			}
+			if principal != tt.principal {
+				t.Fatalf("got principal %q, want %q", principal, tt.principal)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Fatalf("got Retry-After %q, want %q", got, tt.retryAfter)
+			}
+			// A Basic challenge would have browsers prompt for and remember a password
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != `Bearer realm="example-api"` {
+				t.Fatalf("got WWW-Authenticate %q", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	policies := policy.Table{
+		"GET /users":      policy.Require("users:read"),
+		"GET /users/{id}": policy.Require("users:read").OrSelf("id"),
+		"POST /users":     policy.Public,
+	}
+	reader := &auth.Principal{UserID: "bob", Permissions: []string{"users:read"}}
+	user := &auth.Principal{UserID: "ada"}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		principal *auth.Principal
+		status    int
+	}{
+		{"public route", http.MethodPost, "/api/v1/users", nil, http.StatusOK},
+		{"anonymous", http.MethodGet, "/api/v1/users", nil, http.StatusUnauthorized},
+		{"missing permission", http.MethodGet, "/api/v1/users", user, http.StatusForbidden},
+		{"permission", http.MethodGet, "/api/v1/users", reader, http.StatusOK},
+		{"own account", http.MethodGet, "/api/v1/users/ada", user, http.StatusOK},
+		{"another account", http.MethodGet, "/api/v1/users/bob", user, http.StatusForbidden},
+		{"route without a policy", http.MethodDelete, "/api/v1/users/ada", reader, http.StatusForbidden},
+		{"route outside the API", http.MethodGet, "/health", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok := func(w http.ResponseWriter, r *http.Request) {}
+			r := mux.NewRouter()
+			r.Use(Authorize(policies, slog.New(slog.NewTextHandler(io.Discard, nil)), "/api/v1"))
+			r.HandleFunc("/api/v1/users", ok).Methods(http.MethodGet, http.MethodPost)
+			r.HandleFunc("/api/v1/users/{id}", ok).Methods(http.MethodGet, http.MethodDelete)
+			r.HandleFunc("/health", ok)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
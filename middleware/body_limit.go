@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"example_api/problem"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// BodyLimit caps the request body of each matched route at its entry in limits, keyed by
+// method and path template with the first matching prefix removed as for Authorize, or at def.
+// A larger declared body is refused with 413 Payload Too Large before it is read; one that
+// only turns out larger fails to read past the limit.
+func BodyLimit(def int64, limits map[string]int64, prefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := def
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					if path, ok := apiPath(template, prefixes); ok {
+						if routeLimit, ok := limits[r.Method+" "+path]; ok {
+							limit = routeLimit
+						}
+					}
+				}
+			}
+
+			if r.ContentLength > limit {
+				// Reading no further, the connection cannot be reused
+				w.Header().Set("Connection", "close")
+				problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", limit))
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"errors"
+	"example_api/auth"
+	"example_api/captcha"
+	"example_api/clientip"
+	"example_api/problem"
+	"log/slog"
+	"net/http"
+)
+
+// RequireCaptcha lets anonymous requests through only with the token of a solved CAPTCHA in
+// the X-Captcha-Token header, which verifier accepts for the client's address. Requests without
+// one or with a rejected one get 400; authenticated requests are not checked. Requests are
+// turned away with 503 while the provider cannot be reached, since letting them through would
+// open the route to scripts. Below Idempotency, rejections are not stored, so a retry with the
+// same key and a solved CAPTCHA goes through.
+func RequireCaptcha(verifier captcha.Verifier, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.FromContext(r.Context()) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			err := verifier.Verify(r.Context(), r.Header.Get(captcha.Header), clientip.FromContext(r.Context()))
+			if err != nil {
+				discardIdempotent(r)
+			}
+			switch {
+			case errors.Is(err, captcha.ErrMissing):
+				problem.Error(w, r, http.StatusBadRequest, "A solved CAPTCHA is required in the X-Captcha-Token header")
+			case errors.Is(err, captcha.ErrRejected):
+				problem.Error(w, r, http.StatusBadRequest, "CAPTCHA verification failed")
+			case err != nil:
+				logger.ErrorContext(r.Context(), "CAPTCHA verification failed", slog.Any("error", err))
+				problem.Error(w, r, http.StatusServiceUnavailable, "CAPTCHA verification is unavailable, try again later")
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"example_api/captcha"
+	"example_api/repositories"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// onceVerifier accepts the token "good" once, like providers do, and rejects every other one.
+type onceVerifier struct {
+	spent bool
+}
+
+func (v *onceVerifier) Verify(ctx context.Context, token, ip string) error {
+	if token == "" {
+		return captcha.ErrMissing
+	}
+	if token != "good" || v.spent {
+		return fmt.Errorf("%w: invalid-input-response", captcha.ErrRejected)
+	}
+	v.spent = true
+	return nil
+}
+
+func TestRequireCaptchaRetryAfterRejection(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var created int
+	signup := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		created++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":"%d"}`, created)
+	})
+	handler := Idempotency(repositories.NewMemoryIdempotencyRepository(), time.Hour, logger)(RequireCaptcha(&onceVerifier{}, logger)(signup))
+
+	steps := []struct {
+		name     string
+		token    string
+		status   int
+		replayed bool
+	}{
+		{"rejected CAPTCHA", "bad", http.StatusBadRequest, false},
+		{"missing CAPTCHA", "", http.StatusBadRequest, false},
+		{"retry with a solved CAPTCHA", "good", http.StatusCreated, false},
+		{"retry after success", "good", http.StatusCreated, true},
+	}
+	for _, step := range steps {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":"ada@example.com"}`))
+		req.Header.Set(IdempotencyKeyHeader, "signup-1")
+		if step.token != "" {
+			req.Header.Set(captcha.Header, step.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != step.status {
+			t.Fatalf("%s: got status %d, want %d", step.name, rec.Code, step.status)
+		}
+		if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != step.replayed {
+			t.Fatalf("%s: got replayed %t, want %t", step.name, replayed, step.replayed)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("created %d users, want 1", created)
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"example_api/clientip"
+	"net/http"
+	"net/netip"
+)
+
+// ClientIP stores the address of the client in the request context, taking it from the
+// forwarding headers of requests that come through the proxies in trusted.
+func ClientIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := clientip.NewContext(r.Context(), clientip.Resolve(r, trusted))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
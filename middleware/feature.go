@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"example_api/problem"
+	"net/http"
+)
+
+// FeatureChecker reports whether a feature flag is on for a request.
+type FeatureChecker interface {
+	Enabled(ctx context.Context, key string) bool
+}
+
+// RequireFeature hides the routes it wraps behind the feature flag with the given key: while
+// the flag is off for a request, it gets 404 as if the routes did not exist.
+func RequireFeature(checker FeatureChecker, key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !checker.Enabled(r.Context(), key) {
+				problem.Error(w, r, http.StatusNotFound, "Not Found")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzip(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		etag           string
+		status         int
+		encoding       string
+		wantETag       string
+	}{
+		{"strong ETag is weakened", "gzip", "application/json", `"v1"`, http.StatusOK, "gzip", `W/"v1"`},
+		{"weak ETag is kept", "gzip", "application/json", `W/"v1"`, http.StatusOK, "gzip", `W/"v1"`},
+		{"not accepted", "identity", "application/json", `"v1"`, http.StatusOK, "", `"v1"`},
+		{"refused with q=0", "gzip;q=0", "application/json", `"v1"`, http.StatusOK, "", `"v1"`},
+		{"incompressible content", "gzip", "image/png", `"v1"`, http.StatusOK, "", `"v1"`},
+		{"not modified", "gzip", "application/json", `"v1"`, http.StatusNotModified, "", `"v1"`},
+		{"sniffed content type", "gzip", "", "", http.StatusOK, "gzip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const body = `{"users":[]}`
+			handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusNotModified {
+					w.Write([]byte(body))
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("got Content-Encoding %q, want %q", got, tt.encoding)
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Fatalf("got ETag %q, want %q", got, tt.wantETag)
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Fatalf("got Vary %q, want Accept-Encoding", rec.Header().Get("Vary"))
+			}
+			if tt.status == http.StatusNotModified {
+				return
+			}
+			var reader io.Reader = rec.Body
+			if tt.encoding == "gzip" {
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("body is not gzip: %v", err)
+				}
+				reader = gz
+			}
+			if got, _ := io.ReadAll(reader); string(got) != body {
+				t.Fatalf("got body %q, want %q", got, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"example_api/auth"
+	"example_api/problem"
+	"example_api/repositories"
+	"example_api/tenant"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader carries the client-chosen key that identifies retries of one request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds keys so they stay cheap to index.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with an idempotent response. The rest are
+// set by the middleware chain again on replay.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// discardKey is the context key of the flag that makes Idempotency release a key rather than
+// store its response.
+type discardKey struct{}
+
+// discardIdempotent makes Idempotency release the key of r instead of storing its response,
+// for rejections a retry with the same key may get past, such as a failed CAPTCHA.
+func discardIdempotent(r *http.Request) {
+	if discard, ok := r.Context().Value(discardKey{}).(*bool); ok {
+		*discard = true
+	}
+}
+
+// idempotencyRecorder passes a response through while keeping a copy of it.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Idempotency makes requests carrying an Idempotency-Key safe to retry. The first request with a
+// key runs normally and its response is stored for ttl; retries with the same key and body get
+// the stored response back instead of running again. Reusing a key for a different body is
+// rejected with 422, and a retry that arrives while the first request is still running gets 409.
+// Server errors, and responses discarded by the middleware after it, are not stored so the
+// request can be retried. Keys are scoped to the tenant and principal of the request, so the
+// same key sent by someone else is a different request. Requests without a key are passed
+// through unchanged.
+func Idempotency(store repositories.IdempotencyStore, ttl time.Duration, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				problem.Error(w, r, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+			// Clients choose keys independently, so each tenant, and each principal in it, has
+			// its own and cannot replay the responses others were sent
+			if principal := auth.FromContext(r.Context()); principal != nil {
+				key = auth.ClientKey(principal, "") + "/" + key
+			}
+			if id := tenant.FromContext(r.Context()); id != tenant.Default {
+				key = id + "/" + key
+			}
+
+			// Fingerprint the request so a reused key can be told apart from a genuine retry
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				problem.Error(w, r, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(append([]byte(r.Method+"\n"), body...))
+			fingerprint := hex.EncodeToString(sum[:])
+
+			record, reserved, err := store.Reserve(r.Context(), key, fingerprint, ttl)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "Failed to reserve idempotency key", slog.Any("error", err))
+				problem.Error(w, r, http.StatusServiceUnavailable, "Failed to process Idempotency-Key; retry the request")
+				return
+			}
+			if !reserved {
+				replayIdempotent(w, r, record, fingerprint)
+				return
+			}
+
+			rec := &idempotencyRecorder{ResponseWriter: w}
+			var discard bool
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), discardKey{}, &discard)))
+
+			// Finish bookkeeping even if the client has gone away
+			ctx := context.WithoutCancel(r.Context())
+			if discard || rec.status == 0 || rec.status >= http.StatusInternalServerError {
+				if err := store.Release(ctx, key); err != nil {
+					logger.ErrorContext(ctx, "Failed to release idempotency key", slog.Any("error", err))
+				}
+				return
+			}
+
+			header := http.Header{}
+			for _, name := range replayedHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					header[name] = values
+				}
+			}
+			if err := store.Complete(ctx, key, rec.status, header, rec.body.Bytes()); err != nil {
+				logger.ErrorContext(ctx, "Failed to store idempotent response", slog.Any("error", err))
+			}
+		})
+	}
+}
+
+// replayIdempotent answers a retry from the record already held for its key.
+func replayIdempotent(w http.ResponseWriter, r *http.Request, record *repositories.IdempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		problem.Error(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		return
+	}
+	if !record.Completed {
+		problem.Error(w, r, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"example_api/auth"
+	"example_api/repositories"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	type attempt struct {
+		key      string
+		body     string
+		status   int
+		replayed bool
+	}
+	tests := []struct {
+		name string
+		// statuses are what the handler answers its successive runs with
+		statuses []int
+		attempts []attempt
+		runs     int
+	}{
+		{
+			name:     "success is replayed",
+			statuses: []int{http.StatusCreated},
+			attempts: []attempt{
+				{"k", `{"a":1}`, http.StatusCreated, false},
+				{"k", `{"a":1}`, http.StatusCreated, true},
+			},
+			runs: 1,
+		},
+		{
+			name:     "client error is replayed",
+			statuses: []int{http.StatusBadRequest},
+			attempts: []attempt{
+				{"k", `{"a":1}`, http.StatusBadRequest, false},
+				{"k", `{"a":1}`, http.StatusBadRequest, true},
+			},
+			runs: 1,
+		},
+		{
+			name:     "server error releases the key",
+			statuses: []int{http.StatusInternalServerError, http.StatusCreated},
+			attempts: []attempt{
+				{"k", `{"a":1}`, http.StatusInternalServerError, false},
+				{"k", `{"a":1}`, http.StatusCreated, false},
+				{"k", `{"a":1}`, http.StatusCreated, true},
+			},
+			runs: 2,
+		},
+		{
+			name:     "reused key with a different body",
+			statuses: []int{http.StatusCreated},
+			attempts: []attempt{
+				{"k", `{"a":1}`, http.StatusCreated, false},
+				{"k", `{"a":2}`, http.StatusUnprocessableEntity, false},
+			},
+			runs: 1,
+		},
+		{
+			name:     "requests without a key",
+			statuses: []int{http.StatusCreated, http.StatusCreated},
+			attempts: []attempt{
+				{"", `{"a":1}`, http.StatusCreated, false},
+				{"", `{"a":1}`, http.StatusCreated, false},
+			},
+			runs: 2,
+		},
+		{
+			name:     "key too long",
+			attempts: []attempt{{strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`, http.StatusBadRequest, false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs int
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[runs]
+				runs++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				fmt.Fprintf(w, `{"run":%d}`, runs)
+			})
+			handler := Idempotency(repositories.NewMemoryIdempotencyRepository(), time.Hour, logger)(next)
+
+			var first string
+			for i, attempt := range tt.attempts {
+				req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(attempt.body))
+				if attempt.key != "" {
+					req.Header.Set(IdempotencyKeyHeader, attempt.key)
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				if rec.Code != attempt.status {
+					t.Fatalf("attempt %d: got status %d, want %d", i+1, rec.Code, attempt.status)
+				}
+				replayed := rec.Header().Get("Idempotent-Replayed") == "true"
+				if replayed != attempt.replayed {
+					t.Fatalf("attempt %d: got replayed %t, want %t", i+1, replayed, attempt.replayed)
+				}
+				if replayed && rec.Body.String() != first {
+					t.Fatalf("attempt %d: replayed %q, want %q", i+1, rec.Body.String(), first)
+				}
+				if !replayed {
+					first = rec.Body.String()
+				}
+			}
+			if runs != tt.runs {
+				t.Fatalf("handler ran %d times, want %d", runs, tt.runs)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"a":1}`))
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		return req
+	}
+	var handler http.Handler
+	var runs int
+	handler = Idempotency(repositories.NewMemoryIdempotencyRepository(), time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		// Retry while the first request is still running
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest())
+		if rec.Code != http.StatusConflict {
+			t.Errorf("got status %d for a retry in progress, want %d", rec.Code, http.StatusConflict)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+	if runs != 1 {
+		t.Fatalf("handler ran %d times, want 1", runs)
+	}
+}
+
+func TestIdempotencyScopedByPrincipal(t *testing.T) {
+	var runs int
+	handler := Idempotency(repositories.NewMemoryIdempotencyRepository(), time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"run":%d}`, runs)
+	}))
+
+	ada := &auth.Principal{UserID: "ada", Role: "user"}
+	bob := &auth.Principal{UserID: "bob", Role: "user"}
+	admin := &auth.Principal{Role: "admin"}
+	steps := []struct {
+		name      string
+		principal *auth.Principal
+		replayed  bool
+	}{
+		{"first user", ada, false},
+		{"same user retries", ada, true},
+		{"another user with the same key", bob, false},
+		{"admin with the same key", admin, false},
+		{"anonymous with the same key", nil, false},
+		{"anonymous retries", nil, true},
+		{"another user retries", bob, true},
+	}
+	for _, step := range steps {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"a":1}`))
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		if step.principal != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), step.principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != step.replayed {
+			t.Fatalf("%s: got replayed %t, want %t", step.name, replayed, step.replayed)
+		}
+	}
+	if runs != 4 {
+		t.Fatalf("handler ran %d times, want 4", runs)
+	}
+}
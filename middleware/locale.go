@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"example_api/i18n"
+	"net/http"
+)
+
+// Locale picks the language of response messages for each request from its Accept-Language
+// header, falling back to i18n.Default for languages the API does not speak.
+func Locale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Messages differ per language, so caches must not share responses between languages
+		w.Header().Add("Vary", "Accept-Language")
+		locale := i18n.Negotiate(r.Header.Get("Accept-Language"))
+		next.ServeHTTP(w, r.WithContext(i18n.NewContext(r.Context(), locale)))
+	})
+}
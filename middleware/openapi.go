@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"errors"
+	"example_api/apperrors"
+	"example_api/mediatype"
+	"example_api/problem"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+)
+
+// ValidateRequests rejects requests whose path parameters, query, headers, or JSON body do not
+// match the operation doc documents for their route, with 400 Bad Request listing every
+// problem. Routes are matched to operations by method and path template with the first
+// matching prefix removed on both sides as for Authorize, so the unversioned mount is held to
+// the versioned one's operations; routes without an operation pass unchecked. Bodies in other
+// formats, such as XML and uploads, are left to their handlers, as is the Authorization
+// header, which Authenticate and Authorize have already checked.
+func ValidateRequests(doc *openapi3.T, prefixes ...string) func(http.Handler) http.Handler {
+	operations := map[string]*routers.Route{}
+	for path, item := range doc.Paths.Map() {
+		key := path
+		if apiPath, ok := apiPath(path, prefixes); ok {
+			key = apiPath
+		}
+		for method, operation := range item.Operations() {
+			checked := *operation
+			checked.Parameters = nil
+			for _, param := range operation.Parameters {
+				if param.Value.In != openapi3.ParameterInHeader || !strings.EqualFold(param.Value.Name, "Authorization") {
+					checked.Parameters = append(checked.Parameters, param)
+				}
+			}
+			operations[method+" "+key] = &routers.Route{Spec: doc, Path: path, PathItem: item, Method: method, Operation: &checked}
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if path, ok := apiPath(template, prefixes); ok {
+				template = path
+			}
+			operation, ok := operations[r.Method+" "+template]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: mux.Vars(r),
+				Route:      operation,
+				Options: &openapi3filter.Options{
+					ExcludeRequestBody:  !validatesBody(r, operation.Operation),
+					MultiError:          true,
+					SkipSettingDefaults: true,
+					AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+				},
+			})
+			if err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				w.Header().Set("Connection", "close")
+				problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", maxBytesErr.Limit))
+				return
+			}
+			detail, fields := requestProblems(err)
+			p := problem.New(http.StatusBadRequest, detail)
+			p.Errors = fields
+			problem.Write(w, r, p)
+		})
+	}
+}
+
+// validatesBody reports whether the body of r is JSON, the one format bodies are checked in,
+// and operation documents a JSON body.
+func validatesBody(r *http.Request, operation *openapi3.Operation) bool {
+	if operation.RequestBody == nil || operation.RequestBody.Value.Content.Get(mediatype.JSON) == nil {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == mediatype.JSON
+}
+
+// requestProblems describes the validation failures in err: a detail for the problem and the
+// invalid fields, named by their query parameter, header, or dotted path in the JSON body.
+func requestProblems(err error) (string, []apperrors.FieldError) {
+	detail := "Invalid input"
+	var fields []apperrors.FieldError
+	for _, err := range flatten(err) {
+		var requestErr *openapi3filter.RequestError
+		if !errors.As(err, &requestErr) {
+			continue
+		}
+		param, body := requestErr.Parameter, requestErr.RequestBody
+		var parseErr *openapi3filter.ParseError
+		switch {
+		case param != nil && errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired):
+			fields = append(fields, apperrors.FieldError{Field: param.Name, Rule: "required", Message: fmt.Sprintf("%s is required", param.Name)})
+		case param != nil && errors.Is(requestErr.Err, openapi3filter.ErrInvalidEmptyValue):
+			fields = append(fields, apperrors.FieldError{Field: param.Name, Rule: "required", Message: fmt.Sprintf("%s must not be empty", param.Name)})
+		case param != nil && errors.As(requestErr.Err, &parseErr):
+			fields = append(fields, apperrors.FieldError{Field: param.Name, Rule: "type", Message: fmt.Sprintf("%s must be a %s", param.Name, typeName(param.Schema.Value))})
+		case param != nil:
+			for _, err := range flatten(requestErr.Err) {
+				var schemaErr *openapi3.SchemaError
+				if errors.As(err, &schemaErr) {
+					fields = append(fields, schemaProblem(param.Name, schemaErr))
+				}
+			}
+		case body != nil && errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired):
+			detail = "Request body is required"
+		case body != nil && errors.As(requestErr.Err, &parseErr):
+			detail = "Request body must be valid JSON"
+		case body != nil:
+			for _, err := range flatten(requestErr.Err) {
+				var schemaErr *openapi3.SchemaError
+				if errors.As(err, &schemaErr) {
+					field := strings.Join(schemaErr.JSONPointer(), ".")
+					if field == "" {
+						field = "body"
+					}
+					fields = append(fields, schemaProblem(field, schemaErr))
+				}
+			}
+		}
+	}
+	return detail, fields
+}
+
+// flatten returns the errors err combines, or err alone. Only err itself is looked at, since
+// a RequestError wraps the errors its value has.
+func flatten(err error) []error {
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, err := range multi {
+		errs = append(errs, flatten(err)...)
+	}
+	return errs
+}
+
+// schemaProblem describes the rule of its schema that the value of field broke, in the words
+// the validation package uses for the same rules.
+func schemaProblem(field string, err *openapi3.SchemaError) apperrors.FieldError {
+	schema := err.Schema
+	var message string
+	switch err.SchemaField {
+	case "required":
+		message = fmt.Sprintf("%s is required", field)
+	case "type":
+		message = fmt.Sprintf("%s must be a %s", field, typeName(schema))
+	case "nullable":
+		message = fmt.Sprintf("%s must not be null", field)
+	case "enum":
+		values := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			values[i] = fmt.Sprint(value)
+		}
+		message = fmt.Sprintf("%s must be one of: %s", field, strings.Join(values, ", "))
+	case "minLength":
+		message = fmt.Sprintf("%s must be at least %d characters long", field, schema.MinLength)
+	case "maxLength":
+		message = fmt.Sprintf("%s must be at most %d characters long", field, *schema.MaxLength)
+	case "minItems":
+		message = fmt.Sprintf("%s must have at least %d entries", field, schema.MinItems)
+	case "maxItems":
+		message = fmt.Sprintf("%s must have at most %d entries", field, *schema.MaxItems)
+	case "minimum":
+		message = fmt.Sprintf("%s must be at least %s", field, strconv.FormatFloat(*schema.Min, 'f', -1, 64))
+	case "maximum":
+		message = fmt.Sprintf("%s must be at most %s", field, strconv.FormatFloat(*schema.Max, 'f', -1, 64))
+	default:
+		message = fmt.Sprintf("%s failed the %s rule", field, err.SchemaField)
+	}
+	return apperrors.FieldError{Field: field, Rule: err.SchemaField, Message: message}
+}
+
+// typeName names the JSON type schema allows, calling integers whole numbers.
+func typeName(schema *openapi3.Schema) string {
+	for _, name := range schema.Type.Slice() {
+		switch name {
+		case openapi3.TypeNull:
+		case openapi3.TypeInteger:
+			return "whole number"
+		default:
+			return name
+		}
+	}
+	return "value"
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"example_api/auth"
+	"example_api/clientip"
+	"example_api/problem"
+	"example_api/ratelimit"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit counts the requests whose path is one of prefixes or under it against the plan of
+// their client, and answers those over the limit with 429 Too Many Requests and Retry-After.
+// Every counted response carries X-RateLimit-Limit, X-RateLimit-Remaining, and X-RateLimit-Reset,
+// the Unix time the window ends. It must run after Authenticate, since users are counted by
+// who they are and only anonymous clients by address. Requests are let through when limiter
+// fails, so an unreachable Redis does not take the API down.
+func RateLimit(limiter ratelimit.Limiter, plans ratelimit.Plans, logger *slog.Logger, prefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !underPrefix(r.URL.Path, prefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key, limit := plans.For(auth.FromContext(r.Context()), clientip.FromContext(r.Context()))
+			result, err := limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				logger.WarnContext(r.Context(), "Rate limit check failed", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+			if !result.Allowed {
+				retryAfter := int(time.Until(result.Reset).Seconds()) + 1
+				header.Set("Retry-After", strconv.Itoa(retryAfter))
+				problem.Error(w, r, http.StatusTooManyRequests, "Rate limit exceeded, try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// underPrefix reports whether path is one of prefixes or a path under it.
+func underPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
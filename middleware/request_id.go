@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"example_api/requestid"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestID assigns every request an ID, honoring a well-formed incoming X-Request-ID header,
+// stores it in the request context, and echoes it back in the response headers.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"example_api/clientip"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// SecurityHeaders sets headers that tell browsers to refuse to sniff content types, frame
+// responses, or send referrers, and sets the Content-Security-Policy csp unless it is empty.
+// HTTPS responses also get the Strict-Transport-Security hsts unless it is empty; a request
+// counts as HTTPS when it reached the server over TLS or a proxy in trusted says it did.
+func SecurityHeaders(hsts, csp string, trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", "DENY")
+			header.Set("Referrer-Policy", "no-referrer")
+			if csp != "" {
+				header.Set("Content-Security-Policy", csp)
+			}
+			if hsts != "" && (r.TLS != nil || clientip.Trusted(r, trusted) && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ContentSecurityPolicy replaces the Content-Security-Policy SecurityHeaders set with policy,
+// for pages such as the API docs that load scripts and styles. An empty policy removes it.
+func ContentSecurityPolicy(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy == "" {
+				w.Header().Del("Content-Security-Policy")
+			} else {
+				w.Header().Set("Content-Security-Policy", policy)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
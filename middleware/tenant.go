@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"example_api/problem"
+	"example_api/tenant"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Tenant scopes each request to the tenant named by its header, or failing that by the
+// subdomain of domain it was sent to, such as acme for acme.api.example.com. Requests naming
+// neither belong to tenant.Default. Tenants other than the default must be listed in tenants;
+// requests for any other get 404.
+func Tenant(header, domain string, tenants []string) func(http.Handler) http.Handler {
+	suffix := "." + strings.ToLower(domain)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Responses differ per tenant, so caches must not share them between tenants
+			w.Header().Add("Vary", header)
+
+			id := r.Header.Get(header)
+			if id == "" && domain != "" {
+				host := r.Host
+				if h, _, err := net.SplitHostPort(host); err == nil {
+					host = h
+				}
+				id, _ = strings.CutSuffix(strings.ToLower(host), suffix)
+				if id == strings.ToLower(host) || strings.Contains(id, ".") {
+					id = ""
+				}
+			}
+			id, err := tenant.Lookup(id, tenants)
+			if errors.Is(err, tenant.ErrInvalid) {
+				problem.Error(w, r, http.StatusBadRequest, "Tenant IDs are lowercase letters, digits, and hyphens")
+				return
+			}
+			if err != nil {
+				problem.Error(w, r, http.StatusNotFound, "Unknown tenant")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), id)))
+		})
+	}
+}
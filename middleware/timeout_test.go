@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, r *http.Request)
+		status  int
+		body    string
+		header  string
+	}{
+		{
+			name: "finishes in time",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Handler", "done")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("created"))
+			},
+			status: http.StatusCreated,
+			body:   "created",
+			header: "done",
+		},
+		{
+			name: "writes without a status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			},
+			status: http.StatusOK,
+			body:   "ok",
+		},
+		{
+			name: "writes nothing",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Handler", "silent")
+			},
+			status: http.StatusOK,
+			header: "silent",
+		},
+		{
+			name: "stays past the deadline",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				w.Header().Set("X-Handler", "late")
+				w.WriteHeader(http.StatusCreated)
+			},
+			status: http.StatusGatewayTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Timeout(50 * time.Millisecond)(http.HandlerFunc(tt.handler))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.status)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Fatalf("got body %q, want %q", rec.Body.String(), tt.body)
+			}
+			if got := rec.Header().Get("X-Handler"); got != tt.header {
+				t.Fatalf("got X-Handler %q, want %q", got, tt.header)
+			}
+		})
+	}
+}
+
+func TestTimeoutWriterAfterDeadline(t *testing.T) {
+	written := make(chan error, 1)
+	handler := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		// Give the middleware time to answer with 504 first
+		time.Sleep(10 * time.Millisecond)
+		_, err := w.Write([]byte("too late"))
+		written <- err
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if err := <-written; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Fatalf("got error %v writing after the deadline, want %v", err, http.ErrHandlerTimeout)
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"example_api/problem"
+	"net/http"
+	"strings"
+)
+
+// RequireToken rejects requests that do not present token as a bearer token with 401. When
+// allowQuery is set, the token may also be passed as the token query parameter, for clients
+// such as EventSource and browser WebSockets that cannot set headers.
+func RequireToken(token string, allowQuery bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok && allowQuery {
+				presented = r.URL.Query().Get("token")
+			}
+			if presented == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="example-api"`)
+				problem.Error(w, r, http.StatusUnauthorized, "A valid token is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
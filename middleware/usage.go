@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"example_api/auth"
+	"example_api/clientip"
+	"example_api/usage"
+	"net/http"
+)
+
+// Usage records the requests whose path is one of prefixes or under it with meter, along with
+// the bytes of their bodies and of the responses as sent, compressed or not. It must run after
+// Authenticate, since clients are told apart as RateLimit tells them apart, and before
+// RateLimit, so the requests turned away count too.
+func Usage(meter *usage.Meter, prefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !underPrefix(r.URL.Path, prefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rec := &responseRecorder{ResponseWriter: w}
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = body
+			}
+
+			next.ServeHTTP(rec, r)
+
+			client := auth.ClientKey(auth.FromContext(r.Context()), clientip.FromContext(r.Context()))
+			meter.Record(r.Context(), client, body.bytes, int64(rec.bytes))
+		})
+	}
+}
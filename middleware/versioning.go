@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIVersionHeader reports which API version served a response.
+const APIVersionHeader = "API-Version"
+
+// Deprecation describes how clients should move off a deprecated API surface.
+type Deprecation struct {
+	// Successor is the path prefix of the replacement version, advertised in a Link header
+	Successor string
+	// Sunset is when the deprecated surface stops working; zero if not scheduled
+	Sunset time.Time
+}
+
+// APIVersion tags responses with the API version that served them. When deprecation is
+// non-nil, responses also carry Deprecation, Link, and (if scheduled) Sunset headers.
+func APIVersion(version string, deprecation *Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set(APIVersionHeader, version)
+			if deprecation != nil {
+				h.Set("Deprecation", "true")
+				if deprecation.Successor != "" {
+					h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", deprecation.Successor))
+				}
+				if !deprecation.Sunset.IsZero() {
+					h.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
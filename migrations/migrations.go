@@ -0,0 +1,134 @@
+package migrations
+
+import (
+	"context"
+	"example_api/mongodb"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is one versioned, forward-only change to stored data.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongodb.Database) error
+}
+
+// all lists every migration in the order it must be applied. Versions must be unique and
+// increasing; never edit or reorder a migration that has shipped, add a new one instead.
+var all = []Migration{
+	{
+		Version:     1,
+		Description: "Backfill joinDate from the ObjectID timestamp for users created without one",
+		Up: func(ctx context.Context, db *mongodb.Database) error {
+			_, err := db.Collection("users").UpdateMany(ctx,
+				bson.M{"$or": bson.A{
+					bson.M{"joinDate": bson.M{"$exists": false}},
+					bson.M{"joinDate": time.Time{}},
+				}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"joinDate": bson.M{"$toDate": "$_id"}}}}},
+			)
+			return err
+		},
+	},
+	{
+		Version:     2,
+		Description: "Assign the user role to users created before roles existed",
+		Up: func(ctx context.Context, db *mongodb.Database) error {
+			_, err := db.Collection("users").UpdateMany(ctx,
+				bson.M{"role": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"role": "user"}},
+			)
+			return err
+		},
+	},
+	{
+		Version:     3,
+		Description: "Start users created before optimistic concurrency control at version 1",
+		Up: func(ctx context.Context, db *mongodb.Database) error {
+			_, err := db.Collection("users").UpdateMany(ctx,
+				bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": int64(1)}},
+			)
+			return err
+		},
+	},
+}
+
+// record is the document stored in schema_migrations for each applied migration.
+type record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+const collectionName = "schema_migrations"
+
+// Run applies every migration that has not been recorded in schema_migrations yet.
+// A migration's record is written before it runs so concurrent instances do not apply it twice;
+// the record is removed again if the migration fails.
+func Run(ctx context.Context, db *mongodb.Database, logger *slog.Logger) error {
+	return run(ctx, db, all, logger)
+}
+
+func run(ctx context.Context, db *mongodb.Database, migrations []Migration, logger *slog.Logger) error {
+	collection := db.Collection(collectionName)
+
+	applied, err := appliedVersions(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	lastVersion := 0
+	for _, m := range migrations {
+		if m.Version <= lastVersion {
+			return fmt.Errorf("migration %d is out of order", m.Version)
+		}
+		lastVersion = m.Version
+
+		if applied[m.Version] {
+			continue
+		}
+
+		log := logger.With(slog.Int("version", m.Version), slog.String("description", m.Description))
+
+		_, err := collection.InsertOne(ctx, record{Version: m.Version, Description: m.Description, AppliedAt: time.Now()})
+		if mongo.IsDuplicateKeyError(err) {
+			log.Info("Migration already claimed by another instance")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %v", m.Version, err)
+		}
+
+		start := time.Now()
+		if err := m.Up(ctx, db); err != nil {
+			collection.DeleteOne(context.Background(), bson.M{"_id": m.Version})
+			return fmt.Errorf("migration %d failed: %v", m.Version, err)
+		}
+		log.Info("Applied migration", slog.Duration("took", time.Since(start)))
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, collection *mongo.Collection) (map[int]bool, error) {
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %v", err)
+	}
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %v", err)
+	}
+
+	applied := make(map[int]bool, len(records))
+	for _, r := range records {
+		applied[r.Version] = true
+	}
+	return applied, nil
+}
@@ -0,0 +1,197 @@
+// Package mock serves the API from its OpenAPI document alone: every documented operation
+// answers with an example of its response, so clients can be built against the API before a
+// database or any other backend is available.
+package mock
+
+import (
+	"encoding/json"
+	"example_api/mediatype"
+	"example_api/middleware"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+)
+
+// NewHandler serves the operations doc documents. Requests are validated against doc as the API
+// validates them, then answered with the operation's first success response, or with the
+// response documented for the status a Prefer: code=<status> header asks for. Credentials are
+// not checked.
+func NewHandler(doc *openapi3.T) http.Handler {
+	r := mux.NewRouter()
+	r.Use(middleware.ValidateRequests(doc))
+	// Paths with fewer parameters come first, so /users/search is not taken for /users/{id}
+	for _, path := range doc.Paths.InMatchingOrder() {
+		for method, operation := range doc.Paths.Value(path).Operations() {
+			r.Handle(path, respond(operation.Responses)).Methods(method)
+		}
+	}
+	return r
+}
+
+// respond answers with an example of the response responses documents for the request.
+func respond(responses *openapi3.Responses) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, response := pick(responses, r.Header.Get("Prefer"))
+		if response == nil || len(response.Content) == 0 {
+			w.WriteHeader(status)
+			return
+		}
+
+		// XML has no example the schemas could describe, so only the other formats are offered
+		var offers []string
+		for mediaType := range response.Content {
+			if !mediatype.IsXML(mediaType) {
+				offers = append(offers, mediaType)
+			}
+		}
+		if len(offers) == 0 {
+			w.WriteHeader(status)
+			return
+		}
+		sort.Slice(offers, func(i, j int) bool {
+			if (offers[i] == mediatype.JSON) != (offers[j] == mediatype.JSON) {
+				return offers[i] == mediatype.JSON
+			}
+			return offers[i] < offers[j]
+		})
+		mediaType := mediatype.Negotiate(r, offers...)
+
+		value := example(response.Content[mediaType].Schema, nil)
+		// Envelopes and problems carry the status they are sent with
+		if object, ok := value.(map[string]interface{}); ok {
+			if _, ok := object["status"].(int); ok {
+				object["status"] = status
+			}
+		}
+		w.Header().Set("Content-Type", mediaType)
+		w.WriteHeader(status)
+		if text, ok := value.(string); ok && !isJSON(mediaType) {
+			w.Write([]byte(text))
+			return
+		}
+		json.NewEncoder(w).Encode(value)
+	})
+}
+
+// pick returns the status and response responses documents for prefer, a Prefer header
+// naming a status, or else the lowest success status and its response.
+func pick(responses *openapi3.Responses, prefer string) (int, *openapi3.Response) {
+	for _, preference := range strings.Split(prefer, ",") {
+		code, ok := strings.CutPrefix(strings.TrimSpace(preference), "code=")
+		if !ok {
+			continue
+		}
+		if status, err := strconv.Atoi(code); err == nil {
+			if response := responses.Status(status); response != nil {
+				return status, response.Value
+			}
+		}
+	}
+
+	codes := make([]string, 0, responses.Len())
+	for code := range responses.Map() {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	for _, code := range codes {
+		if status, err := strconv.Atoi(code); err == nil && status >= 200 && status < 300 {
+			return status, responses.Value(code).Value
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// isJSON reports whether mediaType is JSON or a format built on it.
+func isJSON(mediaType string) bool {
+	return mediaType == mediatype.JSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// example builds a value that ref describes: the example, first enum value, or default the
+// schema documents, or a placeholder of its type. Arrays hold one item, objects every property,
+// and a schema combining others the first of the alternatives or all of the parts. seen holds
+// the schemas being built, so a schema that contains itself stops at the first repeat.
+func example(ref *openapi3.SchemaRef, seen []*openapi3.Schema) interface{} {
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	schema := ref.Value
+	if slices.Contains(seen, schema) {
+		return nil
+	}
+	seen = append(seen, schema)
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.OneOf) > 0:
+		return example(schema.OneOf[0], seen)
+	case len(schema.AnyOf) > 0:
+		return example(schema.AnyOf[0], seen)
+	case len(schema.AllOf) > 0:
+		// Parts describe properties of one object, and later parts refine the earlier ones
+		merged := map[string]interface{}{}
+		for _, part := range schema.AllOf {
+			if object, ok := example(part, seen).(map[string]interface{}); ok {
+				for name, value := range object {
+					merged[name] = value
+				}
+			}
+		}
+		for name, property := range schema.Properties {
+			merged[name] = example(property, seen)
+		}
+		return merged
+	}
+
+	switch {
+	case schema.Type.Includes(openapi3.TypeArray):
+		return []interface{}{example(schema.Items, seen)}
+	case schema.Type.Includes(openapi3.TypeString):
+		return placeholder(schema.Format)
+	case schema.Type.Includes(openapi3.TypeInteger):
+		return 0
+	case schema.Type.Includes(openapi3.TypeNumber):
+		return 0.0
+	case schema.Type.Includes(openapi3.TypeBoolean):
+		return false
+	case schema.Type.Includes(openapi3.TypeNull):
+		return nil
+	}
+	object := map[string]interface{}{}
+	for name, property := range schema.Properties {
+		object[name] = example(property, seen)
+	}
+	if additional := schema.AdditionalProperties.Schema; additional != nil && len(object) == 0 {
+		object["key"] = example(additional, seen)
+	}
+	return object
+}
+
+// placeholder returns a string in format.
+func placeholder(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "binary", "byte":
+		return ""
+	default:
+		return "string"
+	}
+}
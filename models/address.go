@@ -0,0 +1,16 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// MaxAddresses is how many addresses a user may have.
+const MaxAddresses = 10
+
+// Address is a postal address of a user. CountryCode is an ISO 3166-1 alpha-2 code, stored in
+// upper case. PostalCode is optional, since not every country has them.
+type Address struct {
+	Id          primitive.ObjectID `json:"id" bson:"_id"`
+	Street      string             `json:"street" bson:"street" validate:"required,max=200"`
+	City        string             `json:"city" bson:"city" validate:"required,max=100"`
+	PostalCode  string             `json:"postalCode,omitempty" bson:"postalCode,omitempty" validate:"max=20"`
+	CountryCode string             `json:"countryCode" bson:"countryCode" validate:"required,iso3166_1_alpha2"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audited actions
+const (
+	AuditUserCreated = "user.created"
+	AuditUserUpdated = "user.updated"
+	AuditUserDeleted = "user.deleted"
+	AuditUserErased  = "user.erased"
+)
+
+// Placeholders the audit log keeps instead of a value. Password hashes are always redacted;
+// personal data is erased from the log along with the user's.
+const (
+	AuditRedacted = "[redacted]"
+	AuditErased   = "[erased]"
+)
+
+// AuditEntry records one change to a user: what changed, who changed it, when, and from where.
+type AuditEntry struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id"`
+	Time      time.Time          `json:"time" bson:"time"`
+	Action    string             `json:"action" bson:"action"`
+	Actor     string             `json:"actor" bson:"actor"`
+	IP        string             `json:"ip,omitempty" bson:"ip,omitempty"`
+	RequestID string             `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	TargetID  primitive.ObjectID `json:"targetId" bson:"targetId"`
+	// Changes lists the fields that changed, by name
+	Changes []FieldChange `json:"changes,omitempty" bson:"changes,omitempty"`
+}
+
+// FieldChange is the value of a field before and after a change. Before is nil for a user
+// being created.
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// AuditQuery selects audit entries by the fields that are set. TargetID is a user's hex ID;
+// From is inclusive and To exclusive.
+type AuditQuery struct {
+	Actor    string
+	Action   string
+	TargetID string
+	From     time.Time
+	To       time.Time
+}
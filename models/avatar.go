@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Avatar variants. The original is the uploaded image without its metadata; the others are
+// scaled-down copies.
+const (
+	AvatarOriginal  = "original"
+	AvatarMedium    = "medium"
+	AvatarThumbnail = "thumbnail"
+)
+
+// Avatar describes one variant of a user's profile picture. The image itself is kept by the
+// avatar store.
+type Avatar struct {
+	UserID      primitive.ObjectID `json:"userId" bson:"userId"`
+	Variant     string             `json:"variant" bson:"variant"`
+	ContentType string             `json:"contentType" bson:"contentType"`
+	Size        int64              `json:"size" bson:"size"`
+	// ETag is derived from the image bytes, so it changes exactly when the image does
+	ETag      string    `json:"etag" bson:"etag"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
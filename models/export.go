@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportFile describes the file an export job produced. The bytes are kept by the export store.
+type ExportFile struct {
+	// Id is the ID of the export job
+	Id          primitive.ObjectID `json:"id"`
+	Filename    string             `json:"filename"`
+	ContentType string             `json:"contentType"`
+	Size        int64              `json:"size"`
+	CreatedAt   time.Time          `json:"createdAt"`
+}
+
+// ExportRequest asks for the users matching the filters to be exported in the background.
+// Format is csv or xlsx, and Columns holds column names as for synchronous exports.
+type ExportRequest struct {
+	Format  string `json:"format" validate:"omitempty,oneof=csv xlsx"`
+	Columns string `json:"columns"`
+	Role    string `json:"role"`
+	Email   string `json:"email"`
+	Filter  string `json:"filter"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// FeatureFlag switches a behavior on or off without a deploy, so it can ship dark and be
+// rolled out gradually. An enabled flag is on for Rollout percent of users, picked by a hash
+// of their ID so each user keeps the same answer; anonymous requests count as their tenant.
+// Tenants turns the flag fully on or off for the named tenants, whatever Enabled and Rollout
+// say. Flags are shared by every tenant.
+type FeatureFlag struct {
+	Key         string          `json:"key" bson:"_id"`
+	Description string          `json:"description,omitempty" bson:"description,omitempty" validate:"max=200"`
+	Enabled     bool            `json:"enabled" bson:"enabled"`
+	Rollout     int             `json:"rollout" bson:"rollout" validate:"min=0,max=100"`
+	Tenants     map[string]bool `json:"tenants,omitempty" bson:"tenants,omitempty" validate:"max=100"`
+	UpdatedAt   time.Time       `json:"updatedAt" bson:"updatedAt"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Group is a named set of users who hold its permissions on top of those of their role, so
+// access is granted to a group once rather than to each of its members.
+type Group struct {
+	Id          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=100"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" validate:"max=200"`
+	Permissions []string           `json:"permissions" bson:"permissions" validate:"dive,oneof=users:read users:write users:delete roles:read roles:write groups:read groups:write organizations:read organizations:write notifications:read notifications:write"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// GroupMember places a user in a group. A user is in a group at most once.
+type GroupMember struct {
+	GroupID primitive.ObjectID `json:"groupId" bson:"groupId"`
+	UserID  primitive.ObjectID `json:"userId" bson:"userId"`
+	AddedAt time.Time          `json:"addedAt" bson:"addedAt"`
+}
+
+// GroupMembersAddition adds up to 100 users to a group at once.
+type GroupMembersAddition struct {
+	UserIDs []string `json:"userIds" validate:"required,min=1,max=100,dive,required"`
+}
+
+// GroupMembersAdded reports how many of the users were added; the others were members already.
+type GroupMembersAdded struct {
+	Added int64 `json:"added"`
+}
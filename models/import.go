@@ -0,0 +1,20 @@
+package models
+
+import "example_api/apperrors"
+
+// ImportReport summarises a bulk user import. Errors lists every row that was not imported.
+type ImportReport struct {
+	Total   int           `json:"total"`
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Errors  []ImportError `json:"errors"`
+}
+
+// ImportError explains why one row of an import file was not imported. Line counts from the
+// start of the file, including a CSV header row.
+type ImportError struct {
+	Line    int                    `json:"line"`
+	Email   string                 `json:"email,omitempty"`
+	Message string                 `json:"message"`
+	Fields  []apperrors.FieldError `json:"fields,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job statuses. Pending jobs wait for their run time, running ones are leased to a worker until
+// it, and dead ones failed for good or ran out of attempts and wait in the dead letter queue
+// until an administrator retries them.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+// Job is a unit of background work, such as sending an email or delivering a webhook, that a
+// worker runs outside the request that caused it. Payload is the JSON input of its type's
+// handler. Attempts counts the runs so far, including the current one while it is running.
+type Job struct {
+	Id          primitive.ObjectID `json:"id" bson:"_id"`
+	Type        string             `json:"type" bson:"type"`
+	Payload     json.RawMessage    `json:"payload" bson:"payload" swaggertype:"object"`
+	Status      string             `json:"status" bson:"status"`
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	MaxAttempts int                `json:"maxAttempts" bson:"maxAttempts"`
+	// RunAt is when a pending job is due, or when the lease of a running one ends
+	RunAt      time.Time  `json:"runAt" bson:"runAt"`
+	LastError  string     `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+	// Tenant is the tenant the job was enqueued in, which its handler runs in
+	Tenant string `json:"-" bson:"-"`
+}
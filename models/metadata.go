@@ -0,0 +1,16 @@
+package models
+
+// Limits on the metadata of a user. Keys count at every level, and the size is that of the
+// metadata encoded as JSON.
+const (
+	MaxMetadataKeys        = 50
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+	MaxMetadataDepth       = 3
+	MaxMetadataSize        = 8 << 10
+)
+
+// Metadata holds custom keys clients attach to a user, for their own use. Values are strings,
+// numbers, booleans, or nested Metadata. Keys hold letters, digits, _, and -, and keys beginning
+// with _ are reserved for the API.
+type Metadata map[string]interface{}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types
+const (
+	NotificationRoleAssigned           = "role.assigned"
+	NotificationOrganizationInvitation = "organization.invited"
+	NotificationWebhookFailed          = "webhook.failed"
+	NotificationNotice                 = "notice"
+)
+
+// Notification tells a user about something that concerns them, in the app rather than by
+// email. Data holds the IDs and names the message refers to, for clients that link to them.
+// ReadAt is set once the user has read it.
+type Notification struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id"`
+	UserID    primitive.ObjectID `json:"userId" bson:"userId"`
+	Type      string             `json:"type" bson:"type"`
+	Message   string             `json:"message" bson:"message"`
+	Data      map[string]string  `json:"data,omitempty" bson:"data,omitempty"`
+	ReadAt    *time.Time         `json:"readAt,omitempty" bson:"readAt,omitempty"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Notice is a notification an administrator sends a user.
+type Notice struct {
+	Message string            `json:"message" validate:"required,max=500"`
+	Data    map[string]string `json:"data" validate:"max=20"`
+}
+
+// NotificationsRead reports how many notifications were marked read.
+type NotificationsRead struct {
+	Read int64 `json:"read"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization roles, from most to least privileged
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Membership statuses
+const (
+	MembershipInvited = "invited"
+	MembershipActive  = "active"
+)
+
+// Organization groups users, each through a Membership with a role in it.
+type Organization struct {
+	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name" validate:"required,max=100"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Membership places a user in an organization with an org-level role. Invited users are
+// members once they accept, which sets JoinedAt. A user has at most one membership per
+// organization.
+type Membership struct {
+	Id             primitive.ObjectID `json:"id" bson:"_id"`
+	OrganizationID primitive.ObjectID `json:"organizationId" bson:"organizationId"`
+	UserID         primitive.ObjectID `json:"userId" bson:"userId"`
+	Role           string             `json:"role" bson:"role"`
+	Status         string             `json:"status" bson:"status"`
+	InvitedAt      time.Time          `json:"invitedAt" bson:"invitedAt"`
+	JoinedAt       *time.Time         `json:"joinedAt,omitempty" bson:"joinedAt,omitempty"`
+}
+
+// Invitation asks a user to join an organization, as a member unless Role says otherwise.
+type Invitation struct {
+	UserID string `json:"userId" validate:"required"`
+	Role   string `json:"role" validate:"omitempty,oneof=owner admin member"`
+}
+
+// MembershipUpdate assigns a member a new org-level role.
+type MembershipUpdate struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member"`
+}
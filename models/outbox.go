@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxEvent is a user event saved in the same transaction as the change it describes, kept
+// until it has been published to the message bus.
+type OutboxEvent struct {
+	Id primitive.ObjectID `json:"id" bson:"_id"`
+	// MessageID stays the same when publishing is retried, so consumers can discard duplicates
+	MessageID string    `json:"messageId" bson:"messageId"`
+	Type      string    `json:"type" bson:"type"`
+	Subject   string    `json:"subject" bson:"subject"`
+	Tenant    string    `json:"tenant" bson:"tenant"`
+	Time      time.Time `json:"time" bson:"time"`
+	// Data is the JSON encoding of the event payload
+	Data      []byte     `json:"data" bson:"data"`
+	Attempts  int        `json:"attempts" bson:"attempts"`
+	LastError string     `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	SentAt    *time.Time `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// UserDataExport is everything stored about a user, as returned to them for a data subject
+// access request. User is a copy of their record without the password hash, AuditLog the
+// audit entries about them and Notifications theirs, both newest first, and Memberships and
+// Groups the organizations and groups they belong to.
+type UserDataExport struct {
+	GeneratedAt   time.Time
+	User          *User
+	Avatars       []AvatarFile
+	AuditLog      []AuditEntry
+	Notifications []Notification
+	Memberships   []Membership
+	Groups        []Group
+}
+
+// AvatarFile is one variant of a user's avatar with its image.
+type AvatarFile struct {
+	Avatar Avatar
+	Data   []byte
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PhoneVerification describes the verification of a user's phone number: when the code last
+// sent to it expires and another may be requested, or, once confirmed, when it was verified.
+type PhoneVerification struct {
+	Phone      string     `json:"phone"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	ResendAt   *time.Time `json:"resendAt,omitempty"`
+	VerifiedAt *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// PhoneCodeConfirmation submits the code sent to a user's phone number.
+type PhoneCodeConfirmation struct {
+	Code string `json:"code" validate:"required,len=6,number"`
+}
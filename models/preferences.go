@@ -0,0 +1,25 @@
+package models
+
+// Themes a user may pick for clients to render in
+const (
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// Preferences are the application settings of a user, kept for clients so every device shows
+// the same ones. An empty Theme means ThemeSystem, and an empty Locale the language of each
+// request. Locale must be one of the languages the API speaks.
+type Preferences struct {
+	Theme         string                  `json:"theme,omitempty" bson:"theme,omitempty" validate:"omitempty,oneof=system light dark"`
+	Locale        string                  `json:"locale,omitempty" bson:"locale,omitempty" validate:"omitempty,locale"`
+	Notifications NotificationPreferences `json:"notifications" bson:"notifications"`
+}
+
+// NotificationPreferences are the channels a user has opted in to being notified on. Every
+// channel is off until the user opts in.
+type NotificationPreferences struct {
+	Email bool `json:"email" bson:"email"`
+	SMS   bool `json:"sms" bson:"sms"`
+	Push  bool `json:"push" bson:"push"`
+}
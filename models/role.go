@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Permissions
+const (
+	PermUsersRead          = "users:read"
+	PermUsersWrite         = "users:write"
+	PermUsersDelete        = "users:delete"
+	PermRolesRead          = "roles:read"
+	PermRolesWrite         = "roles:write"
+	PermGroupsRead         = "groups:read"
+	PermGroupsWrite        = "groups:write"
+	PermOrganizationsRead  = "organizations:read"
+	PermOrganizationsWrite = "organizations:write"
+	PermNotificationsRead  = "notifications:read"
+	PermNotificationsWrite = "notifications:write"
+)
+
+// Permissions lists every permission a role or group can grant. The validate tags of
+// Role.Permissions and Group.Permissions must list the same.
+var Permissions = []string{
+	PermUsersRead, PermUsersWrite, PermUsersDelete,
+	PermRolesRead, PermRolesWrite,
+	PermGroupsRead, PermGroupsWrite,
+	PermOrganizationsRead, PermOrganizationsWrite,
+	PermNotificationsRead, PermNotificationsWrite,
+}
+
+// Role is a named set of permissions. Every user has one, named by User.Role. The built-in
+// user and admin roles are defined in code and cannot be changed, so they have a zero ID and
+// creation time; the others are stored.
+type Role struct {
+	Id          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=50"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" validate:"max=200"`
+	Permissions []string           `json:"permissions" bson:"permissions" validate:"dive,oneof=users:read users:write users:delete roles:read roles:write groups:read groups:write organizations:read organizations:write notifications:read notifications:write"`
+	BuiltIn     bool               `json:"builtIn" bson:"-"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// RoleAssignment gives a user a role.
+type RoleAssignment struct {
+	Role string `json:"role" validate:"required"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// SearchHit is a user found by a search. Score ranks the hit among the others of the same
+// search, and Highlights mark where the query matched.
+type SearchHit struct {
+	User       User        `json:"user"`
+	Score      float64     `json:"score"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Highlight is a field of a search hit split into the parts that matched the query, of type
+// hit, and the text between them.
+type Highlight struct {
+	Field string          `json:"field"`
+	Texts []HighlightText `json:"texts"`
+}
+
+// Highlight text types
+const (
+	HighlightTypeHit  = "hit"
+	HighlightTypeText = "text"
+)
+
+type HighlightText struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// RuntimeSettings are the settings a configuration reload applies, as they are after it.
+type RuntimeSettings struct {
+	LogLevel string `json:"logLevel"`
+	// FeatureFlagsCacheTTL is a Go duration such as "30s"
+	FeatureFlagsCacheTTL string `json:"featureFlagsCacheTtl"`
+}
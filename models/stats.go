@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// UserStats summarizes the users for the admin dashboard, as counted at GeneratedAt.
+type UserStats struct {
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Total       int64       `json:"total"`
+	Signups     SignupStats `json:"signups"`
+	// ByRole counts users per role
+	ByRole map[string]int64 `json:"byRole"`
+	// Erased counts the tombstones of erased users, which Total includes
+	Erased int64 `json:"erased"`
+}
+
+// SignupStats counts the users who joined in each recent period.
+type SignupStats struct {
+	Last24h int64 `json:"last24h"`
+	Last7d  int64 `json:"last7d"`
+	Last30d int64 `json:"last30d"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Task run statuses
+const (
+	TaskRunRunning   = "running"
+	TaskRunSucceeded = "succeeded"
+	TaskRunFailed    = "failed"
+)
+
+// Task is a scheduled maintenance task, such as purging expired records, that runs every
+// Interval on one instance at a time. Disabled tasks are listed but never run.
+type Task struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	// Interval is a Go duration such as "24h0m0s"; runs are due at whole multiples of it
+	Interval string     `json:"interval"`
+	NextRun  *time.Time `json:"nextRun,omitempty"`
+	LastRun  *TaskRun   `json:"lastRun,omitempty"`
+}
+
+// TaskRun records one run of a scheduled task. ScheduledAt is the time the run was due, which
+// is the same on every instance, so each due run happens once. Affected counts the records the
+// run removed or changed.
+type TaskRun struct {
+	Id          primitive.ObjectID `json:"id" bson:"_id"`
+	Task        string             `json:"task" bson:"task"`
+	ScheduledAt time.Time          `json:"scheduledAt" bson:"scheduledAt"`
+	StartedAt   time.Time          `json:"startedAt" bson:"startedAt"`
+	FinishedAt  *time.Time         `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+	Status      string             `json:"status" bson:"status"`
+	Affected    int64              `json:"affected" bson:"affected"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+}
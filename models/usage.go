@@ -0,0 +1,20 @@
+package models
+
+// Usage is how much of the API one client used on one day: the requests it made and the bytes
+// of their bodies and of the responses. Clients are named as auth.ClientKey names them, and days
+// are UTC dates such as 2024-01-31.
+type Usage struct {
+	Client   string `json:"client" bson:"client"`
+	Day      string `json:"day" bson:"day"`
+	Requests int64  `json:"requests" bson:"requests"`
+	BytesIn  int64  `json:"bytesIn" bson:"bytesIn"`
+	BytesOut int64  `json:"bytesOut" bson:"bytesOut"`
+}
+
+// UsageQuery selects usage records by the fields that are set. From and To are days, both
+// inclusive.
+type UsageQuery struct {
+	Client string
+	From   string
+	To     string
+}
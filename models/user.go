@@ -6,11 +6,35 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Built-in roles, which every tenant has besides the roles it stores
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User is a registered account. Passwords are capped at 72 bytes, the most bcrypt will hash.
+// Version starts at 1 and is incremented by every update, for optimistic concurrency control.
+// ErasedAt is set once the user's personal data has been erased; the record stays behind as a
+// tombstone. Role names a built-in or stored role, whose permissions the user holds. Phone is
+// an optional E.164 number; PhoneVerifiedAt is set once the user confirms a code sent to it, and
+// cleared whenever the number changes. Addresses and Preferences are managed through their own
+// endpoints once the user exists. Metadata is free for clients to use within its limits.
+// Its JSON form, password hash included, is for storage such as the user cache; the HTTP API
+// reads and writes the types in dto instead.
 type User struct {
 	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Email     string             `json:"email" bson:"email"`
-	Password  string             `json:"password" bson:"password"`
-	FirstName string             `json:"firstName" bson:"firstName"`
-	LastName  string             `json:"lastName" bson:"lastName"`
+	Email     string             `json:"email" bson:"email" validate:"required,email,max=254"`
+	Password  string             `json:"password" bson:"password" validate:"required,min=8,max=72"`
+	FirstName string             `json:"firstName" bson:"firstName" validate:"required,max=100"`
+	LastName  string             `json:"lastName" bson:"lastName" validate:"required,max=100"`
+	Phone     string             `json:"phone,omitempty" bson:"phone,omitempty" validate:"omitempty,e164"`
+	Addresses []Address          `json:"addresses,omitempty" bson:"addresses,omitempty" validate:"max=10,dive"`
+	Role      string             `json:"role" bson:"role"`
 	JoinDate  time.Time          `json:"joinDate" bson:"joinDate"`
+	Version   int64              `json:"version" bson:"version"`
+	ErasedAt  *time.Time         `json:"erasedAt,omitempty" bson:"erasedAt,omitempty"`
+
+	PhoneVerifiedAt *time.Time   `json:"phoneVerifiedAt,omitempty" bson:"phoneVerifiedAt,omitempty"`
+	Preferences     *Preferences `json:"preferences,omitempty" bson:"preferences,omitempty"`
+	Metadata        Metadata     `json:"metadata,omitempty" bson:"metadata,omitempty"`
 }
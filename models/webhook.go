@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook subscribes an external URL to user events. Deliveries are signed with Secret, which
+// is only ever shown when the webhook is created or its secret is replaced.
+type Webhook struct {
+	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	URL       string             `json:"url" bson:"url" validate:"required,http_url,max=2048"`
+	Secret    string             `json:"secret,omitempty" bson:"secret" validate:"omitempty,min=16,max=256"`
+	Events    []string           `json:"events" bson:"events" validate:"required,min=1,dive,oneof=user.created user.updated user.deleted"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook. Retries of the same
+// event share a DeliveryID, which receivers also get in the Webhook-Id header.
+type WebhookDelivery struct {
+	Id         primitive.ObjectID `json:"id" bson:"_id"`
+	DeliveryID primitive.ObjectID `json:"deliveryId" bson:"deliveryId"`
+	WebhookID  primitive.ObjectID `json:"webhookId" bson:"webhookId"`
+	EventID    uint64             `json:"eventId" bson:"eventId"`
+	EventType  string             `json:"eventType" bson:"eventType"`
+	Attempt    int                `json:"attempt" bson:"attempt"`
+	// StatusCode is 0 when no response was received
+	StatusCode int       `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	Succeeded  bool      `json:"succeeded" bson:"succeeded"`
+	DurationMs int64     `json:"durationMs" bson:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
+}
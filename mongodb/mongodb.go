@@ -0,0 +1,65 @@
+// Package mongodb holds the MongoDB database the repositories, migrations, and indexes work
+// in. Its collection names carry the configured prefix, so deployments such as staging and
+// production can share a database without sharing data, and lists and inserts can read and
+// write with their own read preference and write concern.
+package mongodb
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Concerns are the read preference and write concern of the classes of operations that can
+// be set apart from the client's. Nil ones use the client's.
+type Concerns struct {
+	// ListReadPreference is for lists, searches, and counts, which can often read from secondaries
+	ListReadPreference *readpref.ReadPref
+	// CreateWriteConcern is for inserts
+	CreateWriteConcern *writeconcern.WriteConcern
+}
+
+// Database is a MongoDB database whose collections are named with a prefix.
+type Database struct {
+	*mongo.Database
+	prefix   string
+	concerns Concerns
+}
+
+func New(db *mongo.Database, prefix string, concerns Concerns) *Database {
+	return &Database{
+		Database: db,
+		prefix:   prefix,
+		concerns: concerns,
+	}
+}
+
+// Collection returns the collection with the given name, prefixed.
+func (db *Database) Collection(name string, opts ...*options.CollectionOptions) *mongo.Collection {
+	return db.Database.Collection(db.Name(name), opts...)
+}
+
+// ListCollection returns the collection with the given name for listing, searching, and
+// counting documents, which reads with the list read preference.
+func (db *Database) ListCollection(name string) *mongo.Collection {
+	if db.concerns.ListReadPreference == nil {
+		return db.Collection(name)
+	}
+	return db.Collection(name, options.Collection().SetReadPreference(db.concerns.ListReadPreference))
+}
+
+// InsertCollection returns the collection with the given name for inserting documents, which
+// writes with the create write concern.
+func (db *Database) InsertCollection(name string) *mongo.Collection {
+	if db.concerns.CreateWriteConcern == nil {
+		return db.Collection(name)
+	}
+	return db.Collection(name, options.Collection().SetWriteConcern(db.concerns.CreateWriteConcern))
+}
+
+// Name returns the prefixed name of the collection or GridFS bucket with the given name, for
+// the places that name one without going through Collection.
+func (db *Database) Name(name string) string {
+	return db.prefix + name
+}
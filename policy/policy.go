@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"example_api/apperrors"
+	"example_api/auth"
+	"fmt"
+)
+
+// Rule is what a route or GraphQL field requires of the principal of a request.
+type Rule struct {
+	permissions []string
+	self        string
+	public      bool
+}
+
+// Public admits every request, including anonymous ones. Routes protected by a token of their
+// own, such as ADMIN_TOKEN or EVENTS_TOKEN, are public as far as policies are concerned.
+var Public = Rule{public: true}
+
+// Require returns a rule admitting principals that hold every one of permissions.
+func Require(permissions ...string) Rule {
+	return Rule{permissions: permissions}
+}
+
+// OrSelf returns a copy of rule that also admits the user whose ID is in the path variable or
+// argument called param, so users can act on their own account without the permissions.
+func (rule Rule) OrSelf(param string) Rule {
+	rule.self = param
+	return rule
+}
+
+// Evaluate returns nil if principal, which is nil for anonymous requests, may make a request
+// with params as its path variables or arguments. Otherwise it returns an Unauthenticated
+// error for anonymous requests and a Forbidden one naming the missing permission.
+func (rule Rule) Evaluate(principal *auth.Principal, params map[string]string) error {
+	if rule.public {
+		return nil
+	}
+	if principal == nil {
+		return apperrors.Unauthenticated("Authentication is required")
+	}
+	if rule.self != "" && principal.UserID != "" && principal.UserID == params[rule.self] {
+		return nil
+	}
+	for _, permission := range rule.permissions {
+		if !principal.Can(permission) {
+			return apperrors.Forbidden(fmt.Sprintf("The %s permission is required", permission))
+		}
+	}
+	return nil
+}
+
+// Table holds the rule of every HTTP route, keyed by its method and path template below the
+// API version such as "GET /users/{id}", of every GraphQL root field, keyed by type and field
+// such as "Query.users", and of every gRPC method, keyed by its full name such as
+// "/example_api.user.v1.UserService/GetUser". Anything missing from the table is denied.
+type Table map[string]Rule
+
+// Route returns the rule of the route with method and path template, and whether it has one.
+func (t Table) Route(method, template string) (Rule, bool) {
+	rule, ok := t[method+" "+template]
+	return rule, ok
+}
+
+// Field returns the rule of the GraphQL root field, and whether it has one.
+func (t Table) Field(object, field string) (Rule, bool) {
+	rule, ok := t[object+"."+field]
+	return rule, ok
+}
+
+// Method returns the rule of the gRPC method with fullMethod as its full name, and whether it
+// has one.
+func (t Table) Method(fullMethod string) (Rule, bool) {
+	rule, ok := t[fullMethod]
+	return rule, ok
+}
@@ -0,0 +1,67 @@
+package policy
+
+import (
+	"errors"
+	"example_api/apperrors"
+	"example_api/auth"
+	"testing"
+)
+
+func TestTableLookups(t *testing.T) {
+	table := Table{
+		"GET /users/{id}":    Require("users:read"),
+		"Query.users":        Require("users:read"),
+		"/svc.Users/GetUser": Public,
+	}
+	tests := []struct {
+		name string
+		ok   bool
+		got  func() (Rule, bool)
+	}{
+		{"route", true, func() (Rule, bool) { return table.Route("GET", "/users/{id}") }},
+		{"route with another method", false, func() (Rule, bool) { return table.Route("DELETE", "/users/{id}") }},
+		{"field", true, func() (Rule, bool) { return table.Field("Query", "users") }},
+		{"field of another type", false, func() (Rule, bool) { return table.Field("Mutation", "users") }},
+		{"method", true, func() (Rule, bool) { return table.Method("/svc.Users/GetUser") }},
+		{"unknown method", false, func() (Rule, bool) { return table.Method("/svc.Users/DeleteUser") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.got(); ok != tt.ok {
+				t.Fatalf("got %t, want %t", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRuleEvaluate(t *testing.T) {
+	reader := &auth.Principal{UserID: "bob", Permissions: []string{"users:read"}}
+	user := &auth.Principal{UserID: "ada"}
+
+	tests := []struct {
+		name      string
+		rule      Rule
+		principal *auth.Principal
+		params    map[string]string
+		err       error
+	}{
+		{"public admits anonymous", Public, nil, nil, nil},
+		{"zero rule requires a principal", Rule{}, nil, nil, apperrors.ErrUnauthenticated},
+		{"zero rule admits any principal", Rule{}, user, nil, nil},
+		{"permission held", Require("users:read"), reader, nil, nil},
+		{"permission missing", Require("users:read"), user, nil, apperrors.ErrForbidden},
+		{"every permission needed", Require("users:read", "users:write"), reader, nil, apperrors.ErrForbidden},
+		{"anonymous needs a principal", Require("users:read"), nil, nil, apperrors.ErrUnauthenticated},
+		{"self", Require("users:write").OrSelf("id"), user, map[string]string{"id": "ada"}, nil},
+		{"someone else", Require("users:write").OrSelf("id"), user, map[string]string{"id": "bob"}, apperrors.ErrForbidden},
+		{"self needs a user ID", Require("users:write").OrSelf("id"), &auth.Principal{}, map[string]string{"id": ""}, apperrors.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Evaluate(tt.principal, tt.params)
+			if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package problem
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"example_api/apperrors"
+	"example_api/i18n"
+	"example_api/mediatype"
+	"example_api/requestid"
+	"io"
+	"net/http"
+)
+
+// ContentType is the media type of RFC 7807 problem details.
+const ContentType = mediatype.ProblemJSON
+
+// Problem is an RFC 7807 problem details object extended with the request ID.
+type Problem struct {
+	XMLName xml.Name `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	// Type is a URI identifying the problem type; "about:blank" means the status code says it all
+	Type      string `json:"type" xml:"type"`
+	Title     string `json:"title" xml:"title"`
+	Status    int    `json:"status" xml:"status"`
+	Detail    string `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty" xml:"instance,omitempty"`
+	RequestID string `json:"requestId,omitempty" xml:"requestId,omitempty"`
+	// Errors lists the invalid fields of a validation problem
+	Errors []apperrors.FieldError `json:"errors,omitempty" xml:"errors>error,omitempty"`
+}
+
+// New returns a problem of the default type for status with the given human-readable detail.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Write renders p as application/problem+json, or application/problem+xml for clients that
+// prefer XML, filling in the instance and request ID from r. The title, detail, and field
+// messages are translated to the language negotiated for r.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = requestid.FromContext(r.Context())
+	}
+	locale := i18n.FromContext(r.Context())
+	w.Header().Set("Content-Language", locale)
+	p.Title = i18n.Translate(locale, p.Title)
+	p.Detail = i18n.Translate(locale, p.Detail)
+	p.Errors = translateFields(locale, p.Errors)
+
+	if mediatype.Negotiate(r, mediatype.JSON, mediatype.XML) == mediatype.XML {
+		w.Header().Set("Content-Type", mediatype.ProblemXML)
+		w.WriteHeader(p.Status)
+		io.WriteString(w, xml.Header)
+		xml.NewEncoder(w).Encode(p)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error writes a default-typed problem for status with the given detail.
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, detail))
+}
+
+// translateFields returns a copy of fields with their messages in locale.
+func translateFields(locale string, fields []apperrors.FieldError) []apperrors.FieldError {
+	if len(fields) == 0 {
+		return fields
+	}
+	translated := make([]apperrors.FieldError, len(fields))
+	for i, field := range fields {
+		field.Message = i18n.Translate(locale, field.Message)
+		translated[i] = field
+	}
+	return translated
+}
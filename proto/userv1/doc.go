@@ -0,0 +1,4 @@
+// Package userv1 holds the generated protobuf messages and gRPC stubs for user.proto.
+package userv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative proto/userv1/user.proto
@@ -0,0 +1,57 @@
+package publishers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher writes messages to a Kafka topic, keyed by subject.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:  kafka.TCP(brokers...),
+			Topic: topic,
+			// Messages with the same key land on the same partition, preserving per-user order
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// Messages are written one at a time, so there is nothing to gain from waiting for a batch
+			BatchTimeout: 5 * time.Millisecond,
+			// A topic with the wrong partition count or retention is worse than a startup failure
+			AllowAutoTopicCreation: false,
+		},
+	}
+}
+
+// Publish writes msg and waits until every in-sync replica has it.
+func (p *KafkaPublisher) Publish(ctx context.Context, msg Message) error {
+	value, err := msg.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.Subject),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(msg.Type)},
+			{Key: "schemaVersion", Value: []byte(strconv.Itoa(msg.SchemaVersion))},
+			{Key: "contentType", Value: []byte("application/json")},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending writes and closes the connections.
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
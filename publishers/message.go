@@ -0,0 +1,56 @@
+package publishers
+
+import (
+	"encoding/json"
+	"example_api/events"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is the version of the Message schema. Fields may be added without changing it;
+// renaming or removing one, or changing its meaning, requires a new version.
+const SchemaVersion = 1
+
+// Source identifies this service in published messages.
+const Source = "example_api"
+
+// Message is the JSON envelope published to external systems for every event. Unlike event IDs,
+// which restart with the process, the ID is unique across restarts and instances.
+type Message struct {
+	SchemaVersion int         `json:"schemaVersion"`
+	ID            string      `json:"id"`
+	Type          string      `json:"type"`
+	Source        string      `json:"source"`
+	Time          time.Time   `json:"time"`
+	Subject       string      `json:"subject"`
+	Tenant        string      `json:"tenant"`
+	Data          interface{} `json:"data"`
+}
+
+// NewMessage wraps event in a Message. Its subject is the ID of the user the event is about,
+// which also serves as the partition key so each user's events stay in order.
+func NewMessage(event events.Event) Message {
+	var subject string
+	switch data := event.Data.(type) {
+	case events.User:
+		subject = data.ID
+	case events.UserRef:
+		subject = data.ID
+	}
+	return Message{
+		SchemaVersion: SchemaVersion,
+		ID:            uuid.NewString(),
+		Type:          event.Type,
+		Source:        Source,
+		Time:          event.Time,
+		Subject:       subject,
+		Tenant:        event.Tenant,
+		Data:          event.Data,
+	}
+}
+
+// Encode returns the JSON form of m.
+func (m Message) Encode() ([]byte, error) {
+	return json.Marshal(m)
+}
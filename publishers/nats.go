@@ -0,0 +1,74 @@
+package publishers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// flushTimeout bounds how long Publish waits for the server to acknowledge it has the message.
+const flushTimeout = 5 * time.Second
+
+// NATSPublisher publishes each message to the subject "<prefix>.<type>", such as
+// "user-events.user.created", so consumers can subscribe to "<prefix>.>" or to single types.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSPublisher connects to the NATS server at url. The client reconnects on its own after
+// losing the connection.
+func NewNATSPublisher(url, prefix string, logger *slog.Logger) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name(Source),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warn("Disconnected from NATS", slog.Any("error", err))
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Info("Reconnected to NATS", slog.String("url", conn.ConnectedUrlRedacted()))
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %v", err)
+	}
+	return &NATSPublisher{conn: conn, prefix: prefix}, nil
+}
+
+// Publish sends msg and waits for the server to confirm it was received. The Nats-Msg-Id header
+// lets JetStream streams discard duplicates from retries.
+func (p *NATSPublisher) Publish(ctx context.Context, msg Message) error {
+	data, err := msg.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	err = p.conn.PublishMsg(&nats.Msg{
+		Subject: p.prefix + "." + msg.Type,
+		Data:    data,
+		Header: nats.Header{
+			"Nats-Msg-Id":   []string{msg.ID},
+			"Content-Type":  []string{"application/json"},
+			"SchemaVersion": []string{strconv.Itoa(msg.SchemaVersion)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+
+	// PublishMsg only buffers; flushing surfaces a lost connection instead of silently losing the message
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the connection.
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,125 @@
+package publishers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example_api/events"
+	models "example_api/models"
+	"example_api/repositories"
+	"log/slog"
+	"time"
+)
+
+// OutboxRelay publishes the events saved in the outbox and marks them sent. Events stay in the
+// outbox until the bus accepts them, so a bus outage delays them but loses none. A crash between
+// publishing and marking an event sent publishes it again with the same message ID.
+type OutboxRelay struct {
+	outbox    repositories.OutboxStore
+	publisher Publisher
+	broker    *events.Broker
+	interval  time.Duration
+	batchSize int64
+	logger    *slog.Logger
+}
+
+// NewOutboxRelay returns a relay that checks the outbox every interval, and as soon as broker
+// reports a change, publishing up to batchSize events at a time.
+func NewOutboxRelay(outbox repositories.OutboxStore, publisher Publisher, broker *events.Broker, interval time.Duration, batchSize int, logger *slog.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		broker:    broker,
+		interval:  interval,
+		batchSize: int64(batchSize),
+		logger:    logger,
+	}
+}
+
+// Run publishes outbox events until the broker closes or ctx is done. Events still pending then
+// are published the next time a relay runs.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	// Broker events only wake the relay up early; the outbox is the source of truth
+	sub := r.broker.Subscribe(eventBuffer)
+	defer func() { sub.Close() }()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case _, ok := <-sub.Events():
+			if !ok {
+				if !errors.Is(sub.Err(), events.ErrSlowSubscriber) {
+					return
+				}
+				sub = r.broker.Subscribe(eventBuffer)
+			}
+			// One flush covers every change made so far
+			for len(sub.Events()) > 0 {
+				<-sub.Events()
+			}
+		}
+	}
+}
+
+// flush publishes pending events in order until none are left or one fails. Stopping at a
+// failure keeps each user's events in order; the failed event is retried on the next flush.
+func (r *OutboxRelay) flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		pending, err := r.outbox.Pending(ctx, r.batchSize)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to read the outbox", slog.Any("error", err))
+			return
+		}
+		for _, event := range pending {
+			if !r.publish(ctx, event) {
+				return
+			}
+		}
+		if int64(len(pending)) < r.batchSize {
+			return
+		}
+	}
+}
+
+// publish sends one event and marks it sent, reporting whether the relay may move on.
+func (r *OutboxRelay) publish(ctx context.Context, event models.OutboxEvent) bool {
+	if err := r.publisher.Publish(ctx, outboxMessage(event)); err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		r.logger.WarnContext(ctx, "Failed to publish outbox event; will retry",
+			slog.String("type", event.Type), slog.String("subject", event.Subject), slog.String("id", event.MessageID),
+			slog.Int("attempts", event.Attempts+1), slog.Any("error", err))
+		if err := r.outbox.RecordFailure(context.WithoutCancel(ctx), event.Id, err); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to record outbox failure", slog.Any("error", err))
+		}
+		return false
+	}
+
+	// The event is on the bus, so mark it even once ctx is done to avoid publishing it twice
+	if err := r.outbox.MarkSent(context.WithoutCancel(ctx), event.Id, time.Now().UTC()); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to mark outbox event sent", slog.String("id", event.MessageID), slog.Any("error", err))
+		return false
+	}
+	return true
+}
+
+// outboxMessage rebuilds the Message for a stored event, keeping its original ID.
+func outboxMessage(event models.OutboxEvent) Message {
+	return Message{
+		SchemaVersion: SchemaVersion,
+		ID:            event.MessageID,
+		Type:          event.Type,
+		Source:        Source,
+		Time:          event.Time,
+		Subject:       event.Subject,
+		Tenant:        event.Tenant,
+		Data:          json.RawMessage(event.Data),
+	}
+}
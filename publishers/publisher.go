@@ -0,0 +1,17 @@
+package publishers
+
+import "context"
+
+// Publisher delivers messages to an external message bus. Implementations are used by a single
+// Relay and need not be safe for concurrent use.
+type Publisher interface {
+	// Publish returns once the bus has accepted msg
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+var (
+	_ Publisher = (*KafkaPublisher)(nil)
+	_ Publisher = (*NATSPublisher)(nil)
+	_ Publisher = (*RabbitMQPublisher)(nil)
+)
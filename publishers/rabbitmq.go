@@ -0,0 +1,103 @@
+package publishers
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQPublisher publishes each message to a durable topic exchange with the event type as
+// routing key, so queues can bind to "user.*" or to single types.
+type RabbitMQPublisher struct {
+	url      string
+	exchange string
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+}
+
+// NewRabbitMQPublisher connects to the broker at url and declares the exchange.
+func NewRabbitMQPublisher(url, exchange string) (*RabbitMQPublisher, error) {
+	p := &RabbitMQPublisher{url: url, exchange: exchange}
+	if err := p.connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+	return p, nil
+}
+
+func (p *RabbitMQPublisher) connect() error {
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if err := channel.ExchangeDeclare(p.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		conn.Close()
+		return err
+	}
+	// Publisher confirms tell us when the broker has taken responsibility for a message
+	if err := channel.Confirm(false); err != nil {
+		conn.Close()
+		return err
+	}
+	p.conn, p.channel = conn, channel
+	return nil
+}
+
+// Publish sends msg as a persistent message and waits for the broker to confirm it. A lost
+// connection is re-established on the next call.
+func (p *RabbitMQPublisher) Publish(ctx context.Context, msg Message) error {
+	body, err := msg.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if p.channel == nil || p.channel.IsClosed() {
+		p.reset()
+		if err := p.connect(); err != nil {
+			return fmt.Errorf("failed to reconnect to RabbitMQ: %w", err)
+		}
+	}
+
+	confirmation, err := p.channel.PublishWithDeferredConfirmWithContext(ctx, p.exchange, msg.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.ID,
+		Timestamp:    msg.Time,
+		Type:         msg.Type,
+		AppId:        Source,
+		Headers:      amqp.Table{"schemaVersion": int32(msg.SchemaVersion)},
+		Body:         body,
+	})
+	if err != nil {
+		p.reset()
+		return fmt.Errorf("failed to publish to RabbitMQ: %w", err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to publish to RabbitMQ: %w", err)
+	}
+	if !acked {
+		return fmt.Errorf("failed to publish to RabbitMQ: broker rejected message %s", msg.ID)
+	}
+	return nil
+}
+
+// reset drops the current connection so the next Publish dials a new one.
+func (p *RabbitMQPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.channel = nil, nil
+}
+
+// Close closes the channel and connection.
+func (p *RabbitMQPublisher) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.Close()
+}
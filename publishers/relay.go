@@ -0,0 +1,46 @@
+package publishers
+
+import (
+	"context"
+	"example_api/events"
+	"example_api/repositories"
+	"log/slog"
+	"time"
+)
+
+// eventBuffer is how many events may queue up while a publish is slow before the relay falls
+// behind and catches up from the broker's history.
+const eventBuffer = 1024
+
+// retryPolicy rides out brief bus outages such as leader elections and reconnects.
+var retryPolicy = repositories.RetryPolicy{MaxAttempts: 5, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// Relay forwards broker events to a message bus as Messages.
+type Relay struct {
+	publisher Publisher
+	broker    *events.Broker
+	logger    *slog.Logger
+}
+
+func NewRelay(publisher Publisher, broker *events.Broker, logger *slog.Logger) *Relay {
+	return &Relay{
+		publisher: publisher,
+		broker:    broker,
+		logger:    logger,
+	}
+}
+
+// Run publishes events until the broker closes. Cancelling ctx abandons the publish under way.
+// Events that still fail after the retries are logged and dropped.
+func (r *Relay) Run(ctx context.Context) {
+	r.broker.Consume(ctx, eventBuffer, func(event events.Event) {
+		msg := NewMessage(event)
+		err := retryPolicy.Do(ctx, func(error) bool { return ctx.Err() == nil }, func() error {
+			return r.publisher.Publish(ctx, msg)
+		})
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Dropping event that could not be published",
+				slog.String("type", msg.Type), slog.String("subject", msg.Subject), slog.String("id", msg.ID), slog.Any("error", err))
+		}
+	})
+}
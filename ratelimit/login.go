@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"example_api/auth"
+	"example_api/clientip"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// LoginThrottle slows down the clients that keep failing to authenticate, by address, whichever
+// accounts they try, so credentials cannot be sprayed across many accounts from one address.
+type LoginThrottle interface {
+	// Attempt starts an attempt of the client at ip, which must be finished with Fail or
+	// Succeed unless it is refused.
+	Attempt(ctx context.Context, ip string) (LoginAttempt, error)
+	// Fail counts the failure of attempt and returns how long the client at ip must now wait,
+	// or 0.
+	Fail(ctx context.Context, ip string, attempt LoginAttempt) (time.Duration, error)
+	// Succeed finishes attempt without counting it.
+	Succeed(ctx context.Context, ip string, attempt LoginAttempt) error
+}
+
+// LoginAttempt is what a LoginThrottle decided about an attempt.
+type LoginAttempt struct {
+	// Wait is how long the client must wait before trying again. The attempt is refused when it
+	// is positive.
+	Wait time.Duration
+	// turn is whether the attempt holds the turn of a client with no free failures left, which
+	// has one attempt checked at a time so parallel guesses cannot slip past the wait
+	turn bool
+}
+
+// turnTimeout is how long an attempt may hold its client's turn before the next one is let
+// through, in case it is never finished.
+const turnTimeout = 10 * time.Second
+
+// Escalation is how long clients wait after failing: not at all for their first Attempts
+// failures, then Delay, doubling with every further failure up to MaxDelay. Failures are
+// forgotten Window after the last one.
+type Escalation struct {
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+	Window   time.Duration
+}
+
+// delay returns how long a client waits after its failures-th failure in a row.
+func (e Escalation) delay(failures int64) time.Duration {
+	over := failures - int64(e.Attempts)
+	if over <= 0 {
+		return 0
+	}
+	delay := e.Delay
+	for ; over > 1 && delay < e.MaxDelay; over-- {
+		delay *= 2
+	}
+	return min(delay, e.MaxDelay)
+}
+
+// Authenticator checks user credentials and returns whom they belong to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, email, password string) (*auth.Principal, error)
+}
+
+// ThrottledError is returned for sign-ins of a client that must wait before trying again.
+type ThrottledError struct {
+	Wait time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed sign-ins, retry in %s", e.Wait)
+}
+
+// throttledAuthenticator checks credentials only for clients allowed to try them.
+type throttledAuthenticator struct {
+	next     Authenticator
+	throttle LoginThrottle
+	logger   *slog.Logger
+}
+
+// ThrottleLogins wraps authenticator so the client address in the context of each sign-in is
+// throttled: refused attempts, and failures that start a wait, return a *ThrottledError. Other
+// failures return what authenticator does. Sign-ins go ahead when throttle fails.
+func ThrottleLogins(authenticator Authenticator, throttle LoginThrottle, logger *slog.Logger) Authenticator {
+	return &throttledAuthenticator{
+		next:     authenticator,
+		throttle: throttle,
+		logger:   logger,
+	}
+}
+
+func (a *throttledAuthenticator) Authenticate(ctx context.Context, email, password string) (*auth.Principal, error) {
+	ip := clientip.FromContext(ctx)
+	attempt, err := a.throttle.Attempt(ctx, ip)
+	if err != nil {
+		a.logger.WarnContext(ctx, "Login throttle check failed", slog.Any("error", err))
+		return a.next.Authenticate(ctx, email, password)
+	}
+	if attempt.Wait > 0 {
+		return nil, &ThrottledError{Wait: attempt.Wait}
+	}
+
+	principal, err := a.next.Authenticate(ctx, email, password)
+	// Only wrong credentials count against the client
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		wait, failErr := a.throttle.Fail(context.WithoutCancel(ctx), ip, attempt)
+		if failErr != nil {
+			a.logger.WarnContext(ctx, "Failed to record failed login", slog.Any("error", failErr))
+		}
+		if wait > 0 {
+			return nil, &ThrottledError{Wait: wait}
+		}
+		return nil, err
+	}
+	if err := a.throttle.Succeed(context.WithoutCancel(ctx), ip, attempt); err != nil {
+		a.logger.WarnContext(ctx, "Failed to record login attempt", slog.Any("error", err))
+	}
+	return principal, err
+}
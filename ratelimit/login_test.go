@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"example_api/auth"
+	"example_api/clientip"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEscalationDelay(t *testing.T) {
+	escalation := Escalation{Attempts: 3, Delay: time.Second, MaxDelay: 5 * time.Second, Window: time.Minute}
+	tests := []struct {
+		failures int64
+		delay    time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := escalation.delay(tt.failures); got != tt.delay {
+			t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.delay)
+		}
+	}
+}
+
+func TestMemoryLoginThrottleEscalates(t *testing.T) {
+	throttle := NewMemoryLoginThrottle(Escalation{Attempts: 2, Delay: time.Hour, MaxDelay: 4 * time.Hour, Window: 24 * time.Hour})
+	ctx := context.Background()
+
+	// The first two failures are free, and the third starts a wait
+	for i, delay := range []time.Duration{0, 0, time.Hour} {
+		attempt, err := throttle.Attempt(ctx, "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempt.Wait != 0 {
+			t.Fatalf("attempt %d: got %+v, want it let through", i+1, attempt)
+		}
+		wait, err := throttle.Fail(ctx, "192.0.2.1", attempt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != delay {
+			t.Fatalf("failure %d: got wait %s, want %s", i+1, wait, delay)
+		}
+	}
+	attempt, _ := throttle.Attempt(ctx, "192.0.2.1")
+	if attempt.Wait <= 0 {
+		t.Fatalf("got %+v during the wait, want it refused", attempt)
+	}
+	if attempt, _ := throttle.Attempt(ctx, "192.0.2.2"); attempt.Wait != 0 {
+		t.Fatalf("got %+v for another address, want it let through", attempt)
+	}
+}
+
+func TestMemoryLoginThrottleParallelAttempts(t *testing.T) {
+	const parallel = 50
+	ctx := context.Background()
+	attemptAll := func(throttle LoginThrottle) []LoginAttempt {
+		attempts := make([]LoginAttempt, parallel)
+		var wg sync.WaitGroup
+		for i := range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				attempts[i], _ = throttle.Attempt(ctx, "192.0.2.1")
+			}()
+		}
+		wg.Wait()
+		return attempts
+	}
+
+	t.Run("successful sign-ins are never throttled", func(t *testing.T) {
+		throttle := NewMemoryLoginThrottle(Escalation{Attempts: 3, Delay: time.Hour, MaxDelay: time.Hour, Window: time.Hour})
+		authenticator := ThrottleLogins(credentialsAuthenticator{}, throttle, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		errs := make([]error, parallel)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = authenticator.Authenticate(clientip.NewContext(ctx, "192.0.2.1"), "ada@example.com", "secret")
+			}()
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("sign-in %d: got error %v", i+1, err)
+			}
+		}
+		if attempts := attemptAll(throttle); attempts[0].Wait != 0 {
+			t.Fatalf("got %+v after the sign-ins, want it let through", attempts[0])
+		}
+	})
+
+	t.Run("one attempt at a time without free failures", func(t *testing.T) {
+		throttle := NewMemoryLoginThrottle(Escalation{Attempts: 1, Delay: time.Millisecond, MaxDelay: time.Millisecond, Window: time.Hour})
+		for range 2 {
+			attempt, _ := throttle.Attempt(ctx, "192.0.2.1")
+			throttle.Fail(ctx, "192.0.2.1", attempt)
+		}
+		time.Sleep(2 * time.Millisecond)
+
+		var admitted []LoginAttempt
+		for _, attempt := range attemptAll(throttle) {
+			if attempt.Wait == 0 {
+				admitted = append(admitted, attempt)
+			}
+		}
+		if len(admitted) != 1 {
+			t.Fatalf("%d parallel attempts were let through, want 1", len(admitted))
+		}
+		// Finishing the attempt gives its turn to the next one
+		throttle.Succeed(ctx, "192.0.2.1", admitted[0])
+		if attempt, _ := throttle.Attempt(ctx, "192.0.2.1"); attempt.Wait != 0 {
+			t.Fatalf("got %+v after the attempt succeeded, want it let through", attempt)
+		}
+	})
+}
+
+// credentialsAuthenticator accepts the password "secret" only.
+type credentialsAuthenticator struct{}
+
+func (credentialsAuthenticator) Authenticate(ctx context.Context, email, password string) (*auth.Principal, error) {
+	if password != "secret" {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.Principal{UserID: email}, nil
+}
+
+func TestThrottleLogins(t *testing.T) {
+	throttle := NewMemoryLoginThrottle(Escalation{Attempts: 1, Delay: time.Hour, MaxDelay: time.Hour, Window: time.Hour})
+	authenticator := ThrottleLogins(credentialsAuthenticator{}, throttle, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := clientip.NewContext(context.Background(), "192.0.2.1")
+
+	steps := []struct {
+		password  string
+		throttled bool
+		invalid   bool
+	}{
+		{"secret", false, false},
+		{"wrong", false, true},
+		{"secret", false, false},
+		// The second failure starts the wait and is reported as throttled
+		{"wrong", true, false},
+		{"secret", true, false},
+	}
+	for i, step := range steps {
+		_, err := authenticator.Authenticate(ctx, "ada@example.com", step.password)
+		var throttled *ThrottledError
+		if errors.As(err, &throttled) != step.throttled {
+			t.Fatalf("step %d: got error %v, want throttled %t", i+1, err, step.throttled)
+		}
+		if errors.Is(err, auth.ErrInvalidCredentials) != step.invalid {
+			t.Fatalf("step %d: got error %v, want invalid credentials %t", i+1, err, step.invalid)
+		}
+	}
+}
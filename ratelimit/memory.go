@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter counts requests in memory, so each instance limits clients on its own.
+type MemoryLimiter struct {
+	window time.Duration
+
+	mu sync.Mutex
+	// start is the start of the window counts holds the requests of
+	start  time.Time
+	counts map[string]int64
+}
+
+func NewMemoryLimiter(window time.Duration) *MemoryLimiter {
+	return &MemoryLimiter{
+		window: window,
+		counts: make(map[string]int64),
+	}
+}
+
+var _ Limiter = (*MemoryLimiter)(nil)
+
+// Allow counts the request of key in the current window. Every count starts over when a
+// window begins, so clients of past windows take up no memory.
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit int) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if start := windowStart(time.Now(), l.window); !start.Equal(l.start) {
+		l.start = start
+		clear(l.counts)
+	}
+	l.counts[key]++
+	return result(l.counts[key], limit, l.start.Add(l.window)), nil
+}
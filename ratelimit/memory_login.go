@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLoginThrottle counts failed attempts in memory, so each instance throttles clients on
+// its own.
+type MemoryLoginThrottle struct {
+	escalation Escalation
+
+	mu       sync.Mutex
+	failures map[string]*memoryFailures
+	// swept is when failures last had its forgotten clients removed
+	swept time.Time
+}
+
+// memoryFailures are the failed attempts of one client.
+type memoryFailures struct {
+	count int64
+	last  time.Time
+	until time.Time
+	// turn is when the attempt holding the client's turn stops holding it
+	turn time.Time
+}
+
+func NewMemoryLoginThrottle(escalation Escalation) *MemoryLoginThrottle {
+	return &MemoryLoginThrottle{
+		escalation: escalation,
+		failures:   make(map[string]*memoryFailures),
+	}
+}
+
+var _ LoginThrottle = (*MemoryLoginThrottle)(nil)
+
+// Attempt refuses the client at ip while it must wait, or while another of its attempts holds
+// its turn, and hands out the turn when it has no free failures left. Clients whose failures
+// are forgotten are removed once per window.
+func (t *MemoryLoginThrottle) Attempt(ctx context.Context, ip string) (LoginAttempt, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.swept) >= t.escalation.Window {
+		for key, failures := range t.failures {
+			if now.Sub(failures.last) >= t.escalation.Window {
+				delete(t.failures, key)
+			}
+		}
+		t.swept = now
+	}
+
+	failures := t.current(ip, now)
+	if failures == nil || failures.count < int64(t.escalation.Attempts) {
+		return LoginAttempt{}, nil
+	}
+	if now.Before(failures.until) {
+		return LoginAttempt{Wait: failures.until.Sub(now)}, nil
+	}
+	if now.Before(failures.turn) {
+		return LoginAttempt{Wait: t.escalation.Delay}, nil
+	}
+	failures.turn = now.Add(turnTimeout)
+	return LoginAttempt{turn: true}, nil
+}
+
+// Fail counts a failure of the client at ip, starting over when its last one is older than the
+// window, and makes it wait for the delay the count calls for.
+func (t *MemoryLoginThrottle) Fail(ctx context.Context, ip string, attempt LoginAttempt) (time.Duration, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	failures := t.current(ip, now)
+	if failures == nil {
+		failures = &memoryFailures{}
+		t.failures[ip] = failures
+	}
+	if attempt.turn {
+		failures.turn = time.Time{}
+	}
+	failures.count++
+	failures.last = now
+	delay := t.escalation.delay(failures.count)
+	if until := now.Add(delay); until.After(failures.until) {
+		failures.until = until
+	}
+	return delay, nil
+}
+
+// Succeed gives back the turn attempt holds, if any. The failures of the client at ip are kept.
+func (t *MemoryLoginThrottle) Succeed(ctx context.Context, ip string, attempt LoginAttempt) error {
+	if !attempt.turn {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if failures, ok := t.failures[ip]; ok {
+		failures.turn = time.Time{}
+	}
+	return nil
+}
+
+// current returns the failures of the client at ip, or nil when it has none within the window.
+// t.mu must be held.
+func (t *MemoryLoginThrottle) current(ip string, now time.Time) *memoryFailures {
+	failures, ok := t.failures[ip]
+	if !ok || now.Sub(failures.last) >= t.escalation.Window {
+		return nil
+	}
+	return failures
+}
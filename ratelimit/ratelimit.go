@@ -0,0 +1,62 @@
+// Package ratelimit counts requests per client in fixed windows, so each client can be held to
+// the number of requests its plan allows per window, and failed sign-ins per address, so
+// clients that keep failing are made to wait longer and longer.
+package ratelimit
+
+import (
+	"context"
+	"example_api/auth"
+	"time"
+)
+
+// Result is the state of a client's window after counting a request.
+type Result struct {
+	// Allowed reports whether the request was within the limit
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the window ends and the count starts over
+	Reset time.Time
+}
+
+// Limiter counts the requests of each client.
+type Limiter interface {
+	// Allow counts a request of the client key against limit requests per window.
+	Allow(ctx context.Context, key string, limit int) (Result, error)
+}
+
+// Plans names the number of requests clients may make per window, by plan, and which plan
+// each client has.
+type Plans struct {
+	Limits map[string]int
+	// ByRole gives the plan of users and the admin token by their role, and Default that of the
+	// others and of anonymous clients
+	ByRole  map[string]string
+	Default string
+}
+
+// For returns the key a request by principal from address ip is counted under and the limit of
+// its plan. Users are counted by ID and anonymous clients by address.
+func (p Plans) For(principal *auth.Principal, ip string) (key string, limit int) {
+	plan := p.Default
+	if principal != nil {
+		if byRole, ok := p.ByRole[principal.Role]; ok {
+			plan = byRole
+		}
+	}
+	return auth.ClientKey(principal, ip), p.Limits[plan]
+}
+
+// windowStart returns the start of the window of length window that now falls in.
+func windowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
+
+// result describes a window of limit requests ending at reset that count requests fell in.
+func result(count int64, limit int, reset time.Time) Result {
+	remaining := limit - int(count)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{Allowed: count <= int64(limit), Limit: limit, Remaining: remaining, Reset: reset}
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLimiter counts requests in Redis, so every instance sharing it holds clients to the same
+// limits.
+type RedisLimiter struct {
+	client *redis.Client
+	window time.Duration
+}
+
+func NewRedisLimiter(client *redis.Client, window time.Duration) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		window: window,
+	}
+}
+
+var _ Limiter = (*RedisLimiter)(nil)
+
+// Allow increments the counter of key for the current window, which expires with the window.
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int) (Result, error) {
+	start := windowStart(time.Now(), l.window)
+	counter := "ratelimit:" + key + ":" + strconv.FormatInt(start.Unix(), 10)
+
+	var incr *redis.IntCmd
+	if _, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, counter)
+		pipe.Expire(ctx, counter, l.window)
+		return nil
+	}); err != nil {
+		return Result{}, err
+	}
+	return result(incr.Val(), limit, start.Add(l.window)), nil
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLoginThrottle counts failed attempts in Redis, so every instance sharing it makes
+// clients wait alike.
+type RedisLoginThrottle struct {
+	client     *redis.Client
+	escalation Escalation
+}
+
+func NewRedisLoginThrottle(client *redis.Client, escalation Escalation) *RedisLoginThrottle {
+	return &RedisLoginThrottle{
+		client:     client,
+		escalation: escalation,
+	}
+}
+
+var _ LoginThrottle = (*RedisLoginThrottle)(nil)
+
+// attemptScript returns {wait in ms, whether the attempt holds the turn}. It lets clients
+// whose failure counter (KEYS[1]) is below ARGV[1] through, and refuses the others while their
+// wait key (KEYS[2]) is alive, for its time to live, or while their turn key (KEYS[3]) is, for
+// ARGV[3] ms. Otherwise the attempt takes the turn key, which expires after ARGV[2] ms.
+var attemptScript = redis.NewScript(`
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count < tonumber(ARGV[1]) then
+	return {0, 0}
+end
+local wait = redis.call('PTTL', KEYS[2])
+if wait > 0 then
+	return {wait, 0}
+end
+if redis.call('SET', KEYS[3], 1, 'NX', 'PX', ARGV[2]) then
+	return {0, 1}
+end
+return {tonumber(ARGV[3]), 0}
+`)
+
+// failScript deletes the turn key (KEYS[3]) when ARGV[5] is 1, increments the failure counter
+// (KEYS[1]), which expires ARGV[1] ms after the last failure, and sets the wait key (KEYS[2])
+// for the delay that count calls for, which it returns: none for the first ARGV[2] failures,
+// then ARGV[3] ms, doubling up to ARGV[4] ms.
+var failScript = redis.NewScript(`
+if ARGV[5] == '1' then
+	redis.call('DEL', KEYS[3])
+end
+local count = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+local over = count - tonumber(ARGV[2])
+if over <= 0 then
+	return 0
+end
+local delay = tonumber(ARGV[3])
+local max = tonumber(ARGV[4])
+while over > 1 and delay < max do
+	delay = delay * 2
+	over = over - 1
+end
+delay = math.min(delay, max)
+if delay <= 0 then
+	return 0
+end
+redis.call('SET', KEYS[2], 1, 'PX', delay)
+return delay
+`)
+
+// Attempt runs attemptScript, so checking the failures and taking the turn are one step.
+func (t *RedisLoginThrottle) Attempt(ctx context.Context, ip string) (LoginAttempt, error) {
+	result, err := attemptScript.Run(ctx, t.client, t.keys(ip),
+		t.escalation.Attempts, turnTimeout.Milliseconds(), t.escalation.Delay.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+	return LoginAttempt{
+		Wait: time.Duration(result[0]) * time.Millisecond,
+		turn: result[1] == 1,
+	}, nil
+}
+
+// Fail runs failScript.
+func (t *RedisLoginThrottle) Fail(ctx context.Context, ip string, attempt LoginAttempt) (time.Duration, error) {
+	turn := 0
+	if attempt.turn {
+		turn = 1
+	}
+	delay, err := failScript.Run(ctx, t.client, t.keys(ip),
+		t.escalation.Window.Milliseconds(), t.escalation.Attempts, t.escalation.Delay.Milliseconds(), t.escalation.MaxDelay.Milliseconds(), turn,
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(delay) * time.Millisecond, nil
+}
+
+// Succeed deletes the turn key when attempt holds the turn. The failures of the client at ip
+// are kept.
+func (t *RedisLoginThrottle) Succeed(ctx context.Context, ip string, attempt LoginAttempt) error {
+	if !attempt.turn {
+		return nil
+	}
+	return t.client.Del(ctx, t.keys(ip)[2]).Err()
+}
+
+// keys returns the failure counter, wait key, and turn key of ip.
+func (t *RedisLoginThrottle) keys(ip string) []string {
+	prefix := "loginthrottle:" + ip
+	return []string{prefix + ":failures", prefix + ":wait", prefix + ":turn"}
+}
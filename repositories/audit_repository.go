@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository stores the audit log in the audit_logs MongoDB collection.
+type AuditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(db *mongodb.Database) *AuditRepository {
+	return &AuditRepository{
+		collection: db.Collection("audit_logs"),
+	}
+}
+
+var _ AuditStore = (*AuditRepository)(nil)
+
+// mongoAuditEntry is the stored form of an audit entry. TenantID is empty for the default tenant.
+type mongoAuditEntry struct {
+	models.AuditEntry `bson:",inline"`
+	TenantID          string `bson:"tenantId,omitempty"`
+}
+
+// Add inserts an audit entry in the tenant of ctx.
+func (repo *AuditRepository) Add(ctx context.Context, entry *models.AuditEntry) error {
+	id, _ := mongoTenant(ctx).(string)
+	if _, err := repo.collection.InsertOne(ctx, mongoAuditEntry{AuditEntry: *entry, TenantID: id}); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// Erase overwrites the before and after values of the named fields in the user's entries.
+func (repo *AuditRepository) Erase(ctx context.Context, targetID primitive.ObjectID, fields []string) error {
+	_, err := repo.collection.UpdateMany(ctx,
+		mongoScoped(ctx, bson.M{"targetId": targetID, "changes.field": bson.M{"$in": fields}}),
+		bson.M{"$set": bson.M{"changes.$[change].before": models.AuditErased, "changes.$[change].after": models.AuditErased}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: bson.A{bson.M{"change.field": bson.M{"$in": fields}}}}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to erase audit entries: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes the entries of every tenant older than before.
+func (repo *AuditRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := repo.collection.DeleteMany(ctx, bson.M{"time": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit entries: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// List returns one page of the entries matching filter, newest first.
+func (repo *AuditRepository) List(ctx context.Context, filter AuditFilter, skip, limit int64) ([]models.AuditEntry, int64, error) {
+	query := mongoScoped(ctx, auditQuery(filter))
+	total, err := repo.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	cursor, err := repo.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "time", Value: -1}, {Key: "_id", Value: -1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+func auditQuery(filter AuditFilter) bson.M {
+	query := bson.M{}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if !filter.TargetID.IsZero() {
+		query["targetId"] = filter.TargetID
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		between := bson.M{}
+		if !filter.From.IsZero() {
+			between["$gte"] = filter.From
+		}
+		if !filter.To.IsZero() {
+			between["$lt"] = filter.To
+		}
+		query["time"] = between
+	}
+	return query
+}
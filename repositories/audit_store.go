@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditStore keeps the audit log, an append-only record of every change to users. Entries
+// outlive the users they are about.
+type AuditStore interface {
+	Add(ctx context.Context, entry *models.AuditEntry) error
+	// Erase replaces the values of fields in every entry about the user targetID with
+	// models.AuditErased, so the log keeps what happened but not the data that was erased.
+	Erase(ctx context.Context, targetID primitive.ObjectID, fields []string) error
+	// List returns one page of the entries matching filter, newest first, along with the total
+	// number of matching entries.
+	List(ctx context.Context, filter AuditFilter, skip, limit int64) ([]models.AuditEntry, int64, error)
+	// Purge deletes the entries of every tenant made before the given time and returns how many
+	// it deleted.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AuditFilter restricts List to entries matching every non-empty field. From is inclusive and
+// To exclusive.
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	TargetID primitive.ObjectID
+	From     time.Time
+	To       time.Time
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"example_api/actor"
+	models "example_api/models"
+	"example_api/requestid"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// erasableFields are the user fields holding personal data, which erasing a user also erases
+// from its audit entries.
+var erasableFields = []string{"email", "firstName", "lastName", "phone", "addresses", "metadata"}
+
+// AuditingUserStore records every successful change made through it in the audit log, with the
+// actor, IP, and request ID of ctx. Reads pass straight through to the wrapped store.
+type AuditingUserStore struct {
+	UserStore
+	audits AuditStore
+	logger *slog.Logger
+}
+
+func NewAuditingUserStore(store UserStore, audits AuditStore, logger *slog.Logger) *AuditingUserStore {
+	return &AuditingUserStore{
+		UserStore: store,
+		audits:    audits,
+		logger:    logger,
+	}
+}
+
+// Create stores the user and records its fields.
+func (s *AuditingUserStore) Create(ctx context.Context, user *models.User) error {
+	if err := s.UserStore.Create(ctx, user); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUserCreated, user.Id, auditChanges(nil, auditFields(user)))
+	return nil
+}
+
+// CreateMany creates the users and records the fields of each one that was created.
+func (s *AuditingUserStore) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs, err := s.UserStore.CreateMany(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+	for i, user := range users {
+		if errs[i] == nil {
+			s.record(ctx, models.AuditUserCreated, user.Id, auditChanges(nil, auditFields(user)))
+		}
+	}
+	return errs, nil
+}
+
+// Update updates the user and records the fields whose value changed. The user is read first
+// for the values before the change. An update that sets erasedAt is recorded as an erasure and
+// erases the user's personal data from the entries before it too.
+func (s *AuditingUserStore) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	before, err := s.UserStore.GetByID(ctx, id)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+	if err := s.UserStore.Update(ctx, id, version, fields); err != nil {
+		return err
+	}
+	if before == nil {
+		// Updating a missing user changes nothing
+		return nil
+	}
+
+	changes := auditChanges(auditFields(before), fields)
+	if _, ok := fields["erasedAt"]; !ok {
+		if len(changes) > 0 {
+			s.record(ctx, models.AuditUserUpdated, id, changes)
+		}
+		return nil
+	}
+	for i := range changes {
+		if slices.Contains(erasableFields, changes[i].Field) {
+			changes[i].Before = models.AuditErased
+			changes[i].After = models.AuditErased
+		}
+	}
+	if err := s.audits.Erase(ctx, id, erasableFields); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to erase audit entries", slog.String("id", id.Hex()), slog.Any("error", err))
+	}
+	s.record(ctx, models.AuditUserErased, id, changes)
+	return nil
+}
+
+// Delete deletes the user and records the deletion.
+func (s *AuditingUserStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.UserStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.record(ctx, models.AuditUserDeleted, id, nil)
+	return nil
+}
+
+// record adds an audit entry for a change that has already been made, so a failure is logged
+// rather than returned.
+func (s *AuditingUserStore) record(ctx context.Context, action string, id primitive.ObjectID, changes []models.FieldChange) {
+	who := actor.FromContext(ctx)
+	entry := &models.AuditEntry{
+		Id:        primitive.NewObjectID(),
+		Time:      time.Now().UTC(),
+		Action:    action,
+		Actor:     who.Name,
+		IP:        who.IP,
+		RequestID: requestid.FromContext(ctx),
+		TargetID:  id,
+		Changes:   changes,
+	}
+	if err := s.audits.Add(ctx, entry); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record audit entry",
+			slog.String("action", action), slog.String("id", id.Hex()), slog.String("actor", who.Name), slog.Any("error", err))
+	}
+}
+
+// auditFields returns the stored fields of user by name.
+func auditFields(user *models.User) map[string]interface{} {
+	fields := map[string]interface{}{
+		"email":     user.Email,
+		"password":  user.Password,
+		"firstName": user.FirstName,
+		"lastName":  user.LastName,
+		"phone":     user.Phone,
+		"role":      user.Role,
+		"joinDate":  user.JoinDate.UTC(),
+	}
+	if user.ErasedAt != nil {
+		fields["erasedAt"] = user.ErasedAt.UTC()
+	}
+	if user.PhoneVerifiedAt != nil {
+		fields["phoneVerifiedAt"] = user.PhoneVerifiedAt.UTC()
+	}
+	if user.Addresses != nil {
+		fields["addresses"] = user.Addresses
+	}
+	if user.Preferences != nil {
+		fields["preferences"] = *user.Preferences
+	}
+	if user.Metadata != nil {
+		fields["metadata"] = user.Metadata
+	}
+	return fields
+}
+
+// auditChanges lists the fields of after whose value differs from before, by name. Password
+// hashes are never recorded.
+func auditChanges(before, after map[string]interface{}) []models.FieldChange {
+	names := make([]string, 0, len(after))
+	for name := range after {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var changes []models.FieldChange
+	for _, name := range names {
+		old, value := before[name], after[name]
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC()
+		}
+		if before != nil && reflect.DeepEqual(old, value) {
+			continue
+		}
+		if name == "password" {
+			if old != nil {
+				old = models.AuditRedacted
+			}
+			value = models.AuditRedacted
+		}
+		changes = append(changes, models.FieldChange{Field: name, Before: old, After: value})
+	}
+	return changes
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrAvatarNotFound is returned when the user has no avatar, or not in the requested variant.
+var ErrAvatarNotFound = apperrors.NotFound("Avatar not found")
+
+// AvatarStore keeps one image per user and avatar variant.
+type AvatarStore interface {
+	// Put stores data as the avatar.Variant image of the user's avatar, replacing any previous one.
+	Put(ctx context.Context, avatar *models.Avatar, data []byte) error
+	// Get returns the variant of the user's avatar and a reader for its bytes, which the caller
+	// must close, or ErrAvatarNotFound.
+	Get(ctx context.Context, userID primitive.ObjectID, variant string) (*models.Avatar, io.ReadCloser, error)
+	// Delete removes every variant of the user's avatar, if there is one.
+	Delete(ctx context.Context, userID primitive.ObjectID) error
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"example_api/cache"
+	models "example_api/models"
+	"example_api/tenant"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CachedUserStore is a read-through cache in front of another UserStore. Lookups by ID are
+// served from the cache when possible and entries are invalidated on update and delete.
+// Entries are kept per tenant, so a user is only ever served to its own tenant. Cache failures
+// are logged and fall through to the underlying store.
+type CachedUserStore struct {
+	UserStore
+	cache  cache.Cache
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+func NewCachedUserStore(store UserStore, c cache.Cache, ttl time.Duration, logger *slog.Logger) *CachedUserStore {
+	return &CachedUserStore{
+		UserStore: store,
+		cache:     c,
+		ttl:       ttl,
+		logger:    logger,
+	}
+}
+
+// GetByID returns the cached user if present, otherwise loads it from the underlying store and caches it.
+func (s *CachedUserStore) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	key := userCacheKey(tenant.FromContext(ctx), id)
+
+	if data, ok, err := s.cache.Get(ctx, key); err != nil {
+		s.logger.WarnContext(ctx, "User cache read failed", slog.String("key", key), slog.Any("error", err))
+	} else if ok {
+		var user models.User
+		if err := json.Unmarshal(data, &user); err == nil {
+			return &user, nil
+		}
+	}
+
+	user, err := s.UserStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(user); err == nil {
+		if err := s.cache.Set(ctx, key, data, s.ttl); err != nil {
+			s.logger.WarnContext(ctx, "User cache write failed", slog.String("key", key), slog.Any("error", err))
+		}
+	}
+	return user, nil
+}
+
+// Update updates the underlying store and invalidates the cached entry.
+func (s *CachedUserStore) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	if err := s.UserStore.Update(ctx, id, version, fields); err != nil {
+		return err
+	}
+	s.Invalidate(ctx, id)
+	return nil
+}
+
+// Delete deletes from the underlying store and invalidates the cached entry.
+func (s *CachedUserStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.UserStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.Invalidate(ctx, id)
+	return nil
+}
+
+// Invalidate drops the cached entry for the user with the given ID.
+func (s *CachedUserStore) Invalidate(ctx context.Context, id primitive.ObjectID) {
+	key := userCacheKey(tenant.FromContext(ctx), id)
+	if err := s.cache.Delete(ctx, key); err != nil {
+		s.logger.WarnContext(ctx, "User cache invalidation failed", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+// InvalidateTenants drops the cached entries for the user with the given ID in each of
+// tenants, for changes whose tenant is not known.
+func (s *CachedUserStore) InvalidateTenants(ctx context.Context, id primitive.ObjectID, tenants []string) {
+	keys := make([]string, len(tenants))
+	for i, tenantID := range tenants {
+		keys[i] = userCacheKey(tenantID, id)
+	}
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		s.logger.WarnContext(ctx, "User cache invalidation failed", slog.String("user", id.Hex()), slog.Any("error", err))
+	}
+}
+
+func userCacheKey(tenantID string, id primitive.ObjectID) string {
+	return "users:" + tenantID + ":" + id.Hex()
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CountingUserStore keeps the number of users of each tenant, in total and per role, in front of
+// another UserStore, so listing all users or the users of one role does not count them every
+// time. The counts are taken with counter, kept up to date as users are created through the
+// store, and taken again once they are ttl old, or after a delete or role change the store
+// cannot account for. Changes made through other instances show once the counts are retaken.
+type CountingUserStore struct {
+	UserStore
+	counter UserStatsStore
+	ttl     time.Duration
+
+	// mu also makes concurrent lists wait for a single count
+	mu sync.Mutex
+	// counts holds the counts of each tenant
+	counts map[string]*userTotals
+}
+
+// userTotals are the user counts of a tenant that lists use.
+type userTotals struct {
+	total     int64
+	byRole    map[string]int64
+	countedAt time.Time
+}
+
+func NewCountingUserStore(store UserStore, counter UserStatsStore, ttl time.Duration) *CountingUserStore {
+	return &CountingUserStore{
+		UserStore: store,
+		counter:   counter,
+		ttl:       ttl,
+		counts:    make(map[string]*userTotals),
+	}
+}
+
+var _ UserStore = (*CountingUserStore)(nil)
+var _ UserStatsStore = (*CountingUserStore)(nil)
+
+// List answers lists of every user or of the users of one role with the kept count, and
+// counts the matches of any other list as the underlying store does.
+func (s *CountingUserStore) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	if opts.Filter.Email != "" || opts.Where != nil || !opts.AfterID.IsZero() || opts.IDs != nil || opts.SkipTotal {
+		return s.UserStore.List(ctx, opts)
+	}
+	totals, err := s.totals(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts.SkipTotal = true
+	users, _, err := s.UserStore.List(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if opts.Filter.Role != "" {
+		return users, totals.byRole[opts.Filter.Role], nil
+	}
+	return users, totals.total, nil
+}
+
+// CountUsers counts users with counter and keeps the totals for lists.
+func (s *CountingUserStore) CountUsers(ctx context.Context, since []time.Time) (*UserCounts, error) {
+	counts, err := s.counter.CountUsers(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.counts[tenant.FromContext(ctx)] = newUserTotals(counts)
+	s.mu.Unlock()
+	return counts, nil
+}
+
+// Create creates the user in the underlying store and counts it.
+func (s *CountingUserStore) Create(ctx context.Context, user *models.User) error {
+	if err := s.UserStore.Create(ctx, user); err != nil {
+		return err
+	}
+	s.add(ctx, []*models.User{user})
+	return nil
+}
+
+// CreateMany creates the users in the underlying store and counts those created. After a
+// failure of the batch as a whole, the users are counted again instead.
+func (s *CountingUserStore) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs, err := s.UserStore.CreateMany(ctx, users)
+	if err != nil {
+		s.forget(ctx)
+		return errs, err
+	}
+	var created []*models.User
+	for i, user := range users {
+		if errs[i] == nil {
+			created = append(created, user)
+		}
+	}
+	s.add(ctx, created)
+	return errs, nil
+}
+
+// Update updates the underlying store. A change of role has the users counted again, since the
+// role the user had is not known.
+func (s *CountingUserStore) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	if err := s.UserStore.Update(ctx, id, version, fields); err != nil {
+		return err
+	}
+	if _, ok := fields["role"]; ok {
+		s.forget(ctx)
+	}
+	return nil
+}
+
+// Delete deletes from the underlying store and has the users counted again, since the role the
+// user had is not known.
+func (s *CountingUserStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.UserStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.forget(ctx)
+	return nil
+}
+
+// totals returns the counts of the tenant of ctx, taking them when they are missing or ttl old.
+func (s *CountingUserStore) totals(ctx context.Context) (*userTotals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenantID := tenant.FromContext(ctx)
+	if totals := s.counts[tenantID]; totals != nil && time.Since(totals.countedAt) < s.ttl {
+		return totals, nil
+	}
+	counts, err := s.counter.CountUsers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	totals := newUserTotals(counts)
+	s.counts[tenantID] = totals
+	return totals, nil
+}
+
+// add counts users in the tenant of ctx, if its counts are kept.
+func (s *CountingUserStore) add(ctx context.Context, users []*models.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totals := s.counts[tenant.FromContext(ctx)]
+	if totals == nil {
+		return
+	}
+	// Lists hold on to the totals they were given, so they are replaced rather than changed
+	updated := &userTotals{total: totals.total, byRole: make(map[string]int64, len(totals.byRole)), countedAt: totals.countedAt}
+	for role, n := range totals.byRole {
+		updated.byRole[role] = n
+	}
+	for _, user := range users {
+		updated.total++
+		updated.byRole[user.Role]++
+	}
+	s.counts[tenant.FromContext(ctx)] = updated
+}
+
+// forget drops the counts of the tenant of ctx, so the next list takes them again.
+func (s *CountingUserStore) forget(ctx context.Context) {
+	s.mu.Lock()
+	delete(s.counts, tenant.FromContext(ctx))
+	s.mu.Unlock()
+}
+
+func newUserTotals(counts *UserCounts) *userTotals {
+	return &userTotals{total: counts.Total, byRole: counts.ByRole, countedAt: time.Now()}
+}
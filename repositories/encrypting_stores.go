@@ -0,0 +1,311 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"example_api/encryption"
+	models "example_api/models"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The stores in this file keep the copies other stores make of user emails and phone numbers
+// encrypted under the keyring of EncryptingUserStore. Values stored before encryption was
+// turned on are read as they are.
+
+// EncryptingAuditStore encrypts the email and phone number values of the changes audit entries
+// record, the way EncryptingUserStore stores them, and decrypts them as entries are listed.
+type EncryptingAuditStore struct {
+	AuditStore
+	keys *encryption.Keyring
+}
+
+func NewEncryptingAuditStore(store AuditStore, keys *encryption.Keyring) *EncryptingAuditStore {
+	return &EncryptingAuditStore{AuditStore: store, keys: keys}
+}
+
+var _ AuditStore = (*EncryptingAuditStore)(nil)
+
+// Add stores entry with its email and phone number changes encrypted.
+func (s *EncryptingAuditStore) Add(ctx context.Context, entry *models.AuditEntry) error {
+	changes := entry.Changes
+	defer func() { entry.Changes = changes }()
+	entry.Changes = make([]models.FieldChange, len(changes))
+	for i, change := range changes {
+		if slices.Contains(encryptedFields, change.Field) {
+			change.Before, change.After = s.encrypt(change.Field, change.Before), s.encrypt(change.Field, change.After)
+		}
+		entry.Changes[i] = change
+	}
+	return s.AuditStore.Add(ctx, entry)
+}
+
+// List returns the matching entries with their email and phone number changes decrypted.
+func (s *EncryptingAuditStore) List(ctx context.Context, filter AuditFilter, skip, limit int64) ([]models.AuditEntry, int64, error) {
+	entries, total, err := s.AuditStore.List(ctx, filter, skip, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range entries {
+		for j := range entries[i].Changes {
+			change := &entries[i].Changes[j]
+			if !slices.Contains(encryptedFields, change.Field) {
+				continue
+			}
+			if change.Before, err = s.decrypt(change.Field, change.Before); err != nil {
+				return nil, 0, fmt.Errorf("failed to decrypt audit entry %s: %w", entries[i].Id.Hex(), err)
+			}
+			if change.After, err = s.decrypt(change.Field, change.After); err != nil {
+				return nil, 0, fmt.Errorf("failed to decrypt audit entry %s: %w", entries[i].Id.Hex(), err)
+			}
+		}
+	}
+	return entries, total, nil
+}
+
+func (s *EncryptingAuditStore) encrypt(field string, value interface{}) interface{} {
+	if text, ok := value.(string); ok {
+		return s.keys.Encrypt(field, text)
+	}
+	return value
+}
+
+func (s *EncryptingAuditStore) decrypt(field string, value interface{}) (interface{}, error) {
+	if text, ok := value.(string); ok {
+		return s.keys.Decrypt(field, text)
+	}
+	return value, nil
+}
+
+// EncryptingOutboxStore seals the payloads of outbox events until they are published.
+type EncryptingOutboxStore struct {
+	OutboxStore
+	keys *encryption.Keyring
+}
+
+func NewEncryptingOutboxStore(store OutboxStore, keys *encryption.Keyring) *EncryptingOutboxStore {
+	return &EncryptingOutboxStore{OutboxStore: store, keys: keys}
+}
+
+var _ OutboxStore = (*EncryptingOutboxStore)(nil)
+
+// Add stores event with its payload sealed.
+func (s *EncryptingOutboxStore) Add(ctx context.Context, event *models.OutboxEvent) error {
+	data := event.Data
+	defer func() { event.Data = data }()
+	sealed, err := s.keys.Seal("outbox", data)
+	if err != nil {
+		return err
+	}
+	event.Data = sealed
+	return s.OutboxStore.Add(ctx, event)
+}
+
+// Pending returns the unpublished events with their payloads opened.
+func (s *EncryptingOutboxStore) Pending(ctx context.Context, limit int64) ([]models.OutboxEvent, error) {
+	events, err := s.OutboxStore.Pending(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if events[i].Data, err = s.keys.Open("outbox", events[i].Data); err != nil {
+			return nil, fmt.Errorf("failed to open outbox event %s: %w", events[i].Id.Hex(), err)
+		}
+	}
+	return events, nil
+}
+
+// EncryptingIdempotencyStore seals the responses kept for replaying requests.
+type EncryptingIdempotencyStore struct {
+	IdempotencyStore
+	keys *encryption.Keyring
+}
+
+func NewEncryptingIdempotencyStore(store IdempotencyStore, keys *encryption.Keyring) *EncryptingIdempotencyStore {
+	return &EncryptingIdempotencyStore{IdempotencyStore: store, keys: keys}
+}
+
+var _ IdempotencyStore = (*EncryptingIdempotencyStore)(nil)
+
+// Reserve claims key, opening the response of the existing record when it is already claimed.
+func (s *EncryptingIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	record, reserved, err := s.IdempotencyStore.Reserve(ctx, key, fingerprint, ttl)
+	if err != nil || record == nil {
+		return record, reserved, err
+	}
+	if record.Body, err = s.keys.Open("idempotency", record.Body); err != nil {
+		return nil, false, fmt.Errorf("failed to open idempotent response: %w", err)
+	}
+	return record, reserved, nil
+}
+
+// Complete stores the response for key with its body sealed.
+func (s *EncryptingIdempotencyStore) Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error {
+	sealed, err := s.keys.Seal("idempotency", body)
+	if err != nil {
+		return err
+	}
+	return s.IdempotencyStore.Complete(ctx, key, status, header, sealed)
+}
+
+// EncryptingJobStore seals the payloads of jobs, such as the events webhook deliveries send,
+// storing each as a JSON string.
+type EncryptingJobStore struct {
+	JobStore
+	keys *encryption.Keyring
+}
+
+func NewEncryptingJobStore(store JobStore, keys *encryption.Keyring) *EncryptingJobStore {
+	return &EncryptingJobStore{JobStore: store, keys: keys}
+}
+
+var _ JobStore = (*EncryptingJobStore)(nil)
+
+// Enqueue stores job with its payload sealed.
+func (s *EncryptingJobStore) Enqueue(ctx context.Context, job *models.Job) error {
+	payload := job.Payload
+	defer func() { job.Payload = payload }()
+	sealed, err := s.keys.Seal("job", payload)
+	if err != nil {
+		return err
+	}
+	if job.Payload, err = json.Marshal(string(sealed)); err != nil {
+		return err
+	}
+	return s.JobStore.Enqueue(ctx, job)
+}
+
+// Claim leases a due job and opens its payload.
+func (s *EncryptingJobStore) Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*models.Job, error) {
+	job, err := s.JobStore.Claim(ctx, types, now, leaseUntil)
+	if err != nil || job == nil {
+		return job, err
+	}
+	return job, s.open(job)
+}
+
+// GetByID returns the job with its payload opened.
+func (s *EncryptingJobStore) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Job, error) {
+	job, err := s.JobStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return job, s.open(job)
+}
+
+// List returns one page of the matching jobs with their payloads opened.
+func (s *EncryptingJobStore) List(ctx context.Context, filter JobFilter, skip, limit int64) ([]models.Job, int64, error) {
+	jobs, total, err := s.JobStore.List(ctx, filter, skip, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range jobs {
+		if err := s.open(&jobs[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return jobs, total, nil
+}
+
+// Requeue makes a dead job pending again and returns it with its payload opened.
+func (s *EncryptingJobStore) Requeue(ctx context.Context, id primitive.ObjectID, at time.Time) (*models.Job, error) {
+	job, err := s.JobStore.Requeue(ctx, id, at)
+	if err != nil {
+		return nil, err
+	}
+	return job, s.open(job)
+}
+
+// open replaces the sealed payload of job with its plaintext. Payloads that are not a sealed
+// JSON string were stored unsealed.
+func (s *EncryptingJobStore) open(job *models.Job) error {
+	var sealed string
+	if err := json.Unmarshal(job.Payload, &sealed); err != nil || !encryption.Encrypted(sealed) {
+		return nil
+	}
+	payload, err := s.keys.Open("job", []byte(sealed))
+	if err != nil {
+		return fmt.Errorf("failed to open the payload of job %s: %w", job.Id.Hex(), err)
+	}
+	job.Payload = payload
+	return nil
+}
+
+// EncryptingExportStore seals export files.
+type EncryptingExportStore struct {
+	ExportStore
+	keys *encryption.Keyring
+}
+
+func NewEncryptingExportStore(store ExportStore, keys *encryption.Keyring) *EncryptingExportStore {
+	return &EncryptingExportStore{ExportStore: store, keys: keys}
+}
+
+var _ ExportStore = (*EncryptingExportStore)(nil)
+
+// Put stores data sealed. The file keeps the size of the plaintext.
+func (s *EncryptingExportStore) Put(ctx context.Context, file *models.ExportFile, data []byte) error {
+	sealed, err := s.keys.Seal("export", data)
+	if err != nil {
+		return err
+	}
+	return s.ExportStore.Put(ctx, file, sealed)
+}
+
+// Get returns the export file and a reader for its opened bytes, which are read into memory.
+func (s *EncryptingExportStore) Get(ctx context.Context, id primitive.ObjectID) (*models.ExportFile, io.ReadCloser, error) {
+	file, body, err := s.ExportStore.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+	sealed, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := s.keys.Open("export", sealed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open export %s: %w", id.Hex(), err)
+	}
+	file.Size = int64(len(data))
+	return file, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// EncryptingPhoneCodeStore encrypts the phone numbers verification codes were sent to, the way
+// EncryptingUserStore stores them.
+type EncryptingPhoneCodeStore struct {
+	PhoneCodeStore
+	keys *encryption.Keyring
+}
+
+func NewEncryptingPhoneCodeStore(store PhoneCodeStore, keys *encryption.Keyring) *EncryptingPhoneCodeStore {
+	return &EncryptingPhoneCodeStore{PhoneCodeStore: store, keys: keys}
+}
+
+var _ PhoneCodeStore = (*EncryptingPhoneCodeStore)(nil)
+
+// Put stores code with its phone number encrypted.
+func (s *EncryptingPhoneCodeStore) Put(ctx context.Context, code *PhoneCode) error {
+	phone := code.Phone
+	defer func() { code.Phone = phone }()
+	code.Phone = s.keys.Encrypt("phone", phone)
+	return s.PhoneCodeStore.Put(ctx, code)
+}
+
+// Get returns the user's code with its phone number decrypted.
+func (s *EncryptingPhoneCodeStore) Get(ctx context.Context, userID primitive.ObjectID) (*PhoneCode, error) {
+	code, err := s.PhoneCodeStore.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if code.Phone, err = s.keys.Decrypt("phone", code.Phone); err != nil {
+		return nil, fmt.Errorf("failed to decrypt the phone of the code of user %s: %w", userID.Hex(), err)
+	}
+	return code, nil
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"example_api/encryption"
+	models "example_api/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEncryptingAuditStore(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryAuditRepository()
+	store := NewEncryptingAuditStore(backend, newTestKeyring(t, "k1"))
+	changes := []models.FieldChange{
+		{Field: "email", Before: "ada@example.com", After: "augusta@example.com"},
+		{Field: "phone", Before: nil, After: "+15555550100"},
+		{Field: "firstName", Before: "Ada", After: "Augusta"},
+	}
+	entry := &models.AuditEntry{Id: primitive.NewObjectID(), Time: time.Now(), Action: models.AuditUserUpdated, TargetID: primitive.NewObjectID(), Changes: changes}
+	if err := store.Add(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Changes[0].After != "augusta@example.com" {
+		t.Fatalf("Add left %v in the entry, want the plaintext", entry.Changes[0].After)
+	}
+
+	raw, _, err := backend.List(ctx, AuditFilter{}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range raw[0].Changes[:2] {
+		for _, value := range []interface{}{change.Before, change.After} {
+			if text, ok := value.(string); ok && !encryption.Encrypted(text) {
+				t.Fatalf("the log holds the %s %q in plaintext", change.Field, text)
+			}
+		}
+	}
+	if raw[0].Changes[2].After != "Augusta" {
+		t.Fatalf("the log holds the first name %v, want it as it is", raw[0].Changes[2].After)
+	}
+
+	listed, _, err := store.List(ctx, AuditFilter{}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, change := range listed[0].Changes {
+		if change != changes[i] {
+			t.Fatalf("listed %+v, want %+v", change, changes[i])
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	"example_api/encryption"
+	models "example_api/models"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// encryptedFields are the user fields EncryptingUserStore encrypts, by their JSON names.
+var encryptedFields = []string{"email", "phone"}
+
+var (
+	// ErrEncryptedFilter is returned when a filter expression matches part of an encrypted field.
+	ErrEncryptedFilter = apperrors.Validation("Encrypted fields such as email can only be filtered with = and !=")
+	// ErrEncryptedSort is returned when sorting by an encrypted field.
+	ErrEncryptedSort = apperrors.Validation("Encrypted fields such as email cannot be sorted by")
+)
+
+// EncryptingUserStore encrypts the email and phone number of users before they reach the
+// wrapped store and decrypts them as they are read, so the database only holds ciphertext.
+// Lookups by exact email still work, since a value always encrypts the same way under one key,
+// and match values stored under every key of the keyring and values stored before encryption
+// was turned on. Filters on part of an email and sorting by email do not; they fail with
+// ErrEncryptedFilter and ErrEncryptedSort. It must wrap the storage backend directly, so the
+// stores around it see plaintext.
+type EncryptingUserStore struct {
+	UserStore
+	keys *encryption.Keyring
+}
+
+func NewEncryptingUserStore(store UserStore, keys *encryption.Keyring) *EncryptingUserStore {
+	return &EncryptingUserStore{
+		UserStore: store,
+		keys:      keys,
+	}
+}
+
+// Create encrypts the user's fields and stores it. A unique index only catches emails taken
+// under the current key, so the user is first looked up by every other stored form.
+func (s *EncryptingUserStore) Create(ctx context.Context, user *models.User) error {
+	if err := s.checkEmail(ctx, user.Email, primitive.NilObjectID); err != nil {
+		return err
+	}
+	email, phone := user.Email, user.Phone
+	defer func() { user.Email, user.Phone = email, phone }()
+	user.Email, user.Phone = s.keys.Encrypt("email", email), s.keys.Encrypt("phone", phone)
+	return s.UserStore.Create(ctx, user)
+}
+
+// CreateMany encrypts the users' fields and stores them, checking every email the way Create
+// does.
+func (s *EncryptingUserStore) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs := make([]error, len(users))
+	var pending []*models.User
+	var positions []int
+	for i, user := range users {
+		if err := s.checkEmail(ctx, user.Email, primitive.NilObjectID); errors.Is(err, ErrEmailTaken) {
+			errs[i] = err
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		pending = append(pending, user)
+		positions = append(positions, i)
+	}
+
+	plaintext := make([][2]string, len(pending))
+	for i, user := range pending {
+		plaintext[i] = [2]string{user.Email, user.Phone}
+		user.Email, user.Phone = s.keys.Encrypt("email", user.Email), s.keys.Encrypt("phone", user.Phone)
+	}
+	defer func() {
+		for i, user := range pending {
+			user.Email, user.Phone = plaintext[i][0], plaintext[i][1]
+		}
+	}()
+	created, err := s.UserStore.CreateMany(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	for i, position := range positions {
+		errs[position] = created[i]
+	}
+	return errs, nil
+}
+
+// GetByID loads the user and decrypts its fields.
+func (s *EncryptingUserStore) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := s.UserStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decrypt(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Update encrypts the email and phone number among fields, checking a new email the way
+// Create does.
+func (s *EncryptingUserStore) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	encrypted := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if text, ok := value.(string); ok && slices.Contains(encryptedFields, name) {
+			if name == "email" {
+				if err := s.checkEmail(ctx, text, id); err != nil {
+					return err
+				}
+			}
+			value = s.keys.Encrypt(name, text)
+		}
+		encrypted[name] = value
+	}
+	return s.UserStore.Update(ctx, id, version, encrypted)
+}
+
+// List matches an exact email against every form it may be stored in and decrypts the users
+// found.
+func (s *EncryptingUserStore) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	for _, field := range opts.Sort {
+		if slices.Contains(encryptedFields, field.Field) {
+			return nil, 0, ErrEncryptedSort
+		}
+	}
+	var where []FilterExpr
+	if opts.Where != nil {
+		expr, err := s.encryptFilterExpr(*opts.Where)
+		if err != nil {
+			return nil, 0, err
+		}
+		where = append(where, expr)
+	}
+	if opts.Filter.Email != "" {
+		where = append(where, s.storedForms(FilterExpr{Field: "email", Op: FilterEq, Value: opts.Filter.Email}))
+		opts.Filter.Email = ""
+	}
+	switch len(where) {
+	case 0:
+	case 1:
+		opts.Where = &where[0]
+	default:
+		opts.Where = &FilterExpr{And: where}
+	}
+
+	users, total, err := s.UserStore.List(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range users {
+		if err := s.decrypt(&users[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return users, total, nil
+}
+
+// Reencrypt encrypts under the current key the fields of every user in the tenant of ctx that
+// are stored unencrypted or under another key, calling done with the ID of each user it
+// changes. Users changed meanwhile are skipped and counted; running it again picks them up.
+func (s *EncryptingUserStore) Reencrypt(ctx context.Context, done func(id primitive.ObjectID)) (changed, skipped int, err error) {
+	var after primitive.ObjectID
+	for {
+		users, _, err := s.UserStore.List(ctx, ListOptions{Limit: 500, AfterID: after, Fields: []string{"id", "email", "phone", "version"}})
+		if err != nil {
+			return changed, skipped, err
+		}
+		if len(users) == 0 {
+			return changed, skipped, nil
+		}
+		after = users[len(users)-1].Id
+
+		for i := range users {
+			user := &users[i]
+			fields := map[string]interface{}{}
+			for name, value := range map[string]string{"email": user.Email, "phone": user.Phone} {
+				if s.keys.Current(value) {
+					continue
+				}
+				plaintext, err := s.keys.Decrypt(name, value)
+				if err != nil {
+					return changed, skipped, fmt.Errorf("failed to decrypt the %s of user %s: %w", name, user.Id.Hex(), err)
+				}
+				fields[name] = s.keys.Encrypt(name, plaintext)
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			err := s.UserStore.Update(ctx, user.Id, user.Version, fields)
+			switch {
+			case errors.Is(err, ErrVersionConflict), errors.Is(err, ErrUserNotFound):
+				skipped++
+			case err != nil:
+				return changed, skipped, fmt.Errorf("failed to re-encrypt user %s: %w", user.Id.Hex(), err)
+			default:
+				changed++
+				done(user.Id)
+			}
+		}
+	}
+}
+
+// checkEmail returns ErrEmailTaken when a user other than id has email stored in a form other
+// than the current one, which the unique index on emails does not compare it to.
+func (s *EncryptingUserStore) checkEmail(ctx context.Context, email string, id primitive.ObjectID) error {
+	if email == "" {
+		return nil
+	}
+	others := s.storedForms(FilterExpr{Field: "email", Op: FilterEq, Value: email})
+	others.Or = others.Or[1:]
+	users, _, err := s.UserStore.List(ctx, ListOptions{Limit: 2, Where: &others, Fields: []string{"id"}})
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Id != id {
+			return ErrEmailTaken
+		}
+	}
+	return nil
+}
+
+// encryptFilterExpr rewrites comparisons of encrypted fields in expr to compare every form
+// their value may be stored in.
+func (s *EncryptingUserStore) encryptFilterExpr(expr FilterExpr) (FilterExpr, error) {
+	if len(expr.And) > 0 || len(expr.Or) > 0 {
+		rewritten := FilterExpr{And: make([]FilterExpr, len(expr.And)), Or: make([]FilterExpr, len(expr.Or))}
+		for i := range expr.And {
+			operand, err := s.encryptFilterExpr(expr.And[i])
+			if err != nil {
+				return FilterExpr{}, err
+			}
+			rewritten.And[i] = operand
+		}
+		for i := range expr.Or {
+			operand, err := s.encryptFilterExpr(expr.Or[i])
+			if err != nil {
+				return FilterExpr{}, err
+			}
+			rewritten.Or[i] = operand
+		}
+		return rewritten, nil
+	}
+	if !slices.Contains(encryptedFields, expr.Field) {
+		return expr, nil
+	}
+	if _, ok := expr.Value.(string); !ok || (expr.Op != FilterEq && expr.Op != FilterNe) {
+		return FilterExpr{}, ErrEncryptedFilter
+	}
+	return s.storedForms(expr), nil
+}
+
+// storedForms turns the comparison expr, which is = or !=, into one of every form its value
+// may be stored in: a disjunction of = or a conjunction of !=, current form first.
+func (s *EncryptingUserStore) storedForms(expr FilterExpr) FilterExpr {
+	var forms []FilterExpr
+	for _, value := range s.keys.Stored(expr.Field, expr.Value.(string)) {
+		forms = append(forms, FilterExpr{Field: expr.Field, Op: expr.Op, Value: value})
+	}
+	if expr.Op == FilterNe {
+		return FilterExpr{And: forms}
+	}
+	return FilterExpr{Or: forms}
+}
+
+// decrypt replaces the encrypted fields of user with their plaintext.
+func (s *EncryptingUserStore) decrypt(user *models.User) error {
+	var err error
+	if user.Email, err = s.keys.Decrypt("email", user.Email); err != nil {
+		return fmt.Errorf("failed to decrypt the email of user %s: %w", user.Id.Hex(), err)
+	}
+	if user.Phone, err = s.keys.Decrypt("phone", user.Phone); err != nil {
+		return fmt.Errorf("failed to decrypt the phone of user %s: %w", user.Id.Hex(), err)
+	}
+	return nil
+}
+
+// EncryptingUserSearchStore decrypts the users found by another UserSearchStore. Encrypted
+// emails cannot be searched, so only the names of users match; hits the backend finds in
+// ciphertext are dropped.
+type EncryptingUserSearchStore struct {
+	search UserSearchStore
+	store  *EncryptingUserStore
+}
+
+func NewEncryptingUserSearchStore(search UserSearchStore, store *EncryptingUserStore) *EncryptingUserSearchStore {
+	return &EncryptingUserSearchStore{search: search, store: store}
+}
+
+var _ UserSearchStore = (*EncryptingUserSearchStore)(nil)
+
+// SearchUsers searches the wrapped store and decrypts the users found, keeping those whose
+// names every term of query starts a word of.
+func (s *EncryptingUserSearchStore) SearchUsers(ctx context.Context, query string, limit int64) ([]UserMatch, error) {
+	matches, err := s.search.SearchUsers(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	terms := SearchTerms(query)
+	kept := matches[:0]
+	for _, match := range matches {
+		words := SearchTerms(match.User.FirstName + " " + match.User.LastName)
+		if !slices.ContainsFunc(terms, func(term string) bool {
+			return !slices.ContainsFunc(words, func(word string) bool { return strings.HasPrefix(word, term) })
+		}) {
+			if err := s.store.decrypt(&match.User); err != nil {
+				return nil, err
+			}
+			kept = append(kept, match)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"example_api/encryption"
+	models "example_api/models"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestKeyring(t *testing.T, ids ...string) *encryption.Keyring {
+	t.Helper()
+	var keys []encryption.Key
+	for _, id := range ids {
+		keys = append(keys, encryption.Key{ID: id, Secret: bytes.Repeat([]byte(id[:1]), encryption.KeySize)})
+	}
+	keyring, err := encryption.NewKeyring(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func TestEncryptingUserStoreFindsUserByEmail(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryUserRepository()
+	// Ada signed up before encryption was turned on, Bob under the first key, and Cy under
+	// the current one
+	ada := &models.User{Id: primitive.NewObjectID(), Email: "ada@example.com", Phone: "+15555550100"}
+	if err := backend.Create(ctx, ada); err != nil {
+		t.Fatal(err)
+	}
+	bob := &models.User{Id: primitive.NewObjectID(), Email: "bob@example.com"}
+	if err := NewEncryptingUserStore(backend, newTestKeyring(t, "k1")).Create(ctx, bob); err != nil {
+		t.Fatal(err)
+	}
+	store := NewEncryptingUserStore(backend, newTestKeyring(t, "k2", "k1"))
+	cy := &models.User{Id: primitive.NewObjectID(), Email: "cy@example.com", Phone: "+15555550101"}
+	if err := store.Create(ctx, cy); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := backend.GetByID(ctx, cy.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stored.Email, "enc:k2:") || !strings.HasPrefix(stored.Phone, "enc:k2:") {
+		t.Fatalf("stored email %q and phone %q, want them encrypted under k2", stored.Email, stored.Phone)
+	}
+	if cy.Email != "cy@example.com" {
+		t.Fatalf("Create left the email %q on the user, want the plaintext", cy.Email)
+	}
+
+	tests := []struct {
+		name  string
+		email string
+		want  *models.User
+	}{
+		{"stored unencrypted", "ada@example.com", ada},
+		{"stored under an older key", "bob@example.com", bob},
+		{"stored under the current key", "cy@example.com", cy},
+		{"unknown", "dee@example.com", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := store.List(ctx, ListOptions{Filter: UserFilter{Email: tt.email}, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				if total != 0 {
+					t.Fatalf("found %d users, want none", total)
+				}
+				return
+			}
+			if total != 1 || users[0].Id != tt.want.Id {
+				t.Fatalf("found %d users, want %s", total, tt.want.Id.Hex())
+			}
+			if users[0].Email != tt.want.Email || users[0].Phone != tt.want.Phone {
+				t.Fatalf("got email %q and phone %q, want %q and %q", users[0].Email, users[0].Phone, tt.want.Email, tt.want.Phone)
+			}
+		})
+	}
+
+	// Emails stored in any form are taken
+	for _, email := range []string{"ada@example.com", "bob@example.com", "cy@example.com"} {
+		if err := store.Create(ctx, &models.User{Id: primitive.NewObjectID(), Email: email}); !errors.Is(err, ErrEmailTaken) {
+			t.Fatalf("got error %v signing up as %s again, want ErrEmailTaken", err, email)
+		}
+	}
+}
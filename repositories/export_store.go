@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportRetention is how long the files of background exports can be downloaded.
+const ExportRetention = 24 * time.Hour
+
+// ErrExportNotFound is returned when no export file matches the requested ID, or it expired.
+var ErrExportNotFound = apperrors.NotFound("Export not found")
+
+// ExportStore keeps the files export jobs produce, in the tenant of the context, for
+// ExportRetention.
+type ExportStore interface {
+	// Put stores data as the file of the export, replacing any previous one, and purges files
+	// older than ExportRetention.
+	Put(ctx context.Context, file *models.ExportFile, data []byte) error
+	// Get returns the export file and a reader for its bytes, which the caller must close, or
+	// ErrExportNotFound.
+	Get(ctx context.Context, id primitive.ObjectID) (*models.ExportFile, io.ReadCloser, error)
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FeatureFlagRepository stores feature flags in the feature_flags collection, keyed by their
+// key.
+type FeatureFlagRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFeatureFlagRepository(db *mongodb.Database) *FeatureFlagRepository {
+	return &FeatureFlagRepository{
+		collection: db.Collection("feature_flags"),
+	}
+}
+
+var _ FeatureFlagStore = (*FeatureFlagRepository)(nil)
+
+// List returns every flag ordered by key.
+func (repo *FeatureFlagRepository) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	cursor, err := repo.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	flags := []models.FeatureFlag{}
+	if err := cursor.All(ctx, &flags); err != nil {
+		return nil, fmt.Errorf("failed to decode feature flags: %w", err)
+	}
+	return flags, nil
+}
+
+// GetByKey returns the flag with the given key.
+func (repo *FeatureFlagRepository) GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	err := repo.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&flag)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrFeatureFlagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag: %w", err)
+	}
+	return &flag, nil
+}
+
+// Put replaces the flag's document, inserting it if there is none.
+func (repo *FeatureFlagRepository) Put(ctx context.Context, flag *models.FeatureFlag) error {
+	if _, err := repo.collection.ReplaceOne(ctx, bson.M{"_id": flag.Key}, flag, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to store feature flag: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the flag with the given key.
+func (repo *FeatureFlagRepository) Delete(ctx context.Context, key string) error {
+	result, err := repo.collection.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrFeatureFlagNotFound
+	}
+	return nil
+}
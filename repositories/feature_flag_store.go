@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+)
+
+// ErrFeatureFlagNotFound is returned when no feature flag has the requested key.
+var ErrFeatureFlagNotFound = apperrors.NotFound("Feature flag not found")
+
+// FeatureFlagStore persists feature flags. Flags are shared by every tenant, so the tenant of
+// the context is ignored.
+type FeatureFlagStore interface {
+	// List returns every flag ordered by key.
+	List(ctx context.Context) ([]models.FeatureFlag, error)
+	GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error)
+	// Put creates the flag with the key of flag, or replaces it.
+	Put(ctx context.Context, flag *models.FeatureFlag) error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	models "example_api/models"
+	"strings"
+	"time"
+)
+
+// FilterOp is a comparison operator of a filter expression.
+type FilterOp string
+
+// Filter expression operators
+const (
+	FilterEq  FilterOp = "="
+	FilterNe  FilterOp = "!="
+	FilterGt  FilterOp = ">"
+	FilterGte FilterOp = ">="
+	FilterLt  FilterOp = "<"
+	FilterLte FilterOp = "<="
+	// FilterContains matches strings containing the value, ignoring case
+	FilterContains FilterOp = "~"
+)
+
+// FilterExpr is a parsed filter expression. It is either the conjunction of And, the
+// disjunction of Or, or, when both are empty, the comparison Field Op Value. Field is the JSON
+// name of a user field, or metadata.{key} for a metadata key, and Value a string, a time.Time,
+// or an int64 to match its type.
+type FilterExpr struct {
+	And   []FilterExpr
+	Or    []FilterExpr
+	Field string
+	Op    FilterOp
+	Value interface{}
+}
+
+// matchesFilterExpr reports whether user satisfies expr.
+func matchesFilterExpr(user *models.User, expr *FilterExpr) bool {
+	if len(expr.And) > 0 {
+		for i := range expr.And {
+			if !matchesFilterExpr(user, &expr.And[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if len(expr.Or) > 0 {
+		for i := range expr.Or {
+			if matchesFilterExpr(user, &expr.Or[i]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var c int
+	switch value := expr.Value.(type) {
+	case string:
+		var field string
+		switch expr.Field {
+		case "email":
+			field = user.Email
+		case "firstName":
+			field = user.FirstName
+		case "lastName":
+			field = user.LastName
+		case "role":
+			field = user.Role
+		default:
+			// Only string values compare, and a missing key is empty
+			if key, ok := strings.CutPrefix(expr.Field, "metadata."); ok {
+				field, _ = user.Metadata[key].(string)
+			}
+		}
+		if expr.Op == FilterContains {
+			return strings.Contains(strings.ToLower(field), strings.ToLower(value))
+		}
+		c = strings.Compare(field, value)
+	case time.Time:
+		c = user.JoinDate.Compare(value)
+	case int64:
+		c = compareInt64(user.Version, value)
+	}
+	switch expr.Op {
+	case FilterEq:
+		return c == 0
+	case FilterNe:
+		return c != 0
+	case FilterGt:
+		return c > 0
+	case FilterGte:
+		return c >= 0
+	case FilterLt:
+		return c < 0
+	case FilterLte:
+		return c <= 0
+	}
+	return false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
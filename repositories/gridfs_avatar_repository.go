@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// avatarBucket is the GridFS bucket name, so avatars live in avatars.files and avatars.chunks, after
+// the collection prefix.
+const avatarBucket = "avatars"
+
+// GridFSAvatarRepository stores avatars in MongoDB GridFS, one file per upload and variant,
+// named after the user's ID. A new upload is written before older ones are removed, so readers
+// always find one.
+type GridFSAvatarRepository struct {
+	db *mongodb.Database
+}
+
+func NewGridFSAvatarRepository(db *mongodb.Database) *GridFSAvatarRepository {
+	return &GridFSAvatarRepository{
+		db: db,
+	}
+}
+
+var _ AvatarStore = (*GridFSAvatarRepository)(nil)
+
+// avatarFile is the part of a GridFS files document the repository reads.
+type avatarFile struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Length     int64              `bson:"length"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Metadata   struct {
+		ContentType string `bson:"contentType"`
+		ETag        string `bson:"etag"`
+	} `bson:"metadata"`
+}
+
+// bucket returns a bucket whose uploads and downloads end at ctx's deadline. GridFS keeps
+// deadlines on the bucket rather than taking a context, so every call gets its own.
+func (repo *GridFSAvatarRepository) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(repo.db.Database, options.GridFSBucket().SetName(repo.db.Name(avatarBucket)))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		bucket.SetReadDeadline(deadline)
+		bucket.SetWriteDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+// Put uploads data as a new file and then removes the user's older files of the same variant.
+func (repo *GridFSAvatarRepository) Put(ctx context.Context, avatar *models.Avatar, data []byte) error {
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open avatar bucket: %w", err)
+	}
+
+	// ObjectIDs grow over time, so of two concurrent uploads the later one survives
+	fileID := primitive.NewObjectID()
+	metadata := bson.M{"variant": avatar.Variant, "contentType": avatar.ContentType, "etag": avatar.ETag}
+	err = bucket.UploadFromStreamWithID(fileID, avatar.UserID.Hex(), bytes.NewReader(data), options.GridFSUpload().SetMetadata(metadata))
+	if err != nil {
+		return fmt.Errorf("failed to upload avatar: %w", err)
+	}
+	return repo.deleteFiles(ctx, bucket, bson.M{"filename": avatar.UserID.Hex(), "metadata.variant": avatar.Variant, "_id": bson.M{"$lt": fileID}})
+}
+
+// Get opens the user's newest file of the variant.
+func (repo *GridFSAvatarRepository) Get(ctx context.Context, userID primitive.ObjectID, variant string) (*models.Avatar, io.ReadCloser, error) {
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open avatar bucket: %w", err)
+	}
+
+	findOptions := options.GridFSFind().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(1)
+	cursor, err := bucket.FindContext(ctx, bson.M{"filename": userID.Hex(), "metadata.variant": variant}, findOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find avatar: %w", err)
+	}
+	var files []avatarFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode avatar: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, nil, ErrAvatarNotFound
+	}
+
+	file := files[0]
+	stream, err := bucket.OpenDownloadStream(file.ID)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		// Replaced by a concurrent upload; its replacement is now the newest
+		return repo.Get(ctx, userID, variant)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open avatar: %w", err)
+	}
+	return &models.Avatar{
+		UserID:      userID,
+		Variant:     variant,
+		ContentType: file.Metadata.ContentType,
+		Size:        file.Length,
+		ETag:        file.Metadata.ETag,
+		UpdatedAt:   file.UploadDate,
+	}, stream, nil
+}
+
+// Delete removes every file of the user.
+func (repo *GridFSAvatarRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open avatar bucket: %w", err)
+	}
+	return repo.deleteFiles(ctx, bucket, bson.M{"filename": userID.Hex()})
+}
+
+func (repo *GridFSAvatarRepository) deleteFiles(ctx context.Context, bucket *gridfs.Bucket, filter bson.M) error {
+	cursor, err := bucket.FindContext(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to find avatars: %w", err)
+	}
+	var files []avatarFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return fmt.Errorf("failed to decode avatars: %w", err)
+	}
+	for _, file := range files {
+		if err := bucket.DeleteContext(ctx, file.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return fmt.Errorf("failed to delete avatar: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// exportBucket is the GridFS bucket name, so exports live in exports.files and exports.chunks, after
+// the collection prefix.
+const exportBucket = "exports"
+
+// GridFSExportRepository stores export files in MongoDB GridFS under the ID of their job, so
+// files larger than a document fit.
+type GridFSExportRepository struct {
+	db *mongodb.Database
+}
+
+func NewGridFSExportRepository(db *mongodb.Database) *GridFSExportRepository {
+	return &GridFSExportRepository{
+		db: db,
+	}
+}
+
+var _ ExportStore = (*GridFSExportRepository)(nil)
+
+// exportFile is the part of a GridFS files document the repository reads.
+type exportFile struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Filename   string             `bson:"filename"`
+	Length     int64              `bson:"length"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Metadata   struct {
+		ContentType string `bson:"contentType"`
+	} `bson:"metadata"`
+}
+
+// bucket returns a bucket whose uploads and downloads end at ctx's deadline.
+func (repo *GridFSExportRepository) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(repo.db.Database, options.GridFSBucket().SetName(repo.db.Name(exportBucket)))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		bucket.SetReadDeadline(deadline)
+		bucket.SetWriteDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+// Put replaces the file of the export with data and then removes expired files.
+func (repo *GridFSExportRepository) Put(ctx context.Context, file *models.ExportFile, data []byte) error {
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open export bucket: %w", err)
+	}
+
+	// A retried job uploads again under the same ID
+	if err := bucket.DeleteContext(ctx, file.Id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return fmt.Errorf("failed to replace export: %w", err)
+	}
+	metadata := bson.M{"contentType": file.ContentType}
+	if id, ok := mongoTenant(ctx).(string); ok {
+		metadata["tenantId"] = id
+	}
+	err = bucket.UploadFromStreamWithID(file.Id, file.Filename, bytes.NewReader(data), options.GridFSUpload().SetMetadata(metadata))
+	if err != nil {
+		return fmt.Errorf("failed to upload export: %w", err)
+	}
+
+	cursor, err := bucket.FindContext(ctx, bson.M{"uploadDate": bson.M{"$lt": time.Now().Add(-ExportRetention)}})
+	if err != nil {
+		return fmt.Errorf("failed to find expired exports: %w", err)
+	}
+	var expired []exportFile
+	if err := cursor.All(ctx, &expired); err != nil {
+		return fmt.Errorf("failed to decode expired exports: %w", err)
+	}
+	for _, old := range expired {
+		if err := bucket.DeleteContext(ctx, old.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return fmt.Errorf("failed to delete expired export: %w", err)
+		}
+	}
+	return nil
+}
+
+// Get opens the file of the export.
+func (repo *GridFSExportRepository) Get(ctx context.Context, id primitive.ObjectID) (*models.ExportFile, io.ReadCloser, error) {
+	bucket, err := repo.bucket(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open export bucket: %w", err)
+	}
+
+	filter := bson.M{"_id": id, "metadata.tenantId": mongoTenant(ctx), "uploadDate": bson.M{"$gte": time.Now().Add(-ExportRetention)}}
+	cursor, err := bucket.FindContext(ctx, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find export: %w", err)
+	}
+	var files []exportFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode export: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, nil, ErrExportNotFound
+	}
+
+	file := files[0]
+	stream, err := bucket.OpenDownloadStream(file.ID)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	return &models.ExportFile{
+		Id:          file.ID,
+		Filename:    file.Filename,
+		ContentType: file.Metadata.ContentType,
+		Size:        file.Length,
+		CreatedAt:   file.UploadDate,
+	}, stream, nil
+}
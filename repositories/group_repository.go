@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GroupRepository stores groups and their members in MongoDB, in separate collections. A
+// unique index on the group and user of members rules out duplicates and serves member pages
+// in user order.
+type GroupRepository struct {
+	groups  *mongo.Collection
+	members *mongo.Collection
+}
+
+func NewGroupRepository(db *mongodb.Database) *GroupRepository {
+	return &GroupRepository{
+		groups:  db.Collection("groups"),
+		members: db.Collection("group_members"),
+	}
+}
+
+var _ GroupStore = (*GroupRepository)(nil)
+
+// mongoGroup is the stored form of a group. TenantID is empty for the default tenant.
+type mongoGroup struct {
+	models.Group `bson:",inline"`
+	TenantID     string `bson:"tenantId,omitempty"`
+}
+
+// mongoGroupMember is the stored form of a group member. TenantID is empty for the default
+// tenant.
+type mongoGroupMember struct {
+	models.GroupMember `bson:",inline"`
+	TenantID           string `bson:"tenantId,omitempty"`
+}
+
+// Create inserts a new group document in the tenant of ctx.
+func (repo *GroupRepository) Create(ctx context.Context, group *models.Group) error {
+	id, _ := mongoTenant(ctx).(string)
+	if _, err := repo.groups.InsertOne(ctx, mongoGroup{Group: *group, TenantID: id}); err != nil {
+		return fmt.Errorf("failed to insert group: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the group with the given ID, or ErrGroupNotFound.
+func (repo *GroupRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+	var group models.Group
+	err := repo.groups.FindOne(ctx, mongoScoped(ctx, bson.M{"_id": id})).Decode(&group)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find group: %w", err)
+	}
+	return &group, nil
+}
+
+// Replace overwrites the group document with the same ID.
+func (repo *GroupRepository) Replace(ctx context.Context, group *models.Group) error {
+	id, _ := mongoTenant(ctx).(string)
+	result, err := repo.groups.ReplaceOne(ctx, mongoScoped(ctx, bson.M{"_id": group.Id}), mongoGroup{Group: *group, TenantID: id})
+	if err != nil {
+		return fmt.Errorf("failed to update group: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// Delete removes the group with the given ID and its members.
+func (repo *GroupRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.groups.DeleteOne(ctx, mongoScoped(ctx, bson.M{"_id": id})); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	if _, err := repo.members.DeleteMany(ctx, mongoScoped(ctx, bson.M{"groupId": id})); err != nil {
+		return fmt.Errorf("failed to delete group members: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of groups ordered by ID, along with the total number of groups.
+func (repo *GroupRepository) List(ctx context.Context, skip, limit int64) ([]models.Group, int64, error) {
+	filter := mongoScoped(ctx, bson.M{})
+	total, err := repo.groups.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count groups: %w", err)
+	}
+	groups, err := repo.findGroups(ctx, filter, skip, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// AddMembers inserts the members in the tenant of ctx. The insert is unordered, so members
+// already in their group are reported by the unique index and skipped without stopping the
+// rest.
+func (repo *GroupRepository) AddMembers(ctx context.Context, members []models.GroupMember) (int64, error) {
+	id, _ := mongoTenant(ctx).(string)
+	documents := make([]interface{}, len(members))
+	for i, member := range members {
+		documents[i] = mongoGroupMember{GroupMember: member, TenantID: id}
+	}
+
+	_, err := repo.members.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(writeErr) {
+				return 0, fmt.Errorf("failed to insert group members: %w", err)
+			}
+		}
+		return int64(len(members) - len(bulkErr.WriteErrors)), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert group members: %w", err)
+	}
+	return int64(len(members)), nil
+}
+
+// RemoveMember deletes the user's member document of the group.
+func (repo *GroupRepository) RemoveMember(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	result, err := repo.members.DeleteOne(ctx, mongoScoped(ctx, bson.M{"groupId": groupID, "userId": userID}))
+	if err != nil {
+		return fmt.Errorf("failed to delete group member: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrGroupMemberNotFound
+	}
+	return nil
+}
+
+// ListMembers returns one page of the group's members ordered by user ID.
+func (repo *GroupRepository) ListMembers(ctx context.Context, groupID primitive.ObjectID, skip, limit int64) ([]models.GroupMember, int64, error) {
+	filter := mongoScoped(ctx, bson.M{"groupId": groupID})
+	total, err := repo.members.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count group members: %w", err)
+	}
+
+	cursor, err := repo.members.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "userId", Value: 1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list group members: %w", err)
+	}
+	members := []models.GroupMember{}
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode group members: %w", err)
+	}
+	return members, total, nil
+}
+
+// ListUserGroups returns one page of the groups the user is in ordered by ID. The page is
+// taken from the user's member documents, which are ordered by group ID through the userId
+// index, and then the groups are read.
+func (repo *GroupRepository) ListUserGroups(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Group, int64, error) {
+	filter := mongoScoped(ctx, bson.M{"userId": userID})
+	total, err := repo.members.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count user groups: %w", err)
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "groupId", Value: 1}}).SetSkip(skip).SetLimit(limit).SetProjection(bson.M{"groupId": 1})
+	cursor, err := repo.members.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list user groups: %w", err)
+	}
+	var members []models.GroupMember
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode user groups: %w", err)
+	}
+	if len(members) == 0 {
+		return []models.Group{}, total, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(members))
+	for i, member := range members {
+		ids[i] = member.GroupID
+	}
+	groups, err := repo.findGroups(ctx, mongoScoped(ctx, bson.M{"_id": bson.M{"$in": ids}}), 0, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// DeleteUserMembers removes every member document of the user.
+func (repo *GroupRepository) DeleteUserMembers(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.members.DeleteMany(ctx, mongoScoped(ctx, bson.M{"userId": userID})); err != nil {
+		return fmt.Errorf("failed to delete user group members: %w", err)
+	}
+	return nil
+}
+
+func (repo *GroupRepository) findGroups(ctx context.Context, filter bson.M, skip, limit int64) ([]models.Group, error) {
+	cursor, err := repo.groups.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	groups := []models.Group{}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode groups: %w", err)
+	}
+	return groups, nil
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrGroupNotFound is returned when no group matches the requested ID.
+	ErrGroupNotFound = apperrors.NotFound("Group not found")
+	// ErrGroupMemberNotFound is returned when the user is not a member of the group.
+	ErrGroupMemberNotFound = apperrors.NotFound("User is not a member of the group")
+)
+
+// GroupStore persists groups and their members in the tenant of the context. Members are
+// stored one record per user apart from the group, so large groups are paged through an index
+// rather than loaded whole. Deleting a group also deletes its members.
+type GroupStore interface {
+	Create(ctx context.Context, group *models.Group) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Group, error)
+	// Replace overwrites the stored group with the same ID, or returns ErrGroupNotFound.
+	Replace(ctx context.Context, group *models.Group) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// List returns one page of groups ordered by ID, along with the total number of groups.
+	List(ctx context.Context, skip, limit int64) ([]models.Group, int64, error)
+
+	// AddMembers stores the members, skipping users already in their group, and returns how
+	// many were added.
+	AddMembers(ctx context.Context, members []models.GroupMember) (int64, error)
+	// RemoveMember takes the user out of the group, or returns ErrGroupMemberNotFound.
+	RemoveMember(ctx context.Context, groupID, userID primitive.ObjectID) error
+	// ListMembers returns one page of the group's members ordered by user ID, along with
+	// their total number.
+	ListMembers(ctx context.Context, groupID primitive.ObjectID, skip, limit int64) ([]models.GroupMember, int64, error)
+	// ListUserGroups returns one page of the groups the user is in ordered by ID, along with
+	// their total number. A limit of 0 returns them all.
+	ListUserGroups(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Group, int64, error)
+	// DeleteUserMembers takes a deleted user out of every group.
+	DeleteUserMembers(ctx context.Context, userID primitive.ObjectID) error
+}
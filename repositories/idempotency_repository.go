@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"example_api/mongodb"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IdempotencyRepository stores idempotency records in MongoDB. A TTL index on expiresAt
+// removes them once they expire.
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *mongodb.Database) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		collection: db.Collection("idempotency_keys"),
+	}
+}
+
+var _ IdempotencyStore = (*IdempotencyRepository)(nil)
+
+// Reserve inserts a pending record for key, or returns the record that already holds it.
+func (repo *IdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	now := time.Now()
+	record := IdempotencyRecord{Key: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+
+	_, err := repo.collection.InsertOne(ctx, record)
+	if err == nil {
+		return &record, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	// The TTL monitor only runs once a minute, so take over records that expired in the meantime
+	result, err := repo.collection.ReplaceOne(ctx, bson.M{"_id": key, "expiresAt": bson.M{"$lte": now}}, record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return &record, true, nil
+	}
+
+	var existing IdempotencyRecord
+	err = repo.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// The holder released the key between our insert and lookup; let the client retry
+		return nil, false, fmt.Errorf("idempotency key %q was released concurrently", key)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+	return &existing, false, nil
+}
+
+// Complete stores the response for key.
+func (repo *IdempotencyRepository) Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error {
+	_, err := repo.collection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{
+		"completed": true,
+		"status":    status,
+		"header":    header,
+		"body":      body,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes expired records, which the TTL index would otherwise remove within a minute or
+// so.
+func (repo *IdempotencyRepository) Purge(ctx context.Context, now time.Time) (int64, error) {
+	result, err := repo.collection.DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lte": now}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Release deletes the record for key.
+func (repo *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := repo.collection.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
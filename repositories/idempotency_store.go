@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRecord remembers one request made with an Idempotency-Key and, once it has
+// completed, the response that was sent for it.
+type IdempotencyRecord struct {
+	Key         string      `bson:"_id"`
+	Fingerprint string      `bson:"fingerprint"`
+	Completed   bool        `bson:"completed"`
+	Status      int         `bson:"status,omitempty"`
+	Header      http.Header `bson:"header,omitempty"`
+	Body        []byte      `bson:"body,omitempty"`
+	CreatedAt   time.Time   `bson:"createdAt"`
+	ExpiresAt   time.Time   `bson:"expiresAt"`
+}
+
+// IdempotencyStore persists idempotency records until they expire.
+type IdempotencyStore interface {
+	// Reserve claims key for a request with the given fingerprint until ttl passes. If the key
+	// is already claimed, it returns the existing record and false instead.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Complete stores the response for a reserved key.
+	Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error
+	// Release drops a reservation so the request can be retried.
+	Release(ctx context.Context, key string) error
+	// Purge deletes the records that expired by now and returns how many it deleted.
+	Purge(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"example_api/tenant"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobRepository stores the job queue in the jobs collection, where a TTL index enforces
+// JobRetention on finished jobs.
+type JobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewJobRepository(db *mongodb.Database) *JobRepository {
+	return &JobRepository{
+		collection: db.Collection("jobs"),
+	}
+}
+
+var _ JobStore = (*JobRepository)(nil)
+
+// mongoJob is the stored form of a job. TenantID is empty for the default tenant.
+type mongoJob struct {
+	models.Job `bson:",inline"`
+	TenantID   string `bson:"tenantId,omitempty"`
+}
+
+func (job mongoJob) model() *models.Job {
+	job.Job.Tenant = tenant.Default
+	if job.TenantID != "" {
+		job.Job.Tenant = job.TenantID
+	}
+	return &job.Job
+}
+
+// Enqueue inserts a new job document in the tenant of ctx.
+func (repo *JobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	id, _ := mongoTenant(ctx).(string)
+	if _, err := repo.collection.InsertOne(ctx, mongoJob{Job: *job, TenantID: id}); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// Claim leases the longest due job of one of types in a single atomic update.
+func (repo *JobRepository) Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*models.Job, error) {
+	filter := bson.M{
+		"status": bson.M{"$in": bson.A{models.JobPending, models.JobRunning}},
+		"type":   bson.M{"$in": types},
+		"runAt":  bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobRunning, "runAt": leaseUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "runAt", Value: 1}}).SetReturnDocument(options.After)
+
+	var job mongoJob
+	err := repo.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job.model(), nil
+}
+
+// Release stores the outcome of a claimed job, unless it has been claimed again since.
+func (repo *JobRepository) Release(ctx context.Context, job *models.Job, attempt int) error {
+	set := bson.M{"status": job.Status, "attempts": job.Attempts, "runAt": job.RunAt, "lastError": job.LastError}
+	update := bson.M{"$set": set}
+	if job.FinishedAt != nil {
+		set["finishedAt"] = *job.FinishedAt
+	}
+	filter := bson.M{"_id": job.Id, "status": models.JobRunning, "attempts": attempt}
+	result, err := repo.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to release job: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// GetByID returns the job with the given ID, or ErrJobNotFound.
+func (repo *JobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Job, error) {
+	var job mongoJob
+	err := repo.collection.FindOne(ctx, mongoScoped(ctx, bson.M{"_id": id})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job: %w", err)
+	}
+	return job.model(), nil
+}
+
+// List returns one page of the jobs matching filter, newest first.
+func (repo *JobRepository) List(ctx context.Context, filter JobFilter, skip, limit int64) ([]models.Job, int64, error) {
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	query = mongoScoped(ctx, query)
+	total, err := repo.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	cursor, err := repo.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	var stored []mongoJob
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode jobs: %w", err)
+	}
+	jobs := make([]models.Job, len(stored))
+	for i, job := range stored {
+		jobs[i] = *job.model()
+	}
+	return jobs, total, nil
+}
+
+// Requeue makes a dead job pending again.
+func (repo *JobRepository) Requeue(ctx context.Context, id primitive.ObjectID, at time.Time) (*models.Job, error) {
+	filter := mongoScoped(ctx, bson.M{"_id": id, "status": models.JobDead})
+	update := bson.M{
+		"$set":   bson.M{"status": models.JobPending, "attempts": 0, "runAt": at},
+		"$unset": bson.M{"finishedAt": ""},
+	}
+	var job mongoJob
+	err := repo.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := repo.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobNotDead
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue job: %w", err)
+	}
+	return job.model(), nil
+}
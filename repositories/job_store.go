@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	models "example_api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobRetention is how long finished jobs, succeeded or dead, are kept.
+const JobRetention = 14 * 24 * time.Hour
+
+var (
+	// ErrJobNotFound is returned when no job matches the requested ID.
+	ErrJobNotFound = apperrors.NotFound("Job not found")
+	// ErrJobNotDead is returned when retrying a job that is not in the dead letter queue.
+	ErrJobNotDead = apperrors.Conflict("Only dead jobs can be retried")
+	// ErrJobLeaseLost is returned when a worker releases a job whose lease ran out and which
+	// another worker has claimed since.
+	ErrJobLeaseLost = errors.New("job lease lost")
+)
+
+// JobFilter restricts List to jobs matching every non-empty field.
+type JobFilter struct {
+	Status string
+	Type   string
+}
+
+// JobStore persists the job queue. Workers claim and release jobs of every tenant; the other
+// methods only see jobs of the tenant of the context.
+type JobStore interface {
+	// Enqueue stores a new job in the tenant of ctx.
+	Enqueue(ctx context.Context, job *models.Job) error
+	// Claim leases the job of one of types that has been due the longest to a worker until
+	// leaseUntil, marking it running and counting an attempt, and returns it with its tenant.
+	// Running jobs whose lease has ended are due again. It returns nil when no job is due.
+	Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*models.Job, error)
+	// Release stores the outcome of the given claimed attempt of a job: its status, attempts,
+	// run time, last error, and finish time. It returns ErrJobLeaseLost when the job has been
+	// claimed again since, and purges jobs finished longer than JobRetention ago.
+	Release(ctx context.Context, job *models.Job, attempt int) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Job, error)
+	// List returns one page of the jobs matching filter, newest first, along with their total
+	// number.
+	List(ctx context.Context, filter JobFilter, skip, limit int64) ([]models.Job, int64, error)
+	// Requeue makes a dead job pending again as of at, with its attempts reset, and returns it,
+	// or ErrJobNotDead.
+	Requeue(ctx context.Context, id primitive.ObjectID, at time.Time) (*models.Job, error)
+}
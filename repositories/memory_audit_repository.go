@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAuditRepository keeps the audit log in process memory, oldest entry first.
+type MemoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []memoryAuditEntry
+}
+
+// memoryAuditEntry is an audit entry with the ID of its tenant.
+type memoryAuditEntry struct {
+	models.AuditEntry
+	tenant string
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+var _ AuditStore = (*MemoryAuditRepository)(nil)
+
+// Add appends a copy of entry to the tenant of ctx.
+func (repo *MemoryAuditRepository) Add(ctx context.Context, entry *models.AuditEntry) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored := memoryAuditEntry{AuditEntry: *entry, tenant: tenant.FromContext(ctx)}
+	stored.Changes = slices.Clone(entry.Changes)
+	repo.entries = append(repo.entries, stored)
+	return nil
+}
+
+// Erase overwrites the before and after values of the named fields in the user's entries.
+func (repo *MemoryAuditRepository) Erase(ctx context.Context, targetID primitive.ObjectID, fields []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tenantID := tenant.FromContext(ctx)
+	for i := range repo.entries {
+		if repo.entries[i].TargetID != targetID || repo.entries[i].tenant != tenantID {
+			continue
+		}
+		for j := range repo.entries[i].Changes {
+			if change := &repo.entries[i].Changes[j]; slices.Contains(fields, change.Field) {
+				change.Before = models.AuditErased
+				change.After = models.AuditErased
+			}
+		}
+	}
+	return nil
+}
+
+// Purge drops the entries of every tenant older than before.
+func (repo *MemoryAuditRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	count := len(repo.entries)
+	repo.entries = slices.DeleteFunc(repo.entries, func(entry memoryAuditEntry) bool {
+		return entry.Time.Before(before)
+	})
+	return int64(count - len(repo.entries)), nil
+}
+
+// List returns one page of the entries matching filter, newest first.
+func (repo *MemoryAuditRepository) List(ctx context.Context, filter AuditFilter, skip, limit int64) ([]models.AuditEntry, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenantID := tenant.FromContext(ctx)
+	matches := []models.AuditEntry{}
+	for i := len(repo.entries) - 1; i >= 0; i-- {
+		if entry := repo.entries[i].AuditEntry; repo.entries[i].tenant == tenantID && matchesAudit(&entry, filter) {
+			entry.Changes = slices.Clone(entry.Changes)
+			matches = append(matches, entry)
+		}
+	}
+	return pageOf(matches, skip, limit), int64(len(matches)), nil
+}
+
+func matchesAudit(entry *models.AuditEntry, filter AuditFilter) bool {
+	return (filter.Actor == "" || entry.Actor == filter.Actor) &&
+		(filter.Action == "" || entry.Action == filter.Action) &&
+		(filter.TargetID.IsZero() || entry.TargetID == filter.TargetID) &&
+		(filter.From.IsZero() || !entry.Time.Before(filter.From)) &&
+		(filter.To.IsZero() || entry.Time.Before(filter.To))
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	models "example_api/models"
+	"io"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAvatarRepository keeps avatars in process memory.
+type MemoryAvatarRepository struct {
+	mu      sync.RWMutex
+	avatars map[memoryAvatarKey]memoryAvatar
+}
+
+type memoryAvatarKey struct {
+	userID  primitive.ObjectID
+	variant string
+}
+
+type memoryAvatar struct {
+	avatar models.Avatar
+	data   []byte
+}
+
+func NewMemoryAvatarRepository() *MemoryAvatarRepository {
+	return &MemoryAvatarRepository{
+		avatars: make(map[memoryAvatarKey]memoryAvatar),
+	}
+}
+
+var _ AvatarStore = (*MemoryAvatarRepository)(nil)
+
+// Put stores copies of avatar and data.
+func (repo *MemoryAvatarRepository) Put(ctx context.Context, avatar *models.Avatar, data []byte) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.avatars[memoryAvatarKey{avatar.UserID, avatar.Variant}] = memoryAvatar{avatar: *avatar, data: bytes.Clone(data)}
+	return nil
+}
+
+// Get returns the variant of the user's avatar, or ErrAvatarNotFound.
+func (repo *MemoryAvatarRepository) Get(ctx context.Context, userID primitive.ObjectID, variant string) (*models.Avatar, io.ReadCloser, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.avatars[memoryAvatarKey{userID, variant}]
+	if !ok {
+		return nil, nil, ErrAvatarNotFound
+	}
+	// Put never modifies stored data in place, so readers can share it
+	return &stored.avatar, io.NopCloser(bytes.NewReader(stored.data)), nil
+}
+
+// Delete removes every variant of the user's avatar.
+func (repo *MemoryAvatarRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for key := range repo.avatars {
+		if key.userID == userID {
+			delete(repo.avatars, key)
+		}
+	}
+	return nil
+}
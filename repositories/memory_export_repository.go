@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"io"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryExportRepository keeps export files in process memory.
+type MemoryExportRepository struct {
+	mu    sync.RWMutex
+	files map[primitive.ObjectID]memoryExport
+}
+
+func NewMemoryExportRepository() *MemoryExportRepository {
+	return &MemoryExportRepository{
+		files: make(map[primitive.ObjectID]memoryExport),
+	}
+}
+
+var _ ExportStore = (*MemoryExportRepository)(nil)
+
+// memoryExport is an export file with its bytes and the ID of its tenant.
+type memoryExport struct {
+	models.ExportFile
+	data   []byte
+	tenant string
+}
+
+// Put stores a copy of data as the export file and drops files older than ExportRetention.
+func (repo *MemoryExportRepository) Put(ctx context.Context, file *models.ExportFile, data []byte) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	cutoff := time.Now().Add(-ExportRetention)
+	for id, stored := range repo.files {
+		if stored.CreatedAt.Before(cutoff) {
+			delete(repo.files, id)
+		}
+	}
+	repo.files[file.Id] = memoryExport{ExportFile: *file, data: bytes.Clone(data), tenant: tenant.FromContext(ctx)}
+	return nil
+}
+
+// Get returns the export file and its bytes, or ErrExportNotFound.
+func (repo *MemoryExportRepository) Get(ctx context.Context, id primitive.ObjectID) (*models.ExportFile, io.ReadCloser, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.files[id]
+	if !ok || stored.tenant != tenant.FromContext(ctx) || stored.CreatedAt.Before(time.Now().Add(-ExportRetention)) {
+		return nil, nil, ErrExportNotFound
+	}
+	file := stored.ExportFile
+	return &file, io.NopCloser(bytes.NewReader(stored.data)), nil
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryFeatureFlagRepository keeps feature flags in process memory.
+type MemoryFeatureFlagRepository struct {
+	mu    sync.RWMutex
+	flags map[string]models.FeatureFlag
+}
+
+func NewMemoryFeatureFlagRepository() *MemoryFeatureFlagRepository {
+	return &MemoryFeatureFlagRepository{
+		flags: make(map[string]models.FeatureFlag),
+	}
+}
+
+var _ FeatureFlagStore = (*MemoryFeatureFlagRepository)(nil)
+
+// List returns copies of every flag ordered by key.
+func (repo *MemoryFeatureFlagRepository) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	flags := make([]models.FeatureFlag, 0, len(repo.flags))
+	for _, flag := range repo.flags {
+		flags = append(flags, cloneFeatureFlag(flag))
+	}
+	slices.SortFunc(flags, func(a, b models.FeatureFlag) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return flags, nil
+}
+
+// GetByKey returns a copy of the flag with the given key.
+func (repo *MemoryFeatureFlagRepository) GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	flag, ok := repo.flags[key]
+	if !ok {
+		return nil, ErrFeatureFlagNotFound
+	}
+	flag = cloneFeatureFlag(flag)
+	return &flag, nil
+}
+
+// Put stores a copy of flag under its key.
+func (repo *MemoryFeatureFlagRepository) Put(ctx context.Context, flag *models.FeatureFlag) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.flags[flag.Key] = cloneFeatureFlag(*flag)
+	return nil
+}
+
+// Delete removes the flag with the given key.
+func (repo *MemoryFeatureFlagRepository) Delete(ctx context.Context, key string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.flags[key]; !ok {
+		return ErrFeatureFlagNotFound
+	}
+	delete(repo.flags, key)
+	return nil
+}
+
+// cloneFeatureFlag returns a copy of flag that shares no tenant overrides with it.
+func cloneFeatureFlag(flag models.FeatureFlag) models.FeatureFlag {
+	flag.Tenants = maps.Clone(flag.Tenants)
+	return flag
+}
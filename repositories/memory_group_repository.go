@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryGroupRepository keeps groups and their members in process memory. Members are indexed
+// both by group and by user, so neither lookup scans every member.
+type MemoryGroupRepository struct {
+	mu     sync.RWMutex
+	groups map[primitive.ObjectID]memoryGroup
+	// members maps group IDs to the group's members by user ID
+	members map[primitive.ObjectID]map[primitive.ObjectID]models.GroupMember
+	// userGroups maps user IDs to the IDs of the groups they are in
+	userGroups map[primitive.ObjectID]map[primitive.ObjectID]struct{}
+}
+
+func NewMemoryGroupRepository() *MemoryGroupRepository {
+	return &MemoryGroupRepository{
+		groups:     make(map[primitive.ObjectID]memoryGroup),
+		members:    make(map[primitive.ObjectID]map[primitive.ObjectID]models.GroupMember),
+		userGroups: make(map[primitive.ObjectID]map[primitive.ObjectID]struct{}),
+	}
+}
+
+var _ GroupStore = (*MemoryGroupRepository)(nil)
+
+// memoryGroup is a group with the ID of its tenant. Members belong to the tenant of their
+// group.
+type memoryGroup struct {
+	models.Group
+	tenant string
+}
+
+// Create stores a copy of group in the tenant of ctx.
+func (repo *MemoryGroupRepository) Create(ctx context.Context, group *models.Group) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.groups[group.Id]; exists {
+		return fmt.Errorf("failed to insert group: duplicate ID %s", group.Id.Hex())
+	}
+	repo.groups[group.Id] = memoryGroup{Group: cloneGroup(*group), tenant: tenant.FromContext(ctx)}
+	return nil
+}
+
+// GetByID returns a copy of the group with the given ID, or ErrGroupNotFound.
+func (repo *MemoryGroupRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.findGroupLocked(ctx, id)
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	group := cloneGroup(stored.Group)
+	return &group, nil
+}
+
+// Replace overwrites the stored group with the same ID.
+func (repo *MemoryGroupRepository) Replace(ctx context.Context, group *models.Group) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.findGroupLocked(ctx, group.Id)
+	if !ok {
+		return ErrGroupNotFound
+	}
+	stored.Group = cloneGroup(*group)
+	repo.groups[group.Id] = stored
+	return nil
+}
+
+// Delete removes the group with the given ID and its members.
+func (repo *MemoryGroupRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.findGroupLocked(ctx, id); !ok {
+		return nil
+	}
+	delete(repo.groups, id)
+	for userID := range repo.members[id] {
+		delete(repo.userGroups[userID], id)
+	}
+	delete(repo.members, id)
+	return nil
+}
+
+// List returns one page of groups ordered by ID, along with the total number of groups.
+func (repo *MemoryGroupRepository) List(ctx context.Context, skip, limit int64) ([]models.Group, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenantID := tenant.FromContext(ctx)
+	all := []models.Group{}
+	for _, stored := range repo.groups {
+		if stored.tenant == tenantID {
+			all = append(all, cloneGroup(stored.Group))
+		}
+	}
+	sortGroups(all)
+	return pageOf(all, skip, limit), int64(len(all)), nil
+}
+
+// AddMembers stores copies of the members whose group is in the tenant of ctx, skipping users
+// already in their group.
+func (repo *MemoryGroupRepository) AddMembers(ctx context.Context, members []models.GroupMember) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var added int64
+	for _, member := range members {
+		if _, ok := repo.findGroupLocked(ctx, member.GroupID); !ok {
+			return added, ErrGroupNotFound
+		}
+		if _, exists := repo.members[member.GroupID][member.UserID]; exists {
+			continue
+		}
+		if repo.members[member.GroupID] == nil {
+			repo.members[member.GroupID] = make(map[primitive.ObjectID]models.GroupMember)
+		}
+		if repo.userGroups[member.UserID] == nil {
+			repo.userGroups[member.UserID] = make(map[primitive.ObjectID]struct{})
+		}
+		repo.members[member.GroupID][member.UserID] = member
+		repo.userGroups[member.UserID][member.GroupID] = struct{}{}
+		added++
+	}
+	return added, nil
+}
+
+// RemoveMember takes the user out of the group.
+func (repo *MemoryGroupRepository) RemoveMember(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.findGroupLocked(ctx, groupID); !ok {
+		return ErrGroupMemberNotFound
+	}
+	if _, ok := repo.members[groupID][userID]; !ok {
+		return ErrGroupMemberNotFound
+	}
+	delete(repo.members[groupID], userID)
+	delete(repo.userGroups[userID], groupID)
+	return nil
+}
+
+// ListMembers returns one page of the group's members ordered by user ID.
+func (repo *MemoryGroupRepository) ListMembers(ctx context.Context, groupID primitive.ObjectID, skip, limit int64) ([]models.GroupMember, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	all := []models.GroupMember{}
+	if _, ok := repo.findGroupLocked(ctx, groupID); ok {
+		for _, member := range repo.members[groupID] {
+			all = append(all, member)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return bytes.Compare(all[i].UserID[:], all[j].UserID[:]) < 0
+	})
+	return pageOf(all, skip, limit), int64(len(all)), nil
+}
+
+// ListUserGroups returns one page of copies of the groups the user is in ordered by ID.
+func (repo *MemoryGroupRepository) ListUserGroups(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Group, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	all := []models.Group{}
+	for groupID := range repo.userGroups[userID] {
+		if stored, ok := repo.findGroupLocked(ctx, groupID); ok {
+			all = append(all, cloneGroup(stored.Group))
+		}
+	}
+	sortGroups(all)
+	if limit == 0 {
+		limit = int64(len(all))
+	}
+	return pageOf(all, skip, limit), int64(len(all)), nil
+}
+
+// DeleteUserMembers takes the user out of every group in the tenant of ctx.
+func (repo *MemoryGroupRepository) DeleteUserMembers(ctx context.Context, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for groupID := range repo.userGroups[userID] {
+		if _, ok := repo.findGroupLocked(ctx, groupID); ok {
+			delete(repo.members[groupID], userID)
+			delete(repo.userGroups[userID], groupID)
+		}
+	}
+	return nil
+}
+
+// findGroupLocked returns the group with the given ID if it is in the tenant of ctx. The
+// caller must hold repo.mu.
+func (repo *MemoryGroupRepository) findGroupLocked(ctx context.Context, id primitive.ObjectID) (memoryGroup, bool) {
+	stored, ok := repo.groups[id]
+	if !ok || stored.tenant != tenant.FromContext(ctx) {
+		return memoryGroup{}, false
+	}
+	return stored, true
+}
+
+// cloneGroup copies group so the stored permissions are not shared with callers.
+func cloneGroup(group models.Group) models.Group {
+	group.Permissions = slices.Clone(group.Permissions)
+	return group
+}
+
+func sortGroups(groups []models.Group) {
+	sort.Slice(groups, func(i, j int) bool {
+		return bytes.Compare(groups[i].Id[:], groups[j].Id[:]) < 0
+	})
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MemoryIdempotencyRepository keeps idempotency records in process memory.
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[string]IdempotencyRecord),
+	}
+}
+
+var _ IdempotencyStore = (*MemoryIdempotencyRepository)(nil)
+
+// Reserve stores a pending record for key, or returns the unexpired record that already holds it.
+func (repo *MemoryIdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	repo.purge(now)
+
+	if existing, ok := repo.records[key]; ok {
+		return &existing, false, nil
+	}
+	record := IdempotencyRecord{Key: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	repo.records[key] = record
+	return &record, true, nil
+}
+
+// Complete stores the response for key.
+func (repo *MemoryIdempotencyRepository) Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	record, ok := repo.records[key]
+	if !ok {
+		return nil
+	}
+	record.Completed = true
+	record.Status = status
+	record.Header = header.Clone()
+	record.Body = append([]byte(nil), body...)
+	repo.records[key] = record
+	return nil
+}
+
+// Release deletes the record for key.
+func (repo *MemoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.records, key)
+	return nil
+}
+
+// Purge deletes expired records.
+func (repo *MemoryIdempotencyRepository) Purge(ctx context.Context, now time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.purge(now), nil
+}
+
+// purge deletes the records that expired by now and returns how many it deleted. The caller
+// must hold mu.
+func (repo *MemoryIdempotencyRepository) purge(now time.Time) int64 {
+	var purged int64
+	for key, record := range repo.records {
+		if !record.ExpiresAt.After(now) {
+			delete(repo.records, key)
+			purged++
+		}
+	}
+	return purged
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryJobRepository keeps the job queue in process memory, so pending jobs are lost on
+// restart.
+type MemoryJobRepository struct {
+	mu   sync.Mutex
+	jobs map[primitive.ObjectID]models.Job
+}
+
+func NewMemoryJobRepository() *MemoryJobRepository {
+	return &MemoryJobRepository{
+		jobs: make(map[primitive.ObjectID]models.Job),
+	}
+}
+
+var _ JobStore = (*MemoryJobRepository)(nil)
+
+// Enqueue stores a copy of job in the tenant of ctx.
+func (repo *MemoryJobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.jobs[job.Id]; exists {
+		return fmt.Errorf("failed to enqueue job: duplicate ID %s", job.Id.Hex())
+	}
+	stored := cloneJob(*job)
+	stored.Tenant = tenant.FromContext(ctx)
+	repo.jobs[job.Id] = stored
+	return nil
+}
+
+// Claim leases the longest due job of one of types.
+func (repo *MemoryJobRepository) Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*models.Job, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var due *models.Job
+	for id := range repo.jobs {
+		job := repo.jobs[id]
+		if (job.Status != models.JobPending && job.Status != models.JobRunning) || job.RunAt.After(now) || !slices.Contains(types, job.Type) {
+			continue
+		}
+		if due == nil || job.RunAt.Before(due.RunAt) {
+			due = &job
+		}
+	}
+	if due == nil {
+		return nil, nil
+	}
+	due.Status = models.JobRunning
+	due.RunAt = leaseUntil
+	due.Attempts++
+	repo.jobs[due.Id] = *due
+	claimed := cloneJob(*due)
+	return &claimed, nil
+}
+
+// Release stores the outcome of a claimed job, unless it has been claimed again since, and
+// drops jobs finished longer than JobRetention ago.
+func (repo *MemoryJobRepository) Release(ctx context.Context, job *models.Job, attempt int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.jobs[job.Id]
+	if !ok || stored.Status != models.JobRunning || stored.Attempts != attempt {
+		return ErrJobLeaseLost
+	}
+	stored.Status = job.Status
+	stored.Attempts = job.Attempts
+	stored.RunAt = job.RunAt
+	stored.LastError = job.LastError
+	if job.FinishedAt != nil {
+		finishedAt := *job.FinishedAt
+		stored.FinishedAt = &finishedAt
+	}
+	repo.jobs[job.Id] = stored
+
+	cutoff := time.Now().Add(-JobRetention)
+	for id, job := range repo.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(repo.jobs, id)
+		}
+	}
+	return nil
+}
+
+// GetByID returns a copy of the job with the given ID, or ErrJobNotFound.
+func (repo *MemoryJobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Job, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	job, ok := repo.jobs[id]
+	if !ok || job.Tenant != tenant.FromContext(ctx) {
+		return nil, ErrJobNotFound
+	}
+	job = cloneJob(job)
+	return &job, nil
+}
+
+// List returns one page of copies of the jobs matching filter, newest first.
+func (repo *MemoryJobRepository) List(ctx context.Context, filter JobFilter, skip, limit int64) ([]models.Job, int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	id := tenant.FromContext(ctx)
+	jobs := []models.Job{}
+	for _, job := range repo.jobs {
+		if job.Tenant != id || (filter.Status != "" && job.Status != filter.Status) || (filter.Type != "" && job.Type != filter.Type) {
+			continue
+		}
+		jobs = append(jobs, cloneJob(job))
+	}
+	slices.SortFunc(jobs, func(a, b models.Job) int {
+		return bytes.Compare(b.Id[:], a.Id[:])
+	})
+	return pageOf(jobs, skip, limit), int64(len(jobs)), nil
+}
+
+// Requeue makes a dead job pending again.
+func (repo *MemoryJobRepository) Requeue(ctx context.Context, id primitive.ObjectID, at time.Time) (*models.Job, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	job, ok := repo.jobs[id]
+	if !ok || job.Tenant != tenant.FromContext(ctx) {
+		return nil, ErrJobNotFound
+	}
+	if job.Status != models.JobDead {
+		return nil, ErrJobNotDead
+	}
+	job.Status = models.JobPending
+	job.Attempts = 0
+	job.RunAt = at
+	job.FinishedAt = nil
+	repo.jobs[id] = job
+	job = cloneJob(job)
+	return &job, nil
+}
+
+// cloneJob returns a copy of job that shares no payload or finish time with it.
+func cloneJob(job models.Job) models.Job {
+	job.Payload = slices.Clone(job.Payload)
+	if job.FinishedAt != nil {
+		finishedAt := *job.FinishedAt
+		job.FinishedAt = &finishedAt
+	}
+	return job
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryNotificationRepository keeps notifications in process memory, indexed by user in the
+// order they were created.
+type MemoryNotificationRepository struct {
+	mu sync.RWMutex
+	// notifications maps user IDs to the user's notifications, oldest first
+	notifications map[primitive.ObjectID][]memoryNotification
+}
+
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{
+		notifications: make(map[primitive.ObjectID][]memoryNotification),
+	}
+}
+
+var _ NotificationStore = (*MemoryNotificationRepository)(nil)
+
+// memoryNotification is a notification with the ID of its tenant.
+type memoryNotification struct {
+	models.Notification
+	tenant string
+}
+
+// Create stores a copy of notification in the tenant of ctx, dropping the user's notifications
+// older than NotificationRetention.
+func (repo *MemoryNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, stored := range repo.notifications[notification.UserID] {
+		if stored.Id == notification.Id {
+			return fmt.Errorf("failed to insert notification: duplicate ID %s", notification.Id.Hex())
+		}
+	}
+	cutoff := time.Now().Add(-NotificationRetention)
+	stored := slices.DeleteFunc(repo.notifications[notification.UserID], func(n memoryNotification) bool {
+		return n.CreatedAt.Before(cutoff)
+	})
+	repo.notifications[notification.UserID] = append(stored, memoryNotification{Notification: cloneNotification(*notification), tenant: tenant.FromContext(ctx)})
+	return nil
+}
+
+// List returns one page of copies of the user's notifications, newest first.
+func (repo *MemoryNotificationRepository) List(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, skip, limit int64) ([]models.Notification, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	id := tenant.FromContext(ctx)
+	stored := repo.notifications[userID]
+	notifications := []models.Notification{}
+	for i := len(stored) - 1; i >= 0; i-- {
+		if stored[i].tenant != id || (unreadOnly && stored[i].ReadAt != nil) {
+			continue
+		}
+		notifications = append(notifications, cloneNotification(stored[i].Notification))
+	}
+	return pageOf(notifications, skip, limit), int64(len(notifications)), nil
+}
+
+// MarkRead sets the read time of the user's notification unless it was read before.
+func (repo *MemoryNotificationRepository) MarkRead(ctx context.Context, userID, id primitive.ObjectID, at time.Time) (*models.Notification, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i, ok := repo.findLocked(ctx, userID, id)
+	if !ok {
+		return nil, ErrNotificationNotFound
+	}
+	stored := &repo.notifications[userID][i]
+	if stored.ReadAt == nil {
+		stored.ReadAt = &at
+	}
+	notification := cloneNotification(stored.Notification)
+	return &notification, nil
+}
+
+// MarkAllRead sets the read time of every unread notification of the user.
+func (repo *MemoryNotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	id := tenant.FromContext(ctx)
+	var read int64
+	for i := range repo.notifications[userID] {
+		stored := &repo.notifications[userID][i]
+		if stored.tenant == id && stored.ReadAt == nil {
+			stored.ReadAt = &at
+			read++
+		}
+	}
+	return read, nil
+}
+
+// Delete removes the user's notification.
+func (repo *MemoryNotificationRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i, ok := repo.findLocked(ctx, userID, id)
+	if !ok {
+		return ErrNotificationNotFound
+	}
+	repo.notifications[userID] = slices.Delete(repo.notifications[userID], i, i+1)
+	return nil
+}
+
+// DeleteUserNotifications removes every notification of the user.
+func (repo *MemoryNotificationRepository) DeleteUserNotifications(ctx context.Context, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	id := tenant.FromContext(ctx)
+	repo.notifications[userID] = slices.DeleteFunc(repo.notifications[userID], func(n memoryNotification) bool {
+		return n.tenant == id
+	})
+	if len(repo.notifications[userID]) == 0 {
+		delete(repo.notifications, userID)
+	}
+	return nil
+}
+
+// findLocked returns the index of the user's notification with the given ID in the tenant of
+// ctx. The caller must hold the lock.
+func (repo *MemoryNotificationRepository) findLocked(ctx context.Context, userID, id primitive.ObjectID) (int, bool) {
+	tenantID := tenant.FromContext(ctx)
+	i := slices.IndexFunc(repo.notifications[userID], func(n memoryNotification) bool {
+		return n.Id == id && n.tenant == tenantID
+	})
+	return i, i >= 0
+}
+
+// cloneNotification returns a copy of notification that shares no data or read time with it.
+func cloneNotification(notification models.Notification) models.Notification {
+	notification.Data = maps.Clone(notification.Data)
+	if notification.ReadAt != nil {
+		readAt := *notification.ReadAt
+		notification.ReadAt = &readAt
+	}
+	return notification
+}
@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryOrganizationRepository keeps organizations and their memberships in process memory.
+type MemoryOrganizationRepository struct {
+	mu            sync.RWMutex
+	organizations map[primitive.ObjectID]memoryOrganization
+	memberships   map[primitive.ObjectID]memoryMembership
+}
+
+func NewMemoryOrganizationRepository() *MemoryOrganizationRepository {
+	return &MemoryOrganizationRepository{
+		organizations: make(map[primitive.ObjectID]memoryOrganization),
+		memberships:   make(map[primitive.ObjectID]memoryMembership),
+	}
+}
+
+var _ OrganizationStore = (*MemoryOrganizationRepository)(nil)
+
+// memoryOrganization is an organization with the ID of its tenant.
+type memoryOrganization struct {
+	models.Organization
+	tenant string
+}
+
+// memoryMembership is a membership with the ID of its tenant.
+type memoryMembership struct {
+	models.Membership
+	tenant string
+}
+
+// Create stores a copy of org in the tenant of ctx.
+func (repo *MemoryOrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.organizations[org.Id]; exists {
+		return fmt.Errorf("failed to insert organization: duplicate ID %s", org.Id.Hex())
+	}
+	repo.organizations[org.Id] = memoryOrganization{Organization: *org, tenant: tenant.FromContext(ctx)}
+	return nil
+}
+
+// GetByID returns a copy of the organization with the given ID, or ErrOrganizationNotFound.
+func (repo *MemoryOrganizationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.organizations[id]
+	if !ok || stored.tenant != tenant.FromContext(ctx) {
+		return nil, ErrOrganizationNotFound
+	}
+	org := stored.Organization
+	return &org, nil
+}
+
+// Replace overwrites the stored organization with the same ID.
+func (repo *MemoryOrganizationRepository) Replace(ctx context.Context, org *models.Organization) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.organizations[org.Id]
+	if !ok || stored.tenant != tenant.FromContext(ctx) {
+		return ErrOrganizationNotFound
+	}
+	stored.Organization = *org
+	repo.organizations[org.Id] = stored
+	return nil
+}
+
+// Delete removes the organization with the given ID and its memberships.
+func (repo *MemoryOrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if stored, ok := repo.organizations[id]; !ok || stored.tenant != tenant.FromContext(ctx) {
+		return nil
+	}
+	delete(repo.organizations, id)
+	for membershipID, membership := range repo.memberships {
+		if membership.OrganizationID == id {
+			delete(repo.memberships, membershipID)
+		}
+	}
+	return nil
+}
+
+// List returns one page of organizations ordered by ID, along with the total number of
+// organizations.
+func (repo *MemoryOrganizationRepository) List(ctx context.Context, skip, limit int64) ([]models.Organization, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenantID := tenant.FromContext(ctx)
+	all := []models.Organization{}
+	for _, stored := range repo.organizations {
+		if stored.tenant == tenantID {
+			all = append(all, stored.Organization)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return bytes.Compare(all[i].Id[:], all[j].Id[:]) < 0
+	})
+	return pageOf(all, skip, limit), int64(len(all)), nil
+}
+
+// AddMembership stores a copy of membership in the tenant of ctx.
+func (repo *MemoryOrganizationRepository) AddMembership(ctx context.Context, membership *models.Membership) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.findMembershipLocked(ctx, membership.OrganizationID, membership.UserID); ok {
+		return ErrAlreadyMember
+	}
+	repo.memberships[membership.Id] = memoryMembership{Membership: *membership, tenant: tenant.FromContext(ctx)}
+	return nil
+}
+
+// GetMembership returns a copy of the user's membership of the organization, or
+// ErrMembershipNotFound.
+func (repo *MemoryOrganizationRepository) GetMembership(ctx context.Context, orgID, userID primitive.ObjectID) (*models.Membership, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.findMembershipLocked(ctx, orgID, userID)
+	if !ok {
+		return nil, ErrMembershipNotFound
+	}
+	membership := stored.Membership
+	return &membership, nil
+}
+
+// ReplaceMembership overwrites the stored membership with the same ID.
+func (repo *MemoryOrganizationRepository) ReplaceMembership(ctx context.Context, membership *models.Membership) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.memberships[membership.Id]
+	if !ok || stored.tenant != tenant.FromContext(ctx) {
+		return ErrMembershipNotFound
+	}
+	stored.Membership = *membership
+	repo.memberships[membership.Id] = stored
+	return nil
+}
+
+// RemoveMembership deletes the user's membership of the organization.
+func (repo *MemoryOrganizationRepository) RemoveMembership(ctx context.Context, orgID, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.findMembershipLocked(ctx, orgID, userID)
+	if !ok {
+		return ErrMembershipNotFound
+	}
+	delete(repo.memberships, stored.Id)
+	return nil
+}
+
+// ListMembers returns one page of the organization's memberships in the order they were created.
+func (repo *MemoryOrganizationRepository) ListMembers(ctx context.Context, orgID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error) {
+	return repo.listMemberships(ctx, func(m *models.Membership) bool { return m.OrganizationID == orgID }, skip, limit)
+}
+
+// ListUserMemberships returns one page of the user's memberships in the order they were created.
+func (repo *MemoryOrganizationRepository) ListUserMemberships(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error) {
+	return repo.listMemberships(ctx, func(m *models.Membership) bool { return m.UserID == userID }, skip, limit)
+}
+
+func (repo *MemoryOrganizationRepository) listMemberships(ctx context.Context, match func(*models.Membership) bool, skip, limit int64) ([]models.Membership, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenantID := tenant.FromContext(ctx)
+	all := []models.Membership{}
+	for _, stored := range repo.memberships {
+		if stored.tenant == tenantID && match(&stored.Membership) {
+			all = append(all, stored.Membership)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return bytes.Compare(all[i].Id[:], all[j].Id[:]) < 0
+	})
+	return pageOf(all, skip, limit), int64(len(all)), nil
+}
+
+// DeleteUserMemberships removes every membership of the user.
+func (repo *MemoryOrganizationRepository) DeleteUserMemberships(ctx context.Context, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tenantID := tenant.FromContext(ctx)
+	for id, stored := range repo.memberships {
+		if stored.tenant == tenantID && stored.UserID == userID {
+			delete(repo.memberships, id)
+		}
+	}
+	return nil
+}
+
+// findMembershipLocked returns the user's membership of the organization in the tenant of ctx.
+// The caller must hold repo.mu.
+func (repo *MemoryOrganizationRepository) findMembershipLocked(ctx context.Context, orgID, userID primitive.ObjectID) (memoryMembership, bool) {
+	tenantID := tenant.FromContext(ctx)
+	for _, stored := range repo.memberships {
+		if stored.tenant == tenantID && stored.OrganizationID == orgID && stored.UserID == userID {
+			return stored, true
+		}
+	}
+	return memoryMembership{}, false
+}
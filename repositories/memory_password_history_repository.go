@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryPasswordHistoryRepository keeps password histories in process memory.
+type MemoryPasswordHistoryRepository struct {
+	mu        sync.Mutex
+	histories map[primitive.ObjectID][]string
+}
+
+func NewMemoryPasswordHistoryRepository() *MemoryPasswordHistoryRepository {
+	return &MemoryPasswordHistoryRepository{
+		histories: make(map[primitive.ObjectID][]string),
+	}
+}
+
+var _ PasswordHistoryStore = (*MemoryPasswordHistoryRepository)(nil)
+
+// Add puts hash in front of the user's hashes and trims them to keep.
+func (repo *MemoryPasswordHistoryRepository) Add(ctx context.Context, userID primitive.ObjectID, hash string, keep int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	hashes := append([]string{hash}, repo.histories[userID]...)
+	if len(hashes) > keep {
+		hashes = hashes[:keep]
+	}
+	repo.histories[userID] = hashes
+	return nil
+}
+
+// List returns a copy of the user's hashes.
+func (repo *MemoryPasswordHistoryRepository) List(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return slices.Clone(repo.histories[userID]), nil
+}
+
+// Delete forgets the user's hashes.
+func (repo *MemoryPasswordHistoryRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.histories, userID)
+	return nil
+}
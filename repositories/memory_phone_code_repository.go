@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryPhoneCodeRepository keeps phone verification codes in process memory.
+type MemoryPhoneCodeRepository struct {
+	mu    sync.Mutex
+	codes map[primitive.ObjectID]PhoneCode
+}
+
+func NewMemoryPhoneCodeRepository() *MemoryPhoneCodeRepository {
+	return &MemoryPhoneCodeRepository{
+		codes: make(map[primitive.ObjectID]PhoneCode),
+	}
+}
+
+var _ PhoneCodeStore = (*MemoryPhoneCodeRepository)(nil)
+
+// Put stores the user's code.
+func (repo *MemoryPhoneCodeRepository) Put(ctx context.Context, code *PhoneCode) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.codes[code.UserID] = *code
+	return nil
+}
+
+// Get returns the user's code unless it has expired.
+func (repo *MemoryPhoneCodeRepository) Get(ctx context.Context, userID primitive.ObjectID) (*PhoneCode, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	code, ok := repo.find(userID)
+	if !ok {
+		return nil, ErrPhoneCodeNotFound
+	}
+	return &code, nil
+}
+
+// AddAttempt increments the attempts of the user's unexpired code.
+func (repo *MemoryPhoneCodeRepository) AddAttempt(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	code, ok := repo.find(userID)
+	if !ok {
+		return 0, ErrPhoneCodeNotFound
+	}
+	code.Attempts++
+	repo.codes[userID] = code
+	return code.Attempts, nil
+}
+
+// Delete removes the user's code.
+func (repo *MemoryPhoneCodeRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.codes, userID)
+	return nil
+}
+
+// find returns the user's code, deleting it instead if it has expired. The caller must
+// hold mu.
+func (repo *MemoryPhoneCodeRepository) find(userID primitive.ObjectID) (PhoneCode, bool) {
+	code, ok := repo.codes[userID]
+	if !ok {
+		return PhoneCode{}, false
+	}
+	if !code.ExpiresAt.After(time.Now()) {
+		delete(repo.codes, userID)
+		return PhoneCode{}, false
+	}
+	return code, true
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// MemoryRoleRepository keeps roles in process memory.
+type MemoryRoleRepository struct {
+	mu sync.RWMutex
+	// roles holds the roles of each tenant by name
+	roles map[string]map[string]models.Role
+}
+
+func NewMemoryRoleRepository() *MemoryRoleRepository {
+	return &MemoryRoleRepository{
+		roles: make(map[string]map[string]models.Role),
+	}
+}
+
+var _ RoleStore = (*MemoryRoleRepository)(nil)
+
+// Create stores a copy of role in the tenant of ctx.
+func (repo *MemoryRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tenantID := tenant.FromContext(ctx)
+	if _, exists := repo.roles[tenantID][role.Name]; exists {
+		return ErrRoleExists
+	}
+	if repo.roles[tenantID] == nil {
+		repo.roles[tenantID] = make(map[string]models.Role)
+	}
+	repo.roles[tenantID][role.Name] = cloneRole(*role)
+	return nil
+}
+
+// GetByName returns a copy of the role with the given name, or ErrRoleNotFound.
+func (repo *MemoryRoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	role, ok := repo.roles[tenant.FromContext(ctx)][name]
+	if !ok {
+		return nil, ErrRoleNotFound
+	}
+	role = cloneRole(role)
+	return &role, nil
+}
+
+// Replace overwrites the stored role with the same name.
+func (repo *MemoryRoleRepository) Replace(ctx context.Context, role *models.Role) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tenantID := tenant.FromContext(ctx)
+	if _, ok := repo.roles[tenantID][role.Name]; !ok {
+		return ErrRoleNotFound
+	}
+	repo.roles[tenantID][role.Name] = cloneRole(*role)
+	return nil
+}
+
+// Delete removes the role with the given name.
+func (repo *MemoryRoleRepository) Delete(ctx context.Context, name string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.roles[tenant.FromContext(ctx)], name)
+	return nil
+}
+
+// List returns one page of roles ordered by name, along with the total number of roles.
+func (repo *MemoryRoleRepository) List(ctx context.Context, skip, limit int64) ([]models.Role, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	roles := repo.roles[tenant.FromContext(ctx)]
+	all := make([]models.Role, 0, len(roles))
+	for _, role := range roles {
+		all = append(all, cloneRole(role))
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+	return pageOf(all, skip, limit), int64(len(all)), nil
+}
+
+func cloneRole(role models.Role) models.Role {
+	role.Permissions = slices.Clone(role.Permissions)
+	return role
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryTaskRunRepository keeps the history of scheduled tasks in process memory, oldest run
+// first.
+type MemoryTaskRunRepository struct {
+	mu   sync.RWMutex
+	runs []models.TaskRun
+}
+
+func NewMemoryTaskRunRepository() *MemoryTaskRunRepository {
+	return &MemoryTaskRunRepository{}
+}
+
+var _ TaskRunStore = (*MemoryTaskRunRepository)(nil)
+
+// Start appends a copy of run unless its task already has a run for the same scheduled time,
+// dropping runs older than TaskRunRetention.
+func (repo *MemoryTaskRunRepository) Start(ctx context.Context, run *models.TaskRun) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	cutoff := time.Now().Add(-TaskRunRetention)
+	repo.runs = slices.DeleteFunc(repo.runs, func(stored models.TaskRun) bool {
+		return stored.StartedAt.Before(cutoff)
+	})
+	for _, stored := range repo.runs {
+		if stored.Task == run.Task && stored.ScheduledAt.Equal(run.ScheduledAt) {
+			return false, nil
+		}
+	}
+	repo.runs = append(repo.runs, cloneTaskRun(*run))
+	return true, nil
+}
+
+// Finish stores the outcome of run.
+func (repo *MemoryTaskRunRepository) Finish(ctx context.Context, run *models.TaskRun) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i := range repo.runs {
+		if repo.runs[i].Id == run.Id {
+			repo.runs[i].Status = run.Status
+			repo.runs[i].Affected = run.Affected
+			repo.runs[i].Error = run.Error
+			repo.runs[i].FinishedAt = cloneTaskRun(*run).FinishedAt
+		}
+	}
+	return nil
+}
+
+// List returns one page of copies of the runs of task, newest first.
+func (repo *MemoryTaskRunRepository) List(ctx context.Context, task string, skip, limit int64) ([]models.TaskRun, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	matched := []models.TaskRun{}
+	for _, run := range repo.runs {
+		if run.Task == task {
+			matched = append(matched, cloneTaskRun(run))
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b models.TaskRun) int {
+		return b.ScheduledAt.Compare(a.ScheduledAt)
+	})
+	return pageOf(matched, skip, limit), int64(len(matched)), nil
+}
+
+// cloneTaskRun returns a copy of run that shares no finish time with it.
+func cloneTaskRun(run models.TaskRun) models.TaskRun {
+	if run.FinishedAt != nil {
+		finishedAt := *run.FinishedAt
+		run.FinishedAt = &finishedAt
+	}
+	return run
+}
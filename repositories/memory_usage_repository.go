@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"slices"
+	"sync"
+)
+
+// MemoryUsageRepository keeps API usage in process memory.
+type MemoryUsageRepository struct {
+	mu      sync.RWMutex
+	records map[memoryUsageKey]models.Usage
+}
+
+// memoryUsageKey identifies the record of a client and day in a tenant.
+type memoryUsageKey struct {
+	tenant string
+	client string
+	day    string
+}
+
+func NewMemoryUsageRepository() *MemoryUsageRepository {
+	return &MemoryUsageRepository{
+		records: map[memoryUsageKey]models.Usage{},
+	}
+}
+
+var _ UsageStore = (*MemoryUsageRepository)(nil)
+
+// Add increments the counts of the records in the tenant of ctx.
+func (repo *MemoryUsageRepository) Add(ctx context.Context, records []models.Usage) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tenantID := tenant.FromContext(ctx)
+	for _, record := range records {
+		key := memoryUsageKey{tenant: tenantID, client: record.Client, day: record.Day}
+		stored := repo.records[key]
+		stored.Client, stored.Day = record.Client, record.Day
+		stored.Requests += record.Requests
+		stored.BytesIn += record.BytesIn
+		stored.BytesOut += record.BytesOut
+		repo.records[key] = stored
+	}
+	return nil
+}
+
+// List returns one page of the records matching filter, newest day first.
+func (repo *MemoryUsageRepository) List(ctx context.Context, filter UsageFilter, skip, limit int64) ([]models.Usage, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenantID := tenant.FromContext(ctx)
+	matches := []models.Usage{}
+	for key, record := range repo.records {
+		if key.tenant == tenantID && matchesUsage(&record, filter) {
+			matches = append(matches, record)
+		}
+	}
+	slices.SortFunc(matches, func(a, b models.Usage) int {
+		return cmp.Or(cmp.Compare(b.Day, a.Day), cmp.Compare(a.Client, b.Client))
+	})
+	return pageOf(matches, skip, limit), int64(len(matches)), nil
+}
+
+func matchesUsage(record *models.Usage, filter UsageFilter) bool {
+	return (filter.Client == "" || record.Client == filter.Client) &&
+		(filter.From == "" || record.Day >= filter.From) &&
+		(filter.To == "" || record.Day <= filter.To)
+}
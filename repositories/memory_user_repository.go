@@ -0,0 +1,345 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserRepository keeps users in process memory. It is safe for concurrent use and
+// intended for tests and local demos; data is lost when the process exits.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]models.User
+	// tenants holds the tenant ID of every user
+	tenants map[primitive.ObjectID]string
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:   make(map[primitive.ObjectID]models.User),
+		tenants: make(map[primitive.ObjectID]string),
+	}
+}
+
+var _ UserStore = (*MemoryUserRepository)(nil)
+
+// Ping always succeeds; the in-memory store has no connection to lose.
+func (repo *MemoryUserRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Create stores a copy of user in the tenant of ctx.
+func (repo *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.users[user.Id]; exists {
+		return fmt.Errorf("failed to insert user: duplicate ID %s", user.Id.Hex())
+	}
+	tenantID := tenant.FromContext(ctx)
+	if repo.emailTakenLocked(tenantID, user.Email, user.Id) {
+		return ErrEmailTaken
+	}
+	repo.users[user.Id] = *user
+	repo.tenants[user.Id] = tenantID
+	return nil
+}
+
+// CreateMany stores a copy of each user whose ID and email are not taken yet.
+func (repo *MemoryUserRepository) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs := make([]error, len(users))
+	for i, user := range users {
+		if err := repo.Create(ctx, user); errors.Is(err, ErrEmailTaken) {
+			errs[i] = err
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return errs, nil
+}
+
+// GetByID returns a copy of the user with the given ID, or ErrUserNotFound.
+func (repo *MemoryUserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	user, ok := repo.getLocked(ctx, id)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// Update sets the given fields on the user with the given ID if it is still at version.
+func (repo *MemoryUserRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, ok := repo.getLocked(ctx, id)
+	if !ok {
+		// Match the other backends, which treat updating a missing user as a no-op
+		return nil
+	}
+	if version != AnyVersion && user.Version != version {
+		return ErrVersionConflict
+	}
+	for key, value := range fields {
+		if err := setUserField(&user, key, value); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+	if repo.emailTakenLocked(repo.tenants[id], user.Email, id) {
+		return ErrEmailTaken
+	}
+	user.Version++
+	repo.users[id] = user
+	return nil
+}
+
+// Delete removes the user with the given ID.
+func (repo *MemoryUserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.getLocked(ctx, id); ok {
+		delete(repo.users, id)
+		delete(repo.tenants, id)
+	}
+	return nil
+}
+
+// List returns one page of matching users ordered by ID, along with the total number of matches.
+func (repo *MemoryUserRepository) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	repo.mu.RLock()
+	tenantID := tenant.FromContext(ctx)
+	all := make([]models.User, 0, len(repo.users))
+	for id, user := range repo.users {
+		if repo.tenants[id] != tenantID {
+			continue
+		}
+		if opts.Filter.Role != "" && user.Role != opts.Filter.Role {
+			continue
+		}
+		if opts.Filter.Email != "" && user.Email != opts.Filter.Email {
+			continue
+		}
+		if !opts.AfterID.IsZero() && bytes.Compare(user.Id[:], opts.AfterID[:]) <= 0 {
+			continue
+		}
+		if opts.IDs != nil && !slices.Contains(opts.IDs, user.Id) {
+			continue
+		}
+		if opts.Where != nil && !matchesFilterExpr(&user, opts.Where) {
+			continue
+		}
+		all = append(all, user)
+	}
+	repo.mu.RUnlock()
+
+	order := withIDOrder(opts.Sort)
+	sort.Slice(all, func(i, j int) bool {
+		return CompareUsers(&all[i], &all[j], order) < 0
+	})
+
+	total := int64(len(all))
+	start := min(opts.Skip, total)
+	end := total
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, total)
+	}
+	return all[start:end], total, nil
+}
+
+// getLocked returns the user with the given ID if it belongs to the tenant of ctx. The caller
+// must hold mu.
+func (repo *MemoryUserRepository) getLocked(ctx context.Context, id primitive.ObjectID) (models.User, bool) {
+	user, ok := repo.users[id]
+	if !ok || repo.tenants[id] != tenant.FromContext(ctx) {
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// emailTakenLocked reports whether a user of the tenant other than id already has email. The
+// caller must hold mu.
+func (repo *MemoryUserRepository) emailTakenLocked(tenantID, email string, id primitive.ObjectID) bool {
+	for otherID, other := range repo.users {
+		if otherID != id && other.Email == email && repo.tenants[otherID] == tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+// setUserField assigns value to the user field with the given JSON name.
+func setUserField(user *models.User, key string, value interface{}) error {
+	switch key {
+	case "email", "password", "firstName", "lastName", "phone", "role":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("field %q must be a string", key)
+		}
+		switch key {
+		case "email":
+			user.Email = s
+		case "password":
+			user.Password = s
+		case "firstName":
+			user.FirstName = s
+		case "lastName":
+			user.LastName = s
+		case "phone":
+			user.Phone = s
+		case "role":
+			user.Role = s
+		}
+	case "joinDate":
+		t, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("field %q must be a time", key)
+		}
+		user.JoinDate = t
+	case "erasedAt":
+		t, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("field %q must be a time", key)
+		}
+		user.ErasedAt = &t
+	case "addresses":
+		// nil removes every address
+		if value == nil {
+			user.Addresses = nil
+			break
+		}
+		addresses, ok := value.([]models.Address)
+		if !ok {
+			return fmt.Errorf("field %q must be a list of addresses", key)
+		}
+		user.Addresses = slices.Clone(addresses)
+	case "metadata":
+		// nil removes every key
+		if value == nil {
+			user.Metadata = nil
+			break
+		}
+		metadata, ok := value.(models.Metadata)
+		if !ok {
+			return fmt.Errorf("field %q must be metadata", key)
+		}
+		user.Metadata = metadata
+	case "preferences":
+		// nil restores the defaults
+		if value == nil {
+			user.Preferences = nil
+			break
+		}
+		preferences, ok := value.(models.Preferences)
+		if !ok {
+			return fmt.Errorf("field %q must be preferences", key)
+		}
+		user.Preferences = &preferences
+	case "phoneVerifiedAt":
+		// nil clears the verification
+		if value == nil {
+			user.PhoneVerifiedAt = nil
+			break
+		}
+		t, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("field %q must be a time", key)
+		}
+		user.PhoneVerifiedAt = &t
+	default:
+		return fmt.Errorf("unknown field %q", key)
+	}
+	return nil
+}
+
+// CountUsers counts users by scanning them all.
+func (repo *MemoryUserRepository) CountUsers(ctx context.Context, since []time.Time) (*UserCounts, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenantID := tenant.FromContext(ctx)
+	counts := &UserCounts{ByRole: map[string]int64{}, JoinedSince: make([]int64, len(since))}
+	for id, user := range repo.users {
+		if repo.tenants[id] != tenantID {
+			continue
+		}
+		counts.Total++
+		counts.ByRole[user.Role]++
+		if user.ErasedAt != nil {
+			counts.Erased++
+		}
+		for i, t := range since {
+			if !user.JoinDate.Before(t) {
+				counts.JoinedSince[i]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// SearchUsers scores every user by its words: each term scores 2 for a word it equals and 1 for
+// a word it only starts.
+func (repo *MemoryUserRepository) SearchUsers(ctx context.Context, query string, limit int64) ([]UserMatch, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []UserMatch{}, nil
+	}
+
+	repo.mu.RLock()
+	tenantID := tenant.FromContext(ctx)
+	matches := []UserMatch{}
+	for id, user := range repo.users {
+		if user.ErasedAt != nil || repo.tenants[id] != tenantID {
+			continue
+		}
+		words := SearchTerms(user.Email + " " + user.FirstName + " " + user.LastName)
+		if score := searchScore(terms, words); score > 0 {
+			matches = append(matches, UserMatch{User: user, Score: score})
+		}
+	}
+	repo.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return bytes.Compare(matches[i].User.Id[:], matches[j].User.Id[:]) < 0
+	})
+	return pageOf(matches, 0, limit), nil
+}
+
+// searchScore scores words against terms, or returns 0 when some term starts none of them.
+func searchScore(terms, words []string) float64 {
+	var total float64
+	for _, term := range terms {
+		var best float64
+		for _, word := range words {
+			if word == term {
+				best = 2
+				break
+			}
+			if strings.HasPrefix(word, term) {
+				best = 1
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	models "example_api/models"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryWebhookRepository keeps webhooks and their delivery log in process memory.
+type MemoryWebhookRepository struct {
+	mu         sync.RWMutex
+	webhooks   map[primitive.ObjectID]models.Webhook
+	deliveries map[primitive.ObjectID][]models.WebhookDelivery
+}
+
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		webhooks:   make(map[primitive.ObjectID]models.Webhook),
+		deliveries: make(map[primitive.ObjectID][]models.WebhookDelivery),
+	}
+}
+
+var _ WebhookStore = (*MemoryWebhookRepository)(nil)
+
+// Create stores a copy of webhook.
+func (repo *MemoryWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.webhooks[webhook.Id]; exists {
+		return fmt.Errorf("failed to insert webhook: duplicate ID %s", webhook.Id.Hex())
+	}
+	repo.webhooks[webhook.Id] = cloneWebhook(*webhook)
+	return nil
+}
+
+// GetByID returns a copy of the webhook with the given ID, or ErrWebhookNotFound.
+func (repo *MemoryWebhookRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	webhook, ok := repo.webhooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	webhook = cloneWebhook(webhook)
+	return &webhook, nil
+}
+
+// Replace overwrites the stored webhook with the same ID.
+func (repo *MemoryWebhookRepository) Replace(ctx context.Context, webhook *models.Webhook) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.webhooks[webhook.Id]; !ok {
+		return ErrWebhookNotFound
+	}
+	repo.webhooks[webhook.Id] = cloneWebhook(*webhook)
+	return nil
+}
+
+// Delete removes the webhook with the given ID and its delivery log.
+func (repo *MemoryWebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.webhooks, id)
+	delete(repo.deliveries, id)
+	return nil
+}
+
+// List returns one page of webhooks ordered by ID, along with the total number of webhooks.
+func (repo *MemoryWebhookRepository) List(ctx context.Context, skip, limit int64) ([]models.Webhook, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	all := make([]models.Webhook, 0, len(repo.webhooks))
+	for _, webhook := range repo.webhooks {
+		all = append(all, cloneWebhook(webhook))
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return bytes.Compare(all[i].Id[:], all[j].Id[:]) < 0
+	})
+	return pageOf(all, skip, limit), int64(len(all)), nil
+}
+
+// ListByEvent returns every webhook whose events include eventType.
+func (repo *MemoryWebhookRepository) ListByEvent(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var webhooks []models.Webhook
+	for _, webhook := range repo.webhooks {
+		if slices.Contains(webhook.Events, eventType) {
+			webhooks = append(webhooks, cloneWebhook(webhook))
+		}
+	}
+	return webhooks, nil
+}
+
+// RecordDelivery appends a delivery attempt, dropping attempts older than DeliveryRetention.
+// Attempts for a webhook deleted in the meantime are discarded.
+func (repo *MemoryWebhookRepository) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.webhooks[delivery.WebhookID]; !ok {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-DeliveryRetention)
+	log := slices.DeleteFunc(repo.deliveries[delivery.WebhookID], func(d models.WebhookDelivery) bool {
+		return d.CreatedAt.Before(cutoff)
+	})
+	repo.deliveries[delivery.WebhookID] = append(log, *delivery)
+	return nil
+}
+
+// ListDeliveries returns one page of the webhook's delivery attempts, newest first.
+func (repo *MemoryWebhookRepository) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, skip, limit int64) ([]models.WebhookDelivery, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	log := repo.deliveries[webhookID]
+	newestFirst := make([]models.WebhookDelivery, len(log))
+	for i, delivery := range log {
+		newestFirst[len(log)-1-i] = delivery
+	}
+	return pageOf(newestFirst, skip, limit), int64(len(log)), nil
+}
+
+func cloneWebhook(webhook models.Webhook) models.Webhook {
+	webhook.Events = slices.Clone(webhook.Events)
+	return webhook
+}
+
+// pageOf returns the window of items selected by skip and limit.
+func pageOf[T any](items []T, skip, limit int64) []T {
+	start := min(skip, int64(len(items)))
+	end := min(start+limit, int64(len(items)))
+	return items[start:end]
+}
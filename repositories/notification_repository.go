@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationRepository stores notifications in the notifications collection, where a TTL
+// index enforces NotificationRetention.
+type NotificationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationRepository(db *mongodb.Database) *NotificationRepository {
+	return &NotificationRepository{
+		collection: db.Collection("notifications"),
+	}
+}
+
+var _ NotificationStore = (*NotificationRepository)(nil)
+
+// mongoNotification is the stored form of a notification. TenantID is empty for the default
+// tenant.
+type mongoNotification struct {
+	models.Notification `bson:",inline"`
+	TenantID            string `bson:"tenantId,omitempty"`
+}
+
+// Create inserts a new notification document in the tenant of ctx.
+func (repo *NotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	id, _ := mongoTenant(ctx).(string)
+	if _, err := repo.collection.InsertOne(ctx, mongoNotification{Notification: *notification, TenantID: id}); err != nil {
+		return fmt.Errorf("failed to insert notification: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of the user's notifications, newest first.
+func (repo *NotificationRepository) List(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, skip, limit int64) ([]models.Notification, int64, error) {
+	query := bson.M{"userId": userID}
+	if unreadOnly {
+		query["readAt"] = bson.M{"$exists": false}
+	}
+	filter := mongoScoped(ctx, query)
+	total, err := repo.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	cursor, err := repo.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode notifications: %w", err)
+	}
+	return notifications, total, nil
+}
+
+// MarkRead sets the read time of the user's notification unless it was read before.
+func (repo *NotificationRepository) MarkRead(ctx context.Context, userID, id primitive.ObjectID, at time.Time) (*models.Notification, error) {
+	// The first read time is kept, so marking read twice is a match without a change
+	filter := mongoScoped(ctx, bson.M{"_id": id, "userId": userID})
+	if _, err := repo.collection.UpdateOne(ctx, bson.M{"$and": bson.A{filter, bson.M{"readAt": bson.M{"$exists": false}}}}, bson.M{"$set": bson.M{"readAt": at}}); err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	var notification models.Notification
+	err := repo.collection.FindOne(ctx, filter).Decode(&notification)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification: %w", err)
+	}
+	return &notification, nil
+}
+
+// MarkAllRead sets the read time of every unread notification of the user.
+func (repo *NotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	filter := mongoScoped(ctx, bson.M{"userId": userID, "readAt": bson.M{"$exists": false}})
+	result, err := repo.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"readAt": at}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// Delete removes the user's notification.
+func (repo *NotificationRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	result, err := repo.collection.DeleteOne(ctx, mongoScoped(ctx, bson.M{"_id": id, "userId": userID}))
+	if err != nil {
+		return fmt.Errorf("failed to delete notification: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// DeleteUserNotifications removes every notification of the user.
+func (repo *NotificationRepository) DeleteUserNotifications(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.collection.DeleteMany(ctx, mongoScoped(ctx, bson.M{"userId": userID})); err != nil {
+		return fmt.Errorf("failed to delete user notifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationRetention is how long notifications are kept, read or not.
+const NotificationRetention = 90 * 24 * time.Hour
+
+// ErrNotificationNotFound is returned when the user has no notification with the requested ID.
+var ErrNotificationNotFound = apperrors.NotFound("Notification not found")
+
+// NotificationStore persists the notifications of users in the tenant of the context. Every
+// lookup is by user, so one user's notifications cannot be reached through another's.
+type NotificationStore interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	// List returns one page of the user's notifications, newest first, along with their total
+	// number. unreadOnly leaves out those already read.
+	List(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, skip, limit int64) ([]models.Notification, int64, error)
+	// MarkRead sets the read time of the user's notification unless it was read before, and
+	// returns it, or ErrNotificationNotFound.
+	MarkRead(ctx context.Context, userID, id primitive.ObjectID, at time.Time) (*models.Notification, error)
+	// MarkAllRead sets the read time of every unread notification of the user and returns how
+	// many there were.
+	MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error)
+	// Delete removes the user's notification, or returns ErrNotificationNotFound.
+	Delete(ctx context.Context, userID, id primitive.ObjectID) error
+	// DeleteUserNotifications removes every notification of a deleted or erased user.
+	DeleteUserNotifications(ctx context.Context, userID primitive.ObjectID) error
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrganizationRepository stores organizations and their memberships in MongoDB, in separate
+// collections. A unique index on the organization and user of memberships rules out
+// duplicates.
+type OrganizationRepository struct {
+	organizations *mongo.Collection
+	memberships   *mongo.Collection
+}
+
+func NewOrganizationRepository(db *mongodb.Database) *OrganizationRepository {
+	return &OrganizationRepository{
+		organizations: db.Collection("organizations"),
+		memberships:   db.Collection("memberships"),
+	}
+}
+
+var _ OrganizationStore = (*OrganizationRepository)(nil)
+
+// mongoOrganization is the stored form of an organization. TenantID is empty for the default
+// tenant.
+type mongoOrganization struct {
+	models.Organization `bson:",inline"`
+	TenantID            string `bson:"tenantId,omitempty"`
+}
+
+// mongoMembership is the stored form of a membership. TenantID is empty for the default tenant.
+type mongoMembership struct {
+	models.Membership `bson:",inline"`
+	TenantID          string `bson:"tenantId,omitempty"`
+}
+
+// Create inserts a new organization document in the tenant of ctx.
+func (repo *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	id, _ := mongoTenant(ctx).(string)
+	if _, err := repo.organizations.InsertOne(ctx, mongoOrganization{Organization: *org, TenantID: id}); err != nil {
+		return fmt.Errorf("failed to insert organization: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the organization with the given ID, or ErrOrganizationNotFound.
+func (repo *OrganizationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error) {
+	var org models.Organization
+	err := repo.organizations.FindOne(ctx, mongoScoped(ctx, bson.M{"_id": id})).Decode(&org)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organization: %w", err)
+	}
+	return &org, nil
+}
+
+// Replace overwrites the organization document with the same ID.
+func (repo *OrganizationRepository) Replace(ctx context.Context, org *models.Organization) error {
+	id, _ := mongoTenant(ctx).(string)
+	result, err := repo.organizations.ReplaceOne(ctx, mongoScoped(ctx, bson.M{"_id": org.Id}), mongoOrganization{Organization: *org, TenantID: id})
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+// Delete removes the organization with the given ID and its memberships.
+func (repo *OrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.organizations.DeleteOne(ctx, mongoScoped(ctx, bson.M{"_id": id})); err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+	if _, err := repo.memberships.DeleteMany(ctx, mongoScoped(ctx, bson.M{"organizationId": id})); err != nil {
+		return fmt.Errorf("failed to delete organization memberships: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of organizations ordered by ID, along with the total number of
+// organizations.
+func (repo *OrganizationRepository) List(ctx context.Context, skip, limit int64) ([]models.Organization, int64, error) {
+	filter := mongoScoped(ctx, bson.M{})
+	total, err := repo.organizations.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count organizations: %w", err)
+	}
+
+	cursor, err := repo.organizations.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	orgs := []models.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode organizations: %w", err)
+	}
+	return orgs, total, nil
+}
+
+// AddMembership inserts a new membership document in the tenant of ctx.
+func (repo *OrganizationRepository) AddMembership(ctx context.Context, membership *models.Membership) error {
+	id, _ := mongoTenant(ctx).(string)
+	_, err := repo.memberships.InsertOne(ctx, mongoMembership{Membership: *membership, TenantID: id})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadyMember
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert membership: %w", err)
+	}
+	return nil
+}
+
+// GetMembership returns the user's membership of the organization, or ErrMembershipNotFound.
+func (repo *OrganizationRepository) GetMembership(ctx context.Context, orgID, userID primitive.ObjectID) (*models.Membership, error) {
+	var membership models.Membership
+	err := repo.memberships.FindOne(ctx, mongoScoped(ctx, bson.M{"organizationId": orgID, "userId": userID})).Decode(&membership)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMembershipNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find membership: %w", err)
+	}
+	return &membership, nil
+}
+
+// ReplaceMembership overwrites the membership document with the same ID.
+func (repo *OrganizationRepository) ReplaceMembership(ctx context.Context, membership *models.Membership) error {
+	id, _ := mongoTenant(ctx).(string)
+	result, err := repo.memberships.ReplaceOne(ctx, mongoScoped(ctx, bson.M{"_id": membership.Id}), mongoMembership{Membership: *membership, TenantID: id})
+	if err != nil {
+		return fmt.Errorf("failed to update membership: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrMembershipNotFound
+	}
+	return nil
+}
+
+// RemoveMembership deletes the user's membership of the organization.
+func (repo *OrganizationRepository) RemoveMembership(ctx context.Context, orgID, userID primitive.ObjectID) error {
+	result, err := repo.memberships.DeleteOne(ctx, mongoScoped(ctx, bson.M{"organizationId": orgID, "userId": userID}))
+	if err != nil {
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrMembershipNotFound
+	}
+	return nil
+}
+
+// ListMembers returns one page of the organization's memberships in the order they were created.
+func (repo *OrganizationRepository) ListMembers(ctx context.Context, orgID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error) {
+	return repo.listMemberships(ctx, bson.M{"organizationId": orgID}, skip, limit)
+}
+
+// ListUserMemberships returns one page of the user's memberships in the order they were created.
+func (repo *OrganizationRepository) ListUserMemberships(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error) {
+	return repo.listMemberships(ctx, bson.M{"userId": userID}, skip, limit)
+}
+
+func (repo *OrganizationRepository) listMemberships(ctx context.Context, filter bson.M, skip, limit int64) ([]models.Membership, int64, error) {
+	filter = mongoScoped(ctx, filter)
+	total, err := repo.memberships.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count memberships: %w", err)
+	}
+
+	// ObjectIDs start with their creation time, so they order memberships by age
+	cursor, err := repo.memberships.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	memberships := []models.Membership{}
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode memberships: %w", err)
+	}
+	return memberships, total, nil
+}
+
+// DeleteUserMemberships removes every membership of the user.
+func (repo *OrganizationRepository) DeleteUserMemberships(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.memberships.DeleteMany(ctx, mongoScoped(ctx, bson.M{"userId": userID})); err != nil {
+		return fmt.Errorf("failed to delete user memberships: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrOrganizationNotFound is returned when no organization matches the requested ID.
+	ErrOrganizationNotFound = apperrors.NotFound("Organization not found")
+	// ErrMembershipNotFound is returned when the user is not a member of the organization.
+	ErrMembershipNotFound = apperrors.NotFound("Membership not found")
+	// ErrAlreadyMember is returned when inviting a user who already has a membership.
+	ErrAlreadyMember = apperrors.Conflict("User is already a member of the organization or invited to it")
+)
+
+// OrganizationStore persists organizations and their memberships in the tenant of the context.
+// Deleting an organization also deletes its memberships.
+type OrganizationStore interface {
+	Create(ctx context.Context, org *models.Organization) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error)
+	// Replace overwrites the stored organization with the same ID, or returns
+	// ErrOrganizationNotFound.
+	Replace(ctx context.Context, org *models.Organization) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// List returns one page of organizations ordered by ID, along with the total number of
+	// organizations.
+	List(ctx context.Context, skip, limit int64) ([]models.Organization, int64, error)
+
+	// AddMembership stores a new membership, or returns ErrAlreadyMember if the user already
+	// has one in the organization.
+	AddMembership(ctx context.Context, membership *models.Membership) error
+	// GetMembership returns the user's membership of the organization, or ErrMembershipNotFound.
+	GetMembership(ctx context.Context, orgID, userID primitive.ObjectID) (*models.Membership, error)
+	// ReplaceMembership overwrites the stored membership with the same ID, or returns
+	// ErrMembershipNotFound.
+	ReplaceMembership(ctx context.Context, membership *models.Membership) error
+	// RemoveMembership deletes the user's membership of the organization, or returns
+	// ErrMembershipNotFound.
+	RemoveMembership(ctx context.Context, orgID, userID primitive.ObjectID) error
+	// ListMembers returns one page of the organization's memberships in the order they were
+	// created, along with their total number.
+	ListMembers(ctx context.Context, orgID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error)
+	// ListUserMemberships is ListMembers for the memberships of one user.
+	ListUserMemberships(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error)
+	// DeleteUserMemberships removes every membership of a deleted user.
+	DeleteUserMemberships(ctx context.Context, userID primitive.ObjectID) error
+}
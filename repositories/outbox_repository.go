@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OutboxRepository stores outbox events in MongoDB, where a TTL index removes them
+// OutboxRetention after they were sent.
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(db *mongodb.Database) *OutboxRepository {
+	return &OutboxRepository{
+		collection: db.Collection("outbox"),
+	}
+}
+
+var _ OutboxStore = (*OutboxRepository)(nil)
+
+// Add inserts an outbox event.
+func (repo *OutboxRepository) Add(ctx context.Context, event *models.OutboxEvent) error {
+	if _, err := repo.collection.InsertOne(ctx, event); err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// Pending returns the oldest unsent events.
+func (repo *OutboxRepository) Pending(ctx context.Context, limit int64) ([]models.OutboxEvent, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+	cursor, err := repo.collection.Find(ctx, bson.M{"sentAt": nil}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending outbox events: %w", err)
+	}
+
+	pending := []models.OutboxEvent{}
+	if err := cursor.All(ctx, &pending); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox events: %w", err)
+	}
+	return pending, nil
+}
+
+// MarkSent records when the event was published.
+func (repo *OutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	if _, err := repo.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"sentAt": at}}); err != nil {
+		return fmt.Errorf("failed to mark outbox event sent: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure increments the event's attempt count and stores the latest error.
+func (repo *OutboxRepository) RecordFailure(ctx context.Context, id primitive.ObjectID, cause error) error {
+	update := bson.M{"$inc": bson.M{"attempts": 1}, "$set": bson.M{"lastError": cause.Error()}}
+	if _, err := repo.collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("failed to record outbox failure: %w", err)
+	}
+	return nil
+}
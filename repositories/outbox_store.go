@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxRetention is how long published outbox events are kept before they expire.
+const OutboxRetention = 7 * 24 * time.Hour
+
+// OutboxStore persists events waiting to be published. Add takes part in the transaction of
+// the context it is given, so an event is only stored when the change it describes is.
+type OutboxStore interface {
+	Add(ctx context.Context, event *models.OutboxEvent) error
+	// Pending returns up to limit unpublished events, oldest first.
+	Pending(ctx context.Context, limit int64) ([]models.OutboxEvent, error)
+	MarkSent(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// RecordFailure counts a failed publish attempt and keeps its error for troubleshooting.
+	RecordFailure(ctx context.Context, id primitive.ObjectID, cause error) error
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"example_api/events"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxUserStore saves a user lifecycle event to the outbox in the same transaction as every
+// change made through it, so an event is stored exactly when its change is. Reads pass straight
+// through to the wrapped store.
+type OutboxUserStore struct {
+	UserStore
+	outbox     OutboxStore
+	transactor Transactor
+}
+
+func NewOutboxUserStore(store UserStore, outbox OutboxStore, transactor Transactor) *OutboxUserStore {
+	return &OutboxUserStore{
+		UserStore:  store,
+		outbox:     outbox,
+		transactor: transactor,
+	}
+}
+
+// Create stores the user and a user.created event.
+func (s *OutboxUserStore) Create(ctx context.Context, user *models.User) error {
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.UserStore.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.add(ctx, events.UserCreated, user.Id, events.UserPayload(user))
+	})
+}
+
+// CreateMany creates the users one at a time, each with its user.created event. A duplicate
+// key aborts a whole Mongo transaction, so a bulk insert would let one taken email fail every
+// user in the batch.
+func (s *OutboxUserStore) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs := make([]error, len(users))
+	for i, user := range users {
+		if err := s.Create(ctx, user); errors.Is(err, ErrEmailTaken) {
+			errs[i] = err
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return errs, nil
+}
+
+// Update updates the user and stores a user.updated event with its new state. Nothing is
+// stored when the user does not exist.
+func (s *OutboxUserStore) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.UserStore.Update(ctx, id, version, fields); err != nil {
+			return err
+		}
+		// Read inside the transaction so the event carries exactly the state this update produced
+		user, err := s.UserStore.GetByID(ctx, id)
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.add(ctx, events.UserUpdated, id, events.UserPayload(user))
+	})
+}
+
+// Delete deletes the user and stores a user.deleted event. Nothing is stored when the user
+// does not exist.
+func (s *OutboxUserStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.UserStore.GetByID(ctx, id); err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := s.UserStore.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.add(ctx, events.UserDeleted, id, events.UserRef{ID: id.Hex()})
+	})
+}
+
+func (s *OutboxUserStore) add(ctx context.Context, eventType string, subject primitive.ObjectID, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+	return s.outbox.Add(ctx, &models.OutboxEvent{
+		Id:        primitive.NewObjectID(),
+		MessageID: uuid.NewString(),
+		Type:      eventType,
+		Subject:   subject.Hex(),
+		Tenant:    tenant.FromContext(ctx),
+		Time:      time.Now().UTC(),
+		Data:      data,
+	})
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"example_api/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PasswordHistoryRepository stores password histories in MongoDB, one document per user holding
+// their previous hashes newest first.
+type PasswordHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPasswordHistoryRepository(db *mongodb.Database) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{
+		collection: db.Collection("password_history"),
+	}
+}
+
+var _ PasswordHistoryStore = (*PasswordHistoryRepository)(nil)
+
+// passwordHistory is the document stored per user.
+type passwordHistory struct {
+	UserID primitive.ObjectID `bson:"_id"`
+	Hashes []string           `bson:"hashes"`
+}
+
+// Add pushes hash to the front of the user's hashes and trims them to keep in one update.
+func (repo *PasswordHistoryRepository) Add(ctx context.Context, userID primitive.ObjectID, hash string, keep int) error {
+	update := bson.M{"$push": bson.M{"hashes": bson.M{"$each": bson.A{hash}, "$position": 0, "$slice": keep}}}
+	if _, err := repo.collection.UpdateOne(ctx, bson.M{"_id": userID}, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to record password history: %w", err)
+	}
+	return nil
+}
+
+// List reads the user's hashes.
+func (repo *PasswordHistoryRepository) List(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	var history passwordHistory
+	err := repo.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&history)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find password history: %w", err)
+	}
+	return history.Hashes, nil
+}
+
+// Delete removes the user's document.
+func (repo *PasswordHistoryRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.collection.DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete password history: %w", err)
+	}
+	return nil
+}
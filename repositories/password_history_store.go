@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordHistoryStore keeps the hashes of the passwords users had before their current one,
+// newest first, so they cannot be reused. User IDs are unique across tenants, so histories need
+// no tenant of their own.
+type PasswordHistoryStore interface {
+	// Add records hash as the user's newest previous password and forgets all but the newest
+	// keep hashes.
+	Add(ctx context.Context, userID primitive.ObjectID, hash string, keep int) error
+	// List returns the user's previous password hashes, newest first.
+	List(ctx context.Context, userID primitive.ObjectID) ([]string, error)
+	// Delete forgets the user's previous passwords, if they have any.
+	Delete(ctx context.Context, userID primitive.ObjectID) error
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"example_api/mongodb"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PhoneCodeRepository stores phone verification codes in MongoDB. A TTL index on expiresAt
+// removes them once they expire.
+type PhoneCodeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPhoneCodeRepository(db *mongodb.Database) *PhoneCodeRepository {
+	return &PhoneCodeRepository{
+		collection: db.Collection("phone_codes"),
+	}
+}
+
+var _ PhoneCodeStore = (*PhoneCodeRepository)(nil)
+
+// Put upserts the user's code.
+func (repo *PhoneCodeRepository) Put(ctx context.Context, code *PhoneCode) error {
+	_, err := repo.collection.ReplaceOne(ctx, bson.M{"_id": code.UserID}, code, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to store phone code: %w", err)
+	}
+	return nil
+}
+
+// Get finds the user's code. The TTL monitor only runs once a minute, so expired ones
+// are filtered out too.
+func (repo *PhoneCodeRepository) Get(ctx context.Context, userID primitive.ObjectID) (*PhoneCode, error) {
+	var code PhoneCode
+	err := repo.collection.FindOne(ctx, bson.M{"_id": userID, "expiresAt": bson.M{"$gt": time.Now()}}).Decode(&code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPhoneCodeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find phone code: %w", err)
+	}
+	return &code, nil
+}
+
+// AddAttempt increments the attempts of the user's unexpired code.
+func (repo *PhoneCodeRepository) AddAttempt(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var code PhoneCode
+	err := repo.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": userID, "expiresAt": bson.M{"$gt": time.Now()}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, ErrPhoneCodeNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count phone code attempt: %w", err)
+	}
+	return code.Attempts, nil
+}
+
+// Delete removes the user's code.
+func (repo *PhoneCodeRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.collection.DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		return fmt.Errorf("failed to delete phone code: %w", err)
+	}
+	return nil
+}
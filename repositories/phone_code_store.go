@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrPhoneCodeNotFound is returned when a user has no unexpired phone verification code.
+var ErrPhoneCodeNotFound = apperrors.NotFound("Verification code not found")
+
+// PhoneCode is the verification code last sent to a user's phone number, awaiting
+// confirmation. Only a hash of the code is kept. Attempts counts the wrong codes submitted for it.
+type PhoneCode struct {
+	UserID    primitive.ObjectID `bson:"_id"`
+	Phone     string             `bson:"phone"`
+	CodeHash  string             `bson:"codeHash"`
+	Attempts  int                `bson:"attempts"`
+	SentAt    time.Time          `bson:"sentAt"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+}
+
+// PhoneCodeStore persists one pending verification code per user until it expires. User IDs are
+// unique across tenants, so codes need no tenant of their own.
+type PhoneCodeStore interface {
+	// Put stores code, replacing the user's earlier one.
+	Put(ctx context.Context, code *PhoneCode) error
+	// Get returns the user's unexpired code or ErrPhoneCodeNotFound.
+	Get(ctx context.Context, userID primitive.ObjectID) (*PhoneCode, error)
+	// AddAttempt counts a wrong guess against the user's unexpired code and returns the
+	// attempts made so far, or ErrPhoneCodeNotFound.
+	AddAttempt(ctx context.Context, userID primitive.ObjectID) (int, error)
+	// Delete removes the user's code, if there is one.
+	Delete(ctx context.Context, userID primitive.ObjectID) error
+}
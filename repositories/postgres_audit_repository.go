@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const postgresAuditColumns = "id, time, action, actor, ip, request_id, target_id, changes"
+
+// PostgresAuditRepository stores the audit log in the audit_logs table, which EnsureSchema
+// creates alongside users. Changes are kept as a JSONB array.
+type PostgresAuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAuditRepository(pool *pgxpool.Pool) *PostgresAuditRepository {
+	return &PostgresAuditRepository{
+		pool: pool,
+	}
+}
+
+var _ AuditStore = (*PostgresAuditRepository)(nil)
+
+// Add inserts an audit entry row in the tenant of ctx.
+func (repo *PostgresAuditRepository) Add(ctx context.Context, entry *models.AuditEntry) error {
+	var changes []byte
+	if len(entry.Changes) > 0 {
+		var err error
+		if changes, err = json.Marshal(entry.Changes); err != nil {
+			return fmt.Errorf("failed to encode audit changes: %w", err)
+		}
+	}
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO audit_logs (`+postgresAuditColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		entry.Id.Hex(), entry.Time, entry.Action, entry.Actor, entry.IP, entry.RequestID, entry.TargetID.Hex(), changes, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// Erase overwrites the before and after values of the named fields in the user's entries.
+func (repo *PostgresAuditRepository) Erase(ctx context.Context, targetID primitive.ObjectID, fields []string) error {
+	_, err := repo.pool.Exec(ctx, `
+		UPDATE audit_logs SET changes = (
+			SELECT jsonb_agg(CASE WHEN change->>'field' = ANY($2)
+				THEN change || jsonb_build_object('before', $3::text, 'after', $3::text)
+				ELSE change END ORDER BY position)
+			FROM jsonb_array_elements(changes) WITH ORDINALITY AS c(change, position)
+		)
+		WHERE target_id = $1 AND tenant_id = $4 AND jsonb_typeof(changes) = 'array'`,
+		targetID.Hex(), fields, models.AuditErased, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to erase audit entries: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes the entry rows of every tenant older than before.
+func (repo *PostgresAuditRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := repo.pool.Exec(ctx, `DELETE FROM audit_logs WHERE time < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit entries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// List returns one page of the entries matching filter, newest first.
+func (repo *PostgresAuditRepository) List(ctx context.Context, filter AuditFilter, skip, limit int64) ([]models.AuditEntry, int64, error) {
+	// Empty filter values match every row
+	where := `WHERE ($1 = '' OR actor = $1) AND ($2 = '' OR action = $2) AND ($3 = '' OR target_id = $3)
+		AND ($4::timestamptz IS NULL OR time >= $4) AND ($5::timestamptz IS NULL OR time < $5) AND tenant_id = $6`
+	var targetID string
+	if !filter.TargetID.IsZero() {
+		targetID = filter.TargetID.Hex()
+	}
+	args := []interface{}{filter.Actor, filter.Action, targetID, optionalTime(filter.From), optionalTime(filter.To), tenant.FromContext(ctx)}
+
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM audit_logs `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresAuditColumns+` FROM audit_logs `+where+` ORDER BY time DESC, id DESC LIMIT $7 OFFSET $8`,
+		append(args, limit, skip)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanPostgresAuditEntry(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode audit entries: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+// optionalTime returns nil for the zero time, which Postgres receives as NULL.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func scanPostgresAuditEntry(row pgx.Row) (*models.AuditEntry, error) {
+	var entry models.AuditEntry
+	var id, targetID string
+	var changes []byte
+	if err := row.Scan(&id, &entry.Time, &entry.Action, &entry.Actor, &entry.IP, &entry.RequestID, &targetID, &changes); err != nil {
+		return nil, err
+	}
+	var err error
+	if entry.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, fmt.Errorf("invalid audit entry ID %q: %w", id, err)
+	}
+	if entry.TargetID, err = primitive.ObjectIDFromHex(targetID); err != nil {
+		return nil, fmt.Errorf("invalid audit target ID %q: %w", targetID, err)
+	}
+	if changes != nil {
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("invalid audit changes: %w", err)
+		}
+	}
+	return &entry, nil
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	models "example_api/models"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostgresAvatarRepository stores avatars in the avatars table, one row per user and variant,
+// which EnsureSchema creates alongside users. Rows are removed along with their user.
+type PostgresAvatarRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAvatarRepository(pool *pgxpool.Pool) *PostgresAvatarRepository {
+	return &PostgresAvatarRepository{
+		pool: pool,
+	}
+}
+
+var _ AvatarStore = (*PostgresAvatarRepository)(nil)
+
+// Put inserts or replaces the row of the user's avatar variant.
+func (repo *PostgresAvatarRepository) Put(ctx context.Context, avatar *models.Avatar, data []byte) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO avatars (user_id, variant, content_type, size, etag, data, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, variant) DO UPDATE SET content_type = EXCLUDED.content_type, size = EXCLUDED.size,
+			etag = EXCLUDED.etag, data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`,
+		avatar.UserID.Hex(), avatar.Variant, avatar.ContentType, avatar.Size, avatar.ETag, data, avatar.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store avatar: %w", err)
+	}
+	return nil
+}
+
+// Get returns the variant of the user's avatar, or ErrAvatarNotFound.
+func (repo *PostgresAvatarRepository) Get(ctx context.Context, userID primitive.ObjectID, variant string) (*models.Avatar, io.ReadCloser, error) {
+	avatar := models.Avatar{UserID: userID, Variant: variant}
+	var data []byte
+	err := repo.pool.QueryRow(ctx,
+		`SELECT content_type, size, etag, data, updated_at FROM avatars WHERE user_id = $1 AND variant = $2`, userID.Hex(), variant,
+	).Scan(&avatar.ContentType, &avatar.Size, &avatar.ETag, &data, &avatar.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrAvatarNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find avatar: %w", err)
+	}
+	return &avatar, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete removes the rows of every variant of the user's avatar.
+func (repo *PostgresAvatarRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM avatars WHERE user_id = $1`, userID.Hex()); err != nil {
+		return fmt.Errorf("failed to delete avatar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostgresExportRepository stores export files in the exports table, which EnsureSchema
+// creates alongside users.
+type PostgresExportRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresExportRepository(pool *pgxpool.Pool) *PostgresExportRepository {
+	return &PostgresExportRepository{
+		pool: pool,
+	}
+}
+
+var _ ExportStore = (*PostgresExportRepository)(nil)
+
+// Put inserts or replaces the row of the export and purges rows older than ExportRetention.
+func (repo *PostgresExportRepository) Put(ctx context.Context, file *models.ExportFile, data []byte) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO exports (id, tenant_id, filename, content_type, size, data, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET filename = EXCLUDED.filename, content_type = EXCLUDED.content_type,
+			size = EXCLUDED.size, data = EXCLUDED.data, created_at = EXCLUDED.created_at`,
+		file.Id.Hex(), tenant.FromContext(ctx), file.Filename, file.ContentType, file.Size, data, file.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM exports WHERE created_at < $1`, time.Now().Add(-ExportRetention)); err != nil {
+		return fmt.Errorf("failed to purge exports: %w", err)
+	}
+	return nil
+}
+
+// Get returns the export file and its bytes, or ErrExportNotFound.
+func (repo *PostgresExportRepository) Get(ctx context.Context, id primitive.ObjectID) (*models.ExportFile, io.ReadCloser, error) {
+	file := models.ExportFile{Id: id}
+	var data []byte
+	err := repo.pool.QueryRow(ctx,
+		`SELECT filename, content_type, size, data, created_at FROM exports WHERE id = $1 AND tenant_id = $2 AND created_at >= $3`,
+		id.Hex(), tenant.FromContext(ctx), time.Now().Add(-ExportRetention),
+	).Scan(&file.Filename, &file.ContentType, &file.Size, &data, &file.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find export: %w", err)
+	}
+	return &file, io.NopCloser(bytes.NewReader(data)), nil
+}
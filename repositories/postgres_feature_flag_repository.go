@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	models "example_api/models"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const postgresFeatureFlagColumns = "key, description, enabled, rollout, tenants, updated_at"
+
+// PostgresFeatureFlagRepository stores feature flags in the feature_flags table, which
+// EnsureSchema creates alongside users. Tenant overrides are kept as a JSONB object.
+type PostgresFeatureFlagRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresFeatureFlagRepository(pool *pgxpool.Pool) *PostgresFeatureFlagRepository {
+	return &PostgresFeatureFlagRepository{
+		pool: pool,
+	}
+}
+
+var _ FeatureFlagStore = (*PostgresFeatureFlagRepository)(nil)
+
+// List returns every flag ordered by key.
+func (repo *PostgresFeatureFlagRepository) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	rows, err := repo.pool.Query(ctx, `SELECT `+postgresFeatureFlagColumns+` FROM feature_flags ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []models.FeatureFlag{}
+	for rows.Next() {
+		flag, err := scanPostgresFeatureFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode feature flags: %w", err)
+		}
+		flags = append(flags, *flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	return flags, nil
+}
+
+// GetByKey returns the flag with the given key.
+func (repo *PostgresFeatureFlagRepository) GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	row := repo.pool.QueryRow(ctx, `SELECT `+postgresFeatureFlagColumns+` FROM feature_flags WHERE key = $1`, key)
+	flag, err := scanPostgresFeatureFlag(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFeatureFlagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag: %w", err)
+	}
+	return flag, nil
+}
+
+// Put inserts the flag's row, or replaces the existing one.
+func (repo *PostgresFeatureFlagRepository) Put(ctx context.Context, flag *models.FeatureFlag) error {
+	var tenants []byte
+	if len(flag.Tenants) > 0 {
+		var err error
+		if tenants, err = json.Marshal(flag.Tenants); err != nil {
+			return fmt.Errorf("failed to encode feature flag tenants: %w", err)
+		}
+	}
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO feature_flags (`+postgresFeatureFlagColumns+`) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET description = EXCLUDED.description, enabled = EXCLUDED.enabled,
+			rollout = EXCLUDED.rollout, tenants = EXCLUDED.tenants, updated_at = EXCLUDED.updated_at`,
+		flag.Key, flag.Description, flag.Enabled, flag.Rollout, tenants, flag.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store feature flag: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the flag's row.
+func (repo *PostgresFeatureFlagRepository) Delete(ctx context.Context, key string) error {
+	tag, err := repo.pool.Exec(ctx, `DELETE FROM feature_flags WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFeatureFlagNotFound
+	}
+	return nil
+}
+
+// scanPostgresFeatureFlag reads a row selected with postgresFeatureFlagColumns.
+func scanPostgresFeatureFlag(row pgx.Row) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	var tenants []byte
+	if err := row.Scan(&flag.Key, &flag.Description, &flag.Enabled, &flag.Rollout, &tenants, &flag.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if len(tenants) > 0 {
+		if err := json.Unmarshal(tenants, &flag.Tenants); err != nil {
+			return nil, fmt.Errorf("invalid tenants of feature flag %q: %w", flag.Key, err)
+		}
+	}
+	return &flag, nil
+}
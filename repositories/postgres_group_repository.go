@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const postgresGroupColumns = "id, name, description, permissions, created_at"
+
+// PostgresGroupRepository stores groups and their members in the groups and group_members
+// tables, which EnsureSchema creates alongside users. Members go with their group or user
+// through foreign keys.
+type PostgresGroupRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresGroupRepository(pool *pgxpool.Pool) *PostgresGroupRepository {
+	return &PostgresGroupRepository{
+		pool: pool,
+	}
+}
+
+var _ GroupStore = (*PostgresGroupRepository)(nil)
+
+// Create inserts a new group row in the tenant of ctx.
+func (repo *PostgresGroupRepository) Create(ctx context.Context, group *models.Group) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO groups (`+postgresGroupColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6)`,
+		group.Id.Hex(), group.Name, group.Description, group.Permissions, group.CreatedAt, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert group: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the group with the given ID, or ErrGroupNotFound.
+func (repo *PostgresGroupRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+	row := repo.pool.QueryRow(ctx,
+		`SELECT `+postgresGroupColumns+` FROM groups WHERE id = $1 AND tenant_id = $2`,
+		id.Hex(), tenant.FromContext(ctx),
+	)
+	group, err := scanPostgresGroup(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find group: %w", err)
+	}
+	return group, nil
+}
+
+// Replace overwrites the name, description, and permissions of the group row with the same ID.
+func (repo *PostgresGroupRepository) Replace(ctx context.Context, group *models.Group) error {
+	tag, err := repo.pool.Exec(ctx,
+		`UPDATE groups SET name = $2, description = $3, permissions = $4 WHERE id = $1 AND tenant_id = $5`,
+		group.Id.Hex(), group.Name, group.Description, group.Permissions, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// Delete removes the group with the given ID; its members go with it through the foreign key.
+func (repo *PostgresGroupRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM groups WHERE id = $1 AND tenant_id = $2`, id.Hex(), tenant.FromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of groups ordered by ID, along with the total number of groups.
+func (repo *PostgresGroupRepository) List(ctx context.Context, skip, limit int64) ([]models.Group, int64, error) {
+	tenantID := tenant.FromContext(ctx)
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM groups WHERE tenant_id = $1`, tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count groups: %w", err)
+	}
+	groups, err := repo.queryGroups(ctx,
+		`SELECT `+postgresGroupColumns+` FROM groups WHERE tenant_id = $1 ORDER BY id LIMIT $2 OFFSET $3`,
+		tenantID, limit, skip,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// AddMembers inserts the member rows in the tenant of ctx in one statement, skipping users
+// already in their group.
+func (repo *PostgresGroupRepository) AddMembers(ctx context.Context, members []models.GroupMember) (int64, error) {
+	groupIDs := make([]string, len(members))
+	userIDs := make([]string, len(members))
+	addedAt := make([]time.Time, len(members))
+	for i, member := range members {
+		groupIDs[i] = member.GroupID.Hex()
+		userIDs[i] = member.UserID.Hex()
+		addedAt[i] = member.AddedAt
+	}
+
+	tag, err := repo.pool.Exec(ctx,
+		`INSERT INTO group_members (group_id, user_id, added_at, tenant_id)
+		SELECT group_id, user_id, added_at, $4 FROM unnest($1::text[], $2::text[], $3::timestamptz[]) AS m (group_id, user_id, added_at)
+		ON CONFLICT DO NOTHING`,
+		groupIDs, userIDs, addedAt, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert group members: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RemoveMember deletes the user's member row of the group.
+func (repo *PostgresGroupRepository) RemoveMember(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	tag, err := repo.pool.Exec(ctx,
+		`DELETE FROM group_members WHERE group_id = $1 AND user_id = $2 AND tenant_id = $3`,
+		groupID.Hex(), userID.Hex(), tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete group member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGroupMemberNotFound
+	}
+	return nil
+}
+
+// ListMembers returns one page of the group's members ordered by user ID.
+func (repo *PostgresGroupRepository) ListMembers(ctx context.Context, groupID primitive.ObjectID, skip, limit int64) ([]models.GroupMember, int64, error) {
+	tenantID := tenant.FromContext(ctx)
+	var total int64
+	if err := repo.pool.QueryRow(ctx,
+		`SELECT count(*) FROM group_members WHERE group_id = $1 AND tenant_id = $2`, groupID.Hex(), tenantID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count group members: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT group_id, user_id, added_at FROM group_members WHERE group_id = $1 AND tenant_id = $2
+		ORDER BY user_id LIMIT $3 OFFSET $4`,
+		groupID.Hex(), tenantID, limit, skip,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list group members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.GroupMember{}
+	for rows.Next() {
+		var groupHex, userHex string
+		var member models.GroupMember
+		if err := rows.Scan(&groupHex, &userHex, &member.AddedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode group members: %w", err)
+		}
+		if member.GroupID, err = primitive.ObjectIDFromHex(groupHex); err != nil {
+			return nil, 0, fmt.Errorf("invalid group ID %q: %w", groupHex, err)
+		}
+		if member.UserID, err = primitive.ObjectIDFromHex(userHex); err != nil {
+			return nil, 0, fmt.Errorf("invalid user ID %q: %w", userHex, err)
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list group members: %w", err)
+	}
+	return members, total, nil
+}
+
+// ListUserGroups returns one page of the groups the user is in ordered by ID. A NULL limit
+// returns every row, which is what a limit of 0 asks for.
+func (repo *PostgresGroupRepository) ListUserGroups(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Group, int64, error) {
+	tenantID := tenant.FromContext(ctx)
+	var total int64
+	if err := repo.pool.QueryRow(ctx,
+		`SELECT count(*) FROM group_members WHERE user_id = $1 AND tenant_id = $2`, userID.Hex(), tenantID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count user groups: %w", err)
+	}
+	groups, err := repo.queryGroups(ctx,
+		`SELECT g.id, g.name, g.description, g.permissions, g.created_at FROM group_members m
+		JOIN groups g ON g.id = m.group_id
+		WHERE m.user_id = $1 AND m.tenant_id = $2 ORDER BY m.group_id LIMIT NULLIF($3, 0) OFFSET $4`,
+		userID.Hex(), tenantID, limit, skip,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// DeleteUserMembers removes every member row of the user.
+func (repo *PostgresGroupRepository) DeleteUserMembers(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM group_members WHERE user_id = $1 AND tenant_id = $2`, userID.Hex(), tenant.FromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete user group members: %w", err)
+	}
+	return nil
+}
+
+func (repo *PostgresGroupRepository) queryGroups(ctx context.Context, query string, args ...interface{}) ([]models.Group, error) {
+	rows, err := repo.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		group, err := scanPostgresGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode groups: %w", err)
+		}
+		groups = append(groups, *group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	return groups, nil
+}
+
+func scanPostgresGroup(row pgx.Row) (*models.Group, error) {
+	var group models.Group
+	var id string
+	if err := row.Scan(&id, &group.Name, &group.Description, &group.Permissions, &group.CreatedAt); err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group ID %q: %w", id, err)
+	}
+	group.Id = objectID
+	return &group, nil
+}
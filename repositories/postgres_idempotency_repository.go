@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresIdempotencyRepository stores idempotency records in the idempotency_keys table,
+// which EnsureSchema creates alongside users.
+type PostgresIdempotencyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresIdempotencyRepository(pool *pgxpool.Pool) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{
+		pool: pool,
+	}
+}
+
+var _ IdempotencyStore = (*PostgresIdempotencyRepository)(nil)
+
+// Reserve inserts a pending row for key, taking over an expired one, or returns the row that
+// already holds it. Expired rows are purged along the way.
+func (repo *PostgresIdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now() AND key <> $1`, key); err != nil {
+		return nil, false, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	now := time.Now()
+	record := IdempotencyRecord{Key: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	tag, err := repo.pool.Exec(ctx,
+		`INSERT INTO idempotency_keys (key, fingerprint, created_at, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, completed = false, status = NULL,
+			header = NULL, body = NULL, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()`,
+		key, fingerprint, record.CreatedAt, record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return &record, true, nil
+	}
+
+	var existing IdempotencyRecord
+	var status *int
+	var header []byte
+	err = repo.pool.QueryRow(ctx,
+		`SELECT key, fingerprint, completed, status, header, body, created_at, expires_at FROM idempotency_keys WHERE key = $1`, key,
+	).Scan(&existing.Key, &existing.Fingerprint, &existing.Completed, &status, &header, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The holder released the key between our insert and lookup; let the client retry
+		return nil, false, fmt.Errorf("idempotency key %q was released concurrently", key)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+	if status != nil {
+		existing.Status = *status
+	}
+	if header != nil {
+		if err := json.Unmarshal(header, &existing.Header); err != nil {
+			return nil, false, fmt.Errorf("failed to decode idempotency key: %w", err)
+		}
+	}
+	return &existing, false, nil
+}
+
+// Complete stores the response for key.
+func (repo *PostgresIdempotencyRepository) Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error {
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+	_, err = repo.pool.Exec(ctx,
+		`UPDATE idempotency_keys SET completed = true, status = $2, header = $3, body = $4 WHERE key = $1`,
+		key, status, encodedHeader, body,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the row for key.
+func (repo *PostgresIdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes the rows of expired keys.
+func (repo *PostgresIdempotencyRepository) Purge(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := repo.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
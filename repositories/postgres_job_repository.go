@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const postgresJobColumns = "id, type, payload, status, attempts, max_attempts, run_at, last_error, created_at, finished_at, tenant_id"
+
+// PostgresJobRepository stores the job queue in the jobs table, which EnsureSchema creates
+// alongside users. Workers claim jobs with SKIP LOCKED, so they never wait on each other.
+type PostgresJobRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresJobRepository(pool *pgxpool.Pool) *PostgresJobRepository {
+	return &PostgresJobRepository{
+		pool: pool,
+	}
+}
+
+var _ JobStore = (*PostgresJobRepository)(nil)
+
+// Enqueue inserts a new job row in the tenant of ctx.
+func (repo *PostgresJobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO jobs (`+postgresJobColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		job.Id.Hex(), job.Type, []byte(job.Payload), job.Status, job.Attempts, job.MaxAttempts, job.RunAt,
+		job.LastError, job.CreatedAt, job.FinishedAt, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// Claim leases the longest due job of one of types, skipping jobs other workers are claiming.
+func (repo *PostgresJobRepository) Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*models.Job, error) {
+	row := repo.pool.QueryRow(ctx,
+		`UPDATE jobs SET status = $4, run_at = $3, attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM jobs WHERE status IN ($5, $4) AND type = ANY($1) AND run_at <= $2
+			ORDER BY run_at LIMIT 1 FOR UPDATE SKIP LOCKED
+		) RETURNING `+postgresJobColumns,
+		types, now, leaseUntil, models.JobRunning, models.JobPending,
+	)
+	job, err := scanPostgresJob(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// Release stores the outcome of a claimed job, unless it has been claimed again since, and
+// purges jobs finished longer than JobRetention ago.
+func (repo *PostgresJobRepository) Release(ctx context.Context, job *models.Job, attempt int) error {
+	tag, err := repo.pool.Exec(ctx,
+		`UPDATE jobs SET status = $3, run_at = $4, last_error = $5, finished_at = COALESCE($6, finished_at), attempts = $8
+		WHERE id = $1 AND attempts = $2 AND status = $7`,
+		job.Id.Hex(), attempt, job.Status, job.RunAt, job.LastError, job.FinishedAt, models.JobRunning, job.Attempts,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrJobLeaseLost
+	}
+
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM jobs WHERE finished_at < $1`, time.Now().Add(-JobRetention)); err != nil {
+		return fmt.Errorf("failed to purge jobs: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the job with the given ID, or ErrJobNotFound.
+func (repo *PostgresJobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Job, error) {
+	row := repo.pool.QueryRow(ctx,
+		`SELECT `+postgresJobColumns+` FROM jobs WHERE id = $1 AND tenant_id = $2`,
+		id.Hex(), tenant.FromContext(ctx),
+	)
+	job, err := scanPostgresJob(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job: %w", err)
+	}
+	return job, nil
+}
+
+// List returns one page of the jobs matching filter, newest first.
+func (repo *PostgresJobRepository) List(ctx context.Context, filter JobFilter, skip, limit int64) ([]models.Job, int64, error) {
+	const where = ` WHERE tenant_id = $1 AND ($2 = '' OR status = $2) AND ($3 = '' OR type = $3)`
+	args := []any{tenant.FromContext(ctx), filter.Status, filter.Type}
+
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM jobs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresJobColumns+` FROM jobs`+where+` ORDER BY id DESC LIMIT $4 OFFSET $5`,
+		append(args, limit, skip)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanPostgresJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode jobs: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return jobs, total, nil
+}
+
+// Requeue makes a dead job pending again.
+func (repo *PostgresJobRepository) Requeue(ctx context.Context, id primitive.ObjectID, at time.Time) (*models.Job, error) {
+	row := repo.pool.QueryRow(ctx,
+		`UPDATE jobs SET status = $4, attempts = 0, run_at = $3, finished_at = NULL
+		WHERE id = $1 AND tenant_id = $2 AND status = $5 RETURNING `+postgresJobColumns,
+		id.Hex(), tenant.FromContext(ctx), at, models.JobPending, models.JobDead,
+	)
+	job, err := scanPostgresJob(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := repo.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobNotDead
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue job: %w", err)
+	}
+	return job, nil
+}
+
+func scanPostgresJob(row pgx.Row) (*models.Job, error) {
+	var job models.Job
+	var id string
+	var payload []byte
+	err := row.Scan(&id, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt,
+		&job.LastError, &job.CreatedAt, &job.FinishedAt, &job.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	if job.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, fmt.Errorf("invalid job ID %q: %w", id, err)
+	}
+	job.Payload = payload
+	return &job, nil
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const postgresNotificationColumns = "id, user_id, type, message, data, read_at, created_at"
+
+// PostgresNotificationRepository stores notifications in the notifications table, which
+// EnsureSchema creates alongside users. Data is kept as a JSONB object, and notifications go
+// with their user through a foreign key.
+type PostgresNotificationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresNotificationRepository(pool *pgxpool.Pool) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{
+		pool: pool,
+	}
+}
+
+var _ NotificationStore = (*PostgresNotificationRepository)(nil)
+
+// Create inserts a notification row in the tenant of ctx and purges the user's notifications
+// older than NotificationRetention.
+func (repo *PostgresNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	var data []byte
+	if len(notification.Data) > 0 {
+		var err error
+		if data, err = json.Marshal(notification.Data); err != nil {
+			return fmt.Errorf("failed to encode notification data: %w", err)
+		}
+	}
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO notifications (`+postgresNotificationColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		notification.Id.Hex(), notification.UserID.Hex(), notification.Type, notification.Message, data,
+		notification.ReadAt, notification.CreatedAt, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert notification: %w", err)
+	}
+
+	_, err = repo.pool.Exec(ctx,
+		`DELETE FROM notifications WHERE user_id = $1 AND created_at < $2`,
+		notification.UserID.Hex(), time.Now().Add(-NotificationRetention),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to purge notifications: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of the user's notifications, newest first.
+func (repo *PostgresNotificationRepository) List(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, skip, limit int64) ([]models.Notification, int64, error) {
+	const where = ` WHERE user_id = $1 AND tenant_id = $2 AND ($3 = false OR read_at IS NULL)`
+	args := []any{userID.Hex(), tenant.FromContext(ctx), unreadOnly}
+
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM notifications`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresNotificationColumns+` FROM notifications`+where+` ORDER BY id DESC LIMIT $4 OFFSET $5`,
+		append(args, limit, skip)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		notification, err := scanPostgresNotification(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode notifications: %w", err)
+		}
+		notifications = append(notifications, *notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return notifications, total, nil
+}
+
+// MarkRead sets the read time of the user's notification unless it was read before.
+func (repo *PostgresNotificationRepository) MarkRead(ctx context.Context, userID, id primitive.ObjectID, at time.Time) (*models.Notification, error) {
+	row := repo.pool.QueryRow(ctx,
+		`UPDATE notifications SET read_at = COALESCE(read_at, $4)
+		WHERE id = $1 AND user_id = $2 AND tenant_id = $3 RETURNING `+postgresNotificationColumns,
+		id.Hex(), userID.Hex(), tenant.FromContext(ctx), at,
+	)
+	notification, err := scanPostgresNotification(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return notification, nil
+}
+
+// MarkAllRead sets the read time of every unread notification of the user.
+func (repo *PostgresNotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	tag, err := repo.pool.Exec(ctx,
+		`UPDATE notifications SET read_at = $3 WHERE user_id = $1 AND tenant_id = $2 AND read_at IS NULL`,
+		userID.Hex(), tenant.FromContext(ctx), at,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Delete removes the user's notification.
+func (repo *PostgresNotificationRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	tag, err := repo.pool.Exec(ctx,
+		`DELETE FROM notifications WHERE id = $1 AND user_id = $2 AND tenant_id = $3`,
+		id.Hex(), userID.Hex(), tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// DeleteUserNotifications removes every notification of the user. Deleting the user row does
+// this as well, but erased users keep their row.
+func (repo *PostgresNotificationRepository) DeleteUserNotifications(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := repo.pool.Exec(ctx,
+		`DELETE FROM notifications WHERE user_id = $1 AND tenant_id = $2`,
+		userID.Hex(), tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete user notifications: %w", err)
+	}
+	return nil
+}
+
+func scanPostgresNotification(row pgx.Row) (*models.Notification, error) {
+	var notification models.Notification
+	var id, userID string
+	var data []byte
+	if err := row.Scan(&id, &userID, &notification.Type, &notification.Message, &data, &notification.ReadAt, &notification.CreatedAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if notification.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, fmt.Errorf("invalid notification ID %q: %w", id, err)
+	}
+	if notification.UserID, err = primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, fmt.Errorf("invalid notification user ID %q: %w", userID, err)
+	}
+	if data != nil {
+		if err := json.Unmarshal(data, &notification.Data); err != nil {
+			return nil, fmt.Errorf("invalid notification data: %w", err)
+		}
+	}
+	return &notification, nil
+}
@@ -0,0 +1,265 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	postgresOrganizationColumns = "id, name, created_at"
+	postgresMembershipColumns   = "id, organization_id, user_id, role, status, invited_at, joined_at"
+)
+
+// PostgresOrganizationRepository stores organizations and their memberships in the
+// organizations and memberships tables, which EnsureSchema creates alongside users.
+// Memberships go with their organization or user through foreign keys.
+type PostgresOrganizationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresOrganizationRepository(pool *pgxpool.Pool) *PostgresOrganizationRepository {
+	return &PostgresOrganizationRepository{
+		pool: pool,
+	}
+}
+
+var _ OrganizationStore = (*PostgresOrganizationRepository)(nil)
+
+// Create inserts a new organization row in the tenant of ctx.
+func (repo *PostgresOrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO organizations (`+postgresOrganizationColumns+`, tenant_id) VALUES ($1, $2, $3, $4)`,
+		org.Id.Hex(), org.Name, org.CreatedAt, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert organization: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the organization with the given ID, or ErrOrganizationNotFound.
+func (repo *PostgresOrganizationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error) {
+	row := repo.pool.QueryRow(ctx,
+		`SELECT `+postgresOrganizationColumns+` FROM organizations WHERE id = $1 AND tenant_id = $2`,
+		id.Hex(), tenant.FromContext(ctx),
+	)
+	org, err := scanPostgresOrganization(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organization: %w", err)
+	}
+	return org, nil
+}
+
+// Replace overwrites the organization row with the same ID.
+func (repo *PostgresOrganizationRepository) Replace(ctx context.Context, org *models.Organization) error {
+	tag, err := repo.pool.Exec(ctx,
+		`UPDATE organizations SET name = $2, created_at = $3 WHERE id = $1 AND tenant_id = $4`,
+		org.Id.Hex(), org.Name, org.CreatedAt, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+// Delete removes the organization with the given ID; its memberships go with it through the
+// foreign key.
+func (repo *PostgresOrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM organizations WHERE id = $1 AND tenant_id = $2`, id.Hex(), tenant.FromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of organizations ordered by ID, along with the total number of
+// organizations.
+func (repo *PostgresOrganizationRepository) List(ctx context.Context, skip, limit int64) ([]models.Organization, int64, error) {
+	tenantID := tenant.FromContext(ctx)
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM organizations WHERE tenant_id = $1`, tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count organizations: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresOrganizationColumns+` FROM organizations WHERE tenant_id = $1 ORDER BY id LIMIT $2 OFFSET $3`,
+		tenantID, limit, skip,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	orgs := []models.Organization{}
+	for rows.Next() {
+		org, err := scanPostgresOrganization(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode organizations: %w", err)
+		}
+		orgs = append(orgs, *org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return orgs, total, nil
+}
+
+// AddMembership inserts a new membership row in the tenant of ctx.
+func (repo *PostgresOrganizationRepository) AddMembership(ctx context.Context, membership *models.Membership) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO memberships (`+postgresMembershipColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		membership.Id.Hex(), membership.OrganizationID.Hex(), membership.UserID.Hex(), membership.Role,
+		membership.Status, membership.InvitedAt, membership.JoinedAt, tenant.FromContext(ctx),
+	)
+	if isUniqueViolation(err) {
+		return ErrAlreadyMember
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert membership: %w", err)
+	}
+	return nil
+}
+
+// GetMembership returns the user's membership of the organization, or ErrMembershipNotFound.
+func (repo *PostgresOrganizationRepository) GetMembership(ctx context.Context, orgID, userID primitive.ObjectID) (*models.Membership, error) {
+	row := repo.pool.QueryRow(ctx,
+		`SELECT `+postgresMembershipColumns+` FROM memberships WHERE organization_id = $1 AND user_id = $2 AND tenant_id = $3`,
+		orgID.Hex(), userID.Hex(), tenant.FromContext(ctx),
+	)
+	membership, err := scanPostgresMembership(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMembershipNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find membership: %w", err)
+	}
+	return membership, nil
+}
+
+// ReplaceMembership overwrites the role, status, and join time of the membership row with the
+// same ID.
+func (repo *PostgresOrganizationRepository) ReplaceMembership(ctx context.Context, membership *models.Membership) error {
+	tag, err := repo.pool.Exec(ctx,
+		`UPDATE memberships SET role = $2, status = $3, joined_at = $4 WHERE id = $1 AND tenant_id = $5`,
+		membership.Id.Hex(), membership.Role, membership.Status, membership.JoinedAt, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update membership: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMembershipNotFound
+	}
+	return nil
+}
+
+// RemoveMembership deletes the user's membership of the organization.
+func (repo *PostgresOrganizationRepository) RemoveMembership(ctx context.Context, orgID, userID primitive.ObjectID) error {
+	tag, err := repo.pool.Exec(ctx,
+		`DELETE FROM memberships WHERE organization_id = $1 AND user_id = $2 AND tenant_id = $3`,
+		orgID.Hex(), userID.Hex(), tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMembershipNotFound
+	}
+	return nil
+}
+
+// ListMembers returns one page of the organization's memberships in the order they were created.
+func (repo *PostgresOrganizationRepository) ListMembers(ctx context.Context, orgID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error) {
+	return repo.listMemberships(ctx, "organization_id", orgID, skip, limit)
+}
+
+// ListUserMemberships returns one page of the user's memberships in the order they were created.
+func (repo *PostgresOrganizationRepository) ListUserMemberships(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error) {
+	return repo.listMemberships(ctx, "user_id", userID, skip, limit)
+}
+
+// listMemberships returns one page of the memberships whose column holds id. IDs are hex
+// ObjectIDs, which start with their creation time, so ordering by them orders by age.
+func (repo *PostgresOrganizationRepository) listMemberships(ctx context.Context, column string, id primitive.ObjectID, skip, limit int64) ([]models.Membership, int64, error) {
+	where := `WHERE ` + column + ` = $1 AND tenant_id = $2`
+	tenantID := tenant.FromContext(ctx)
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM memberships `+where, id.Hex(), tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count memberships: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresMembershipColumns+` FROM memberships `+where+` ORDER BY id LIMIT $3 OFFSET $4`,
+		id.Hex(), tenantID, limit, skip,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	defer rows.Close()
+
+	memberships := []models.Membership{}
+	for rows.Next() {
+		membership, err := scanPostgresMembership(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode memberships: %w", err)
+		}
+		memberships = append(memberships, *membership)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	return memberships, total, nil
+}
+
+// DeleteUserMemberships removes every membership of the user. Deleting the user row already
+// does so through the foreign key, so this only matters to callers that keep the row.
+func (repo *PostgresOrganizationRepository) DeleteUserMemberships(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM memberships WHERE user_id = $1 AND tenant_id = $2`, userID.Hex(), tenant.FromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete user memberships: %w", err)
+	}
+	return nil
+}
+
+func scanPostgresOrganization(row pgx.Row) (*models.Organization, error) {
+	var org models.Organization
+	var id string
+	if err := row.Scan(&id, &org.Name, &org.CreatedAt); err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid organization ID %q: %w", id, err)
+	}
+	org.Id = objectID
+	return &org, nil
+}
+
+func scanPostgresMembership(row pgx.Row) (*models.Membership, error) {
+	var membership models.Membership
+	var id, orgID, userID string
+	err := row.Scan(&id, &orgID, &userID, &membership.Role, &membership.Status, &membership.InvitedAt, &membership.JoinedAt)
+	if err != nil {
+		return nil, err
+	}
+	if membership.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, fmt.Errorf("invalid membership ID %q: %w", id, err)
+	}
+	if membership.OrganizationID, err = primitive.ObjectIDFromHex(orgID); err != nil {
+		return nil, fmt.Errorf("invalid organization ID %q: %w", orgID, err)
+	}
+	if membership.UserID, err = primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+	return &membership, nil
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostgresPasswordHistoryRepository stores password histories in the password_history table,
+// one row per user holding their previous hashes newest first. Deleting the user removes it.
+type PostgresPasswordHistoryRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresPasswordHistoryRepository(pool *pgxpool.Pool) *PostgresPasswordHistoryRepository {
+	return &PostgresPasswordHistoryRepository{
+		pool: pool,
+	}
+}
+
+var _ PasswordHistoryStore = (*PostgresPasswordHistoryRepository)(nil)
+
+// Add prepends hash to the user's row and trims it to keep in one statement.
+func (repo *PostgresPasswordHistoryRepository) Add(ctx context.Context, userID primitive.ObjectID, hash string, keep int) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO password_history (user_id, hashes) VALUES ($1, ARRAY[$2::text])
+		ON CONFLICT (user_id) DO UPDATE SET hashes = (ARRAY[$2::text] || password_history.hashes)[1:$3]`,
+		userID.Hex(), hash, keep,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record password history: %w", err)
+	}
+	return nil
+}
+
+// List reads the user's row.
+func (repo *PostgresPasswordHistoryRepository) List(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	var hashes []string
+	err := repo.pool.QueryRow(ctx, `SELECT hashes FROM password_history WHERE user_id = $1`, userID.Hex()).Scan(&hashes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find password history: %w", err)
+	}
+	return hashes, nil
+}
+
+// Delete removes the user's row.
+func (repo *PostgresPasswordHistoryRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM password_history WHERE user_id = $1`, userID.Hex()); err != nil {
+		return fmt.Errorf("failed to delete password history: %w", err)
+	}
+	return nil
+}
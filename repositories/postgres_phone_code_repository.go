@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostgresPhoneCodeRepository stores phone verification codes in the phone_codes table. Each
+// user has at most one row, which the next code replaces and confirming or deleting the user
+// removes, so expired rows are not purged.
+type PostgresPhoneCodeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresPhoneCodeRepository(pool *pgxpool.Pool) *PostgresPhoneCodeRepository {
+	return &PostgresPhoneCodeRepository{
+		pool: pool,
+	}
+}
+
+var _ PhoneCodeStore = (*PostgresPhoneCodeRepository)(nil)
+
+// Put upserts the user's row.
+func (repo *PostgresPhoneCodeRepository) Put(ctx context.Context, code *PhoneCode) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO phone_codes (user_id, phone, code_hash, attempts, sent_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET phone = EXCLUDED.phone, code_hash = EXCLUDED.code_hash,
+			attempts = EXCLUDED.attempts, sent_at = EXCLUDED.sent_at, expires_at = EXCLUDED.expires_at`,
+		code.UserID.Hex(), code.Phone, code.CodeHash, code.Attempts, code.SentAt, code.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store phone code: %w", err)
+	}
+	return nil
+}
+
+// Get reads the user's row unless it has expired.
+func (repo *PostgresPhoneCodeRepository) Get(ctx context.Context, userID primitive.ObjectID) (*PhoneCode, error) {
+	code := PhoneCode{UserID: userID}
+	err := repo.pool.QueryRow(ctx,
+		`SELECT phone, code_hash, attempts, sent_at, expires_at FROM phone_codes WHERE user_id = $1 AND expires_at > now()`,
+		userID.Hex(),
+	).Scan(&code.Phone, &code.CodeHash, &code.Attempts, &code.SentAt, &code.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPhoneCodeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find phone code: %w", err)
+	}
+	return &code, nil
+}
+
+// AddAttempt increments the attempts of the user's unexpired row.
+func (repo *PostgresPhoneCodeRepository) AddAttempt(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var attempts int
+	err := repo.pool.QueryRow(ctx,
+		`UPDATE phone_codes SET attempts = attempts + 1 WHERE user_id = $1 AND expires_at > now() RETURNING attempts`,
+		userID.Hex(),
+	).Scan(&attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrPhoneCodeNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count phone code attempt: %w", err)
+	}
+	return attempts, nil
+}
+
+// Delete removes the user's row.
+func (repo *PostgresPhoneCodeRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM phone_codes WHERE user_id = $1`, userID.Hex()); err != nil {
+		return fmt.Errorf("failed to delete phone code: %w", err)
+	}
+	return nil
+}
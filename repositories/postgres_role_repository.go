@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const postgresRoleColumns = "id, name, description, permissions, created_at"
+
+// PostgresRoleRepository stores roles in the roles table, which EnsureSchema creates alongside
+// users.
+type PostgresRoleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRoleRepository(pool *pgxpool.Pool) *PostgresRoleRepository {
+	return &PostgresRoleRepository{
+		pool: pool,
+	}
+}
+
+var _ RoleStore = (*PostgresRoleRepository)(nil)
+
+// Create inserts a new role row in the tenant of ctx.
+func (repo *PostgresRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO roles (`+postgresRoleColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6)`,
+		role.Id.Hex(), role.Name, role.Description, role.Permissions, role.CreatedAt, tenant.FromContext(ctx),
+	)
+	if isUniqueViolation(err) {
+		return ErrRoleExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert role: %w", err)
+	}
+	return nil
+}
+
+// GetByName returns the role with the given name, or ErrRoleNotFound.
+func (repo *PostgresRoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	row := repo.pool.QueryRow(ctx,
+		`SELECT `+postgresRoleColumns+` FROM roles WHERE name = $1 AND tenant_id = $2`,
+		name, tenant.FromContext(ctx),
+	)
+	role, err := scanPostgresRole(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find role: %w", err)
+	}
+	return role, nil
+}
+
+// Replace overwrites the description and permissions of the role row with the same name.
+func (repo *PostgresRoleRepository) Replace(ctx context.Context, role *models.Role) error {
+	tag, err := repo.pool.Exec(ctx,
+		`UPDATE roles SET description = $2, permissions = $3 WHERE name = $1 AND tenant_id = $4`,
+		role.Name, role.Description, role.Permissions, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// Delete removes the role with the given name.
+func (repo *PostgresRoleRepository) Delete(ctx context.Context, name string) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM roles WHERE name = $1 AND tenant_id = $2`, name, tenant.FromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of roles ordered by name, along with the total number of roles.
+func (repo *PostgresRoleRepository) List(ctx context.Context, skip, limit int64) ([]models.Role, int64, error) {
+	tenantID := tenant.FromContext(ctx)
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM roles WHERE tenant_id = $1`, tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count roles: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresRoleColumns+` FROM roles WHERE tenant_id = $1 ORDER BY name LIMIT $2 OFFSET $3`,
+		tenantID, limit, skip,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanPostgresRole(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode roles: %w", err)
+		}
+		roles = append(roles, *role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, total, nil
+}
+
+func scanPostgresRole(row pgx.Row) (*models.Role, error) {
+	var role models.Role
+	var id string
+	if err := row.Scan(&id, &role.Name, &role.Description, &role.Permissions, &role.CreatedAt); err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid role ID %q: %w", id, err)
+	}
+	role.Id = objectID
+	return &role, nil
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const postgresTaskRunColumns = "id, task, scheduled_at, started_at, finished_at, status, affected, error"
+
+// PostgresTaskRunRepository stores the history of scheduled tasks in the task_runs table, which
+// EnsureSchema creates alongside users. A unique constraint on the task and scheduled time lets
+// one instance start each run.
+type PostgresTaskRunRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresTaskRunRepository(pool *pgxpool.Pool) *PostgresTaskRunRepository {
+	return &PostgresTaskRunRepository{
+		pool: pool,
+	}
+}
+
+var _ TaskRunStore = (*PostgresTaskRunRepository)(nil)
+
+// Start inserts a run row unless its task already has one for the same scheduled time, and
+// purges runs older than TaskRunRetention.
+func (repo *PostgresTaskRunRepository) Start(ctx context.Context, run *models.TaskRun) (bool, error) {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM task_runs WHERE started_at < $1`, time.Now().Add(-TaskRunRetention)); err != nil {
+		return false, fmt.Errorf("failed to purge task runs: %w", err)
+	}
+	tag, err := repo.pool.Exec(ctx,
+		`INSERT INTO task_runs (`+postgresTaskRunColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (task, scheduled_at) DO NOTHING`,
+		run.Id.Hex(), run.Task, run.ScheduledAt, run.StartedAt, run.FinishedAt, run.Status, run.Affected, run.Error,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to start task run: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Finish stores the outcome of run.
+func (repo *PostgresTaskRunRepository) Finish(ctx context.Context, run *models.TaskRun) error {
+	_, err := repo.pool.Exec(ctx,
+		`UPDATE task_runs SET status = $2, finished_at = $3, affected = $4, error = $5 WHERE id = $1`,
+		run.Id.Hex(), run.Status, run.FinishedAt, run.Affected, run.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish task run: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of the runs of task, newest first.
+func (repo *PostgresTaskRunRepository) List(ctx context.Context, task string, skip, limit int64) ([]models.TaskRun, int64, error) {
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM task_runs WHERE task = $1`, task).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count task runs: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresTaskRunColumns+` FROM task_runs WHERE task = $1 ORDER BY scheduled_at DESC LIMIT $2 OFFSET $3`,
+		task, limit, skip,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list task runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.TaskRun{}
+	for rows.Next() {
+		run, err := scanPostgresTaskRun(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode task runs: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list task runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// scanPostgresTaskRun reads a row selected with postgresTaskRunColumns.
+func scanPostgresTaskRun(row pgx.Row) (*models.TaskRun, error) {
+	var run models.TaskRun
+	var id string
+	err := row.Scan(&id, &run.Task, &run.ScheduledAt, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Affected, &run.Error)
+	if err != nil {
+		return nil, err
+	}
+	if run.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, fmt.Errorf("invalid task run ID %q: %w", id, err)
+	}
+	return &run, nil
+}
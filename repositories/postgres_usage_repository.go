@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresUsageRepository stores API usage in the api_usage table, which EnsureSchema creates
+// alongside users, one row per tenant, client, and day.
+type PostgresUsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresUsageRepository(pool *pgxpool.Pool) *PostgresUsageRepository {
+	return &PostgresUsageRepository{
+		pool: pool,
+	}
+}
+
+var _ UsageStore = (*PostgresUsageRepository)(nil)
+
+// Add increments the counts of the record rows in the tenant of ctx in one round trip.
+func (repo *PostgresUsageRepository) Add(ctx context.Context, records []models.Usage) error {
+	if len(records) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	tenantID := tenant.FromContext(ctx)
+	for _, record := range records {
+		batch.Queue(`
+			INSERT INTO api_usage (tenant_id, client, day, requests, bytes_in, bytes_out) VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (tenant_id, client, day) DO UPDATE SET requests = api_usage.requests + EXCLUDED.requests,
+				bytes_in = api_usage.bytes_in + EXCLUDED.bytes_in, bytes_out = api_usage.bytes_out + EXCLUDED.bytes_out`,
+			tenantID, record.Client, record.Day, record.Requests, record.BytesIn, record.BytesOut,
+		)
+	}
+	if err := repo.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to add usage: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of the records matching filter, newest day first.
+func (repo *PostgresUsageRepository) List(ctx context.Context, filter UsageFilter, skip, limit int64) ([]models.Usage, int64, error) {
+	// Empty filter values match every row
+	where := `WHERE ($1 = '' OR client = $1) AND ($2 = '' OR day >= $2::date) AND ($3 = '' OR day <= $3::date) AND tenant_id = $4`
+	args := []interface{}{filter.Client, filter.From, filter.To, tenant.FromContext(ctx)}
+
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM api_usage `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count usage: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT client, day, requests, bytes_in, bytes_out FROM api_usage `+where+` ORDER BY day DESC, client LIMIT $5 OFFSET $6`,
+		append(args, limit, skip)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list usage: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.Usage{}
+	for rows.Next() {
+		var record models.Usage
+		var day time.Time
+		if err := rows.Scan(&record.Client, &day, &record.Requests, &record.BytesIn, &record.BytesOut); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode usage: %w", err)
+		}
+		record.Day = day.Format(time.DateOnly)
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list usage: %w", err)
+	}
+	return records, total, nil
+}
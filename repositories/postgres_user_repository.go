@@ -0,0 +1,512 @@
+package repositories
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	models "example_api/models"
+	"example_api/tenant"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:embed schema/postgres.sql
+var postgresSchema string
+
+// postgresColumns maps user field names to their Postgres columns.
+var postgresColumns = map[string]string{
+	"email":           "email",
+	"password":        "password",
+	"firstName":       "first_name",
+	"lastName":        "last_name",
+	"phone":           "phone",
+	"addresses":       "addresses",
+	"preferences":     "preferences",
+	"metadata":        "metadata",
+	"role":            "role",
+	"joinDate":        "join_date",
+	"erasedAt":        "erased_at",
+	"phoneVerifiedAt": "phone_verified_at",
+}
+
+const postgresUserColumns = "id, email, password, first_name, last_name, role, join_date, version, erased_at, phone, phone_verified_at, addresses, preferences, metadata"
+
+// PostgresUserRepository stores users in PostgreSQL. IDs keep the ObjectID hex format
+// so they are interchangeable with the MongoDB backend.
+type PostgresUserRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresUserRepository(pool *pgxpool.Pool) *PostgresUserRepository {
+	return &PostgresUserRepository{
+		pool: pool,
+	}
+}
+
+var _ UserStore = (*PostgresUserRepository)(nil)
+
+// EnsureSchema creates the users, idempotency_keys, webhook, avatars, and audit_logs tables if
+// they do not already exist.
+func (repo *PostgresUserRepository) EnsureSchema(ctx context.Context) error {
+	if _, err := repo.pool.Exec(ctx, postgresSchema); err != nil {
+		return fmt.Errorf("failed to apply Postgres schema: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new user row in the tenant of ctx.
+func (repo *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	addresses, preferences, metadata, err := encodePostgresUserDocuments(user)
+	if err != nil {
+		return err
+	}
+	_, err = repo.pool.Exec(ctx,
+		`INSERT INTO users (`+postgresUserColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		user.Id.Hex(), user.Email, user.Password, user.FirstName, user.LastName, user.Role, user.JoinDate, user.Version, user.ErasedAt, user.Phone, user.PhoneVerifiedAt, addresses, preferences, metadata, tenant.FromContext(ctx),
+	)
+	if isUniqueViolation(err) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+	return nil
+}
+
+// CreateMany inserts user rows in one round trip. Rows conflicting with an existing email are
+// skipped rather than failing the batch.
+func (repo *PostgresUserRepository) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	batch := &pgx.Batch{}
+	tenantID := tenant.FromContext(ctx)
+	for _, user := range users {
+		addresses, preferences, metadata, err := encodePostgresUserDocuments(user)
+		if err != nil {
+			return nil, err
+		}
+		batch.Queue(
+			`INSERT INTO users (`+postgresUserColumns+`, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) ON CONFLICT DO NOTHING`,
+			user.Id.Hex(), user.Email, user.Password, user.FirstName, user.LastName, user.Role, user.JoinDate, user.Version, user.ErasedAt, user.Phone, user.PhoneVerifiedAt, addresses, preferences, metadata, tenantID,
+		)
+	}
+	results := repo.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	errs := make([]error, len(users))
+	for i := range users {
+		tag, err := results.Exec()
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert users: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			errs[i] = ErrEmailTaken
+		}
+	}
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("failed to insert users: %w", err)
+	}
+	return errs, nil
+}
+
+// GetByID returns the user with the given ID, or ErrUserNotFound.
+func (repo *PostgresUserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	row := repo.pool.QueryRow(ctx, `SELECT `+postgresUserColumns+` FROM users WHERE id = $1 AND tenant_id = $2`, id.Hex(), tenant.FromContext(ctx))
+	user, err := scanPostgresUser(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return user, nil
+}
+
+// Update sets the given fields on the user with the given ID if it is still at version.
+func (repo *PostgresUserRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	assignments := make([]string, 0, len(keys))
+	args := make([]interface{}, 0, len(keys)+2)
+	for _, key := range keys {
+		column, ok := postgresColumns[key]
+		if !ok {
+			return fmt.Errorf("failed to update user: unknown field %q", key)
+		}
+		value := fields[key]
+		switch document := value.(type) {
+		case []models.Address:
+			encoded, err := encodePostgresAddresses(document)
+			if err != nil {
+				return err
+			}
+			value = encoded
+		case models.Preferences:
+			encoded, err := encodePostgresPreferences(&document)
+			if err != nil {
+				return err
+			}
+			value = encoded
+		case models.Metadata:
+			encoded, err := encodePostgresMetadata(document)
+			if err != nil {
+				return err
+			}
+			value = encoded
+		}
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	assignments = append(assignments, "version = version + 1")
+	args = append(args, id.Hex(), tenant.FromContext(ctx))
+	conditions := fmt.Sprintf("id = $%d AND tenant_id = $%d", len(args)-1, len(args))
+	if version != AnyVersion {
+		args = append(args, version)
+		conditions += fmt.Sprintf(" AND version = $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`UPDATE users SET %s WHERE %s`, strings.Join(assignments, ", "), conditions)
+	tag, err := repo.pool.Exec(ctx, query, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailTaken
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if tag.RowsAffected() > 0 || version == AnyVersion {
+		return nil
+	}
+
+	// Nothing matched: either the user is gone or its version moved on
+	var exists bool
+	if err := repo.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND tenant_id = $2)`, id.Hex(), tenant.FromContext(ctx)).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Delete removes the user with the given ID.
+func (repo *PostgresUserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM users WHERE id = $1 AND tenant_id = $2`, id.Hex(), tenant.FromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of matching users ordered by ID, along with the total number of matches.
+func (repo *PostgresUserRepository) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	// Empty filter values match every row
+	where := `WHERE tenant_id = $1 AND ($2 = '' OR role = $2) AND ($3 = '' OR email = $3) AND ($4 = '' OR id > $4)`
+	var afterID string
+	if !opts.AfterID.IsZero() {
+		afterID = opts.AfterID.Hex()
+	}
+	args := []interface{}{tenant.FromContext(ctx), opts.Filter.Role, opts.Filter.Email, afterID}
+	if opts.IDs != nil {
+		ids := make([]string, len(opts.IDs))
+		for i, id := range opts.IDs {
+			ids[i] = id.Hex()
+		}
+		args = append(args, ids)
+		where += fmt.Sprintf(" AND id = ANY($%d)", len(args))
+	}
+	if opts.Where != nil {
+		where += " AND " + postgresFilterExpr(opts.Where, &args)
+	}
+
+	var total int64
+	if !opts.SkipTotal {
+		if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM users `+where, args...).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("failed to count users: %w", err)
+		}
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		fmt.Sprintf(`SELECT `+postgresUserColumns+` FROM users `+where+` ORDER BY `+postgresOrder(opts.Sort)+` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2),
+		append(args, opts.Limit, opts.Skip)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanPostgresUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode users: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// postgresFilterExpr translates expr into a condition, appending its values to args as
+// parameters. Contains matches escape the LIKE wildcards of their value.
+func postgresFilterExpr(expr *FilterExpr, args *[]interface{}) string {
+	if len(expr.And) > 0 || len(expr.Or) > 0 {
+		operands, op := expr.And, " AND "
+		if len(expr.Or) > 0 {
+			operands, op = expr.Or, " OR "
+		}
+		conditions := make([]string, len(operands))
+		for i := range operands {
+			conditions[i] = postgresFilterExpr(&operands[i], args)
+		}
+		return "(" + strings.Join(conditions, op) + ")"
+	}
+
+	column := "version"
+	if key, ok := strings.CutPrefix(expr.Field, "metadata."); ok {
+		// Only string values compare, and a missing key is empty, as on the other backends
+		*args = append(*args, key)
+		column = fmt.Sprintf("COALESCE(CASE WHEN jsonb_typeof(metadata->$%[1]d::text) = 'string' THEN metadata->>$%[1]d::text END, '')", len(*args))
+	} else if expr.Field != "version" {
+		column = postgresColumns[expr.Field]
+	}
+	value, op := expr.Value, string(expr.Op)
+	switch expr.Op {
+	case FilterNe:
+		op = "<>"
+	case FilterContains:
+		op = "ILIKE"
+		value = "%" + postgresLikeEscaper.Replace(value.(string)) + "%"
+	}
+	*args = append(*args, value)
+	return fmt.Sprintf("%s %s $%d", column, op, len(*args))
+}
+
+// postgresLikeEscaper escapes the wildcards of LIKE patterns, which use \ as escape character.
+var postgresLikeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// postgresOrder translates sort into an ORDER BY list, breaking ties by id. Strings sort by
+// byte, as on the other backends, rather than by the database collation.
+func postgresOrder(sort []SortField) string {
+	order := withIDOrder(sort)
+	terms := make([]string, len(order))
+	for i, field := range order {
+		column := "id"
+		if field.Field != "id" {
+			column = postgresColumns[field.Field]
+		}
+		if field.Field != "id" && field.Field != "joinDate" {
+			column += ` COLLATE "C"`
+		}
+		if field.Descending {
+			column += " DESC"
+		}
+		terms[i] = column
+	}
+	return strings.Join(terms, ", ")
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func scanPostgresUser(row pgx.Row) (*models.User, error) {
+	var user models.User
+	var id string
+	var addresses, preferences, metadata []byte
+	if err := row.Scan(&id, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Role, &user.JoinDate, &user.Version, &user.ErasedAt, &user.Phone, &user.PhoneVerifiedAt, &addresses, &preferences, &metadata); err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID %q: %w", id, err)
+	}
+	user.Id = objectID
+	if err := decodePostgresUserDocuments(&user, addresses, preferences, metadata); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// encodePostgresUserDocuments encodes the addresses, preferences, and metadata of user for their
+// JSONB columns.
+func encodePostgresUserDocuments(user *models.User) (addresses, preferences, metadata []byte, err error) {
+	if addresses, err = encodePostgresAddresses(user.Addresses); err != nil {
+		return nil, nil, nil, err
+	}
+	if preferences, err = encodePostgresPreferences(user.Preferences); err != nil {
+		return nil, nil, nil, err
+	}
+	if metadata, err = encodePostgresMetadata(user.Metadata); err != nil {
+		return nil, nil, nil, err
+	}
+	return addresses, preferences, metadata, nil
+}
+
+// encodePostgresAddresses encodes addresses for the addresses column, which is NULL for a user
+// without any.
+func encodePostgresAddresses(addresses []models.Address) ([]byte, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(addresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user addresses: %w", err)
+	}
+	return data, nil
+}
+
+// encodePostgresPreferences encodes preferences for the preferences column, which is NULL for a
+// user who has not set any.
+func encodePostgresPreferences(preferences *models.Preferences) ([]byte, error) {
+	if preferences == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user preferences: %w", err)
+	}
+	return data, nil
+}
+
+// encodePostgresMetadata encodes metadata for the metadata column, which is NULL for a user
+// without any.
+func encodePostgresMetadata(metadata models.Metadata) ([]byte, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user metadata: %w", err)
+	}
+	return data, nil
+}
+
+// decodePostgresUserDocuments sets the addresses, preferences, and metadata of user from their
+// JSONB columns.
+func decodePostgresUserDocuments(user *models.User, addresses, preferences, metadata []byte) error {
+	if addresses != nil {
+		if err := json.Unmarshal(addresses, &user.Addresses); err != nil {
+			return fmt.Errorf("invalid user addresses: %w", err)
+		}
+	}
+	if preferences != nil {
+		user.Preferences = &models.Preferences{}
+		if err := json.Unmarshal(preferences, user.Preferences); err != nil {
+			return fmt.Errorf("invalid user preferences: %w", err)
+		}
+	}
+	if metadata != nil {
+		if err := json.Unmarshal(metadata, &user.Metadata); err != nil {
+			return fmt.Errorf("invalid user metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// CountUsers counts users in two queries: the totals with one filtered count per time in since,
+// and the users per role.
+func (repo *PostgresUserRepository) CountUsers(ctx context.Context, since []time.Time) (*UserCounts, error) {
+	var query strings.Builder
+	query.WriteString(`SELECT count(*), count(*) FILTER (WHERE erased_at IS NOT NULL)`)
+	tenantID := tenant.FromContext(ctx)
+	args := []interface{}{tenantID}
+	for _, t := range since {
+		args = append(args, t)
+		fmt.Fprintf(&query, `, count(*) FILTER (WHERE join_date >= $%d)`, len(args))
+	}
+	query.WriteString(` FROM users WHERE tenant_id = $1`)
+
+	counts := &UserCounts{ByRole: map[string]int64{}, JoinedSince: make([]int64, len(since))}
+	dest := []interface{}{&counts.Total, &counts.Erased}
+	for i := range counts.JoinedSince {
+		dest = append(dest, &counts.JoinedSince[i])
+	}
+	if err := repo.pool.QueryRow(ctx, query.String(), args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx, `SELECT role, count(*) FROM users WHERE tenant_id = $1 GROUP BY role`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role string
+		var n int64
+		if err := rows.Scan(&role, &n); err != nil {
+			return nil, fmt.Errorf("failed to decode user counts: %w", err)
+		}
+		counts.ByRole[role] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+	return counts, nil
+}
+
+// postgresSearchDocument is the text search document of a user row. It must match the
+// expression of users_search_idx for the index to be used. Splitting the email at @ and dots
+// lets its domain be searched too.
+const postgresSearchDocument = `to_tsvector('simple', translate(email, '@.', '  ') || ' ' || first_name || ' ' || last_name)`
+
+// SearchUsers matches users with a prefix text search query, ranked by ts_rank.
+func (repo *PostgresUserRepository) SearchUsers(ctx context.Context, query string, limit int64) ([]UserMatch, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []UserMatch{}, nil
+	}
+	// Terms hold only letters and digits, so they cannot inject tsquery operators
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresUserColumns+`, ts_rank(`+postgresSearchDocument+`, query) AS score
+		FROM users, to_tsquery('simple', $1) AS query
+		WHERE tenant_id = $3 AND erased_at IS NULL AND `+postgresSearchDocument+` @@ query
+		ORDER BY score DESC, id LIMIT $2`,
+		strings.Join(prefixes, " & "), limit, tenant.FromContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []UserMatch{}
+	for rows.Next() {
+		var match UserMatch
+		var score float32
+		var id string
+		var addresses, preferences, metadata []byte
+		user := &match.User
+		if err := rows.Scan(&id, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Role, &user.JoinDate, &user.Version, &user.ErasedAt, &user.Phone, &user.PhoneVerifiedAt, &addresses, &preferences, &metadata, &score); err != nil {
+			return nil, fmt.Errorf("failed to decode users: %w", err)
+		}
+		if user.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, fmt.Errorf("invalid user ID %q: %w", id, err)
+		}
+		if err := decodePostgresUserDocuments(user, addresses, preferences, metadata); err != nil {
+			return nil, err
+		}
+		match.Score = float64(score)
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	return matches, nil
+}
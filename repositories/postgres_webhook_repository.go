@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	postgresWebhookColumns  = "id, url, secret, events, created_at"
+	postgresDeliveryColumns = "id, delivery_id, webhook_id, event_id, event_type, attempt, status_code, error, succeeded, duration_ms, created_at"
+)
+
+// PostgresWebhookRepository stores webhooks and their delivery log in the webhooks and
+// webhook_deliveries tables, which EnsureSchema creates alongside users.
+type PostgresWebhookRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresWebhookRepository(pool *pgxpool.Pool) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{
+		pool: pool,
+	}
+}
+
+var _ WebhookStore = (*PostgresWebhookRepository)(nil)
+
+// Create inserts a new webhook row.
+func (repo *PostgresWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO webhooks (`+postgresWebhookColumns+`) VALUES ($1, $2, $3, $4, $5)`,
+		webhook.Id.Hex(), webhook.URL, webhook.Secret, webhook.Events, webhook.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the webhook with the given ID, or ErrWebhookNotFound.
+func (repo *PostgresWebhookRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	row := repo.pool.QueryRow(ctx, `SELECT `+postgresWebhookColumns+` FROM webhooks WHERE id = $1`, id.Hex())
+	webhook, err := scanPostgresWebhook(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// Replace overwrites the webhook row with the same ID.
+func (repo *PostgresWebhookRepository) Replace(ctx context.Context, webhook *models.Webhook) error {
+	tag, err := repo.pool.Exec(ctx,
+		`UPDATE webhooks SET url = $2, secret = $3, events = $4, created_at = $5 WHERE id = $1`,
+		webhook.Id.Hex(), webhook.URL, webhook.Secret, webhook.Events, webhook.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Delete removes the webhook with the given ID; its deliveries go with it through the foreign key.
+func (repo *PostgresWebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id.Hex()); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of webhooks ordered by ID, along with the total number of webhooks.
+func (repo *PostgresWebhookRepository) List(ctx context.Context, skip, limit int64) ([]models.Webhook, int64, error) {
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM webhooks`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+
+	webhooks, err := repo.queryWebhooks(ctx, `SELECT `+postgresWebhookColumns+` FROM webhooks ORDER BY id LIMIT $1 OFFSET $2`, limit, skip)
+	if err != nil {
+		return nil, 0, err
+	}
+	return webhooks, total, nil
+}
+
+// ListByEvent returns every webhook whose events include eventType.
+func (repo *PostgresWebhookRepository) ListByEvent(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	return repo.queryWebhooks(ctx, `SELECT `+postgresWebhookColumns+` FROM webhooks WHERE $1 = ANY (events)`, eventType)
+}
+
+func (repo *PostgresWebhookRepository) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]models.Webhook, error) {
+	rows, err := repo.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanPostgresWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// RecordDelivery inserts a delivery attempt unless the webhook has been deleted in the meantime,
+// and purges the webhook's attempts older than DeliveryRetention.
+func (repo *PostgresWebhookRepository) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := repo.pool.Exec(ctx,
+		`INSERT INTO webhook_deliveries (`+postgresDeliveryColumns+`)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11 WHERE EXISTS (SELECT 1 FROM webhooks WHERE id = $3)`,
+		delivery.Id.Hex(), delivery.DeliveryID.Hex(), delivery.WebhookID.Hex(), int64(delivery.EventID), delivery.EventType,
+		delivery.Attempt, delivery.StatusCode, delivery.Error, delivery.Succeeded, delivery.DurationMs, delivery.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	_, err = repo.pool.Exec(ctx,
+		`DELETE FROM webhook_deliveries WHERE webhook_id = $1 AND created_at < $2`,
+		delivery.WebhookID.Hex(), time.Now().Add(-DeliveryRetention),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns one page of the webhook's delivery attempts, newest first.
+func (repo *PostgresWebhookRepository) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, skip, limit int64) ([]models.WebhookDelivery, int64, error) {
+	var total int64
+	if err := repo.pool.QueryRow(ctx, `SELECT count(*) FROM webhook_deliveries WHERE webhook_id = $1`, webhookID.Hex()).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	rows, err := repo.pool.Query(ctx,
+		`SELECT `+postgresDeliveryColumns+` FROM webhook_deliveries WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`,
+		webhookID.Hex(), limit, skip,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var id, deliveryID, hookID string
+		var eventID int64
+		err := rows.Scan(&id, &deliveryID, &hookID, &eventID, &delivery.EventType, &delivery.Attempt,
+			&delivery.StatusCode, &delivery.Error, &delivery.Succeeded, &delivery.DurationMs, &delivery.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode webhook deliveries: %w", err)
+		}
+		if delivery.Id, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, 0, fmt.Errorf("invalid webhook delivery ID %q: %w", id, err)
+		}
+		if delivery.DeliveryID, err = primitive.ObjectIDFromHex(deliveryID); err != nil {
+			return nil, 0, fmt.Errorf("invalid webhook delivery ID %q: %w", deliveryID, err)
+		}
+		delivery.WebhookID = webhookID
+		delivery.EventID = uint64(eventID)
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+func scanPostgresWebhook(row pgx.Row) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var id string
+	if err := row.Scan(&id, &webhook.URL, &webhook.Secret, &webhook.Events, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook ID %q: %w", id, err)
+	}
+	webhook.Id = objectID
+	return &webhook, nil
+}
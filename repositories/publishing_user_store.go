@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"example_api/events"
+	models "example_api/models"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PublishingUserStore publishes a user lifecycle event after every successful change made
+// through it. Reads pass straight through to the wrapped store.
+type PublishingUserStore struct {
+	UserStore
+	publisher events.Publisher
+	logger    *slog.Logger
+}
+
+func NewPublishingUserStore(store UserStore, publisher events.Publisher, logger *slog.Logger) *PublishingUserStore {
+	return &PublishingUserStore{
+		UserStore: store,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// Create stores the user and publishes user.created.
+func (s *PublishingUserStore) Create(ctx context.Context, user *models.User) error {
+	if err := s.UserStore.Create(ctx, user); err != nil {
+		return err
+	}
+	s.publisher.Publish(ctx, events.UserCreated, events.UserPayload(user))
+	return nil
+}
+
+// CreateMany creates the users and publishes user.created for each one that was created.
+func (s *PublishingUserStore) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs, err := s.UserStore.CreateMany(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+	for i, user := range users {
+		if errs[i] == nil {
+			s.publisher.Publish(ctx, events.UserCreated, events.UserPayload(user))
+		}
+	}
+	return errs, nil
+}
+
+// Update updates the user and publishes user.updated with its new state.
+func (s *PublishingUserStore) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	if err := s.UserStore.Update(ctx, id, version, fields); err != nil {
+		return err
+	}
+
+	user, err := s.UserStore.GetByID(ctx, id)
+	if err != nil {
+		// The update went through; a missing event must not turn it into a failure
+		s.logger.WarnContext(ctx, "Failed to load updated user for event", slog.String("id", id.Hex()), slog.Any("error", err))
+		return nil
+	}
+	s.publisher.Publish(ctx, events.UserUpdated, events.UserPayload(user))
+	return nil
+}
+
+// Delete deletes the user and publishes user.deleted.
+func (s *PublishingUserStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.UserStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.publisher.Publish(ctx, events.UserDeleted, events.UserRef{ID: id.Hex()})
+	return nil
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"math/rand/v2"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RetryPolicy describes how often and how patiently transient failures are retried.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, the attempts are exhausted,
+// or ctx is done. Delays grow exponentially from BaseDelay up to MaxDelay with full jitter.
+func (p RetryPolicy) Do(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.Backoff(attempt)):
+		}
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt, counting from 1, before the
+// next one.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := p.BaseDelay << shift; d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay + 1)
+}
+
+// retryableServerCodes are MongoDB error codes raised while a replica set elects a new primary
+// or a node becomes briefly unreachable.
+var retryableServerCodes = map[int]bool{
+	6:     true, // HostUnreachable
+	7:     true, // HostNotFound
+	89:    true, // NetworkTimeout
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	9001:  true, // SocketException
+	10107: true, // NotWritablePrimary
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotPrimaryNoSecondaryOk
+	13436: true, // NotPrimaryOrSecondary
+}
+
+// IsTransientMongoError reports whether err is a network or primary-election failure worth retrying.
+func IsTransientMongoError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	if serverErr.HasErrorLabel("RetryableWriteError") {
+		return true
+	}
+	for code := range retryableServerCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryingUserStore retries transient MongoDB failures of another UserStore.
+type RetryingUserStore struct {
+	store  UserStore
+	policy RetryPolicy
+}
+
+func NewRetryingUserStore(store UserStore, policy RetryPolicy) *RetryingUserStore {
+	return &RetryingUserStore{
+		store:  store,
+		policy: policy,
+	}
+}
+
+var _ UserStore = (*RetryingUserStore)(nil)
+
+func (s *RetryingUserStore) Create(ctx context.Context, user *models.User) error {
+	return s.policy.Do(ctx, IsTransientMongoError, func() error {
+		return s.store.Create(ctx, user)
+	})
+}
+
+func (s *RetryingUserStore) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	var errs []error
+	err := s.policy.Do(ctx, IsTransientMongoError, func() error {
+		var err error
+		errs, err = s.store.CreateMany(ctx, users)
+		return err
+	})
+	return errs, err
+}
+
+func (s *RetryingUserStore) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	var user *models.User
+	err := s.policy.Do(ctx, IsTransientMongoError, func() error {
+		var err error
+		user, err = s.store.GetByID(ctx, id)
+		return err
+	})
+	return user, err
+}
+
+func (s *RetryingUserStore) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	return s.policy.Do(ctx, IsTransientMongoError, func() error {
+		return s.store.Update(ctx, id, version, fields)
+	})
+}
+
+func (s *RetryingUserStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return s.policy.Do(ctx, IsTransientMongoError, func() error {
+		return s.store.Delete(ctx, id)
+	})
+}
+
+func (s *RetryingUserStore) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+	err := s.policy.Do(ctx, IsTransientMongoError, func() error {
+		var err error
+		users, total, err = s.store.List(ctx, opts)
+		return err
+	})
+	return users, total, err
+}
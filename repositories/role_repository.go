@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RoleRepository stores roles in MongoDB, where a unique index keeps their names apart per
+// tenant.
+type RoleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRoleRepository(db *mongodb.Database) *RoleRepository {
+	return &RoleRepository{
+		collection: db.Collection("roles"),
+	}
+}
+
+var _ RoleStore = (*RoleRepository)(nil)
+
+// mongoRole is the stored form of a role. TenantID is empty for the default tenant.
+type mongoRole struct {
+	models.Role `bson:",inline"`
+	TenantID    string `bson:"tenantId,omitempty"`
+}
+
+// Create inserts a new role document in the tenant of ctx.
+func (repo *RoleRepository) Create(ctx context.Context, role *models.Role) error {
+	id, _ := mongoTenant(ctx).(string)
+	_, err := repo.collection.InsertOne(ctx, mongoRole{Role: *role, TenantID: id})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrRoleExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert role: %w", err)
+	}
+	return nil
+}
+
+// GetByName returns the role with the given name, or ErrRoleNotFound.
+func (repo *RoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := repo.collection.FindOne(ctx, mongoScoped(ctx, bson.M{"name": name})).Decode(&role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find role: %w", err)
+	}
+	return &role, nil
+}
+
+// Replace overwrites the role document with the same name.
+func (repo *RoleRepository) Replace(ctx context.Context, role *models.Role) error {
+	id, _ := mongoTenant(ctx).(string)
+	result, err := repo.collection.ReplaceOne(ctx, mongoScoped(ctx, bson.M{"name": role.Name}), mongoRole{Role: *role, TenantID: id})
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// Delete removes the role with the given name.
+func (repo *RoleRepository) Delete(ctx context.Context, name string) error {
+	if _, err := repo.collection.DeleteOne(ctx, mongoScoped(ctx, bson.M{"name": name})); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of roles ordered by name, along with the total number of roles.
+func (repo *RoleRepository) List(ctx context.Context, skip, limit int64) ([]models.Role, int64, error) {
+	filter := mongoScoped(ctx, bson.M{})
+	total, err := repo.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count roles: %w", err)
+	}
+
+	cursor, err := repo.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list roles: %w", err)
+	}
+	roles := []models.Role{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode roles: %w", err)
+	}
+	return roles, total, nil
+}
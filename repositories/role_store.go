@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+)
+
+var (
+	// ErrRoleNotFound is returned when no role has the requested name.
+	ErrRoleNotFound = apperrors.NotFound("Role not found")
+	// ErrRoleExists is returned when creating a role with a name already in use.
+	ErrRoleExists = apperrors.Conflict("A role with this name already exists")
+)
+
+// RoleStore persists the roles of the tenant of the context, which are identified by their
+// unique names. The built-in roles are not stored.
+type RoleStore interface {
+	// Create stores a new role, or returns ErrRoleExists if its name is taken.
+	Create(ctx context.Context, role *models.Role) error
+	// GetByName returns the role with the given name, or ErrRoleNotFound.
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+	// Replace overwrites the stored role with the same name, or returns ErrRoleNotFound.
+	Replace(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+	// List returns one page of roles ordered by name, along with the total number of roles.
+	List(ctx context.Context, skip, limit int64) ([]models.Role, int64, error)
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	models "example_api/models"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// s3ETagKey is the user metadata key holding the avatar's ETag. S3 computes its own ETag from
+// the upload, which differs from the one the service derives from the image.
+const s3ETagKey = "Etag"
+
+// S3AvatarRepository stores avatars as objects in an S3-compatible bucket, one per user and
+// variant, keyed as <prefix><user ID>/<variant>. S3 replaces an object atomically, so readers
+// always find a whole image.
+type S3AvatarRepository struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func NewS3AvatarRepository(client *minio.Client, bucket, prefix string) *S3AvatarRepository {
+	return &S3AvatarRepository{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+var _ AvatarStore = (*S3AvatarRepository)(nil)
+
+// userPrefix returns the key prefix of every variant of the user's avatar.
+func (repo *S3AvatarRepository) userPrefix(userID primitive.ObjectID) string {
+	return repo.prefix + userID.Hex() + "/"
+}
+
+// Put uploads data as the object of the user's avatar variant, replacing any previous one.
+func (repo *S3AvatarRepository) Put(ctx context.Context, avatar *models.Avatar, data []byte) error {
+	_, err := repo.client.PutObject(ctx, repo.bucket, repo.userPrefix(avatar.UserID)+avatar.Variant, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:  avatar.ContentType,
+		UserMetadata: map[string]string{s3ETagKey: avatar.ETag},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload avatar: %w", err)
+	}
+	return nil
+}
+
+// Get opens the object of the user's avatar variant, or returns ErrAvatarNotFound.
+func (repo *S3AvatarRepository) Get(ctx context.Context, userID primitive.ObjectID, variant string) (*models.Avatar, io.ReadCloser, error) {
+	object, err := repo.client.GetObject(ctx, repo.bucket, repo.userPrefix(userID)+variant, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open avatar: %w", err)
+	}
+	// GetObject is lazy; Stat sends the request and reports a missing object
+	info, err := object.Stat()
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		object.Close()
+		return nil, nil, ErrAvatarNotFound
+	}
+	if err != nil {
+		object.Close()
+		return nil, nil, fmt.Errorf("failed to open avatar: %w", err)
+	}
+	return &models.Avatar{
+		UserID:      userID,
+		Variant:     variant,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		ETag:        info.UserMetadata[s3ETagKey],
+		UpdatedAt:   info.LastModified,
+	}, object, nil
+}
+
+// Delete removes the objects of every variant of the user's avatar.
+func (repo *S3AvatarRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	// Cancelling stops the listing if a removal fails partway
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := repo.client.ListObjects(ctx, repo.bucket, minio.ListObjectsOptions{Prefix: repo.userPrefix(userID), Recursive: true})
+	for object := range objects {
+		if object.Err != nil {
+			return fmt.Errorf("failed to list avatars: %w", object.Err)
+		}
+		if err := repo.client.RemoveObject(ctx, repo.bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			return fmt.Errorf("failed to delete avatar: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+CREATE TABLE IF NOT EXISTS users (
+    id         CHAR(24)    PRIMARY KEY,
+    email      TEXT        NOT NULL,
+    password   TEXT        NOT NULL,
+    first_name TEXT        NOT NULL,
+    last_name  TEXT        NOT NULL,
+    join_date  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS users_join_date_idx ON users (join_date DESC);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS addresses JSONB;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferences JSONB;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+-- Users from before tenancy belong to the default tenant, and emails are unique per tenant
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_key ON users (tenant_id, email);
+DROP INDEX IF EXISTS users_email_key;
+
+-- Must match postgresSearchDocument in postgres_user_repository.go
+CREATE INDEX IF NOT EXISTS users_search_idx ON users
+    USING GIN (to_tsvector('simple', translate(email, '@.', '  ') || ' ' || first_name || ' ' || last_name));
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key         TEXT        PRIMARY KEY,
+    fingerprint TEXT        NOT NULL,
+    completed   BOOLEAN     NOT NULL DEFAULT false,
+    status      INTEGER,
+    header      JSONB,
+    body        BYTEA,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         CHAR(24)    PRIMARY KEY,
+    url        TEXT        NOT NULL,
+    secret     TEXT        NOT NULL,
+    events     TEXT[]      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id          CHAR(24)    PRIMARY KEY,
+    delivery_id CHAR(24)    NOT NULL,
+    webhook_id  CHAR(24)    NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id    BIGINT      NOT NULL,
+    event_type  TEXT        NOT NULL,
+    attempt     INTEGER     NOT NULL,
+    status_code INTEGER     NOT NULL DEFAULT 0,
+    error       TEXT        NOT NULL DEFAULT '',
+    succeeded   BOOLEAN     NOT NULL,
+    duration_ms BIGINT      NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_created_idx ON webhook_deliveries (webhook_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS avatars (
+    user_id      CHAR(24)    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    variant      TEXT        NOT NULL,
+    content_type TEXT        NOT NULL,
+    size         BIGINT      NOT NULL,
+    etag         TEXT        NOT NULL,
+    data         BYTEA       NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, variant)
+);
+
+-- Audit entries are kept after their user is deleted, so target_id has no foreign key
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         CHAR(24)    PRIMARY KEY,
+    time       TIMESTAMPTZ NOT NULL,
+    action     TEXT        NOT NULL,
+    actor      TEXT        NOT NULL,
+    ip         TEXT        NOT NULL DEFAULT '',
+    request_id TEXT        NOT NULL DEFAULT '',
+    target_id  CHAR(24)    NOT NULL,
+    changes    JSONB
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_target_time_idx ON audit_logs (target_id, time DESC);
+CREATE INDEX IF NOT EXISTS audit_logs_time_idx ON audit_logs (time DESC);
+CREATE INDEX IF NOT EXISTS audit_logs_actor_time_idx ON audit_logs (actor, time DESC);
+CREATE INDEX IF NOT EXISTS audit_logs_action_time_idx ON audit_logs (action, time DESC);
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id         CHAR(24)    PRIMARY KEY,
+    tenant_id  TEXT        NOT NULL DEFAULT 'default',
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS memberships (
+    id              CHAR(24)    PRIMARY KEY,
+    tenant_id       TEXT        NOT NULL DEFAULT 'default',
+    organization_id CHAR(24)    NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id         CHAR(24)    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role            TEXT        NOT NULL,
+    status          TEXT        NOT NULL,
+    invited_at      TIMESTAMPTZ NOT NULL,
+    joined_at       TIMESTAMPTZ,
+    UNIQUE (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS memberships_user_idx ON memberships (user_id);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id          CHAR(24)    PRIMARY KEY,
+    tenant_id   TEXT        NOT NULL DEFAULT 'default',
+    name        TEXT        NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    permissions TEXT[]      NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id          CHAR(24)    PRIMARY KEY,
+    tenant_id   TEXT        NOT NULL DEFAULT 'default',
+    name        TEXT        NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    permissions TEXT[]      NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One row per member; the primary key serves member pages in user order
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id  CHAR(24)    NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id   CHAR(24)    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tenant_id TEXT        NOT NULL DEFAULT 'default',
+    added_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS group_members_user_idx ON group_members (user_id, group_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id         CHAR(24)    PRIMARY KEY,
+    user_id    CHAR(24)    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tenant_id  TEXT        NOT NULL DEFAULT 'default',
+    type       TEXT        NOT NULL,
+    message    TEXT        NOT NULL,
+    data       JSONB,
+    read_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx ON notifications (user_id, id DESC);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id           CHAR(24)    PRIMARY KEY,
+    tenant_id    TEXT        NOT NULL DEFAULT 'default',
+    type         TEXT        NOT NULL,
+    payload      JSONB       NOT NULL,
+    status       TEXT        NOT NULL,
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    max_attempts INTEGER     NOT NULL,
+    run_at       TIMESTAMPTZ NOT NULL,
+    last_error   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at  TIMESTAMPTZ
+);
+
+-- Workers look for due jobs, which are pending or running with an ended lease
+CREATE INDEX IF NOT EXISTS jobs_due_idx ON jobs (run_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS jobs_finished_idx ON jobs (finished_at) WHERE finished_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS exports (
+    id           CHAR(24)    PRIMARY KEY,
+    tenant_id    TEXT        NOT NULL DEFAULT 'default',
+    filename     TEXT        NOT NULL,
+    content_type TEXT        NOT NULL,
+    size         BIGINT      NOT NULL,
+    data         BYTEA       NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS exports_created_idx ON exports (created_at);
+
+CREATE TABLE IF NOT EXISTS task_runs (
+    id           CHAR(24)    PRIMARY KEY,
+    task         TEXT        NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    started_at   TIMESTAMPTZ NOT NULL,
+    finished_at  TIMESTAMPTZ,
+    status       TEXT        NOT NULL,
+    affected     BIGINT      NOT NULL DEFAULT 0,
+    error        TEXT        NOT NULL DEFAULT '',
+    -- Each due run of a task is started by one instance
+    UNIQUE (task, scheduled_at)
+);
+
+CREATE INDEX IF NOT EXISTS task_runs_started_idx ON task_runs (started_at);
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key         TEXT        PRIMARY KEY,
+    description TEXT        NOT NULL DEFAULT '',
+    enabled     BOOLEAN     NOT NULL DEFAULT false,
+    rollout     INTEGER     NOT NULL DEFAULT 100,
+    tenants     JSONB,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS phone_codes (
+    user_id    CHAR(24)    PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    phone      TEXT        NOT NULL,
+    code_hash  TEXT        NOT NULL,
+    attempts   INTEGER     NOT NULL DEFAULT 0,
+    sent_at    TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS password_history (
+    user_id CHAR(24) PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    hashes  TEXT[]   NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_usage (
+    tenant_id TEXT   NOT NULL DEFAULT 'default',
+    client    TEXT   NOT NULL,
+    day       DATE   NOT NULL,
+    requests  BIGINT NOT NULL DEFAULT 0,
+    bytes_in  BIGINT NOT NULL DEFAULT 0,
+    bytes_out BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, client, day)
+);
+
+CREATE INDEX IF NOT EXISTS api_usage_day_idx ON api_usage (tenant_id, day DESC);
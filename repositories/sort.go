@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"bytes"
+	models "example_api/models"
+	"strings"
+)
+
+// SortField orders users by one of their fields, ascending unless Descending. Field is the
+// JSON name of a user field, such as joinDate, or id.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// CompareUsers orders a and b by sort, returning a negative number when a comes first, a
+// positive one when b does, and 0 when sort ranks them equal. Strings compare byte by byte.
+func CompareUsers(a, b *models.User, sort []SortField) int {
+	for _, field := range sort {
+		var c int
+		switch field.Field {
+		case "id":
+			c = bytes.Compare(a.Id[:], b.Id[:])
+		case "email":
+			c = strings.Compare(a.Email, b.Email)
+		case "firstName":
+			c = strings.Compare(a.FirstName, b.FirstName)
+		case "lastName":
+			c = strings.Compare(a.LastName, b.LastName)
+		case "role":
+			c = strings.Compare(a.Role, b.Role)
+		case "joinDate":
+			c = a.JoinDate.Compare(b.JoinDate)
+		}
+		if field.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// withIDOrder appends id to sort unless it is already there, so that users that sort equal
+// still come in the same order on every page.
+func withIDOrder(sort []SortField) []SortField {
+	for _, field := range sort {
+		if field.Field == "id" {
+			return sort
+		}
+	}
+	return append(sort[:len(sort):len(sort)], SortField{Field: "id"})
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TaskRunRepository stores the history of scheduled tasks in the task_runs collection. A unique
+// index on the task and scheduled time lets one instance start each run, and a TTL index
+// enforces TaskRunRetention.
+type TaskRunRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTaskRunRepository(db *mongodb.Database) *TaskRunRepository {
+	return &TaskRunRepository{
+		collection: db.Collection("task_runs"),
+	}
+}
+
+var _ TaskRunStore = (*TaskRunRepository)(nil)
+
+// Start inserts run, reporting a duplicate of its task and scheduled time as false.
+func (repo *TaskRunRepository) Start(ctx context.Context, run *models.TaskRun) (bool, error) {
+	_, err := repo.collection.InsertOne(ctx, run)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to start task run: %w", err)
+	}
+	return true, nil
+}
+
+// Finish stores the outcome of run.
+func (repo *TaskRunRepository) Finish(ctx context.Context, run *models.TaskRun) error {
+	_, err := repo.collection.UpdateByID(ctx, run.Id, bson.M{"$set": bson.M{
+		"status":     run.Status,
+		"finishedAt": run.FinishedAt,
+		"affected":   run.Affected,
+		"error":      run.Error,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to finish task run: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of the runs of task, newest first.
+func (repo *TaskRunRepository) List(ctx context.Context, task string, skip, limit int64) ([]models.TaskRun, int64, error) {
+	filter := bson.M{"task": task}
+	total, err := repo.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count task runs: %w", err)
+	}
+
+	cursor, err := repo.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "scheduledAt", Value: -1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list task runs: %w", err)
+	}
+	runs := []models.TaskRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode task runs: %w", err)
+	}
+	return runs, total, nil
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"time"
+)
+
+// TaskRunRetention is how long the history of scheduled task runs is kept.
+const TaskRunRetention = 30 * 24 * time.Hour
+
+// TaskRunStore keeps the history of scheduled maintenance tasks. Tasks work across every
+// tenant, so their runs belong to none.
+type TaskRunStore interface {
+	// Start records run as started unless a run of the same task was already started for the
+	// same scheduled time, by this or another instance, in which case it returns false. It
+	// purges runs started longer than TaskRunRetention ago.
+	Start(ctx context.Context, run *models.TaskRun) (bool, error)
+	// Finish stores the status, finish time, affected count, and error of a started run.
+	Finish(ctx context.Context, run *models.TaskRun) error
+	// List returns one page of the runs of task, newest first, along with their total number.
+	List(ctx context.Context, task string, skip, limit int64) ([]models.TaskRun, int64, error)
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MongoDB documents record their tenant in a tenantId field, which documents of tenant.Default
+// leave out. Documents stored before tenancy existed therefore belong to the default tenant
+// without being migrated, and unique indexes led by tenantId treat them alike.
+
+// mongoTenant returns the tenantId value of documents of the tenant ctx is scoped to. A nil
+// filter value also matches documents without the field.
+func mongoTenant(ctx context.Context) interface{} {
+	if id := tenant.FromContext(ctx); id != tenant.Default {
+		return id
+	}
+	return nil
+}
+
+// mongoScoped adds the tenant ctx is scoped to to filter and returns it.
+func mongoScoped(ctx context.Context, filter bson.M) bson.M {
+	filter["tenantId"] = mongoTenant(ctx)
+	return filter
+}
+
+// mongoUser is the stored form of a user. TenantID is empty for the default tenant.
+type mongoUser struct {
+	models.User `bson:",inline"`
+	TenantID    string `bson:"tenantId,omitempty"`
+}
+
+// newMongoUser returns the document of user in the tenant ctx is scoped to.
+func newMongoUser(ctx context.Context, user *models.User) mongoUser {
+	id, _ := mongoTenant(ctx).(string)
+	return mongoUser{User: *user, TenantID: id}
+}
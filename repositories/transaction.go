@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxTransactionAttempts bounds how often a transaction is retried after transient errors.
+const maxTransactionAttempts = 5
+
+// Transactor runs a function atomically. Repository calls made with the context passed to fn
+// take part in the transaction.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MongoTransactor runs functions inside MongoDB multi-document transactions.
+// Transactions require a replica set or sharded cluster.
+type MongoTransactor struct {
+	client *mongo.Client
+}
+
+func NewMongoTransactor(client *mongo.Client) *MongoTransactor {
+	return &MongoTransactor{
+		client: client,
+	}
+}
+
+var _ Transactor = (*MongoTransactor)(nil)
+
+// WithTransaction runs fn in a transaction, retrying the whole transaction on
+// TransientTransactionError and the commit on UnknownTransactionCommitResult.
+func (t *MongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(context.Background())
+
+	return mongo.WithSession(ctx, session, func(sessCtx mongo.SessionContext) error {
+		var err error
+		for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+			if err = runTransaction(sessCtx, fn); err == nil || !hasErrorLabel(err, "TransientTransactionError") {
+				return err
+			}
+		}
+		return fmt.Errorf("transaction failed after %d attempts: %w", maxTransactionAttempts, err)
+	})
+}
+
+func runTransaction(sessCtx mongo.SessionContext, fn func(ctx context.Context) error) error {
+	if err := sessCtx.StartTransaction(); err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	if err := fn(sessCtx); err != nil {
+		sessCtx.AbortTransaction(context.Background())
+		return err
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		if err = sessCtx.CommitTransaction(sessCtx); err == nil || !hasErrorLabel(err, "UnknownTransactionCommitResult") {
+			return err
+		}
+	}
+	return err
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorLabel(label)
+}
+
+// NoopTransactor runs functions directly, for backends without multi-document transactions.
+type NoopTransactor struct{}
+
+var _ Transactor = NoopTransactor{}
+
+func (NoopTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
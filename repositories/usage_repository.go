@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/mongodb"
+	"example_api/tenant"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UsageRepository stores API usage in the api_usage MongoDB collection. Each record's _id is
+// made of its tenant, day, and client, so instances adding to the same record at once upsert
+// one document rather than racing to insert two.
+type UsageRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUsageRepository(db *mongodb.Database) *UsageRepository {
+	return &UsageRepository{
+		collection: db.Collection("api_usage"),
+	}
+}
+
+var _ UsageStore = (*UsageRepository)(nil)
+
+// Add increments the counts of the records in the tenant of ctx in one unordered bulk write.
+func (repo *UsageRepository) Add(ctx context.Context, records []models.Usage) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tenantID, _ := mongoTenant(ctx).(string)
+	writes := make([]mongo.WriteModel, len(records))
+	for i, record := range records {
+		onInsert := bson.M{"client": record.Client, "day": record.Day}
+		if tenantID != "" {
+			onInsert["tenantId"] = tenantID
+		}
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": tenant.FromContext(ctx) + "/" + record.Day + "/" + record.Client}).
+			SetUpdate(bson.M{
+				"$inc":         bson.M{"requests": record.Requests, "bytesIn": record.BytesIn, "bytesOut": record.BytesOut},
+				"$setOnInsert": onInsert,
+			}).
+			SetUpsert(true)
+	}
+	if _, err := repo.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to add usage: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of the records matching filter, newest day first.
+func (repo *UsageRepository) List(ctx context.Context, filter UsageFilter, skip, limit int64) ([]models.Usage, int64, error) {
+	query := mongoScoped(ctx, usageQuery(filter))
+	total, err := repo.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count usage: %w", err)
+	}
+
+	cursor, err := repo.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "day", Value: -1}, {Key: "client", Value: 1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list usage: %w", err)
+	}
+	records := []models.Usage{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode usage: %w", err)
+	}
+	return records, total, nil
+}
+
+func usageQuery(filter UsageFilter) bson.M {
+	query := bson.M{}
+	if filter.Client != "" {
+		query["client"] = filter.Client
+	}
+	if filter.From != "" || filter.To != "" {
+		between := bson.M{}
+		if filter.From != "" {
+			between["$gte"] = filter.From
+		}
+		if filter.To != "" {
+			between["$lte"] = filter.To
+		}
+		query["day"] = between
+	}
+	return query
+}
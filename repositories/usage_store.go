@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+)
+
+// UsageStore keeps the API usage of every client, one record per client and day.
+type UsageStore interface {
+	// Add adds the counts of each of records to those of its client and day in the tenant of
+	// ctx, starting from zero for a client and day without a record yet.
+	Add(ctx context.Context, records []models.Usage) error
+	// List returns one page of the records matching filter, newest day first and then by
+	// client, along with the total number of matching records.
+	List(ctx context.Context, filter UsageFilter, skip, limit int64) ([]models.Usage, int64, error)
+}
+
+// UsageFilter restricts List to records matching every non-empty field. From and To are days,
+// both inclusive.
+type UsageFilter struct {
+	Client string
+	From   string
+	To     string
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"example_api/mongodb"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// changeStreamUnsupported is returned by servers that are not part of a replica set
+	changeStreamUnsupported = 40573
+	// changeStreamHistoryLost is returned when the oplog no longer holds the resume point
+	changeStreamHistoryLost = 286
+)
+
+// UserChangeWatcher follows the change stream of the users collection and drops the cached
+// entries of every user that is updated, replaced, or deleted, wherever the change was made.
+// CachedUserStore only sees the changes made through its own instance, so without the watcher
+// other instances, migrations, and tools such as mongosh leave stale entries until they expire.
+type UserChangeWatcher struct {
+	collection *mongo.Collection
+	cache      *CachedUserStore
+	tenants    []string
+	logger     *slog.Logger
+}
+
+// NewUserChangeWatcher watches the users of db for cache. Changes do not say which tenant the
+// user belonged to, so entries are dropped in each of tenants.
+func NewUserChangeWatcher(db *mongodb.Database, cache *CachedUserStore, tenants []string, logger *slog.Logger) *UserChangeWatcher {
+	return &UserChangeWatcher{
+		collection: db.Collection("users"),
+		cache:      cache,
+		tenants:    tenants,
+		logger:     logger,
+	}
+}
+
+// Run follows the change stream until ctx is cancelled. A failed stream is reopened where it
+// left off, waiting longer after each failure in a row, up to a minute. When the oplog has moved
+// past that point, the changes in between are lost and the stream starts over from the present.
+// Run returns at once when the server does not support change streams, as a standalone server
+// does not.
+func (w *UserChangeWatcher) Run(ctx context.Context) {
+	var resumeToken bson.Raw
+	delay := time.Second
+	for {
+		opened, err := w.watch(ctx, &resumeToken)
+		if ctx.Err() != nil {
+			return
+		}
+		if opened {
+			delay = time.Second
+		}
+
+		var serverErr mongo.ServerError
+		switch {
+		case errors.As(err, &serverErr) && serverErr.HasErrorCode(changeStreamUnsupported):
+			w.logger.Warn("Users change stream is not supported; cached users only follow changes made through this instance", slog.Any("error", err))
+			return
+		case errors.As(err, &serverErr) && serverErr.HasErrorCode(changeStreamHistoryLost):
+			w.logger.Warn("Users change stream can no longer resume; changes since it failed stay cached until they expire", slog.Any("error", err))
+			resumeToken = nil
+		case err != nil:
+			w.logger.Error("Users change stream failed", slog.Any("error", err), slog.Duration("retryIn", delay))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, time.Minute)
+	}
+}
+
+// watch invalidates the users changed after resumeToken, or from now on when it is nil, and
+// advances it past each change, until the stream fails or ends. It reports whether the stream
+// was opened.
+func (w *UserChangeWatcher) watch(ctx context.Context, resumeToken *bson.Raw) (bool, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{"update", "replace", "delete"}}}}},
+		{{Key: "$project", Value: bson.M{"documentKey": 1}}},
+	}
+	opts := options.ChangeStream()
+	if *resumeToken != nil {
+		opts.SetResumeAfter(*resumeToken)
+	}
+	stream, err := w.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+
+	for stream.Next(ctx) {
+		var change struct {
+			DocumentKey struct {
+				ID primitive.ObjectID `bson:"_id"`
+			} `bson:"documentKey"`
+		}
+		if err := stream.Decode(&change); err == nil {
+			w.cache.InvalidateTenants(ctx, change.DocumentKey.ID, w.tenants)
+		}
+		*resumeToken = stream.ResumeToken()
+	}
+	if err := stream.Err(); err != nil {
+		return true, err
+	}
+	// The stream only ends by itself when the collection is dropped or renamed, and cannot be
+	// resumed past that
+	*resumeToken = nil
+	return true, nil
+}
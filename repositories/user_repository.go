@@ -2,206 +2,404 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"example_api/apperrors"
 	models "example_api/models"
+	"example_api/mongodb"
 	"fmt"
-	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"golang.org/x/crypto/bcrypt"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// ErrUserNotFound is returned when no user matches the requested ID.
+	ErrUserNotFound = apperrors.NotFound("User not found")
+	// ErrEmailTaken is returned when another user already has the requested email.
+	ErrEmailTaken = apperrors.Conflict("Email already in use")
+	// ErrVersionConflict is returned when a user changed since the version the caller last read.
+	ErrVersionConflict = apperrors.Conflict("User was modified by another request; fetch it again and retry")
+)
+
+// UserRepository stores users in the users MongoDB collection. Searches use the Atlas Search
+// index searchIndex, or the user_text index when it is empty. Lists, searches, and counts read
+// through lists, and inserts write through inserts, with the concerns configured for them.
 type UserRepository struct {
-	collection *mongo.Collection
+	collection  *mongo.Collection
+	lists       *mongo.Collection
+	inserts     *mongo.Collection
+	searchIndex string
 }
 
-func NewUserRepository(db *mongo.Database) *UserRepository {
+func NewUserRepository(db *mongodb.Database, searchIndex string) *UserRepository {
 	return &UserRepository{
-		collection: db.Collection("users"),
-	}
-}
-
-// CreateUser godoc
-// @Summary Create a new user
-// @Description Create a new user with email, password, first name, and last name
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param user body model.User true "User JSON"
-// @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
-// @Router /api/users [post]
-func (repo *UserRepository) CreateUser(w http.ResponseWriter, r *http.Request) {
+		collection:  db.Collection("users"),
+		lists:       db.ListCollection("users"),
+		inserts:     db.InsertCollection("users"),
+		searchIndex: searchIndex,
+	}
+}
+
+// Create inserts a new user document in the tenant of ctx.
+func (repo *UserRepository) Create(ctx context.Context, user *models.User) error {
+	if _, err := repo.inserts.InsertOne(ctx, newMongoUser(ctx, user)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrEmailTaken
+		}
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+	return nil
+}
+
+// CreateMany inserts user documents in one unordered bulk write, so a duplicate email only
+// fails its own document.
+func (repo *UserRepository) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs := make([]error, len(users))
+	if len(users) == 0 {
+		return errs, nil
+	}
+	documents := make([]interface{}, len(users))
+	for i, user := range users {
+		documents[i] = newMongoUser(ctx, user)
+	}
+
+	_, err := repo.inserts.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert users: %w", err)
+		}
+		return errs, nil
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return nil, fmt.Errorf("failed to insert users: %w", err)
+		}
+		// IDs are new for every import, so a duplicate ID was inserted by an earlier
+		// attempt of a retried batch
+		if !strings.Contains(writeErr.Message, "_id_") {
+			errs[writeErr.Index] = ErrEmailTaken
+		}
+	}
+	return errs, nil
+}
+
+// GetByID returns the user with the given ID, or ErrUserNotFound.
+func (repo *UserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
 	var user models.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, `{"status":400, "message":"Invalid input"}`, http.StatusBadRequest)
-		return
+	err := repo.collection.FindOne(ctx, mongoScoped(ctx, bson.M{"_id": id})).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return &user, nil
+}
 
-	// Validate required fields
-	if user.Email == "" || user.Password == "" || user.FirstName == "" || user.LastName == "" {
-		http.Error(w, `{"status":400, "message":"All fields except ID and JoinDate are required"}`, http.StatusBadRequest)
-		return
+// Update sets the given fields on the user with the given ID if it is still at version.
+func (repo *UserRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error {
+	filter := mongoScoped(ctx, bson.M{"_id": id})
+	if version != AnyVersion {
+		filter["version"] = version
 	}
 
-	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	result, err := repo.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M(fields), "$inc": bson.M{"version": 1}})
 	if err != nil {
-		http.Error(w, `{"status":500, "message":"Error hashing password"}`, http.StatusInternalServerError)
-		return
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrEmailTaken
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if result.MatchedCount > 0 || version == AnyVersion {
+		return nil
 	}
-	user.Password = string(hashedPassword)
-	user.Id = primitive.NewObjectID()
-	user.JoinDate = time.Now()
 
-	// Insert into database
-	_, err = repo.collection.InsertOne(context.TODO(), user)
+	// Nothing matched: either the user is gone or its version moved on
+	count, err := repo.collection.CountDocuments(ctx, mongoScoped(ctx, bson.M{"_id": id}))
 	if err != nil {
-		http.Error(w, `{"status":500, "message":"Failed to create user"}`, http.StatusInternalServerError)
-		return
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if count > 0 {
+		return ErrVersionConflict
 	}
+	return nil
+}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  201,
-		"message": fmt.Sprintf("User created successfully with ID: %s", user.Id.Hex()),
-		"data":    user,
-	})
+// Delete removes the user with the given ID.
+func (repo *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.collection.DeleteOne(ctx, mongoScoped(ctx, bson.M{"_id": id})); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
 }
 
-// GetUserByID godoc
-// @Summary Get a user by ID
-// @Description Retrieve user details by their unique ID
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param id path string true "User ID"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Router /api/users/{id} [get]
-func (repo *UserRepository) GetUserByID(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id, err := primitive.ObjectIDFromHex(params["id"])
-	if err != nil {
-		http.Error(w, `{"status":400, "message":"Invalid ID"}`, http.StatusBadRequest)
-		return
+// List returns one page of matching users ordered by ID, along with the total number of matches.
+func (repo *UserRepository) List(ctx context.Context, opts ListOptions) ([]models.User, int64, error) {
+	filter := mongoScoped(ctx, bson.M{})
+	if opts.Filter.Role != "" {
+		filter["role"] = opts.Filter.Role
+	}
+	if opts.Filter.Email != "" {
+		filter["email"] = opts.Filter.Email
+	}
+	if !opts.AfterID.IsZero() || opts.IDs != nil {
+		ids := bson.M{}
+		if !opts.AfterID.IsZero() {
+			ids["$gt"] = opts.AfterID
+		}
+		if opts.IDs != nil {
+			ids["$in"] = opts.IDs
+		}
+		filter["_id"] = ids
+	}
+	if opts.Where != nil {
+		filter["$and"] = bson.A{mongoFilterExpr(opts.Where)}
 	}
 
-	var user models.User
-	err = repo.collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&user)
+	var total int64
+	if !opts.SkipTotal {
+		var err error
+		if total, err = repo.lists.CountDocuments(ctx, filter); err != nil {
+			return nil, 0, fmt.Errorf("failed to count users: %w", err)
+		}
+	}
+
+	findOptions := options.Find().SetSort(mongoSort(opts.Sort)).SetSkip(opts.Skip).SetLimit(opts.Limit)
+	if len(opts.Fields) > 0 {
+		findOptions.SetProjection(mongoProjection(opts.Fields))
+	}
+	cursor, err := repo.lists.Find(ctx, filter, findOptions)
 	if err != nil {
-		http.Error(w, `{"status":404, "message":"User not found"}`, http.StatusNotFound)
-		return
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  200,
-		"message": "User retrieved successfully",
-		"data":    user,
-	})
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode users: %w", err)
+	}
+	return users, total, nil
 }
 
-// UpdateUser godoc
-// @Summary Update user details
-// @Description Update specific fields of a user by their ID
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param id path string true "User ID"
-// @Param updates body map[string]interface{} true "Update fields JSON"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
-// @Router /api/users/{id} [put]
-func (repo *UserRepository) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id, err := primitive.ObjectIDFromHex(params["id"])
-	if err != nil {
-		http.Error(w, `{"status":400, "message":"Invalid ID"}`, http.StatusBadRequest)
-		return
-	}
-
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		http.Error(w, `{"status":400, "message":"Invalid input"}`, http.StatusBadRequest)
-		return
-	}
-
-	allowedFields := map[string]bool{
-		"email":     true,
-		"firstName": true,
-		"lastName":  true,
-		"password":  true,
-	}
-
-	filteredUpdates := bson.M{}
-	for key, value := range updates {
-		if allowedFields[key] {
-			if key == "password" {
-				hashedPassword, err := bcrypt.GenerateFromPassword([]byte(fmt.Sprintf("%v", value)), bcrypt.DefaultCost)
-				if err != nil {
-					http.Error(w, `{"status":500, "message":"Error hashing password"}`, http.StatusInternalServerError)
-					return
-				}
-				filteredUpdates[key] = string(hashedPassword)
-			} else {
-				filteredUpdates[key] = value
-			}
+// mongoSort translates sort into a sort document, breaking ties by _id.
+func mongoSort(sort []SortField) bson.D {
+	order := withIDOrder(sort)
+	document := make(bson.D, len(order))
+	for i, field := range order {
+		key := field.Field
+		if key == "id" {
+			key = "_id"
 		}
+		direction := 1
+		if field.Descending {
+			direction = -1
+		}
+		document[i] = bson.E{Key: key, Value: direction}
 	}
+	return document
+}
 
-	if len(filteredUpdates) == 0 {
-		http.Error(w, `{"status":400, "message":"No valid fields to update"}`, http.StatusBadRequest)
-		return
+// mongoFilterOps maps filter operators to MongoDB query operators.
+var mongoFilterOps = map[FilterOp]string{
+	FilterNe:  "$ne",
+	FilterGt:  "$gt",
+	FilterGte: "$gte",
+	FilterLt:  "$lt",
+	FilterLte: "$lte",
+}
+
+// mongoFilterExpr translates expr into a query document. Values are always passed as values,
+// and contains matches quote them, so nothing in an expression is interpreted as an operator.
+func mongoFilterExpr(expr *FilterExpr) bson.M {
+	if len(expr.And) > 0 || len(expr.Or) > 0 {
+		operands, op := expr.And, "$and"
+		if len(expr.Or) > 0 {
+			operands, op = expr.Or, "$or"
+		}
+		documents := make(bson.A, len(operands))
+		for i := range operands {
+			documents[i] = mongoFilterExpr(&operands[i])
+		}
+		return bson.M{op: documents}
+	}
+	switch expr.Op {
+	case FilterEq:
+		return bson.M{expr.Field: bson.M{"$eq": expr.Value}}
+	case FilterContains:
+		return bson.M{expr.Field: primitive.Regex{Pattern: regexp.QuoteMeta(expr.Value.(string)), Options: "i"}}
 	}
+	return bson.M{expr.Field: bson.M{mongoFilterOps[expr.Op]: expr.Value}}
+}
 
-	_, err = repo.collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": filteredUpdates})
-	if err != nil {
-		http.Error(w, `{"status":500, "message":"Failed to update user"}`, http.StatusInternalServerError)
-		return
+// mongoProjection translates fields into a projection document. MongoDB always returns _id.
+func mongoProjection(fields []string) bson.M {
+	projection := bson.M{}
+	for _, field := range fields {
+		if field != "id" {
+			projection[field] = 1
+		}
+	}
+	if len(projection) == 0 {
+		// An empty projection would return every field
+		projection["_id"] = 1
 	}
+	return projection
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  200,
-		"message": "User updated successfully",
+// CountUsers counts users with a single aggregation, one facet per count.
+func (repo *UserRepository) CountUsers(ctx context.Context, since []time.Time) (*UserCounts, error) {
+	count := bson.D{{Key: "$count", Value: "n"}}
+	facets := bson.D{
+		{Key: "total", Value: bson.A{count}},
+		{Key: "erased", Value: bson.A{bson.M{"$match": bson.M{"erasedAt": bson.M{"$exists": true}}}, count}},
+		{Key: "byRole", Value: bson.A{bson.M{"$group": bson.M{"_id": "$role", "n": bson.M{"$sum": 1}}}}},
+	}
+	for i, t := range since {
+		facets = append(facets, bson.E{Key: fmt.Sprintf("since%d", i), Value: bson.A{bson.M{"$match": bson.M{"joinDate": bson.M{"$gte": t}}}, count}})
+	}
+	cursor, err := repo.lists.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: mongoScoped(ctx, bson.M{})}},
+		{{Key: "$facet", Value: facets}},
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	// $facet always yields a single document of arrays; a $count over nothing is empty
+	var result []map[string][]struct {
+		ID string `bson:"_id"`
+		N  int64  `bson:"n"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode user counts: %w", err)
+	}
+	if len(result) != 1 {
+		return nil, fmt.Errorf("failed to count users: expected 1 result, got %d", len(result))
+	}
+	facet := result[0]
+	n := func(name string) int64 {
+		if rows := facet[name]; len(rows) > 0 {
+			return rows[0].N
+		}
+		return 0
+	}
+
+	counts := &UserCounts{Total: n("total"), Erased: n("erased"), ByRole: map[string]int64{}, JoinedSince: make([]int64, len(since))}
+	for _, row := range facet["byRole"] {
+		counts.ByRole[row.ID] = row.N
+	}
+	for i := range since {
+		counts.JoinedSince[i] = n(fmt.Sprintf("since%d", i))
+	}
+	return counts, nil
 }
 
-// DeleteUser godoc
-// @Summary Delete a user by ID
-// @Description Remove a user from the database using their unique ID
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param id path string true "User ID"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
-// @Router /api/users/{id} [delete]
-func (repo *UserRepository) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id, err := primitive.ObjectIDFromHex(params["id"])
+// searchFields are the user fields searches look in.
+var searchFields = []string{"email", "firstName", "lastName"}
+
+// scoredUser decodes a user along with the score a search gave it.
+type scoredUser struct {
+	models.User `bson:",inline"`
+	Score       float64 `bson:"score"`
+}
+
+// SearchUsers finds users with Atlas Search when an index is configured. Otherwise it ranks the
+// users the text index finds by textScore, then fills any remaining places with users matched
+// by word prefix, which the text index cannot do, with a score of 0.
+func (repo *UserRepository) SearchUsers(ctx context.Context, query string, limit int64) ([]UserMatch, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []UserMatch{}, nil
+	}
+	if repo.searchIndex != "" {
+		return repo.atlasSearch(ctx, terms, limit)
+	}
+
+	notErased := bson.M{"$exists": false}
+	score := bson.M{"$meta": "textScore"}
+	cursor, err := repo.lists.Find(ctx,
+		mongoScoped(ctx, bson.M{"$text": bson.M{"$search": strings.Join(terms, " ")}, "erasedAt": notErased}),
+		options.Find().SetProjection(bson.M{"score": score}).SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).SetLimit(limit),
+	)
 	if err != nil {
-		http.Error(w, `{"status":400, "message":"Invalid ID"}`, http.StatusBadRequest)
-		return
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	var found []scoredUser
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+	matches := make([]UserMatch, 0, len(found))
+	ids := make([]primitive.ObjectID, 0, len(found))
+	for _, user := range found {
+		matches = append(matches, UserMatch{User: user.User, Score: user.Score})
+		ids = append(ids, user.Id)
+	}
+	if int64(len(matches)) == limit {
+		return matches, nil
 	}
 
-	_, err = repo.collection.DeleteOne(context.TODO(), bson.M{"_id": id})
+	// Every term has to start a word of some field
+	filter := bson.A{mongoScoped(ctx, bson.M{"erasedAt": notErased}), bson.M{"_id": bson.M{"$nin": ids}}}
+	for _, term := range terms {
+		pattern := primitive.Regex{Pattern: `(^|[^\pL\pN])` + regexp.QuoteMeta(term), Options: "i"}
+		fields := bson.A{}
+		for _, field := range searchFields {
+			fields = append(fields, bson.M{field: pattern})
+		}
+		filter = append(filter, bson.M{"$or": fields})
+	}
+	cursor, err = repo.lists.Find(ctx, bson.M{"$and": filter}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit-int64(len(matches))))
 	if err != nil {
-		http.Error(w, `{"status":500, "message":"Failed to delete user"}`, http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("failed to search users: %w", err)
 	}
+	var prefixed []models.User
+	if err := cursor.All(ctx, &prefixed); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+	for _, user := range prefixed {
+		matches = append(matches, UserMatch{User: user})
+	}
+	return matches, nil
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  200,
-		"message": "User deleted successfully",
-	})
+// atlasSearch finds users with Atlas Search, which ranks them itself. Each term may match a
+// word fuzzily or as its prefix.
+func (repo *UserRepository) atlasSearch(ctx context.Context, terms []string, limit int64) ([]UserMatch, error) {
+	must := bson.A{}
+	for _, term := range terms {
+		must = append(must, bson.M{"compound": bson.M{"should": bson.A{
+			bson.M{"text": bson.M{"query": term, "path": searchFields, "fuzzy": bson.M{"maxEdits": 1, "prefixLength": 1}}},
+			bson.M{"wildcard": bson.M{"query": term + "*", "path": searchFields, "allowAnalyzedField": true}},
+		}}})
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$search", Value: bson.M{
+			"index": repo.searchIndex,
+			"compound": bson.M{
+				"must":    must,
+				"mustNot": bson.A{bson.M{"exists": bson.M{"path": "erasedAt"}}},
+			},
+		}}},
+		// Filtering inside $search would need tenantId in the search index definition
+		{{Key: "$match", Value: mongoScoped(ctx, bson.M{})}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "searchScore"}}}},
+	}
+	cursor, err := repo.lists.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	var found []scoredUser
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+	matches := make([]UserMatch, len(found))
+	for i, user := range found {
+		matches[i] = UserMatch{User: user.User, Score: user.Score}
+	}
+	return matches, nil
 }
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+	"strings"
+	"unicode"
+)
+
+// UserMatch is a user found by a search, with its relevance score. Higher scores are more
+// relevant; they are only comparable within one search.
+type UserMatch struct {
+	User  models.User
+	Score float64
+}
+
+// UserSearchStore finds users by the words of their email and names, most relevant first.
+// Every term of the query must start a word of one of those fields, so a search for "cerra"
+// finds "Cerrahoğlu". Erased users are never found. Every user storage backend implements it.
+type UserSearchStore interface {
+	SearchUsers(ctx context.Context, query string, limit int64) ([]UserMatch, error)
+}
+
+var _ UserSearchStore = (*UserRepository)(nil)
+var _ UserSearchStore = (*PostgresUserRepository)(nil)
+var _ UserSearchStore = (*MemoryUserRepository)(nil)
+
+// SearchTerms splits a search query into its lowercase words: runs of letters and digits.
+// Everything else separates words, so terms are safe to embed in any query language.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
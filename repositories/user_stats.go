@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// UserCounts are the user totals a UserStatsStore computes.
+type UserCounts struct {
+	Total  int64
+	Erased int64
+	ByRole map[string]int64
+	// JoinedSince holds, for each time passed to CountUsers, the number of users who joined at
+	// or after it
+	JoinedSince []int64
+}
+
+// UserStatsStore counts users in the database, without loading them. Every user storage
+// backend implements it.
+type UserStatsStore interface {
+	CountUsers(ctx context.Context, since []time.Time) (*UserCounts, error)
+}
+
+var _ UserStatsStore = (*UserRepository)(nil)
+var _ UserStatsStore = (*PostgresUserRepository)(nil)
+var _ UserStatsStore = (*MemoryUserRepository)(nil)
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	models "example_api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:generate mockery --name UserStore --output mocks --outpkg mocks --filename user_store.go
+
+// UserStore is the persistence contract for users, implemented by every storage backend.
+// Update only applies when the stored version equals version (unless it is AnyVersion),
+// increments the version, and otherwise returns ErrVersionConflict.
+//
+// CreateMany inserts users in bulk. The returned slice holds, for each user, nil or the reason
+// it was not created, such as ErrEmailTaken; the other users are created regardless. The error
+// reports a failure of the batch as a whole, after which any of the users may or may not exist.
+type UserStore interface {
+	Create(ctx context.Context, user *models.User) error
+	CreateMany(ctx context.Context, users []*models.User) ([]error, error)
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	Update(ctx context.Context, id primitive.ObjectID, version int64, fields map[string]interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	List(ctx context.Context, opts ListOptions) ([]models.User, int64, error)
+}
+
+// AnyVersion passed to Update skips the version check.
+const AnyVersion int64 = 0
+
+// ListOptions controls which page of users List returns.
+type ListOptions struct {
+	Skip   int64
+	Limit  int64
+	Filter UserFilter
+	// Where, when set, restricts List to users matching the expression as well as Filter
+	Where *FilterExpr
+	// Sort orders the users, which are ordered by ID after it, or only by ID when it is empty
+	Sort []SortField
+	// AfterID, when set, restricts List to users with greater IDs. Walking every user in pages
+	// that start after the last ID seen is not thrown off by users added or removed meanwhile.
+	// It only makes sense without Sort.
+	AfterID primitive.ObjectID
+	// IDs, when set, restricts List to users with one of these IDs
+	IDs []primitive.ObjectID
+	// Fields, when set, names the only user fields callers need. Stores that can read fewer
+	// fields leave the others empty; the rest return whole users anyway.
+	Fields []string
+	// SkipTotal, when set, tells stores that count matches separately not to, for callers that
+	// know the total already. Those stores then return a total of zero.
+	SkipTotal bool
+}
+
+// UserFilter restricts List to users matching every non-empty field exactly. Expression is a
+// filter expression, which services parse into ListOptions.Where; stores ignore it.
+type UserFilter struct {
+	Role       string
+	Email      string
+	Expression string
+}
+
+var _ UserStore = (*UserRepository)(nil)
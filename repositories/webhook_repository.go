@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "example_api/models"
+	"example_api/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookRepository stores webhooks in MongoDB and their delivery attempts in a separate
+// collection, where a TTL index enforces DeliveryRetention.
+type WebhookRepository struct {
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewWebhookRepository(db *mongodb.Database) *WebhookRepository {
+	return &WebhookRepository{
+		webhooks:   db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+var _ WebhookStore = (*WebhookRepository)(nil)
+
+// Create inserts a new webhook document.
+func (repo *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	if _, err := repo.webhooks.InsertOne(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns the webhook with the given ID, or ErrWebhookNotFound.
+func (repo *WebhookRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := repo.webhooks.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+// Replace overwrites the webhook document with the same ID.
+func (repo *WebhookRepository) Replace(ctx context.Context, webhook *models.Webhook) error {
+	result, err := repo.webhooks.ReplaceOne(ctx, bson.M{"_id": webhook.Id}, webhook)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Delete removes the webhook with the given ID and its delivery log.
+func (repo *WebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := repo.webhooks.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if _, err := repo.deliveries.DeleteMany(ctx, bson.M{"webhookId": id}); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of webhooks ordered by ID, along with the total number of webhooks.
+func (repo *WebhookRepository) List(ctx context.Context, skip, limit int64) ([]models.Webhook, int64, error) {
+	total, err := repo.webhooks.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+
+	cursor, err := repo.webhooks.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	webhooks := []models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+	return webhooks, total, nil
+}
+
+// ListByEvent returns every webhook whose events include eventType.
+func (repo *WebhookRepository) ListByEvent(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	cursor, err := repo.webhooks.Find(ctx, bson.M{"events": eventType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// RecordDelivery inserts a delivery attempt.
+func (repo *WebhookRepository) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if _, err := repo.deliveries.InsertOne(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns one page of the webhook's delivery attempts, newest first.
+func (repo *WebhookRepository) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, skip, limit int64) ([]models.WebhookDelivery, int64, error) {
+	filter := bson.M{"webhookId": webhookID}
+	total, err := repo.deliveries.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	cursor, err := repo.deliveries.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrWebhookNotFound is returned when no webhook matches the requested ID.
+var ErrWebhookNotFound = apperrors.NotFound("Webhook not found")
+
+// DeliveryRetention is how long webhook delivery attempts are kept in the delivery log.
+const DeliveryRetention = 30 * 24 * time.Hour
+
+// WebhookStore persists webhook subscriptions and their delivery log. Deleting a webhook also
+// deletes its deliveries.
+type WebhookStore interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error)
+	// Replace overwrites the stored webhook with the same ID, or returns ErrWebhookNotFound.
+	Replace(ctx context.Context, webhook *models.Webhook) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// List returns one page of webhooks ordered by ID, along with the total number of webhooks.
+	List(ctx context.Context, skip, limit int64) ([]models.Webhook, int64, error)
+	// ListByEvent returns every webhook subscribed to eventType.
+	ListByEvent(ctx context.Context, eventType string) ([]models.Webhook, error)
+
+	RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListDeliveries returns one page of a webhook's delivery attempts, newest first, along with
+	// the total number of attempts.
+	ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, skip, limit int64) ([]models.WebhookDelivery, int64, error)
+}
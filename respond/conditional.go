@@ -0,0 +1,35 @@
+package respond
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NotModified reports whether the request's If-None-Match header matches etag. Comparison
+// is weak, so an ETag weakened by a compressing proxy or middleware still matches.
+func NotModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Conditional tags the response with etag and Cache-Control. When the client's cached copy is
+// still current it writes an empty 304 and returns true, and the caller must not write a body.
+func Conditional(w http.ResponseWriter, r *http.Request, etag, cacheControl string) bool {
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", cacheControl)
+	if NotModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
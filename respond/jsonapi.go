@@ -0,0 +1,48 @@
+package respond
+
+import (
+	"encoding/json"
+	"example_api/mediatype"
+	"net/http"
+)
+
+// Document is a JSON:API top-level document.
+type Document struct {
+	Data  interface{}            `json:"data"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+	Links map[string]string      `json:"links,omitempty"`
+}
+
+// Resource is a JSON:API resource object.
+type Resource struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id"`
+	Attributes    interface{}             `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         map[string]string       `json:"links,omitempty"`
+}
+
+// Relationship is a JSON:API relationship object.
+type Relationship struct {
+	Data  interface{}       `json:"data"`
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// JSONAPI writes doc as a JSON:API document with the given status.
+func JSONAPI(w http.ResponseWriter, status int, doc Document) {
+	w.Header().Set("Content-Type", mediatype.JSONAPI)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(doc)
+}
+
+// Hrefs flattens links to the plain URLs JSON:API uses.
+func Hrefs(links map[string]Link) map[string]string {
+	if len(links) == 0 {
+		return nil
+	}
+	hrefs := make(map[string]string, len(links))
+	for rel, link := range links {
+		hrefs[rel] = link.Href
+	}
+	return hrefs
+}
@@ -0,0 +1,70 @@
+package respond
+
+import (
+	"encoding/json"
+	"example_api/i18n"
+	"example_api/problem"
+	"net/http"
+)
+
+// Envelope is the standard body of successful API responses.
+type Envelope struct {
+	Status     int             `json:"status"`
+	Message    string          `json:"message"`
+	Data       interface{}     `json:"data,omitempty"`
+	Pagination *Pagination     `json:"pagination,omitempty"`
+	Links      map[string]Link `json:"links,omitempty"`
+}
+
+// Link points at a related resource or action, keyed by its relation in Envelope.Links.
+type Link struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+// Pagination describes the page returned by a list endpoint.
+type Pagination struct {
+	Page  int   `json:"page" xml:"page,attr"`
+	Limit int   `json:"limit" xml:"limit,attr"`
+	Total int64 `json:"total" xml:"total,attr"`
+}
+
+// JSON writes body as JSON with the given status, always setting the Content-Type first.
+func JSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// OK writes a 200 envelope.
+func OK(w http.ResponseWriter, r *http.Request, message string, data interface{}) {
+	JSON(w, http.StatusOK, Envelope{Status: http.StatusOK, Message: Message(w, r, message), Data: data})
+}
+
+// Created writes a 201 envelope.
+func Created(w http.ResponseWriter, r *http.Request, message string, data interface{}) {
+	JSON(w, http.StatusCreated, Envelope{Status: http.StatusCreated, Message: Message(w, r, message), Data: data})
+}
+
+// Accepted writes a 202 envelope for work that continues in the background.
+func Accepted(w http.ResponseWriter, r *http.Request, message string, data interface{}) {
+	JSON(w, http.StatusAccepted, Envelope{Status: http.StatusAccepted, Message: Message(w, r, message), Data: data})
+}
+
+// Page writes a 200 envelope for one page of a list, with links to neighbouring pages.
+func Page(w http.ResponseWriter, r *http.Request, message string, data interface{}, pagination Pagination, links map[string]Link) {
+	JSON(w, http.StatusOK, Envelope{Status: http.StatusOK, Message: Message(w, r, message), Data: data, Pagination: &pagination, Links: links})
+}
+
+// Message returns message in the language negotiated for r and says so in Content-Language.
+// Responses that carry a message outside an Envelope pass it through here.
+func Message(w http.ResponseWriter, r *http.Request, message string) string {
+	locale := i18n.FromContext(r.Context())
+	w.Header().Set("Content-Language", locale)
+	return i18n.Translate(locale, message)
+}
+
+// Error writes an RFC 7807 problem response.
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	problem.Error(w, r, status, detail)
+}
@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Task is a periodic maintenance task. Runs are due at whole multiples of Interval, counted the
+// same way on every instance, and the run store lets one instance start each, so a task runs
+// once per interval however many instances there are.
+type Task struct {
+	Name        string
+	Description string
+	Interval    time.Duration
+	// Enabled tasks run; disabled ones are only listed
+	Enabled bool
+	// Run does the work and returns how many records it removed or changed
+	Run func(ctx context.Context) (int64, error)
+}
+
+// Scheduler runs registered tasks when they are due and records every run.
+type Scheduler struct {
+	store  repositories.TaskRunStore
+	logger *slog.Logger
+	tasks  []Task
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func New(store repositories.TaskRunStore, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		store:  store,
+		logger: logger,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Register adds task to the schedule. It must be called before Run.
+func (s *Scheduler) Register(task Task) {
+	s.tasks = append(s.tasks, task)
+}
+
+// Tasks returns the registered tasks in the order they were registered.
+func (s *Scheduler) Tasks() []Task {
+	return s.tasks
+}
+
+// Task returns the registered task called name, and whether there is one.
+func (s *Scheduler) Task(name string) (Task, bool) {
+	for _, task := range s.tasks {
+		if task.Name == name {
+			return task, true
+		}
+	}
+	return Task{}, false
+}
+
+// Next returns when the next run of a task with the given interval is due after now.
+func Next(interval time.Duration, now time.Time) time.Time {
+	return now.Truncate(interval).Add(interval)
+}
+
+// Close stops starting runs. Run returns once the runs under way are done.
+func (s *Scheduler) Close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// Run runs every enabled task when it is due until Close is called or ctx is done. A task whose
+// latest due run never happened, because no instance was running at the time, runs right away.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, task := range s.tasks {
+		if !task.Enabled {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.schedule(ctx, task)
+		}()
+	}
+	wg.Wait()
+}
+
+// schedule runs task at each due time until Close is called or ctx is done.
+func (s *Scheduler) schedule(ctx context.Context, task Task) {
+	due := time.Now().Truncate(task.Interval)
+	for {
+		s.run(ctx, task, due)
+
+		due = Next(task.Interval, time.Now())
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// run starts the run of task due at the given time, unless another instance already has, and
+// records its outcome.
+func (s *Scheduler) run(ctx context.Context, task Task, due time.Time) {
+	log := s.logger.With(slog.String("task", task.Name), slog.Time("scheduledAt", due))
+	run := &models.TaskRun{
+		Id:          primitive.NewObjectID(),
+		Task:        task.Name,
+		ScheduledAt: due,
+		StartedAt:   time.Now(),
+		Status:      models.TaskRunRunning,
+	}
+	started, err := s.store.Start(ctx, run)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.ErrorContext(ctx, "Failed to start scheduled task", slog.Any("error", err))
+		}
+		return
+	}
+	if !started {
+		return
+	}
+
+	affected, err := s.call(ctx, task)
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Affected = affected
+	if err != nil {
+		run.Status = models.TaskRunFailed
+		run.Error = err.Error()
+		log.ErrorContext(ctx, "Scheduled task failed", slog.Any("error", err))
+	} else {
+		run.Status = models.TaskRunSucceeded
+		log.InfoContext(ctx, "Scheduled task finished", slog.Int64("affected", affected), slog.Duration("duration", finishedAt.Sub(run.StartedAt)))
+	}
+	// The outcome is recorded even once ctx is done, so the history shows why the run stopped
+	if err := s.store.Finish(context.WithoutCancel(ctx), run); err != nil {
+		log.ErrorContext(ctx, "Failed to record scheduled task run", slog.Any("error", err))
+	}
+}
+
+// call runs task, turning a panic into an error so one bad task cannot stop the others.
+func (s *Scheduler) call(ctx context.Context, task Task) (affected int64, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("task panicked: %v", recovered)
+		}
+	}()
+	return task.Run(ctx)
+}
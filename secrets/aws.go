@@ -0,0 +1,164 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// AWSOptions configures an AWS Secrets Manager provider. Credentials come from the standard
+// AWS environment, credentials file, or IAM role.
+type AWSOptions struct {
+	Region string
+	// SecretID is the name or ARN of the secret, whose string value is a JSON object
+	SecretID string
+}
+
+// AWS reads a secret from AWS Secrets Manager.
+type AWS struct {
+	opts     AWSOptions
+	endpoint string
+	creds    *credentials.Credentials
+	client   *http.Client
+}
+
+func NewAWS(opts AWSOptions) *AWS {
+	return &AWS{
+		opts:     opts,
+		endpoint: "https://secretsmanager." + opts.Region + ".amazonaws.com/",
+		creds: credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		}),
+		client: &http.Client{},
+	}
+}
+
+var _ Provider = (*AWS)(nil)
+
+// awsError is the body of an AWS JSON protocol error response.
+type awsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Fetch reads the current version of the secret.
+func (a *AWS) Fetch(ctx context.Context) (map[string]string, error) {
+	creds, err := a.creds.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": a.opts.SecretID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, body, creds, a.opts.Region, "secretsmanager", time.Now().UTC())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach AWS Secrets Manager: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWS Secrets Manager response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr awsError
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Type == "" {
+			return nil, fmt.Errorf("aws secrets manager responded %d", resp.StatusCode)
+		}
+		// Types may be prefixed with a namespace, as in com.amazonaws...#ResourceNotFoundException
+		return nil, fmt.Errorf("aws secrets manager error %s: %s", apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:], apiErr.Message)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return nil, fmt.Errorf("invalid AWS Secrets Manager response: %w", err)
+	}
+	if secret.SecretString == nil {
+		return nil, fmt.Errorf("secret %s has no string value", a.opts.SecretID)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(*secret.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("secret %s must hold a JSON object: %w", a.opts.SecretID, err)
+	}
+	return decodeValues(values)
+}
+
+// signV4 signs req, whose body is body, with AWS Signature Version 4 for service in region.
+// Every header set on req is signed along with Host.
+func signV4(req *http.Request, body []byte, creds credentials.Value, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,83 @@
+// Package secrets reads settings from a secrets manager, so credentials such as MONGO_URI and
+// SMTP_PASSWORD need not be kept in the environment or a .env file. A secret holds settings by
+// their environment variable name.
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Provider reads the settings held by a secret in a secrets manager.
+type Provider interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// Cache keeps the settings last read from a Provider, so lookups never wait on the secrets
+// manager, and reads them again every interval.
+type Cache struct {
+	provider Provider
+	interval time.Duration
+	timeout  time.Duration
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func NewCache(provider Provider, interval, timeout time.Duration) *Cache {
+	return &Cache{
+		provider: provider,
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+// Refresh reads the settings again, replacing all those read before. They are kept when the
+// read fails.
+func (c *Cache) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	values, err := c.provider.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.values = values
+	c.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the setting with the given name and whether the secret holds it.
+func (c *Cache) Lookup(name string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.values[name]
+	return value, ok
+}
+
+// Value returns the setting with the given name, or def if the secret does not hold it.
+func (c *Cache) Value(name, def string) string {
+	if value, ok := c.Lookup(name); ok {
+		return value
+	}
+	return def
+}
+
+// Run refreshes the settings every interval until ctx is done. A failed refresh is logged and
+// the settings read before are kept until the next one.
+func (c *Cache) Run(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Failed to refresh secrets", slog.Any("error", err))
+			}
+		}
+	}
+}
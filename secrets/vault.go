@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// VaultOptions configures a Vault provider.
+type VaultOptions struct {
+	// Address is the base URL of the Vault server, such as https://vault.example.com:8200
+	Address string
+	Token   string
+	// Namespace is the Vault Enterprise namespace of the secret, if any
+	Namespace string
+	// Path is the API path of the secret below /v1, such as secret/data/example-api for a KV
+	// version 2 engine mounted at secret
+	Path string
+}
+
+// Vault reads a secret from a key/value engine of HashiCorp Vault, version 1 or 2.
+type Vault struct {
+	opts     VaultOptions
+	endpoint string
+	client   *http.Client
+}
+
+func NewVault(opts VaultOptions) *Vault {
+	return &Vault{
+		opts:     opts,
+		endpoint: strings.TrimRight(opts.Address, "/") + "/v1/" + strings.TrimLeft(opts.Path, "/"),
+		client:   &http.Client{},
+	}
+}
+
+var _ Provider = (*Vault)(nil)
+
+// vaultSecret is the body of a Vault read. A KV version 2 engine nests the secret's data in
+// data with its metadata beside it.
+type vaultSecret struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []string                   `json:"errors"`
+}
+
+// Fetch reads the latest version of the secret.
+func (v *Vault) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.opts.Token)
+	if v.opts.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.opts.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Vault response: %w", err)
+	}
+	var secret vaultSecret
+	if err := json.Unmarshal(body, &secret); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("invalid Vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(secret.Errors) > 0 {
+			return nil, fmt.Errorf("vault responded %d: %s", resp.StatusCode, strings.Join(secret.Errors, "; "))
+		}
+		return nil, fmt.Errorf("vault responded %d", resp.StatusCode)
+	}
+
+	data := secret.Data
+	if nested, ok := data["data"]; ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nil
+			if err := json.Unmarshal(nested, &data); err != nil {
+				return nil, fmt.Errorf("invalid Vault secret: %w", err)
+			}
+		}
+	}
+	return decodeValues(data)
+}
+
+// decodeValues returns the string values of a secret, which must not hold any other kind.
+func decodeValues(data map[string]json.RawMessage) (map[string]string, error) {
+	values := make(map[string]string, len(data))
+	for name, raw := range data {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("secret value %s must be a string", name)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
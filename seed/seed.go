@@ -0,0 +1,56 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"example_api/actor"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/services"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// DefaultPassword is the password every seeded user can sign in with.
+const DefaultPassword = "password123"
+
+// Users creates count users with realistic fake names and emails through the user service,
+// so seeded data goes through the same validation and hashing as real signups.
+func Users(ctx context.Context, service *services.UserService, count int, logger *slog.Logger) error {
+	ctx = actor.NewContext(ctx, actor.Actor{Name: actor.Seed})
+	faker := gofakeit.New(0)
+
+	created := 0
+	for created < count {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		firstName := faker.FirstName()
+		lastName := faker.LastName()
+		user := &models.User{
+			Email:     fmt.Sprintf("%s.%s.%d@%s", strings.ToLower(firstName), strings.ToLower(lastName), faker.Number(1, 9999), faker.DomainName()),
+			Password:  DefaultPassword,
+			FirstName: firstName,
+			LastName:  lastName,
+		}
+
+		if _, err := service.CreateUser(ctx, user); err != nil {
+			if errors.Is(err, repositories.ErrEmailTaken) {
+				continue
+			}
+			return fmt.Errorf("failed to seed user: %w", err)
+		}
+
+		created++
+		if created%100 == 0 {
+			logger.Info("Seeding users", slog.Int("created", created), slog.Int("total", count))
+		}
+	}
+
+	logger.Info("Seeded users", slog.Int("count", created))
+	return nil
+}
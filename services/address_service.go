@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrAddressNotFound is returned when a user has no address with the requested ID.
+	ErrAddressNotFound = apperrors.NotFound("Address not found")
+	// ErrTooManyAddresses is returned when adding an address to a user who has the most allowed.
+	ErrTooManyAddresses = apperrors.Conflict(fmt.Sprintf("A user can have at most %d addresses", models.MaxAddresses))
+)
+
+// AddressService manages the postal addresses of users. Addresses are stored with their user,
+// so every change rewrites the user's list at the version read, and a concurrent change to the
+// same user fails with ErrVersionConflict rather than being lost.
+type AddressService struct {
+	users repositories.UserStore
+}
+
+func NewAddressService(users repositories.UserStore) *AddressService {
+	return &AddressService{
+		users: users,
+	}
+}
+
+// ListAddresses returns the addresses of the user with the given hex ID in the order they were
+// added.
+func (s *AddressService) ListAddresses(ctx context.Context, userID string) ([]models.Address, error) {
+	objectID, err := parseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Addresses == nil {
+		return []models.Address{}, nil
+	}
+	return user.Addresses, nil
+}
+
+// GetAddress returns the address with the given hex ID of the user with the given hex ID.
+func (s *AddressService) GetAddress(ctx context.Context, userID, id string) (*models.Address, error) {
+	objectID, err := parseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	addressID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(user.Addresses, func(a models.Address) bool { return a.Id == addressID })
+	if i < 0 {
+		return nil, ErrAddressNotFound
+	}
+	return &user.Addresses[i], nil
+}
+
+// AddAddress validates address and adds it to the addresses of the user with the given hex ID.
+func (s *AddressService) AddAddress(ctx context.Context, userID string, address *models.Address) (*models.Address, error) {
+	normalizeAddress(address)
+	if err := validation.Struct(address); err != nil {
+		return nil, err
+	}
+	user, err := s.writableUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Addresses) >= models.MaxAddresses {
+		return nil, ErrTooManyAddresses
+	}
+
+	address.Id = primitive.NewObjectID()
+	addresses := append(slices.Clone(user.Addresses), *address)
+	if err := s.users.Update(ctx, user.Id, user.Version, map[string]interface{}{"addresses": addresses}); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// UpdateAddress validates address and replaces the address with the given hex ID of the user
+// with the given hex ID with it.
+func (s *AddressService) UpdateAddress(ctx context.Context, userID, id string, address *models.Address) (*models.Address, error) {
+	addressID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	normalizeAddress(address)
+	if err := validation.Struct(address); err != nil {
+		return nil, err
+	}
+	user, err := s.writableUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(user.Addresses, func(a models.Address) bool { return a.Id == addressID })
+	if i < 0 {
+		return nil, ErrAddressNotFound
+	}
+
+	address.Id = addressID
+	addresses := slices.Clone(user.Addresses)
+	addresses[i] = *address
+	if err := s.users.Update(ctx, user.Id, user.Version, map[string]interface{}{"addresses": addresses}); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// DeleteAddress removes the address with the given hex ID from the user with the given hex ID.
+func (s *AddressService) DeleteAddress(ctx context.Context, userID, id string) error {
+	addressID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	user, err := s.writableUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(user.Addresses, func(a models.Address) bool { return a.Id == addressID })
+	if i < 0 {
+		return ErrAddressNotFound
+	}
+
+	addresses := slices.Delete(slices.Clone(user.Addresses), i, i+1)
+	return s.users.Update(ctx, user.Id, user.Version, map[string]interface{}{"addresses": addresses})
+}
+
+// writableUser returns the user with the given hex ID unless they have been erased.
+func (s *AddressService) writableUser(ctx context.Context, id string) (*models.User, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+	return user, nil
+}
+
+// normalizeAddress trims the fields of address and upper-cases its country code, so "tr " is
+// accepted and stored as TR.
+func normalizeAddress(address *models.Address) {
+	address.Street = strings.TrimSpace(address.Street)
+	address.City = strings.TrimSpace(address.City)
+	address.PostalCode = strings.TrimSpace(address.PostalCode)
+	address.CountryCode = strings.ToUpper(strings.TrimSpace(address.CountryCode))
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"slices"
+	"strings"
+)
+
+// auditActions lists the actions audit entries can have.
+var auditActions = []string{models.AuditUserCreated, models.AuditUserUpdated, models.AuditUserDeleted, models.AuditUserErased}
+
+var (
+	// ErrInvalidAuditAction is returned when an audit query names an unknown action.
+	ErrInvalidAuditAction = apperrors.Validation("Action must be one of: " + strings.Join(auditActions, ", "))
+	// ErrInvalidTimeRange is returned when an audit query's time range ends before it starts.
+	ErrInvalidTimeRange = apperrors.Validation("The end of the time range must be after its start")
+)
+
+type AuditService struct {
+	repo repositories.AuditStore
+}
+
+func NewAuditService(repo repositories.AuditStore) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+// ListAuditLogs returns the requested page of audit entries matching query, newest first, and
+// the total count.
+func (s *AuditService) ListAuditLogs(ctx context.Context, query models.AuditQuery, page, limit int) ([]models.AuditEntry, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	filter := repositories.AuditFilter{Actor: query.Actor, Action: query.Action, From: query.From, To: query.To}
+	if query.TargetID != "" {
+		targetID, err := parseID(query.TargetID)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.TargetID = targetID
+	}
+	if query.Action != "" && !slices.Contains(auditActions, query.Action) {
+		return nil, 0, ErrInvalidAuditAction
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.To.After(query.From) {
+		return nil, 0, ErrInvalidTimeRange
+	}
+	return s.repo.List(ctx, filter, int64(page-1)*int64(limit), int64(limit))
+}
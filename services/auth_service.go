@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example_api/auth"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"slices"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// missingUserHash is compared against the password of logins with an unknown email, so they
+// take as long as logins with a wrong password and do not reveal which emails are registered.
+var missingUserHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("missing user"), bcrypt.DefaultCost)
+	return hash
+})
+
+type AuthService struct {
+	users  repositories.UserStore
+	roles  repositories.RoleStore
+	groups repositories.GroupStore
+}
+
+func NewAuthService(users repositories.UserStore, roles repositories.RoleStore, groups repositories.GroupStore) *AuthService {
+	return &AuthService{
+		users:  users,
+		roles:  roles,
+		groups: groups,
+	}
+}
+
+// AdminPrincipal is the principal of requests made with ADMIN_TOKEN, which holds every
+// permission.
+func AdminPrincipal() *auth.Principal {
+	return &auth.Principal{Role: models.RoleAdmin, Permissions: models.Permissions}
+}
+
+// Authenticate returns the principal of the user with the given email and password, holding
+// the permissions of their role and of every group they are in, or auth.ErrInvalidCredentials.
+// Erased users cannot sign in.
+func (s *AuthService) Authenticate(ctx context.Context, email, password string) (*auth.Principal, error) {
+	users, _, err := s.users.List(ctx, repositories.ListOptions{Filter: repositories.UserFilter{Email: email}, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 || users[0].ErasedAt != nil {
+		bcrypt.CompareHashAndPassword(missingUserHash(), []byte(password))
+		return nil, auth.ErrInvalidCredentials
+	}
+	user := users[0]
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	var permissions []string
+	role, err := lookupRole(ctx, s.roles, user.Role)
+	switch {
+	case errors.Is(err, repositories.ErrRoleNotFound):
+		// A role deleted out from under its users grants nothing
+	case err != nil:
+		return nil, fmt.Errorf("failed to resolve role %q: %w", user.Role, err)
+	default:
+		permissions = append(permissions, role.Permissions...)
+	}
+
+	groups, _, err := s.groups.ListUserGroups(ctx, user.Id, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve groups: %w", err)
+	}
+	for _, group := range groups {
+		permissions = append(permissions, group.Permissions...)
+	}
+	slices.Sort(permissions)
+	return &auth.Principal{UserID: user.Id.Hex(), Role: user.Role, Permissions: slices.Compact(permissions)}, nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"example_api/apperrors"
+	"example_api/imaging"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// avatarTypes lists the image formats accepted as avatars, by their sniffed content type.
+var avatarTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// avatarVariants lists the scaled-down copies made of every avatar and the square, in pixels,
+// each one fits into.
+var avatarVariants = []struct {
+	name string
+	size int
+}{
+	{models.AvatarThumbnail, 128},
+	{models.AvatarMedium, 512},
+}
+
+var (
+	// ErrUnsupportedAvatarType is returned when an uploaded avatar is not an accepted image format
+	ErrUnsupportedAvatarType = apperrors.UnsupportedMediaType("Avatar must be a PNG, JPEG, GIF, or WebP image")
+	// ErrInvalidAvatar is returned when an uploaded avatar cannot be decoded
+	ErrInvalidAvatar = apperrors.Validation("Avatar is not a valid image")
+	// ErrUnknownAvatarVariant is returned when a requested avatar size does not exist
+	ErrUnknownAvatarVariant = apperrors.Validation("Avatar size must be original, medium, or thumbnail")
+)
+
+type AvatarService struct {
+	users   repositories.UserStore
+	avatars repositories.AvatarStore
+	maxSize int64
+}
+
+// NewAvatarService returns a service accepting avatars of up to maxSize bytes.
+func NewAvatarService(users repositories.UserStore, avatars repositories.AvatarStore, maxSize int64) *AvatarService {
+	return &AvatarService{
+		users:   users,
+		avatars: avatars,
+		maxSize: maxSize,
+	}
+}
+
+// MaxSize returns the largest avatar accepted, in bytes.
+func (s *AvatarService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// SetAvatar stores data as the avatar of the user with the given hex ID and returns the stored
+// original. The content type is determined from the bytes themselves rather than trusted from
+// the client. Metadata such as EXIF location data is removed, and thumbnail and medium copies
+// are stored alongside.
+func (s *AvatarService) SetAvatar(ctx context.Context, id string, data []byte) (*models.Avatar, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, apperrors.TooLarge(fmt.Sprintf("Avatar must not exceed %d bytes", s.maxSize))
+	}
+	contentType := http.DetectContentType(data)
+	if !avatarTypes[contentType] {
+		return nil, ErrUnsupportedAvatarType
+	}
+	img, err := imaging.Decode(data, contentType)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		return nil, apperrors.TooLarge(fmt.Sprintf("Avatar must not exceed %d pixels", imaging.MaxPixels))
+	}
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+	original, err := img.Stripped()
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+
+	// The original is stored last, so once it is replaced its copies already match it
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	for _, variant := range avatarVariants {
+		scaled, scaledType, err := img.Resized(variant.size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resize avatar: %w", err)
+		}
+		if _, err := s.put(ctx, objectID, variant.name, scaledType, scaled, updatedAt); err != nil {
+			return nil, err
+		}
+	}
+	return s.put(ctx, objectID, models.AvatarOriginal, contentType, original, updatedAt)
+}
+
+// put stores one variant of a user's avatar.
+func (s *AvatarService) put(ctx context.Context, userID primitive.ObjectID, variant, contentType string, data []byte, updatedAt time.Time) (*models.Avatar, error) {
+	sum := sha256.Sum256(data)
+	avatar := &models.Avatar{
+		UserID:      userID,
+		Variant:     variant,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+		UpdatedAt:   updatedAt,
+	}
+	if err := s.avatars.Put(ctx, avatar, data); err != nil {
+		return nil, err
+	}
+	return avatar, nil
+}
+
+// GetAvatar returns the variant of the avatar of the user with the given hex ID and a reader
+// for its bytes, which the caller must close. An empty variant selects the original.
+func (s *AvatarService) GetAvatar(ctx context.Context, id, variant string) (*models.Avatar, io.ReadCloser, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch variant {
+	case "":
+		variant = models.AvatarOriginal
+	case models.AvatarOriginal, models.AvatarMedium, models.AvatarThumbnail:
+	default:
+		return nil, nil, ErrUnknownAvatarVariant
+	}
+	return s.avatars.Get(ctx, objectID, variant)
+}
+
+// DeleteAvatar removes the avatar of the user with the given hex ID, or returns
+// ErrAvatarNotFound when there is none.
+func (s *AvatarService) DeleteAvatar(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	_, data, err := s.avatars.Get(ctx, objectID, models.AvatarOriginal)
+	if err != nil {
+		return err
+	}
+	data.Close()
+	return s.avatars.Delete(ctx, objectID)
+}
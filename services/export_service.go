@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"example_api/apperrors"
+	"example_api/jobs"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"fmt"
+	"io"
+	"time"
+)
+
+// JobExportUsers is the job type of a background user export.
+const JobExportUsers = "users.export"
+
+// exportRetryPolicy retries exports that fail on a store outage a few times.
+var exportRetryPolicy = repositories.RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+var (
+	// ErrExportPending is returned when downloading an export whose job has not finished.
+	ErrExportPending = apperrors.Conflict("The export has not finished yet")
+	// ErrExportFailed is returned when downloading an export whose job failed.
+	ErrExportFailed = apperrors.Conflict("The export failed")
+)
+
+// ExportRenderer writes the users an export request selects to w and returns the file's name
+// and media type.
+type ExportRenderer func(ctx context.Context, request *models.ExportRequest, w io.Writer) (filename, contentType string, err error)
+
+// ExportService exports users in the background. Each export is a job, and its file is kept in
+// the export store under the job's ID.
+type ExportService struct {
+	jobs   repositories.JobStore
+	files  repositories.ExportStore
+	queue  *jobs.Queue
+	render ExportRenderer
+}
+
+// NewExportService returns a service and registers its exports with queue.
+func NewExportService(jobStore repositories.JobStore, files repositories.ExportStore, queue *jobs.Queue, render ExportRenderer) *ExportService {
+	s := &ExportService{
+		jobs:   jobStore,
+		files:  files,
+		queue:  queue,
+		render: render,
+	}
+	queue.Register(JobExportUsers, s.export, exportRetryPolicy)
+	return s
+}
+
+// StartExport validates request and queues the export, returning its job.
+func (s *ExportService) StartExport(ctx context.Context, request *models.ExportRequest) (*models.Job, error) {
+	if err := validation.Struct(request); err != nil {
+		return nil, err
+	}
+	if _, err := parseFilter(request.Filter); err != nil {
+		return nil, err
+	}
+	return s.queue.Enqueue(ctx, JobExportUsers, request)
+}
+
+// GetExport returns the job of the export with the given hex ID.
+func (s *ExportService) GetExport(ctx context.Context, id string) (*models.Job, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.jobs.GetByID(ctx, objectID)
+	if errors.Is(err, repositories.ErrJobNotFound) || (err == nil && job.Type != JobExportUsers) {
+		return nil, repositories.ErrExportNotFound
+	}
+	return job, err
+}
+
+// OpenExport returns the file of the finished export with the given hex ID and a reader for its
+// bytes, which the caller must close.
+func (s *ExportService) OpenExport(ctx context.Context, id string) (*models.ExportFile, io.ReadCloser, error) {
+	job, err := s.GetExport(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch job.Status {
+	case models.JobSucceeded:
+		return s.files.Get(ctx, job.Id)
+	case models.JobDead:
+		return nil, nil, ErrExportFailed
+	default:
+		return nil, nil, ErrExportPending
+	}
+}
+
+// export runs an export job. Requests that cannot succeed, such as ones naming unknown
+// columns, fail without being retried.
+func (s *ExportService) export(ctx context.Context, job *models.Job) error {
+	var request models.ExportRequest
+	if err := jobs.Decode(job, &request); err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	filename, contentType, err := s.render(ctx, &request, &data)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			return jobs.Permanent(err)
+		}
+		return fmt.Errorf("failed to render export: %w", err)
+	}
+	file := &models.ExportFile{
+		Id:          job.Id,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(data.Len()),
+		CreatedAt:   time.Now(),
+	}
+	return s.files.Put(ctx, file, data.Bytes())
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	"example_api/auth"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/tenant"
+	"example_api/validation"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidFeatureFlagKey is returned for flag keys that are not lowercase dotted words.
+	ErrInvalidFeatureFlagKey = apperrors.InvalidFields("Invalid input", []apperrors.FieldError{{
+		Field:   "key",
+		Rule:    "flagkey",
+		Message: "key must start with a lowercase letter followed by at most 99 lowercase letters, digits, dots, hyphens, or underscores",
+	}})
+	// ErrInvalidFeatureFlagTenant is returned for tenant overrides that do not name a tenant.
+	ErrInvalidFeatureFlagTenant = apperrors.InvalidFields("Invalid input", []apperrors.FieldError{{
+		Field:   "tenants",
+		Rule:    "tenant",
+		Message: "tenants must be keyed by tenant IDs, which are lowercase letters, digits, and hyphens",
+	}})
+)
+
+var featureFlagKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9._-]{0,99}$`)
+
+// FeatureFlagService manages feature flags and answers whether they are on. Flags are checked
+// on hot paths, so they are read from the store at most once per ttl; changes made through
+// another instance take up to ttl to apply here.
+type FeatureFlagService struct {
+	repo   repositories.FeatureFlagStore
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	ttl      time.Duration
+	flags    map[string]models.FeatureFlag
+	loadedAt time.Time
+}
+
+func NewFeatureFlagService(repo repositories.FeatureFlagStore, ttl time.Duration, logger *slog.Logger) *FeatureFlagService {
+	return &FeatureFlagService{
+		repo:   repo,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// ListFlags returns every flag ordered by key.
+func (s *FeatureFlagService) ListFlags(ctx context.Context) ([]models.FeatureFlag, error) {
+	return s.repo.List(ctx)
+}
+
+// GetFlag returns the flag with the given key.
+func (s *FeatureFlagService) GetFlag(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	return s.repo.GetByKey(ctx, key)
+}
+
+// PutFlag validates flag and stores it under key, creating the flag or replacing it.
+func (s *FeatureFlagService) PutFlag(ctx context.Context, key string, flag *models.FeatureFlag) (*models.FeatureFlag, error) {
+	if !featureFlagKeyPattern.MatchString(key) {
+		return nil, ErrInvalidFeatureFlagKey
+	}
+	if err := validation.Struct(flag); err != nil {
+		return nil, err
+	}
+	for id := range flag.Tenants {
+		if !tenant.Valid(id) {
+			return nil, ErrInvalidFeatureFlagTenant
+		}
+	}
+	flag.Key = key
+	flag.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Put(ctx, flag); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return flag, nil
+}
+
+// DeleteFlag removes the flag with the given key, which turns it off everywhere.
+func (s *FeatureFlagService) DeleteFlag(ctx context.Context, key string) error {
+	if err := s.repo.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Enabled reports whether the flag with the given key is on for the request of ctx. Unknown
+// flags are off, and so is every flag while they cannot be loaded, so unfinished behavior
+// stays dark.
+func (s *FeatureFlagService) Enabled(ctx context.Context, key string) bool {
+	flags, ok := s.load(ctx)
+	if !ok {
+		return false
+	}
+	flag, ok := flags[key]
+	return ok && flagEnabled(ctx, flag)
+}
+
+// EnabledFeatures returns the keys of the flags that are on for the request of ctx, sorted.
+func (s *FeatureFlagService) EnabledFeatures(ctx context.Context) []string {
+	keys := []string{}
+	flags, _ := s.load(ctx)
+	for key, flag := range flags {
+		if flagEnabled(ctx, flag) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// load returns the flags by key, read from the store if they were last read more than ttl
+// ago. When reading fails the previous flags are kept, if there are any.
+func (s *FeatureFlagService) load(ctx context.Context) (map[string]models.FeatureFlag, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.flags != nil && time.Since(s.loadedAt) < s.ttl {
+		return s.flags, true
+	}
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load feature flags", slog.Any("error", err))
+		return s.flags, s.flags != nil
+	}
+	s.flags = make(map[string]models.FeatureFlag, len(list))
+	for _, flag := range list {
+		s.flags[flag.Key] = flag
+	}
+	s.loadedAt = time.Now()
+	return s.flags, true
+}
+
+// Reconfigure reads the flags from the store at most once per ttl from now on. The flags are
+// read again at the next check, so changes made through other instances apply at once.
+func (s *FeatureFlagService) Reconfigure(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+	s.flags = nil
+}
+
+// invalidate makes the next check read the flags from the store again.
+func (s *FeatureFlagService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags = nil
+}
+
+// flagEnabled evaluates flag for the request of ctx: a tenant override wins, and otherwise an
+// enabled flag is on for the users, or anonymously for the tenants, whose bucket falls below
+// its rollout percentage.
+func flagEnabled(ctx context.Context, flag models.FeatureFlag) bool {
+	tenantID := tenant.FromContext(ctx)
+	if enabled, ok := flag.Tenants[tenantID]; ok {
+		return enabled
+	}
+	if !flag.Enabled {
+		return false
+	}
+	subject := tenantID
+	if principal := auth.FromContext(ctx); principal != nil && principal.UserID != "" {
+		subject = principal.UserID
+	}
+	return rolloutBucket(flag.Key, subject) < flag.Rollout
+}
+
+// rolloutBucket places subject in one of 100 buckets for the flag with the given key. Hashing
+// the key along with the subject keeps the same users from getting every new flag first.
+func rolloutBucket(key, subject string) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%s", key, subject)
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"example_api/apperrors"
+	"example_api/repositories"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on filter expressions, which keep the queries they become cheap to build and run
+const (
+	maxFilterLength      = 1000
+	maxFilterComparisons = 20
+	maxFilterDepth       = 5
+)
+
+// filterField is a user field filter expressions can compare, with the operators it allows and
+// how its values are parsed.
+type filterField struct {
+	ops   []repositories.FilterOp
+	parse func(string) (interface{}, bool)
+}
+
+var (
+	stringFilterOps = []repositories.FilterOp{repositories.FilterEq, repositories.FilterNe, repositories.FilterContains}
+	orderFilterOps  = []repositories.FilterOp{
+		repositories.FilterEq, repositories.FilterNe,
+		repositories.FilterGt, repositories.FilterGte, repositories.FilterLt, repositories.FilterLte,
+	}
+)
+
+// filterFields lists the fields filter expressions can compare.
+var filterFields = map[string]filterField{
+	"email":     {stringFilterOps, parseFilterString},
+	"firstName": {stringFilterOps, parseFilterString},
+	"lastName":  {stringFilterOps, parseFilterString},
+	"role":      {stringFilterOps, parseFilterString},
+	"joinDate":  {orderFilterOps, parseFilterTime},
+	"version":   {orderFilterOps, parseFilterInt},
+}
+
+// metadataFilterField is how filter expressions compare metadata.{key}, which matches the
+// string value of a top-level metadata key.
+var metadataFilterField = filterField{stringFilterOps, parseFilterString}
+
+// filterFieldNames lists filterFields in the order error messages name them.
+var filterFieldNames = []string{"email", "firstName", "lastName", "role", "joinDate", "version", "metadata.{key}"}
+
+func parseFilterString(value string) (interface{}, bool) {
+	return value, true
+}
+
+// parseFilterTime accepts RFC 3339 times and dates, which stand for midnight UTC.
+func parseFilterTime(value string) (interface{}, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true
+	}
+	return nil, false
+}
+
+func parseFilterInt(value string) (interface{}, bool) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n, err == nil
+}
+
+// parseFilter parses a filter expression such as
+//
+//	joinDate>=2024-01-01 AND (lastName~"oğlu" OR role=admin)
+//
+// Comparisons take a field, an operator, and a value, which is either a word or a double-quoted
+// string with \" and \\ escapes. = and != compare exactly, ~ matches strings containing the
+// value ignoring case, and >, >=, <, and <= order dates and versions. Comparisons combine with
+// AND, which binds tighter, OR, and parentheses. Only the fields in filterFields and metadata
+// keys, as metadata.{key}, can be compared, each with the operators it allows. An empty
+// expression filters nothing.
+func parseFilter(expression string) (*repositories.FilterExpr, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+	if len(expression) > maxFilterLength {
+		return nil, apperrors.Validation(fmt.Sprintf("Filter must not exceed %d bytes", maxFilterLength))
+	}
+	p := &filterParser{input: expression}
+	expr, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.input) {
+		return nil, p.errorf("expected AND or OR")
+	}
+	return expr, nil
+}
+
+// filterParser is a recursive descent parser of filter expressions.
+type filterParser struct {
+	input       string
+	pos         int
+	comparisons int
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return apperrors.Validation(fmt.Sprintf("Invalid filter at character %d: %s", utf8.RuneCountInString(p.input[:p.pos])+1, fmt.Sprintf(format, args...)))
+}
+
+func (p *filterParser) or(depth int) (*repositories.FilterExpr, error) {
+	return p.combine(depth, "OR", p.and)
+}
+
+func (p *filterParser) and(depth int) (*repositories.FilterExpr, error) {
+	return p.combine(depth, "AND", p.operand)
+}
+
+// combine parses one or more operands separated by keyword.
+func (p *filterParser) combine(depth int, keyword string, operand func(int) (*repositories.FilterExpr, error)) (*repositories.FilterExpr, error) {
+	var operands []repositories.FilterExpr
+	for {
+		expr, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		// Flatten a AND (b AND c), and likewise for OR
+		if keyword == "AND" && len(expr.And) > 0 {
+			operands = append(operands, expr.And...)
+		} else if keyword == "OR" && len(expr.Or) > 0 {
+			operands = append(operands, expr.Or...)
+		} else {
+			operands = append(operands, *expr)
+		}
+		if !p.keyword(keyword) {
+			break
+		}
+	}
+	switch {
+	case len(operands) == 1:
+		return &operands[0], nil
+	case keyword == "AND":
+		return &repositories.FilterExpr{And: operands}, nil
+	default:
+		return &repositories.FilterExpr{Or: operands}, nil
+	}
+}
+
+// keyword consumes keyword, in any case, if it comes next as a whole word.
+func (p *filterParser) keyword(keyword string) bool {
+	p.skipSpace()
+	end := p.pos + len(keyword)
+	if end > len(p.input) || !strings.EqualFold(p.input[p.pos:end], keyword) {
+		return false
+	}
+	if end < len(p.input) && isFilterWordByte(p.input[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+// operand parses a parenthesized expression or a comparison.
+func (p *filterParser) operand(depth int) (*repositories.FilterExpr, error) {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		if depth == maxFilterDepth {
+			return nil, p.errorf("parentheses nest more than %d deep", maxFilterDepth)
+		}
+		p.pos++
+		expr, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpace(); p.pos == len(p.input) || p.input[p.pos] != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser) comparison() (*repositories.FilterExpr, error) {
+	start := p.pos
+	name := p.word()
+	if name == "" {
+		return nil, p.errorf("expected a field")
+	}
+	field, ok := filterFields[name]
+	if key, isMetadata := strings.CutPrefix(name, "metadata."); isMetadata && metadataKey.MatchString(key) {
+		field, ok = metadataFilterField, true
+	}
+	if !ok {
+		p.pos = start
+		return nil, p.errorf("cannot filter by %q; filterable fields are %s", name, strings.Join(filterFieldNames, ", "))
+	}
+
+	p.skipSpace()
+	opStart := p.pos
+	op := p.op()
+	if op == "" {
+		return nil, p.errorf("expected an operator after %s", name)
+	}
+	if !slices.Contains(field.ops, op) {
+		p.pos = opStart
+		return nil, p.errorf("%s cannot be compared with %s", name, op)
+	}
+
+	p.skipSpace()
+	valueStart := p.pos
+	raw, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := field.parse(raw)
+	if !ok {
+		p.pos = valueStart
+		return nil, p.errorf("invalid %s value %q", name, raw)
+	}
+
+	if p.comparisons++; p.comparisons > maxFilterComparisons {
+		return nil, p.errorf("filters hold at most %d comparisons", maxFilterComparisons)
+	}
+	return &repositories.FilterExpr{Field: name, Op: op, Value: value}, nil
+}
+
+// filterOps lists the operators longest first, so >= is not read as >.
+var filterOps = []repositories.FilterOp{
+	repositories.FilterNe, repositories.FilterGte, repositories.FilterLte,
+	repositories.FilterEq, repositories.FilterGt, repositories.FilterLt, repositories.FilterContains,
+}
+
+func (p *filterParser) op() repositories.FilterOp {
+	for _, op := range filterOps {
+		if strings.HasPrefix(p.input[p.pos:], string(op)) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return ""
+}
+
+// value parses a quoted string or a word.
+func (p *filterParser) value() (string, error) {
+	if p.pos == len(p.input) || p.input[p.pos] != '"' {
+		if word := p.word(); word != "" {
+			return word, nil
+		}
+		return "", p.errorf("expected a value")
+	}
+	start := p.pos
+	p.pos++
+	var value strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return value.String(), nil
+		case '\\':
+			if p.pos == len(p.input) || (p.input[p.pos] != '"' && p.input[p.pos] != '\\') {
+				p.pos--
+				return "", p.errorf(`only \" and \\ can be escaped`)
+			}
+			c = p.input[p.pos]
+			p.pos++
+		}
+		value.WriteByte(c)
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// word parses a run of bytes that are not spaces, quotes, parentheses, or operators.
+func (p *filterParser) word() string {
+	start := p.pos
+	for p.pos < len(p.input) && isFilterWordByte(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func isFilterWordByte(c byte) bool {
+	return !strings.ContainsRune(" \t\r\n\"()=!<>~", rune(c))
+}
+
+func (p *filterParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"example_api/apperrors"
+	"example_api/repositories"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func comparison(field string, op repositories.FilterOp, value interface{}) repositories.FilterExpr {
+	return repositories.FilterExpr{Field: field, Op: op, Value: value}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       *repositories.FilterExpr
+	}{
+		{"empty", "", nil},
+		{"blank", "  \t", nil},
+		{"equals", "role=admin", &repositories.FilterExpr{Field: "role", Op: repositories.FilterEq, Value: "admin"}},
+		{"not equals", "role!=admin", &repositories.FilterExpr{Field: "role", Op: repositories.FilterNe, Value: "admin"}},
+		{"contains", "lastName~oğlu", &repositories.FilterExpr{Field: "lastName", Op: repositories.FilterContains, Value: "oğlu"}},
+		{"spaces around the operator", "role = admin", &repositories.FilterExpr{Field: "role", Op: repositories.FilterEq, Value: "admin"}},
+		{"version greater than", "version>3", &repositories.FilterExpr{Field: "version", Op: repositories.FilterGt, Value: int64(3)}},
+		{"version at most", "version<=3", &repositories.FilterExpr{Field: "version", Op: repositories.FilterLte, Value: int64(3)}},
+		{"date", "joinDate>=2024-01-01", &repositories.FilterExpr{Field: "joinDate", Op: repositories.FilterGte, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{"time", "joinDate<2024-01-01T12:00:00Z", &repositories.FilterExpr{Field: "joinDate", Op: repositories.FilterLt, Value: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}},
+		{"metadata key", "metadata.plan=pro", &repositories.FilterExpr{Field: "metadata.plan", Op: repositories.FilterEq, Value: "pro"}},
+		{"quoted value", `firstName="Ada Augusta"`, &repositories.FilterExpr{Field: "firstName", Op: repositories.FilterEq, Value: "Ada Augusta"}},
+		{"quoted keyword", `lastName="AND"`, &repositories.FilterExpr{Field: "lastName", Op: repositories.FilterEq, Value: "AND"}},
+		{"escaped quote and backslash", `lastName="O\"Brien \\ Jr"`, &repositories.FilterExpr{Field: "lastName", Op: repositories.FilterEq, Value: `O"Brien \ Jr`}},
+		{"empty quoted value", `lastName=""`, &repositories.FilterExpr{Field: "lastName", Op: repositories.FilterEq, Value: ""}},
+		{"AND", "role=admin AND version>1", &repositories.FilterExpr{And: []repositories.FilterExpr{
+			comparison("role", repositories.FilterEq, "admin"),
+			comparison("version", repositories.FilterGt, int64(1)),
+		}}},
+		{"keywords in any case", "role=admin and version>1 Or role=user", &repositories.FilterExpr{Or: []repositories.FilterExpr{
+			{And: []repositories.FilterExpr{
+				comparison("role", repositories.FilterEq, "admin"),
+				comparison("version", repositories.FilterGt, int64(1)),
+			}},
+			comparison("role", repositories.FilterEq, "user"),
+		}}},
+		{"AND binds tighter than OR", "role=user OR role=admin AND version=1", &repositories.FilterExpr{Or: []repositories.FilterExpr{
+			comparison("role", repositories.FilterEq, "user"),
+			{And: []repositories.FilterExpr{
+				comparison("role", repositories.FilterEq, "admin"),
+				comparison("version", repositories.FilterEq, int64(1)),
+			}},
+		}}},
+		{"parentheses", "(role=user OR role=admin) AND version=1", &repositories.FilterExpr{And: []repositories.FilterExpr{
+			{Or: []repositories.FilterExpr{
+				comparison("role", repositories.FilterEq, "user"),
+				comparison("role", repositories.FilterEq, "admin"),
+			}},
+			comparison("version", repositories.FilterEq, int64(1)),
+		}}},
+		{"nested AND is flattened", "role=user AND (version=1 AND lastName~a)", &repositories.FilterExpr{And: []repositories.FilterExpr{
+			comparison("role", repositories.FilterEq, "user"),
+			comparison("version", repositories.FilterEq, int64(1)),
+			comparison("lastName", repositories.FilterContains, "a"),
+		}}},
+		{"redundant parentheses", "((role=admin))", &repositories.FilterExpr{Field: "role", Op: repositories.FilterEq, Value: "admin"}},
+		{"word starting with a keyword", "lastName=ANDERSON", &repositories.FilterExpr{Field: "lastName", Op: repositories.FilterEq, Value: "ANDERSON"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFilter(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + "role=admin" + strings.Repeat(")", depth)
+	}
+	comparisons := func(n int) string {
+		return strings.TrimSuffix(strings.Repeat("version=1 OR ", n), " OR ")
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		message    string
+	}{
+		{"unknown field", "password=x", `Invalid filter at character 1: cannot filter by "password"; filterable fields are email, firstName, lastName, role, joinDate, version, metadata.{key}`},
+		{"invalid metadata key", "metadata.a.b=x", `Invalid filter at character 1: cannot filter by "metadata.a.b"`},
+		{"missing field", "=admin", "Invalid filter at character 1: expected a field"},
+		{"missing operator", "role admin", "Invalid filter at character 6: expected an operator after role"},
+		{"operator the field does not allow", "version~1", "Invalid filter at character 8: version cannot be compared with ~"},
+		{"ordering strings", "email>a", "Invalid filter at character 6: email cannot be compared with >"},
+		{"missing value", "role=", "Invalid filter at character 6: expected a value"},
+		{"invalid version", "version=one", `Invalid filter at character 9: invalid version value "one"`},
+		{"invalid date", "joinDate>yesterday", `Invalid filter at character 10: invalid joinDate value "yesterday"`},
+		{"unterminated string", `lastName="Ada`, "Invalid filter at character 10: unterminated string"},
+		{"unknown escape", `lastName="a\nb"`, `Invalid filter at character 12: only \" and \\ can be escaped`},
+		{"position counts characters", `lastName~oğlu AND x=1`, `Invalid filter at character 19: cannot filter by "x"`},
+		{"missing closing parenthesis", "(role=admin", "Invalid filter at character 12: expected )"},
+		{"stray closing parenthesis", "role=admin)", "Invalid filter at character 11: expected AND or OR"},
+		{"missing keyword", "role=admin version=1", "Invalid filter at character 12: expected AND or OR"},
+		{"dangling AND", "role=admin AND", "Invalid filter at character 15: expected a field"},
+		{"too deep", nested(maxFilterDepth + 1), "Invalid filter at character 6: parentheses nest more than 5 deep"},
+		{"too many comparisons", comparisons(maxFilterComparisons + 1), "Invalid filter at character 270: filters hold at most 20 comparisons"},
+		{"too long", "lastName=" + strings.Repeat("a", maxFilterLength), "Filter must not exceed 1000 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseFilter(tt.expression)
+			if err == nil {
+				t.Fatalf("got %+v, want an error", expr)
+			}
+			if !errors.Is(err, apperrors.ErrValidation) {
+				t.Fatalf("got error %v, want a validation error", err)
+			}
+			if !strings.HasPrefix(err.Error(), tt.message) {
+				t.Fatalf("got error %q, want %q", err.Error(), tt.message)
+			}
+		})
+	}
+
+	// The limits themselves are allowed
+	for _, expression := range []string{nested(maxFilterDepth), comparisons(maxFilterComparisons)} {
+		if _, err := parseFilter(expression); err != nil {
+			t.Fatalf("got error %v for %q, which is within the limits", err, expression)
+		}
+	}
+}
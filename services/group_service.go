@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GroupService struct {
+	repo  repositories.GroupStore
+	users repositories.UserStore
+}
+
+func NewGroupService(repo repositories.GroupStore, users repositories.UserStore) *GroupService {
+	return &GroupService{
+		repo:  repo,
+		users: users,
+	}
+}
+
+// CreateGroup validates and stores a new group. It starts without members.
+func (s *GroupService) CreateGroup(ctx context.Context, group *models.Group) (*models.Group, error) {
+	if err := prepareGroup(group); err != nil {
+		return nil, err
+	}
+	group.Id = primitive.NewObjectID()
+	group.CreatedAt = time.Now()
+
+	if err := s.repo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetGroup returns the group with the given hex ID.
+func (s *GroupService) GetGroup(ctx context.Context, id string) (*models.Group, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, objectID)
+}
+
+// UpdateGroup replaces the name, description, and permissions of the group with the given hex
+// ID. Its members hold the new permissions from their next request.
+func (s *GroupService) UpdateGroup(ctx context.Context, id string, update *models.Group) (*models.Group, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareGroup(update); err != nil {
+		return nil, err
+	}
+
+	group, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	group.Name = update.Name
+	group.Description = update.Description
+	group.Permissions = update.Permissions
+	if err := s.repo.Replace(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup removes the group with the given hex ID and its members. The users themselves
+// are kept.
+func (s *GroupService) DeleteGroup(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, objectID)
+}
+
+// ListGroups returns the requested page of groups and the total count.
+func (s *GroupService) ListGroups(ctx context.Context, page, limit int) ([]models.Group, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	return s.repo.List(ctx, int64(page-1)*int64(limit), int64(limit))
+}
+
+// AddMembers adds existing users to the group with the given hex ID in one write and reports
+// how many were added; users already in the group are skipped. Erased users cannot be added.
+func (s *GroupService) AddMembers(ctx context.Context, groupID string, addition *models.GroupMembersAddition) (*models.GroupMembersAdded, error) {
+	groupObjectID, err := parseID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(addition); err != nil {
+		return nil, err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(addition.UserIDs))
+	for _, id := range addition.UserIDs {
+		objectID, err := parseID(id)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(userIDs, objectID) {
+			userIDs = append(userIDs, objectID)
+		}
+	}
+	if _, err := s.repo.GetByID(ctx, groupObjectID); err != nil {
+		return nil, err
+	}
+
+	addedAt := time.Now()
+	members := make([]models.GroupMember, len(userIDs))
+	for i, userID := range userIDs {
+		user, err := s.users.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.ErasedAt != nil {
+			return nil, ErrUserErased
+		}
+		members[i] = models.GroupMember{GroupID: groupObjectID, UserID: userID, AddedAt: addedAt}
+	}
+	added, err := s.repo.AddMembers(ctx, members)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GroupMembersAdded{Added: added}, nil
+}
+
+// RemoveMember takes the user out of the group.
+func (s *GroupService) RemoveMember(ctx context.Context, groupID, userID string) error {
+	groupObjectID, err := parseID(groupID)
+	if err != nil {
+		return err
+	}
+	userObjectID, err := parseID(userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveMember(ctx, groupObjectID, userObjectID)
+}
+
+// ListMembers returns the requested page of members of the group with the given hex ID,
+// ordered by user ID, and the total count.
+func (s *GroupService) ListMembers(ctx context.Context, groupID string, page, limit int) ([]models.GroupMember, int64, error) {
+	objectID, err := parseID(groupID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if _, err := s.repo.GetByID(ctx, objectID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListMembers(ctx, objectID, int64(page-1)*int64(limit), int64(limit))
+}
+
+// ListUserGroups returns the requested page of groups the user with the given hex ID is in,
+// and the total count.
+func (s *GroupService) ListUserGroups(ctx context.Context, userID string, page, limit int) ([]models.Group, int64, error) {
+	objectID, err := parseID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if _, err := s.users.GetByID(ctx, objectID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListUserGroups(ctx, objectID, int64(page-1)*int64(limit), int64(limit))
+}
+
+// prepareGroup validates the client-supplied fields and removes duplicate permissions.
+func prepareGroup(group *models.Group) error {
+	if err := validation.Struct(group); err != nil {
+		return err
+	}
+	if group.Permissions == nil {
+		group.Permissions = []string{}
+	}
+	slices.Sort(group.Permissions)
+	group.Permissions = slices.Compact(group.Permissions)
+	return nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	"example_api/jobs"
+	models "example_api/models"
+	"example_api/repositories"
+	"slices"
+	"strings"
+)
+
+// jobStatuses lists the statuses jobs can have.
+var jobStatuses = []string{models.JobPending, models.JobRunning, models.JobSucceeded, models.JobDead}
+
+// ErrInvalidJobStatus is returned when a job query names an unknown status.
+var ErrInvalidJobStatus = apperrors.Validation("Status must be one of: " + strings.Join(jobStatuses, ", "))
+
+type JobService struct {
+	repo  repositories.JobStore
+	queue *jobs.Queue
+}
+
+func NewJobService(repo repositories.JobStore, queue *jobs.Queue) *JobService {
+	return &JobService{
+		repo:  repo,
+		queue: queue,
+	}
+}
+
+// ListJobs returns the requested page of jobs matching filter, newest first, and the total
+// count. Listing dead jobs shows the dead letter queue.
+func (s *JobService) ListJobs(ctx context.Context, filter repositories.JobFilter, page, limit int) ([]models.Job, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if filter.Status != "" && !slices.Contains(jobStatuses, filter.Status) {
+		return nil, 0, ErrInvalidJobStatus
+	}
+	return s.repo.List(ctx, filter, int64(page-1)*int64(limit), int64(limit))
+}
+
+// GetJob returns the job with the given hex ID.
+func (s *JobService) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, objectID)
+}
+
+// RetryJob moves the dead job with the given hex ID back into the queue with its attempts reset.
+func (s *JobService) RetryJob(ctx context.Context, id string) (*models.Job, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.queue.Retry(ctx, objectID)
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"encoding/json"
+	"example_api/apperrors"
+	models "example_api/models"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// metadataKey matches the keys metadata may hold, which are also safe to name in filters.
+var metadataKey = regexp.MustCompile(fmt.Sprintf(`^[A-Za-z0-9_-]{1,%d}$`, models.MaxMetadataKeyLength))
+
+// validateMetadata checks metadata against the limits in models, reporting each offending key
+// by its dotted path.
+func validateMetadata(metadata models.Metadata) error {
+	if metadata == nil {
+		return nil
+	}
+	var invalid []apperrors.FieldError
+	keys := checkMetadata("metadata", metadata, 1, &invalid)
+	if keys > models.MaxMetadataKeys {
+		invalid = append(invalid, apperrors.FieldError{Field: "metadata", Rule: "max", Message: fmt.Sprintf("metadata must have at most %d keys", models.MaxMetadataKeys)})
+	}
+	if len(invalid) == 0 {
+		if data, err := json.Marshal(metadata); err != nil || len(data) > models.MaxMetadataSize {
+			invalid = append(invalid, apperrors.FieldError{Field: "metadata", Rule: "size", Message: fmt.Sprintf("metadata must not exceed %d bytes", models.MaxMetadataSize)})
+		}
+	}
+	if len(invalid) > 0 {
+		return apperrors.InvalidFields("Invalid input", invalid)
+	}
+	return nil
+}
+
+// checkMetadata appends the problems with metadata, found at path and depth, to invalid and
+// returns how many keys it holds at every level.
+func checkMetadata(path string, metadata map[string]interface{}, depth int, invalid *[]apperrors.FieldError) int {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	count := len(keys)
+	for _, key := range keys {
+		field := path + "." + key
+		switch {
+		case !metadataKey.MatchString(key):
+			*invalid = append(*invalid, apperrors.FieldError{Field: field, Rule: "key", Message: fmt.Sprintf("%s must be a key of at most %d letters, digits, _, and -", field, models.MaxMetadataKeyLength)})
+			continue
+		case strings.HasPrefix(key, "_"):
+			*invalid = append(*invalid, apperrors.FieldError{Field: field, Rule: "reserved", Message: fmt.Sprintf("%s is reserved, as are all keys beginning with _", field)})
+			continue
+		}
+
+		switch value := metadata[key].(type) {
+		case string:
+			if utf8.RuneCountInString(value) > models.MaxMetadataValueLength {
+				*invalid = append(*invalid, apperrors.FieldError{Field: field, Rule: "max", Message: fmt.Sprintf("%s must be at most %s characters long", field, strconv.Itoa(models.MaxMetadataValueLength))})
+			}
+		case float64, int, int32, int64, bool:
+		case models.Metadata, map[string]interface{}:
+			if depth == models.MaxMetadataDepth {
+				*invalid = append(*invalid, apperrors.FieldError{Field: field, Rule: "depth", Message: fmt.Sprintf("%s nests metadata more than %d levels deep", field, models.MaxMetadataDepth)})
+				continue
+			}
+			nested, _ := asMetadata(value)
+			count += checkMetadata(field, nested, depth+1, invalid)
+		default:
+			*invalid = append(*invalid, apperrors.FieldError{Field: field, Rule: "type", Message: fmt.Sprintf("%s must be a string, number, boolean, or object", field)})
+		}
+	}
+	return count
+}
+
+// mergeMetadata applies patch to a copy of current the way a JSON merge patch would: keys set
+// to null are removed, nested objects are merged, and other values replace what was there. A
+// null patch removes every key. The result is nil once no keys are left.
+func mergeMetadata(current models.Metadata, patch interface{}) (models.Metadata, error) {
+	if patch == nil {
+		return nil, nil
+	}
+	changes, ok := asMetadata(patch)
+	if !ok {
+		return nil, apperrors.InvalidFields("Invalid input", []apperrors.FieldError{{Field: "metadata", Rule: "type", Message: "metadata must be an object"}})
+	}
+	merged := mergeMetadataLevel(current, changes)
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return merged, nil
+}
+
+func mergeMetadataLevel(current, changes models.Metadata) models.Metadata {
+	merged := make(models.Metadata, len(current)+len(changes))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		if nested, ok := asMetadata(value); ok {
+			existing, _ := asMetadata(merged[key])
+			merged[key] = mergeMetadataLevel(existing, nested)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// asMetadata returns value as Metadata if it is an object, whether read from a store or decoded
+// from a request.
+func asMetadata(value interface{}) (models.Metadata, bool) {
+	switch m := value.(type) {
+	case models.Metadata:
+		return m, true
+	case map[string]interface{}:
+		return models.Metadata(m), true
+	}
+	return nil, false
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// adminBatchSize is how many admins NotifyAdmins reads from the store at a time.
+const adminBatchSize = 100
+
+// Notifier creates notifications on behalf of other subsystems. A notification is never worth
+// failing the operation that caused it, so failures are logged rather than returned.
+type Notifier interface {
+	Notify(ctx context.Context, userID primitive.ObjectID, kind, message string, data map[string]string)
+}
+
+type NotificationService struct {
+	repo   repositories.NotificationStore
+	users  repositories.UserStore
+	logger *slog.Logger
+}
+
+func NewNotificationService(repo repositories.NotificationStore, users repositories.UserStore, logger *slog.Logger) *NotificationService {
+	return &NotificationService{
+		repo:   repo,
+		users:  users,
+		logger: logger,
+	}
+}
+
+var _ Notifier = (*NotificationService)(nil)
+
+// Notify creates an unread notification of the given type for the user, logging failures.
+func (s *NotificationService) Notify(ctx context.Context, userID primitive.ObjectID, kind, message string, data map[string]string) {
+	if err := s.repo.Create(ctx, newNotification(userID, kind, message, data)); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create notification",
+			slog.String("user", userID.Hex()), slog.String("type", kind), slog.Any("error", err))
+	}
+}
+
+// NotifyAdmins notifies every user with the admin role in the tenant of ctx, logging failures.
+// Erased users are skipped.
+func (s *NotificationService) NotifyAdmins(ctx context.Context, kind, message string, data map[string]string) {
+	opts := repositories.ListOptions{Filter: repositories.UserFilter{Role: models.RoleAdmin}, Limit: adminBatchSize}
+	for {
+		admins, _, err := s.users.List(ctx, opts)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to list admins to notify", slog.String("type", kind), slog.Any("error", err))
+			return
+		}
+		for _, admin := range admins {
+			if admin.ErasedAt == nil {
+				s.Notify(ctx, admin.Id, kind, message, data)
+			}
+		}
+		if len(admins) < adminBatchSize {
+			return
+		}
+		opts.AfterID = admins[len(admins)-1].Id
+	}
+}
+
+// SendNotice validates a notice and delivers it to the user with the given hex ID. Erased users
+// cannot be sent notices.
+func (s *NotificationService) SendNotice(ctx context.Context, userID string, notice *models.Notice) (*models.Notification, error) {
+	objectID, err := parseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(notice); err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+
+	notification := newNotification(objectID, models.NotificationNotice, notice.Message, notice.Data)
+	if err := s.repo.Create(ctx, notification); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// ListNotifications returns the requested page of the user's notifications, newest first, and
+// their total count. unreadOnly leaves out those already read.
+func (s *NotificationService) ListNotifications(ctx context.Context, userID string, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	objectID, err := parseID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if _, err := s.users.GetByID(ctx, objectID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(ctx, objectID, unreadOnly, int64(page-1)*int64(limit), int64(limit))
+}
+
+// MarkRead marks one of the user's notifications read. Marking it again keeps the first read
+// time.
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id string) (*models.Notification, error) {
+	userObjectID, err := parseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.MarkRead(ctx, userObjectID, objectID, time.Now())
+}
+
+// MarkAllRead marks every unread notification of the user read and reports how many there were.
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID string) (*models.NotificationsRead, error) {
+	objectID, err := parseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.users.GetByID(ctx, objectID); err != nil {
+		return nil, err
+	}
+	read, err := s.repo.MarkAllRead(ctx, objectID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationsRead{Read: read}, nil
+}
+
+// DeleteNotification removes one of the user's notifications.
+func (s *NotificationService) DeleteNotification(ctx context.Context, userID, id string) error {
+	userObjectID, err := parseID(userID)
+	if err != nil {
+		return err
+	}
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userObjectID, objectID)
+}
+
+func newNotification(userID primitive.ObjectID, kind, message string, data map[string]string) *models.Notification {
+	return &models.Notification{
+		Id:        primitive.NewObjectID(),
+		UserID:    userID,
+		Type:      kind,
+		Message:   message,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+}
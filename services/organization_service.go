@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrganizationService struct {
+	repo     repositories.OrganizationStore
+	users    repositories.UserStore
+	notifier Notifier
+}
+
+func NewOrganizationService(repo repositories.OrganizationStore, users repositories.UserStore, notifier Notifier) *OrganizationService {
+	return &OrganizationService{
+		repo:     repo,
+		users:    users,
+		notifier: notifier,
+	}
+}
+
+// CreateOrganization validates and stores a new organization. It starts without members.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	if err := validation.Struct(org); err != nil {
+		return nil, err
+	}
+	org.Id = primitive.NewObjectID()
+	org.CreatedAt = time.Now()
+
+	if err := s.repo.Create(ctx, org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrganization returns the organization with the given hex ID.
+func (s *OrganizationService) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, objectID)
+}
+
+// UpdateOrganization renames the organization with the given hex ID.
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id string, update *models.Organization) (*models.Organization, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(update); err != nil {
+		return nil, err
+	}
+
+	org, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	org.Name = update.Name
+	if err := s.repo.Replace(ctx, org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// DeleteOrganization removes the organization with the given hex ID and its memberships. The
+// users themselves are kept.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, objectID)
+}
+
+// ListOrganizations returns the requested page of organizations and the total count.
+func (s *OrganizationService) ListOrganizations(ctx context.Context, page, limit int) ([]models.Organization, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	return s.repo.List(ctx, int64(page-1)*int64(limit), int64(limit))
+}
+
+// InviteMember invites an existing user to the organization with the given hex ID, with the
+// role the invitation names or as a member, and notifies them. The membership is pending until
+// the user accepts.
+func (s *OrganizationService) InviteMember(ctx context.Context, orgID string, invitation *models.Invitation) (*models.Membership, error) {
+	orgObjectID, err := parseID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(invitation); err != nil {
+		return nil, err
+	}
+	userObjectID, err := parseID(invitation.UserID)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.repo.GetByID(ctx, orgObjectID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, userObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+
+	role := invitation.Role
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+	membership := &models.Membership{
+		Id:             primitive.NewObjectID(),
+		OrganizationID: orgObjectID,
+		UserID:         userObjectID,
+		Role:           role,
+		Status:         models.MembershipInvited,
+		InvitedAt:      time.Now(),
+	}
+	if err := s.repo.AddMembership(ctx, membership); err != nil {
+		return nil, err
+	}
+	s.notifier.Notify(ctx, userObjectID, models.NotificationOrganizationInvitation,
+		fmt.Sprintf("You have been invited to join %s", org.Name),
+		map[string]string{"organizationId": org.Id.Hex(), "role": role})
+	return membership, nil
+}
+
+// AcceptInvitation makes the invited user a member of the organization. Accepting again
+// returns the membership unchanged.
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, orgID, userID string) (*models.Membership, error) {
+	membership, err := s.membership(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership.Status == models.MembershipActive {
+		return membership, nil
+	}
+	joinedAt := time.Now()
+	membership.Status = models.MembershipActive
+	membership.JoinedAt = &joinedAt
+	if err := s.repo.ReplaceMembership(ctx, membership); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// UpdateMember assigns the user a new role in the organization. Pending invitations keep
+// their status and take the new role once accepted.
+func (s *OrganizationService) UpdateMember(ctx context.Context, orgID, userID string, update *models.MembershipUpdate) (*models.Membership, error) {
+	if err := validation.Struct(update); err != nil {
+		return nil, err
+	}
+	membership, err := s.membership(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	membership.Role = update.Role
+	if err := s.repo.ReplaceMembership(ctx, membership); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// RemoveMember removes the user from the organization, or withdraws their invitation.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID, userID string) error {
+	orgObjectID, err := parseID(orgID)
+	if err != nil {
+		return err
+	}
+	userObjectID, err := parseID(userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveMembership(ctx, orgObjectID, userObjectID)
+}
+
+// ListMembers returns the requested page of memberships of the organization with the given hex
+// ID, invitations included, oldest first, and the total count.
+func (s *OrganizationService) ListMembers(ctx context.Context, orgID string, page, limit int) ([]models.Membership, int64, error) {
+	objectID, err := parseID(orgID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if _, err := s.repo.GetByID(ctx, objectID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListMembers(ctx, objectID, int64(page-1)*int64(limit), int64(limit))
+}
+
+// ListUserMemberships returns the requested page of memberships of the user with the given hex
+// ID, invitations included, oldest first, and the total count.
+func (s *OrganizationService) ListUserMemberships(ctx context.Context, userID string, page, limit int) ([]models.Membership, int64, error) {
+	objectID, err := parseID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if _, err := s.users.GetByID(ctx, objectID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListUserMemberships(ctx, objectID, int64(page-1)*int64(limit), int64(limit))
+}
+
+// membership returns the membership of the user with hex ID userID in the organization with
+// hex ID orgID.
+func (s *OrganizationService) membership(ctx context.Context, orgID, userID string) (*models.Membership, error) {
+	orgObjectID, err := parseID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	userObjectID, err := parseID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembership(ctx, orgObjectID, userObjectID)
+}
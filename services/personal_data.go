@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUserErased is returned when changing a user whose personal data has been erased.
+var ErrUserErased = apperrors.Conflict("User has been erased")
+
+// personalDataBatchSize is how many records ExportUserData reads from a store at a time.
+const personalDataBatchSize = 500
+
+// storedAvatarVariants lists every avatar variant a user may have stored.
+var storedAvatarVariants = []string{models.AvatarOriginal, models.AvatarMedium, models.AvatarThumbnail}
+
+// ExportUserData gathers everything stored about the user with the given hex ID, for a data
+// subject access request. The password hash is left out: it is a credential, and of no use to
+// anyone but an attacker.
+func (s *UserService) ExportUserData(ctx context.Context, id string) (*models.UserDataExport, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	profile := *user
+	profile.Password = ""
+	export := &models.UserDataExport{GeneratedAt: time.Now().UTC(), User: &profile}
+
+	for _, variant := range storedAvatarVariants {
+		avatar, data, err := s.avatars.Get(ctx, objectID, variant)
+		if errors.Is(err, repositories.ErrAvatarNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		image, err := io.ReadAll(data)
+		data.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read avatar: %w", err)
+		}
+		export.Avatars = append(export.Avatars, models.AvatarFile{Avatar: *avatar, Data: image})
+	}
+
+	filter := repositories.AuditFilter{TargetID: objectID}
+	if export.AuditLog, err = exportAll(func(skip, limit int64) ([]models.AuditEntry, int64, error) {
+		return s.audits.List(ctx, filter, skip, limit)
+	}); err != nil {
+		return nil, err
+	}
+	if export.Notifications, err = exportAll(func(skip, limit int64) ([]models.Notification, int64, error) {
+		return s.notifications.List(ctx, objectID, false, skip, limit)
+	}); err != nil {
+		return nil, err
+	}
+	if export.Memberships, err = exportAll(func(skip, limit int64) ([]models.Membership, int64, error) {
+		return s.memberships.ListUserMemberships(ctx, objectID, skip, limit)
+	}); err != nil {
+		return nil, err
+	}
+	if export.Groups, err = exportAll(func(skip, limit int64) ([]models.Group, int64, error) {
+		return s.groups.ListUserGroups(ctx, objectID, skip, limit)
+	}); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// exportAll reads every page of list, personalDataBatchSize records at a time.
+func exportAll[T any](list func(skip, limit int64) ([]T, int64, error)) ([]T, error) {
+	all := []T{}
+	for {
+		page, _, err := list(int64(len(all)), personalDataBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < personalDataBatchSize {
+			return all, nil
+		}
+	}
+}
+
+// EraseUser irreversibly anonymizes the user with the given hex ID, deletes their avatar,
+// memberships, notifications, password history, and phone verification code, and takes them
+// out of their groups, for a right to erasure request. The record stays behind as a tombstone,
+// so references to the ID still resolve, but its email is replaced and its names and password
+// hash are cleared. Erasing a user again only repeats the deletion of related data, so failed
+// erasures can be retried.
+func (s *UserService) EraseUser(ctx context.Context, id string) (*models.User, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt == nil {
+		err := s.repo.Update(ctx, objectID, repositories.AnyVersion, map[string]interface{}{
+			"email":           erasedEmail(objectID),
+			"firstName":       "",
+			"lastName":        "",
+			"password":        "",
+			"phone":           "",
+			"addresses":       nil,
+			"preferences":     nil,
+			"metadata":        nil,
+			"erasedAt":        time.Now().UTC(),
+			"phoneVerifiedAt": nil,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.avatars.Delete(ctx, objectID); err != nil {
+		return nil, err
+	}
+	if err := s.memberships.DeleteUserMemberships(ctx, objectID); err != nil {
+		return nil, err
+	}
+	if err := s.groups.DeleteUserMembers(ctx, objectID); err != nil {
+		return nil, err
+	}
+	if err := s.notifications.DeleteUserNotifications(ctx, objectID); err != nil {
+		return nil, err
+	}
+	if err := s.passwords.Delete(ctx, objectID); err != nil {
+		return nil, err
+	}
+	if err := s.phoneCodes.Delete(ctx, objectID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, objectID)
+}
+
+// erasedEmail returns the address an erased user's email is replaced with. It is unique, so
+// the unique index on emails still holds, and on the reserved .invalid domain, so nothing is
+// ever sent to it.
+func erasedEmail(id primitive.ObjectID) string {
+	return "erased-" + id.Hex() + "@erased.invalid"
+}
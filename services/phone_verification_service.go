@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"example_api/apperrors"
+	"example_api/i18n"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/sms"
+	"example_api/validation"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Limits of phone verification: a code is valid for PhoneCodeTTL, a new one may be requested
+// every PhoneCodeInterval, and PhoneCodeAttempts wrong guesses void it.
+const (
+	PhoneCodeTTL      = 10 * time.Minute
+	PhoneCodeInterval = time.Minute
+	PhoneCodeAttempts = 5
+)
+
+var (
+	// ErrNoPhone is returned when verifying the phone number of a user who has none.
+	ErrNoPhone = apperrors.Conflict("User has no phone number")
+	// ErrPhoneVerified is returned when verifying a phone number that is already verified.
+	ErrPhoneVerified = apperrors.Conflict("Phone number is already verified")
+	// ErrPhoneCodeTooSoon is returned when a new code is requested within PhoneCodeInterval of
+	// the last one.
+	ErrPhoneCodeTooSoon = apperrors.TooManyRequests("A verification code was sent less than a minute ago")
+	// ErrPhoneUndeliverable is returned when the SMS provider refuses to send to the number.
+	ErrPhoneUndeliverable = apperrors.Validation("Phone number cannot receive text messages")
+	// ErrInvalidPhoneCode is returned for a wrong, expired, or voided code, or one sent to a
+	// number the user has since changed.
+	ErrInvalidPhoneCode = apperrors.Validation("Invalid or expired verification code")
+)
+
+// PhoneVerificationService verifies phone numbers by texting users a code to confirm.
+type PhoneVerificationService struct {
+	users  repositories.UserStore
+	codes  repositories.PhoneCodeStore
+	sender sms.Sender
+}
+
+func NewPhoneVerificationService(users repositories.UserStore, codes repositories.PhoneCodeStore, sender sms.Sender) *PhoneVerificationService {
+	return &PhoneVerificationService{
+		users:  users,
+		codes:  codes,
+		sender: sender,
+	}
+}
+
+// SendCode texts a new code to the phone number of the user with the given hex ID, replacing
+// any code sent before, in the language of ctx. A code that could not be sent is dropped, so
+// it can be requested again right away.
+func (s *PhoneVerificationService) SendCode(ctx context.Context, id string) (*models.PhoneVerification, error) {
+	user, err := s.unverifiedUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.codes.Get(ctx, user.Id)
+	if err != nil && !errors.Is(err, repositories.ErrPhoneCodeNotFound) {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if previous != nil && now.Before(previous.SentAt.Add(PhoneCodeInterval)) {
+		return nil, ErrPhoneCodeTooSoon
+	}
+
+	code, err := newPhoneCode()
+	if err != nil {
+		return nil, apperrors.Internal("Failed to send verification code", err)
+	}
+	record := &repositories.PhoneCode{
+		UserID:    user.Id,
+		Phone:     user.Phone,
+		CodeHash:  hashPhoneCode(user.Id.Hex(), code),
+		SentAt:    now,
+		ExpiresAt: now.Add(PhoneCodeTTL),
+	}
+	if err := s.codes.Put(ctx, record); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(PhoneCodeTTL/time.Minute))
+	if sendErr := s.sender.Send(ctx, user.Phone, i18n.Translate(i18n.FromContext(ctx), message)); sendErr != nil {
+		if err := s.codes.Delete(context.WithoutCancel(ctx), user.Id); err != nil {
+			return nil, err
+		}
+		if errors.Is(sendErr, sms.ErrUndeliverable) {
+			return nil, ErrPhoneUndeliverable
+		}
+		return nil, apperrors.Internal("Failed to send verification code", sendErr)
+	}
+
+	resendAt := now.Add(PhoneCodeInterval)
+	return &models.PhoneVerification{Phone: user.Phone, ExpiresAt: &record.ExpiresAt, ResendAt: &resendAt}, nil
+}
+
+// ConfirmCode verifies the phone number of the user with the given hex ID if confirmation
+// holds the code last sent to it. Wrong guesses count against the code, which is voided after
+// PhoneCodeAttempts of them.
+func (s *PhoneVerificationService) ConfirmCode(ctx context.Context, id string, confirmation *models.PhoneCodeConfirmation) (*models.PhoneVerification, error) {
+	if err := validation.Struct(confirmation); err != nil {
+		return nil, err
+	}
+	user, err := s.unverifiedUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.codes.Get(ctx, user.Id)
+	if errors.Is(err, repositories.ErrPhoneCodeNotFound) {
+		return nil, ErrInvalidPhoneCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.Phone != user.Phone || record.Attempts >= PhoneCodeAttempts {
+		return nil, ErrInvalidPhoneCode
+	}
+
+	hash := hashPhoneCode(user.Id.Hex(), confirmation.Code)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(record.CodeHash)) != 1 {
+		attempts, err := s.codes.AddAttempt(ctx, user.Id)
+		if err != nil && !errors.Is(err, repositories.ErrPhoneCodeNotFound) {
+			return nil, err
+		}
+		if attempts >= PhoneCodeAttempts {
+			if err := s.codes.Delete(ctx, user.Id); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidPhoneCode
+	}
+
+	// Updating at the version read fails if the number changed in the meantime
+	verifiedAt := time.Now().UTC()
+	if err := s.users.Update(ctx, user.Id, user.Version, map[string]interface{}{"phoneVerifiedAt": verifiedAt}); err != nil {
+		return nil, err
+	}
+	if err := s.codes.Delete(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	return &models.PhoneVerification{Phone: user.Phone, VerifiedAt: &verifiedAt}, nil
+}
+
+// unverifiedUser returns the user with the given hex ID if they have a phone number that is
+// not verified yet.
+func (s *PhoneVerificationService) unverifiedUser(ctx context.Context, id string) (*models.User, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case user.ErasedAt != nil:
+		return nil, ErrUserErased
+	case user.Phone == "":
+		return nil, ErrNoPhone
+	case user.PhoneVerifiedAt != nil:
+		return nil, ErrPhoneVerified
+	}
+	return user, nil
+}
+
+// newPhoneCode returns a random six-digit code.
+func newPhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashPhoneCode returns the hex SHA-256 of code salted with the user's ID, which is what the
+// store keeps instead of the code.
+func hashPhoneCode(userID, code string) string {
+	sum := sha256.Sum256([]byte(userID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
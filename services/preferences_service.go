@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+)
+
+// PreferencesService manages the application settings of users.
+type PreferencesService struct {
+	users repositories.UserStore
+}
+
+func NewPreferencesService(users repositories.UserStore) *PreferencesService {
+	return &PreferencesService{
+		users: users,
+	}
+}
+
+// GetPreferences returns the preferences of the user with the given hex ID, which are the
+// defaults until they set any.
+func (s *PreferencesService) GetPreferences(ctx context.Context, id string) (*models.Preferences, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Preferences == nil {
+		return &models.Preferences{}, nil
+	}
+	return user.Preferences, nil
+}
+
+// UpdatePreferences validates preferences and replaces those of the user with the given hex ID
+// with them.
+func (s *PreferencesService) UpdatePreferences(ctx context.Context, id string, preferences *models.Preferences) (*models.Preferences, error) {
+	if err := validation.Struct(preferences); err != nil {
+		return nil, err
+	}
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+
+	if err := s.users.Update(ctx, user.Id, user.Version, map[string]interface{}{"preferences": *preferences}); err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrBuiltInRole is returned when changing or deleting a built-in role.
+	ErrBuiltInRole = apperrors.Conflict("Built-in roles cannot be changed")
+	// ErrInvalidRoleName is returned for role names that are not lowercase words.
+	ErrInvalidRoleName = apperrors.InvalidFields("Invalid input", []apperrors.FieldError{{
+		Field:   "name",
+		Rule:    "rolename",
+		Message: "name must start with a lowercase letter followed by lowercase letters, digits, hyphens, or underscores",
+	}})
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// builtInRoles are the roles every tenant has, ordered by name. Users get models.RoleUser when
+// they sign up.
+var builtInRoles = []models.Role{
+	{Name: models.RoleAdmin, Description: "Full access", Permissions: models.Permissions, BuiltIn: true},
+	{Name: models.RoleUser, Description: "Regular account", Permissions: []string{models.PermUsersRead, models.PermOrganizationsRead}, BuiltIn: true},
+}
+
+func builtInRole(name string) (*models.Role, bool) {
+	for _, role := range builtInRoles {
+		if role.Name == name {
+			role.Permissions = slices.Clone(role.Permissions)
+			return &role, true
+		}
+	}
+	return nil, false
+}
+
+// lookupRole returns the built-in or stored role with the given name.
+func lookupRole(ctx context.Context, roles repositories.RoleStore, name string) (*models.Role, error) {
+	if role, ok := builtInRole(name); ok {
+		return role, nil
+	}
+	return roles.GetByName(ctx, name)
+}
+
+type RoleService struct {
+	repo  repositories.RoleStore
+	users repositories.UserStore
+}
+
+func NewRoleService(repo repositories.RoleStore, users repositories.UserStore) *RoleService {
+	return &RoleService{
+		repo:  repo,
+		users: users,
+	}
+}
+
+// CreateRole validates and stores a new role. Its name cannot be changed later, since users
+// refer to it.
+func (s *RoleService) CreateRole(ctx context.Context, role *models.Role) (*models.Role, error) {
+	if err := prepareRole(role); err != nil {
+		return nil, err
+	}
+	if !roleNamePattern.MatchString(role.Name) {
+		return nil, ErrInvalidRoleName
+	}
+	if _, ok := builtInRole(role.Name); ok {
+		return nil, repositories.ErrRoleExists
+	}
+	role.Id = primitive.NewObjectID()
+	role.BuiltIn = false
+	role.CreatedAt = time.Now()
+
+	if err := s.repo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// GetRole returns the built-in or stored role with the given name.
+func (s *RoleService) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	return lookupRole(ctx, s.repo, name)
+}
+
+// UpdateRole replaces the description and permissions of the stored role with the given name.
+// Its users hold the new permissions from their next request.
+func (s *RoleService) UpdateRole(ctx context.Context, name string, update *models.Role) (*models.Role, error) {
+	if _, ok := builtInRole(name); ok {
+		return nil, ErrBuiltInRole
+	}
+	update.Name = name
+	if err := prepareRole(update); err != nil {
+		return nil, err
+	}
+
+	role, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	role.Description = update.Description
+	role.Permissions = update.Permissions
+	if err := s.repo.Replace(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// DeleteRole removes the stored role with the given name, provided no user has it.
+func (s *RoleService) DeleteRole(ctx context.Context, name string) error {
+	if _, ok := builtInRole(name); ok {
+		return ErrBuiltInRole
+	}
+	_, assigned, err := s.users.List(ctx, repositories.ListOptions{Filter: repositories.UserFilter{Role: name}, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if assigned > 0 {
+		return apperrors.Conflict(fmt.Sprintf("Role is assigned to %d users; give them another role first", assigned))
+	}
+	return s.repo.Delete(ctx, name)
+}
+
+// ListRoles returns the requested page of roles, the built-in ones first and then the stored
+// ones by name, and the total count.
+func (s *RoleService) ListRoles(ctx context.Context, page, limit int) ([]models.Role, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	skip := int64(page-1) * int64(limit)
+	builtIn := int64(len(builtInRoles))
+
+	roles := []models.Role{}
+	for i := skip; i < builtIn && int64(len(roles)) < int64(limit); i++ {
+		role, _ := builtInRole(builtInRoles[i].Name)
+		roles = append(roles, *role)
+	}
+	// MongoDB reads a limit of 0 as no limit, so a page of built-in roles still asks for one
+	remaining := int64(limit) - int64(len(roles))
+	stored, total, err := s.repo.List(ctx, max(skip-builtIn, 0), max(remaining, 1))
+	if err != nil {
+		return nil, 0, err
+	}
+	if remaining > 0 {
+		roles = append(roles, stored...)
+	}
+	return roles, builtIn + total, nil
+}
+
+// prepareRole validates the client-supplied fields and removes duplicate permissions.
+func prepareRole(role *models.Role) error {
+	if err := validation.Struct(role); err != nil {
+		return err
+	}
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	slices.Sort(role.Permissions)
+	role.Permissions = slices.Compact(role.Permissions)
+	return nil
+}
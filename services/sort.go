@@ -0,0 +1,36 @@
+package services
+
+import (
+	"example_api/apperrors"
+	"example_api/repositories"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sortableFields lists the user fields lists and searches can be sorted by.
+var sortableFields = []string{"id", "email", "firstName", "lastName", "role", "joinDate"}
+
+// parseSort parses a comma-separated list of sortable fields, each optionally prefixed with -
+// to sort it in descending order, such as "joinDate,-lastName". Fields are applied in the
+// order given. An empty list sorts by nothing.
+func parseSort(sort string) ([]repositories.SortField, error) {
+	if sort == "" {
+		return nil, nil
+	}
+	var order []repositories.SortField
+	for _, name := range strings.Split(sort, ",") {
+		field := repositories.SortField{Field: strings.TrimSpace(name)}
+		if rest, ok := strings.CutPrefix(field.Field, "-"); ok {
+			field = repositories.SortField{Field: rest, Descending: true}
+		}
+		if !slices.Contains(sortableFields, field.Field) {
+			return nil, apperrors.Validation(fmt.Sprintf("Cannot sort by %q; sortable fields are %s", field.Field, strings.Join(sortableFields, ", ")))
+		}
+		if slices.ContainsFunc(order, func(f repositories.SortField) bool { return f.Field == field.Field }) {
+			return nil, apperrors.Validation(fmt.Sprintf("Cannot sort by %q more than once", field.Field))
+		}
+		order = append(order, field)
+	}
+	return order, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/tenant"
+	"sync"
+	"time"
+)
+
+// signupWindows are the periods UserStats counts signups over, in the order of SignupStats.
+var signupWindows = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// StatsService computes user statistics for the admin dashboard. Counting every user is
+// expensive and dashboards poll, so the result is reused for ttl.
+type StatsService struct {
+	repo repositories.UserStatsStore
+	ttl  time.Duration
+
+	// mu also makes concurrent requests wait for a single computation
+	mu sync.Mutex
+	// cached holds the statistics of each tenant
+	cached map[string]*models.UserStats
+}
+
+func NewStatsService(repo repositories.UserStatsStore, ttl time.Duration) *StatsService {
+	return &StatsService{
+		repo:   repo,
+		ttl:    ttl,
+		cached: make(map[string]*models.UserStats),
+	}
+}
+
+// UserStats returns the user statistics of the tenant of ctx, counted at most ttl ago.
+func (s *StatsService) UserStats(ctx context.Context) (*models.UserStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	tenantID := tenant.FromContext(ctx)
+	if cached := s.cached[tenantID]; cached != nil && now.Sub(cached.GeneratedAt) < s.ttl {
+		return cached, nil
+	}
+
+	since := make([]time.Time, len(signupWindows))
+	for i, window := range signupWindows {
+		since[i] = now.Add(-window)
+	}
+	counts, err := s.repo.CountUsers(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.UserStats{
+		GeneratedAt: now,
+		Total:       counts.Total,
+		Signups: models.SignupStats{
+			Last24h: counts.JoinedSince[0],
+			Last7d:  counts.JoinedSince[1],
+			Last30d: counts.JoinedSince[2],
+		},
+		ByRole: counts.ByRole,
+		Erased: counts.Erased,
+	}
+	s.cached[tenantID] = stats
+	return stats, nil
+}
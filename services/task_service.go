@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/scheduler"
+	"fmt"
+	"time"
+)
+
+// ErrTaskNotFound is returned when no scheduled task has the requested name.
+var ErrTaskNotFound = apperrors.NotFound("Task not found")
+
+type TaskService struct {
+	scheduler *scheduler.Scheduler
+	runs      repositories.TaskRunStore
+}
+
+func NewTaskService(scheduler *scheduler.Scheduler, runs repositories.TaskRunStore) *TaskService {
+	return &TaskService{
+		scheduler: scheduler,
+		runs:      runs,
+	}
+}
+
+// ListTasks returns every scheduled task with its latest run and, if it is enabled, when it is
+// next due.
+func (s *TaskService) ListTasks(ctx context.Context) ([]models.Task, error) {
+	now := time.Now()
+	tasks := []models.Task{}
+	for _, task := range s.scheduler.Tasks() {
+		listed := models.Task{
+			Name:        task.Name,
+			Description: task.Description,
+			Enabled:     task.Enabled,
+			Interval:    task.Interval.String(),
+		}
+		if task.Enabled {
+			next := scheduler.Next(task.Interval, now)
+			listed.NextRun = &next
+		}
+		runs, _, err := s.runs.List(ctx, task.Name, 0, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the latest run of %s: %w", task.Name, err)
+		}
+		if len(runs) > 0 {
+			listed.LastRun = &runs[0]
+		}
+		tasks = append(tasks, listed)
+	}
+	return tasks, nil
+}
+
+// ListRuns returns the requested page of the run history of the named task, newest first, and
+// the total count.
+func (s *TaskService) ListRuns(ctx context.Context, name string, page, limit int) ([]models.TaskRun, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if _, ok := s.scheduler.Task(name); !ok {
+		return nil, 0, ErrTaskNotFound
+	}
+	return s.runs.List(ctx, name, int64(page-1)*int64(limit), int64(limit))
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	"example_api/auth"
+	"example_api/clientip"
+	models "example_api/models"
+	"example_api/repositories"
+	"time"
+)
+
+var (
+	// ErrInvalidUsageDay is returned when a usage query names a day that is not a date.
+	ErrInvalidUsageDay = apperrors.Validation("Days must be dates such as 2024-01-31")
+	// ErrInvalidDayRange is returned when a usage query's range of days ends before it starts.
+	ErrInvalidDayRange = apperrors.Validation("The range of days must not end before it starts")
+)
+
+type UsageService struct {
+	repo repositories.UsageStore
+}
+
+func NewUsageService(repo repositories.UsageStore) *UsageService {
+	return &UsageService{
+		repo: repo,
+	}
+}
+
+// ListUsage returns the requested page of usage records matching query, newest day first, and
+// the total count.
+func (s *UsageService) ListUsage(ctx context.Context, query models.UsageQuery, page, limit int) ([]models.Usage, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	for _, day := range []string{query.From, query.To} {
+		if _, err := time.Parse(time.DateOnly, day); day != "" && err != nil {
+			return nil, 0, ErrInvalidUsageDay
+		}
+	}
+	if query.From != "" && query.To != "" && query.To < query.From {
+		return nil, 0, ErrInvalidDayRange
+	}
+	filter := repositories.UsageFilter{Client: query.Client, From: query.From, To: query.To}
+	return s.repo.List(ctx, filter, int64(page-1)*int64(limit), int64(limit))
+}
+
+// ListOwnUsage is ListUsage for the usage of the client making the request ctx belongs to,
+// whatever client query names.
+func (s *UsageService) ListOwnUsage(ctx context.Context, query models.UsageQuery, page, limit int) ([]models.Usage, int64, error) {
+	query.Client = auth.ClientKey(auth.FromContext(ctx), clientip.FromContext(ctx))
+	return s.ListUsage(ctx, query, page, limit)
+}
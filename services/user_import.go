@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/validation"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// importBatchSize is how many users ImportUsers hashes and inserts at a time.
+const importBatchSize = 100
+
+// ErrInvalidImportRole is reported for import rows with a role other than user or admin.
+var ErrInvalidImportRole = apperrors.InvalidFields("Invalid input", []apperrors.FieldError{{
+	Field:   "role",
+	Rule:    "oneof",
+	Message: "role must be one of: user, admin",
+}})
+
+// importRow is a validated user waiting to be inserted, with the line it was read from.
+type importRow struct {
+	line int
+	user *models.User
+}
+
+// ImportUsers creates every user next yields and reports the rows that could not be imported.
+// next returns each user with its line number in the file, and io.EOF after the last one. A
+// validation error from next marks that row as failed and the import carries on; any other
+// error stops it. Rows may set the role to user or admin, and default to user.
+//
+// Users are hashed and inserted in batches, so an import that fails partway keeps the batches
+// before it. Running it again is safe: the users already imported are reported as taken.
+func (s *UserService) ImportUsers(ctx context.Context, next func() (*models.User, int, error)) (*models.ImportReport, error) {
+	report := &models.ImportReport{Errors: []models.ImportError{}}
+	// Lines of the emails seen so far, which the store would only reject once inserted
+	seen := make(map[string]int)
+	batch := make([]importRow, 0, importBatchSize)
+	for {
+		user, line, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, apperrors.ErrValidation) {
+			return nil, err
+		}
+		report.Total++
+		if err == nil {
+			err = validateImport(user)
+		}
+		if err == nil {
+			if first, ok := seen[user.Email]; ok {
+				err = apperrors.Conflict(fmt.Sprintf("Email already appears on line %d", first))
+			} else {
+				seen[user.Email] = line
+			}
+		}
+		if err != nil {
+			addImportError(report, line, user, err)
+			continue
+		}
+
+		batch = append(batch, importRow{line: line, user: user})
+		if len(batch) == importBatchSize {
+			if err := s.importBatch(ctx, batch, report); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := s.importBatch(ctx, batch, report); err != nil {
+			return nil, err
+		}
+	}
+	report.Failed = len(report.Errors)
+	return report, nil
+}
+
+func validateImport(user *models.User) error {
+	if err := validation.Struct(user); err != nil {
+		return err
+	}
+	switch user.Role {
+	case "":
+		user.Role = models.RoleUser
+	case models.RoleUser, models.RoleAdmin:
+	default:
+		return ErrInvalidImportRole
+	}
+	return nil
+}
+
+// importBatch hashes the passwords of batch in parallel, inserts its users, and records the
+// outcome of each row in report.
+func (s *UserService) importBatch(ctx context.Context, batch []importRow, report *models.ImportReport) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	users := make([]*models.User, len(batch))
+	hashErrs := make([]error, len(batch))
+	workers := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, row := range batch {
+		users[i] = row.user
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			row.user.Password, hashErrs[i] = s.hashPassword(row.user.Password)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(hashErrs...); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		user.Id = primitive.NewObjectID()
+		user.JoinDate = now
+		user.Version = 1
+	}
+	errs, err := s.repo.CreateMany(ctx, users)
+	if err != nil {
+		return err
+	}
+	for i, err := range errs {
+		if err != nil {
+			addImportError(report, batch[i].line, batch[i].user, err)
+		} else {
+			report.Created++
+		}
+	}
+	return nil
+}
+
+func addImportError(report *models.ImportReport, line int, user *models.User, err error) {
+	importErr := models.ImportError{
+		Line:    line,
+		Message: apperrors.Message(err, "Failed to import user"),
+		Fields:  apperrors.Fields(err),
+	}
+	if user != nil {
+		importErr.Email = user.Email
+	}
+	report.Errors = append(report.Errors, importErr)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSearchLength caps the length of search queries, in bytes.
+const maxSearchLength = 200
+
+var (
+	// ErrSearchQueryRequired is returned when a search query has no words to look for.
+	ErrSearchQueryRequired = apperrors.Validation("The search query must contain a letter or digit")
+	// ErrSearchQueryTooLong is returned when a search query exceeds maxSearchLength.
+	ErrSearchQueryTooLong = apperrors.Validation(fmt.Sprintf("The search query must not exceed %d bytes", maxSearchLength))
+)
+
+// SearchUsers returns up to limit users whose email or names have a word starting with each
+// word of query, most relevant first, with the matching parts highlighted. Erased users are
+// never found. A sort, as described by parseSort, reorders the most relevant hits and keeps
+// relevance order among the hits it ranks equal.
+func (s *UserService) SearchUsers(ctx context.Context, query, sort string, limit int) ([]models.SearchHit, error) {
+	if len(query) > maxSearchLength {
+		return nil, ErrSearchQueryTooLong
+	}
+	terms := repositories.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, ErrSearchQueryRequired
+	}
+	if limit < 1 || limit > MaxPageSize {
+		return nil, ErrInvalidPagination
+	}
+	order, err := parseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.search.SearchUsers(ctx, query, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+	if len(order) > 0 {
+		slices.SortStableFunc(matches, func(a, b repositories.UserMatch) int {
+			return repositories.CompareUsers(&a.User, &b.User, order)
+		})
+	}
+	hits := make([]models.SearchHit, len(matches))
+	for i, match := range matches {
+		hits[i] = models.SearchHit{User: match.User, Score: match.Score, Highlights: []models.Highlight{}}
+		for _, field := range []struct{ name, value string }{
+			{"email", match.User.Email},
+			{"firstName", match.User.FirstName},
+			{"lastName", match.User.LastName},
+		} {
+			if texts, ok := highlight(field.value, terms); ok {
+				hits[i].Highlights = append(hits[i].Highlights, models.Highlight{Field: field.name, Texts: texts})
+			}
+		}
+	}
+	return hits, nil
+}
+
+// highlight splits value into the parts of its words that terms start, and the text around
+// them. It reports whether any term matched.
+func highlight(value string, terms []string) ([]models.HighlightText, bool) {
+	var texts []models.HighlightText
+	add := func(part, kind string) {
+		if part == "" {
+			return
+		}
+		if n := len(texts); n > 0 && texts[n-1].Type == kind {
+			texts[n-1].Value += part
+			return
+		}
+		texts = append(texts, models.HighlightText{Value: part, Type: kind})
+	}
+
+	matched := false
+	for rest := value; rest != ""; {
+		// Split off the text up to the next word, then the word itself
+		start := strings.IndexFunc(rest, isWordRune)
+		if start < 0 {
+			add(rest, models.HighlightTypeText)
+			break
+		}
+		add(rest[:start], models.HighlightTypeText)
+		rest = rest[start:]
+		end := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+		if end < 0 {
+			end = len(rest)
+		}
+		word := rest[:end]
+		rest = rest[end:]
+
+		n := 0
+		for _, term := range terms {
+			n = max(n, prefixLength(word, term))
+		}
+		if n > 0 {
+			matched = true
+		}
+		add(word[:n], models.HighlightTypeHit)
+		add(word[n:], models.HighlightTypeText)
+	}
+	return texts, matched
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// prefixLength returns the length in bytes of the start of word that equals term, ignoring
+// case, or 0 when term does not start word. term is lowercase.
+func prefixLength(word, term string) int {
+	i := 0
+	for _, want := range term {
+		got, size := utf8.DecodeRuneInString(word[i:])
+		if size == 0 || unicode.ToLower(got) != want {
+			return 0
+		}
+		i += size
+	}
+	return i
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxPageSize caps how many users a single list request may return.
+const MaxPageSize = 100
+
+// exportBatchSize is how many users ExportUsers reads from the store at a time.
+const exportBatchSize = 500
+
+var (
+	// ErrInvalidID is returned when a user ID is not a valid ObjectID.
+	ErrInvalidID = apperrors.Validation("Invalid ID")
+	// ErrNoValidFields is returned when an update contains no updatable fields.
+	ErrNoValidFields = apperrors.Validation("No valid fields to update")
+	// ErrInvalidPagination is returned when a page or page size is out of range.
+	ErrInvalidPagination = apperrors.Validation(fmt.Sprintf("Page must be at least 1 and limit between 1 and %d", MaxPageSize))
+	// ErrInvalidBatchSize is returned when a batch get asks for no users or more than a page of them.
+	ErrInvalidBatchSize = apperrors.Validation(fmt.Sprintf("Between 1 and %d IDs must be given", MaxPageSize))
+	// ErrVersionRequired is returned when an update does not say which version it was based on.
+	ErrVersionRequired = apperrors.PreconditionRequired("Send the user's current version in If-Match or the version field")
+)
+
+// updatableFields lists the user fields clients may change.
+var updatableFields = map[string]bool{
+	"email":     true,
+	"firstName": true,
+	"lastName":  true,
+	"password":  true,
+	"phone":     true,
+	"metadata":  true,
+}
+
+type UserService struct {
+	repo          repositories.UserStore
+	avatars       repositories.AvatarStore
+	audits        repositories.AuditStore
+	search        repositories.UserSearchStore
+	memberships   repositories.OrganizationStore
+	roles         repositories.RoleStore
+	groups        repositories.GroupStore
+	notifications repositories.NotificationStore
+	passwords     repositories.PasswordHistoryStore
+	phoneCodes    repositories.PhoneCodeStore
+	notifier      Notifier
+	bcryptCost    int
+	// passwordHistory is how many recent passwords, the current one included, cannot be reused
+	passwordHistory int
+}
+
+func NewUserService(repo repositories.UserStore, avatars repositories.AvatarStore, audits repositories.AuditStore, search repositories.UserSearchStore, memberships repositories.OrganizationStore, roles repositories.RoleStore, groups repositories.GroupStore, notifications repositories.NotificationStore, passwords repositories.PasswordHistoryStore, phoneCodes repositories.PhoneCodeStore, notifier Notifier, bcryptCost int, passwordHistory int) *UserService {
+	return &UserService{
+		repo:          repo,
+		avatars:       avatars,
+		audits:        audits,
+		search:        search,
+		memberships:   memberships,
+		roles:         roles,
+		groups:        groups,
+		notifications: notifications,
+		passwords:     passwords,
+		phoneCodes:    phoneCodes,
+		notifier:      notifier,
+		bcryptCost:    bcryptCost,
+
+		passwordHistory: passwordHistory,
+	}
+}
+
+// CreateUser validates a new user, hashes its password, and stores it with the regular user role.
+func (s *UserService) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	return s.createWithRole(ctx, user, models.RoleUser)
+}
+
+// CreateAdmin creates a user with the admin role. It is only reachable from the admin CLI.
+func (s *UserService) CreateAdmin(ctx context.Context, user *models.User) (*models.User, error) {
+	return s.createWithRole(ctx, user, models.RoleAdmin)
+}
+
+func (s *UserService) createWithRole(ctx context.Context, user *models.User, role string) (*models.User, error) {
+	// Validate the submitted fields
+	for i := range user.Addresses {
+		normalizeAddress(&user.Addresses[i])
+	}
+	if err := validation.Struct(user); err != nil {
+		return nil, err
+	}
+	if err := validateMetadata(user.Metadata); err != nil {
+		return nil, err
+	}
+	for i := range user.Addresses {
+		user.Addresses[i].Id = primitive.NewObjectID()
+	}
+
+	// Hash the password
+	hashedPassword, err := s.hashPassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hashedPassword
+	user.Role = role
+	user.Id = primitive.NewObjectID()
+	user.JoinDate = time.Now()
+	user.Version = 1
+	// Only a confirmed code verifies a number
+	user.PhoneVerifiedAt = nil
+
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByID returns the user with the given hex ID.
+func (s *UserService) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, objectID)
+}
+
+// GetUsersByIDs returns, in one read, the users with the given hex IDs that exist, in no
+// particular order. IDs that are given twice are looked up once.
+func (s *UserService) GetUsersByIDs(ctx context.Context, ids []string, fields []string) ([]models.User, error) {
+	if len(ids) == 0 || len(ids) > MaxPageSize {
+		return nil, ErrInvalidBatchSize
+	}
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := parseID(id)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(objectIDs, objectID) {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	users, _, err := s.repo.List(ctx, repositories.ListOptions{Limit: int64(len(objectIDs)), IDs: objectIDs, Fields: fields, SkipTotal: true})
+	return users, err
+}
+
+// UpdateUser applies the updatable subset of updates to the user with the given hex ID, provided
+// the user is still at version, and returns the updated user.
+func (s *UserService) UpdateUser(ctx context.Context, id string, version int64, updates map[string]interface{}) (*models.User, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 {
+		return nil, ErrVersionRequired
+	}
+
+	if err := validation.Fields(models.User{}, updates); err != nil {
+		return nil, err
+	}
+
+	filteredUpdates := map[string]interface{}{}
+	for key, value := range updates {
+		if updatableFields[key] {
+			filteredUpdates[key] = value
+		}
+	}
+
+	if len(filteredUpdates) == 0 {
+		return nil, ErrNoValidFields
+	}
+	user, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+	if password, ok := filteredUpdates["password"].(string); ok {
+		hashedPassword, err := s.changePassword(ctx, user, password)
+		if err != nil {
+			return nil, err
+		}
+		filteredUpdates["password"] = hashedPassword
+	}
+	// A new number has not been verified, whatever the old one was
+	if phone, ok := filteredUpdates["phone"]; ok && phone != user.Phone && user.PhoneVerifiedAt != nil {
+		filteredUpdates["phoneVerifiedAt"] = nil
+	}
+	// Metadata is merged into what the user has rather than replacing it
+	if patch, ok := filteredUpdates["metadata"]; ok {
+		metadata, err := mergeMetadata(user.Metadata, patch)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateMetadata(metadata); err != nil {
+			return nil, err
+		}
+		// Stores remove a field set to an untyped nil
+		if metadata == nil {
+			filteredUpdates["metadata"] = nil
+		} else {
+			filteredUpdates["metadata"] = metadata
+		}
+	}
+
+	if err := s.repo.Update(ctx, objectID, version, filteredUpdates); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, objectID)
+}
+
+// ListUsers returns the requested page of users matching filter and the total number of matches.
+// The filter expression is parsed as described by parseFilter. sort orders them as described by
+// parseSort, and by ID when it is empty. fields, when set, names the only fields the caller
+// needs, which the store may limit its reads to.
+func (s *UserService) ListUsers(ctx context.Context, filter repositories.UserFilter, sort string, fields []string, page, limit int) ([]models.User, int64, error) {
+	where, err := parseFilter(filter.Expression)
+	if err != nil {
+		return nil, 0, err
+	}
+	order, err := parseSort(sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	return s.repo.List(ctx, repositories.ListOptions{
+		Skip:   int64(page-1) * int64(limit),
+		Limit:  int64(limit),
+		Filter: filter,
+		Where:  where,
+		Sort:   order,
+		Fields: fields,
+	})
+}
+
+// ExportUsers calls visit with every user matching filter, including its expression, in ID order. Users are read in
+// batches, so exports of any size use little memory. It stops at the first error visit returns.
+func (s *UserService) ExportUsers(ctx context.Context, filter repositories.UserFilter, visit func(*models.User) error) error {
+	where, err := parseFilter(filter.Expression)
+	if err != nil {
+		return err
+	}
+	opts := repositories.ListOptions{Limit: exportBatchSize, Filter: filter, Where: where}
+	for {
+		users, _, err := s.repo.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for i := range users {
+			if err := visit(&users[i]); err != nil {
+				return err
+			}
+		}
+		if len(users) < exportBatchSize {
+			return nil
+		}
+		opts.AfterID = users[len(users)-1].Id
+	}
+}
+
+// ResetPassword replaces the password of the user with the given hex ID.
+func (s *UserService) ResetPassword(ctx context.Context, id string, password string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	if err := validation.Fields(models.User{}, map[string]interface{}{"password": password}); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return err
+	}
+	if user.ErasedAt != nil {
+		return ErrUserErased
+	}
+	hashedPassword, err := s.changePassword(ctx, user, password)
+	if err != nil {
+		return err
+	}
+	// Administrative resets override whatever version the user is at
+	return s.repo.Update(ctx, objectID, repositories.AnyVersion, map[string]interface{}{"password": hashedPassword})
+}
+
+// AssignRole gives the user with the given hex ID the built-in or stored role assignment names,
+// and returns the updated user. The user is notified when their role changes.
+func (s *UserService) AssignRole(ctx context.Context, id string, assignment *models.RoleAssignment) (*models.User, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(assignment); err != nil {
+		return nil, err
+	}
+	if _, err := lookupRole(ctx, s.roles, assignment.Role); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+	if err := s.repo.Update(ctx, objectID, repositories.AnyVersion, map[string]interface{}{"role": assignment.Role}); err != nil {
+		return nil, err
+	}
+	if user.Role != assignment.Role {
+		s.notifier.Notify(ctx, objectID, models.NotificationRoleAssigned,
+			fmt.Sprintf("You have been given the %s role", assignment.Role), map[string]string{"role": assignment.Role})
+	}
+	return s.repo.GetByID(ctx, objectID)
+}
+
+// DeleteUser removes the user with the given hex ID, their avatar, their memberships, their
+// notifications, and their password history, and takes them out of their groups.
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, objectID); err != nil {
+		return err
+	}
+	// Deleting the user again is harmless, so a failure here can be retried with the whole request
+	if err := s.avatars.Delete(ctx, objectID); err != nil {
+		return err
+	}
+	if err := s.memberships.DeleteUserMemberships(ctx, objectID); err != nil {
+		return err
+	}
+	if err := s.groups.DeleteUserMembers(ctx, objectID); err != nil {
+		return err
+	}
+	if err := s.notifications.DeleteUserNotifications(ctx, objectID); err != nil {
+		return err
+	}
+	return s.passwords.Delete(ctx, objectID)
+}
+
+// changePassword hashes password to replace the current password of user with, after checking
+// it against the password history, and records the current password there. A change that then
+// fails leaves the current password in the history, which is harmless since it was the user's.
+func (s *UserService) changePassword(ctx context.Context, user *models.User, password string) (string, error) {
+	if s.passwordHistory > 0 {
+		hashes := []string{user.Password}
+		if s.passwordHistory > 1 {
+			previous, err := s.passwords.List(ctx, user.Id)
+			if err != nil {
+				return "", err
+			}
+			// The history may be longer than the policy if it was lowered since
+			if len(previous) > s.passwordHistory-1 {
+				previous = previous[:s.passwordHistory-1]
+			}
+			hashes = append(hashes, previous...)
+		}
+		for _, hash := range hashes {
+			if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+				return "", passwordReusedError(s.passwordHistory)
+			}
+		}
+	}
+
+	hashedPassword, err := s.hashPassword(password)
+	if err != nil {
+		return "", err
+	}
+	if s.passwordHistory > 1 && user.Password != "" {
+		if err := s.passwords.Add(ctx, user.Id, user.Password, s.passwordHistory-1); err != nil {
+			return "", err
+		}
+	}
+	return hashedPassword, nil
+}
+
+// passwordReusedError is returned for a new password that matches one of the last history
+// passwords of the user.
+func passwordReusedError(history int) error {
+	message := "password must differ from the current password"
+	if history > 1 {
+		message = fmt.Sprintf("password must differ from the last %d passwords", history)
+	}
+	return apperrors.InvalidFields("Invalid input", []apperrors.FieldError{{Field: "password", Rule: "history", Message: message}})
+}
+
+func (s *UserService) hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidID
+	}
+	return objectID, nil
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example_api/apperrors"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/repositories/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recordingNotifier remembers the kinds of the notifications it was asked to send.
+type recordingNotifier struct {
+	kinds []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, userID primitive.ObjectID, kind, message string, data map[string]string) {
+	n.kinds = append(n.kinds, kind)
+}
+
+// memoryStores are the stores behind a UserService built by newTestUserService.
+type memoryStores struct {
+	users         *repositories.MemoryUserRepository
+	organizations *repositories.MemoryOrganizationRepository
+	groups        *repositories.MemoryGroupRepository
+	notifications *repositories.MemoryNotificationRepository
+	phoneCodes    *repositories.MemoryPhoneCodeRepository
+}
+
+func newTestUserService() (*UserService, memoryStores) {
+	stores := memoryStores{
+		users:         repositories.NewMemoryUserRepository(),
+		organizations: repositories.NewMemoryOrganizationRepository(),
+		groups:        repositories.NewMemoryGroupRepository(),
+		notifications: repositories.NewMemoryNotificationRepository(),
+		phoneCodes:    repositories.NewMemoryPhoneCodeRepository(),
+	}
+	service := NewUserService(stores.users, repositories.NewMemoryAvatarRepository(), repositories.NewMemoryAuditRepository(), stores.users,
+		stores.organizations, repositories.NewMemoryRoleRepository(), stores.groups, stores.notifications,
+		repositories.NewMemoryPasswordHistoryRepository(), stores.phoneCodes, &recordingNotifier{}, bcrypt.MinCost, 5)
+	return service, stores
+}
+
+func newUser(email string) *models.User {
+	return &models.User{Email: email, Password: "correct horse", FirstName: "Ada", LastName: "Lovelace"}
+}
+
+func TestCreateUser(t *testing.T) {
+	service, _ := newTestUserService()
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, newUser("taken@example.com")); err != nil {
+		t.Fatalf("failed to create the first user: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		user *models.User
+		err  error
+	}{
+		{"valid", newUser("ada@example.com"), nil},
+		{"invalid email", newUser("not-an-email"), apperrors.ErrValidation},
+		{"short password", &models.User{Email: "bob@example.com", Password: "short", FirstName: "Bob", LastName: "Smith"}, apperrors.ErrValidation},
+		{"taken email", newUser("taken@example.com"), repositories.ErrEmailTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := service.CreateUser(ctx, tt.user)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created.Role != models.RoleUser || created.Version != 1 || created.Id.IsZero() {
+				t.Fatalf("got role %q, version %d, and ID %s", created.Role, created.Version, created.Id.Hex())
+			}
+			if err := bcrypt.CompareHashAndPassword([]byte(created.Password), []byte("correct horse")); err != nil {
+				t.Fatalf("password is not stored as its bcrypt hash: %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	service, _ := newTestUserService()
+	ctx := context.Background()
+	created, err := service.CreateUser(ctx, newUser("ada@example.com"))
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	id := created.Id.Hex()
+
+	tests := []struct {
+		name    string
+		id      string
+		version int64
+		updates map[string]interface{}
+		err     error
+	}{
+		{"invalid ID", "nope", 1, map[string]interface{}{"firstName": "Augusta"}, ErrInvalidID},
+		{"missing version", id, 0, map[string]interface{}{"firstName": "Augusta"}, ErrVersionRequired},
+		{"only fields that cannot be changed", id, 1, map[string]interface{}{"role": models.RoleAdmin}, ErrNoValidFields},
+		{"current password", id, 1, map[string]interface{}{"password": "correct horse"}, apperrors.ErrValidation},
+		{"valid", id, 1, map[string]interface{}{"firstName": "Augusta"}, nil},
+		{"stale version", id, 1, map[string]interface{}{"firstName": "Ada"}, repositories.ErrVersionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := service.UpdateUser(ctx, tt.id, tt.version, tt.updates)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated.FirstName != "Augusta" || updated.Version != 2 {
+				t.Fatalf("got first name %q at version %d", updated.FirstName, updated.Version)
+			}
+		})
+	}
+}
+
+func TestDeleteUserRemovesRelatedData(t *testing.T) {
+	service, stores := newTestUserService()
+	ctx := context.Background()
+	created, err := service.CreateUser(ctx, newUser("ada@example.com"))
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	seedRelatedData(t, stores, created.Id)
+
+	if err := service.DeleteUser(ctx, created.Id.Hex()); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if _, err := service.GetUserByID(ctx, created.Id.Hex()); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("got error %v reading the deleted user, want not found", err)
+	}
+	if _, total, _ := stores.notifications.List(ctx, created.Id, false, 0, 10); total != 0 {
+		t.Fatalf("%d notifications left", total)
+	}
+	if _, total, _ := stores.organizations.ListUserMemberships(ctx, created.Id, 0, 10); total != 0 {
+		t.Fatalf("%d memberships left", total)
+	}
+	if _, total, _ := stores.groups.ListUserGroups(ctx, created.Id, 0, 0); total != 0 {
+		t.Fatalf("still in %d groups", total)
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	service, stores := newTestUserService()
+	ctx := context.Background()
+	created, err := service.CreateUser(ctx, newUser("ada@example.com"))
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	seedRelatedData(t, stores, created.Id)
+
+	export, err := service.ExportUserData(ctx, created.Id.Hex())
+	if err != nil {
+		t.Fatalf("failed to export user data: %v", err)
+	}
+	if export.User.Password != "" {
+		t.Fatal("export holds the password hash")
+	}
+	if len(export.Notifications) != 1 || len(export.Memberships) != 1 || len(export.Groups) != 1 {
+		t.Fatalf("got %d notifications, %d memberships, and %d groups, want one of each", len(export.Notifications), len(export.Memberships), len(export.Groups))
+	}
+}
+
+func TestEraseUserRemovesRelatedData(t *testing.T) {
+	service, stores := newTestUserService()
+	ctx := context.Background()
+	created, err := service.CreateUser(ctx, newUser("ada@example.com"))
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	seedRelatedData(t, stores, created.Id)
+	code := &repositories.PhoneCode{UserID: created.Id, Phone: "+15555550100", CodeHash: "hash", SentAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := stores.phoneCodes.Put(ctx, code); err != nil {
+		t.Fatalf("failed to store phone code: %v", err)
+	}
+
+	// Erasing again repeats the deletions, so check both runs
+	for run := 1; run <= 2; run++ {
+		erased, err := service.EraseUser(ctx, created.Id.Hex())
+		if err != nil {
+			t.Fatalf("run %d: failed to erase user: %v", run, err)
+		}
+		if erased.ErasedAt == nil || erased.Email == "ada@example.com" || erased.FirstName != "" {
+			t.Fatalf("run %d: got %+v, want the user anonymized", run, erased)
+		}
+		if _, total, _ := stores.notifications.List(ctx, created.Id, false, 0, 10); total != 0 {
+			t.Fatalf("run %d: %d notifications left", run, total)
+		}
+		if _, total, _ := stores.organizations.ListUserMemberships(ctx, created.Id, 0, 10); total != 0 {
+			t.Fatalf("run %d: %d memberships left", run, total)
+		}
+		if _, total, _ := stores.groups.ListUserGroups(ctx, created.Id, 0, 0); total != 0 {
+			t.Fatalf("run %d: still in %d groups", run, total)
+		}
+		if _, err := stores.phoneCodes.Get(ctx, created.Id); !errors.Is(err, repositories.ErrPhoneCodeNotFound) {
+			t.Fatalf("run %d: got error %v reading the phone code, want it deleted", run, err)
+		}
+	}
+}
+
+// seedRelatedData gives the user a notification, an organization membership, and a group.
+func seedRelatedData(t *testing.T, stores memoryStores, userID primitive.ObjectID) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	if err := stores.notifications.Create(ctx, &models.Notification{Id: primitive.NewObjectID(), UserID: userID, Type: "notice", Message: "Hello", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to create notification: %v", err)
+	}
+	org := &models.Organization{Id: primitive.NewObjectID(), Name: "Analytical Engines", CreatedAt: now}
+	if err := stores.organizations.Create(ctx, org); err != nil {
+		t.Fatalf("failed to create organization: %v", err)
+	}
+	membership := &models.Membership{Id: primitive.NewObjectID(), OrganizationID: org.Id, UserID: userID, Role: "member", Status: "active", InvitedAt: now}
+	if err := stores.organizations.AddMembership(ctx, membership); err != nil {
+		t.Fatalf("failed to add membership: %v", err)
+	}
+	group := &models.Group{Id: primitive.NewObjectID(), Name: "Mathematicians", Permissions: []string{}, CreatedAt: now}
+	if err := stores.groups.Create(ctx, group); err != nil {
+		t.Fatalf("failed to create group: %v", err)
+	}
+	if _, err := stores.groups.AddMembers(ctx, []models.GroupMember{{GroupID: group.Id, UserID: userID, AddedAt: now}}); err != nil {
+		t.Fatalf("failed to add group member: %v", err)
+	}
+}
+
+func TestGetUserByIDWithMockStore(t *testing.T) {
+	id := primitive.NewObjectID()
+	storeErr := errors.New("connection reset")
+
+	tests := []struct {
+		name  string
+		id    string
+		setup func(store *mocks.UserStore)
+		err   error
+	}{
+		{"found", id.Hex(), func(store *mocks.UserStore) {
+			store.On("GetByID", mock.Anything, id).Return(&models.User{Id: id, Email: "ada@example.com"}, nil).Once()
+		}, nil},
+		{"invalid ID is not looked up", "nope", func(store *mocks.UserStore) {}, ErrInvalidID},
+		{"store failure", id.Hex(), func(store *mocks.UserStore) {
+			store.On("GetByID", mock.Anything, id).Return(nil, storeErr).Once()
+		}, storeErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := mocks.NewUserStore(t)
+			tt.setup(store)
+			service := NewUserService(store, nil, nil, nil, nil, nil, nil, nil, nil, nil, &recordingNotifier{}, bcrypt.MinCost, 0)
+
+			user, err := service.GetUserByID(context.Background(), tt.id)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && user.Id != id {
+				t.Fatalf("got user %s, want %s", user.Id.Hex(), id.Hex())
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/validation"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookService struct {
+	repo repositories.WebhookStore
+}
+
+func NewWebhookService(repo repositories.WebhookStore) *WebhookService {
+	return &WebhookService{
+		repo: repo,
+	}
+}
+
+// CreateWebhook validates and stores a new webhook, generating a signing secret when none is given.
+// The returned webhook is the only one that includes the secret.
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	if err := prepareWebhook(webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+	webhook.Id = primitive.NewObjectID()
+	webhook.CreatedAt = time.Now()
+
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// GetWebhook returns the webhook with the given hex ID, without its secret.
+func (s *WebhookService) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// UpdateWebhook replaces the URL and events of the webhook with the given hex ID. The secret is
+// only replaced when update carries one, in which case the result includes it.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id string, update *models.Webhook) (*models.Webhook, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareWebhook(update); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	webhook.URL = update.URL
+	webhook.Events = update.Events
+	rotated := update.Secret != ""
+	if rotated {
+		webhook.Secret = update.Secret
+	}
+
+	if err := s.repo.Replace(ctx, webhook); err != nil {
+		return nil, err
+	}
+	if !rotated {
+		webhook.Secret = ""
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes the webhook with the given hex ID and its delivery log.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, objectID)
+}
+
+// ListWebhooks returns the requested page of webhooks, without their secrets, and the total count.
+func (s *WebhookService) ListWebhooks(ctx context.Context, page, limit int) ([]models.Webhook, int64, error) {
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	webhooks, total, err := s.repo.List(ctx, int64(page-1)*int64(limit), int64(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, total, nil
+}
+
+// ListDeliveries returns the requested page of delivery attempts of the webhook with the given
+// hex ID, newest first, and the total count.
+func (s *WebhookService) ListDeliveries(ctx context.Context, id string, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || limit < 1 || limit > MaxPageSize {
+		return nil, 0, ErrInvalidPagination
+	}
+	if _, err := s.repo.GetByID(ctx, objectID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListDeliveries(ctx, objectID, int64(page-1)*int64(limit), int64(limit))
+}
+
+// prepareWebhook validates the client-supplied fields and removes duplicate event types.
+func prepareWebhook(webhook *models.Webhook) error {
+	if err := validation.Struct(webhook); err != nil {
+		return err
+	}
+	slices.Sort(webhook.Events)
+	webhook.Events = slices.Compact(webhook.Events)
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,36 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// ErrUndeliverable is wrapped by the errors of messages the provider refused because of their
+// recipient, such as a number that does not exist or cannot receive text messages.
+var ErrUndeliverable = errors.New("recipient cannot receive text messages")
+
+// Sender sends text messages to E.164 phone numbers.
+type Sender interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// LogSender writes messages to the log instead of sending them, for development without an SMS
+// provider. Anyone who can read the log can read the messages.
+type LogSender struct {
+	logger *slog.Logger
+}
+
+func NewLogSender(logger *slog.Logger) *LogSender {
+	return &LogSender{
+		logger: logger,
+	}
+}
+
+var _ Sender = (*LogSender)(nil)
+
+// Send logs the message.
+func (s *LogSender) Send(ctx context.Context, to, body string) error {
+	s.logger.InfoContext(ctx, "Text message not sent; SMS_PROVIDER is log", slog.String("to", to), slog.String("body", body))
+	return nil
+}
@@ -0,0 +1,89 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// twilioAPI is the base URL of the Twilio REST API.
+const twilioAPI = "https://api.twilio.com/2010-04-01"
+
+// twilioUndeliverable lists the Twilio error codes that blame the recipient: an invalid number,
+// one that is not mobile, one that replied STOP, and one in a region the account cannot reach.
+var twilioUndeliverable = []int{21211, 21408, 21610, 21612, 21614}
+
+// TwilioOptions configures a TwilioSender.
+type TwilioOptions struct {
+	AccountSID string
+	AuthToken  string
+	// From is the sending phone number, or the SID of a messaging service, which starts with MG
+	From string
+	// Timeout bounds each request to Twilio
+	Timeout time.Duration
+}
+
+// TwilioSender sends text messages through the Twilio Programmable Messaging API.
+type TwilioSender struct {
+	opts     TwilioOptions
+	endpoint string
+	client   *http.Client
+}
+
+func NewTwilioSender(opts TwilioOptions) *TwilioSender {
+	return &TwilioSender{
+		opts:     opts,
+		endpoint: twilioAPI + "/Accounts/" + url.PathEscape(opts.AccountSID) + "/Messages.json",
+		client:   &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+var _ Sender = (*TwilioSender)(nil)
+
+// twilioError is the body of a Twilio API error response.
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Send asks Twilio to send body to the number to. Twilio queues the message and returns; its
+// delivery is not tracked.
+func (s *TwilioSender) Send(ctx context.Context, to, body string) error {
+	form := url.Values{"To": {to}, "Body": {body}}
+	if strings.HasPrefix(s.opts.From, "MG") {
+		form.Set("MessagingServiceSid", s.opts.From)
+	} else {
+		form.Set("From", s.opts.From)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.opts.AccountSID, s.opts.AuthToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Twilio: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var apiErr twilioError
+	if json.Unmarshal(data, &apiErr) != nil || apiErr.Code == 0 {
+		return fmt.Errorf("twilio responded %d", resp.StatusCode)
+	}
+	if slices.Contains(twilioUndeliverable, apiErr.Code) {
+		return fmt.Errorf("twilio error %d: %s: %w", apiErr.Code, apiErr.Message, ErrUndeliverable)
+	}
+	return fmt.Errorf("twilio error %d: %s", apiErr.Code, apiErr.Message)
+}
@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+)
+
+// Default is the tenant of requests that do not name one, and of all data stored before
+// tenancy existed.
+const Default = "default"
+
+// validID matches tenant IDs: DNS labels, so every ID can also be used as a subdomain.
+var validID = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+var (
+	// ErrInvalid is returned by Lookup for malformed tenant IDs
+	ErrInvalid = errors.New("tenant IDs are lowercase letters, digits, and hyphens")
+	// ErrUnknown is returned by Lookup for tenants that do not exist
+	ErrUnknown = errors.New("unknown tenant")
+)
+
+// Valid reports whether id is a well-formed tenant ID.
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+// Lookup returns the tenant a request naming id belongs to: Default when id is empty, and
+// otherwise id itself if it is Default or one of tenants.
+func Lookup(id string, tenants []string) (string, error) {
+	switch {
+	case id == "" || id == Default:
+		return Default, nil
+	case !Valid(id):
+		return "", ErrInvalid
+	case !slices.Contains(tenants, id):
+		return "", ErrUnknown
+	}
+	return id, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx scoped to the tenant with the given ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID of the tenant ctx is scoped to, or Default when there is none.
+func FromContext(ctx context.Context) string {
+	id, ok := ctx.Value(contextKey{}).(string)
+	if !ok {
+		return Default
+	}
+	return id
+}
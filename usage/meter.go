@@ -0,0 +1,112 @@
+// Package usage meters the API: it counts the requests each client makes and the bytes they
+// send and receive, and adds the counts up per client and day in a store, for billing and for
+// looking into abuse.
+package usage
+
+import (
+	"context"
+	models "example_api/models"
+	"example_api/repositories"
+	"example_api/tenant"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Meter counts requests in memory and adds the counts to its store now and then, so requests
+// do not wait on the store.
+type Meter struct {
+	store    repositories.UsageStore
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	pending map[key]*models.Usage
+}
+
+// key identifies the counts of a client on a day in a tenant.
+type key struct {
+	tenant string
+	client string
+	day    string
+}
+
+// NewMeter returns a meter that adds its counts to store every interval once Run is called.
+func NewMeter(store repositories.UsageStore, interval time.Duration, logger *slog.Logger) *Meter {
+	return &Meter{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+		pending:  map[key]*models.Usage{},
+	}
+}
+
+// Record counts a request of client in the tenant of ctx, which sent bytesIn bytes and received
+// bytesOut, on the current UTC day.
+func (m *Meter) Record(ctx context.Context, client string, bytesIn, bytesOut int64) {
+	day := time.Now().UTC().Format(time.DateOnly)
+	k := key{tenant: tenant.FromContext(ctx), client: client, day: day}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.pending[k]
+	if !ok {
+		record = &models.Usage{Client: client, Day: day}
+		m.pending[k] = record
+	}
+	record.Requests++
+	record.BytesIn += bytesIn
+	record.BytesOut += bytesOut
+}
+
+// Run flushes the counts every interval until ctx is cancelled. Counts recorded after the last
+// flush are left for a final call to Flush once requests have drained.
+func (m *Meter) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Flush(ctx)
+		}
+	}
+}
+
+// Flush adds the counts recorded since the last flush to the store, tenant by tenant. The
+// counts of a tenant the store fails to take are kept for the next flush.
+func (m *Meter) Flush(ctx context.Context) {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = map[key]*models.Usage{}
+	m.mu.Unlock()
+
+	byTenant := map[string][]models.Usage{}
+	for k, record := range pending {
+		byTenant[k.tenant] = append(byTenant[k.tenant], *record)
+	}
+	for id, records := range byTenant {
+		if err := m.store.Add(tenant.NewContext(ctx, id), records); err != nil {
+			m.logger.Error("Failed to record API usage", slog.String("tenant", id), slog.Int("records", len(records)), slog.Any("error", err))
+			m.restore(id, records)
+		}
+	}
+}
+
+// restore adds records of the tenant id back to the pending counts.
+func (m *Meter) restore(id string, records []models.Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, record := range records {
+		k := key{tenant: id, client: record.Client, day: record.Day}
+		pending, ok := m.pending[k]
+		if !ok {
+			pending = &models.Usage{Client: record.Client, Day: record.Day}
+			m.pending[k] = pending
+		}
+		pending.Requests += record.Requests
+		pending.BytesIn += record.BytesIn
+		pending.BytesOut += record.BytesOut
+	}
+}
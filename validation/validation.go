@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"errors"
+	"example_api/apperrors"
+	"example_api/i18n"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// locale accepts the languages messages can be translated to
+	v.RegisterValidation("locale", func(fl validator.FieldLevel) bool {
+		return slices.Contains(i18n.Locales, fl.Field().String())
+	})
+	// Report fields by their JSON names, which is what clients send
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// Struct validates s against its `validate` struct tags.
+func Struct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return apperrors.Internal("Validation failed", err)
+	}
+
+	fields := make([]apperrors.FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		fields = append(fields, fieldError(fe.Field(), fe.Tag(), fe.Param(), fe.Kind()))
+	}
+	return newError(fields)
+}
+
+// Fields validates a partial update against the `validate` tags of the matching fields of
+// the struct s, keyed by JSON name. Keys that do not correspond to a tagged field are skipped.
+func Fields(s interface{}, fields map[string]interface{}) error {
+	tags := tagsByJSONName(reflect.TypeOf(s))
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var invalid []apperrors.FieldError
+	for _, name := range names {
+		tag, ok := tags[name]
+		if !ok {
+			continue
+		}
+		value := fields[name]
+		if _, isString := value.(string); !isString {
+			invalid = append(invalid, apperrors.FieldError{Field: name, Rule: "type", Message: fmt.Sprintf("%s must be a string", name)})
+			continue
+		}
+
+		err := validate.Var(value, tag)
+		var fieldErrs validator.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			for _, fe := range fieldErrs {
+				invalid = append(invalid, fieldError(name, fe.Tag(), fe.Param(), fe.Kind()))
+			}
+		} else if err != nil {
+			return apperrors.Internal("Validation failed", err)
+		}
+	}
+
+	if len(invalid) == 0 {
+		return nil
+	}
+	return newError(invalid)
+}
+
+func newError(fields []apperrors.FieldError) error {
+	return apperrors.InvalidFields("Invalid input", fields)
+}
+
+func tagsByJSONName(t reflect.Type) map[string]string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	tags := make(map[string]string, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("validate")
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag != "" && name != "" && name != "-" {
+			tags[name] = tag
+		}
+	}
+	return tags
+}
+
+func fieldError(field, tag, param string, kind reflect.Kind) apperrors.FieldError {
+	return apperrors.FieldError{Field: field, Rule: tag, Message: describe(field, tag, param, kind)}
+}
+
+// describe turns a failed validation rule into a human-readable sentence.
+func describe(field, tag, param string, kind reflect.Kind) string {
+	switch tag {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "iso3166_1_alpha2":
+		return fmt.Sprintf("%s must be an ISO 3166-1 alpha-2 country code, such as TR", field)
+	case "e164":
+		return fmt.Sprintf("%s must be a phone number in E.164 format, such as +14155552671", field)
+	case "locale":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(i18n.Locales, ", "))
+	case "http_url":
+		return fmt.Sprintf("%s must be an http or https URL", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(strings.Fields(param), ", "))
+	case "min":
+		if kind == reflect.String {
+			return fmt.Sprintf("%s must be at least %s characters long", field, param)
+		}
+		if kind == reflect.Slice {
+			return fmt.Sprintf("%s must have at least %s entries", field, param)
+		}
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max":
+		if kind == reflect.String {
+			return fmt.Sprintf("%s must be at most %s characters long", field, param)
+		}
+		if kind == reflect.Slice {
+			return fmt.Sprintf("%s must have at most %s entries", field, param)
+		}
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "len":
+		if kind == reflect.String {
+			return fmt.Sprintf("%s must be exactly %s characters long", field, param)
+		}
+		return fmt.Sprintf("%s must have exactly %s entries", field, param)
+	case "number":
+		return fmt.Sprintf("%s must hold only digits", field)
+	default:
+		return fmt.Sprintf("%s failed the %s rule", field, tag)
+	}
+}
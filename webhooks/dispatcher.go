@@ -0,0 +1,219 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"example_api/events"
+	"example_api/jobs"
+	models "example_api/models"
+	"example_api/repositories"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// eventBuffer is how many events may wait to be queued before the dispatcher falls behind and
+// catches up from the broker's history instead.
+const eventBuffer = 256
+
+// JobDeliver is the job type of one event's delivery to one webhook.
+const JobDeliver = "webhook.deliver"
+
+// Options tunes delivery.
+type Options struct {
+	// Timeout bounds each delivery attempt
+	Timeout time.Duration
+	Retry   repositories.RetryPolicy
+}
+
+// Notifier tells the administrators about deliveries that failed for good.
+type Notifier interface {
+	NotifyAdmins(ctx context.Context, kind, message string, data map[string]string)
+}
+
+// Dispatcher delivers broker events to the webhooks subscribed to them, signing each payload
+// with the webhook's secret and recording every attempt in the delivery log. Each delivery is
+// a job, so deliveries and their retries survive restarts.
+type Dispatcher struct {
+	store    repositories.WebhookStore
+	broker   *events.Broker
+	queue    *jobs.Queue
+	notifier Notifier
+	client   *http.Client
+	logger   *slog.Logger
+}
+
+// NewDispatcher returns a dispatcher and registers its deliveries with queue, which retries them
+// as opts.Retry says.
+func NewDispatcher(store repositories.WebhookStore, broker *events.Broker, queue *jobs.Queue, notifier Notifier, opts Options, logger *slog.Logger) *Dispatcher {
+	d := &Dispatcher{
+		store:    store,
+		broker:   broker,
+		queue:    queue,
+		notifier: notifier,
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			// A redirect would resend the signed payload somewhere the subscriber did not register
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}
+	queue.Register(JobDeliver, d.deliver, opts.Retry)
+	return d
+}
+
+// delivery is the payload of a delivery job. Body holds the event JSON exactly as sent, so
+// every attempt is signed over the same bytes.
+type delivery struct {
+	ID        primitive.ObjectID `json:"id"`
+	WebhookID primitive.ObjectID `json:"webhookId"`
+	EventID   uint64             `json:"eventId"`
+	EventType string             `json:"eventType"`
+	Body      string             `json:"body"`
+}
+
+// Run queues a delivery for every event until the broker closes.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.broker.Consume(ctx, eventBuffer, func(event events.Event) {
+		d.dispatch(ctx, event)
+	})
+}
+
+// dispatch queues a delivery of event to every webhook subscribed to its type.
+func (d *Dispatcher) dispatch(ctx context.Context, event events.Event) {
+	webhooks, err := d.store.ListByEvent(ctx, event.Type)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "Failed to find webhooks for event", slog.String("type", event.Type), slog.Any("error", err))
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "Failed to encode webhook payload", slog.String("type", event.Type), slog.Any("error", err))
+		return
+	}
+	for _, webhook := range webhooks {
+		payload := delivery{ID: primitive.NewObjectID(), WebhookID: webhook.Id, EventID: event.ID, EventType: event.Type, Body: string(body)}
+		if _, err := d.queue.Enqueue(ctx, JobDeliver, payload); err != nil {
+			d.logger.ErrorContext(ctx, "Failed to queue webhook delivery", slog.String("webhook", webhook.Id.Hex()), slog.String("type", event.Type), slog.Any("error", err))
+		}
+	}
+}
+
+// deliver makes one attempt of a delivery job and records it. Once the receiver rejects it
+// permanently or the attempts run out, the administrators are notified; attempts cut short by
+// shutdown are retried instead. Deliveries to webhooks deleted in the meantime are dropped.
+func (d *Dispatcher) deliver(ctx context.Context, job *models.Job) error {
+	var payload delivery
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	webhook, err := d.store.GetByID(ctx, payload.WebhookID)
+	if errors.Is(err, repositories.ErrWebhookNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	status, err := d.send(ctx, webhook, payload)
+	record := &models.WebhookDelivery{
+		Id:         primitive.NewObjectID(),
+		DeliveryID: payload.ID,
+		WebhookID:  webhook.Id,
+		EventID:    payload.EventID,
+		EventType:  payload.EventType,
+		Attempt:    job.Attempts,
+		StatusCode: status,
+		Succeeded:  err == nil,
+		DurationMs: time.Since(started).Milliseconds(),
+		CreatedAt:  started,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	// The delivery log must not hold up delivery, so record it even once ctx is done
+	if recordErr := d.store.RecordDelivery(context.WithoutCancel(ctx), record); recordErr != nil {
+		d.logger.ErrorContext(ctx, "Failed to record webhook delivery", slog.String("webhook", webhook.Id.Hex()), slog.Any("error", recordErr))
+	}
+	if err == nil {
+		return nil
+	}
+
+	if ctx.Err() == nil && (jobs.IsPermanent(err) || job.Attempts >= job.MaxAttempts) {
+		attempts := "attempts"
+		if job.Attempts == 1 {
+			attempts = "attempt"
+		}
+		d.notifier.NotifyAdmins(ctx, models.NotificationWebhookFailed,
+			fmt.Sprintf("Delivering %s to %s failed after %d %s", payload.EventType, webhook.URL, job.Attempts, attempts),
+			map[string]string{"webhookId": webhook.Id.Hex(), "deliveryId": payload.ID.Hex(), "event": payload.EventType})
+	}
+	return err
+}
+
+// send makes one delivery attempt and returns the response status, if there was one.
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, payload delivery) (int, error) {
+	body := []byte(payload.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, jobs.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "example-api-webhooks/1")
+	req.Header.Set(HeaderID, payload.ID.Hex())
+	req.Header.Set(HeaderEvent, payload.EventType)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout:
+		return resp.StatusCode, fmt.Errorf("receiver responded %d", resp.StatusCode)
+	default:
+		return resp.StatusCode, jobs.Permanent(fmt.Errorf("receiver responded %d", resp.StatusCode))
+	}
+}
+
+// Sign returns the Webhook-Signature header value for body sent at t: the Unix timestamp and
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret, as "t=<timestamp>,v1=<hmac>".
+// Receivers recompute the HMAC and reject stale timestamps to prevent replays.
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}